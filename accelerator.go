@@ -0,0 +1,124 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// ParseAccelerator interprets a label that marks a keyboard accelerator with an
+// ampersand, e.g. "&File" or "Save &As". It returns the label without the markup, the
+// index in runes of the marked character in that label, and the accelerator key, in
+// lower case. If no character is marked, the index is -1 and the key is 0. A doubled
+// ampersand stands for a literal one.
+func ParseAccelerator(label string) (string, int, rune) {
+	var sb strings.Builder
+	pos, key := -1, rune(0)
+	n := 0
+	runes := []rune(label)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '&' && i+1 < len(runes) {
+			i++
+			r = runes[i]
+			if r != '&' && key == 0 {
+				pos, key = n, unicode.ToLower(r)
+			}
+		}
+		sb.WriteRune(r)
+		n++
+	}
+	return sb.String(), pos, key
+}
+
+// IAcceleratorTracker is implemented by an App that activates widgets when their
+// accelerator key is pressed with alt. Widgets register their accelerator by calling
+// TrackAccelerator from their Render function; the accelerators of the widgets drawn
+// in the last frame are active until the next frame.
+type IAcceleratorTracker interface {
+	TrackAccelerator(key rune, w IClickable, c ICanvas)
+	ActivateAccelerator(key rune, app IApp) bool
+}
+
+// TrackAccelerator asks the app to click w when alt and key are pressed, provided the
+// canvas c, rendered by w, reaches the screen. It does nothing if the app doesn't
+// support accelerators, or key is 0.
+func TrackAccelerator(key rune, w IClickable, c ICanvas, app IApp) {
+	if key == 0 {
+		return
+	}
+	if at, ok := app.(IAcceleratorTracker); ok {
+		at.TrackAccelerator(key, w, c)
+	}
+}
+
+// AcceleratorKey returns the accelerator key pressed - the lower case rune - if ev
+// is a key press with alt and no other modifier, or 0.
+func AcceleratorKey(ev interface{}) rune {
+	if evk, ok := ev.(*tcell.EventKey); ok && evk.Key() == tcell.KeyRune && evk.Modifiers() == tcell.ModAlt {
+		return unicode.ToLower(evk.Rune())
+	}
+	return 0
+}
+
+type trackedAccelerator struct {
+	key rune
+	w   IClickable
+}
+
+const acceleratorMarkPrefix = "gowid-accelerator-"
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// TrackAccelerator marks the canvas so the accelerator is only active if the canvas
+// reaches the screen.
+func (a *App) TrackAccelerator(key rune, w IClickable, c ICanvas) {
+	a.trackMu.Lock()
+	defer a.trackMu.Unlock()
+	c.SetMark(fmt.Sprintf("%s%d", acceleratorMarkPrefix, len(a.acceleratorsPending)), 0, 0)
+	a.acceleratorsPending = append(a.acceleratorsPending, trackedAccelerator{key: unicode.ToLower(key), w: w})
+}
+
+// ActivateAccelerator clicks the widget drawn in the last frame with the given
+// accelerator key, and returns false if there is none. If several widgets share the
+// key, the one drawn last wins - e.g. a button in a dialog over the main view.
+func (a *App) ActivateAccelerator(key rune, app IApp) bool {
+	key = unicode.ToLower(key)
+	for i := len(a.accelerators) - 1; i >= 0; i-- {
+		if a.accelerators[i].key == key {
+			a.accelerators[i].w.Click(app)
+			return true
+		}
+	}
+	return false
+}
+
+// collectAccelerators keeps the accelerators whose canvases reached the screen, then
+// removes the marks used to find them.
+func (a *App) collectAccelerators(canvas ICanvas) {
+	res := a.accelerators[:0]
+	for i, t := range a.acceleratorsPending {
+		name := fmt.Sprintf("%s%d", acceleratorMarkPrefix, i)
+		if _, ok := canvas.GetMark(name); ok {
+			res = append(res, t)
+			canvas.RemoveMark(name)
+		}
+		a.acceleratorsPending[i] = trackedAccelerator{}
+	}
+	a.accelerators = res
+	a.acceleratorsPending = a.acceleratorsPending[:0]
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,168 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+//======================================================================
+
+// Politeness says whether an announcement can wait for the user to finish hearing
+// the previous one, or should interrupt it.
+type Politeness int
+
+const (
+	AnnouncePolite Politeness = iota
+	AnnounceAssertive
+)
+
+func (p Politeness) String() string {
+	switch p {
+	case AnnouncePolite:
+		return "polite"
+	case AnnounceAssertive:
+		return "assertive"
+	default:
+		return fmt.Sprintf("politeness(%d)", int(p))
+	}
+}
+
+// Announcement is a description of a change in the UI, meant for users who can't see
+// it - e.g. "checked", when a checkbox is toggled, or the name of the item a list has
+// moved to. Source is the widget that posted it, if any.
+type Announcement struct {
+	Text       string
+	Politeness Politeness
+	Source     IWidget
+}
+
+func (a Announcement) String() string {
+	return fmt.Sprintf("announcement[%q,%v]", a.Text, a.Politeness)
+}
+
+// IAnnouncer is implemented by an App that passes announcements on to screen readers
+// and other assistive technology. See Announce.
+type IAnnouncer interface {
+	Announce(a Announcement)
+}
+
+// Announce posts a polite announcement of text on behalf of the source widget. It does
+// nothing if the app can't carry announcements. Call it from the widget rendering
+// goroutine, e.g. when a widget's state changes in UserInput.
+func Announce(app IApp, source IWidget, text string) {
+	AnnounceWith(app, Announcement{Text: text, Source: source})
+}
+
+// AnnounceWith posts the announcement, if the app can carry announcements.
+func AnnounceWith(app IApp, a Announcement) {
+	if an, ok := app.(IAnnouncer); ok {
+		an.Announce(a)
+	}
+}
+
+// IAnnouncementBackend is implemented by types that deliver announcements to the user -
+// e.g. to a speech synthesizer or braille display.
+type IAnnouncementBackend interface {
+	Announce(a Announcement, app IApp) error
+}
+
+//======================================================================
+
+// LogAnnouncer is an IAnnouncementBackend that logs each announcement with the app's
+// logger, which is handy when checking what a screen reader user would hear.
+type LogAnnouncer struct{}
+
+var _ IAnnouncementBackend = LogAnnouncer{}
+
+func (b LogAnnouncer) Announce(a Announcement, app IApp) error {
+	Log(app, LogInfo, "Announcement", LogField{"text", a.Text}, LogField{"politeness", a.Politeness})
+	return nil
+}
+
+// WriterAnnouncer is an IAnnouncementBackend that writes the text of each announcement
+// on its own line - e.g. to a named pipe read by a speech synthesizer or braille driver.
+type WriterAnnouncer struct {
+	Writer io.Writer
+}
+
+var _ IAnnouncementBackend = WriterAnnouncer{}
+
+func (b WriterAnnouncer) Announce(a Announcement, app IApp) error {
+	_, err := fmt.Fprintln(b.Writer, a.Text)
+	return err
+}
+
+// CommandAnnouncer is an IAnnouncementBackend that runs a command for each announcement,
+// with the text as its last argument - e.g. spd-say or espeak. The command runs in the
+// background; the app doesn't wait for it to finish.
+type CommandAnnouncer struct {
+	Command string
+	Args    []string
+}
+
+var _ IAnnouncementBackend = CommandAnnouncer{}
+
+func (b CommandAnnouncer) Announce(a Announcement, app IApp) error {
+	args := append(append([]string(nil), b.Args...), a.Text)
+	cmd := exec.Command(b.Command, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+	return nil
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+type AnnounceCB struct{}
+
+// Announce runs the app's announcement callbacks, then sends the announcement to the
+// app's backend, if it has one. A backend error is logged.
+func (a *App) Announce(an Announcement) {
+	if a.announceCallbacks != nil {
+		RunWidgetCallbacks(a.announceCallbacks, AnnounceCB{}, a, an.Source, an)
+	}
+	if a.announcer != nil {
+		if err := a.announcer.Announce(an, a); err != nil {
+			a.Log(LogWarn, "Could not deliver announcement", LogField{"announcement", an}, LogField{"error", err})
+		}
+	}
+}
+
+// OnAnnouncement registers a callback that is run for each announcement posted. The
+// callback's extra argument is the Announcement. This is how an app can show
+// announcements itself, e.g. in a status line.
+func (a *App) OnAnnouncement(f IWidgetChangedCallback) {
+	if a.announceCallbacks == nil {
+		a.announceCallbacks = NewCallbacks()
+	}
+	AddWidgetCallback(a.announceCallbacks, AnnounceCB{}, f)
+}
+
+func (a *App) RemoveOnAnnouncement(f IIdentity) {
+	if a.announceCallbacks != nil {
+		RemoveWidgetCallback(a.announceCallbacks, AnnounceCB{}, f)
+	}
+}
+
+// AnnouncementBackend returns the backend that announcements are sent to, or nil.
+func (a *App) AnnouncementBackend() IAnnouncementBackend {
+	return a.announcer
+}
+
+func (a *App) SetAnnouncementBackend(b IAnnouncementBackend) {
+	a.announcer = b
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
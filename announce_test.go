@@ -0,0 +1,48 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnounce1(t *testing.T) {
+	app, _ := newTestApp(t, 10, 2)
+	src := &leafWidget{name: "checkbox"}
+
+	// Nowhere to send it - nothing happens
+	Announce(app, src, "checked")
+
+	var got []Announcement
+	cb := MakeWidgetCallbackExt("cb", func(app IApp, w IWidget, data ...interface{}) {
+		assert.Equal(t, src, w)
+		got = append(got, data[0].(Announcement))
+	})
+	app.OnAnnouncement(cb)
+	buf := &bytes.Buffer{}
+	app.SetAnnouncementBackend(WriterAnnouncer{Writer: buf})
+
+	Announce(app, src, "checked")
+	AnnounceWith(app, Announcement{Text: "Save failed", Politeness: AnnounceAssertive, Source: src})
+	assert.Equal(t, []Announcement{
+		{Text: "checked", Source: src},
+		{Text: "Save failed", Politeness: AnnounceAssertive, Source: src},
+	}, got)
+	assert.Equal(t, "checked\nSave failed\n", buf.String())
+
+	app.RemoveOnAnnouncement(cb)
+	Announce(app, src, "unchecked")
+	assert.Equal(t, 2, len(got))
+	assert.Equal(t, "checked\nSave failed\nunchecked\n", buf.String())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
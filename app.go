@@ -102,14 +102,73 @@ type App struct {
 	screenInited         bool
 	dontOwnScreen        bool
 	tty                  string
-
-	lastMouse    MouseState    // So I can tell if a button was previously clicked
-	MouseState                 // Track which mouse buttons are currently down
-	ClickTargets               // When mouse is clicked, track potential interaction here
-	log          log.StdLogger // For any application logging
+	runner               *AppRunner // The active event-polling runner, if any - stopped while suspended
+	resizeDebounce       time.Duration
+	resizeTimer          *time.Timer
+	minColumns           int
+	minRows              int
+	tooSmall             IWidget // Displayed instead of the view if the terminal is smaller than the minimum
+	errorPolicy          ErrorPolicy
+	onRenderError        RenderErrorFunc
+	jobControl           bool
+	signals              chan os.Signal // SIGTSTP and SIGCONT, if job control is enabled
+	recorder             IFrameRecorder // If not nil, sees every frame drawn to the terminal
+	inputRecorder        IInputRecorder // If not nil, sees every input event from the terminal
+	recoverPanics        bool           // If true, MainLoop recovers from a panic and restores the terminal
+	crashReport          string         // If not empty, a recovered panic is reported to this file
+	activity             string         // What the app is doing, for a panic report
+	activityEvent        interface{}
+	closeOnce            sync.Once
+	bus                  *EventBus        // Carries application events between widgets
+	clipboard            IClipboardWriter // How copied text reaches the system clipboard - detected if nil
+	pages                []pageEntry      // Pages hidden by PushPage, most recent last
+	pageCallbacks        *Callbacks
+	drag                 *Drag // The drag in progress, if any
+	dragCallbacks        *Callbacks
+	mouseX               int // Terminal coordinates of the last mouse event
+	mouseY               int
+	geometry             []trackedGeometry // Widgets that opted in to geometry tracking, as last drawn
+	trackMu              sync.Mutex        // Guards the pending lists below - children may render concurrently
+	geometryPending      []IIdentity       // Widgets tracked during the render in progress
+	hovered              []IHoverTarget    // Tracked widgets under the mouse at the last mouse event
+	accelerators         []trackedAccelerator
+	acceleratorsPending  []trackedAccelerator
+	cursorStyle          tcell.CursorStyle // The cursor style last applied to the screen
+	cursorStylesPending  []tcell.CursorStyle
+	announcer            IAnnouncementBackend // Where announcements for assistive technology are sent, if anywhere
+	announceCallbacks    *Callbacks
+	displayFilter        ICellProcessor // If not nil, applied to every cell just before it's drawn
+	colorModeCallbacks   *Callbacks
+	frames               frameScheduler  // Decides when frames are drawn - see SetMaxFPS
+	idle                 []IdleFunc      // Run when there's nothing else to do - see OnIdle
+	colorConverter       *ColorConverter // Converts and caches colors for this app's rendering
+	roots                []*Root         // Widget hierarchies rendered alongside the view - see AddRoot
+	focusRoot            *Root           // The root that gets keyboard input, or nil for the view
+	viewPlacement        RootPlacement   // The view's region of the screen, or nil for all of it
+	canvasPool           *CanvasPool     // Reuses canvas memory from frame to frame, or nil
+
+	timings map[IWidget]RenderTiming // Render timings this frame, or nil if timing is off - see IRenderTimer
+
+	lastMouse    MouseState // So I can tell if a button was previously clicked
+	MouseState              // Track which mouse buttons are currently down
+	ClickTargets            // When mouse is clicked, track potential interaction here
+	logger       ILogger    // For any application logging
 }
 
 var _ IApp = (*App)(nil)
+var _ IRenderErrorHandler = (*App)(nil)
+var _ IClipboardService = (*App)(nil)
+var _ IDragDrop = (*App)(nil)
+var _ IGeometryTracker = (*App)(nil)
+var _ IHoverTracker = (*App)(nil)
+var _ IAcceleratorTracker = (*App)(nil)
+var _ ICursorStyleTracker = (*App)(nil)
+var _ IRenderTimer = (*App)(nil)
+var _ IEventBus = (*App)(nil)
+var _ IAnnouncer = (*App)(nil)
+var _ IAnimationFrames = (*App)(nil)
+var _ IIdleScheduler = (*App)(nil)
+var _ IGetColorConverter = (*App)(nil)
 
 // AppArgs is a helper struct, providing arguments for the initialization of App.
 type AppArgs struct {
@@ -119,8 +178,26 @@ type AppArgs struct {
 	EnableMouseMotion    bool
 	EnableBracketedPaste bool
 	Log                  log.StdLogger
+	Logger               ILogger // Receives the app's diagnostics; if nil, they go to Log
 	DontActivate         bool
 	Tty                  string
+	ResizeDebounce       time.Duration          // If non-zero, redraw only once resize events have stopped for this long
+	MinColumns           int                    // If the terminal is narrower than this, TooSmallView is displayed instead
+	MinRows              int                    // If the terminal is shorter than this, TooSmallView is displayed instead
+	TooSmallView         IWidget                // Displayed when the terminal is below the minimum size. A default is provided if nil.
+	ErrorPolicy          ErrorPolicy            // Whether to panic or recover when a widget fails to render
+	OnRenderError        RenderErrorFunc        // Called when a render error is recovered. If nil, the error is logged.
+	JobControl           bool                   // If true, ctrl-z and SIGTSTP suspend the process, restoring the terminal
+	Recorder             IFrameRecorder         // If not nil, every frame drawn is recorded - see NewCastRecorder
+	InputRecorder        IInputRecorder         // If not nil, every input event is recorded - see NewInputRecorder
+	RecoverPanics        bool                   // If true, a panic in MainLoop restores the terminal and is reported - see RecoverPanic
+	CrashReport          string                 // If not empty, the report of a recovered panic is also written to this file
+	Clipboard            IClipboardWriter       // How CopyToClipboard reaches the system clipboard. Detected at runtime if nil.
+	Announcer            IAnnouncementBackend   // If not nil, receives announcements for assistive technology - see Announce
+	DisplayFilter        ICellProcessor         // If not nil, transforms every cell before drawing - e.g. HighContrastFilter
+	MaxFPS               int                    // If non-zero, frames are drawn at most this many times a second - see SetMaxFPS
+	CanvasPooling        bool                   // If true, canvas memory is reused from frame to frame - see CanvasPool
+	ColorOptions         []ColorConverterOption // Configure the app's ColorConverter - e.g. IgnoreBase16Colors(true)
 }
 
 // IUnhandledInput is used as a handler for application user input that is not handled by any
@@ -246,7 +323,7 @@ func newApp(args AppArgs) (rapp *App, rerr error) {
 	screen := args.Screen
 	if screen == nil {
 		var err error
-		screen, err = tcellScreen(args.Tty)
+		screen, err = newScreen(args.Tty)
 		if err != nil {
 			rerr = WithKVs(err, map[string]interface{}{"TERM": os.Getenv("TERM")})
 			return
@@ -263,7 +340,7 @@ func newApp(args AppArgs) (rapp *App, rerr error) {
 
 	clicks := MakeClickTargets()
 
-	if args.Log == nil {
+	if args.Logger == nil && args.Log == nil {
 		logname := filepath.Base(os.Args[0])
 		logname = fmt.Sprintf("%s.log", strings.TrimSuffix(logname, filepath.Ext(logname)))
 		logfile, err := os.Create(logname)
@@ -274,6 +351,9 @@ func newApp(args AppArgs) (rapp *App, rerr error) {
 		logger.Out = logfile
 		args.Log = logger
 	}
+	if args.Logger == nil {
+		args.Logger = StdLogger{args.Log}
+	}
 
 	res := &App{
 		IPalette:             palette,
@@ -285,13 +365,35 @@ func newApp(args AppArgs) (rapp *App, rerr error) {
 		viewPlusMenus:        args.View,
 		colorMode:            Mode256Colors,
 		ClickTargets:         clicks,
-		log:                  args.Log,
+		logger:               args.Logger,
+		colorConverter:       NewColorConverter(args.ColorOptions...),
 		enableMouseMotion:    args.EnableMouseMotion,
 		enableBracketedPaste: args.EnableBracketedPaste,
 		dontOwnScreen:        args.Screen != nil,
 		tty:                  args.Tty,
+		resizeDebounce:       args.ResizeDebounce,
+		minColumns:           args.MinColumns,
+		minRows:              args.MinRows,
+		tooSmall:             args.TooSmallView,
+		errorPolicy:          args.ErrorPolicy,
+		onRenderError:        args.OnRenderError,
+		jobControl:           args.JobControl,
+		recorder:             args.Recorder,
+		inputRecorder:        args.InputRecorder,
+		recoverPanics:        args.RecoverPanics,
+		crashReport:          args.CrashReport,
+		clipboard:            args.Clipboard,
+		announcer:            args.Announcer,
+		displayFilter:        args.DisplayFilter,
 	}
 
+	if res.tooSmall == nil {
+		res.tooSmall = &tooSmallWidget{app: res}
+	}
+	res.SetCanvasPooling(args.CanvasPooling)
+	res.SetMaxFPS(args.MaxFPS)
+	res.bus = NewEventBus(res.Run)
+
 	if !res.dontOwnScreen && !args.DontActivate {
 		if err := res.initScreen(); err != nil {
 			return nil, err
@@ -309,20 +411,37 @@ func newApp(args AppArgs) (rapp *App, rerr error) {
 }
 
 func (a *App) initColorMode() {
-	cols := a.screen.Colors()
+	a.colorMode = DetectColorMode(a.screen)
+}
+
+// DetectColorMode returns the color mode of the terminal behind screen. Terminals that
+// advertise 24-bit color with COLORTERM=truecolor or 24bit, or with a "-direct" terminfo
+// entry, get Mode24BitColors even if the screen reports fewer colors; otherwise the
+// number of colors the screen reports decides.
+func DetectColorMode(screen tcell.Screen) ColorMode {
+	cols := screen.Colors()
+	if cols > 0 {
+		switch strings.ToLower(os.Getenv("COLORTERM")) {
+		case "truecolor", "24bit":
+			cols = 1 << 24
+		}
+		if strings.HasSuffix(os.Getenv("TERM"), "-direct") {
+			cols = 1 << 24
+		}
+	}
 	switch {
 	case cols > 256:
-		a.SetColorMode(Mode24BitColors)
+		return Mode24BitColors
 	case cols == 256:
-		a.SetColorMode(Mode256Colors)
+		return Mode256Colors
 	case cols == 88:
-		a.SetColorMode(Mode88Colors)
+		return Mode88Colors
 	case cols == 16:
-		a.SetColorMode(Mode16Colors)
+		return Mode16Colors
 	case cols < 0:
-		a.SetColorMode(ModeMonochrome)
+		return ModeMonochrome
 	default:
-		a.SetColorMode(Mode8Colors)
+		return Mode8Colors
 	}
 }
 
@@ -363,10 +482,7 @@ func (a *App) CopyModeClaimedBy(id ...IIdentity) IIdentity {
 }
 
 func (a *App) SetSubWidget(widget IWidget, app IApp) {
-	a.view = widget
-	if a.viewPlusMenus == nil {
-		a.viewPlusMenus = widget
-	}
+	a.setView(widget)
 }
 
 func (a *App) SubWidget() IWidget {
@@ -389,14 +505,165 @@ func (a *App) GetLastMouseState() MouseState {
 	return a.lastMouse
 }
 
+// SetColorMode changes the color mode the app renders in - e.g. to let the user pick
+// fewer colors than the terminal supports. If the mode changes, the callbacks registered
+// with OnColorModeChange are run, and the app is redrawn. The app's ColorConverter caches
+// conversions for each mode separately, so nothing needs to be flushed.
 func (a *App) SetColorMode(mode ColorMode) {
+	if mode == a.colorMode {
+		return
+	}
 	a.colorMode = mode
+	if a.colorModeCallbacks != nil {
+		RunWidgetCallbacks(a.colorModeCallbacks, ColorModeCB{}, a, nil, mode)
+	}
+	a.Redraw()
+}
+
+type ColorModeCB struct{}
+
+// OnColorModeChange registers a callback that is run when SetColorMode changes the
+// color mode. The callback's extra argument is the new ColorMode. Widgets that cache
+// colors converted for the old mode should discard them.
+func (a *App) OnColorModeChange(f IWidgetChangedCallback) {
+	if a.colorModeCallbacks == nil {
+		a.colorModeCallbacks = NewCallbacks()
+	}
+	AddWidgetCallback(a.colorModeCallbacks, ColorModeCB{}, f)
+}
+
+func (a *App) RemoveOnColorModeChange(f IIdentity) {
+	if a.colorModeCallbacks != nil {
+		RemoveWidgetCallback(a.colorModeCallbacks, ColorModeCB{}, f)
+	}
 }
 
+// GetColorMode returns the terminal's color mode, unless the display filter forces
+// another - see IForceColorMode.
 func (a *App) GetColorMode() ColorMode {
+	if f, ok := a.displayFilter.(IForceColorMode); ok {
+		return f.ForceColorMode()
+	}
 	return a.colorMode
 }
 
+// ColorConverter returns the converter the app uses for colors that must be matched to
+// the terminal's - see AppArgs.ColorOptions.
+func (a *App) ColorConverter() *ColorConverter {
+	return a.colorConverter
+}
+
+// RenderErrorPolicy returns the app's policy for widgets that fail to render.
+func (a *App) RenderErrorPolicy() ErrorPolicy {
+	return a.errorPolicy
+}
+
+// SetRenderErrorPolicy determines whether a widget that fails to render terminates
+// the application, or is replaced by an error placeholder.
+func (a *App) SetRenderErrorPolicy(p ErrorPolicy) {
+	a.errorPolicy = p
+}
+
+// OnRenderError sets the function called when a render error is recovered.
+func (a *App) OnRenderError(f RenderErrorFunc) {
+	a.onRenderError = f
+}
+
+// HandleRenderError reports a recovered render error - via the app's callback if
+// one is set, otherwise to the app's log.
+func (a *App) HandleRenderError(w IWidget, size IRenderSize, err error) {
+	if a.onRenderError != nil {
+		a.onRenderError(a, w, size, err)
+	} else {
+		a.Log(LogError, "Widget failed to render", LogField{"widget", w}, LogField{"size", size}, LogField{"error", err})
+	}
+}
+
+// Logger returns the logger that receives the app's diagnostics.
+func (a *App) Logger() ILogger {
+	return a.logger
+}
+
+// SetLogger routes the app's diagnostics, and those of widgets that log via the app, to
+// l.
+func (a *App) SetLogger(l ILogger) {
+	a.logger = l
+}
+
+// Log logs a message with the app's logger.
+func (a *App) Log(level LogLevel, msg string, fields ...LogField) {
+	a.logger.Log(level, msg, fields...)
+}
+
+// Recorder returns the app's frame recorder, or nil if frames are not being recorded.
+func (a *App) Recorder() IFrameRecorder {
+	return a.recorder
+}
+
+// SetRecorder starts recording each frame drawn with r, or stops recording if r is nil.
+func (a *App) SetRecorder(r IFrameRecorder) {
+	a.recorder = r
+}
+
+// recordFrame passes the frame to the recorder. If recording fails, the error is
+// logged and recording stops.
+func (a *App) recordFrame(canvas IDrawCanvas) {
+	if a.recorder == nil {
+		return
+	}
+	if err := a.recorder.RecordFrame(canvas, a); err != nil {
+		a.Log(LogError, "Could not record frame - recording stopped", LogField{"error", err})
+		a.recorder = nil
+	}
+}
+
+// SetMinimumSize sets the smallest terminal size at which the application's widgets
+// are rendered. Below this size, the too-small view is displayed instead and user
+// input is not passed to the widgets, but only to the unhandled input handler. A
+// value of 0 means no minimum.
+func (a *App) SetMinimumSize(cols, rows int) {
+	a.minColumns = cols
+	a.minRows = rows
+}
+
+// MinimumSize returns the smallest terminal size at which the application's widgets
+// are rendered.
+func (a *App) MinimumSize() (cols, rows int) {
+	return a.minColumns, a.minRows
+}
+
+// SetTooSmallView sets the widget displayed when the terminal is smaller than the
+// application's declared minimum size.
+func (a *App) SetTooSmallView(w IWidget) {
+	a.tooSmall = w
+}
+
+// SetResizeDebounce sets the interval for which the app waits, after a terminal resize
+// event, for further resize events before redrawing. Zero means redraw immediately.
+func (a *App) SetResizeDebounce(d time.Duration) {
+	a.resizeDebounce = d
+}
+
+// TerminalTooSmall returns true if the terminal is currently smaller than the
+// application's declared minimum size.
+func (a *App) TerminalTooSmall() bool {
+	x, y := a.TerminalSize()
+	return x < a.minColumns || y < a.minRows
+}
+
+// root returns the widget to render and send input to - the view, including menus,
+// along with any roots added with AddRoot, or the too-small view if the terminal is
+// below the declared minimum size.
+func (a *App) root() IWidget {
+	if a.TerminalTooSmall() {
+		return a.tooSmall
+	}
+	if len(a.roots) > 0 || a.viewPlacement != nil {
+		return &multiRoot{app: a}
+	}
+	return a.viewPlusMenus
+}
+
 // TerminalSize returns the terminal's size.
 func (a *App) TerminalSize() (x, y int) {
 	x, y = a.screen.Size()
@@ -418,11 +685,6 @@ func (a *App) TerminalSize() (x, y int) {
 	return
 }
 
-type LogField struct {
-	Name string
-	Val  interface{}
-}
-
 type CopyModeEvent struct{}
 
 func (c CopyModeEvent) When() time.Time {
@@ -480,6 +742,25 @@ func (a *App) Clips() []ICopyResult {
 // input can be processed; other events might result in gowid updating its
 // internal state, like the size of the underlying terminal.
 func (a *App) HandleTCellEvent(ev interface{}, unhandled IUnhandledInput) {
+	a.setActivity("handling", ev)
+	if tev, ok := ev.(tcell.Event); ok {
+		a.recordInput(tev)
+	}
+
+	if evk, ok := ev.(*tcell.EventKey); ok && a.jobControl && evk.Key() == tcell.KeyCtrlZ {
+		// The terminal is in raw mode so the tty won't generate SIGTSTP itself
+		if err := a.SuspendProcess(); err != nil {
+			a.Log(LogError, "Could not suspend process", LogField{"error", err})
+		}
+		return
+	}
+
+	if evk, ok := ev.(*tcell.EventKey); ok && evk.Key() == tcell.KeyEscape && a.drag != nil && a.drag.Active() {
+		a.CancelDrag()
+		a.requestFrame()
+		return
+	}
+
 	switch ev := ev.(type) {
 	case *tcell.EventKey, *tcell.EventPaste:
 		// This makes for a better experience on limited hardware like raspberry pi
@@ -498,7 +779,7 @@ func (a *App) HandleTCellEvent(ev interface{}, unhandled IUnhandledInput) {
 			a.handleInputEvent(CopyModeEvent{}, unhandled)
 			a.refreshCopy = false
 		}
-		a.RedrawTerminal()
+		a.requestFrame()
 
 		//case *tcell.EventPaste:
 		//log.Infof("GCLA: app.go tcell paste")
@@ -516,7 +797,12 @@ func (a *App) HandleTCellEvent(ev interface{}, unhandled IUnhandledInput) {
 			}
 			debug.SetGCPercent(-1)
 			defer debug.SetGCPercent(100)
+			a.dragMouseEvent(ev)
+			a.updateHover(a.mouseX, a.mouseY)
 			a.handleInputEvent(ev, unhandled)
+			if ev.Buttons() == tcell.ButtonNone {
+				a.endDrag()
+			}
 			// Make sure we don't hold on to references longer than we need to
 			if ev.Buttons() == tcell.ButtonNone {
 				a.ClickTargets.DeleteClickTargets(tcell.Button1)
@@ -528,33 +814,26 @@ func (a *App) HandleTCellEvent(ev interface{}, unhandled IUnhandledInput) {
 			a.MouseState = MouseState{
 				MouseLastClickedTime: a.MouseLastClickedTime,
 			}
-			a.RedrawTerminal()
+			a.requestFrame()
 		}
 	case *tcell.EventResize:
-		if flog, ok := a.log.(log.FieldLogger); ok {
-			flog.WithField("event", ev).Infof("Terminal was resized")
+		a.Log(LogInfo, "Terminal was resized", LogField{"event", ev})
+		if a.resizeDebounce > 0 {
+			// Each resize pushes back the redraw - so dragging a window edge doesn't
+			// result in a render for every intermediate size.
+			if a.resizeTimer != nil {
+				a.resizeTimer.Stop()
+			}
+			a.resizeTimer = time.AfterFunc(a.resizeDebounce, a.Redraw)
 		} else {
-			a.log.Printf("Terminal was resized\n")
+			a.requestFrame()
 		}
-		a.RedrawTerminal()
 	case *tcell.EventInterrupt:
-		if flog, ok := a.log.(log.FieldLogger); ok {
-			flog.WithField("event", ev).Infof("Interrupt event from tcell")
-		} else {
-			a.log.Printf("Interrupt event from tcell: %v\n", ev)
-		}
+		a.Log(LogInfo, "Interrupt event from tcell", LogField{"event", ev})
 	case *tcell.EventError:
-		if flog, ok := a.log.(log.FieldLogger); ok {
-			flog.WithField("event", ev).WithField("error", ev.Error()).Errorf("Error event from tcell")
-		} else {
-			a.log.Printf("Error event from tcell: %v, %v\n", ev, ev.Error())
-		}
+		a.Log(LogError, "Error event from tcell", LogField{"event", ev}, LogField{"error", ev.Error()})
 	default:
-		if flog, ok := a.log.(log.FieldLogger); ok {
-			flog.WithField("event", ev).Infof("Unanticipated event from tcell")
-		} else {
-			a.log.Printf("Unanticipated event from tcell: %v\n", ev)
-		}
+		a.Log(LogInfo, "Unanticipated event from tcell", LogField{"event", ev})
 	}
 
 	if ev, ok := ev.(*tcell.EventMouse); ok && ev.Buttons() == 0 && ev.Modifiers() == 0 {
@@ -565,9 +844,13 @@ func (a *App) HandleTCellEvent(ev interface{}, unhandled IUnhandledInput) {
 }
 
 // Close should be called by a gowid application after the user terminates the application.
-// It will cleanup tcell's screen object.
+// It will cleanup tcell's screen object. Calls after the first have no effect.
 func (a *App) Close() {
-	a.screen.Fini()
+	a.closeOnce.Do(func() {
+		if a.screen != nil {
+			a.screen.Fini()
+		}
+	})
 }
 
 // StartTCellEvents starts a goroutine that listens for events from TCell. The
@@ -635,12 +918,16 @@ func (a *App) Runner() *AppRunner {
 
 func (st *AppRunner) Start() {
 	st.app.StartTCellEvents(st.quitCh, &st.wg)
+	st.app.runner = st
 	st.started = true
 }
 
 func (st *AppRunner) Stop() {
 	if st.started {
 		st.app.StopTCellEvents(st.quitCh, &st.wg)
+		if st.app.runner == st {
+			st.app.runner = nil
+		}
 		st.started = false
 	}
 }
@@ -653,13 +940,27 @@ func (a *App) MainLoop(unhandled IUnhandledInput) {
 	st := a.Runner()
 	st.Start()
 	defer st.Stop()
+	if a.jobControl {
+		a.startJobControl()
+		defer a.stopJobControl()
+	}
+	if a.recoverPanics {
+		defer a.RecoverPanic()
+	}
 	a.handleEvents(unhandled)
 }
 
+// SetJobControl determines whether ctrl-z suspends the process. If the app is run with
+// MainLoop(), this must be set before the loop starts for SIGTSTP to be caught too.
+func (a *App) SetJobControl(on bool) {
+	a.jobControl = on
+}
+
 // RunThenRenderEvent dispatches the event by calling it with the
 // app as an argument - then it will force the application to re-render
 // itself.
 func (a *App) RunThenRenderEvent(ev IAfterRenderEvent) {
+	a.setActivity("running", ev)
 	redraw := true
 	if evext, ok := ev.(IAppRun); ok {
 		redraw = evext.RunThenOptionallyRenderEvent(a)
@@ -667,7 +968,7 @@ func (a *App) RunThenRenderEvent(ev IAfterRenderEvent) {
 		ev.RunThenRenderEvent(a)
 	}
 	if redraw {
-		a.RedrawTerminal()
+		a.requestFrame()
 	}
 }
 
@@ -675,18 +976,42 @@ func (a *App) RunThenRenderEvent(ev IAfterRenderEvent) {
 // like a function which must be executed on the render goroutine, or events from
 // the underlying TCell library like user input or terminal resize.
 func (a *App) handleEvents(unhandled IUnhandledInput) {
-Loop:
+	a.frames.active = true
+	defer func() {
+		a.frames.active = false
+		a.frames.stopTimer()
+	}()
 	for {
-		select {
-		case ev := <-a.TCellEvents:
-			a.HandleTCellEvent(ev, unhandled)
-		case ev := <-a.AfterRenderEvents:
-			if ev == nil {
-				break Loop
+		if !a.handleEvent(unhandled) {
+			return
+		}
+		// Handle the events already waiting too, so they share a frame
+		for len(a.TCellEvents) > 0 || len(a.AfterRenderEvents) > 0 {
+			if !a.handleEvent(unhandled) {
+				return
 			}
-			a.RunThenRenderEvent(ev)
 		}
+		a.drawFrameIfDue()
+		// Do deferred work while there's nothing more pressing
+		for a.isIdle() && a.RunIdle() {
+		}
+	}
+}
+
+// handleEvent waits for one event and handles it. A frame falling due counts as an
+// event. It returns false if the app has quit.
+func (a *App) handleEvent(unhandled IUnhandledInput) bool {
+	select {
+	case ev := <-a.TCellEvents:
+		a.HandleTCellEvent(ev, unhandled)
+	case ev := <-a.AfterRenderEvents:
+		if ev == nil {
+			return false
+		}
+		a.RunThenRenderEvent(ev)
+	case <-a.frames.due():
 	}
+	return true
 }
 
 // handleInputEvent manages key-press events. A keybinding handler is called when
@@ -696,20 +1021,21 @@ func (a *App) handleInputEvent(ev interface{}, unhandled IUnhandledInput) {
 	switch ev.(type) {
 	case *tcell.EventKey, *tcell.EventPaste, *tcell.EventMouse:
 		x, y := a.TerminalSize()
-		handled := UserInputIfSelectable(a.viewPlusMenus, ev, RenderBox{C: x, R: y}, Focused, a)
+		handled := UserInputIfSelectable(a.root(), ev, RenderBox{C: x, R: y}, Focused, a)
+		if !handled {
+			if key := AcceleratorKey(ev); key != 0 {
+				handled = a.ActivateAccelerator(key, a)
+			}
+		}
 		if !handled {
 			handled = unhandled.UnhandledInput(a, ev)
 			if !handled {
-				if flog, ok := a.log.(log.FieldLogger); ok {
-					flog.WithField("event", ev).Debugf("Input was not handled")
-				} else {
-					a.log.Printf("Input was not handled: %v\n", ev)
-				}
+				a.Log(LogDebug, "Input was not handled", LogField{"event", ev})
 			}
 		}
 	default:
 		x, y := a.TerminalSize()
-		UserInputIfSelectable(a.viewPlusMenus, ev, RenderBox{C: x, R: y}, Focused, a)
+		UserInputIfSelectable(a.root(), ev, RenderBox{C: x, R: y}, Focused, a)
 	}
 }
 
@@ -721,9 +1047,16 @@ func (a *App) Sync() {
 
 // RedrawTerminal updates the gui, re-drawing frames and buffers. Call this from
 // the widget-handling goroutine only. Intended for use by apps that construct their
-// own main loops and handle gowid events themselves.
+// own main loops and handle gowid events themselves. Functions requested with
+// RequestAnimationFrame are called first.
 func (a *App) RedrawTerminal() {
-	RenderRoot(a.viewPlusMenus, a)
+	now := time.Now()
+	a.frames.pending = false
+	a.frames.last = now
+	a.frames.stopTimer()
+	a.runAnimationFrames(now)
+	a.setActivity("rendering", nil)
+	RenderRoot(a.root(), a)
 	a.screen.Show()
 }
 
@@ -748,6 +1081,12 @@ func (a *menuView) SetSubWidget(widget IWidget, app IApp) {
 	a.viewPlusMenus = widget
 }
 
+// SubWidget returns the real root of the widget hierarchy, so that UnregisterMenu
+// searches the registered menus rather than the App's view.
+func (a *menuView) SubWidget() IWidget {
+	return a.viewPlusMenus
+}
+
 func (a *App) unregisterMenu(cur ISettableComposite, removeMe IMenuCompatible) bool {
 	res := true
 	for {
@@ -774,6 +1113,54 @@ func (a *App) UnregisterMenu(menu IMenuCompatible) bool {
 
 //======================================================================
 
+// tooSmallWidget is the default widget displayed when the terminal is smaller than
+// the app's declared minimum size. It explains the minimum size required, centered,
+// and truncated if even that doesn't fit.
+type tooSmallWidget struct {
+	app *App
+	RejectUserInput
+	NotSelectable
+}
+
+func (w *tooSmallWidget) String() string {
+	return "toosmall"
+}
+
+func (w *tooSmallWidget) RenderSize(size IRenderSize, focus Selector, app IApp) IRenderBox {
+	return CalculateRenderSizeFallback(w, size, focus, app)
+}
+
+func (w *tooSmallWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	box, ok := size.(IRenderBox)
+	if !ok {
+		panic(WidgetSizeError{Widget: w, Size: size, Required: "IRenderBox"})
+	}
+	cols, rows := box.BoxColumns(), box.BoxRows()
+	res := CanvasPoolOf(app).NewCanvasOfSize(cols, rows)
+	msgs := []string{
+		Translate("Terminal too small"),
+		Translatef("Need %dx%d", w.app.minColumns, w.app.minRows),
+	}
+	top := (rows - len(msgs)) / 2
+	for i, msg := range msgs {
+		y := top + i
+		if y < 0 || y >= rows {
+			continue
+		}
+		line := []rune(msg)
+		if len(line) > cols {
+			line = line[:cols]
+		}
+		left := (cols - len(line)) / 2
+		for j, r := range line {
+			res.SetCellAt(left+j, y, CellFromRune(r))
+		}
+	}
+	return res
+}
+
+//======================================================================
+
 type RunFunction func(IApp)
 
 // IAfterRenderEvent is implemented by clients that wish to run a function on the
@@ -799,6 +1186,8 @@ func (f RunFunction) RunThenRenderEvent(app IApp) {
 
 var AppClosingErr = fmt.Errorf("App is closing - no more events accepted.")
 
+var AppScreenNotOwnedErr = fmt.Errorf("App does not own the screen - it cannot be suspended.")
+
 // Run executes this function on the goroutine that renders
 // widgets and processes their callbacks. Any function that manipulates
 // widget state outside of the Render/UserInput chain should be run this
@@ -835,7 +1224,7 @@ func (a *App) Quit() {
 //
 // Assumes we own the screen...
 func (a *App) ActivateScreen() error {
-	screen, err := tcellScreen(a.tty)
+	screen, err := newScreen(a.tty)
 	if err != nil {
 		return WithKVs(err, map[string]interface{}{"TERM": os.Getenv("TERM")})
 	}
@@ -850,6 +1239,44 @@ func (a *App) ActivateScreen() error {
 	return nil
 }
 
+// newScreen makes the screen of an app that owns its screen. It's a variable so tests can
+// use a simulation screen.
+var newScreen = tcellScreen
+
+// Suspend gives the terminal back to the user while the supplied function runs - for
+// example, to launch $EDITOR or a shell. The tcell screen is torn down, restoring the
+// terminal to its normal cooked state, and the goroutine reading tcell events is
+// stopped. When the function returns, a new screen is initialized, event processing
+// resumes and the whole widget hierarchy is redrawn. The error returned by the function
+// is returned, unless the screen could not be reinitialized. Call this from the widget
+// rendering goroutine e.g. from a widget callback or via app.Run().
+func (a *App) Suspend(f func() error) error {
+	if a.dontOwnScreen {
+		return AppScreenNotOwnedErr
+	}
+
+	runner := a.runner
+	if runner != nil {
+		runner.Stop()
+	}
+
+	a.DeactivateScreen()
+
+	ferr := f()
+
+	if err := a.ActivateScreen(); err != nil {
+		return err
+	}
+
+	if runner != nil {
+		runner.Start()
+	}
+
+	a.RedrawTerminal()
+
+	return ferr
+}
+
 // Assumes we own the screen
 func (a *App) DeactivateScreen() {
 	if a.screen != nil && a.screenInited {
@@ -872,8 +1299,8 @@ func (a *App) initScreen() error {
 	defSt := StyleNone
 	if paletteDefault, ok := a.IPalette.CellStyler("default"); ok {
 		fgCol, bgCol, style := paletteDefault.GetStyle(a)
-		defFg = IColorToTCell(fgCol, defFg, a.GetColorMode())
-		defBg = IColorToTCell(bgCol, defBg, a.GetColorMode())
+		defFg = ColorToTCellIn(fgCol, defFg, a)
+		defBg = ColorToTCellIn(bgCol, defBg, a)
 		defSt = defSt.MergeUnder(style)
 	}
 	defStyle := tcell.Style{}.Attributes(defSt.OnOff).Background(defBg.ToTCell()).Foreground(defFg.ToTCell())
@@ -0,0 +1,820 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	stdlog "log"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+type xWidget struct {
+	RejectUserInput
+	NotSelectable
+}
+
+func (w *xWidget) RenderSize(size IRenderSize, focus Selector, app IApp) IRenderBox {
+	return CalculateRenderSizeFallback(w, size, focus, app)
+}
+
+func (w *xWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	box := size.(IRenderBox)
+	return NewCanvasOfSizeExt(box.BoxColumns(), box.BoxRows(), CellFromRune('x'))
+}
+
+type fillWidget struct {
+	xWidget
+	r rune
+}
+
+func (w *fillWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	box := size.(IRenderBox)
+	return NewCanvasOfSizeExt(box.BoxColumns(), box.BoxRows(), CellFromRune(w.r))
+}
+
+func screenString(s tcell.SimulationScreen) string {
+	cells, cols, rows := s.GetContents()
+	lines := make([]string, rows)
+	for y := 0; y < rows; y++ {
+		line := make([]rune, cols)
+		for x := 0; x < cols; x++ {
+			line[x] = cells[y*cols+x].Runes[0]
+		}
+		lines[y] = string(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func newTestApp(t *testing.T, cols, rows int) (*App, tcell.SimulationScreen) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(cols, rows)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	app, err := NewApp(AppArgs{
+		Screen: screen,
+		View:   &xWidget{},
+		Log:    logger,
+	})
+	assert.NoError(t, err)
+	return app, screen
+}
+
+// simulateOwnedScreens makes apps that own their screen use simulation screens until the
+// test ends. It returns the screens made so far.
+func simulateOwnedScreens(t *testing.T) *[]tcell.SimulationScreen {
+	res := make([]tcell.SimulationScreen, 0)
+	old := newScreen
+	t.Cleanup(func() {
+		newScreen = old
+	})
+	newScreen = func(tty string) (tcell.Screen, error) {
+		s := tcell.NewSimulationScreen("")
+		res = append(res, s)
+		return s, nil
+	}
+	return &res
+}
+
+func TestColorMode1(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("COLORTERM", "")
+	app, _ := newTestApp(t, 2, 1)
+	assert.Equal(t, Mode256Colors, app.GetColorMode())
+
+	modes := make([]ColorMode, 0)
+	app.OnColorModeChange(MakeWidgetCallbackExt("test", func(app IApp, w IWidget, data ...interface{}) {
+		modes = append(modes, data[0].(ColorMode))
+	}))
+	app.SetColorMode(Mode256Colors)
+	app.SetColorMode(Mode8Colors)
+	assert.Equal(t, []ColorMode{Mode8Colors}, modes)
+	assert.Equal(t, Mode8Colors, app.GetColorMode())
+	// The change is redrawn
+	assert.Equal(t, 1, len(app.AfterRenderEvents))
+
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	assert.Equal(t, Mode256Colors, DetectColorMode(screen))
+	t.Setenv("COLORTERM", "truecolor")
+	assert.Equal(t, Mode24BitColors, DetectColorMode(screen))
+	t.Setenv("COLORTERM", "")
+	t.Setenv("TERM", "xterm-direct")
+	assert.Equal(t, Mode24BitColors, DetectColorMode(screen))
+}
+
+func TestMinimumSize1(t *testing.T) {
+	app, screen := newTestApp(t, 20, 4)
+
+	app.RedrawTerminal()
+	assert.Equal(t, strings.Repeat("x", 20), strings.Split(screenString(screen), "\n")[0])
+
+	app.SetMinimumSize(30, 4)
+	assert.True(t, app.TerminalTooSmall())
+	app.RedrawTerminal()
+	assert.Equal(t, strings.Join([]string{
+		"                    ",
+		" Terminal too small ",
+		"     Need 30x4      ",
+		"                    ",
+	}, "\n"), screenString(screen))
+
+	app.SetMinimumSize(20, 4)
+	assert.False(t, app.TerminalTooSmall())
+	app.RedrawTerminal()
+	assert.Equal(t, strings.Repeat("x", 20), strings.Split(screenString(screen), "\n")[3])
+}
+
+func TestRenderError1(t *testing.T) {
+	app, _ := newTestApp(t, 20, 2)
+	w := &xWidget{}
+	flow := RenderFlowWith{C: 10}
+
+	assert.Panics(t, func() {
+		RenderChild(w, flow, Focused, app)
+	})
+
+	var reported error
+	app.SetRenderErrorPolicy(RecoverRenderError)
+	app.OnRenderError(func(app IApp, w IWidget, size IRenderSize, err error) {
+		reported = err
+	})
+	c := RenderChild(w, flow, Focused, app)
+	assert.Equal(t, 10, c.BoxColumns())
+	assert.Equal(t, 1, c.BoxRows())
+	assert.Equal(t, "!interface", c.String())
+	assert.Equal(t, StyleReverse, c.CellAt(0, 0).Style())
+	assert.IsType(t, &runtime.TypeAssertionError{}, reported)
+
+	// Errors that aren't render errors still panic
+	assert.Panics(t, func() {
+		RenderChild(&panicWidget{}, flow, Focused, app)
+	})
+}
+
+type panicWidget struct {
+	xWidget
+}
+
+func (w *panicWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	panic("boom")
+}
+
+func TestSuspendNotOwned1(t *testing.T) {
+	app, _ := newTestApp(t, 20, 4)
+	called := false
+	err := app.Suspend(func() error {
+		called = true
+		return nil
+	})
+	assert.Equal(t, AppScreenNotOwnedErr, err)
+	assert.False(t, called)
+	assert.NotNil(t, app.GetScreen())
+}
+
+func TestSuspend1(t *testing.T) {
+	screens := simulateOwnedScreens(t)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	app, err := NewApp(AppArgs{
+		View: &xWidget{},
+		Log:  logger,
+	})
+	assert.NoError(t, err)
+	defer app.Close()
+	assert.Equal(t, 1, len(*screens))
+	first := app.GetScreen()
+	assert.Equal(t, (*screens)[0], first)
+
+	fnErr := fmt.Errorf("editor failed")
+	called := false
+	err = app.Suspend(func() error {
+		// The screen is stopped while the function runs
+		called = true
+		assert.Nil(t, app.GetScreen())
+		cols, rows := first.Size()
+		assert.Equal(t, 0, cols*rows)
+		return fnErr
+	})
+	assert.True(t, called)
+	assert.Equal(t, fnErr, err)
+
+	// Resumed on a new screen, with the view redrawn
+	assert.Equal(t, 2, len(*screens))
+	assert.Equal(t, (*screens)[1], app.GetScreen())
+	cols, _ := app.GetScreen().Size()
+	assert.Equal(t, strings.Repeat("x", cols), strings.Split(screenString((*screens)[1]), "\n")[0])
+
+	err = app.Suspend(func() error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(*screens))
+}
+
+func TestRecorder1(t *testing.T) {
+	app, _ := newTestApp(t, 3, 2)
+	var buf bytes.Buffer
+	rec := NewCastRecorder(&buf)
+	clock := time.Unix(1000, 0)
+	rec.now = func() time.Time { return clock }
+	app.SetRecorder(rec)
+
+	app.RedrawTerminal()
+	clock = clock.Add(time.Second)
+	app.RedrawTerminal() // same frame, not recorded
+	clock = clock.Add(time.Second)
+	app.SetSubWidget(&fillWidget{r: 'y'}, app)
+	app.RedrawTerminal()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, `{"version":2,"width":3,"height":2,"timestamp":1000}`, lines[0])
+	assert.Equal(t, `[0,"o","\u001b[H\u001b[0mxxx\r\nxxx\u001b[0m\u001b[?25l"]`, lines[1])
+	// The time the duplicate frame was on screen isn't lost
+	assert.True(t, strings.HasPrefix(lines[2], `[2,"o",`), lines[2])
+}
+
+// typingWidget remembers the keys typed, and displays them.
+type typingWidget struct {
+	xWidget
+	typed []rune
+}
+
+func (w *typingWidget) Selectable() bool {
+	return true
+}
+
+func (w *typingWidget) UserInput(ev interface{}, size IRenderSize, focus Selector, app IApp) bool {
+	if evk, ok := ev.(*tcell.EventKey); ok && evk.Key() == tcell.KeyRune {
+		w.typed = append(w.typed, evk.Rune())
+		return true
+	}
+	return false
+}
+
+func (w *typingWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	box := size.(IRenderBox)
+	res := NewCanvasOfSize(box.BoxColumns(), box.BoxRows())
+	for i, r := range w.typed {
+		if i < res.BoxColumns() {
+			res.SetCellAt(i, 0, CellFromRune(r))
+		}
+	}
+	return res
+}
+
+func TestInputRecorder1(t *testing.T) {
+	app, _ := newTestApp(t, 5, 2)
+	w := &typingWidget{}
+	app.SetSubWidget(w, app)
+
+	var buf bytes.Buffer
+	rec := NewInputRecorder(&buf)
+	clock := time.Unix(1000, 0)
+	rec.now = func() time.Time { return clock }
+	app.SetInputRecorder(rec)
+
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone), IgnoreUnhandledInput)
+	clock = clock.Add(500 * time.Millisecond)
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModAlt), IgnoreUnhandledInput)
+	app.HandleTCellEvent(tcell.NewEventMouse(2, 1, tcell.Button1, tcell.ModNone), IgnoreUnhandledInput)
+	app.HandleTCellEvent(tcell.NewEventInterrupt(nil), IgnoreUnhandledInput) // not recorded
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		`{"t":0,"type":"resize","width":5,"height":2}`,
+		`{"t":0,"type":"key","key":256,"rune":97}`,
+		`{"t":0.5,"type":"key","key":256,"rune":98,"mod":4}`,
+		`{"t":0.5,"type":"mouse","x":2,"y":1,"buttons":1}`,
+	}, lines)
+	assert.Equal(t, "ab", string(w.typed))
+
+	// Replay against a fresh app, with a different size
+	app2, screen2 := newTestApp(t, 3, 3)
+	w2 := &typingWidget{}
+	app2.SetSubWidget(w2, app2)
+	err := app2.ReplayInput(strings.NewReader(buf.String()), IgnoreUnhandledInput)
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", string(w2.typed))
+	cols, rows := screen2.Size()
+	assert.Equal(t, 5, cols)
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, "ab   \n     ", screenString(screen2))
+
+	err = app2.ReplayInput(strings.NewReader(`{"t":0,"type":"bogus"}`), IgnoreUnhandledInput)
+	assert.Equal(t, UnknownInputEventType{Type: "bogus"}, err)
+	_, err = ReadInputEvents(strings.NewReader("{"))
+	assert.Error(t, err)
+}
+
+type panicInputWidget struct {
+	typingWidget
+}
+
+func (w *panicInputWidget) UserInput(ev interface{}, size IRenderSize, focus Selector, app IApp) bool {
+	return w.typed[len(w.typed)] == 'x'
+}
+
+func TestRecoverPanic1(t *testing.T) {
+	app, _ := newTestApp(t, 5, 2)
+	app.SetSubWidget(&panicInputWidget{}, app)
+	app.crashReport = filepath.Join(t.TempDir(), "crash.txt")
+
+	var report *PanicReport
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				report = app.MakePanicReport(r)
+			}
+		}()
+		app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), IgnoreUnhandledInput)
+	}()
+
+	assert.NotNil(t, report)
+	assert.Equal(t, "handling *tcell.EventKey", report.Activity)
+	assert.Equal(t, []string{"gowid.(*panicInputWidget).UserInput"}, report.WidgetPath)
+	assert.Contains(t, report.String(), "gowid: panic while handling *tcell.EventKey: runtime error: index out of range")
+	assert.Contains(t, report.String(), "app_test.go")
+
+	var buf bytes.Buffer
+	app.handlePanic(report, &buf)
+	assert.Contains(t, buf.String(), "Crash report written to "+app.crashReport)
+	data, err := ioutil.ReadFile(app.crashReport)
+	assert.NoError(t, err)
+	assert.Equal(t, report.String(), string(data))
+
+	// The terminal has been restored; closing again has no effect
+	app.Close()
+}
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	line := level.String() + " " + msg
+	for _, f := range fields {
+		line += " " + f.Name
+	}
+	l.lines = append(l.lines, line)
+}
+
+func TestLogger1(t *testing.T) {
+	app, _ := newTestApp(t, 10, 2)
+	logger := &testLogger{}
+	app.SetLogger(logger)
+	assert.Equal(t, ILogger(logger), LoggerOf(app))
+
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone), IgnoreUnhandledInput)
+	app.HandleTCellEvent(tcell.NewEventInterrupt(nil), IgnoreUnhandledInput)
+	assert.Equal(t, []string{"debug Input was not handled event", "info Interrupt event from tcell event"}, logger.lines)
+
+	// Without field support, StdLogger formats the fields itself
+	var buf bytes.Buffer
+	StdLogger{stdlog.New(&buf, "", 0)}.Log(LogWarn, "oops", LogField{Name: "n", Val: 3})
+	assert.Equal(t, "warning: oops n=3\n", buf.String())
+
+	assert.Equal(t, DefaultLogger, LoggerOf(nil))
+}
+
+//======================================================================
+
+type testClipboard struct {
+	text string
+}
+
+func (c *testClipboard) WriteClipboard(text string, app IApp) error {
+	c.text = text
+	return nil
+}
+
+func TestClipboard1(t *testing.T) {
+	var buf bytes.Buffer
+	err := OSC52Clipboard{Writer: &buf}.WriteClipboard("hello", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x1b]52;c;aGVsbG8=\x07", buf.String())
+
+	app, _ := newTestApp(t, 3, 2)
+	cb := &testClipboard{}
+	app.SetClipboard(cb)
+	assert.NoError(t, CopyToClipboard("foo", app))
+	assert.Equal(t, "foo", cb.text)
+
+	err = ExecClipboard{Command: "gowid-no-such-command"}.WriteClipboard("foo", app)
+	assert.Error(t, err)
+}
+
+func TestCanvasToANSIUnderline1(t *testing.T) {
+	c := NewCanvasOfSizeExt(2, 1, CellFromRune('x'))
+	red := MakeTCellColorExt(tcell.ColorRed)
+	c.SetCellAt(1, 0, CellFromRune('y').WithStyle(StyleCurlyUnderline).WithUnderlineColor(red))
+	assert.Equal(t, "\x1b[H\x1b[0mx\x1b[0;4:3;58;5;9my\x1b[0m\x1b[?25l", string(CanvasToANSI(c, nil)))
+
+	st := MakeCellStyleExt(ColorNone, ColorNone, red, StyleDoubleUnderline)
+	assert.Equal(t, tcell.StyleDefault.Underline(tcell.UnderlineStyleDouble, tcell.ColorRed), st)
+}
+
+func TestCanvasToANSIHyperlink1(t *testing.T) {
+	c := NewCanvasOfSizeExt(3, 1, CellFromRune('x'))
+	c.SetCellAt(1, 0, CellFromRune('y').WithHyperlink(Hyperlink{URL: "http://a", ID: "1"}))
+	assert.Equal(t, "\x1b[H\x1b[0mx\x1b]8;id=1;http://a\x1b\\y\x1b]8;;\x1b\\x\x1b[0m\x1b[?25l",
+		string(CanvasToANSI(c, nil)))
+}
+
+// exportWidget renders "a<b" on each of two rows, with the b styled from the palette's
+// "hi" entry. The last cell is 'f' if the widget is rendered with the focus.
+type exportWidget struct {
+	xWidget
+}
+
+func (w *exportWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	cols := size.(IColumns).Columns()
+	rows := 2
+	if box, ok := size.(IRenderBox); ok {
+		rows = box.BoxRows()
+	}
+	res := NewCanvasOfSizeExt(cols, rows, CellFromRune(' '))
+	fg, bg, st := MakePaletteRef("hi").GetStyle(app)
+	for y := 0; y < rows; y++ {
+		res.SetCellAt(0, y, CellFromRune('a'))
+		res.SetCellAt(1, y, CellFromRune('<'))
+		res.SetCellAt(2, y, MakeCell('b', ColorToTCellIn(fg, ColorNone, app), ColorToTCellIn(bg, ColorNone, app), st))
+	}
+	if focus.Focus {
+		res.SetCellAt(cols-1, rows-1, CellFromRune('f'))
+	}
+	return res
+}
+
+func TestRenderToString1(t *testing.T) {
+	w := &exportWidget{}
+	palette := Palette{
+		"hi": MakeStyledPaletteEntry(ColorRed, MakeRGBColor("#00f"), StyleBold),
+	}
+	assert.Equal(t, "a<b \na<b ", RenderToString(w, 4, Mode256Colors))
+	assert.Equal(t, "a<b \na<b \na<bf", RenderToString(w, 4, Mode256Colors, ExportOptions{Rows: 3, Focused: true}))
+
+	assert.Equal(t, "\x1b[0ma<\x1b[0;1;91;104mb\x1b[0m \x1b[0m",
+		RenderToANSI(w, 4, Mode256Colors, ExportOptions{Rows: 1, Palette: palette}))
+	assert.Equal(t, "\x1b[0ma<\x1b[0;1;91;48;2;0;0;240mb\x1b[0m \x1b[0m",
+		RenderToANSI(w, 4, Mode24BitColors, ExportOptions{Rows: 1, Palette: palette}))
+
+	assert.Equal(t, `<pre class="gowid">a&lt;<span style="color:#ff0000;background-color:#0000f0;font-weight:bold">b</span> </pre>`,
+		RenderToHTML(w, 4, Mode24BitColors, ExportOptions{Rows: 1, Palette: palette}))
+
+	// The default palette entry is layered under every cell
+	palette["default"] = MakePaletteEntry(NoColor{}, MakeRGBColor("#fff"))
+	palette["hi"] = MakeStyledAs(StyleItalic.MergeUnder(StyleUnderline))
+	assert.Equal(t, `<pre class="gowid"><span style="background-color:#f0f0f0">a&lt;</span>`+
+		`<span style="background-color:#f0f0f0;font-style:italic;text-decoration:underline">b</span>`+
+		`<span style="background-color:#f0f0f0"> </span></pre>`,
+		RenderToHTML(w, 4, Mode24BitColors, ExportOptions{Rows: 1, Palette: palette}))
+}
+
+type paletteWidget struct {
+	xWidget
+	name string
+}
+
+func (w *paletteWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	MakePaletteRef(w.name).GetStyle(app)
+	return w.xWidget.Render(size, focus, app)
+}
+
+func TestMissingPaletteRefs1(t *testing.T) {
+	app, _ := newTestApp(t, 2, 1)
+	app.IPalette = Palette{"there": MakePaletteEntry(NoColor{}, NoColor{})}
+
+	app.SetSubWidget(&paletteWidget{name: "there"}, app)
+	assert.Equal(t, []string{}, app.MissingPaletteRefs())
+
+	app.SetSubWidget(&paletteWidget{name: "gone"}, app)
+	assert.Equal(t, []string{"gone"}, app.MissingPaletteRefs())
+}
+
+func TestPages1(t *testing.T) {
+	app, screen := newTestApp(t, 2, 1)
+	base := app.SubWidget()
+	a, b := &fillWidget{r: 'a'}, &fillWidget{r: 'b'}
+
+	changes := make([]PageChange, 0)
+	app.OnPageChange(MakeWidgetCallbackExt("test", func(app IApp, w IWidget, data ...interface{}) {
+		changes = append(changes, data[0].(PageChange))
+	}))
+
+	_, ok := app.PopPage()
+	assert.False(t, ok)
+
+	app.PushPage(a)
+	app.RedrawTerminal()
+	assert.Equal(t, "aa", screenString(screen))
+	assert.Equal(t, 1, app.PageDepth())
+
+	app.ReplacePage(b)
+	app.RedrawTerminal()
+	assert.Equal(t, "bb", screenString(screen))
+	assert.Equal(t, 1, app.PageDepth())
+
+	w, ok := app.PopPage()
+	assert.True(t, ok)
+	assert.Equal(t, b, w)
+	assert.Equal(t, base, app.SubWidget())
+	app.RedrawTerminal()
+	assert.Equal(t, "xx", screenString(screen))
+
+	assert.Equal(t, []PageChange{
+		{Transition: PagePushed, From: base, To: a},
+		{Transition: PageReplaced, From: a, To: b},
+		{Transition: PagePopped, From: b, To: base},
+	}, changes)
+}
+
+type fakeMenu struct {
+	IWidget
+}
+
+func (w *fakeMenu) SubWidget() IWidget {
+	return w.IWidget
+}
+
+func (w *fakeMenu) SetSubWidget(widget IWidget, app IApp) {
+	w.IWidget = widget
+}
+
+func TestUnregisterMenu1(t *testing.T) {
+	app, _ := newTestApp(t, 20, 4)
+	view := app.root()
+
+	m1, m2 := &fakeMenu{}, &fakeMenu{}
+	app.RegisterMenu(m1)
+	app.RegisterMenu(m2)
+	assert.Equal(t, IWidget(m2), app.root())
+	assert.Equal(t, IWidget(m1), m2.SubWidget())
+
+	assert.True(t, app.UnregisterMenu(m1))
+	assert.Equal(t, IWidget(m2), app.root())
+	assert.Equal(t, view, m2.SubWidget())
+	assert.False(t, app.UnregisterMenu(m1))
+
+	assert.True(t, app.UnregisterMenu(m2))
+	assert.Equal(t, view, app.root())
+}
+
+func TestParseAccelerator1(t *testing.T) {
+	for _, c := range []struct {
+		label string
+		plain string
+		pos   int
+		key   rune
+	}{
+		{"&File", "File", 0, 'f'},
+		{"Save &As", "Save As", 5, 'a'},
+		{"Plain", "Plain", -1, 0},
+		{"Fish && &Chips", "Fish & Chips", 7, 'c'},
+		{"Trailing&", "Trailing&", -1, 0},
+	} {
+		plain, pos, key := ParseAccelerator(c.label)
+		assert.Equal(t, c.plain, plain, c.label)
+		assert.Equal(t, c.pos, pos, c.label)
+		assert.Equal(t, c.key, key, c.label)
+	}
+}
+
+type accelWidget struct {
+	fillWidget
+	key    rune
+	clicks int
+}
+
+func (w *accelWidget) Click(app IApp) {
+	w.clicks++
+}
+
+func (w *accelWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	res := w.fillWidget.Render(size, focus, app)
+	TrackAccelerator(w.key, w, res, app)
+	return res
+}
+
+func TestAccelerator1(t *testing.T) {
+	app, _ := newTestApp(t, 20, 4)
+	shown := &accelWidget{fillWidget: fillWidget{r: 's'}, key: 's'}
+	hidden := &accelWidget{fillWidget: fillWidget{r: 'h'}, key: 'h'}
+	app.SetSubWidget(shown, app)
+	app.RedrawTerminal()
+
+	altKey := func(r rune) {
+		app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModAlt), IgnoreUnhandledInput)
+	}
+	altKey('s')
+	altKey('S')
+	assert.Equal(t, 2, shown.clicks)
+
+	// Without alt, or for a widget not drawn, nothing happens
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone), IgnoreUnhandledInput)
+	altKey('h')
+	assert.Equal(t, 2, shown.clicks)
+	assert.Equal(t, 0, hidden.clicks)
+
+	app.SetSubWidget(hidden, app)
+	app.RedrawTerminal()
+	altKey('h')
+	altKey('s')
+	assert.Equal(t, 1, hidden.clicks)
+	assert.Equal(t, 2, shown.clicks)
+}
+
+type cursorStyleWidget struct {
+	fillWidget
+	style tcell.CursorStyle
+}
+
+func (w *cursorStyleWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	res := w.fillWidget.Render(size, focus, app)
+	TrackCursorStyle(w.style, res, app)
+	return res
+}
+
+func TestCursorStyle1(t *testing.T) {
+	app, _ := newTestApp(t, 20, 4)
+	assert.Equal(t, tcell.CursorStyleDefault, app.CursorStyle())
+
+	app.SetSubWidget(&cursorStyleWidget{fillWidget: fillWidget{r: 'b'}, style: tcell.CursorStyleSteadyBar}, app)
+	app.RedrawTerminal()
+	assert.Equal(t, tcell.CursorStyleSteadyBar, app.CursorStyle())
+	app.RedrawTerminal()
+	assert.Equal(t, tcell.CursorStyleSteadyBar, app.CursorStyle())
+
+	// Once no widget asks for a style, the default is restored
+	app.SetSubWidget(&fillWidget{r: 'f'}, app)
+	app.RedrawTerminal()
+	assert.Equal(t, tcell.CursorStyleDefault, app.CursorStyle())
+}
+
+// countingWidget counts the times it is rendered.
+type countingWidget struct {
+	xWidget
+	renders int
+}
+
+func (w *countingWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	w.renders++
+	return w.xWidget.Render(size, focus, app)
+}
+
+func TestFrames1(t *testing.T) {
+	app, _ := newTestApp(t, 3, 1)
+	w := &countingWidget{}
+	app.SetSubWidget(w, app)
+
+	// Redraws requested together are drawn once
+	for i := 0; i < 5; i++ {
+		app.Redraw()
+	}
+	app.Quit()
+	app.handleEvents(IgnoreUnhandledInput)
+	assert.Equal(t, 1, w.renders)
+
+	// Animation frames are spaced by the frame rate limit
+	app, _ = newTestApp(t, 3, 1)
+	app.SetMaxFPS(100)
+	assert.Equal(t, 100, app.MaxFPS())
+	times := make([]time.Time, 0)
+	var animate AnimationFrameFunc
+	animate = func(now time.Time, app IApp) {
+		times = append(times, now)
+		if len(times) < 3 {
+			RequestAnimationFrame(app, animate)
+		} else {
+			app.Quit()
+		}
+	}
+	canceled := RequestAnimationFrame(app, func(now time.Time, app IApp) {
+		assert.Fail(t, "canceled frame was called")
+	})
+	CancelAnimationFrame(app, canceled)
+	RequestAnimationFrame(app, animate)
+	app.handleEvents(IgnoreUnhandledInput)
+	assert.Equal(t, 3, len(times))
+	for i := 1; i < len(times); i++ {
+		assert.True(t, times[i].Sub(times[i-1]) >= 10*time.Millisecond)
+	}
+}
+
+func TestIdle1(t *testing.T) {
+	app, _ := newTestApp(t, 3, 1)
+	w := &countingWidget{}
+	app.SetSubWidget(w, app)
+
+	done := make([]string, 0)
+	OnIdle(app, func(app IApp) {
+		// Idle work is done after the frame is drawn
+		assert.Equal(t, 1, w.renders)
+		done = append(done, "first")
+		OnIdle(app, func(app IApp) {
+			done = append(done, "more")
+		})
+	})
+	OnIdle(app, func(app IApp) {
+		done = append(done, "second")
+	})
+	app.Redraw()
+	app.Quit()
+	app.handleEvents(IgnoreUnhandledInput)
+	assert.Equal(t, []string{"first", "second", "more"}, done)
+	assert.False(t, app.RunIdle())
+}
+
+type inputWidget struct {
+	fillWidget
+	keys   int
+	clicks []CanvasPos
+}
+
+func (w *inputWidget) Selectable() bool {
+	return true
+}
+
+func (w *inputWidget) UserInput(ev interface{}, size IRenderSize, focus Selector, app IApp) bool {
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		w.keys++
+		return true
+	case *tcell.EventMouse:
+		if ev.Buttons() == tcell.Button1 {
+			x, y := ev.Position()
+			w.clicks = append(w.clicks, CanvasPos{X: x, Y: y})
+			return true
+		}
+	}
+	return false
+}
+
+func TestRoots1(t *testing.T) {
+	app, screen := newTestApp(t, 4, 3)
+	main := &inputWidget{fillWidget: fillWidget{r: 'm'}}
+	bar := &inputWidget{fillWidget: fillWidget{r: 'b'}}
+	app.SetSubWidget(main, app)
+	r := app.AddRoot(bar, PlaceBottomRows(1))
+	app.RedrawTerminal()
+	assert.Equal(t, "mmmm\nmmmm\nbbbb", screenString(screen))
+	assert.Equal(t, Rect{Y: 2, W: 4, H: 1}, r.Rect())
+
+	// The view is rendered only in its own region
+	app.SetViewPlacement(PlaceInset(0, 0, 1, 1))
+	app.RedrawTerminal()
+	assert.Equal(t, " mmm\n mmm\nbbbb", screenString(screen))
+
+	key := tcell.NewEventKey(tcell.KeyRune, 'k', tcell.ModNone)
+	app.HandleTCellEvent(key, IgnoreUnhandledInput)
+	assert.Equal(t, 1, main.keys)
+
+	// Clicks are translated to the region under the pointer, which gets the focus
+	click := func(x, y int) {
+		app.HandleTCellEvent(tcell.NewEventMouse(x, y, tcell.Button1, 0), IgnoreUnhandledInput)
+		app.HandleTCellEvent(tcell.NewEventMouse(x, y, tcell.ButtonNone, 0), IgnoreUnhandledInput)
+	}
+	click(3, 2)
+	assert.Equal(t, []CanvasPos{{X: 3, Y: 0}}, bar.clicks)
+	assert.Equal(t, r, app.FocusRoot())
+	app.HandleTCellEvent(key, IgnoreUnhandledInput)
+	assert.Equal(t, 1, main.keys)
+	assert.Equal(t, 1, bar.keys)
+
+	click(2, 1)
+	assert.Equal(t, []CanvasPos{{X: 1, Y: 1}}, main.clicks)
+	assert.Nil(t, app.FocusRoot())
+
+	// Outside every region
+	click(0, 0)
+	assert.Equal(t, 1, len(main.clicks))
+
+	app.SetFocusRoot(r)
+	assert.True(t, app.RemoveRoot(r))
+	assert.False(t, app.RemoveRoot(r))
+	assert.Nil(t, app.FocusRoot())
+	app.SetViewPlacement(nil)
+	app.RedrawTerminal()
+	assert.Equal(t, "mmmm\nmmmm\nmmmm", screenString(screen))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,135 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+	"sync"
+)
+
+//======================================================================
+
+// PropertyChangedCB is the name under which property change callbacks are
+// registered.
+type PropertyChangedCB struct{}
+
+// Property is an observable value. It can be read and set from any goroutine.
+// Callbacks registered with OnChange run on the goroutine that calls Set, so
+// to update widgets, use Bind, which moves the update onto the widget rendering
+// goroutine.
+type Property[T any] struct {
+	mtx       sync.Mutex
+	value     T
+	callbacks *Callbacks
+}
+
+func NewProperty[T any](value T) *Property[T] {
+	return &Property[T]{
+		value:     value,
+		callbacks: NewCallbacks(),
+	}
+}
+
+func (p *Property[T]) String() string {
+	return fmt.Sprintf("property[%v]", p.Get())
+}
+
+// Get returns the property's current value.
+func (p *Property[T]) Get() T {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.value
+}
+
+// Set changes the property's value and runs the OnChange callbacks with the old and
+// new values.
+func (p *Property[T]) Set(value T) {
+	p.mtx.Lock()
+	old := p.value
+	p.value = value
+	p.mtx.Unlock()
+	p.callbacks.RunCallbacks(PropertyChangedCB{}, old, value)
+}
+
+// Update sets the property to the result of fn applied to its current value. No
+// other Set or Update can intervene - useful for e.g. incrementing a counter from
+// several goroutines.
+func (p *Property[T]) Update(fn func(T) T) {
+	p.mtx.Lock()
+	old := p.value
+	p.value = fn(old)
+	value := p.value
+	p.mtx.Unlock()
+	p.callbacks.RunCallbacks(PropertyChangedCB{}, old, value)
+}
+
+// OnChange registers fn to be called with the old and new values whenever the
+// property is set. The name identifies the callback for RemoveOnChange.
+func (p *Property[T]) OnChange(name interface{}, fn func(old, new T)) {
+	p.callbacks.AddCallback(PropertyChangedCB{}, Callback{
+		Name: name,
+		CallbackFunction: func(args ...interface{}) {
+			fn(args[0].(T), args[1].(T))
+		},
+	})
+}
+
+func (p *Property[T]) RemoveOnChange(name IIdentity) {
+	p.callbacks.RemoveCallback(PropertyChangedCB{}, name)
+}
+
+//======================================================================
+
+// Binding connects a property to a function that applies its value - typically to
+// a widget. Unbind disconnects it.
+type Binding struct {
+	unbind func()
+	done   bool
+}
+
+// NewBinding returns a binding whose Unbind calls unbind - for example, to combine
+// bindings in both directions into one.
+func NewBinding(unbind func()) *Binding {
+	return &Binding{unbind: unbind}
+}
+
+// Unbind stops the property's changes being applied. Call it from the widget rendering
+// goroutine - a change already scheduled is then not applied.
+func (b *Binding) Unbind() {
+	b.done = true
+	b.unbind()
+}
+
+type bindingID struct {
+	*Binding
+}
+
+// Bind calls apply with the property's value now, and again on the widget rendering
+// goroutine, followed by a redraw, whenever the property changes. Call Bind itself
+// from the rendering goroutine, or before the app's main loop starts. Changes are
+// applied in order; apply always receives the latest value, so a burst of changes
+// from a worker goroutine may be applied as one.
+func Bind[T any](app IApp, p *Property[T], apply func(app IApp, value T)) *Binding {
+	res := &Binding{}
+	id := CallbackID{Name: bindingID{res}}
+	res.unbind = func() {
+		p.RemoveOnChange(id)
+	}
+	apply(app, p.Get())
+	p.OnChange(id.Name, func(old, new T) {
+		app.Run(RunFunction(func(app IApp) {
+			if !res.done {
+				apply(app, p.Get())
+			}
+		}))
+	})
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,65 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package gowid
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestProperty1(t *testing.T) {
+	p := NewProperty(1)
+	changes := make([][2]int, 0)
+	p.OnChange("test", func(old, new int) {
+		changes = append(changes, [2]int{old, new})
+	})
+	p.Set(2)
+	p.Update(func(v int) int { return v * 10 })
+	assert.Equal(t, 20, p.Get())
+	assert.Equal(t, [][2]int{{1, 2}, {2, 20}}, changes)
+
+	p.RemoveOnChange(CallbackID{Name: "test"})
+	p.Set(3)
+	assert.Equal(t, 2, len(changes))
+}
+
+func TestBind1(t *testing.T) {
+	app, _ := newTestApp(t, 2, 1)
+	p := NewProperty("a")
+	applied := make([]string, 0)
+	b := Bind(app, p, func(app IApp, v string) {
+		applied = append(applied, v)
+	})
+	assert.Equal(t, []string{"a"}, applied)
+
+	// Set from another goroutine - applied only when the app runs the event
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		p.Set("b")
+		wg.Done()
+	}()
+	wg.Wait()
+	assert.Equal(t, []string{"a"}, applied)
+	app.RunThenRenderEvent(<-app.AfterRenderEvents)
+	assert.Equal(t, []string{"a", "b"}, applied)
+
+	// A change scheduled before unbinding is dropped
+	p.Set("c")
+	b.Unbind()
+	app.RunThenRenderEvent(<-app.AfterRenderEvents)
+	p.Set("d")
+	assert.Equal(t, 0, len(app.AfterRenderEvents))
+	assert.Equal(t, []string{"a", "b"}, applied)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
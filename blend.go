@@ -0,0 +1,131 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+//======================================================================
+
+// BlendMode determines how the colors of an upper layer combine with those of the
+// layer underneath when cells are merged.
+type BlendMode int
+
+const (
+	// BlendReplace uses the upper cell's colors where they are set - the behavior of
+	// Cell.MergeUnder.
+	BlendReplace BlendMode = iota
+	// BlendAlpha mixes the upper cell's colors, where set, with the lower cell's. Alpha
+	// is the weight of the upper color.
+	BlendAlpha
+	// BlendDarken mixes the merged colors with black. Alpha is the weight of black.
+	BlendDarken
+	// BlendLighten mixes the merged colors with white. Alpha is the weight of white.
+	BlendLighten
+)
+
+// Blend describes how colors are combined when one layer is merged over another. The
+// zero value is BlendReplace. Colors can only be mixed if they have RGB values, and
+// the result is displayed in 24-bit or 256-color modes; otherwise, or if a color is
+// "none" or the terminal default, blending falls back to BlendReplace. This lets e.g.
+// the widgets behind a modal dialog be dimmed without choosing darker colors for them.
+type Blend struct {
+	Mode  BlendMode
+	Alpha float64 // from 0 to 1
+}
+
+var (
+	blendBlack = MakeRGBColorExt(0, 0, 0)
+	blendWhite = MakeRGBColorExt(0xff, 0xff, 0xff)
+)
+
+// IsReplace returns true if the blend doesn't mix colors, so merging with it is the
+// same as merging with Cell.MergeUnder.
+func (b Blend) IsReplace() bool {
+	return b.Mode == BlendReplace
+}
+
+// Color returns the color displayed when a cell with color upper is merged over one
+// with color lower.
+func (b Blend) Color(lower, upper TCellColor, mode ColorMode) TCellColor {
+	res := lower
+	if upper != ColorNone {
+		res = upper
+	}
+	if mode != Mode24BitColors && mode != Mode256Colors {
+		return res
+	}
+	switch b.Mode {
+	case BlendAlpha:
+		if upper == ColorNone {
+			return lower
+		}
+		if _, ok := colorfulOf(lower); !ok {
+			return upper
+		}
+		if _, ok := colorfulOf(upper); !ok {
+			return upper
+		}
+		return IColorToTCell(Lerp(lower, upper, b.Alpha), res, mode)
+	case BlendDarken, BlendLighten:
+		if _, ok := colorfulOf(res); !ok {
+			return res
+		}
+		var to IColor = blendBlack
+		if b.Mode == BlendLighten {
+			to = blendWhite
+		}
+		return IColorToTCell(Lerp(res, to, b.Alpha), res, mode)
+	default:
+		return res
+	}
+}
+
+// MergeFunc returns a CellMergeFunc that merges cells with fn - e.g. Cell.MergeUnder -
+// then replaces the foreground and background colors with those determined by the
+// blend, for display in the supplied color mode.
+func (b Blend) MergeFunc(fn CellMergeFunc, mode ColorMode) CellMergeFunc {
+	if b.IsReplace() {
+		return fn
+	}
+	return func(lower, upper Cell) Cell {
+		res := fn(lower, upper)
+		lfg, lbg, _ := lower.GetDisplayAttrs()
+		ufg, ubg, _ := upper.GetDisplayAttrs()
+		res.fg = b.Color(lfg, ufg, mode)
+		res.bg = b.Color(lbg, ubg, mode)
+		return res
+	}
+}
+
+// BlendUnder returns a Cell representing the receiver merged "underneath" the Cell
+// argument, like MergeUnder, but with colors combined according to the blend.
+func (c Cell) BlendUnder(upper Cell, b Blend, mode ColorMode) Cell {
+	return b.MergeFunc(Cell.MergeUnder, mode)(c, upper)
+}
+
+// BlendCanvas blends every cell of the canvas with an empty cell, in place. With
+// BlendDarken or BlendLighten this dims or lightens the whole canvas; the other modes
+// leave it unchanged.
+func BlendCanvas(c ICanvas, b Blend, mode ColorMode) {
+	if b.IsReplace() {
+		return
+	}
+	fn := b.MergeFunc(Cell.MergeUnder, mode)
+	for y := 0; y < c.BoxRows(); y++ {
+		for x := 0; x < c.BoxColumns(); x++ {
+			c.SetCellAt(x, y, fn(c.CellAt(x, y), Cell{}))
+		}
+	}
+}
+
+// BlendUnder merges the supplied Canvas "under" the receiver Canvas, like MergeUnder,
+// but with colors combined according to the blend.
+func (c *Canvas) BlendUnder(c2 IMergeCanvas, leftOffset, topOffset int, b Blend, mode ColorMode, bottomGetsCursor bool) {
+	c.MergeWithFunc(c2, leftOffset, topOffset, b.MergeFunc(Cell.MergeUnder, mode), bottomGetsCursor)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
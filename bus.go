@@ -0,0 +1,158 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+	"sync"
+)
+
+//======================================================================
+
+// Topic identifies a kind of application event, e.g. "capture started", whose data
+// has type T. Each topic made by NewTopic is distinct, even if two share a name, so
+// packages can't collide by accident.
+type Topic[T any] struct {
+	name string
+}
+
+func NewTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{name: name}
+}
+
+func (t *Topic[T]) String() string {
+	return fmt.Sprintf("topic[%s]", t.name)
+}
+
+// IEventBus is implemented by an App that can carry events between widgets.
+type IEventBus interface {
+	EventBus() *EventBus
+}
+
+// NoEventBusErr is returned by Publish if the app has no event bus.
+var NoEventBusErr = fmt.Errorf("App has no event bus.")
+
+// EventBus delivers events published on a topic to the topic's subscribers, so that
+// widgets far apart in the hierarchy can react to the same domain events without
+// threading callbacks through every container in between. Events can be published from
+// any goroutine; subscribers are always called on the widget rendering goroutine, in
+// the order the events were published, and the app is redrawn afterwards.
+type EventBus struct {
+	mtx  sync.Mutex
+	run  func(IAfterRenderEvent) error
+	subs map[interface{}][]*Subscription
+}
+
+// NewEventBus returns a bus that delivers events by sending functions to run. An App
+// makes its own, with its Run method.
+func NewEventBus(run func(IAfterRenderEvent) error) *EventBus {
+	return &EventBus{
+		run:  run,
+		subs: make(map[interface{}][]*Subscription),
+	}
+}
+
+// Subscription is returned by Subscribe. Unsubscribe stops its deliveries.
+type Subscription struct {
+	bus   *EventBus
+	topic interface{}
+	fn    func(app IApp, data interface{})
+	done  bool
+}
+
+// Unsubscribe stops deliveries to the subscriber, including those of events published
+// but not yet delivered. It can be called from any goroutine.
+func (s *Subscription) Unsubscribe() {
+	if s.bus == nil {
+		return
+	}
+	s.bus.mtx.Lock()
+	defer s.bus.mtx.Unlock()
+	s.done = true
+	subs := s.bus.subs[s.topic]
+	for i, sub := range subs {
+		if sub == s {
+			s.bus.subs[s.topic] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(s.bus.subs[s.topic]) == 0 {
+		delete(s.bus.subs, s.topic)
+	}
+}
+
+func (s *Subscription) active() bool {
+	s.bus.mtx.Lock()
+	defer s.bus.mtx.Unlock()
+	return !s.done
+}
+
+// HasSubscribers returns true if anything is subscribed to the topic.
+func (b *EventBus) HasSubscribers(topic interface{}) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return len(b.subs[topic]) > 0
+}
+
+func (b *EventBus) subscribe(topic interface{}, fn func(app IApp, data interface{})) *Subscription {
+	res := &Subscription{bus: b, topic: topic, fn: fn}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.subs[topic] = append(b.subs[topic], res)
+	return res
+}
+
+func (b *EventBus) publish(topic interface{}, data interface{}) error {
+	b.mtx.Lock()
+	subs := append([]*Subscription(nil), b.subs[topic]...)
+	b.mtx.Unlock()
+	if len(subs) == 0 {
+		return nil
+	}
+	return b.run(RunFunction(func(app IApp) {
+		for _, sub := range subs {
+			if sub.active() {
+				sub.fn(app, data)
+			}
+		}
+	}))
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// EventBus returns the app's event bus.
+func (a *App) EventBus() *EventBus {
+	return a.bus
+}
+
+// Subscribe calls fn on the widget rendering goroutine with the data of each event
+// published on the topic from now on. If the app has no event bus, fn is never called.
+func Subscribe[T any](app IApp, topic *Topic[T], fn func(app IApp, data T)) *Subscription {
+	eb, ok := app.(IEventBus)
+	if !ok {
+		return &Subscription{}
+	}
+	return eb.EventBus().subscribe(topic, func(app IApp, data interface{}) {
+		fn(app, data.(T))
+	})
+}
+
+// Publish sends an event on the topic to its current subscribers. It can be called from
+// any goroutine and doesn't wait for the event to be delivered - even on the rendering
+// goroutine, so a subscriber can publish without recursion. It returns AppClosingErr if
+// the app is shutting down.
+func Publish[T any](app IApp, topic *Topic[T], data T) error {
+	eb, ok := app.(IEventBus)
+	if !ok {
+		return NoEventBusErr
+	}
+	return eb.EventBus().publish(topic, data)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,67 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestEventBus1(t *testing.T) {
+	app, _ := newTestApp(t, 2, 1)
+	started := NewTopic[string]("capture started")
+	stopped := NewTopic[int]("capture stopped")
+
+	got := make([]string, 0)
+	sub := Subscribe(app, started, func(app IApp, iface string) {
+		got = append(got, iface)
+	})
+	Subscribe(app, stopped, func(app IApp, packets int) {
+		got = append(got, "stopped")
+		// Publishing from a subscriber is queued, not delivered recursively
+		assert.NoError(t, Publish(app, started, "again"))
+	})
+	assert.True(t, app.EventBus().HasSubscribers(started))
+
+	// Published from another goroutine - delivered only when the app runs the event
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		assert.NoError(t, Publish(app, started, "eth0"))
+		wg.Done()
+	}()
+	wg.Wait()
+	assert.Equal(t, []string{}, got)
+	app.RunThenRenderEvent(<-app.AfterRenderEvents)
+	assert.Equal(t, []string{"eth0"}, got)
+
+	assert.NoError(t, Publish(app, stopped, 10))
+	app.RunThenRenderEvent(<-app.AfterRenderEvents)
+	assert.Equal(t, []string{"eth0", "stopped"}, got)
+	app.RunThenRenderEvent(<-app.AfterRenderEvents)
+	assert.Equal(t, []string{"eth0", "stopped", "again"}, got)
+
+	// An event published before unsubscribing isn't delivered
+	assert.NoError(t, Publish(app, started, "lo"))
+	sub.Unsubscribe()
+	assert.False(t, app.EventBus().HasSubscribers(started))
+	app.RunThenRenderEvent(<-app.AfterRenderEvents)
+	assert.NoError(t, Publish(app, started, "eth1"))
+	assert.Equal(t, 0, len(app.AfterRenderEvents))
+	assert.Equal(t, []string{"eth0", "stopped", "again"}, got)
+
+	// A topic with the same name is a different topic
+	assert.False(t, app.EventBus().HasSubscribers(NewTopic[int]("capture stopped")))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -12,8 +12,8 @@ import (
 
 	"github.com/gcla/gowid/gwutil"
 	tcell "github.com/gdamore/tcell/v2"
-	"github.com/mattn/go-runewidth"
 	"github.com/pkg/errors"
+	"github.com/rivo/uniseg"
 )
 
 //======================================================================
@@ -71,6 +71,9 @@ type ICanvas interface {
 	AppendRight(c IMergeCanvas, useCursor bool)
 	SetCellAt(col, row int, c Cell)
 	SetLineAt(row int, line []Cell)
+	SetLineRange(col, row int, cells []Cell)
+	FillRect(r Rect, cell Cell)
+	CopyRows(src IAppendCanvas, srcRow, col, row, rows int)
 	Truncate(above, below int)
 	ExtendRight(cells []Cell)
 	ExtendLeft(cells []Cell)
@@ -260,6 +263,9 @@ type Canvas struct {
 	Lines  [][]Cell // inner array is a line
 	Marks  *map[string]CanvasPos
 	maxCol int
+
+	pool   *CanvasPool // where pooled lines come from, and return to
+	pooled []*[]Cell   // lines taken from the pool, returned by Release
 }
 
 // NewCanvas returns an initialized Canvas struct. Its size is 0 columns and
@@ -395,29 +401,44 @@ func WriteToCanvas(c IRangeOverCanvas, p []byte) (n int, err error) {
 	maxcol := c.BoxColumns()
 	line := 0
 	col := 0
-	for i, chr := range string(p) {
-		if c.BoxRows() > line {
-			switch chr {
-			case '\n':
-				for col < maxcol {
-					c.SetCellAt(col, line, Cell{})
-					col++
-				}
-				line++
+	state := -1
+	rest := string(p)
+loop:
+	for len(rest) > 0 && c.BoxRows() > line {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		chr, size := utf8.DecodeRuneInString(cluster)
+		if chr < ' ' && size < len(cluster) {
+			// Don't combine control characters e.g. \r\n
+			rest = cluster[size:] + rest
+			cluster = cluster[:size]
+			state = -1
+		}
+		switch chr {
+		case '\n':
+			for col < maxcol {
+				c.SetCellAt(col, line, Cell{})
+				col++
+			}
+			line++
+			col = 0
+		default:
+			var comb []rune
+			if size < len(cluster) {
+				comb = []rune(cluster[size:])
+			}
+			wid := CellFromRune(chr).WithCombining(comb).Width()
+			if col+wid > maxcol {
 				col = 0
-			default:
-				wid := runewidth.RuneWidth(chr)
-				if col+wid > maxcol {
-					col = 0
-					line++
+				line++
+				if c.BoxRows() <= line {
+					break loop
 				}
-				c.SetCellAt(col, line, c.CellAt(col, line).WithRune(chr))
-				col += wid
 			}
-			done = i + utf8.RuneLen(chr)
-		} else {
-			break
+			c.SetCellAt(col, line, c.CellAt(col, line).WithRune(chr).WithCombining(comb))
+			col += wid
 		}
+		done = len(p) - len(rest)
 	}
 	return done, nil
 }
@@ -517,6 +538,61 @@ func (c *Canvas) SetLineAt(row int, line []Cell) {
 	c.Lines[row] = line
 }
 
+// SetLineRange copies cells into the given row of the Canvas, starting at column col.
+// Unlike SetLineAt, the Canvas keeps its own line. Cells that would fall outside the
+// Canvas are skipped.
+func (c *Canvas) SetLineRange(col, row int, cells []Cell) {
+	if row < 0 || row >= len(c.Lines) {
+		return
+	}
+	if col < 0 {
+		if -col >= len(cells) {
+			return
+		}
+		cells = cells[-col:]
+		col = 0
+	}
+	if col < len(c.Lines[row]) {
+		copy(c.Lines[row][col:], cells)
+	}
+}
+
+// FillRect sets every Cell of the Canvas inside r to cell. The part of r outside the
+// Canvas is ignored.
+func (c *Canvas) FillRect(r Rect, cell Cell) {
+	r = r.Intersect(Rect{W: c.BoxColumns(), H: c.BoxRows()})
+	for y := r.Y; y < r.Y+r.H; y++ {
+		line := c.Lines[y][r.X:gwutil.Min(r.X+r.W, len(c.Lines[y]))]
+		for x := range line {
+			line[x] = cell
+		}
+	}
+}
+
+// CopyRows copies rows rows of src, starting at row srcRow, into the Canvas so that
+// the first Cell copied lands at column col and row row. Rows that don't exist in src,
+// and Cells that would fall outside the Canvas, are skipped. src may be the receiver,
+// in which case the rows can overlap.
+func (c *Canvas) CopyRows(src IAppendCanvas, srcRow, col, row, rows int) {
+	copyRow := func(i int) {
+		if srcRow+i >= 0 && srcRow+i < src.BoxRows() {
+			line := src.Line(srcRow+i, LineCopy{}).Line
+			c.SetLineRange(col, row+i, line[:gwutil.Min(len(line), src.BoxColumns())])
+		}
+	}
+	// Copy from the bottom up when moving rows down, so a row isn't overwritten
+	// before it's copied
+	if row > srcRow {
+		for i := rows - 1; i >= 0; i-- {
+			copyRow(i)
+		}
+	} else {
+		for i := 0; i < rows; i++ {
+			copyRow(i)
+		}
+	}
+}
+
 // AppendLine will append the array of Cells provided to the bottom of
 // the receiver Canvas. If the makeCopy argument is true, a copy is made
 // of the provided Cell array; otherwise, a slice is taken and used
@@ -550,13 +626,12 @@ func CanvasToString(c ICanvas) string {
 	lineStrings := make([]string, c.BoxRows())
 	for i := 0; i < c.BoxRows(); i++ {
 		line := c.Line(i, LineCopy{}).Line
-		curLine := make([]rune, 0)
+		var curLine strings.Builder
 		for x := 0; x < len(line); {
-			r := line[x].Rune()
-			curLine = append(curLine, r)
-			x += runewidth.RuneWidth(r)
+			curLine.WriteString(line[x].Grapheme())
+			x += gwutil.Max(line[x].Width(), 1)
 		}
-		lineStrings[i] = string(curLine)
+		lineStrings[i] = curLine.String()
 	}
 	return strings.Join(lineStrings, "\n")
 }
@@ -618,6 +693,14 @@ func (c *Canvas) AppendBelow(c2 IAppendCanvas, doCursor bool, makeCopy bool) {
 		}
 	}
 	c.AlignRight()
+	if gc, ok := c2.(*Canvas); ok && !makeCopy && gc != c {
+		// The lines now belong to c, so are released with it
+		if c.pool == nil {
+			c.pool = gc.pool
+		}
+		c.pooled = append(c.pooled, gc.pooled...)
+		gc.pooled = nil
+	}
 	c2.RangeOverMarks(func(k string, pos CanvasPos) bool {
 		if doCursor || (k != "cursor") {
 			if c.Marks == nil {
@@ -659,12 +742,23 @@ type CellMergeFunc func(lower, upper Cell) Cell
 // which rune, and so on.
 func (c *Canvas) MergeWithFunc(c2 IMergeCanvas, leftOffset, topOffset int, fn CellMergeFunc, bottomGetsCursor bool) {
 	c2w := c2.BoxColumns()
+	lr, isLineReader := c2.(ICanvasLineReader)
 	for i := 0; i < c2.BoxRows(); i++ {
 		if i+topOffset < len(c.Lines) {
 			cl := len(c.Lines[i+topOffset])
+			var c2line []Cell
+			if isLineReader {
+				// Fetch the whole line once rather than each cell
+				c2line = lr.Line(i, LineCopy{}).Line
+			}
 			for j := 0; j < c2w; j++ {
 				if j+leftOffset < cl {
-					c2ij := c2.CellAt(j, i)
+					var c2ij Cell
+					if j < len(c2line) {
+						c2ij = c2line[j]
+					} else {
+						c2ij = c2.CellAt(j, i)
+					}
 					c.Lines[i+topOffset][j+leftOffset] = fn(c.Lines[i+topOffset][j+leftOffset], c2ij)
 				} else {
 					break
@@ -701,14 +795,26 @@ func (c *Canvas) AppendRight(c2 IMergeCanvas, useCursor bool) {
 	c2w := c2.BoxColumns()
 	for y := 0; y < c2.BoxRows(); y++ {
 		if cap(c.Lines[y]) < len(c.Lines[y])+c2w {
-			widerLine := make([]Cell, len(c.Lines[y])+c2w)
+			var widerLine []Cell
+			if c.pool != nil {
+				p := c.pool.getLine(len(c.Lines[y]) + c2w)
+				widerLine = *p
+				c.pooled = append(c.pooled, p)
+			} else {
+				widerLine = make([]Cell, len(c.Lines[y])+c2w)
+			}
 			copy(widerLine, c.Lines[y])
 			c.Lines[y] = widerLine
 		} else {
 			c.Lines[y] = c.Lines[y][0 : len(c.Lines[y])+c2w]
 		}
-		for x := 0; x < c2w; x++ {
-			c.Lines[y][x+m] = c2.CellAt(x, y)
+		dst := c.Lines[y][m : m+c2w]
+		if lr, ok := c2.(ICanvasLineReader); ok {
+			copy(dst, lr.Line(y, LineCopy{}).Line)
+		} else {
+			for x := range dst {
+				dst[x] = c2.CellAt(x, y)
+			}
 		}
 	}
 
@@ -810,9 +916,15 @@ func Draw(canvas IDrawCanvas, mode IColorMode, screen tcell.Screen) {
 		for x := 0; x < len(vline); {
 			c := vline[x]
 			f, b, s := c.ForegroundColor(), c.BackgroundColor(), c.Style()
-			st := MakeCellStyle(f, b, s)
-			screen.SetContent(x, y, c.Rune(), nil, st)
-			x += runewidth.RuneWidth(c.Rune())
+			st := MakeCellStyleExt(f, b, c.UnderlineColor(), s)
+			if link, ok := c.Hyperlink(); ok {
+				st = st.Url(link.URL)
+				if link.ID != "" {
+					st = st.UrlId(link.ID)
+				}
+			}
+			screen.SetContent(x, y, c.Rune(), c.Combining(), st)
+			x += gwutil.Max(c.Width(), 1)
 
 			if x == cpos.X && y == cpos.Y {
 				screen.ShowCursor(x, y)
@@ -0,0 +1,180 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"sync"
+
+	"github.com/gcla/gowid/gwutil"
+)
+
+//======================================================================
+
+// CanvasPool holds the lines of released canvases for reuse, so that each frame can
+// reuse the memory of the last rather than leaving it for the garbage collector. An App
+// has one if it's made with AppArgs.CanvasPooling, or SetCanvasPooling is called - other
+// apps, and canvases not made from a pool, are unaffected. Canvases made with the pool's
+// NewCanvasOfSize take their lines from it; containers release the canvases of their
+// children once they have copied them, as does the app once a frame is drawn, and the
+// lines go back to the pool. A canvas that has lines from a pool moved into it by
+// AppendBelow takes its wider lines, from AppendRight, from the same pool.
+//
+// A widget that keeps a canvas it renders, or returns the same canvas each time it's
+// rendered, must call Retain on it if the canvas might hold lines from a pool - otherwise
+// the canvas may be released, and its lines reused, while the widget still holds it.
+type CanvasPool struct {
+	lines [lineClasses]sync.Pool
+}
+
+// NewCanvasPool returns an empty pool.
+func NewCanvasPool() *CanvasPool {
+	return &CanvasPool{}
+}
+
+// ICanvasPooler is implemented by apps that may have a canvas pool - see CanvasPool.
+type ICanvasPooler interface {
+	CanvasPool() *CanvasPool
+}
+
+// CanvasPoolOf returns the app's canvas pool, or nil if it has none. The result can be
+// used either way, e.g. CanvasPoolOf(app).NewCanvasOfSize(cols, rows).
+func CanvasPoolOf(app IApp) *CanvasPool {
+	if p, ok := app.(ICanvasPooler); ok {
+		return p.CanvasPool()
+	}
+	return nil
+}
+
+// CanvasPool returns the app's canvas pool, or nil if canvas pooling is off.
+func (a *App) CanvasPool() *CanvasPool {
+	return a.canvasPool
+}
+
+// SetCanvasPooling turns canvas pooling on or off for this app only - see CanvasPool. It
+// is off unless the app was made with AppArgs.CanvasPooling.
+func (a *App) SetCanvasPooling(enabled bool) {
+	switch {
+	case enabled && a.canvasPool == nil:
+		a.canvasPool = NewCanvasPool()
+	case !enabled:
+		a.canvasPool = nil
+	}
+}
+
+//======================================================================
+
+// Lines are pooled by capacity, in powers of two from minPooledLine to maxPooledLine.
+// Longer lines are allocated as usual.
+const (
+	minPooledLine = 16
+	lineClasses   = 10
+	maxPooledLine = minPooledLine << (lineClasses - 1)
+)
+
+// lineClass returns the index of the pool for lines of capacity at least n.
+func lineClass(n int) int {
+	i, c := 0, minPooledLine
+	for c < n {
+		i++
+		c <<= 1
+	}
+	return i
+}
+
+// NewCanvasOfSize is like gowid.NewCanvasOfSize, but the canvas's lines come from the
+// pool. If p is nil, it's just gowid.NewCanvasOfSize.
+func (p *CanvasPool) NewCanvasOfSize(cols, rows int) *Canvas {
+	return p.NewCanvasOfSizeExt(cols, rows, Cell{})
+}
+
+// NewCanvasOfSizeExt is like gowid.NewCanvasOfSizeExt, but the canvas's lines come from
+// the pool. If p is nil, it's just gowid.NewCanvasOfSizeExt.
+func (p *CanvasPool) NewCanvasOfSizeExt(cols, rows int, fill Cell) *Canvas {
+	if p == nil {
+		return NewCanvasOfSizeExt(cols, rows, fill)
+	}
+	res := &Canvas{
+		Lines:  make([][]Cell, rows, gwutil.Max(rows, 120)),
+		pool:   p,
+		pooled: make([]*[]Cell, rows),
+	}
+	for i := 0; i < rows; i++ {
+		l := p.getLine(cols)
+		line := *l
+		for j := range line {
+			line[j] = fill
+		}
+		res.Lines[i] = line
+		res.pooled[i] = l
+	}
+	if rows > 0 {
+		res.maxCol = cols
+	}
+	return res
+}
+
+// getLine returns a line of n cells from the pool, or newly allocated if there are none
+// free. The cells may hold the values of a previous use. The line is returned by pointer
+// so it can be put back in the pool without allocating.
+func (p *CanvasPool) getLine(n int) *[]Cell {
+	if n > maxPooledLine {
+		line := make([]Cell, n)
+		return &line
+	}
+	i := lineClass(n)
+	if l, ok := p.lines[i].Get().(*[]Cell); ok {
+		*l = (*l)[:n]
+		return l
+	}
+	line := make([]Cell, n, minPooledLine<<i)
+	return &line
+}
+
+// putLine returns a line from getLine to the pool.
+func (p *CanvasPool) putLine(l *[]Cell) {
+	c := cap(*l)
+	if c < minPooledLine || c > maxPooledLine {
+		return
+	}
+	p.lines[lineClass(c)].Put(l)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// Release returns the lines the canvas took from a pool for reuse - see CanvasPool. Neither the canvas nor lines taken from it can be used afterwards. Lines moved
+// into the canvas from another with AppendBelow are released too.
+func (c *Canvas) Release() {
+	if len(c.pooled) == 0 {
+		return
+	}
+	for _, line := range c.pooled {
+		c.pool.putLine(line)
+	}
+	c.pooled = nil
+	c.Lines = nil
+	c.Marks = nil
+	c.maxCol = 0
+}
+
+// Retain stops the canvas's lines being released, so it can be kept beyond the frame it
+// was rendered for - see CanvasPool.
+func (c *Canvas) Retain() {
+	c.pooled = nil
+}
+
+// ReleaseCanvas releases c, a child's canvas whose cells have been copied elsewhere, if
+// it's a Canvas - see CanvasPool. Other canvas types, which may hold state beyond
+// a frame, are left alone.
+func ReleaseCanvas(c ICanvas) {
+	if gc, ok := c.(*Canvas); ok {
+		gc.Release()
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -8,6 +8,7 @@ import (
 	"io"
 	"testing"
 
+	tcell "github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,6 +77,59 @@ func TestCanvas19(t *testing.T) {
 	assert.Equal(t, "12 \nR2 ", canvas.String())
 }
 
+func TestCanvasGrapheme1(t *testing.T) {
+	canvas := NewCanvasOfSize(4, 2)
+	n, err := canvas.Write([]byte("e\u0301x\U0001F469\u200d\U0001F4BBy"))
+	assert.NoError(t, err)
+	assert.Equal(t, 16, n)
+	assert.Equal(t, 'e', canvas.CellAt(0, 0).Rune())
+	assert.Equal(t, []rune{'\u0301'}, canvas.CellAt(0, 0).Combining())
+	assert.Equal(t, 1, canvas.CellAt(0, 0).Width())
+	assert.Equal(t, []rune{'\u200d', '\U0001F4BB'}, canvas.CellAt(2, 0).Combining())
+	assert.Equal(t, 2, canvas.CellAt(2, 0).Width())
+	assert.Equal(t, "e\u0301x\U0001F469\u200d\U0001F4BB\ny   ", canvas.String())
+
+	c := canvas.CellAt(0, 0).MergeUnder(CellFromRune('z'))
+	assert.Equal(t, 'z', c.Rune())
+	assert.Nil(t, c.Combining())
+	c = CellFromRune('z').MergeUnder(canvas.CellAt(0, 0))
+	assert.Equal(t, "e\u0301", c.Grapheme())
+}
+
+func TestBlend1(t *testing.T) {
+	red := MakeTCellColorExt(tcell.NewRGBColor(200, 0, 0))
+	blue := MakeTCellColorExt(tcell.NewRGBColor(0, 0, 200))
+	lower := MakeCell('x', red, blue, StyleNone)
+
+	// Replace behaves like MergeUnder
+	upper := MakeCell(0, ColorNone, red, StyleNone)
+	assert.Equal(t, lower.MergeUnder(upper), lower.BlendUnder(upper, Blend{}, Mode24BitColors))
+
+	c := lower.BlendUnder(upper, Blend{Mode: BlendAlpha, Alpha: 0.5}, Mode24BitColors)
+	assert.Equal(t, 'x', c.Rune())
+	assert.Equal(t, red, c.ForegroundColor())
+	r, g, b := c.BackgroundColor().ToTCell().RGB()
+	assert.True(t, r > 0 && r < 200 && b > 0 && b < 200, "got %d,%d,%d", r, g, b)
+
+	c = lower.BlendUnder(Cell{}, Blend{Mode: BlendDarken, Alpha: 0.5}, Mode24BitColors)
+	r, _, _ = c.ForegroundColor().ToTCell().RGB()
+	assert.True(t, r > 0 && r < 200)
+	c = lower.BlendUnder(Cell{}, Blend{Mode: BlendLighten, Alpha: 1}, Mode24BitColors)
+	r, g, b = c.BackgroundColor().ToTCell().RGB()
+	assert.True(t, r > 250 && g > 250 && b > 250, "got %d,%d,%d", r, g, b)
+
+	// No RGB value to mix, or a color mode that can't display the mix
+	assert.Equal(t, ColorDefault, Blend{Mode: BlendDarken, Alpha: 0.5}.Color(ColorDefault, ColorNone, Mode24BitColors))
+	assert.Equal(t, red, Blend{Mode: BlendAlpha, Alpha: 0.5}.Color(ColorDefault, red, Mode24BitColors))
+	assert.Equal(t, red, Blend{Mode: BlendAlpha, Alpha: 0.5}.Color(blue, red, Mode16Colors))
+
+	canvas := NewCanvasOfSize(2, 1)
+	canvas.SetCellAt(0, 0, lower)
+	BlendCanvas(canvas, Blend{Mode: BlendDarken, Alpha: 1}, Mode24BitColors)
+	assert.Equal(t, MakeTCellColorExt(tcell.NewRGBColor(0, 0, 0)), canvas.CellAt(0, 0).BackgroundColor())
+	assert.Equal(t, ColorNone, canvas.CellAt(1, 0).BackgroundColor())
+}
+
 type MyString string
 
 func (s MyString) Tester() int {
@@ -95,6 +149,150 @@ func TestCanvas1(t *testing.T) {
 	assert.Equal(t, f.Tester(), 3)
 }
 
+func TestCanvasPool1(t *testing.T) {
+	p := NewCanvasPool()
+
+	c := p.NewCanvasOfSizeExt(5, 2, CellFromRune('x'))
+	assert.Equal(t, 5, c.BoxColumns())
+	assert.Equal(t, 2, c.BoxRows())
+	assert.Equal(t, "xxxxx\nxxxxx", c.String())
+
+	// Lines appended below move, with their ownership, into c
+	c2 := p.NewCanvasOfSizeExt(5, 1, CellFromRune('y'))
+	c.AppendBelow(c2, false, false)
+	assert.Equal(t, "xxxxx\nxxxxx\nyyyyy", c.String())
+	assert.Equal(t, 3, len(c.pooled))
+	assert.Equal(t, 0, len(c2.pooled))
+
+	// Only lines from the pool are released
+	c.AppendBelow(LineCanvas(CellsFromString("zzzzz")), false, false)
+	assert.Equal(t, 3, len(c.pooled))
+
+	c.Release()
+	assert.Equal(t, 0, c.BoxRows())
+	assert.Equal(t, 0, c.BoxColumns())
+
+	// A retained canvas keeps its lines
+	c3 := p.NewCanvasOfSizeExt(3, 1, CellFromRune('r'))
+	c3.Retain()
+	ReleaseCanvas(c3)
+	assert.Equal(t, "rrr", c3.String())
+
+	// A canvas that takes pooled lines from a child widens from the same pool
+	c5 := NewCanvas()
+	c5.AppendBelow(p.NewCanvasOfSize(3, 1), false, false)
+	c5.AppendRight(NewCanvasOfSize(20, 1), false)
+	assert.Equal(t, 2, len(c5.pooled))
+
+	// Canvases not made from a pool are never released
+	c4 := NewCanvasOfSizeExt(3, 1, CellFromRune('k'))
+	ReleaseCanvas(c4)
+	assert.Equal(t, "kkk", c4.String())
+	var nilPool *CanvasPool
+	assert.Equal(t, 0, len(nilPool.NewCanvasOfSize(3, 1).pooled))
+}
+
+func TestCanvasPool2(t *testing.T) {
+	// Pooling is per app - one app turning it on doesn't affect another
+	app1, _ := newTestApp(t, 4, 2)
+	app1.SetCanvasPooling(true)
+	app2, _ := newTestApp(t, 4, 2)
+	assert.NotNil(t, CanvasPoolOf(app1))
+	assert.Nil(t, CanvasPoolOf(app2))
+	assert.Equal(t, 1, len(CanvasPoolOf(app1).NewCanvasOfSize(2, 1).pooled))
+	assert.Equal(t, 0, len(CanvasPoolOf(app2).NewCanvasOfSize(2, 1).pooled))
+
+	app1.SetCanvasPooling(false)
+	assert.Nil(t, CanvasPoolOf(app1))
+}
+
+// composeCanvases builds a screen-sized canvas from columns of smaller ones, as a
+// columns widget of piles would, then releases it as the app does once it's drawn.
+func composeCanvases(p *CanvasPool, cols, rows, n int) {
+	res := NewCanvas()
+	for i := 0; i < n; i++ {
+		col := NewCanvas()
+		for j := 0; j < rows; j++ {
+			col.AppendBelow(p.NewCanvasOfSize(cols/n, 1), false, false)
+		}
+		if i == 0 {
+			res = col
+		} else {
+			res.AppendRight(col, false)
+			ReleaseCanvas(col)
+		}
+	}
+	ReleaseCanvas(res)
+}
+
+func benchmarkCompose(b *testing.B, pooling bool) {
+	var p *CanvasPool
+	if pooling {
+		p = NewCanvasPool()
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		composeCanvases(p, 200, 60, 4)
+	}
+}
+
+func BenchmarkCompose(b *testing.B) {
+	benchmarkCompose(b, false)
+}
+
+func BenchmarkComposePooled(b *testing.B) {
+	benchmarkCompose(b, true)
+}
+
+func TestCanvasBulk1(t *testing.T) {
+	c := NewCanvasOfSizeExt(5, 3, CellFromRune('.'))
+
+	c.SetLineRange(3, 0, CellsFromString("abc"))
+	c.SetLineRange(-1, 1, CellsFromString("xyz"))
+	c.SetLineRange(0, 5, CellsFromString("q"))
+	assert.Equal(t, "...ab\nyz...\n.....", c.String())
+
+	c.FillRect(Rect{X: 2, Y: 1, W: 10, H: 10}, CellFromRune('#'))
+	assert.Equal(t, "...ab\nyz###\n..###", c.String())
+
+	src := NewCanvasWithLines([][]Cell{CellsFromString("12"), CellsFromString("34")})
+	c.CopyRows(src, 0, 4, 1, 5)
+	assert.Equal(t, "...ab\nyz##1\n..##3", c.String())
+
+	// Moving rows within a canvas, in both directions
+	c.CopyRows(c, 0, 0, 1, 2)
+	assert.Equal(t, "...ab\n...ab\nyz##1", c.String())
+	c.CopyRows(c, 1, 0, 0, 2)
+	assert.Equal(t, "...ab\nyz##1\nyz##1", c.String())
+}
+
+func TestCanvasAppendRight1(t *testing.T) {
+	c := NewCanvasWithLines([][]Cell{CellsFromString("ab"), CellsFromString("cd")})
+	c.AppendRight(NewCanvasWithLines([][]Cell{CellsFromString("e"), CellsFromString("f")}), false)
+	c.MergeUnder(NewCanvasWithLines([][]Cell{CellsFromString("X")}), 1, 1, false)
+	assert.Equal(t, "abe\ncXf", c.String())
+}
+
+func benchmarkMerge(b *testing.B, merge func(c, c2 *Canvas)) {
+	c2 := NewCanvasOfSizeExt(100, 60, CellFromRune('x'))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		merge(NewCanvasOfSize(100, 60), c2)
+	}
+}
+
+func BenchmarkMergeUnder(b *testing.B) {
+	benchmarkMerge(b, func(c, c2 *Canvas) {
+		c.MergeUnder(c2, 0, 0, false)
+	})
+}
+
+func BenchmarkAppendRight(b *testing.B) {
+	benchmarkMerge(b, func(c, c2 *Canvas) {
+		c.AppendRight(c2, false)
+	})
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
@@ -4,6 +4,10 @@
 
 package gowid
 
+import (
+	"github.com/mattn/go-runewidth"
+)
+
 //======================================================================
 
 // Cell represents a single element of terminal output. The empty value
@@ -13,9 +17,20 @@ package gowid
 // Cell is instantiated.
 type Cell struct {
 	codePoint rune
+	comb      string // combining runes following codePoint in the same grapheme cluster
 	fg        TCellColor
 	bg        TCellColor
+	ul        TCellColor
 	style     StyleAttrs
+	link      *Hyperlink
+}
+
+// Hyperlink is the target of a terminal hyperlink, which supporting terminals let
+// the user open e.g. by clicking. Cells with the same non-empty ID are treated as
+// one link even if they are not adjacent, e.g. if the link is wrapped across lines.
+type Hyperlink struct {
+	URL string
+	ID  string
 }
 
 // MakeCell returns a Cell initialized with the supplied run (char to display),
@@ -40,6 +55,7 @@ func (c Cell) MergeUnder(upper Cell) Cell {
 	res := c
 	if upper.codePoint != 0 {
 		res.codePoint = upper.codePoint
+		res.comb = upper.comb
 	}
 	return res.MergeDisplayAttrsUnder(upper)
 }
@@ -55,6 +71,12 @@ func (c Cell) MergeDisplayAttrsUnder(upper Cell) Cell {
 	if ufg != ColorNone {
 		res = res.WithForegroundColor(ufg)
 	}
+	if upper.ul != ColorNone {
+		res.ul = upper.ul
+	}
+	if upper.link != nil {
+		res.link = upper.link
+	}
 	res.style = res.style.MergeUnder(ust)
 	return res
 }
@@ -86,12 +108,44 @@ func (c Cell) Rune() rune {
 }
 
 // WithRune returns a Cell equal to the receiver Cell but that will render the supplied
-// rune instead.
+// rune instead. Any combining runes are removed.
 func (c Cell) WithRune(r rune) Cell {
 	c.codePoint = r
+	c.comb = ""
 	return c
 }
 
+// Combining returns the runes that combine with the Cell's rune to make a single
+// grapheme cluster, e.g. accents, or the rest of an emoji ZWJ sequence. It returns nil
+// if there are none.
+func (c Cell) Combining() []rune {
+	if c.comb == "" {
+		return nil
+	}
+	return []rune(c.comb)
+}
+
+// WithCombining returns a Cell equal to the receiver Cell but whose rune is combined
+// with the supplied runes when rendered.
+func (c Cell) WithCombining(comb []rune) Cell {
+	c.comb = string(comb)
+	return c
+}
+
+// Grapheme returns the Cell's rune, as returned by Rune(), followed by its combining runes.
+func (c Cell) Grapheme() string {
+	return string(c.Rune()) + c.comb
+}
+
+// Width returns the number of screen columns the Cell's grapheme cluster occupies. This
+// is usually 1, 2 for wide runes, and can be 0 for a lone combining rune.
+func (c Cell) Width() int {
+	if c.comb == "" {
+		return runewidth.RuneWidth(c.Rune())
+	}
+	return runewidth.StringWidth(c.Grapheme())
+}
+
 // BackgroundColor returns the background color of the receiver Cell.
 func (c Cell) BackgroundColor() TCellColor {
 	return c.bg
@@ -102,6 +156,20 @@ func (c Cell) ForegroundColor() TCellColor {
 	return c.fg
 }
 
+// UnderlineColor returns the color of the receiver Cell's underline. ColorNone means the
+// underline, if any, is drawn in the foreground color.
+func (c Cell) UnderlineColor() TCellColor {
+	return c.ul
+}
+
+// Hyperlink returns the hyperlink of the receiver Cell, and false if it has none.
+func (c Cell) Hyperlink() (Hyperlink, bool) {
+	if c.link == nil {
+		return Hyperlink{}, false
+	}
+	return *c.link, true
+}
+
 // Style returns the style of the receiver Cell.
 func (c Cell) Style() StyleAttrs {
 	return c.style
@@ -111,6 +179,7 @@ func (c Cell) Style() StyleAttrs {
 // rune instead i.e. it is "empty".
 func (c Cell) WithNoRune() Cell {
 	c.codePoint = 0
+	c.comb = ""
 	return c
 }
 
@@ -132,6 +201,24 @@ func (c Cell) WithForegroundColor(a TCellColor) Cell {
 	return c
 }
 
+// WithUnderlineColor returns a Cell equal to the receiver Cell but whose underline,
+// if it has one, will be drawn in the supplied color. Not all terminals support this.
+func (c Cell) WithUnderlineColor(a TCellColor) Cell {
+	c.ul = a
+	return c
+}
+
+// WithHyperlink returns a Cell equal to the receiver Cell but that links to
+// the supplied target. A link with an empty URL removes the Cell's hyperlink.
+func (c Cell) WithHyperlink(link Hyperlink) Cell {
+	if link.URL == "" {
+		c.link = nil
+	} else {
+		c.link = &link
+	}
+	return c
+}
+
 // WithStyle returns a Cell equal to the receiver Cell but that will render
 // with the supplied style (e.g. underline) instead. Note that this style
 // can be set to "none" by passing the value gowid.AttrNone, meaning allow
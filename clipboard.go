@@ -0,0 +1,163 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+//======================================================================
+
+// ErrNoClipboard is returned when text is copied but no clipboard is available.
+var ErrNoClipboard = errors.New("no clipboard is available")
+
+// IClipboardWriter is implemented by types that can place text on the system
+// clipboard. The app is provided so that a writer can use the terminal, e.g. to
+// emit an OSC 52 escape sequence.
+type IClipboardWriter interface {
+	WriteClipboard(text string, app IApp) error
+}
+
+// IClipboardService is implemented by an App that can place text on the system
+// clipboard. See CopyToClipboard.
+type IClipboardService interface {
+	CopyToClipboard(text string) error
+}
+
+// CopyToClipboard places text on the system clipboard using app's clipboard service.
+// It returns ErrNoClipboard if app does not provide one.
+func CopyToClipboard(text string, app IApp) error {
+	if cs, ok := app.(IClipboardService); ok {
+		return cs.CopyToClipboard(text)
+	}
+	return ErrNoClipboard
+}
+
+//======================================================================
+
+// OSC52Clipboard sets the clipboard by sending the OSC 52 escape sequence to the
+// terminal. This works over ssh, but not every terminal emulator supports it, and
+// some disable it by default. If Writer is nil, the sequence is sent via the app's
+// tcell screen, which only emits it if the terminal is known to be xterm-like.
+type OSC52Clipboard struct {
+	Writer io.Writer
+}
+
+var _ IClipboardWriter = OSC52Clipboard{}
+
+func (c OSC52Clipboard) String() string {
+	return "osc52"
+}
+
+func (c OSC52Clipboard) WriteClipboard(text string, app IApp) error {
+	if c.Writer == nil {
+		app.GetScreen().SetClipboard([]byte(text))
+		return nil
+	}
+	_, err := fmt.Fprintf(c.Writer, "\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+// ExecClipboard sets the clipboard by running an external command, such as xclip,
+// with the text on its standard input.
+type ExecClipboard struct {
+	Command string
+	Args    []string
+}
+
+var _ IClipboardWriter = ExecClipboard{}
+
+var (
+	// WlCopyClipboard uses wl-copy, for Wayland sessions.
+	WlCopyClipboard = ExecClipboard{Command: "wl-copy"}
+	// XClipClipboard uses xclip, for X11 sessions.
+	XClipClipboard = ExecClipboard{Command: "xclip", Args: []string{"-selection", "clipboard"}}
+	// XSelClipboard uses xsel, for X11 sessions without xclip.
+	XSelClipboard = ExecClipboard{Command: "xsel", Args: []string{"--clipboard", "--input"}}
+	// PbcopyClipboard uses pbcopy, on macOS.
+	PbcopyClipboard = ExecClipboard{Command: "pbcopy"}
+)
+
+func (c ExecClipboard) String() string {
+	return c.Command
+}
+
+// Available returns true if the command can be found in the PATH.
+func (c ExecClipboard) Available() bool {
+	_, err := exec.LookPath(c.Command)
+	return err == nil
+}
+
+func (c ExecClipboard) WriteClipboard(text string, app IApp) error {
+	cmd := exec.Command(c.Command, c.Args...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("%s: %w: %s", c.Command, err, strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("%s: %w", c.Command, err)
+	}
+	return nil
+}
+
+// DetectClipboard returns the clipboard writer best suited to the environment the
+// process is running in. A local clipboard command is preferred - wl-copy under
+// Wayland, xclip or xsel under X11, pbcopy on macOS. If none is found, or the
+// process is running over ssh, OSC 52 is used.
+func DetectClipboard() IClipboardWriter {
+	if os.Getenv("SSH_TTY") == "" {
+		candidates := make([]ExecClipboard, 0, 3)
+		switch {
+		case runtime.GOOS == "darwin":
+			candidates = append(candidates, PbcopyClipboard)
+		case os.Getenv("WAYLAND_DISPLAY") != "":
+			candidates = append(candidates, WlCopyClipboard, XClipClipboard, XSelClipboard)
+		case os.Getenv("DISPLAY") != "":
+			candidates = append(candidates, XClipClipboard, XSelClipboard)
+		}
+		for _, c := range candidates {
+			if c.Available() {
+				return c
+			}
+		}
+	}
+	return OSC52Clipboard{}
+}
+
+//======================================================================
+
+// Clipboard returns the writer used by CopyToClipboard. If none was set, one is chosen
+// with DetectClipboard the first time text is copied.
+func (a *App) Clipboard() IClipboardWriter {
+	return a.clipboard
+}
+
+// SetClipboard changes how CopyToClipboard reaches the system clipboard. If c is nil,
+// a writer is chosen with DetectClipboard on the next copy.
+func (a *App) SetClipboard(c IClipboardWriter) {
+	a.clipboard = c
+}
+
+// CopyToClipboard places text on the system clipboard - for example, the value of
+// one of the results returned by Clips().
+func (a *App) CopyToClipboard(text string) error {
+	if a.clipboard == nil {
+		a.clipboard = DetectClipboard()
+	}
+	return a.clipboard.WriteClipboard(text, a)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
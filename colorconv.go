@@ -0,0 +1,151 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+//======================================================================
+
+// ColorConverter converts colors that must be matched to the nearest of the terminal's
+// colors, like RGBColor, and caches the results. It keeps a separate cache for each color
+// mode, so apps rendering in different modes, or switching mode at runtime, don't evict or
+// invalidate each other's conversions. An App has its own converter - see AppArgs.ColorOptions -
+// and colors converted without one use DefaultColorConverter. A ColorConverter is safe to
+// use from several goroutines.
+type ColorConverter struct {
+	ignoreBase16 *bool // If nil, follow the package-level IgnoreBase16
+	size         int
+	caches       map[colorShard]*lru.Cache // Built by NewColorConverter, then only read
+}
+
+// colorShard identifies the cache for one color mode. In 256-color mode, the results
+// depend too on whether colors 0-21 are considered.
+type colorShard struct {
+	mode         ColorMode
+	ignoreBase16 bool
+}
+
+// ColorConverterOption configures a ColorConverter - see NewColorConverter.
+type ColorConverterOption func(c *ColorConverter)
+
+// IgnoreBase16Colors makes the converter skip colors 0-21 when finding the closest match in
+// 256-color mode, or not, regardless of the package-level IgnoreBase16 which a converter
+// follows by default.
+func IgnoreBase16Colors(ignore bool) ColorConverterOption {
+	return func(c *ColorConverter) {
+		c.ignoreBase16 = &ignore
+	}
+}
+
+// ColorCacheSize sets the number of conversions cached for each color mode. The default
+// is 100.
+func ColorCacheSize(n int) ColorConverterOption {
+	return func(c *ColorConverter) {
+		c.size = n
+	}
+}
+
+// DefaultColorConverter converts colors when no app's converter is available, e.g. via
+// IColor's ToTCellColor.
+var DefaultColorConverter = NewColorConverter()
+
+// NewColorConverter returns a ColorConverter configured by opts.
+func NewColorConverter(opts ...ColorConverterOption) *ColorConverter {
+	res := &ColorConverter{
+		size: 100,
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	if res.size <= 0 {
+		res.size = 100
+	}
+	shards := []colorShard{
+		{mode: Mode256Colors},
+		{mode: Mode256Colors, ignoreBase16: true},
+		{mode: Mode16Colors},
+		{mode: Mode8Colors},
+		{mode: ModeMonochrome},
+	}
+	res.caches = make(map[colorShard]*lru.Cache, len(shards))
+	for _, shard := range shards {
+		cache, err := lru.New(res.size)
+		if err != nil {
+			panic(err)
+		}
+		res.caches[shard] = cache
+	}
+	return res
+}
+
+// IgnoreBase16 returns true if the converter skips colors 0-21 in 256-color mode.
+func (c *ColorConverter) IgnoreBase16() bool {
+	if c.ignoreBase16 != nil {
+		return *c.ignoreBase16
+	}
+	return IgnoreBase16
+}
+
+// Flush discards the converter's cached conversions.
+func (c *ColorConverter) Flush() {
+	for _, cache := range c.caches {
+		cache.Purge()
+	}
+}
+
+// ToTCellColor converts color to a TCellColor for mode, using the converter for colors
+// that support it - see IConvertibleColor.
+func (c *ColorConverter) ToTCellColor(color IColor, mode ColorMode) (TCellColor, bool) {
+	if cc, ok := color.(IConvertibleColor); ok {
+		return cc.ToTCellColorWith(mode, c)
+	}
+	return color.ToTCellColor(mode)
+}
+
+// closest returns the color in corresponding at the same index as the color in from
+// closest to r, caching the result in the shard for mode.
+func (c *ColorConverter) closest(r RGBColor, mode ColorMode, ignoreBase16 bool, from []colorful.Color, corresponding []TCellColor) TCellColor {
+	return r.findClosest(from, corresponding, c.caches[colorShard{mode: mode, ignoreBase16: ignoreBase16}])
+}
+
+//======================================================================
+
+// IConvertibleColor is implemented by colors whose conversion to a TCellColor can be done,
+// and cached, by a ColorConverter.
+type IConvertibleColor interface {
+	IColor
+	ToTCellColorWith(mode ColorMode, conv *ColorConverter) (TCellColor, bool)
+}
+
+// IGetColorConverter is implemented by types, like App, that provide a ColorConverter.
+type IGetColorConverter interface {
+	ColorConverter() *ColorConverter
+}
+
+// ColorConverterOf returns the ColorConverter provided by v, if it has one, and
+// DefaultColorConverter otherwise.
+func ColorConverterOf(v interface{}) *ColorConverter {
+	if g, ok := v.(IGetColorConverter); ok {
+		if c := g.ColorConverter(); c != nil {
+			return c
+		}
+	}
+	return DefaultColorConverter
+}
+
+// ColorToTCellIn is like IColorToTCell, but converts color for the color mode of ctx -
+// usually the app, or the IRenderContext passed to GetStyle - using its ColorConverter.
+func ColorToTCellIn(color IColor, def TCellColor, ctx IColorMode) TCellColor {
+	return IColorToTCellWith(color, def, ctx.GetColorMode(), ColorConverterOf(ctx))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,93 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"sync"
+)
+
+//======================================================================
+
+// IConcurrentSafe is implemented by widgets that can say whether they may be rendered on
+// a goroutine other than the one running the app, at the same time as other widgets. A
+// widget that changes state shared with other widgets, or with the app, while rendering
+// should implement it and return false. A widget that returns true vouches for itself
+// and for every widget inside it.
+type IConcurrentSafe interface {
+	ConcurrentSafe() bool
+}
+
+// ConcurrentSafe returns true if w may be rendered concurrently with other widgets. If
+// w implements IConcurrentSafe, its answer is used. Otherwise w is safe if all the
+// widgets inside it are, as found by WalkWidgets, so e.g. a terminal wrapped in a frame
+// is still rendered on the app's goroutine. Containers that create their children on
+// demand, like lists, don't expose them to the walk - if such a container may hold
+// widgets that aren't safe, it should be wrapped in a widget that returns false.
+func ConcurrentSafe(w IWidget) bool {
+	res := true
+	WalkWidgets(w, WidgetVisitor{
+		EnterFn: func(w IWidget, depth int) WalkAction {
+			if s, ok := w.(IConcurrentSafe); ok {
+				if !s.ConcurrentSafe() {
+					res = false
+					return WalkStop
+				}
+				return WalkSkipChildren
+			}
+			return WalkContinue
+		},
+	})
+	return res
+}
+
+// RenderConcurrently renders each widget in ws with the size and focus at the same
+// index, as RenderChild would, and returns the canvases in the same order. The widgets
+// are rendered in parallel, each on its own goroutine, except for widgets for which
+// ConcurrentSafe returns false - these are rendered on the calling goroutine. If a
+// widget panics on another goroutine, the panic is handled, or propagated, on the calling
+// goroutine just as RenderChild would have - the widget isn't rendered again. Container widgets should only use this if told to by the user,
+// who knows whether the widgets inside are safe to render concurrently. The App's
+// trackers - TrackGeometry, TrackAccelerator and TrackCursorStyle - may be called from
+// widgets rendered this way.
+func RenderConcurrently(ws []IWidget, sizes []IRenderSize, focus []Selector, app IApp) []ICanvas {
+	res := make([]ICanvas, len(ws))
+	panics := make([]interface{}, len(ws))
+	serial := make([]int, 0, len(ws))
+
+	var wg sync.WaitGroup
+	for i, w := range ws {
+		if len(ws) == 1 || !ConcurrentSafe(w) {
+			serial = append(serial, i)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, w IWidget) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					panics[i] = r
+				}
+			}()
+			res[i] = w.Render(sizes[i], focus[i], app)
+		}(i, w)
+	}
+	for _, i := range serial {
+		res[i] = RenderChild(ws[i], sizes[i], focus[i], app)
+	}
+	wg.Wait()
+
+	for i := range ws {
+		if panics[i] != nil {
+			res[i] = renderPanicked(ws[i], sizes[i], focus[i], app, panics[i])
+		}
+	}
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,92 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// IForceColorMode is implemented by display filters that need colors converted for a
+// particular mode, whatever the terminal supports. While such a filter is set, the app's
+// GetColorMode returns that mode, so widgets and palettes take the same conversion paths
+// as they would on such a terminal.
+type IForceColorMode interface {
+	ForceColorMode() ColorMode
+}
+
+// ContrastFilter is a display filter - see AppArgs.DisplayFilter - that drops every
+// color, so the UI is drawn in the terminal's default colors using text styles alone. It
+// forces ModeMonochrome; check a palette with Palette.Validate(ModeMonochrome) before
+// offering the mode.
+//
+// Without colors, anything highlighted only by its background - the focus, a selection -
+// would disappear. With ReverseBackground set, a cell with a background color has its
+// reverse-video style flipped instead, and with BoldForeground set, a cell with just a
+// foreground color is drawn bold.
+type ContrastFilter struct {
+	ReverseBackground bool
+	BoldForeground    bool
+}
+
+var _ ICellProcessor = ContrastFilter{}
+var _ IForceColorMode = ContrastFilter{}
+
+// MonochromeFilter drops all colors, keeping text styles as they are.
+var MonochromeFilter = ContrastFilter{}
+
+// HighContrastFilter drops all colors, showing backgrounds as reverse video and
+// foreground colors as bold.
+var HighContrastFilter = ContrastFilter{ReverseBackground: true, BoldForeground: true}
+
+func (f ContrastFilter) ForceColorMode() ColorMode {
+	return ModeMonochrome
+}
+
+// ProcessCell implements ICellProcessor.
+func (f ContrastFilter) ProcessCell(c Cell) Cell {
+	hasFg, hasBg := isColored(c.ForegroundColor()), isColored(c.BackgroundColor())
+	st := c.Style()
+	if f.ReverseBackground && hasBg {
+		if st.Set&tcell.AttrReverse != 0 && st.OnOff&tcell.AttrReverse != 0 {
+			st = st.MergeUnder(StyleAttrs{Set: tcell.AttrReverse})
+		} else {
+			st = st.MergeUnder(StyleReverse)
+		}
+	}
+	if f.BoldForeground && hasFg && !hasBg {
+		st = st.MergeUnder(StyleBold)
+	}
+	return c.WithForegroundColor(ColorDefault).
+		WithBackgroundColor(ColorDefault).
+		WithUnderlineColor(ColorNone).
+		WithStyle(st)
+}
+
+// isColored returns true if the color is neither unset nor the terminal's default.
+func isColored(c TCellColor) bool {
+	return c.tc != nil && *c.tc != tcell.ColorDefault
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// DisplayFilter returns the filter applied to every cell before it's drawn, or nil.
+func (a *App) DisplayFilter() ICellProcessor {
+	return a.displayFilter
+}
+
+// SetDisplayFilter sets the filter applied to every cell before it's drawn - e.g.
+// HighContrastFilter, when the user asks for a high-contrast mode - or removes it if f
+// is nil. Call Redraw afterwards.
+func (a *App) SetDisplayFilter(f ICellProcessor) {
+	a.displayFilter = f
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,75 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"testing"
+
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type colorWidget struct {
+	xWidget
+	cell Cell
+}
+
+func (w *colorWidget) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	box := size.(IRenderBox)
+	return NewCanvasOfSizeExt(box.BoxColumns(), box.BoxRows(), w.cell)
+}
+
+func TestContrastFilter1(t *testing.T) {
+	red := MakeTCellColorExt(tcell.ColorRed)
+	blue := MakeTCellColorExt(tcell.ColorBlue)
+
+	fgOnly := MakeCell('x', red, ColorNone, StyleUnderline)
+	c := MonochromeFilter.ProcessCell(fgOnly)
+	assert.Equal(t, ColorDefault, c.ForegroundColor())
+	assert.Equal(t, StyleUnderline, c.Style())
+
+	c = HighContrastFilter.ProcessCell(fgOnly)
+	assert.Equal(t, StyleUnderline.MergeUnder(StyleBold), c.Style())
+	assert.Equal(t, 'x', c.Rune())
+
+	// A highlight shown by its background is reversed - or un-reversed if it was reversed
+	c = HighContrastFilter.ProcessCell(MakeCell('x', red, blue, StyleNone))
+	assert.Equal(t, StyleReverse, c.Style())
+	assert.Equal(t, ColorDefault, c.BackgroundColor())
+	c = HighContrastFilter.ProcessCell(MakeCell('x', red, blue, StyleReverse))
+	assert.Equal(t, StyleAttrs{Set: tcell.AttrReverse}, c.Style())
+
+	// Default colors aren't highlights
+	c = HighContrastFilter.ProcessCell(MakeCell('x', ColorDefault, ColorDefault, StyleNone))
+	assert.Equal(t, StyleNone, c.Style())
+}
+
+func TestContrastFilter2(t *testing.T) {
+	app, screen := newTestApp(t, 2, 1)
+	app.SetSubWidget(&colorWidget{cell: MakeCell('x', ColorNone, MakeTCellColorExt(tcell.ColorBlue), StyleNone)}, app)
+	app.SetColorMode(Mode256Colors)
+
+	app.RedrawTerminal()
+	_, _, st, _ := screen.GetContent(0, 0)
+	_, bg, _ := st.Decompose()
+	assert.Equal(t, tcell.ColorBlue, bg)
+
+	app.SetDisplayFilter(HighContrastFilter)
+	assert.Equal(t, ModeMonochrome, app.GetColorMode())
+	app.RedrawTerminal()
+	_, _, st, _ = screen.GetContent(0, 0)
+	_, bg, attrs := st.Decompose()
+	assert.Equal(t, tcell.ColorDefault, bg)
+	assert.NotZero(t, attrs&tcell.AttrReverse)
+
+	app.SetDisplayFilter(nil)
+	assert.Equal(t, Mode256Colors, app.GetColorMode())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
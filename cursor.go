@@ -0,0 +1,75 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// ICursorStyleTracker is implemented by an App that can change the shape of the
+// terminal's cursor. Widgets request a shape by calling TrackCursorStyle from their
+// Render function; the request applies only to the frame being rendered, and only if
+// the canvas reaches the screen. If nothing requests a shape, the terminal's default is
+// restored.
+type ICursorStyleTracker interface {
+	TrackCursorStyle(style tcell.CursorStyle, c ICanvas)
+	CursorStyle() tcell.CursorStyle
+}
+
+// TrackCursorStyle asks the app to draw the cursor with the given style - e.g. a bar
+// or an underline - while the canvas c, which should hold the cursor, is on the screen.
+// Widgets typically do this only when they have focus. It does nothing if the app
+// doesn't support cursor styles.
+func TrackCursorStyle(style tcell.CursorStyle, c ICanvas, app IApp) {
+	if ct, ok := app.(ICursorStyleTracker); ok {
+		ct.TrackCursorStyle(style, c)
+	}
+}
+
+const cursorStyleMarkPrefix = "gowid-cursor-style-"
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// TrackCursorStyle marks the canvas so the style is only used if the canvas reaches the
+// screen. If several widgets request a style, the one rendered last wins.
+func (a *App) TrackCursorStyle(style tcell.CursorStyle, c ICanvas) {
+	a.trackMu.Lock()
+	defer a.trackMu.Unlock()
+	c.SetMark(fmt.Sprintf("%s%d", cursorStyleMarkPrefix, len(a.cursorStylesPending)), 0, 0)
+	a.cursorStylesPending = append(a.cursorStylesPending, style)
+}
+
+// CursorStyle returns the cursor style applied to the terminal by the last frame drawn.
+func (a *App) CursorStyle() tcell.CursorStyle {
+	return a.cursorStyle
+}
+
+// collectCursorStyle determines the cursor style for the frame about to be drawn,
+// removes the marks used to find it, and applies it to the screen if it has changed.
+func (a *App) collectCursorStyle(canvas ICanvas) {
+	style := tcell.CursorStyleDefault
+	for i, s := range a.cursorStylesPending {
+		name := fmt.Sprintf("%s%d", cursorStyleMarkPrefix, i)
+		if _, ok := canvas.GetMark(name); ok {
+			style = s
+			canvas.RemoveMark(name)
+		}
+	}
+	a.cursorStylesPending = a.cursorStylesPending[:0]
+	if style != a.cursorStyle {
+		a.cursorStyle = style
+		a.GetScreen().SetCursorStyle(style)
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
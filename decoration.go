@@ -24,7 +24,15 @@ import (
 // an underline preference, so when layered, the cell is rendered with an underline.
 const (
 	StyleNoneSet tcell.AttrMask = 0 // Just unstyled text.
-	StyleAllSet  tcell.AttrMask = tcell.AttrBold | tcell.AttrBlink | tcell.AttrReverse | tcell.AttrUnderline | tcell.AttrDim
+	StyleAllSet  tcell.AttrMask = tcell.AttrBold | tcell.AttrBlink | tcell.AttrReverse | tcell.AttrUnderline | tcell.AttrDim |
+		tcell.AttrItalic | tcell.AttrStrikeThrough | AttrDoubleUnderline | AttrCurlyUnderline
+)
+
+// AttrDoubleUnderline and AttrCurlyUnderline extend tcell's attributes. tcell represents these as
+// underline styles rather than attributes, so they are translated when a cell is styled for tcell.
+const (
+	AttrDoubleUnderline tcell.AttrMask = tcell.AttrStrikeThrough << (iota + 1)
+	AttrCurlyUnderline
 )
 
 // StyleAttrs allows the user to represent a set of styles, either affirmatively set (on) or unset (off)
@@ -36,7 +44,8 @@ type StyleAttrs struct {
 }
 
 // AllStyleMasks is an array of all the styles that can be applied to a Cell.
-var AllStyleMasks = [...]tcell.AttrMask{tcell.AttrBold, tcell.AttrBlink, tcell.AttrDim, tcell.AttrReverse, tcell.AttrUnderline}
+var AllStyleMasks = [...]tcell.AttrMask{tcell.AttrBold, tcell.AttrBlink, tcell.AttrDim, tcell.AttrReverse, tcell.AttrUnderline,
+	tcell.AttrItalic, tcell.AttrStrikeThrough, AttrDoubleUnderline, AttrCurlyUnderline}
 
 // StyleNone expresses no preference for any text styles.
 var StyleNone = StyleAttrs{}
@@ -56,6 +65,19 @@ var StyleReverse = StyleAttrs{tcell.AttrReverse, tcell.AttrReverse}
 // StyleUnderline specifies the text should be underlined, but expresses no preference for other text styles.
 var StyleUnderline = StyleAttrs{tcell.AttrUnderline, tcell.AttrUnderline}
 
+// StyleItalic specifies the text should be italic, but expresses no preference for other text styles.
+var StyleItalic = StyleAttrs{tcell.AttrItalic, tcell.AttrItalic}
+
+// StyleStrikethrough specifies the text should be struck through, but expresses no preference for other text styles.
+var StyleStrikethrough = StyleAttrs{tcell.AttrStrikeThrough, tcell.AttrStrikeThrough}
+
+// StyleDoubleUnderline specifies the text should be double-underlined, but expresses no preference for other text styles.
+var StyleDoubleUnderline = StyleAttrs{AttrDoubleUnderline, AttrDoubleUnderline}
+
+// StyleCurlyUnderline specifies the text should have a curly underline, as used by spell-checkers, but expresses
+// no preference for other text styles.
+var StyleCurlyUnderline = StyleAttrs{AttrCurlyUnderline, AttrCurlyUnderline}
+
 // StyleBoldOnly specifies the text should be bold, and no other styling should apply.
 var StyleBoldOnly = StyleAttrs{tcell.AttrBold, StyleAllSet}
 
@@ -71,9 +93,22 @@ var StyleReverseOnly = StyleAttrs{tcell.AttrReverse, StyleAllSet}
 // StyleUnderlineOnly specifies the text should be underlined, and no other styling should apply.
 var StyleUnderlineOnly = StyleAttrs{tcell.AttrUnderline, StyleAllSet}
 
+// StyleItalicOnly specifies the text should be italic, and no other styling should apply.
+var StyleItalicOnly = StyleAttrs{tcell.AttrItalic, StyleAllSet}
+
+// StyleStrikethroughOnly specifies the text should be struck through, and no other styling should apply.
+var StyleStrikethroughOnly = StyleAttrs{tcell.AttrStrikeThrough, StyleAllSet}
+
+// StyleDoubleUnderlineOnly specifies the text should be double-underlined, and no other styling should apply.
+var StyleDoubleUnderlineOnly = StyleAttrs{AttrDoubleUnderline, StyleAllSet}
+
+// StyleCurlyUnderlineOnly specifies the text should have a curly underline, and no other styling should apply.
+var StyleCurlyUnderlineOnly = StyleAttrs{AttrCurlyUnderline, StyleAllSet}
+
 // IgnoreBase16 should be set to true if gowid should not consider colors 0-21 for closest-match when
 // interpolating RGB colors in 256-color space. You might use this if you use base16-shell, for example,
-// to make use of base16-themes for all terminal applications (https://github.com/chriskempson/base16-shell)
+// to make use of base16-themes for all terminal applications (https://github.com/chriskempson/base16-shell).
+// It is the default for ColorConverters not configured with IgnoreBase16Colors.
 var IgnoreBase16 = false
 
 // MergeUnder merges cell styles. E.g. if a is {underline, underline}, and upper is {!bold, bold}, that
@@ -885,12 +920,6 @@ var (
 		MakeTCellColorExt(tcell.Color254),
 		MakeTCellColorExt(tcell.Color255),
 	}
-
-	term2Cache               *lru.Cache
-	term8Cache               *lru.Cache
-	term16Cache              *lru.Cache
-	term256Cache             *lru.Cache
-	term256CacheIgnoreBase16 *lru.Cache
 )
 
 //======================================================================
@@ -910,19 +939,19 @@ func init() {
 		grayLookup88_101[i] = grayLookup88[intScale(i, 101, 0x100)]
 	}
 
-	var err error
-	for _, cache := range []**lru.Cache{&term2Cache, &term8Cache, &term16Cache, &term256Cache, &term256CacheIgnoreBase16} {
-		*cache, err = lru.New(100)
-		if err != nil {
-			panic(err)
-		}
-	}
-
 	if os.Getenv("GOWID_IGNORE_BASE16") == "1" {
 		IgnoreBase16 = true
 	}
 }
 
+// FlushColorCaches discards the cached results of converting colors to tcell colors
+// with DefaultColorConverter. Call it after
+// changing anything that affects conversions, such as IgnoreBase16. An app's own
+// converter is flushed with its Flush method.
+func FlushColorCaches() {
+	DefaultColorConverter.Flush()
+}
+
 // makeColorLookup([0, 7, 9], 10)
 // [0, 0, 0, 0, 1, 1, 1, 1, 2, 2]
 //
@@ -1001,19 +1030,46 @@ type IColor interface {
 // MakeCellStyle constructs a tcell.Style from gowid colors and styles. The return value can be provided
 // to tcell in order to style a particular region of the screen.
 func MakeCellStyle(fg TCellColor, bg TCellColor, attr StyleAttrs) tcell.Style {
-	var fgt, bgt tcell.Color
-	if fg == ColorNone {
-		fgt = tcell.ColorDefault
-	} else {
-		fgt = fg.ToTCell()
+	return MakeCellStyleExt(fg, bg, ColorNone, attr)
+}
+
+// MakeCellStyleExt is like MakeCellStyle, but also takes the color of the underline. This only has an
+// effect if the style includes an underline.
+func MakeCellStyleExt(fg TCellColor, bg TCellColor, ul TCellColor, attr StyleAttrs) tcell.Style {
+	st := StyleNone.MergeUnder(attr)
+	res := tcell.Style{}.
+		Attributes(st.OnOff &^ (tcell.AttrUnderline | AttrDoubleUnderline | AttrCurlyUnderline)).
+		Foreground(tcellColorOrDefault(fg)).
+		Background(tcellColorOrDefault(bg))
+	if us := UnderlineStyleOf(st.OnOff); us != tcell.UnderlineStyleNone {
+		res = res.Underline(us)
+		if ul != ColorNone {
+			res = res.Underline(ul.ToTCell())
+		}
 	}
-	if bg == ColorNone {
-		bgt = tcell.ColorDefault
-	} else {
-		bgt = bg.ToTCell()
+	return res
+}
+
+// UnderlineStyleOf returns the tcell underline style expressed by a set of attributes. If more than
+// one underline is set, curly wins over double, which wins over a plain underline.
+func UnderlineStyleOf(attrs tcell.AttrMask) tcell.UnderlineStyle {
+	switch {
+	case attrs&AttrCurlyUnderline != 0:
+		return tcell.UnderlineStyleCurly
+	case attrs&AttrDoubleUnderline != 0:
+		return tcell.UnderlineStyleDouble
+	case attrs&tcell.AttrUnderline != 0:
+		return tcell.UnderlineStyleSolid
+	default:
+		return tcell.UnderlineStyleNone
 	}
-	st := StyleNone.MergeUnder(attr)
-	return tcell.Style{}.Attributes(st.OnOff).Foreground(fgt).Background(bgt)
+}
+
+func tcellColorOrDefault(c TCellColor) tcell.Color {
+	if c == ColorNone {
+		return tcell.ColorDefault
+	}
+	return c.ToTCell()
 }
 
 //======================================================================
@@ -1034,14 +1090,25 @@ func (c Color) String() string {
 // do-what-I-mean fashion - it tries the Color struct maker functions in
 // a pre-determined order until one successfully initialized a Color, or
 // until all fail - in which case an error is returned. The order tried is
-// TCellColor, RGBColor, GrayColor, UrwidColor.
+// TCellColor, a CSS named color (see MakeNamedColorSafe), RGBColor, GrayColor,
+// UrwidColor - except that tcell's names for colors specified by RGB components,
+// like "aliceblue", give way to the CSS color of the same name, so they are
+// matched to the app's color mode like any other RGBColor. To parse a color
+// along with settings, like "dark blue,underline", use ParseColorSpec.
 func MakeColorSafe(s string) (Color, error) {
 	var col IColor
 	var err error
-	col, err = MakeTCellColor(s)
+	tc, tcErr := MakeTCellColor(s)
+	if tcErr == nil && !tc.isRGB() {
+		return Color{tc, s}, nil
+	}
+	col, err = MakeNamedColorSafe(s)
 	if err == nil {
 		return Color{col, s}, nil
 	}
+	if tcErr == nil {
+		return Color{tc, s}, nil
+	}
 	col, err = MakeRGBColorSafe(s)
 	if err == nil {
 		return Color{col, s}, nil
@@ -1087,8 +1154,14 @@ func MakeColorByModeSafe(cols map[ColorMode]IColor) (ColorByMode, error) {
 }
 
 func (c ColorByMode) ToTCellColor(mode ColorMode) (TCellColor, bool) {
+	return c.ToTCellColorWith(mode, DefaultColorConverter)
+}
+
+// ToTCellColorWith converts the color for mode using conv. It lets ColorByMode conform to
+// IConvertibleColor.
+func (c ColorByMode) ToTCellColorWith(mode ColorMode, conv *ColorConverter) (TCellColor, bool) {
 	if col, ok := c.Colors[mode]; ok {
-		col2, ok := col.ToTCellColor(mode)
+		col2, ok := conv.ToTCellColor(col, mode)
 		return col2, ok
 	}
 	panic(ColorModeMismatch{Color: c, Mode: mode})
@@ -1204,15 +1277,21 @@ func (r RGBColor) findClosest(from []colorful.Color, corresponding []TCellColor,
 // ToTCellColor converts an RGBColor to a TCellColor, suitable for rendering to the screen
 // with tcell. It lets RGBColor conform to IColor.
 func (r RGBColor) ToTCellColor(mode ColorMode) (TCellColor, bool) {
+	return r.ToTCellColorWith(mode, DefaultColorConverter)
+}
+
+// ToTCellColorWith converts an RGBColor to a TCellColor, using and filling the caches
+// of conv. It lets RGBColor conform to IConvertibleColor.
+func (r RGBColor) ToTCellColorWith(mode ColorMode, conv *ColorConverter) (TCellColor, bool) {
 	switch mode {
 	case Mode24BitColors:
 		c := tcell.NewRGBColor(int32(r.Red), int32(r.Green), int32(r.Blue))
 		return MakeTCellColorExt(c), true
 	case Mode256Colors:
-		if IgnoreBase16 {
-			return r.findClosest(colorful256[22:], term256[22:], term256CacheIgnoreBase16), true
+		if conv.IgnoreBase16() {
+			return conv.closest(r, mode, true, colorful256[22:], term256[22:]), true
 		} else {
-			return r.findClosest(colorful256, term256, term256Cache), true
+			return conv.closest(r, mode, false, colorful256, term256), true
 		}
 	case Mode88Colors:
 		rd := cubeLookup88_16[r.Red>>4]
@@ -1221,11 +1300,11 @@ func (r RGBColor) ToTCellColor(mode ColorMode) (TCellColor, bool) {
 		c := tcell.Color((CubeStart + (((rd * cubeSize88) + g) * cubeSize88) + b) + 0) + tcell.ColorValid
 		return MakeTCellColorExt(c), true
 	case Mode16Colors:
-		return r.findClosest(colorful16, term16, term16Cache), true
+		return conv.closest(r, mode, false, colorful16, term16), true
 	case Mode8Colors:
-		return r.findClosest(colorful8, term8, term8Cache), true
+		return conv.closest(r, mode, false, colorful8, term8), true
 	case ModeMonochrome:
-		return r.findClosest(colorful8[0:1], term8[0:1], term2Cache), true
+		return conv.closest(r, mode, false, colorful8[0:1], term8[0:1]), true
 	default:
 		return TCellColor{}, false
 	}
@@ -1236,17 +1315,19 @@ func (r RGBColor) ToTCellColor(mode ColorMode) (TCellColor, bool) {
 // UrwidColor is a gowid Color implementing IColor and which allows urwid color names to be used
 // (http://urwid.org/manual/displayattributes.html#foreground-and-background-settings) e.g.
 // "dark blue", "light gray".
+//
+// UrwidColor doesn't cache its conversions - they're cheap, and it may be converted
+// by widgets rendered concurrently.
 type UrwidColor struct {
-	Id     string
-	cached bool
-	cache  [2]TCellColor
+	Id string
 }
 
 var _ IColor = (*UrwidColor)(nil)
 
 // NewUrwidColorSafe returns a pointer to an UrwidColor struct and builds the UrwidColor from
 // a string argument e.g. "yellow". Note that in urwid proper (python), a color can also specify
-// a style, like "yellow, underline". UrwidColor does not support specifying styles in that manner.
+// a style, like "yellow, underline". UrwidColor does not support specifying styles in that manner -
+// use ParseColorSpec or ParseStyleSpec for those.
 func NewUrwidColorSafe(val string) (*UrwidColor, error) {
 	if _, ok := basicColors[val]; !ok {
 		return nil, errors.WithStack(InvalidColor{Color: val})
@@ -1275,27 +1356,23 @@ func (r UrwidColor) String() string {
 // ToTCellColor converts the receiver UrwidColor to a TCellColor, ready for rendering to a
 // tcell screen. This lets UrwidColor conform to IColor.
 func (s *UrwidColor) ToTCellColor(mode ColorMode) (TCellColor, bool) {
-	if s.cached {
-		switch mode {
-		case Mode24BitColors, Mode256Colors, Mode88Colors, Mode16Colors:
-			return s.cache[0], true
-		case Mode8Colors, ModeMonochrome:
-			return s.cache[1], true
-		default:
-			panic(errors.WithStack(ColorModeMismatch{Color: s, Mode: mode}))
-		}
-	}
-
-	idx := -1
+	slot := 0
 	switch mode {
 	case Mode24BitColors, Mode256Colors, Mode88Colors, Mode16Colors:
-		idx = posInMap(s.Id, basicColors)
+		slot = 0
 	case Mode8Colors, ModeMonochrome:
-		idx = posInMap(s.Id, tBasicColors)
+		slot = 1
 	default:
 		panic(errors.WithStack(ColorModeMismatch{Color: s, Mode: mode}))
 	}
 
+	idx := -1
+	if slot == 0 {
+		idx = posInMap(s.Id, basicColors)
+	} else {
+		idx = posInMap(s.Id, tBasicColors)
+	}
+
 	if idx == -1 {
 		panic(errors.WithStack(InvalidColor{Color: s}))
 	}
@@ -1305,17 +1382,7 @@ func (s *UrwidColor) ToTCellColor(mode ColorMode) (TCellColor, bool) {
 		idx = idx - 1
 		col = tcell.ColorValid + tcell.Color(idx)
 	}
-	c := MakeTCellColorExt(col)
-
-	switch mode {
-	case Mode24BitColors, Mode256Colors, Mode88Colors, Mode16Colors:
-		s.cache[0] = c
-	case Mode8Colors, ModeMonochrome:
-		s.cache[1] = c
-	}
-	s.cached = true
-
-	return c, true
+	return MakeTCellColorExt(col), true
 }
 
 //======================================================================
@@ -1474,6 +1541,12 @@ func (r TCellColor) ToTCellColor(mode ColorMode) (TCellColor, bool) {
 	return r, true
 }
 
+// isRGB returns true if the color is specified by its RGB components rather than as
+// one of the terminal's palette.
+func (r TCellColor) isRGB() bool {
+	return r.tc != nil && *r.tc&tcell.ColorIsRGB != 0
+}
+
 //======================================================================
 
 // NoColor implements IColor, and represents "no color preference", distinct from the default terminal color,
@@ -1729,9 +1802,15 @@ func (m Palette) RangeOverPalette(f func(k string, v ICellStyler) bool) {
 // in preparation for passing to tcell to render; if the conversion fails, a default
 // TCellColor is returned (provided to the function via a parameter)
 func IColorToTCell(color IColor, def TCellColor, mode ColorMode) TCellColor {
+	return IColorToTCellWith(color, def, mode, DefaultColorConverter)
+}
+
+// IColorToTCellWith is like IColorToTCell, but colors that support it are converted, and
+// the results cached, by conv - see ColorConverter.
+func IColorToTCellWith(color IColor, def TCellColor, mode ColorMode, conv *ColorConverter) TCellColor {
 	res := def
-	colTC, ok := color.ToTCellColor(mode) // Is there a color specified affirmatively? (i.e. not NoColor)
-	if ok && colTC != ColorNone {         // Yes a color specified
+	colTC, ok := conv.ToTCellColor(color, mode) // Is there a color specified affirmatively? (i.e. not NoColor)
+	if ok && colTC != ColorNone {               // Yes a color specified
 		res = colTC
 	}
 	return res
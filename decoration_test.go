@@ -4,10 +4,12 @@
 package gowid
 
 import (
+	"strings"
 	"testing"
 
 	tcell "github.com/gdamore/tcell/v2"
 	"github.com/go-test/deep"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,6 +44,21 @@ func TestColor2(t *testing.T) {
 	}
 }
 
+func TestColor2b(t *testing.T) {
+	c := NewUrwidColor("light red")
+	i2, _ := c.ToTCellColor(Mode256Colors)
+	assert.Equal(t, tcell.ColorRed, i2.ToTCell())
+	// The cached conversion for 256 colors mustn't be used for 8
+	i2, _ = c.ToTCellColor(Mode8Colors)
+	assert.Equal(t, tcell.ColorMaroon, i2.ToTCell())
+	i2, _ = c.ToTCellColor(Mode16Colors)
+	assert.Equal(t, tcell.ColorRed, i2.ToTCell())
+
+	FlushColorCaches()
+	i2, _ = c.ToTCellColor(Mode8Colors)
+	assert.Equal(t, tcell.ColorMaroon, i2.ToTCell())
+}
+
 func TestColor3(t *testing.T) {
 	c := MakeGrayColor("g#ff")
 	if c.Val != 255 {
@@ -155,6 +172,206 @@ func TestTCell2(t *testing.T) {
 	assert.Equal(t, v.ToTCell(), tcell.ColorMaroon)
 }
 
+func TestGradient1(t *testing.T) {
+	g := MakeGradient(MakeRGBColor("#000"), MakeColor("white"), 3)
+	assert.Equal(t, 3, len(g))
+	assert.Equal(t, MakeRGBColorExt(0, 0, 0), g[0])
+	assert.Equal(t, MakeRGBColorExt(255, 255, 255), g[2])
+	mid := g[1].(RGBColor)
+	assert.True(t, mid.Red > 0x30 && mid.Red < 0xd0)
+	assert.Equal(t, mid.Red, mid.Green)
+
+	v, _ := g[2].ToTCellColor(Mode8Colors)
+	assert.Equal(t, tcell.ColorWhite, v.ToTCell())
+
+	assert.Equal(t, NoColor{}, Lerp(NoColor{}, MakeRGBColor("#fff"), 0.2))
+	assert.Equal(t, MakeRGBColor("#fff"), Lerp(NoColor{}, MakeRGBColor("#fff"), 0.7))
+	assert.Equal(t, []IColor{NoColor{}}, MakeGradient(NoColor{}, DefaultColor{}, 1))
+}
+
+func TestPaletteValidate1(t *testing.T) {
+	p := Palette{
+		"ok":      MakePaletteEntry(MakeColor("red"), NoColor{}),
+		"gray":    MakePaletteEntry(MakeGrayColor("g50"), NoColor{}),
+		"ref":     MakePaletteRef("ok"),
+		"dangles": MakePaletteRef("nope"),
+		"loop":    MakePaletteRef("loop"),
+		"mod":     MakeStyleMod(MakePaletteRef("ok"), MakeStyledAs(StyleBold)),
+	}
+
+	e, err := p.Resolve("ref", Mode16Colors)
+	assert.NoError(t, err)
+	assert.Equal(t, MakeTCellColorExt(tcell.ColorRed), e.FG)
+	assert.Equal(t, ColorNone, e.BG)
+
+	e, err = p.Resolve("mod", Mode16Colors)
+	assert.NoError(t, err)
+	assert.Equal(t, StyleBold, e.Style)
+
+	_, err = p.Resolve("missing", Mode16Colors)
+	assert.IsType(t, UnknownPaletteEntryError{}, errors.Cause(err))
+
+	err = p.Validate(Mode256Colors)
+	assert.Error(t, err)
+	errs := err.(PaletteErrors)
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "dangles", errs[0].Name)
+	assert.Equal(t, UnknownPaletteEntryError{Name: "nope"}, errors.Cause(errs[0].Err))
+	assert.Equal(t, "loop", errs[1].Name)
+	assert.IsType(t, PaletteCycleError{}, errors.Cause(errs[1].Err))
+
+	err = p.Validate(Mode16Colors)
+	errs = err.(PaletteErrors)
+	assert.Equal(t, 3, len(errs))
+	assert.Equal(t, "gray", errs[1].Name)
+	assert.IsType(t, ColorModeMismatch{}, errors.Cause(errs[1].Err))
+
+	assert.NoError(t, Palette{"ok": p["ok"]}.Validate(Mode8Colors))
+}
+
+func TestColorConverter1(t *testing.T) {
+	ignore := NewColorConverter(IgnoreBase16Colors(true))
+	keep := NewColorConverter(IgnoreBase16Colors(false))
+	c, _ := MakeRGBColorExtSafe(0, 0, 0)
+
+	for _, global := range []bool{false, true} {
+		IgnoreBase16 = global
+		i1, _ := ignore.ToTCellColor(c, Mode256Colors)
+		assert.Equal(t, tcell.Color232, i1.ToTCell())
+		i2, _ := keep.ToTCellColor(c, Mode256Colors)
+		assert.Equal(t, tcell.ColorValid, i2.ToTCell())
+	}
+	IgnoreBase16 = false
+
+	// Each mode has its own cache, and flushing one converter leaves the other alone
+	i3, _ := keep.ToTCellColor(c, Mode8Colors)
+	assert.Equal(t, tcell.ColorBlack, i3.ToTCell())
+	ignore.Flush()
+	i2, _ := keep.ToTCellColor(c, Mode256Colors)
+	assert.Equal(t, tcell.ColorValid, i2.ToTCell())
+
+	// Colors that wrap others pass the converter on
+	bm := MakeColorByMode(map[ColorMode]IColor{Mode256Colors: c})
+	i4, _ := ignore.ToTCellColor(bm, Mode256Colors)
+	assert.Equal(t, tcell.Color232, i4.ToTCell())
+	assert.Equal(t, tcell.Color232, IColorToTCellWith(bm, ColorNone, Mode256Colors, ignore).ToTCell())
+
+	app := &App{colorMode: Mode256Colors, colorConverter: ignore}
+	assert.Equal(t, ignore, ColorConverterOf(app))
+	assert.Equal(t, DefaultColorConverter, ColorConverterOf(42))
+	assert.Equal(t, tcell.Color232, ColorToTCellIn(c, ColorNone, app).ToTCell())
+}
+
+func TestStyleSpec1(t *testing.T) {
+	c, st, err := ParseColorSpec("dark blue,underline")
+	assert.NoError(t, err)
+	assert.Equal(t, MakeColor("dark blue"), c)
+	assert.Equal(t, StyleUnderline, st)
+
+	c, st, err = ParseColorSpec("bold")
+	assert.NoError(t, err)
+	assert.Equal(t, NoColor{}, c)
+	assert.Equal(t, StyleBold, st)
+
+	_, st, err = ParseColorSpec("#f80, Bold ,italics,standout")
+	assert.NoError(t, err)
+	assert.Equal(t, StyleBold.MergeUnder(StyleItalic).MergeUnder(StyleReverse), st)
+
+	_, _, err = ParseColorSpec("dark blue,light red")
+	assert.Error(t, err)
+	_, _, err = ParseColorSpec("dark blue,sparkly")
+	assert.IsType(t, InvalidStyleSpec{}, errors.Cause(err))
+
+	e, err := ParseStyleSpec("yellow,bold on dark blue")
+	assert.NoError(t, err)
+	assert.Equal(t, MakeStyledPaletteEntry(MakeColor("yellow"), MakeColor("dark blue"), StyleBold), e)
+
+	e = MakeStyleSpec("underline")
+	assert.Equal(t, MakeStyledPaletteEntry(NoColor{}, NoColor{}, StyleUnderline), e)
+
+	assert.Panics(t, func() { MakeStyleSpec("yellow on sparkly") })
+}
+
+func TestReadPalette1(t *testing.T) {
+	p, err := ReadPalette(strings.NewReader(`
+# A theme
+title: yellow,bold on dark blue
+  warning : light red,underline
+
+bad line
+worse: dark blue,sparkly
+`))
+	assert.Len(t, p, 2)
+	assert.Equal(t, MakeStyledPaletteEntry(MakeColor("yellow"), MakeColor("dark blue"), StyleBold), p["title"])
+	assert.Equal(t, MakeStyledPaletteEntry(MakeColor("light red"), NoColor{}, StyleUnderline), p["warning"])
+
+	errs, ok := err.(PaletteErrors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "worse", errs[1].Name)
+	assert.Contains(t, errs[1].Error(), "line 7")
+}
+
+func TestColorSpaces1(t *testing.T) {
+	assert.Equal(t, MakeRGBColorExt(0xff, 0, 0), MakeColorHSL(0, 1, 0.5))
+	assert.Equal(t, MakeRGBColorExt(0, 0xff, 0), MakeColorHSL(-240, 1, 0.5))
+	assert.Equal(t, MakeRGBColorExt(0x80, 0x80, 0x80), MakeColorHSL(0, 0, 0.5))
+	assert.Equal(t, MakeRGBColorExt(0, 0, 0xff), MakeColorHSV(240, 1, 1))
+	assert.Equal(t, MakeRGBColorExt(0xff, 0xff, 0xff), MakeColorHSV(0, 0, 1))
+	_, err := MakeColorHSLSafe(0, 1.5, 0.5)
+	assert.Error(t, err)
+	assert.Panics(t, func() { MakeColorHSV(0, 0, -1) })
+
+	h, s, l := MakeRGBColorExt(0xff, 0, 0).HSL()
+	assert.Equal(t, []float64{0, 1, 0.5}, []float64{h, s, l})
+	h, s, v := MakeRGBColorExt(0, 0, 0xff).HSV()
+	assert.Equal(t, []float64{240, 1, 1}, []float64{h, s, v})
+}
+
+func TestNamedColor1(t *testing.T) {
+	assert.Equal(t, MakeRGBColorExt(0x66, 0x33, 0x99), MakeNamedColor("RebeccaPurple"))
+	assert.Equal(t, MakeRGBColorExt(0xff, 0, 0), MakeNamedColor("red"))
+	_, err := MakeNamedColorSafe("dark blue")
+	assert.Error(t, err)
+
+	// tcell's RGB names become RGBColors, matched to the color mode
+	c := MakeColor("aliceblue")
+	assert.Equal(t, MakeRGBColorExt(0xf0, 0xf8, 0xff), c.IColor)
+	// The terminal's own colors are still preferred
+	c = MakeColor("red")
+	assert.Equal(t, tcell.ColorRed, c.IColor.(TCellColor).ToTCell())
+	c = MakeColor("Magenta")
+	assert.Equal(t, MakeRGBColorExt(0xff, 0, 0xff), c.IColor)
+	c = MakeColor("dark blue")
+	assert.IsType(t, &UrwidColor{}, c.IColor)
+}
+
+func TestStylers1(t *testing.T) {
+	mono := &paletteResolver{Palette: Palette{}, mode: ModeMonochrome}
+	full := &paletteResolver{Palette: Palette{}, mode: Mode24BitColors}
+
+	hi := MakeStyledPaletteEntry(ColorRed, ColorBlue, StyleUnderline)
+	w := When(InColorModes(ModeMonochrome, Mode8Colors), MakeStyledAs(StyleReverse), hi)
+	f, b, s := w.GetStyle(mono)
+	assert.Equal(t, []interface{}{NoColor{}, NoColor{}, StyleReverse}, []interface{}{f, b, s})
+	f, b, s = w.GetStyle(full)
+	assert.Equal(t, []interface{}{ColorRed, ColorBlue, StyleUnderline}, []interface{}{f, b, s})
+
+	f, b, s = When(func(IRenderContext) bool { return false }, hi, nil).GetStyle(full)
+	assert.Equal(t, []interface{}{NoColor{}, NoColor{}, StyleNone}, []interface{}{f, b, s})
+
+	c := Compose(hi, MakeForeground(ColorGreen), nil, MakeStyledAs(StyleBold), MakeStyledAs(StyleAttrs{Set: tcell.AttrUnderline}))
+	f, b, s = c.GetStyle(full)
+	assert.Equal(t, ColorGreen, f)
+	assert.Equal(t, ColorBlue, b)
+	assert.Equal(t, StyleBold.MergeUnder(StyleAttrs{Set: tcell.AttrUnderline}), s)
+
+	// Conditionals compose
+	f, _, s = Compose(hi, w).GetStyle(mono)
+	assert.Equal(t, ColorRed, f)
+	assert.Equal(t, StyleUnderline.MergeUnder(StyleReverse), s)
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
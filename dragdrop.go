@@ -0,0 +1,267 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// DragCB is the name under which drag callbacks are registered on the App. They
+// are run when a drag starts and when it ends.
+type DragCB struct{}
+
+// DragPayload is the data carried by a drag. Kind lets drop targets decide whether
+// they accept the payload without inspecting its value, e.g. "list-item" or "file".
+type DragPayload struct {
+	Kind  string
+	Value interface{}
+}
+
+func (p DragPayload) String() string {
+	return fmt.Sprintf("%s[%v]", p.Kind, p.Value)
+}
+
+// Drag describes a drag in progress. A drag source creates one when the left mouse
+// button is pressed and passes it to StartDrag. The drag becomes active once the mouse
+// moves with the button held; while it is active, Ghost is drawn over the app with the
+// mouse pointer at column HotX and row HotY of the ghost. When the button is released,
+// the drop target under the pointer, if any, receives the drag.
+type Drag struct {
+	Payload DragPayload
+	Source  IWidget // Told when the drag ends, if it implements IDragSource
+	Ghost   ICanvas // Drawn under the mouse pointer during the drag - may be nil
+	HotX    int
+	HotY    int
+
+	active  bool
+	startX  int
+	startY  int
+	x       int
+	y       int
+	target  IDropTarget
+	dropped bool
+}
+
+func (d *Drag) String() string {
+	return fmt.Sprintf("drag[%v]", d.Payload)
+}
+
+// Active returns true once the mouse has moved since the drag was started.
+func (d *Drag) Active() bool {
+	return d.active
+}
+
+// Position returns the terminal coordinates of the mouse pointer.
+func (d *Drag) Position() (x, y int) {
+	return d.x, d.y
+}
+
+// Target returns the drop target under the mouse pointer that will accept the
+// drag if the button is released, or nil.
+func (d *Drag) Target() IDropTarget {
+	return d.target
+}
+
+// Dropped returns true if the drag ended by being dropped on a target that accepted it.
+func (d *Drag) Dropped() bool {
+	return d.dropped
+}
+
+// IDragSource may be implemented by a drag's Source to learn when the drag ends,
+// e.g. to remove an item that was moved elsewhere. Check Dropped() to see if
+// the drag succeeded.
+type IDragSource interface {
+	DragEnded(d *Drag, app IApp)
+}
+
+// IDropTarget is implemented by widgets that can receive a drag. AcceptsDrop is
+// called as the pointer moves over the widget, and Drop when the mouse button is
+// released over it - x and y are the pointer's coordinates within the widget. Drop
+// returns true if the payload was used.
+type IDropTarget interface {
+	AcceptsDrop(d *Drag, app IApp) bool
+	Drop(d *Drag, x, y int, app IApp) bool
+}
+
+// IDragDrop is implemented by an App that supports drag-and-drop.
+type IDragDrop interface {
+	StartDrag(d *Drag)
+	CurrentDrag() *Drag
+	CancelDrag()
+}
+
+// StartDrag begins a drag if app supports drag-and-drop, returning false if not.
+func StartDrag(d *Drag, app IApp) bool {
+	if dd, ok := app.(IDragDrop); ok {
+		dd.StartDrag(d)
+		return true
+	}
+	return false
+}
+
+// CurrentDrag returns the active drag, or nil if there is none or app does not
+// support drag-and-drop.
+func CurrentDrag(app IApp) *Drag {
+	if dd, ok := app.(IDragDrop); ok {
+		if d := dd.CurrentDrag(); d != nil && d.Active() {
+			return d
+		}
+	}
+	return nil
+}
+
+// DropTargetUserInput can be called from the UserInput function of a widget that
+// implements IDropTarget. It makes the widget the target of an active drag if the
+// pointer is over it and the drag is accepted, and drops the drag on the widget when
+// the button is released. It returns true if the event was used.
+func DropTargetUserInput(w IDropTarget, ev interface{}, size IRenderSize, app IApp) bool {
+	d := CurrentDrag(app)
+	if d == nil {
+		return false
+	}
+	ev2, ok := ev.(*tcell.EventMouse)
+	if !ok {
+		return false
+	}
+	x, y := ev2.Position()
+	if box, ok := size.(IRenderBox); ok {
+		if x < 0 || y < 0 || x >= box.BoxColumns() || y >= box.BoxRows() {
+			return false
+		}
+	}
+	if !w.AcceptsDrop(d, app) {
+		return false
+	}
+	switch ev2.Buttons() {
+	case tcell.Button1:
+		d.target = w
+		return true
+	case tcell.ButtonNone:
+		d.target = nil
+		d.dropped = w.Drop(d, x, y, app)
+		return true
+	}
+	return false
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// StartDrag registers a drag that becomes active when the mouse moves with the left
+// button held. Call it from a widget's UserInput when the button is pressed. Any
+// drag already in progress is cancelled.
+func (a *App) StartDrag(d *Drag) {
+	if a.drag != nil {
+		a.CancelDrag()
+	}
+	d.active = false
+	d.dropped = false
+	d.target = nil
+	d.startX, d.startY = a.mouseX, a.mouseY
+	d.x, d.y = a.mouseX, a.mouseY
+	a.drag = d
+}
+
+// CurrentDrag returns the drag in progress, which might not yet be active, or nil.
+func (a *App) CurrentDrag() *Drag {
+	return a.drag
+}
+
+// CancelDrag ends the drag in progress without dropping it.
+func (a *App) CancelDrag() {
+	a.endDrag()
+}
+
+// OnDrag registers a callback that is run when a drag becomes active and when it
+// ends. The callback's extra argument is the *Drag - check Active() to tell which.
+func (a *App) OnDrag(f IWidgetChangedCallback) {
+	if a.dragCallbacks == nil {
+		a.dragCallbacks = NewCallbacks()
+	}
+	AddWidgetCallback(a.dragCallbacks, DragCB{}, f)
+}
+
+func (a *App) RemoveOnDrag(f IIdentity) {
+	if a.dragCallbacks != nil {
+		RemoveWidgetCallback(a.dragCallbacks, DragCB{}, f)
+	}
+}
+
+func (a *App) dragChanged(d *Drag) {
+	if a.dragCallbacks != nil {
+		RunWidgetCallbacks(a.dragCallbacks, DragCB{}, a, d.Source, d)
+	}
+}
+
+// endDrag finishes the drag in progress, telling the source and any callbacks if it
+// had become active.
+func (a *App) endDrag() {
+	d := a.drag
+	if d == nil {
+		return
+	}
+	a.drag = nil
+	if !d.active {
+		return
+	}
+	d.active = false
+	d.target = nil
+	if src, ok := d.Source.(IDragSource); ok {
+		src.DragEnded(d, a)
+	}
+	a.dragChanged(d)
+}
+
+// dragMouseEvent updates the drag in progress, if any, before the event is sent to
+// the widget hierarchy. It activates the drag once the pointer moves.
+func (a *App) dragMouseEvent(ev *tcell.EventMouse) {
+	a.mouseX, a.mouseY = ev.Position()
+	d := a.drag
+	if d == nil {
+		return
+	}
+	d.x, d.y = a.mouseX, a.mouseY
+	d.target = nil
+	if !d.active && ev.Buttons() == tcell.Button1 && (d.x != d.startX || d.y != d.startY) {
+		d.active = true
+		a.dragChanged(d)
+	}
+}
+
+// drawDragGhost draws the ghost of an active drag onto the canvas, clipped to its
+// bounds.
+func (a *App) drawDragGhost(canvas ICanvas) {
+	d := a.drag
+	if d == nil || !d.active || d.Ghost == nil {
+		return
+	}
+	left, top := d.x-d.HotX, d.y-d.HotY
+	for y := 0; y < d.Ghost.BoxRows(); y++ {
+		cy := top + y
+		if cy < 0 || cy >= canvas.BoxRows() {
+			continue
+		}
+		for x := 0; x < d.Ghost.BoxColumns(); x++ {
+			cx := left + x
+			if cx < 0 || cx >= canvas.BoxColumns() {
+				continue
+			}
+			g := d.Ghost.CellAt(x, y)
+			if !g.HasRune() {
+				g = g.WithRune(' ')
+			}
+			canvas.SetCellAt(cx, cy, canvas.CellAt(cx, cy).MergeUnder(g))
+		}
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
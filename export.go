@@ -0,0 +1,225 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+
+	"github.com/gcla/gowid/gwutil"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// ExportOptions can be supplied to RenderToString, RenderToANSI and RenderToHTML. If
+// Rows is zero, the widget is rendered as a flow widget, as many rows high as it needs;
+// otherwise it's rendered as a box of that many rows. If Focused is true, the widget is
+// rendered with the focus. Palette provides the widget's named styles - its "default"
+// entry, if any, is layered under every cell, as when an App draws to the terminal.
+type ExportOptions struct {
+	Rows    int
+	Focused bool
+	Palette IPalette
+}
+
+// RenderCanvas renders w width columns wide without a terminal, in the given color
+// mode, and returns the canvas. The widget is rendered by an App that isn't attached
+// to a terminal, so features widgets find via the App, like geometry tracking, work as
+// usual.
+func RenderCanvas(w IWidget, width int, mode ColorMode, opts ...ExportOptions) ICanvas {
+	var opt ExportOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	app, err := NewApp(AppArgs{
+		Screen:       tcell.NewSimulationScreen(""),
+		View:         w,
+		Palette:      opt.Palette,
+		Logger:       discardLogger{},
+		DontActivate: true,
+	})
+	if err != nil {
+		panic(err)
+	}
+	app.SetColorMode(mode)
+
+	var size IRenderSize = RenderFlowWith{C: width}
+	if opt.Rows > 0 {
+		size = RenderBox{C: width, R: opt.Rows}
+	}
+	focus := NotSelected
+	if opt.Focused {
+		focus = Focused
+	}
+	res := RenderChild(w, size, focus, app)
+	layerDefaultStyle(res, app)
+	return res
+}
+
+// RenderToString renders w without a terminal, as RenderCanvas does, and returns the
+// text of each row, without any colors or styles, separated by newlines - e.g. for a
+// golden test.
+func RenderToString(w IWidget, width int, mode ColorMode, opts ...ExportOptions) string {
+	c := RenderCanvas(w, width, mode, opts...)
+	defer ReleaseCanvas(c)
+	return CanvasToString(c)
+}
+
+// RenderToANSI renders w without a terminal, as RenderCanvas does, and returns its
+// rows separated by newlines, with ANSI escape sequences for colors, styles and
+// hyperlinks - e.g. to print to a terminal, or for a report viewed with less -R. Each
+// row ends with a reset.
+func RenderToANSI(w IWidget, width int, mode ColorMode, opts ...ExportOptions) string {
+	c := RenderCanvas(w, width, mode, opts...)
+	defer ReleaseCanvas(c)
+
+	var buf bytes.Buffer
+	for y := 0; y < c.BoxRows(); y++ {
+		if y > 0 {
+			buf.WriteString("\n")
+		}
+		cur := ""
+		var curLink Hyperlink
+		line := c.Line(y, LineCopy{}).Line
+		for x := 0; x < len(line); {
+			cell := line[x]
+			if st := sgr(cell); st != cur {
+				buf.WriteString(st)
+				cur = st
+			}
+			if link, _ := cell.Hyperlink(); link != curLink {
+				buf.WriteString(osc8(link))
+				curLink = link
+			}
+			buf.WriteString(cell.Grapheme())
+			x += gwutil.Max(cell.Width(), 1)
+		}
+		if curLink.URL != "" {
+			buf.WriteString(osc8(Hyperlink{}))
+		}
+		buf.WriteString("\x1b[0m")
+	}
+	return buf.String()
+}
+
+// RenderToHTML renders w without a terminal, as RenderCanvas does, and returns it as an
+// HTML pre element, with inline styles for colors and text styles, and hyperlinks as
+// anchors - e.g. for documentation. Cells without a color use the page's.
+func RenderToHTML(w IWidget, width int, mode ColorMode, opts ...ExportOptions) string {
+	c := RenderCanvas(w, width, mode, opts...)
+	defer ReleaseCanvas(c)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<pre class="gowid">`)
+	for y := 0; y < c.BoxRows(); y++ {
+		if y > 0 {
+			buf.WriteString("\n")
+		}
+		line := c.Line(y, LineCopy{}).Line
+		for x := 0; x < len(line); {
+			// Group the cells with the same style and link into one span
+			style := cssStyle(line[x])
+			link, _ := line[x].Hyperlink()
+			var text bytes.Buffer
+			for x < len(line) {
+				l2, _ := line[x].Hyperlink()
+				if l2 != link || cssStyle(line[x]) != style {
+					break
+				}
+				text.WriteString(line[x].Grapheme())
+				x += gwutil.Max(line[x].Width(), 1)
+			}
+			if link.URL != "" {
+				fmt.Fprintf(&buf, `<a href="%s">`, html.EscapeString(link.URL))
+			}
+			if style != "" {
+				fmt.Fprintf(&buf, `<span style="%s">`, style)
+			}
+			buf.WriteString(html.EscapeString(text.String()))
+			if style != "" {
+				buf.WriteString("</span>")
+			}
+			if link.URL != "" {
+				buf.WriteString("</a>")
+			}
+		}
+	}
+	buf.WriteString("</pre>")
+	return buf.String()
+}
+
+var cssAttrs = []struct {
+	attr tcell.AttrMask
+	css  string
+}{
+	{tcell.AttrBold, "font-weight:bold"},
+	{tcell.AttrDim, "opacity:0.5"},
+	{tcell.AttrItalic, "font-style:italic"},
+}
+
+var cssUnderlines = map[tcell.UnderlineStyle]string{
+	tcell.UnderlineStyleSolid:  "underline",
+	tcell.UnderlineStyleDouble: "underline double",
+	tcell.UnderlineStyleCurly:  "underline wavy",
+}
+
+// cssStyle returns the inline CSS for the cell's colors and style, or "" if it has none.
+func cssStyle(c Cell) string {
+	attrs := StyleNone.MergeUnder(c.Style()).OnOff
+	fg, bg := tcellColorOrDefault(c.ForegroundColor()), tcellColorOrDefault(c.BackgroundColor())
+	if attrs&tcell.AttrReverse != 0 {
+		fg, bg = bg, fg
+	}
+	var buf bytes.Buffer
+	add := func(s string) {
+		if buf.Len() > 0 {
+			buf.WriteString(";")
+		}
+		buf.WriteString(s)
+	}
+	if col, ok := cssColor(fg); ok {
+		add("color:" + col)
+	}
+	if col, ok := cssColor(bg); ok {
+		add("background-color:" + col)
+	}
+	for _, a := range cssAttrs {
+		if attrs&a.attr != 0 {
+			add(a.css)
+		}
+	}
+	var decorations []string
+	if us, ok := cssUnderlines[UnderlineStyleOf(attrs)]; ok {
+		decorations = append(decorations, us)
+	}
+	if attrs&tcell.AttrStrikeThrough != 0 {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		d := decorations[0]
+		if len(decorations) > 1 {
+			// A style can't follow the second line
+			d = "underline line-through"
+		}
+		add("text-decoration:" + d)
+	}
+	return buf.String()
+}
+
+// cssColor returns c as a CSS hex color, or false if c is the terminal's default.
+func cssColor(c tcell.Color) (string, bool) {
+	if c == tcell.ColorDefault || !c.Valid() {
+		return "", false
+	}
+	return fmt.Sprintf("#%06x", c.Hex()), true
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
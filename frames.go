@@ -0,0 +1,189 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"time"
+)
+
+//======================================================================
+
+// AnimationFrameFunc is called just before a frame is drawn, with the time of the frame,
+// if it was requested with RequestAnimationFrame.
+type AnimationFrameFunc func(now time.Time, app IApp)
+
+// AnimationFrameID identifies a request made with RequestAnimationFrame, so it can be
+// canceled.
+type AnimationFrameID int
+
+// IAnimationFrames is implemented by apps that can run a function before the next frame
+// is drawn - see RequestAnimationFrame.
+type IAnimationFrames interface {
+	RequestAnimationFrame(f AnimationFrameFunc) AnimationFrameID
+	CancelAnimationFrame(id AnimationFrameID)
+}
+
+// RequestAnimationFrame arranges for f to be called on the app's goroutine just before
+// the next frame is drawn, and for that frame to be drawn. f is called once; an
+// animation requests another frame from f to keep going. Because the app coalesces
+// redraws and may cap the frame rate (see App.SetMaxFPS), animations driven this way
+// render at most once per frame however many are running. If the app doesn't support
+// animation frames, f is run via app.Run, and the returned ID is zero.
+func RequestAnimationFrame(app IApp, f AnimationFrameFunc) AnimationFrameID {
+	if af, ok := app.(IAnimationFrames); ok {
+		return af.RequestAnimationFrame(f)
+	}
+	app.Run(RunFunction(func(app IApp) {
+		f(time.Now(), app)
+	}))
+	return 0
+}
+
+// CancelAnimationFrame stops f, requested with RequestAnimationFrame, from being called,
+// if it hasn't been already.
+func CancelAnimationFrame(app IApp, id AnimationFrameID) {
+	if af, ok := app.(IAnimationFrames); ok {
+		af.CancelAnimationFrame(id)
+	}
+}
+
+//======================================================================
+
+type animationFrame struct {
+	id AnimationFrameID
+	f  AnimationFrameFunc
+}
+
+// frameScheduler decides when the app draws a frame. While the app's main loop runs,
+// redraws are requested rather than done straight away; the loop handles all the events
+// waiting before it draws, so a burst of input or of Redraw calls results in one frame,
+// and the frames are spaced at least interval apart.
+type frameScheduler struct {
+	interval  time.Duration // minimum time between frames; zero means no limit
+	active    bool          // true while the main loop schedules frames
+	pending   bool          // a frame has been requested but not yet drawn
+	last      time.Time     // when the last frame was drawn
+	timer     *time.Timer   // fires when the next frame may be drawn, if waiting
+	callbacks []animationFrame
+	nextID    AnimationFrameID
+}
+
+// nowChan is closed, so always ready to receive.
+var nowChan = make(chan time.Time)
+
+func init() {
+	close(nowChan)
+}
+
+// due returns a channel that receives when the next frame may be drawn - straight away
+// if a frame is pending and isn't waiting for a timer - or nil, which never receives, if
+// no frame is pending.
+func (f *frameScheduler) due() <-chan time.Time {
+	switch {
+	case f.timer != nil:
+		return f.timer.C
+	case f.pending:
+		return nowChan
+	default:
+		return nil
+	}
+}
+
+func (f *frameScheduler) stopTimer() {
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// SetMaxFPS limits the rate at which the app draws frames while the main loop runs. Frames
+// requested sooner than 1/fps seconds after the last are delayed, and coalesced into one.
+// Zero, the default, means no limit - the app draws once it has handled the events
+// waiting. Call from the app's goroutine.
+func (a *App) SetMaxFPS(fps int) {
+	if fps <= 0 {
+		a.frames.interval = 0
+	} else {
+		a.frames.interval = time.Second / time.Duration(fps)
+	}
+}
+
+// MaxFPS returns the limit on the frame rate set with SetMaxFPS, or zero if there is none.
+func (a *App) MaxFPS() int {
+	if a.frames.interval == 0 {
+		return 0
+	}
+	return int(time.Second / a.frames.interval)
+}
+
+// RequestAnimationFrame arranges for f to be called just before the next frame is drawn -
+// see the package function RequestAnimationFrame. Call from the app's goroutine.
+func (a *App) RequestAnimationFrame(f AnimationFrameFunc) AnimationFrameID {
+	a.frames.nextID++
+	a.frames.callbacks = append(a.frames.callbacks, animationFrame{id: a.frames.nextID, f: f})
+	if a.frames.active {
+		a.frames.pending = true
+	} else {
+		// Drawn when the app's own loop handles the redraw event
+		a.Redraw()
+	}
+	return a.frames.nextID
+}
+
+// CancelAnimationFrame stops a function requested with RequestAnimationFrame from being
+// called. Call from the app's goroutine.
+func (a *App) CancelAnimationFrame(id AnimationFrameID) {
+	for i, cb := range a.frames.callbacks {
+		if cb.id == id {
+			a.frames.callbacks = append(a.frames.callbacks[:i], a.frames.callbacks[i+1:]...)
+			return
+		}
+	}
+}
+
+// requestFrame redraws the terminal - straight away, unless the main loop is scheduling
+// frames, in which case the frame is drawn once the loop is ready.
+func (a *App) requestFrame() {
+	if a.frames.active {
+		a.frames.pending = true
+	} else {
+		a.RedrawTerminal()
+	}
+}
+
+// drawFrameIfDue draws a requested frame, unless it must wait for the frame rate limit,
+// in which case a timer is set for when it can be drawn.
+func (a *App) drawFrameIfDue() {
+	if !a.frames.pending {
+		return
+	}
+	if a.frames.interval > 0 {
+		if wait := time.Until(a.frames.last.Add(a.frames.interval)); wait > 0 {
+			if a.frames.timer == nil {
+				a.frames.timer = time.NewTimer(wait)
+			}
+			return
+		}
+	}
+	a.RedrawTerminal()
+}
+
+// runAnimationFrames calls the functions requested with RequestAnimationFrame, ahead of
+// drawing a frame. Functions they request are left for the next frame.
+func (a *App) runAnimationFrames(now time.Time) {
+	cbs := a.frames.callbacks
+	a.frames.callbacks = nil
+	for _, cb := range cbs {
+		cb.f(now, a)
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,175 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid/gwutil"
+)
+
+//======================================================================
+
+// Rect is a rectangle of cells, e.g. the area of a canvas occupied by a widget.
+type Rect struct {
+	X, Y int
+	W, H int
+}
+
+func (r Rect) String() string {
+	return fmt.Sprintf("%dx%d@%d,%d", r.W, r.H, r.X, r.Y)
+}
+
+// Contains returns true if the cell at column x and row y is inside the rectangle.
+func (r Rect) Contains(x, y int) bool {
+	return x >= r.X && y >= r.Y && x < r.X+r.W && y < r.Y+r.H
+}
+
+// Empty returns true if the rectangle contains no cells.
+func (r Rect) Empty() bool {
+	return r.W <= 0 || r.H <= 0
+}
+
+// Intersect returns the area covered by both rectangles.
+func (r Rect) Intersect(r2 Rect) Rect {
+	x1, y1 := gwutil.Max(r.X, r2.X), gwutil.Max(r.Y, r2.Y)
+	x2, y2 := gwutil.Min(r.X+r.W, r2.X+r2.W), gwutil.Min(r.Y+r.H, r2.Y+r2.H)
+	if x2 <= x1 || y2 <= y1 {
+		return Rect{}
+	}
+	return Rect{X: x1, Y: y1, W: x2 - x1, H: y2 - y1}
+}
+
+// MarkRect records the whole area of the canvas under the supplied name, using two
+// canvas marks. Marks move with the canvas as it is composed into larger canvases,
+// so once rendering is done, MarkedRect can find where the canvas ended up - e.g. to
+// position a popup next to a widget.
+func MarkRect(c ICanvas, name string) {
+	c.SetMark(name+"/tl", 0, 0)
+	c.SetMark(name+"/br", c.BoxColumns(), c.BoxRows())
+}
+
+// MarkedRect returns the area recorded in the canvas with MarkRect under the supplied
+// name, and false if there is no such area.
+func MarkedRect(c ICanvas, name string) (Rect, bool) {
+	tl, ok := c.GetMark(name + "/tl")
+	if !ok {
+		return Rect{}, false
+	}
+	br, ok := c.GetMark(name + "/br")
+	if !ok {
+		return Rect{}, false
+	}
+	return Rect{X: tl.X, Y: tl.Y, W: br.X - tl.X, H: br.Y - tl.Y}, true
+}
+
+//======================================================================
+
+// IGeometryTracker is implemented by an App that records where widgets are drawn on
+// the screen. Widgets opt in by calling TrackGeometry from their Render function;
+// once the frame is drawn, their screen areas are available until the next frame.
+type IGeometryTracker interface {
+	TrackGeometry(w IIdentity, c ICanvas)
+	GeometryOf(id interface{}) (Rect, bool)
+	WidgetAt(x, y int) IIdentity
+}
+
+// TrackGeometry asks the app to record where the canvas c, rendered by w, is drawn on
+// the screen. It does nothing if the app doesn't track geometry.
+func TrackGeometry(w IIdentity, c ICanvas, app IApp) {
+	if gt, ok := app.(IGeometryTracker); ok {
+		gt.TrackGeometry(w, c)
+	}
+}
+
+// GeometryOf returns the screen area of the widget with the given ID when the last
+// frame was drawn, and false if the widget wasn't tracked or the app doesn't track
+// geometry.
+func GeometryOf(id interface{}, app IApp) (Rect, bool) {
+	if gt, ok := app.(IGeometryTracker); ok {
+		return gt.GeometryOf(id)
+	}
+	return Rect{}, false
+}
+
+// WidgetAt returns the tracked widget drawn at the given screen coordinates in the last
+// frame, or nil.
+func WidgetAt(x, y int, app IApp) IIdentity {
+	if gt, ok := app.(IGeometryTracker); ok {
+		return gt.WidgetAt(x, y)
+	}
+	return nil
+}
+
+type trackedGeometry struct {
+	w    IIdentity
+	rect Rect
+}
+
+const geometryMarkPrefix = "gowid-geometry-"
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// TrackGeometry marks the canvas so that its position can be found once the frame is
+// composed. If the widget renders more than once in a frame, the last canvas to reach
+// the screen wins.
+func (a *App) TrackGeometry(w IIdentity, c ICanvas) {
+	a.trackMu.Lock()
+	defer a.trackMu.Unlock()
+	MarkRect(c, fmt.Sprintf("%s%d", geometryMarkPrefix, len(a.geometryPending)))
+	a.geometryPending = append(a.geometryPending, w)
+}
+
+// GeometryOf returns the screen area, clipped to the screen, of the tracked widget with
+// the given ID in the last frame.
+func (a *App) GeometryOf(id interface{}) (Rect, bool) {
+	for i := len(a.geometry) - 1; i >= 0; i-- {
+		if a.geometry[i].w.ID() == id {
+			return a.geometry[i].rect, true
+		}
+	}
+	return Rect{}, false
+}
+
+// WidgetAt returns the tracked widget drawn at the given screen coordinates in the last
+// frame, or nil. If tracked widgets overlap there, e.g. because one contains another,
+// the one with the smallest area is returned, and of those, the one drawn last.
+func (a *App) WidgetAt(x, y int) IIdentity {
+	var res IIdentity
+	area := -1
+	for _, g := range a.geometry {
+		if g.rect.Contains(x, y) && (area == -1 || g.rect.W*g.rect.H <= area) {
+			res = g.w
+			area = g.rect.W * g.rect.H
+		}
+	}
+	return res
+}
+
+// collectGeometry finds the tracked widgets' areas in the screen canvas, then removes
+// the marks used to find them.
+func (a *App) collectGeometry(canvas ICanvas) {
+	screen := Rect{W: canvas.BoxColumns(), H: canvas.BoxRows()}
+	res := a.geometry[:0]
+	for i, w := range a.geometryPending {
+		name := fmt.Sprintf("%s%d", geometryMarkPrefix, i)
+		if r, ok := MarkedRect(canvas, name); ok {
+			if r = r.Intersect(screen); !r.Empty() {
+				res = append(res, trackedGeometry{w: w, rect: r})
+			}
+			canvas.RemoveMark(name + "/tl")
+			canvas.RemoveMark(name + "/br")
+		}
+		a.geometryPending[i] = nil
+	}
+	a.geometry = res
+	a.geometryPending = a.geometryPending[:0]
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,75 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+//======================================================================
+
+// Lerp returns the color a fraction t of the way from c1 to c2, where t is clamped to
+// [0, 1]. The colors are blended in the CIE-L*a*b* space so the steps look even. The
+// result is an RGBColor, so like any RGBColor it renders as the closest available color
+// in the terminal's color mode. If either color has no RGB value - e.g. NoColor or
+// DefaultColor - then c1 is returned for t < 0.5 and c2 otherwise.
+func Lerp(c1, c2 IColor, t float64) IColor {
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	from, ok1 := colorfulOf(c1)
+	to, ok2 := colorfulOf(c2)
+	if !ok1 || !ok2 {
+		if t < 0.5 {
+			return c1
+		}
+		return c2
+	}
+	r, g, b := from.BlendLab(to, t).Clamped().RGB255()
+	return MakeRGBColorExt(int(r), int(g), int(b))
+}
+
+// MakeGradient returns steps colors running evenly from start to end, inclusive.
+// This is useful for e.g. coloring the cells of a heatmap or the bars of a chart.
+func MakeGradient(start, end IColor, steps int) []IColor {
+	if steps <= 0 {
+		return []IColor{}
+	}
+	res := make([]IColor, steps)
+	if steps == 1 {
+		res[0] = start
+		return res
+	}
+	for i := 0; i < steps; i++ {
+		res[i] = Lerp(start, end, float64(i)/float64(steps-1))
+	}
+	return res
+}
+
+// colorfulOf returns the RGB value of an IColor, as the color would be rendered in a
+// 24-bit terminal, and false if it doesn't have one.
+func colorfulOf(c IColor) (colorful.Color, bool) {
+	if rgb, ok := c.(RGBColor); ok {
+		res, _ := colorful.MakeColor(rgb)
+		return res, true
+	}
+	tc, ok := c.ToTCellColor(Mode24BitColors)
+	if !ok || tc == ColorNone {
+		return colorful.Color{}, false
+	}
+	r, g, b := tc.ToTCell().RGB()
+	if r < 0 {
+		return colorful.Color{}, false
+	}
+	return colorful.Color{R: float64(r) / 255.0, G: float64(g) / 255.0, B: float64(b) / 255.0}, true
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
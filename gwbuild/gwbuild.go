@@ -0,0 +1,394 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package gwbuild constructs a widget hierarchy from a declarative document - JSON
+// or YAML - rather than from code. Each node of the document names a widget type,
+// and may give it an ID, text, palette references for its style, the dimension it
+// takes inside its container, and its children. A registry maps type names to
+// constructors; the common gowid widgets are registered by default, and an
+// application can register its own. After building, widgets can be looked up by ID
+// to attach callbacks or to read their state.
+//
+// For example, in YAML:
+//
+//	type: framed
+//	title: Login
+//	child:
+//	  type: pile
+//	  children:
+//	    - {type: edit, id: user, props: {caption: "User: "}}
+//	    - {type: divider}
+//	    - {type: button, id: ok, text: OK, style: btn, focusStyle: btnfocus, dim: fixed}
+package gwbuild
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+//======================================================================
+
+// Spec is one node of a declarative layout. Fields that a widget type doesn't use
+// are ignored. Type-specific settings that don't have a field of their own go in
+// Props.
+type Spec struct {
+	Type       string                 `json:"type" yaml:"type"`
+	ID         string                 `json:"id,omitempty" yaml:"id,omitempty"`
+	Text       string                 `json:"text,omitempty" yaml:"text,omitempty"`
+	Title      string                 `json:"title,omitempty" yaml:"title,omitempty"`
+	Style      string                 `json:"style,omitempty" yaml:"style,omitempty"`           // palette entry name
+	FocusStyle string                 `json:"focusStyle,omitempty" yaml:"focusStyle,omitempty"` // palette entry name
+	Dim        string                 `json:"dim,omitempty" yaml:"dim,omitempty"`               // dimension within a pile or columns
+	Align      string                 `json:"align,omitempty" yaml:"align,omitempty"`
+	Width      string                 `json:"width,omitempty" yaml:"width,omitempty"`
+	Height     string                 `json:"height,omitempty" yaml:"height,omitempty"`
+	Child      *Spec                  `json:"child,omitempty" yaml:"child,omitempty"`
+	Children   []*Spec                `json:"children,omitempty" yaml:"children,omitempty"`
+	Props      map[string]interface{} `json:"props,omitempty" yaml:"props,omitempty"`
+}
+
+func (s *Spec) String() string {
+	if s.ID != "" {
+		return fmt.Sprintf("%s#%s", s.Type, s.ID)
+	}
+	return s.Type
+}
+
+// Prop returns the value of a prop, if present.
+func (s *Spec) Prop(name string) (interface{}, bool) {
+	v, ok := s.Props[name]
+	return v, ok
+}
+
+// StringProp returns the named prop as a string, or def if it isn't set.
+func (s *Spec) StringProp(name string, def string) (string, error) {
+	v, ok := s.Prop(name)
+	if !ok {
+		return def, nil
+	}
+	res, ok := v.(string)
+	if !ok {
+		return def, errors.Errorf("prop %q should be a string, found %v", name, v)
+	}
+	return res, nil
+}
+
+// IntProp returns the named prop as an int, or def if it isn't set. JSON numbers
+// are accepted if they have no fractional part.
+func (s *Spec) IntProp(name string, def int) (int, error) {
+	v, ok := s.Prop(name)
+	if !ok {
+		return def, nil
+	}
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), nil
+		}
+	}
+	return def, errors.Errorf("prop %q should be an integer, found %v", name, v)
+}
+
+// BoolProp returns the named prop as a bool, or def if it isn't set.
+func (s *Spec) BoolProp(name string, def bool) (bool, error) {
+	v, ok := s.Prop(name)
+	if !ok {
+		return def, nil
+	}
+	res, ok := v.(bool)
+	if !ok {
+		return def, errors.Errorf("prop %q should be true or false, found %v", name, v)
+	}
+	return res, nil
+}
+
+//======================================================================
+
+// Constructor makes the widget for a spec. Children should be built with the
+// context's Build method, so that their IDs are recorded.
+type Constructor func(spec *Spec, ctx *Context) (gowid.IWidget, error)
+
+// Registry maps widget type names to constructors.
+type Registry map[string]Constructor
+
+// Register adds or replaces the constructor for a widget type.
+func (r Registry) Register(name string, fn Constructor) {
+	r[name] = fn
+}
+
+// Types returns the registered type names, sorted.
+func (r Registry) Types() []string {
+	res := make([]string, 0, len(r))
+	for k := range r {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+type UnknownTypeError struct {
+	Type string
+}
+
+var _ error = UnknownTypeError{}
+
+func (e UnknownTypeError) Error() string {
+	return fmt.Sprintf("Unknown widget type %q", e.Type)
+}
+
+type DuplicateIDError struct {
+	ID string
+}
+
+var _ error = DuplicateIDError{}
+
+func (e DuplicateIDError) Error() string {
+	return fmt.Sprintf("Widget ID %q is used more than once", e.ID)
+}
+
+// BuildError reports the node that failed to build. Path identifies it, starting
+// from the root, e.g. "framed/pile[2]/button#ok".
+type BuildError struct {
+	Path string
+	Err  error
+}
+
+var _ error = BuildError{}
+
+func (e BuildError) Error() string {
+	return fmt.Sprintf("Could not build %s: %v", e.Path, e.Err)
+}
+
+func (e BuildError) Cause() error {
+	return e.Err
+}
+
+//======================================================================
+
+// Builder makes widget hierarchies from specs using its registry.
+type Builder struct {
+	Registry Registry
+}
+
+// New returns a builder with the default widget types registered.
+func New() *Builder {
+	return &Builder{
+		Registry: DefaultRegistry(),
+	}
+}
+
+// Register adds or replaces the constructor for a widget type.
+func (b *Builder) Register(name string, fn Constructor) {
+	b.Registry.Register(name, fn)
+}
+
+// Build makes the widget hierarchy described by spec.
+func (b *Builder) Build(spec *Spec) (*Layout, error) {
+	ctx := &Context{
+		builder: b,
+		ids:     make(map[string]gowid.IWidget),
+	}
+	root, err := ctx.Build(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &Layout{
+		root: root,
+		ids:  ctx.ids,
+	}, nil
+}
+
+// BuildJSON decodes a JSON spec and builds it.
+func (b *Builder) BuildJSON(r io.Reader) (*Layout, error) {
+	var spec Spec
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, err
+	}
+	return b.Build(&spec)
+}
+
+// BuildYAML decodes a YAML spec and builds it.
+func (b *Builder) BuildYAML(r io.Reader) (*Layout, error) {
+	var spec Spec
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		return nil, err
+	}
+	return b.Build(&spec)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// Context is passed to constructors while a layout is built.
+type Context struct {
+	builder *Builder
+	ids     map[string]gowid.IWidget
+	path    []string
+}
+
+// Build makes the widget for spec, recording its ID. Errors are wrapped in a
+// BuildError giving the path to the failing node.
+func (c *Context) Build(spec *Spec) (gowid.IWidget, error) {
+	if spec == nil {
+		return nil, c.errorf(errors.New("missing widget"))
+	}
+	c.path = append(c.path, spec.String())
+	defer func() {
+		c.path = c.path[:len(c.path)-1]
+	}()
+
+	fn, ok := c.builder.Registry[spec.Type]
+	if !ok {
+		return nil, c.errorf(UnknownTypeError{Type: spec.Type})
+	}
+	res, err := fn(spec, c)
+	if err != nil {
+		if _, ok := err.(BuildError); ok {
+			return nil, err
+		}
+		return nil, c.errorf(err)
+	}
+	if spec.ID != "" {
+		if _, ok := c.ids[spec.ID]; ok {
+			return nil, c.errorf(DuplicateIDError{ID: spec.ID})
+		}
+		c.ids[spec.ID] = res
+	}
+	return withStyle(res, spec), nil
+}
+
+// BuildChildren makes each child of spec, paired with the dimension it asks for,
+// or def if it doesn't specify one.
+func (c *Context) BuildChildren(spec *Spec, def gowid.IWidgetDimension) ([]gowid.IContainerWidget, error) {
+	res := make([]gowid.IContainerWidget, 0, len(spec.Children))
+	for i, child := range spec.Children {
+		c.path[len(c.path)-1] = fmt.Sprintf("%v[%d]", spec, i)
+		w, err := c.Build(child)
+		if err != nil {
+			return nil, err
+		}
+		d := def
+		if child.Dim != "" {
+			if d, err = ParseDimension(child.Dim); err != nil {
+				return nil, c.errorf(err)
+			}
+		}
+		res = append(res, &gowid.ContainerWidget{IWidget: w, D: d})
+	}
+	c.path[len(c.path)-1] = spec.String()
+	return res, nil
+}
+
+func (c *Context) errorf(err error) error {
+	return BuildError{
+		Path: strings.Join(c.path, "/"),
+		Err:  err,
+	}
+}
+
+//======================================================================
+
+// Layout is a built widget hierarchy.
+type Layout struct {
+	root gowid.IWidget
+	ids  map[string]gowid.IWidget
+}
+
+func (l *Layout) Root() gowid.IWidget {
+	return l.root
+}
+
+// Widget returns the widget built for the spec with the given ID.
+func (l *Layout) Widget(id string) (gowid.IWidget, bool) {
+	w, ok := l.ids[id]
+	return w, ok
+}
+
+// IDs returns the IDs of the widgets in the layout, sorted.
+func (l *Layout) IDs() []string {
+	res := make([]string, 0, len(l.ids))
+	for k := range l.ids {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+//======================================================================
+
+// ParseDimension converts a dimension from a spec - "fixed", "flow", "weight N",
+// "units N" or "ratio F" - to a gowid dimension.
+func ParseDimension(s string) (gowid.IWidgetDimension, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 1 {
+		switch fields[0] {
+		case "fixed":
+			return gowid.RenderFixed{}, nil
+		case "flow":
+			return gowid.RenderFlow{}, nil
+		}
+	} else if len(fields) == 2 {
+		switch fields[0] {
+		case "weight", "units":
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, errors.Errorf("invalid dimension %q", s)
+			}
+			if fields[0] == "weight" {
+				return gowid.RenderWithWeight{W: n}, nil
+			}
+			return gowid.RenderWithUnits{U: n}, nil
+		case "ratio":
+			f, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid dimension %q", s)
+			}
+			return gowid.RenderWithRatio{R: f}, nil
+		}
+	}
+	return nil, errors.Errorf("invalid dimension %q", s)
+}
+
+func parseHAlign(s string) (gowid.IHAlignment, error) {
+	switch s {
+	case "", "left":
+		return gowid.HAlignLeft{}, nil
+	case "middle", "center":
+		return gowid.HAlignMiddle{}, nil
+	case "right":
+		return gowid.HAlignRight{}, nil
+	}
+	return nil, errors.Errorf("invalid horizontal alignment %q", s)
+}
+
+func parseVAlign(s string) (gowid.IVAlignment, error) {
+	switch s {
+	case "", "top":
+		return gowid.VAlignTop{}, nil
+	case "middle", "center":
+		return gowid.VAlignMiddle{}, nil
+	case "bottom":
+		return gowid.VAlignBottom{}, nil
+	}
+	return nil, errors.Errorf("invalid vertical alignment %q", s)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,114 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package gwbuild
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/checkbox"
+	"github.com/gcla/gowid/widgets/edit"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+var yaml1 = `
+type: border
+title: Login
+props: {runes: ascii}
+child:
+  type: pile
+  children:
+    - {type: edit, id: user, text: bob, props: {caption: "U:"}}
+    - {type: divider}
+    - type: columns
+      children:
+        - {type: button, id: ok, text: OK, style: btn, dim: fixed}
+        - {type: checkbox, id: remember, props: {checked: true}, dim: fixed}
+`
+
+func TestYAML1(t *testing.T) {
+	l, err := New().BuildYAML(strings.NewReader(yaml1))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ok", "remember", "user"}, l.IDs())
+
+	w, ok := l.Widget("user")
+	assert.True(t, ok)
+	assert.Equal(t, "bob", w.(*edit.Widget).Text())
+	w, _ = l.Widget("remember")
+	assert.True(t, w.(*checkbox.Widget).IsChecked())
+	w, _ = l.Widget("ok")
+	_, ok = w.(*button.Widget)
+	assert.True(t, ok)
+
+	c := l.Root().Render(gowid.RenderFlowWith{C: 10}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, strings.Join([]string{
+		"+ Login -+",
+		"|U:bob   |",
+		"|--------|",
+		"|<OK>[X] |",
+		"+--------+",
+	}, "\n"), c.String())
+}
+
+func TestJSON1(t *testing.T) {
+	b := New()
+	b.Register("greeting", func(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+		name, err := spec.StringProp("name", "world")
+		if err != nil {
+			return nil, err
+		}
+		return text.New("hello " + name), nil
+	})
+	l, err := b.BuildJSON(strings.NewReader(`{"type": "pile", "children": [{"type": "greeting", "id": "g", "props": {"name": "json"}}]}`))
+	assert.NoError(t, err)
+	c := l.Root().Render(gowid.RenderFlowWith{C: 10}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "hello json", c.String())
+
+	_, err = b.BuildJSON(strings.NewReader(`{"type": "pile", "bogus": 1}`))
+	assert.Error(t, err)
+}
+
+func TestErrors1(t *testing.T) {
+	_, err := New().Build(&Spec{Type: "pile", Children: []*Spec{{Type: "text"}, {Type: "nope"}}})
+	assert.Equal(t, "pile[1]/nope", err.(BuildError).Path)
+	assert.Equal(t, UnknownTypeError{Type: "nope"}, errors.Cause(err))
+
+	_, err = New().Build(&Spec{Type: "pile", Children: []*Spec{{Type: "text", ID: "a"}, {Type: "text", ID: "a"}}})
+	assert.Equal(t, DuplicateIDError{ID: "a"}, errors.Cause(err))
+
+	_, err = New().Build(&Spec{Type: "framed"})
+	assert.Equal(t, "framed", err.(BuildError).Path)
+
+	_, err = New().Build(&Spec{Type: "checkbox", Props: map[string]interface{}{"checked": "yes"}})
+	assert.Error(t, err)
+}
+
+func TestDimension1(t *testing.T) {
+	for s, d := range map[string]gowid.IWidgetDimension{
+		"fixed":     gowid.RenderFixed{},
+		"flow":      gowid.RenderFlow{},
+		"weight 2":  gowid.RenderWithWeight{W: 2},
+		"units 10":  gowid.RenderWithUnits{U: 10},
+		"ratio 0.5": gowid.RenderWithRatio{R: 0.5},
+	} {
+		res, err := ParseDimension(s)
+		assert.NoError(t, err)
+		assert.Equal(t, d, res)
+	}
+	_, err := ParseDimension("weight")
+	assert.Error(t, err)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,224 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gwbuild
+
+import (
+	"unicode/utf8"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/border"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/checkbox"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/divider"
+	"github.com/gcla/gowid/widgets/edit"
+	"github.com/gcla/gowid/widgets/fill"
+	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/hpadding"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/gcla/gowid/widgets/vpadding"
+	"github.com/pkg/errors"
+)
+
+//======================================================================
+
+// DefaultRegistry returns a registry with constructors for these types:
+//
+//	text      Text, Align
+//	edit      Text, props caption (string)
+//	button    Child, or Text for a text label
+//	checkbox  props checked (bool)
+//	divider   Text is the rune to repeat, default '-'
+//	fill      Text is the rune to fill with, default ' '
+//	pile      Children - each defaults to dim "flow"
+//	columns   Children - each defaults to dim "weight 1"
+//	framed    Child, Title
+//	border    Child, Title, Align for the title, props runes (ascii|light|rounded|double|heavy)
+//	hpadding  Child, Align, Width
+//	vpadding  Child, Align, Height
+//
+// For any type, Style and FocusStyle wrap the widget in a styled widget using those
+// palette entries; looking up the widget by ID returns the unstyled widget.
+func DefaultRegistry() Registry {
+	return Registry{
+		"text":     makeText,
+		"edit":     makeEdit,
+		"button":   makeButton,
+		"checkbox": makeCheckbox,
+		"divider":  makeDivider,
+		"fill":     makeFill,
+		"pile":     makePile,
+		"columns":  makeColumns,
+		"framed":   makeFramed,
+		"border":   makeBorder,
+		"hpadding": makeHPadding,
+		"vpadding": makeVPadding,
+	}
+}
+
+// withStyle applies the spec's palette references, if any.
+func withStyle(w gowid.IWidget, spec *Spec) gowid.IWidget {
+	switch {
+	case spec.Style != "" && spec.FocusStyle != "":
+		return styled.NewExt(w, gowid.MakePaletteRef(spec.Style), gowid.MakePaletteRef(spec.FocusStyle))
+	case spec.Style != "":
+		return styled.New(w, gowid.MakePaletteRef(spec.Style))
+	case spec.FocusStyle != "":
+		return styled.NewFocus(w, gowid.MakePaletteRef(spec.FocusStyle))
+	}
+	return w
+}
+
+func runeOf(s string, def rune) rune {
+	if s == "" {
+		return def
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func makeText(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	align, err := parseHAlign(spec.Align)
+	if err != nil {
+		return nil, err
+	}
+	return text.New(spec.Text, text.Options{Align: align}), nil
+}
+
+func makeEdit(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	caption, err := spec.StringProp("caption", "")
+	if err != nil {
+		return nil, err
+	}
+	return edit.New(edit.Options{Caption: caption, Text: spec.Text}), nil
+}
+
+func makeButton(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	var inner gowid.IWidget = text.New(spec.Text)
+	if spec.Child != nil {
+		var err error
+		if inner, err = ctx.Build(spec.Child); err != nil {
+			return nil, err
+		}
+	}
+	return button.New(inner), nil
+}
+
+func makeCheckbox(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	checked, err := spec.BoolProp("checked", false)
+	if err != nil {
+		return nil, err
+	}
+	return checkbox.New(checked), nil
+}
+
+func makeDivider(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	return divider.New(divider.Options{Chr: runeOf(spec.Text, '-')}), nil
+}
+
+func makeFill(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	return fill.New(runeOf(spec.Text, ' ')), nil
+}
+
+func makePile(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	children, err := ctx.BuildChildren(spec, gowid.RenderFlow{})
+	if err != nil {
+		return nil, err
+	}
+	return pile.New(children), nil
+}
+
+func makeColumns(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	children, err := ctx.BuildChildren(spec, gowid.RenderWithWeight{W: 1})
+	if err != nil {
+		return nil, err
+	}
+	return columns.New(children), nil
+}
+
+func makeFramed(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	inner, err := ctx.Build(spec.Child)
+	if err != nil {
+		return nil, err
+	}
+	return framed.New(inner, framed.Options{
+		Frame: framed.UnicodeFrame,
+		Title: spec.Title,
+	}), nil
+}
+
+var borderRunes = map[string]border.Runes{
+	"ascii":   border.AsciiRunes,
+	"light":   border.LightRunes,
+	"rounded": border.RoundedRunes,
+	"double":  border.DoubleRunes,
+	"heavy":   border.HeavyRunes,
+}
+
+func makeBorder(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	inner, err := ctx.Build(spec.Child)
+	if err != nil {
+		return nil, err
+	}
+	name, err := spec.StringProp("runes", "light")
+	if err != nil {
+		return nil, err
+	}
+	runes, ok := borderRunes[name]
+	if !ok {
+		return nil, errors.Errorf("invalid border runes %q", name)
+	}
+	align, err := parseHAlign(spec.Align)
+	if err != nil {
+		return nil, err
+	}
+	return border.New(inner, border.Options{
+		Runes:      runes,
+		Title:      spec.Title,
+		TitleAlign: align,
+	}), nil
+}
+
+func makeHPadding(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	inner, err := ctx.Build(spec.Child)
+	if err != nil {
+		return nil, err
+	}
+	align, err := parseHAlign(spec.Align)
+	if err != nil {
+		return nil, err
+	}
+	width, err := ParseDimension(spec.Width)
+	if err != nil {
+		return nil, err
+	}
+	return hpadding.New(inner, align, width), nil
+}
+
+func makeVPadding(spec *Spec, ctx *Context) (gowid.IWidget, error) {
+	inner, err := ctx.Build(spec.Child)
+	if err != nil {
+		return nil, err
+	}
+	align, err := parseVAlign(spec.Align)
+	if err != nil {
+		return nil, err
+	}
+	height, err := ParseDimension(spec.Height)
+	if err != nil {
+		return nil, err
+	}
+	return vpadding.New(inner, align, height), nil
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -4,11 +4,14 @@
 package gwtest
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/widgets/columns"
 	"github.com/gcla/gowid/widgets/edit"
+	"github.com/gcla/gowid/widgets/holder"
+	"github.com/gcla/gowid/widgets/list"
 	"github.com/gcla/gowid/widgets/pile"
 	"github.com/gcla/gowid/widgets/styled"
 	"github.com/stretchr/testify/assert"
@@ -77,6 +80,93 @@ func Test1(t *testing.T) {
 	assert.Equal(t, 0, c2.Focus())
 }
 
+func TestFocusPathByID1(t *testing.T) {
+	fx := gowid.RenderFixed{}
+	row := func(id string) gowid.IContainerWidget {
+		return &gowid.ContainerWidget{IWidget: gowid.WithID(edit.New(edit.Options{Text: id}), id), D: fx}
+	}
+	inner := columns.New([]gowid.IContainerWidget{row("x"), row("y")})
+	p1 := pile.New([]gowid.IContainerWidget{row("a"), row("b"), &gowid.ContainerWidget{IWidget: inner, D: fx}})
+	p1.SetFocus(D, 2)
+	inner.SetFocus(D, 1)
+
+	path := gowid.FocusPathByID(p1)
+	assert.Equal(t, []gowid.FocusStep{{Pos: 2}, {ID: "y", Pos: 1}}, path)
+
+	// Rebuild with new rows and the same IDs - focus follows the IDs, not the positions
+	inner2 := columns.New([]gowid.IContainerWidget{row("w"), row("x"), row("y")})
+	p2 := pile.New([]gowid.IContainerWidget{row("b"), row("a"), &gowid.ContainerWidget{IWidget: inner2, D: fx}})
+	r := gowid.SetFocusPathByID(p2, []gowid.FocusStep{{ID: "a", Pos: 0}}, D)
+	assert.True(t, r.Succeeded)
+	assert.Equal(t, 1, p2.Focus())
+
+	r = gowid.SetFocusPathByID(p2, path, D)
+	assert.True(t, r.Succeeded)
+	assert.Equal(t, 2, p2.Focus())
+	assert.Equal(t, 2, inner2.Focus())
+
+	// A missing ID falls back to the position
+	r = gowid.SetFocusPathByID(p2, []gowid.FocusStep{{Pos: 2}, {ID: "z", Pos: 0}}, D)
+	assert.False(t, r.Succeeded)
+	assert.Equal(t, 1, r.FailedLevel)
+	assert.Equal(t, 0, inner2.Focus())
+}
+
+func TestSnapshot1(t *testing.T) {
+	build := func() (*pile.Widget, *columns.Widget, *holder.Widget, *list.Widget, map[string]gowid.IWidget) {
+		rows := make([]gowid.IWidget, 0)
+		for _, s := range []string{"a", "b", "c", "d"} {
+			rows = append(rows, edit.New(edit.Options{Text: s}))
+		}
+		l := list.New(list.NewSimpleListWalker(rows))
+		alt := map[string]gowid.IWidget{
+			"one": gowid.WithID(edit.New(edit.Options{Text: "one"}), "one"),
+			"two": gowid.WithID(edit.New(edit.Options{Text: "two"}), "two"),
+		}
+		h := holder.New(alt["one"])
+		cols := columns.New([]gowid.IContainerWidget{
+			&gowid.ContainerWidget{IWidget: gowid.WithID(h, "holder"), D: gowid.RenderWithWeight{W: 1}},
+			&gowid.ContainerWidget{IWidget: gowid.WithID(l, "list"), D: gowid.RenderWithWeight{W: 1}},
+		})
+		p := pile.New([]gowid.IContainerWidget{
+			&gowid.ContainerWidget{IWidget: edit.New(), D: gowid.RenderFlow{}},
+			&gowid.ContainerWidget{IWidget: gowid.WithID(cols, "cols"), D: gowid.RenderWithWeight{W: 1}},
+		})
+		return p, cols, h, l, alt
+	}
+
+	p, cols, h, l, alt := build()
+	p.SetFocus(D, 1)
+	cols.SetFocus(D, 1)
+	cols.SetDimensions([]gowid.IWidgetDimension{gowid.RenderWithUnits{U: 5}, gowid.RenderWithWeight{W: 2}}, D)
+	h.SetSubWidget(alt["two"], D)
+	l.Walker().SetFocus(list.ListPos(2), D)
+
+	snap, err := gowid.TakeSnapshot(gowid.WithID(p, "root"))
+	assert.NoError(t, err)
+	data, err := json.Marshal(snap)
+	assert.NoError(t, err)
+
+	var snap2 gowid.Snapshot
+	assert.NoError(t, json.Unmarshal(data, &snap2))
+
+	p, cols, h, l, alt = build()
+	err = snap2.Restore(gowid.WithID(p, "root"), &gowid.StateContext{Widgets: alt}, D)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.Focus())
+	assert.Equal(t, 1, cols.Focus())
+	assert.Equal(t, []gowid.IWidgetDimension{gowid.RenderWithUnits{U: 5}, gowid.RenderWithWeight{W: 2}}, cols.Dimensions())
+	assert.Equal(t, alt["two"], h.SubWidget())
+	assert.Equal(t, list.ListPos(2), l.Walker().Focus())
+
+	// Saved state for the wrong widget is reported, but the rest is restored
+	snap2.Widgets["list"] = json.RawMessage(`"oops"`)
+	p, _, h, _, alt = build()
+	err = snap2.Restore(gowid.WithID(p, "root"), &gowid.StateContext{Widgets: alt}, D)
+	assert.Error(t, err)
+	assert.Equal(t, alt["two"], h.SubWidget())
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
@@ -93,7 +93,11 @@ func (d testApp) InCopyMode(...bool) bool {
 	return false
 }
 
-func (d testApp) Log(lvl log.Level, msg string, fields ...gowid.LogField) {
+func (d testApp) Logger() gowid.ILogger {
+	panic(errors.New("Must not call!"))
+}
+
+func (d testApp) Log(lvl gowid.LogLevel, msg string, fields ...gowid.LogField) {
 	panic(errors.New("Must not call!"))
 }
 
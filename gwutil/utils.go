@@ -41,6 +41,14 @@ func Max(i int, js ...int) int {
 	return res
 }
 
+// Abs returns the absolute value of an integer.
+func Abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
 // LimitTo is a one-liner that uses Min and Max to bound a value. Assumes
 // a <= b.
 func LimitTo(a, v, b int) int {
@@ -0,0 +1,380 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package gwweb serves gowid applications to a web browser. Each WebSocket connection
+// gets its own tcell screen, which writes the terminal's ANSI byte stream to the
+// browser - where it is displayed by xterm.js - and reads key and mouse input back.
+// The same widget tree that runs in a terminal can then run in a browser, without
+// an SSH session.
+//
+// The protocol is deliberately simple. Every message from the browser starts with a
+// type byte. '0' is followed by terminal input, exactly as xterm.js's onData provides
+// it. '1' is followed by a JSON object {"cols": C, "rows": R} giving the size of the
+// browser's terminal. Messages to the browser are binary, and are the bytes to write
+// to the terminal.
+package gwweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gcla/gowid"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+const (
+	MsgInput  = '0'
+	MsgResize = '1'
+)
+
+// Options can be supplied to NewHandler. If CheckOrigin is nil, connections are only
+// accepted from pages served by the same host. Term is the terminfo entry used to
+// encode output - xterm-256color if empty. MaxMessageSize limits messages from the
+// browser - 64KiB if zero. If Page is empty, IndexHTML is served to requests that
+// aren't WebSocket upgrades - see IndexHTML before using it in production. If Log is not nil, sessions that end with an error are
+// logged to it.
+type Options struct {
+	CheckOrigin    func(r *http.Request) bool
+	Term           string
+	MaxMessageSize int
+	Page           string
+	Log            gowid.ILogger
+}
+
+// Session is one browser connection.
+type Session struct {
+	Screen  tcell.Screen
+	Request *http.Request
+	tty     *Tty
+	log     gowid.ILogger
+}
+
+// Done is closed when the browser disconnects.
+func (s *Session) Done() <-chan struct{} {
+	return s.tty.done
+}
+
+func (s *Session) String() string {
+	return fmt.Sprintf("session[%v]", s.Request.RemoteAddr)
+}
+
+// SessionFunc runs an application for a session. The screen is initialized before
+// it is called, and finalized after it returns.
+type SessionFunc func(s *Session) error
+
+type handler struct {
+	fn   SessionFunc
+	opts Options
+}
+
+var _ http.Handler = (*handler)(nil)
+
+// NewHandler returns an http.Handler that runs fn for each WebSocket connection. Other
+// requests are served an HTML page that connects back to the same URL.
+func NewHandler(fn SessionFunc, opts ...Options) http.Handler {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.CheckOrigin == nil {
+		opt.CheckOrigin = sameOrigin
+	}
+	if opt.Term == "" {
+		opt.Term = "xterm-256color"
+	}
+	if opt.MaxMessageSize == 0 {
+		opt.MaxMessageSize = 64 * 1024
+	}
+	if opt.Page == "" {
+		opt.Page = IndexHTML
+	}
+	return &handler{fn: fn, opts: opt}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isWebSocketRequest(r) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, h.opts.Page)
+		return
+	}
+	if !h.opts.CheckOrigin(r) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+	ws, err := upgrade(w, r, h.opts.MaxMessageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tty := newTty(ws)
+	defer tty.Close()
+
+	err = h.run(tty, r)
+	if err != nil {
+		h.log(gowid.LogError, "Session ended with error", gowid.LogField{"remote", r.RemoteAddr}, gowid.LogField{"error", err})
+	}
+}
+
+func (h *handler) run(tty *Tty, r *http.Request) error {
+	ti, err := tcell.LookupTerminfo(h.opts.Term)
+	if err != nil {
+		return err
+	}
+	screen, err := tcell.NewTerminfoScreenFromTtyTerminfo(tty, ti)
+	if err != nil {
+		return err
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	defer screen.Fini()
+
+	return h.fn(&Session{
+		Screen:  screen,
+		Request: r,
+		tty:     tty,
+		log:     h.opts.Log,
+	})
+}
+
+func (h *handler) log(level gowid.LogLevel, msg string, fields ...gowid.LogField) {
+	if h.opts.Log != nil {
+		h.opts.Log.Log(level, msg, fields...)
+	}
+}
+
+// RunApp is a convenience for the common case of a SessionFunc - it makes a gowid App
+// that draws to the session's screen, runs its main loop, and quits the app if the
+// browser disconnects. If args has no logger, the app logs to the handler's
+// Options.Log, or nowhere - not to a log file per session.
+func RunApp(s *Session, args gowid.AppArgs, unhandled gowid.IUnhandledInput) error {
+	args.Screen = s.Screen
+	if args.Logger == nil && args.Log == nil {
+		args.Logger = gowid.DiscardLogger
+		if s.log != nil {
+			args.Logger = s.log
+		}
+	}
+	app, err := gowid.NewApp(args)
+	if err != nil {
+		return err
+	}
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		select {
+		case <-s.Done():
+			// Via the app's goroutine - the app may be quitting already
+			app.Run(gowid.RunFunction(func(app gowid.IApp) {
+				app.Quit()
+			}))
+		case <-quit:
+		}
+	}()
+	app.MainLoop(unhandled)
+	return nil
+}
+
+//======================================================================
+
+// Tty is a tcell.Tty that talks to a browser over a WebSocket.
+type Tty struct {
+	ws       *wsConn
+	input    chan []byte
+	pending  []byte
+	done     chan struct{}
+	drain    chan struct{}
+	cols     int
+	rows     int
+	onResize func()
+	err      error
+	doneOnce sync.Once
+	sync.Mutex
+}
+
+var _ tcell.Tty = (*Tty)(nil)
+
+func newTty(ws *wsConn) *Tty {
+	res := &Tty{
+		ws:    ws,
+		input: make(chan []byte, 64),
+		done:  make(chan struct{}),
+		drain: make(chan struct{}),
+		cols:  80,
+		rows:  24,
+	}
+	go res.readLoop()
+	return res
+}
+
+func (t *Tty) readLoop() {
+	for {
+		_, msg, err := t.ws.readMessage()
+		if err != nil {
+			t.finish(err)
+			return
+		}
+		if len(msg) == 0 {
+			continue
+		}
+		switch msg[0] {
+		case MsgInput:
+			select {
+			case t.input <- msg[1:]:
+			case <-t.done:
+				return
+			}
+		case MsgResize:
+			var sz struct {
+				Cols int `json:"cols"`
+				Rows int `json:"rows"`
+			}
+			if err := json.Unmarshal(msg[1:], &sz); err != nil || sz.Cols <= 0 || sz.Rows <= 0 {
+				continue
+			}
+			t.Lock()
+			t.cols, t.rows = sz.Cols, sz.Rows
+			cb := t.onResize
+			t.Unlock()
+			if cb != nil {
+				cb()
+			}
+		}
+	}
+}
+
+func (t *Tty) finish(err error) {
+	t.doneOnce.Do(func() {
+		t.Lock()
+		t.err = err
+		t.Unlock()
+		close(t.done)
+	})
+}
+
+// Err returns the reason the connection ended, or nil if it is still open.
+func (t *Tty) Err() error {
+	t.Lock()
+	defer t.Unlock()
+	return t.err
+}
+
+func (t *Tty) Start() error {
+	t.Lock()
+	defer t.Unlock()
+	t.drain = make(chan struct{})
+	return nil
+}
+
+func (t *Tty) Stop() error {
+	return nil
+}
+
+// Drain wakes up a pending Read, which returns no data.
+func (t *Tty) Drain() error {
+	t.Lock()
+	defer t.Unlock()
+	select {
+	case <-t.drain:
+	default:
+		close(t.drain)
+	}
+	return nil
+}
+
+func (t *Tty) NotifyResize(cb func()) {
+	t.Lock()
+	defer t.Unlock()
+	t.onResize = cb
+}
+
+func (t *Tty) WindowSize() (tcell.WindowSize, error) {
+	t.Lock()
+	defer t.Unlock()
+	return tcell.WindowSize{Width: t.cols, Height: t.rows}, nil
+}
+
+func (t *Tty) Read(p []byte) (int, error) {
+	if len(t.pending) == 0 {
+		t.Lock()
+		drain := t.drain
+		t.Unlock()
+		select {
+		case b := <-t.input:
+			t.pending = b
+		case <-drain:
+			return 0, nil
+		case <-t.done:
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+func (t *Tty) Write(p []byte) (int, error) {
+	if err := t.ws.writeFrame(opBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *Tty) Close() error {
+	t.finish(io.EOF)
+	return t.ws.close()
+}
+
+//======================================================================
+
+// IndexHTML is a page that displays the app with xterm.js, loaded from a CDN, and
+// connects to the URL it was served from. The scripts are loaded without integrity
+// checks, so the page trusts the CDN - in production, serve xterm.js yourself and set
+// Options.Page to a page that loads it from there.
+const IndexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gowid</title>
+<link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/xterm@4.19.0/css/xterm.css">
+<script src="https://cdn.jsdelivr.net/npm/xterm@4.19.0/lib/xterm.js"></script>
+<script src="https://cdn.jsdelivr.net/npm/xterm-addon-fit@0.5.0/lib/xterm-addon-fit.js"></script>
+<style>html, body, #terminal { height: 100%; margin: 0; background: black; }</style>
+</head>
+<body>
+<div id="terminal"></div>
+<script>
+var term = new Terminal();
+var fit = new FitAddon.FitAddon();
+term.loadAddon(fit);
+term.open(document.getElementById("terminal"));
+fit.fit();
+var proto = location.protocol === "https:" ? "wss://" : "ws://";
+var ws = new WebSocket(proto + location.host + location.pathname + location.search);
+ws.binaryType = "arraybuffer";
+function resize() {
+	ws.send("1" + JSON.stringify({cols: term.cols, rows: term.rows}));
+}
+ws.onopen = function() { resize(); term.focus(); };
+ws.onmessage = function(ev) { term.write(new Uint8Array(ev.data)); };
+ws.onclose = function() { term.write("\r\n[disconnected]\r\n"); };
+term.onData(function(data) { ws.send("0" + data); });
+term.onResize(resize);
+window.addEventListener("resize", function() { fit.fit(); });
+</script>
+</body>
+</html>
+`
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
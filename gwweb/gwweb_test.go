@@ -0,0 +1,149 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package gwweb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestAcceptKey1(t *testing.T) {
+	// Example from RFC 6455
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+type client struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+func dial(t *testing.T, srv *httptest.Server, origin string) (*client, *http.Response) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	assert.NoError(t, err)
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\nOrigin: %s\r\n\r\n",
+		strings.TrimPrefix(srv.URL, "http://"), origin)
+	rd := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(rd, nil)
+	assert.NoError(t, err)
+	return &client{conn: conn, rd: rd}, resp
+}
+
+func (c *client) send(msg string) error {
+	mask := []byte{1, 2, 3, 4}
+	hdr := []byte{0x80 | opText, 0x80 | byte(len(msg))}
+	payload := []byte(msg)
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	_, err := c.conn.Write(append(append(hdr, mask...), payload...))
+	return err
+}
+
+func (c *client) recv() (int, []byte, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.rd, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	size := uint64(hdr[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.rd, ext[:])
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.rd, ext[:])
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, size)
+	_, err := io.ReadFull(c.rd, payload)
+	return int(hdr[0] & 0x0F), payload, err
+}
+
+func TestSession1(t *testing.T) {
+	// Apps without a logger of their own don't make a log file
+	logname := strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0])) + ".log"
+	os.Remove(logname)
+	defer func() {
+		_, err := os.Stat(logname)
+		assert.True(t, os.IsNotExist(err))
+	}()
+
+	ended := make(chan error, 1)
+	srv := httptest.NewServer(NewHandler(func(s *Session) error {
+		err := RunApp(s, gowid.AppArgs{
+			View: text.New("hello web"),
+		}, gowid.UnhandledInputFunc(func(app gowid.IApp, ev interface{}) bool {
+			if evk, ok := ev.(*tcell.EventKey); ok && evk.Rune() == 'q' {
+				app.Quit()
+				return true
+			}
+			return false
+		}))
+		ended <- err
+		return err
+	}))
+	defer srv.Close()
+
+	// Plain requests get the page
+	resp, err := http.Get(srv.URL)
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Contains(t, string(body), "xterm.js")
+
+	// Other origins are refused
+	_, resp = dial(t, srv, "http://evil.example.com")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	c, resp := dial(t, srv, srv.URL)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.NoError(t, c.send(`1{"cols": 20, "rows": 5}`))
+
+	var out bytes.Buffer
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for !strings.Contains(out.String(), "hello web") {
+		op, payload, err := c.recv()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, opBinary, op)
+		out.Write(payload)
+	}
+
+	assert.NoError(t, c.send("0q"))
+	select {
+	case err := <-ended:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "app did not quit")
+	}
+	c.conn.Close()
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
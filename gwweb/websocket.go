@@ -0,0 +1,266 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gwweb
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+//======================================================================
+
+// A minimal server side of RFC 6455 - enough to exchange messages with a browser.
+// Extensions and subprotocols are not supported.
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+type HandshakeError struct {
+	Reason string
+}
+
+var _ error = HandshakeError{}
+
+func (e HandshakeError) Error() string {
+	return fmt.Sprintf("WebSocket handshake failed: %s", e.Reason)
+}
+
+type MessageTooLargeError struct {
+	Size  uint64
+	Limit int
+}
+
+var _ error = MessageTooLargeError{}
+
+func (e MessageTooLargeError) Error() string {
+	return fmt.Sprintf("WebSocket message of %d bytes exceeds the limit of %d", e.Size, e.Limit)
+}
+
+type wsConn struct {
+	conn     net.Conn
+	rd       *bufio.Reader
+	maxSize  int
+	writeMtx sync.Mutex
+	closed   bool
+}
+
+func isWebSocketRequest(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+func headerContains(h http.Header, name string, value string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, tok := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(tok), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameOrigin returns true if the request has no Origin header, or if the origin's
+// host matches the request's host - so pages on other sites can't connect.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func upgrade(w http.ResponseWriter, r *http.Request, maxSize int) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		return nil, HandshakeError{Reason: "method is not GET"}
+	}
+	if !isWebSocketRequest(r) {
+		return nil, HandshakeError{Reason: "not a websocket upgrade request"}
+	}
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		return nil, HandshakeError{Reason: "unsupported version"}
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		return nil, HandshakeError{Reason: "missing Sec-WebSocket-Key"}
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, HandshakeError{Reason: "response does not support hijacking"}
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(resp)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{
+		conn:    conn,
+		rd:      brw.Reader,
+		maxSize: maxSize,
+	}, nil
+}
+
+// readMessage returns the next complete data message, answering pings along the
+// way. When the peer closes the connection, io.EOF is returned.
+func (c *wsConn) readMessage() (int, []byte, error) {
+	var msg []byte
+	msgOp := -1
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return 0, nil, io.EOF
+		case opText, opBinary:
+			if msgOp != -1 {
+				return 0, nil, errors.New("WebSocket data frame interrupts fragmented message")
+			}
+			msgOp = op
+		case opContinuation:
+			if msgOp == -1 {
+				return 0, nil, errors.New("WebSocket continuation frame without a message")
+			}
+		default:
+			return 0, nil, errors.Errorf("WebSocket opcode %d not supported", op)
+		}
+		if len(msg)+len(payload) > c.maxSize {
+			return 0, nil, MessageTooLargeError{Size: uint64(len(msg) + len(payload)), Limit: c.maxSize}
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msgOp, msg, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (bool, int, []byte, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.rd, hdr[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := hdr[0]&0x80 != 0
+	op := int(hdr[0] & 0x0F)
+	masked := hdr[1]&0x80 != 0
+	size := uint64(hdr[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.rd, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.rd, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if !masked {
+		return false, 0, nil, errors.New("WebSocket frame from client is not masked")
+	}
+	if size > uint64(c.maxSize) {
+		return false, 0, nil, MessageTooLargeError{Size: size, Limit: c.maxSize}
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.rd, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.rd, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame sends a single unfragmented, unmasked frame. It is safe to call from
+// more than one goroutine.
+func (c *wsConn) writeFrame(op int, payload []byte) error {
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+	if c.closed {
+		return io.ErrClosedPipe
+	}
+	hdr := make([]byte, 2, 10)
+	hdr[0] = 0x80 | byte(op)
+	switch n := len(payload); {
+	case n < 126:
+		hdr[1] = byte(n)
+	case n <= 0xFFFF:
+		hdr[1] = 126
+		hdr = hdr[:4]
+		binary.BigEndian.PutUint16(hdr[2:], uint16(n))
+	default:
+		hdr[1] = 127
+		hdr = hdr[:10]
+		binary.BigEndian.PutUint64(hdr[2:], uint64(n))
+	}
+	if _, err := c.conn.Write(append(hdr, payload...)); err != nil {
+		return err
+	}
+	if op == opClose {
+		c.closed = true
+	}
+	return nil
+}
+
+func (c *wsConn) close() error {
+	c.writeFrame(opClose, nil)
+	return c.conn.Close()
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,82 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+//======================================================================
+
+// IHoverTarget is implemented by widgets that want to know when the mouse moves
+// over them and off them again. The widget must also opt in to geometry tracking
+// by calling TrackGeometry from its Render function - the App uses the areas from
+// the last frame drawn to decide which widgets are under the mouse. If tracked
+// widgets are nested, each one under the mouse is told. MouseEnter and MouseLeave
+// are called before the mouse event is sent to the widget hierarchy. Without
+// AppArgs.EnableMouseMotion, the App only sees the mouse move when a button is
+// pressed or released.
+type IHoverTarget interface {
+	IIdentity
+	MouseEnter(app IApp)
+	MouseLeave(app IApp)
+}
+
+// IHoverTracker is implemented by an App that tells widgets when the mouse moves
+// over them.
+type IHoverTracker interface {
+	MouseOver(id interface{}) bool
+}
+
+// MouseOver returns true if the widget with the given ID was under the mouse at the
+// last mouse event. It returns false if the app doesn't track hovering.
+func MouseOver(id interface{}, app IApp) bool {
+	if ht, ok := app.(IHoverTracker); ok {
+		return ht.MouseOver(id)
+	}
+	return false
+}
+
+func hoverTargetsContain(targets []IHoverTarget, id interface{}) bool {
+	for _, t := range targets {
+		if t.ID() == id {
+			return true
+		}
+	}
+	return false
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// MouseOver returns true if the widget with the given ID was under the mouse at the
+// last mouse event.
+func (a *App) MouseOver(id interface{}) bool {
+	return hoverTargetsContain(a.hovered, id)
+}
+
+// updateHover works out which tracked widgets are under the mouse at column x and
+// row y, then tells those the mouse has left, followed by those it has entered.
+func (a *App) updateHover(x, y int) {
+	var now []IHoverTarget
+	for _, g := range a.geometry {
+		if t, ok := g.w.(IHoverTarget); ok && g.rect.Contains(x, y) && !hoverTargetsContain(now, t.ID()) {
+			now = append(now, t)
+		}
+	}
+	prev := a.hovered
+	a.hovered = now
+	for _, t := range prev {
+		if !hoverTargetsContain(now, t.ID()) {
+			t.MouseLeave(a)
+		}
+	}
+	for _, t := range now {
+		if !hoverTargetsContain(prev, t.ID()) {
+			t.MouseEnter(a)
+		}
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
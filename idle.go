@@ -0,0 +1,69 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+//======================================================================
+
+// IdleFunc is low-priority work to be done once the app has drawn a frame and has no
+// events waiting - see OnIdle.
+type IdleFunc func(app IApp)
+
+// IIdleScheduler is implemented by apps that can defer work until they're idle.
+type IIdleScheduler interface {
+	OnIdle(f IdleFunc)
+}
+
+// OnIdle arranges for f to be called on the app's goroutine once the app has drawn a
+// frame and has no events waiting - e.g. to prefetch the rows of a list just out of
+// view. f is called once; if it has more to do, it can call OnIdle again. Idle functions
+// are run one at a time, and the app handles any events that arrive before running the
+// next, so long-running work should be split into small pieces. If f changes what's
+// displayed, it should call app.Redraw(). If the app can't defer work, f is run via
+// app.Run.
+func OnIdle(app IApp, f IdleFunc) {
+	if s, ok := app.(IIdleScheduler); ok {
+		s.OnIdle(f)
+	} else {
+		app.Run(RunFunction(f))
+	}
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// OnIdle arranges for f to be called once the app is idle - see the package function
+// OnIdle. Call from the app's goroutine. Idle functions are only run by MainLoop; an app
+// with its own loop can run them with RunIdle.
+func (a *App) OnIdle(f IdleFunc) {
+	a.idle = append(a.idle, f)
+}
+
+// RunIdle calls the first function waiting for the app to be idle, if there is one, and
+// returns true if it did. Call from the app's goroutine. It's intended for apps that
+// construct their own main loops, to call when they have no events to handle.
+func (a *App) RunIdle() bool {
+	if len(a.idle) == 0 {
+		return false
+	}
+	f := a.idle[0]
+	a.idle[0] = nil
+	a.idle = a.idle[1:]
+	f(a)
+	return true
+}
+
+// isIdle returns true if the app has no events waiting and no frame to draw straight
+// away.
+func (a *App) isIdle() bool {
+	if len(a.TCellEvents) > 0 || len(a.AfterRenderEvents) > 0 {
+		return false
+	}
+	return !a.frames.pending || a.frames.timer != nil
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
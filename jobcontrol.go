@@ -0,0 +1,75 @@
+// Copyright 2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+//go:build !windows
+// +build !windows
+
+package gowid
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+//======================================================================
+
+// stopProcess sends SIGTSTP to the process group, stopping the process. It's a variable
+// so tests can suspend an app without being stopped.
+var stopProcess = func() error {
+	return syscall.Kill(0, syscall.SIGTSTP)
+}
+
+// SuspendProcess stops the process as if the user had typed ctrl-z at a shell
+// prompt. The terminal is restored first so the shell is usable; when the process
+// is continued (e.g. with fg), the screen is reinitialized and redrawn. Call this
+// from the widget rendering goroutine.
+func (a *App) SuspendProcess() error {
+	return a.Suspend(func() error {
+		// Restore the default action so the signal really does stop the process -
+		// then catch it again once continued.
+		signal.Reset(syscall.SIGTSTP)
+		err := stopProcess()
+		if a.signals != nil {
+			signal.Notify(a.signals, syscall.SIGTSTP)
+		}
+		return err
+	})
+}
+
+// startJobControl arranges for SIGTSTP sent to the process to suspend the app
+// cleanly, and for SIGCONT to refresh the screen, which may have been disturbed
+// while the process was stopped.
+func (a *App) startJobControl() {
+	a.signals = make(chan os.Signal, 1)
+	signal.Notify(a.signals, syscall.SIGTSTP, syscall.SIGCONT)
+	go func(ch <-chan os.Signal) {
+		for sig := range ch {
+			switch sig {
+			case syscall.SIGTSTP:
+				a.Run(RunFunction(func(app IApp) {
+					a.SuspendProcess()
+				}))
+			case syscall.SIGCONT:
+				a.Run(RunFunction(func(app IApp) {
+					a.Sync()
+				}))
+			}
+		}
+	}(a.signals)
+}
+
+func (a *App) stopJobControl() {
+	if a.signals != nil {
+		signal.Stop(a.signals)
+		close(a.signals)
+		a.signals = nil
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
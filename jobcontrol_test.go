@@ -0,0 +1,66 @@
+// Copyright 2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+//go:build !windows
+// +build !windows
+
+package gowid
+
+import (
+	"io/ioutil"
+	"testing"
+
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestJobControl1(t *testing.T) {
+	screens := simulateOwnedScreens(t)
+	stops := 0
+	defer func(f func() error) {
+		stopProcess = f
+	}(stopProcess)
+	stopProcess = func() error {
+		stops++
+		return nil
+	}
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	ctrlz := tcell.NewEventKey(tcell.KeyCtrlZ, 0, tcell.ModNone)
+
+	for _, jobControl := range []bool{false, true} {
+		*screens, stops = nil, 0
+		view := &inputWidget{}
+		app, err := NewApp(AppArgs{
+			View:       view,
+			Log:        logger,
+			JobControl: jobControl,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(*screens))
+
+		app.HandleTCellEvent(ctrlz, IgnoreUnhandledInput)
+		if jobControl {
+			// Swallowed, and the app is suspended on a new screen
+			assert.Equal(t, 0, view.keys)
+			assert.Equal(t, 1, stops)
+			assert.Equal(t, 2, len(*screens))
+		} else {
+			// Sent to the view like any other key
+			assert.Equal(t, 1, view.keys)
+			assert.Equal(t, 0, stops)
+			assert.Equal(t, 1, len(*screens))
+		}
+		app.Close()
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,29 @@
+// Copyright 2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+//
+
+package gowid
+
+import (
+	"fmt"
+)
+
+//======================================================================
+
+var JobControlNotSupportedErr = fmt.Errorf("Job control is not supported on this platform.")
+
+// SuspendProcess is not supported on Windows.
+func (a *App) SuspendProcess() error {
+	return JobControlNotSupportedErr
+}
+
+func (a *App) startJobControl() {}
+
+func (a *App) stopJobControl() {}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,129 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//======================================================================
+
+// LogLevel is the severity of a message logged with an ILogger.
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warning"
+	case LogError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// LogField is a named value attached to a logged message, e.g. the error that caused
+// it.
+type LogField struct {
+	Name string
+	Val  interface{}
+}
+
+// ILogger is implemented by types that receive gowid's diagnostics. An application can
+// route them into its own logging stack by supplying an ILogger to the App; widgets find
+// it with LoggerOf.
+type ILogger interface {
+	Log(level LogLevel, msg string, fields ...LogField)
+}
+
+// IGetLogger is implemented by types that provide a logger - e.g. the App.
+type IGetLogger interface {
+	Logger() ILogger
+}
+
+// LoggerOf returns the logger provided by v, if it implements IGetLogger, or
+// DefaultLogger. Widgets call it with the IApp they're given.
+func LoggerOf(v interface{}) ILogger {
+	if g, ok := v.(IGetLogger); ok {
+		if l := g.Logger(); l != nil {
+			return l
+		}
+	}
+	return DefaultLogger
+}
+
+// Log logs a message with the logger provided by v - see LoggerOf.
+func Log(v interface{}, level LogLevel, msg string, fields ...LogField) {
+	LoggerOf(v).Log(level, msg, fields...)
+}
+
+//======================================================================
+
+// StdLogger adapts a logrus-style logger to ILogger. If the logger is a
+// logrus.FieldLogger, such as a *logrus.Logger, fields and levels are passed through;
+// otherwise each message is formatted with Printf, prefixed by its level and followed by
+// its fields.
+type StdLogger struct {
+	log.StdLogger
+}
+
+var _ ILogger = StdLogger{}
+
+// DefaultLogger is used by LoggerOf when no logger is provided. It writes to the
+// standard logrus logger.
+var DefaultLogger ILogger = StdLogger{log.StandardLogger()}
+
+// DiscardLogger ignores everything logged.
+var DiscardLogger ILogger = discardLogger{}
+
+func (l StdLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	if flog, ok := l.StdLogger.(log.FieldLogger); ok {
+		entry := flog.WithFields(log.Fields{})
+		for _, f := range fields {
+			entry = entry.WithField(f.Name, f.Val)
+		}
+		switch level {
+		case LogDebug:
+			entry.Debug(msg)
+		case LogInfo:
+			entry.Info(msg)
+		case LogWarn:
+			entry.Warn(msg)
+		default:
+			entry.Error(msg)
+		}
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v: %s", level, msg)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%v", f.Name, f.Val)
+	}
+	l.StdLogger.Printf("%s\n", b.String())
+}
+
+type discardLogger struct{}
+
+func (l discardLogger) Log(level LogLevel, msg string, fields ...LogField) {}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,170 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+)
+
+//======================================================================
+
+// IMetadata is implemented by widgets that carry arbitrary user data and string tags,
+// so that a dynamic UI can be queried - e.g. with App.FindByTag - without maintaining
+// a separate map from widgets to application state.
+type IMetadata interface {
+	Tags() []string
+	HasTag(tag string) bool
+	UserData() interface{}
+}
+
+// Metadata wraps a widget to attach user data and tags to it, and optionally an ID. It
+// renders and handles input exactly as the widget it wraps, and, like any composite
+// widget, is descended by FindInHierarchy.
+type Metadata struct {
+	IWidget
+	id   interface{}
+	data interface{}
+	tags []string
+}
+
+var _ IWidget = (*Metadata)(nil)
+var _ ICompositeWidget = (*Metadata)(nil)
+var _ ISettableComposite = (*Metadata)(nil)
+var _ IMetadata = (*Metadata)(nil)
+var _ IIdentity = (*Metadata)(nil)
+
+// WithMetadata wraps w with the supplied user data and tags.
+func WithMetadata(w IWidget, data interface{}, tags ...string) *Metadata {
+	return &Metadata{
+		IWidget: w,
+		data:    data,
+		tags:    tags,
+	}
+}
+
+// Tag wraps w with the supplied tags and no user data.
+func Tag(w IWidget, tags ...string) *Metadata {
+	return WithMetadata(w, nil, tags...)
+}
+
+// WithID wraps w to give it an ID - e.g. so that FocusPathByID can identify it after
+// the widget tree is rebuilt. Use a string or number if the focus path is to be saved.
+func WithID(w IWidget, id interface{}) *Metadata {
+	return &Metadata{
+		IWidget: w,
+		id:      id,
+	}
+}
+
+func (w *Metadata) String() string {
+	return fmt.Sprintf("metadata%v[%v]", w.tags, w.IWidget)
+}
+
+func (w *Metadata) SubWidget() IWidget {
+	return w.IWidget
+}
+
+func (w *Metadata) SetSubWidget(inner IWidget, app IApp) {
+	w.IWidget = inner
+}
+
+func (w *Metadata) SubWidgetSize(size IRenderSize, focus Selector, app IApp) IRenderSize {
+	return size
+}
+
+// ID returns the ID given with WithID or SetID, or nil.
+func (w *Metadata) ID() interface{} {
+	return w.id
+}
+
+func (w *Metadata) SetID(id interface{}) {
+	w.id = id
+}
+
+func (w *Metadata) UserData() interface{} {
+	return w.data
+}
+
+func (w *Metadata) SetUserData(data interface{}) {
+	w.data = data
+}
+
+func (w *Metadata) Tags() []string {
+	return w.tags
+}
+
+func (w *Metadata) HasTag(tag string) bool {
+	for _, t := range w.tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTag adds the tag, if the widget doesn't have it already.
+func (w *Metadata) AddTag(tag string) {
+	if !w.HasTag(tag) {
+		w.tags = append(w.tags, tag)
+	}
+}
+
+// RemoveTag removes the tag, returning false if the widget didn't have it.
+func (w *Metadata) RemoveTag(tag string) bool {
+	for i, t := range w.tags {
+		if t == tag {
+			w.tags = append(w.tags[:i:i], w.tags[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+//======================================================================
+
+// HasTag returns a predicate, for use with FindInHierarchy or FindAll, that matches
+// widgets with the supplied tag.
+func HasTag(tag string) WidgetPredicate {
+	return func(w IWidget) bool {
+		md, ok := w.(IMetadata)
+		return ok && md.HasTag(tag)
+	}
+}
+
+// UserDataOf returns the user data of the first widget carrying metadata found by
+// descending from w through single-child composites - so it can be called with the
+// Metadata wrapper or the widget it wraps, if that carries its own metadata.
+func UserDataOf(w IWidget) (interface{}, bool) {
+	for w != nil {
+		if md, ok := w.(IMetadata); ok {
+			return md.UserData(), true
+		}
+		cw, ok := w.(IComposite)
+		if !ok {
+			break
+		}
+		w = cw.SubWidget()
+	}
+	return nil, false
+}
+
+// FindByTag returns the widgets in the app's view with the supplied tag, in depth-first
+// order - see FindAll. A widget tagged by a Metadata wrapper is returned unwrapped, so
+// it can be used directly, e.g. app.FindByTag("status")[0].(*text.Widget).
+func (a *App) FindByTag(tag string) []IWidget {
+	res := FindAll(a.SubWidget(), HasTag(tag))
+	for i, w := range res {
+		if md, ok := w.(*Metadata); ok {
+			res[i] = md.SubWidget()
+		}
+	}
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,63 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+// leafWidget has a name so that distinct instances have distinct addresses
+type leafWidget struct {
+	xWidget
+	name string
+}
+
+type multiWidget struct {
+	xWidget
+	subs []IWidget
+}
+
+func (w *multiWidget) SubWidgets() []IWidget {
+	return w.subs
+}
+
+func TestMetadata1(t *testing.T) {
+	a, b, c := &leafWidget{name: "a"}, &leafWidget{name: "b"}, &leafWidget{name: "c"}
+	ma := WithMetadata(a, 42, "row", "first")
+	view := &multiWidget{subs: []IWidget{ma, Tag(b, "row"), c}}
+
+	app, _ := newTestApp(t, 2, 1)
+	app.view = view
+
+	found := app.FindByTag("row")
+	assert.Equal(t, 2, len(found))
+	assert.True(t, found[0] == IWidget(a))
+	assert.True(t, found[1] == IWidget(b))
+	assert.Equal(t, 0, len(app.FindByTag("missing")))
+
+	data, ok := UserDataOf(ma)
+	assert.True(t, ok)
+	assert.Equal(t, 42, data)
+	_, ok = UserDataOf(c)
+	assert.False(t, ok)
+
+	// The wrapper is descended like any other composite
+	assert.True(t, FindInHierarchy(ma, true, HasTag("first")) == IWidget(ma))
+
+	ma.RemoveTag("row")
+	ma.AddTag("first")
+	assert.Equal(t, []string{"first"}, ma.Tags())
+	assert.Equal(t, 1, len(app.FindByTag("row")))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
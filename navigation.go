@@ -0,0 +1,154 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+)
+
+//======================================================================
+
+// PageChangeCB is the name under which page change callbacks are registered
+// on the App.
+type PageChangeCB struct{}
+
+type PageTransition int
+
+const (
+	PagePushed PageTransition = iota
+	PagePopped
+	PageReplaced
+)
+
+func (t PageTransition) String() string {
+	switch t {
+	case PagePushed:
+		return "pushed"
+	case PagePopped:
+		return "popped"
+	case PageReplaced:
+		return "replaced"
+	default:
+		return fmt.Sprintf("PageTransition(%d)", int(t))
+	}
+}
+
+// PageChange is passed as the extra argument to page change callbacks. The
+// callback's widget argument is the new page, To.
+type PageChange struct {
+	Transition PageTransition
+	From       IWidget
+	To         IWidget
+}
+
+func (p PageChange) String() string {
+	return fmt.Sprintf("%v[%v -> %v]", p.Transition, p.From, p.To)
+}
+
+// pageEntry is a page hidden beneath the current one, with the focus it had when
+// it was hidden so that it can be restored.
+type pageEntry struct {
+	widget IWidget
+	focus  []interface{}
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// PushPage displays page in place of the app's current view, which is kept on a
+// stack with its focus, to be restored by PopPage. Like other functions that change
+// the widget hierarchy, call this from the widget rendering goroutine.
+func (a *App) PushPage(page IWidget) {
+	from := a.view
+	a.pages = append(a.pages, pageEntry{
+		widget: from,
+		focus:  FocusPath(from),
+	})
+	a.setView(page)
+	a.pageChanged(PagePushed, from, page)
+}
+
+// PopPage removes the current page and displays the one beneath it, restoring its
+// focus. It returns the page removed. If there is no page beneath - the current view
+// is the app's first - nothing happens and false is returned.
+func (a *App) PopPage() (IWidget, bool) {
+	if len(a.pages) == 0 {
+		return nil, false
+	}
+	top := a.pages[len(a.pages)-1]
+	a.pages = a.pages[:len(a.pages)-1]
+	from := a.view
+	a.setView(top.widget)
+	SetFocusPath(top.widget, top.focus, a)
+	a.pageChanged(PagePopped, from, top.widget)
+	return from, true
+}
+
+// ReplacePage displays page in place of the current one, without changing the
+// depth of the stack - for example, to move from a detail page to an edit page such
+// that PopPage returns to the list the detail page was pushed from.
+func (a *App) ReplacePage(page IWidget) {
+	from := a.view
+	a.setView(page)
+	a.pageChanged(PageReplaced, from, page)
+}
+
+// PageDepth returns the number of pages beneath the current one.
+func (a *App) PageDepth() int {
+	return len(a.pages)
+}
+
+// OnPageChange registers a callback that is run after the current page is pushed,
+// popped or replaced. The callback's extra argument is a PageChange.
+func (a *App) OnPageChange(f IWidgetChangedCallback) {
+	if a.pageCallbacks == nil {
+		a.pageCallbacks = NewCallbacks()
+	}
+	AddWidgetCallback(a.pageCallbacks, PageChangeCB{}, f)
+}
+
+func (a *App) RemoveOnPageChange(f IIdentity) {
+	if a.pageCallbacks != nil {
+		RemoveWidgetCallback(a.pageCallbacks, PageChangeCB{}, f)
+	}
+}
+
+func (a *App) pageChanged(t PageTransition, from, to IWidget) {
+	if a.pageCallbacks != nil {
+		RunWidgetCallbacks(a.pageCallbacks, PageChangeCB{}, a, to, PageChange{
+			Transition: t,
+			From:       from,
+			To:         to,
+		})
+	}
+}
+
+// setView replaces the base of the displayed widget hierarchy, beneath any menus
+// that are registered.
+func (a *App) setView(widget IWidget) {
+	old := a.view
+	a.view = widget
+	if a.viewPlusMenus == nil || a.viewPlusMenus == old {
+		a.viewPlusMenus = widget
+		return
+	}
+	cur := a.viewPlusMenus
+	for {
+		menu, ok := cur.(IMenuCompatible)
+		if !ok {
+			break
+		}
+		if menu.SubWidget() == old {
+			menu.SetSubWidget(widget, a)
+			break
+		}
+		cur = menu.SubWidget()
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,195 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//======================================================================
+
+// UnknownPaletteEntryError is returned when a palette entry is looked up by a name the
+// palette doesn't have.
+type UnknownPaletteEntryError struct {
+	Name string
+}
+
+var _ error = UnknownPaletteEntryError{}
+
+func (e UnknownPaletteEntryError) Error() string {
+	return fmt.Sprintf("Palette entry %q not found", e.Name)
+}
+
+// PaletteCycleError is returned when palette entries refer to each other in a loop,
+// which would recurse forever when rendered.
+type PaletteCycleError struct {
+	Name string
+}
+
+var _ error = PaletteCycleError{}
+
+func (e PaletteCycleError) Error() string {
+	return fmt.Sprintf("Palette entry %q is part of a cycle of references", e.Name)
+}
+
+// PaletteError records the problem found with a single palette entry.
+type PaletteError struct {
+	Name string
+	Err  error
+}
+
+var _ error = PaletteError{}
+
+func (e PaletteError) Error() string {
+	return fmt.Sprintf("Palette entry %q: %v", e.Name, e.Err)
+}
+
+func (e PaletteError) Cause() error {
+	return e.Err
+}
+
+// PaletteErrors is returned by Palette.Validate and holds a problem for each bad entry.
+type PaletteErrors []PaletteError
+
+var _ error = PaletteErrors{}
+
+func (e PaletteErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, pe := range e {
+		msgs = append(msgs, pe.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//======================================================================
+
+// maxPaletteLookups bounds the number of lookups made resolving one entry, so that a
+// cycle of PaletteRefs is reported rather than overflowing the stack.
+const maxPaletteLookups = 1000
+
+// paletteResolver is the render context used to resolve a palette's entries outside of an
+// App. It records the first entry that can't be found.
+type paletteResolver struct {
+	Palette
+	mode    ColorMode
+	lookups int
+	missing string
+}
+
+var _ IRenderContext = (*paletteResolver)(nil)
+
+func (r *paletteResolver) GetColorMode() ColorMode {
+	return r.mode
+}
+
+func (r *paletteResolver) CellStyler(name string) (ICellStyler, bool) {
+	r.lookups++
+	if r.lookups > maxPaletteLookups {
+		panic(errors.WithStack(PaletteCycleError{Name: name}))
+	}
+	res, ok := r.Palette.CellStyler(name)
+	if !ok && r.missing == "" {
+		r.missing = name
+	}
+	return res, ok
+}
+
+// Resolve returns the effective colors and style of the named entry, following any
+// PaletteRefs and layering, as they would be rendered in the given color mode. The colors
+// of the result are TCellColors. An error is returned if the entry, or any entry it refers
+// to, is missing, or if one of its colors can't be rendered in the mode - which would
+// otherwise cause a panic during rendering.
+func (m Palette) Resolve(name string, mode ColorMode) (res PaletteEntry, err error) {
+	st, ok := m[name]
+	if !ok {
+		return res, errors.WithStack(UnknownPaletteEntryError{Name: name})
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = errors.Errorf("%v", r)
+			}
+		}
+	}()
+
+	ctx := &paletteResolver{Palette: m, mode: mode}
+	f, b, s := st.GetStyle(ctx)
+	fg := IColorToTCell(f, ColorNone, mode)
+	bg := IColorToTCell(b, ColorNone, mode)
+	if ctx.missing != "" {
+		return res, errors.WithStack(UnknownPaletteEntryError{Name: ctx.missing})
+	}
+	return PaletteEntry{FG: fg, BG: bg, Style: s}, nil
+}
+
+// Validate resolves every entry in the palette for the given color mode, and returns a
+// PaletteErrors describing each entry that would fail when rendered, or nil if there are
+// none. Call this at startup to catch mistakes in a theme - e.g. a GrayColor used in
+// 16-color mode - before they cause a panic mid-render.
+func (m Palette) Validate(mode ColorMode) error {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var res PaletteErrors
+	for _, name := range names {
+		if _, err := m.Resolve(name, mode); err != nil {
+			res = append(res, PaletteError{Name: name, Err: err})
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+//======================================================================
+
+// paletteTracker is an App that records the palette entries looked up while rendering
+// that the App's palette doesn't have.
+type paletteTracker struct {
+	*App
+	missing map[string]struct{}
+}
+
+func (t *paletteTracker) CellStyler(name string) (ICellStyler, bool) {
+	res, ok := t.App.CellStyler(name)
+	if !ok {
+		t.missing[name] = struct{}{}
+	}
+	return res, ok
+}
+
+// MissingPaletteRefs renders the App's widgets, without drawing them, and returns the
+// sorted names of the palette entries they look up that the palette does not have. Only
+// the widgets that would be rendered now are checked - e.g. not the other pages of a
+// tabbed layout.
+func (a *App) MissingPaletteRefs() []string {
+	t := &paletteTracker{App: a, missing: make(map[string]struct{})}
+	maxX, maxY := a.TerminalSize()
+	RenderChild(a.root(), RenderBox{C: maxX, R: maxY}, Focused, t)
+
+	res := make([]string, 0, len(t.missing))
+	for name := range t.missing {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,142 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+//======================================================================
+
+// PanicReport describes a panic recovered by the App. Activity says what the App was
+// doing - e.g. rendering, or handling a key press - and WidgetPath lists the widget
+// methods that were running, outermost first, e.g. "list.(*Widget).UserInput".
+type PanicReport struct {
+	Value      interface{}
+	Time       time.Time
+	Activity   string
+	WidgetPath []string
+	Stack      []byte
+}
+
+func (r *PanicReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "gowid: panic while %s: %v\n", r.Activity, r.Value)
+	if len(r.WidgetPath) > 0 {
+		fmt.Fprintf(&b, "\nWidget path (outermost first):\n")
+		for _, w := range r.WidgetPath {
+			fmt.Fprintf(&b, "  %s\n", w)
+		}
+	}
+	fmt.Fprintf(&b, "\n%s", r.Stack)
+	return b.String()
+}
+
+// panicExit terminates the process after a recovered panic has been reported. Tests
+// replace it.
+var panicExit = os.Exit
+
+// widgetMethods are the functions reported in a PanicReport's widget path.
+var widgetMethods = map[string]bool{
+	"Render":     true,
+	"RenderSize": true,
+	"UserInput":  true,
+}
+
+// setActivity records what the App is doing, for a PanicReport. It's called on every
+// event, so the description is only built if there's a panic.
+func (a *App) setActivity(activity string, ev interface{}) {
+	a.activity = activity
+	a.activityEvent = ev
+}
+
+func (a *App) describeActivity() string {
+	switch {
+	case a.activity == "":
+		return "running"
+	case a.activityEvent == nil:
+		return a.activity
+	default:
+		return fmt.Sprintf("%s %T", a.activity, a.activityEvent)
+	}
+}
+
+// MakePanicReport builds a report of the panic value r. It must be called from the
+// function deferred to recover from the panic, so that the stack still holds the
+// frames that panicked.
+func (a *App) MakePanicReport(r interface{}) *PanicReport {
+	return &PanicReport{
+		Value:      r,
+		Time:       time.Now(),
+		Activity:   a.describeActivity(),
+		WidgetPath: widgetPath(2),
+		Stack:      debug.Stack(),
+	}
+}
+
+// widgetPath returns the widget methods on the stack, outermost first, skipping the
+// innermost skip frames. Only the package name is kept from each function name, and
+// a method that calls itself through an embedded type is listed once.
+func widgetPath(skip int) []string {
+	pcs := make([]uintptr, 256)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	res := make([]string, 0)
+	for {
+		frame, more := frames.Next()
+		name := frame.Function
+		if i := strings.LastIndex(name, "/"); i != -1 {
+			name = name[i+1:]
+		}
+		if i := strings.LastIndex(name, "."); i != -1 && widgetMethods[name[i+1:]] {
+			if len(res) == 0 || res[len(res)-1] != name {
+				res = append(res, name)
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
+
+// RecoverPanic recovers from a panic on the widget rendering goroutine, restores the
+// terminal, prints a PanicReport to stderr - and to the crash report file, if one is
+// configured - then exits. MainLoop uses it if the App was created with
+// RecoverPanics; an application with its own event loop can defer it directly.
+func (a *App) RecoverPanic() {
+	if r := recover(); r != nil {
+		a.handlePanic(a.MakePanicReport(r), os.Stderr)
+		panicExit(2)
+	}
+}
+
+func (a *App) handlePanic(report *PanicReport, out io.Writer) {
+	// Restore the terminal first, so the report is readable
+	a.Close()
+	fmt.Fprint(out, report.String())
+	if a.crashReport != "" {
+		if err := os.WriteFile(a.crashReport, []byte(report.String()), 0644); err != nil {
+			fmt.Fprintf(out, "\nCould not write crash report to %s: %v\n", a.crashReport, err)
+		} else {
+			fmt.Fprintf(out, "\nCrash report written to %s\n", a.crashReport)
+		}
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,272 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gcla/gowid/gwutil"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// IFrameRecorder is implemented by types that want to see every frame the App
+// draws to the terminal. RecordFrame is called on the widget rendering goroutine
+// after the canvas has been drawn. The canvas must not be kept once RecordFrame returns,
+// as its memory is reused if canvas pooling is on - see CanvasPool.
+type IFrameRecorder interface {
+	RecordFrame(canvas IDrawCanvas, mode IColorMode) error
+}
+
+// CastOptions can be supplied to NewCastRecorder. Title and Env are written to the
+// header of the recording. If IdleLimit is non-zero, gaps between frames longer than
+// this are shortened to IdleLimit.
+type CastOptions struct {
+	Title     string
+	Env       map[string]string
+	IdleLimit time.Duration
+}
+
+// CastRecorder writes each frame, with its timing, in asciinema's cast v2 format,
+// so a recording can be played back with asciinema or with the playback widget.
+// The header is written when the first frame is recorded, using that frame's
+// dimensions. A frame identical to the previous one is not recorded. If the
+// terminal is resized, an "r" event is written, followed by the new frame.
+type CastRecorder struct {
+	w       io.Writer
+	opts    CastOptions
+	started bool
+	start   time.Time
+	elapsed time.Duration
+	prev    time.Time
+	cols    int
+	rows    int
+	last    []byte
+	now     func() time.Time
+	sync.Mutex
+}
+
+var _ IFrameRecorder = (*CastRecorder)(nil)
+
+// CastHeader is the first line of an asciinema cast v2 file.
+type CastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+func NewCastRecorder(w io.Writer, opts ...CastOptions) *CastRecorder {
+	var opt CastOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return &CastRecorder{
+		w:    w,
+		opts: opt,
+		now:  time.Now,
+	}
+}
+
+func (r *CastRecorder) String() string {
+	return fmt.Sprintf("castrecorder[%dx%d]", r.cols, r.rows)
+}
+
+// RecordFrame writes the canvas as an "o" event. It is safe to call from more than
+// one goroutine.
+func (r *CastRecorder) RecordFrame(canvas IDrawCanvas, mode IColorMode) error {
+	r.Lock()
+	defer r.Unlock()
+
+	now := r.now()
+	cols, rows := canvas.BoxColumns(), canvas.BoxRows()
+
+	if !r.started {
+		hdr := CastHeader{
+			Version:   2,
+			Width:     cols,
+			Height:    rows,
+			Timestamp: now.Unix(),
+			Title:     r.opts.Title,
+			Env:       r.opts.Env,
+		}
+		b, err := json.Marshal(hdr)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(r.w, "%s\n", b); err != nil {
+			return err
+		}
+		r.started = true
+		r.start = now
+		r.prev = now
+		r.cols, r.rows = cols, rows
+	}
+
+	gap := now.Sub(r.prev)
+	if r.opts.IdleLimit != 0 && gap > r.opts.IdleLimit {
+		gap = r.opts.IdleLimit
+	}
+
+	frame := CanvasToANSI(canvas, mode)
+	resized := cols != r.cols || rows != r.rows
+	if !resized && bytes.Equal(frame, r.last) {
+		// The time passes on to the next frame that's written
+		return nil
+	}
+	r.prev = now
+	r.elapsed += gap
+	r.last = frame
+
+	if resized {
+		r.cols, r.rows = cols, rows
+		if err := r.writeEvent("r", fmt.Sprintf("%dx%d", cols, rows)); err != nil {
+			return err
+		}
+	}
+
+	return r.writeEvent("o", string(frame))
+}
+
+func (r *CastRecorder) writeEvent(code string, data string) error {
+	b, err := json.Marshal([]interface{}{r.elapsed.Seconds(), code, data})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(r.w, "%s\n", b)
+	return err
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// CanvasToANSI returns the bytes that would repaint a terminal with the contents
+// of the canvas, starting from the top-left. Cells are styled the same way Draw()
+// styles them for tcell. The cursor is positioned and shown if the canvas has the
+// cursor enabled, and hidden otherwise.
+func CanvasToANSI(canvas IDrawCanvas, mode IColorMode) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H")
+	cur := ""
+	var curLink Hyperlink
+
+	for y := 0; y < canvas.BoxRows(); y++ {
+		if y > 0 {
+			buf.WriteString("\r\n")
+		}
+		line := canvas.Line(y, LineCopy{}).Line
+		for x := 0; x < len(line); {
+			c := line[x]
+			if st := sgr(c); st != cur {
+				buf.WriteString(st)
+				cur = st
+			}
+			if link, _ := c.Hyperlink(); link != curLink {
+				buf.WriteString(osc8(link))
+				curLink = link
+			}
+			buf.WriteString(c.Grapheme())
+			x += gwutil.Max(c.Width(), 1)
+		}
+	}
+	if curLink.URL != "" {
+		buf.WriteString(osc8(Hyperlink{}))
+	}
+	buf.WriteString("\x1b[0m")
+	if canvas.CursorEnabled() {
+		pos := canvas.CursorCoords()
+		fmt.Fprintf(&buf, "\x1b[%d;%dH\x1b[?25h", pos.Y+1, pos.X+1)
+	} else {
+		buf.WriteString("\x1b[?25l")
+	}
+	return buf.Bytes()
+}
+
+var sgrAttrs = []struct {
+	attr tcell.AttrMask
+	code int
+}{
+	{tcell.AttrBold, 1},
+	{tcell.AttrDim, 2},
+	{tcell.AttrItalic, 3},
+	{tcell.AttrBlink, 5},
+	{tcell.AttrReverse, 7},
+	{tcell.AttrStrikeThrough, 9},
+}
+
+var sgrUnderlines = map[tcell.UnderlineStyle]string{
+	tcell.UnderlineStyleSolid:  ";4",
+	tcell.UnderlineStyleDouble: ";21",
+	tcell.UnderlineStyleCurly:  ";4:3",
+}
+
+// sgr returns the escape sequence to select the cell's colors and style, starting from a reset.
+func sgr(c Cell) string {
+	attrs := StyleNone.MergeUnder(c.Style()).OnOff
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[0")
+	for _, a := range sgrAttrs {
+		if attrs&a.attr != 0 {
+			fmt.Fprintf(&buf, ";%d", a.code)
+		}
+	}
+	us := UnderlineStyleOf(attrs)
+	buf.WriteString(sgrUnderlines[us])
+	buf.WriteString(sgrColor(tcellColorOrDefault(c.ForegroundColor()), 30, 90, 38))
+	buf.WriteString(sgrColor(tcellColorOrDefault(c.BackgroundColor()), 40, 100, 48))
+	if us != tcell.UnderlineStyleNone {
+		// There are no short codes for underline colors
+		buf.WriteString(sgrColor(tcellColorOrDefault(c.UnderlineColor()), -1, -1, 58))
+	}
+	buf.WriteString("m")
+	return buf.String()
+}
+
+// osc8 returns the escape sequence that starts a hyperlink, or ends one if the URL is empty.
+func osc8(link Hyperlink) string {
+	params := ""
+	if link.ID != "" {
+		params = "id=" + link.ID
+	}
+	return fmt.Sprintf("\x1b]8;%s;%s\x1b\\", params, link.URL)
+}
+
+// sgrColor returns the parameters selecting c, using the base and bright codes for the first 16
+// colors if they are not negative, and the extended color code otherwise.
+func sgrColor(c tcell.Color, base, bright, ext int) string {
+	switch {
+	case c == tcell.ColorDefault:
+		return ""
+	case c&tcell.ColorIsRGB != 0:
+		r, g, b := c.RGB()
+		return fmt.Sprintf(";%d;2;%d;%d;%d", ext, r, g, b)
+	}
+	idx := int(c - tcell.ColorValid)
+	switch {
+	case idx < 0:
+		return ""
+	case base < 0:
+		return fmt.Sprintf(";%d;5;%d", ext, idx)
+	case idx < 8:
+		return fmt.Sprintf(";%d", base+idx)
+	case idx < 16:
+		return fmt.Sprintf(";%d", bright+idx-8)
+	default:
+		return fmt.Sprintf(";%d;5;%d", ext, idx)
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,249 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// IInputRecorder is implemented by types that want to see every input event the App
+// receives from the terminal. RecordInput is called on the widget rendering goroutine,
+// before the event is processed - so an event that causes a panic is recorded.
+type IInputRecorder interface {
+	RecordInput(ev tcell.Event) error
+}
+
+// InputEvent is a terminal input event in a form that can be saved and read back. Type
+// is one of "key", "mouse", "paste" or "resize", and determines which other fields are
+// used. Time is the offset from the start of the recording.
+type InputEvent struct {
+	Time    float64 `json:"t"`
+	Type    string  `json:"type"`
+	Key     int     `json:"key,omitempty"`
+	Rune    int     `json:"rune,omitempty"`
+	Mod     int     `json:"mod,omitempty"`
+	X       int     `json:"x,omitempty"`
+	Y       int     `json:"y,omitempty"`
+	Buttons int     `json:"buttons,omitempty"`
+	Start   bool    `json:"start,omitempty"`
+	Width   int     `json:"width,omitempty"`
+	Height  int     `json:"height,omitempty"`
+}
+
+// UnknownInputEventType is returned when a recording holds an event that can't be
+// replayed.
+type UnknownInputEventType struct {
+	Type string
+}
+
+var _ error = UnknownInputEventType{}
+
+func (e UnknownInputEventType) Error() string {
+	return fmt.Sprintf("Unknown input event type %q", e.Type)
+}
+
+// MakeInputEvent converts a tcell event to an InputEvent, occurring at offset t. It
+// returns false if the event isn't user input or a resize.
+func MakeInputEvent(ev tcell.Event, t time.Duration) (InputEvent, bool) {
+	res := InputEvent{Time: t.Seconds()}
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		res.Type = "key"
+		res.Key = int(ev.Key())
+		if ev.Key() == tcell.KeyRune {
+			res.Rune = int(ev.Rune())
+		}
+		res.Mod = int(ev.Modifiers())
+	case *tcell.EventMouse:
+		res.Type = "mouse"
+		res.X, res.Y = ev.Position()
+		res.Buttons = int(ev.Buttons())
+		res.Mod = int(ev.Modifiers())
+	case *tcell.EventPaste:
+		res.Type = "paste"
+		res.Start = ev.Start()
+	case *tcell.EventResize:
+		res.Type = "resize"
+		res.Width, res.Height = ev.Size()
+	default:
+		return res, false
+	}
+	return res, true
+}
+
+// Event returns the tcell event that the InputEvent represents.
+func (e InputEvent) Event() (tcell.Event, error) {
+	switch e.Type {
+	case "key":
+		return tcell.NewEventKey(tcell.Key(e.Key), rune(e.Rune), tcell.ModMask(e.Mod)), nil
+	case "mouse":
+		return tcell.NewEventMouse(e.X, e.Y, tcell.ButtonMask(e.Buttons), tcell.ModMask(e.Mod)), nil
+	case "paste":
+		return tcell.NewEventPaste(e.Start), nil
+	case "resize":
+		return tcell.NewEventResize(e.Width, e.Height), nil
+	default:
+		return nil, UnknownInputEventType{Type: e.Type}
+	}
+}
+
+// Offset returns the time of the event from the start of the recording.
+func (e InputEvent) Offset() time.Duration {
+	return time.Duration(e.Time * float64(time.Second))
+}
+
+//======================================================================
+
+// InputRecorder writes each input event it's given as a line of JSON, in the form of
+// an InputEvent, timed from the first event. Each event is written as soon as it
+// arrives, so a trace survives the application crashing. Read it back with
+// ReadInputEvents, or replay it with App.ReplayInput.
+type InputRecorder struct {
+	w       io.Writer
+	started bool
+	start   time.Time
+	now     func() time.Time
+	sync.Mutex
+}
+
+var _ IInputRecorder = (*InputRecorder)(nil)
+
+func NewInputRecorder(w io.Writer) *InputRecorder {
+	return &InputRecorder{
+		w:   w,
+		now: time.Now,
+	}
+}
+
+func (r *InputRecorder) String() string {
+	return "inputrecorder"
+}
+
+// RecordInput implements IInputRecorder.
+func (r *InputRecorder) RecordInput(ev tcell.Event) error {
+	r.Lock()
+	defer r.Unlock()
+
+	now := r.now()
+	if !r.started {
+		r.start = now
+		r.started = true
+	}
+	iev, ok := MakeInputEvent(ev, now.Sub(r.start))
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(iev)
+	if err != nil {
+		return err
+	}
+	_, err = r.w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadInputEvents reads a recording made by an InputRecorder.
+func ReadInputEvents(rd io.Reader) ([]InputEvent, error) {
+	res := make([]InputEvent, 0)
+	scanner := bufio.NewScanner(rd)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var ev InputEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		res = append(res, ev)
+	}
+	return res, scanner.Err()
+}
+
+//======================================================================
+
+// ReplayOptions can be supplied to App.ReplayInput. Speed scales the time between
+// events - 1 replays them as they were recorded, 2 twice as fast. If Speed is 0, the
+// events are replayed without pausing.
+type ReplayOptions struct {
+	Speed float64
+}
+
+// InputRecorder returns the app's input recorder, or nil if input is not being
+// recorded.
+func (a *App) InputRecorder() IInputRecorder {
+	return a.inputRecorder
+}
+
+// SetInputRecorder starts recording each input event with r, or stops recording if r
+// is nil. The terminal's current size is recorded first, so a replay starts with the
+// same layout.
+func (a *App) SetInputRecorder(r IInputRecorder) {
+	a.inputRecorder = r
+	if r != nil && (a.screenInited || a.dontOwnScreen) {
+		a.recordInput(tcell.NewEventResize(a.TerminalSize()))
+	}
+}
+
+// recordInput passes the event to the input recorder. If recording fails, the error is
+// logged and recording stops.
+func (a *App) recordInput(ev tcell.Event) {
+	if a.inputRecorder == nil {
+		return
+	}
+	if err := a.inputRecorder.RecordInput(ev); err != nil {
+		a.Log(LogError, "Could not record input - recording stopped", LogField{"error", err})
+		a.inputRecorder = nil
+	}
+}
+
+// ReplayInput reads a recording made by an InputRecorder and processes its events as if
+// the user had generated them, against the app's current widget tree. It must be called
+// on the widget rendering goroutine - e.g. before MainLoop, or from a function passed to
+// Run. Events are handled one at a time, in order, so a replay that starts from the
+// same state reproduces the session - including any panic. If the app's screen is a
+// tcell.SimulationScreen, recorded resizes change its size; otherwise they only cause a
+// redraw.
+func (a *App) ReplayInput(rd io.Reader, unhandled IUnhandledInput, opts ...ReplayOptions) error {
+	var opt ReplayOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	events, err := ReadInputEvents(rd)
+	if err != nil {
+		return err
+	}
+	var prev time.Duration
+	for _, iev := range events {
+		ev, err := iev.Event()
+		if err != nil {
+			return err
+		}
+		if opt.Speed > 0 && iev.Offset() > prev {
+			time.Sleep(time.Duration(float64(iev.Offset()-prev) / opt.Speed))
+		}
+		prev = iev.Offset()
+		if rev, ok := ev.(*tcell.EventResize); ok {
+			if sim, ok := a.screen.(tcell.SimulationScreen); ok {
+				sim.SetSize(rev.Size())
+			}
+		}
+		a.HandleTCellEvent(ev, unhandled)
+	}
+	return nil
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
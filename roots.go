@@ -0,0 +1,255 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid/gwutil"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// RootPlacement returns the rectangle of the screen a root widget occupies, given the
+// size of the terminal.
+type RootPlacement func(cols, rows int) Rect
+
+// PlaceAt returns a RootPlacement for a fixed rectangle of the screen.
+func PlaceAt(r Rect) RootPlacement {
+	return func(cols, rows int) Rect {
+		return r
+	}
+}
+
+// PlaceTopRows returns a RootPlacement for the top n rows of the screen.
+func PlaceTopRows(n int) RootPlacement {
+	return func(cols, rows int) Rect {
+		return Rect{W: cols, H: gwutil.Min(n, rows)}
+	}
+}
+
+// PlaceBottomRows returns a RootPlacement for the bottom n rows of the screen.
+func PlaceBottomRows(n int) RootPlacement {
+	return func(cols, rows int) Rect {
+		n := gwutil.Min(n, rows)
+		return Rect{Y: rows - n, W: cols, H: n}
+	}
+}
+
+// PlaceInset returns a RootPlacement for the screen less the given number of rows and
+// columns on each side - e.g. PlaceInset(0, 0, 1, 0) leaves the bottom row for a root
+// placed with PlaceBottomRows(1).
+func PlaceInset(top, right, bottom, left int) RootPlacement {
+	return func(cols, rows int) Rect {
+		return Rect{
+			X: left,
+			Y: top,
+			W: gwutil.Max(0, cols-left-right),
+			H: gwutil.Max(0, rows-top-bottom),
+		}
+	}
+}
+
+// Root is a widget hierarchy rendered in its own region of the screen, alongside the
+// app's view - see App.AddRoot.
+type Root struct {
+	widget IWidget
+	place  RootPlacement
+	rect   Rect // The region of the screen the root occupied when last laid out
+}
+
+func (r *Root) String() string {
+	return fmt.Sprintf("root[%v@%v]", r.widget, r.rect)
+}
+
+func (r *Root) Widget() IWidget {
+	return r.widget
+}
+
+// SetWidget replaces the root's widget hierarchy. Call this from the widget rendering
+// goroutine.
+func (r *Root) SetWidget(w IWidget) {
+	r.widget = w
+}
+
+func (r *Root) SetPlacement(place RootPlacement) {
+	r.place = place
+}
+
+// Rect returns the region of the screen the root occupied when the app was last
+// rendered.
+func (r *Root) Rect() Rect {
+	return r.rect
+}
+
+// layout computes the root's region for a screen of the given size, clipped to the
+// screen.
+func (r *Root) layout(cols, rows int) {
+	res := r.place(cols, rows)
+	x0, y0 := gwutil.Max(0, res.X), gwutil.Max(0, res.Y)
+	x1, y1 := gwutil.Min(cols, res.X+res.W), gwutil.Min(rows, res.Y+res.H)
+	r.rect = Rect{X: x0, Y: y0, W: gwutil.Max(0, x1-x0), H: gwutil.Max(0, y1-y0)}
+}
+
+func (r *Root) contains(x, y int) bool {
+	return x >= r.rect.X && x < r.rect.X+r.rect.W && y >= r.rect.Y && y < r.rect.Y+r.rect.H
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// AddRoot adds a widget hierarchy rendered in the region of the screen given by
+// place - e.g. a status bar owned by a different part of the application from the
+// main UI. Roots are rendered on top of the app's view, and on top of roots added
+// before them; the view's own region can be set with SetViewPlacement, so that it
+// doesn't extend under the roots. Mouse input goes to the topmost root, or the view,
+// under the pointer, translated to its region. Keyboard input goes to the focus root -
+// see SetFocusRoot. Menus registered with the app are rendered over the view only. Like
+// other functions that change the widget hierarchy, call this from the widget rendering
+// goroutine.
+func (a *App) AddRoot(w IWidget, place RootPlacement) *Root {
+	res := &Root{widget: w, place: place}
+	a.roots = append(a.roots, res)
+	return res
+}
+
+// RemoveRoot removes a root added with AddRoot, returning false if it isn't one of the
+// app's roots. If it has the focus, the view gets the focus.
+func (a *App) RemoveRoot(r *Root) bool {
+	for i, r2 := range a.roots {
+		if r2 == r {
+			a.roots = append(a.roots[:i], a.roots[i+1:]...)
+			if a.focusRoot == r {
+				a.focusRoot = nil
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Roots returns the roots added with AddRoot, bottom-most first.
+func (a *App) Roots() []*Root {
+	return append([]*Root(nil), a.roots...)
+}
+
+// SetViewPlacement sets the region of the screen the app's view - and any menus - is
+// rendered in. If place is nil, the view fills the screen.
+func (a *App) SetViewPlacement(place RootPlacement) {
+	a.viewPlacement = place
+}
+
+// FocusRoot returns the root that receives keyboard input, or nil if the app's view
+// does.
+func (a *App) FocusRoot() *Root {
+	return a.focusRoot
+}
+
+// SetFocusRoot gives the keyboard focus to r, or to the app's view if r is nil. A
+// root also gets the focus when a mouse button is pressed over it, if its widget is
+// selectable.
+func (a *App) SetFocusRoot(r *Root) {
+	a.focusRoot = r
+}
+
+//======================================================================
+
+// multiRoot renders the app's view and its roots, each in its own region of the
+// screen, and routes input to them. The app renders it in place of the view when the
+// app has roots, or the view has a placement.
+type multiRoot struct {
+	app *App
+}
+
+var _ IWidget = (*multiRoot)(nil)
+
+func (w *multiRoot) String() string {
+	return fmt.Sprintf("roots[%d]", len(w.app.roots))
+}
+
+// regions returns the view, as a Root, followed by the app's roots, laid out for the
+// given size.
+func (w *multiRoot) regions(size IRenderSize) []*Root {
+	box, _ := size.(IRenderBox)
+	cols, rows := box.BoxColumns(), box.BoxRows()
+	place := w.app.viewPlacement
+	if place == nil {
+		place = PlaceInset(0, 0, 0, 0)
+	}
+	res := make([]*Root, 0, len(w.app.roots)+1)
+	res = append(res, &Root{widget: w.app.viewPlusMenus, place: place})
+	res = append(res, w.app.roots...)
+	for _, r := range res {
+		r.layout(cols, rows)
+	}
+	return res
+}
+
+func (w *multiRoot) focused(i int, r *Root) bool {
+	if w.app.focusRoot == nil {
+		return i == 0
+	}
+	return r == w.app.focusRoot
+}
+
+func (w *multiRoot) Selectable() bool {
+	return true
+}
+
+func (w *multiRoot) RenderSize(size IRenderSize, focus Selector, app IApp) IRenderBox {
+	return CalculateRenderSizeFallback(w, size, focus, app)
+}
+
+func (w *multiRoot) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	box, _ := size.(IRenderBox)
+	res := CanvasPoolOf(app).NewCanvasOfSize(box.BoxColumns(), box.BoxRows())
+	for i, r := range w.regions(size) {
+		if r.rect.W == 0 || r.rect.H == 0 {
+			continue
+		}
+		f := focus.And(w.focused(i, r))
+		c := RenderChild(r.widget, RenderBox{C: r.rect.W, R: r.rect.H}, f, app)
+		res.MergeUnder(c, r.rect.X, r.rect.Y, !f.Focus)
+		ReleaseCanvas(c)
+	}
+	return res
+}
+
+// UserInput sends a mouse event to the topmost region under the pointer, and other
+// input to the focus region.
+func (w *multiRoot) UserInput(ev interface{}, size IRenderSize, focus Selector, app IApp) bool {
+	regions := w.regions(size)
+	if evm, ok := ev.(*tcell.EventMouse); ok {
+		mx, my := evm.Position()
+		for i := len(regions) - 1; i >= 0; i-- {
+			r := regions[i]
+			if !r.contains(mx, my) {
+				continue
+			}
+			if evm.Buttons() != tcell.ButtonNone && r.widget.Selectable() {
+				if i == 0 {
+					w.app.focusRoot = nil
+				} else {
+					w.app.focusRoot = r
+				}
+			}
+			ev2 := TranslatedMouseEvent(ev, -r.rect.X, -r.rect.Y)
+			return UserInputIfSelectable(r.widget, ev2, RenderBox{C: r.rect.W, R: r.rect.H}, focus.And(w.focused(i, r)), app)
+		}
+		return false
+	}
+	for i, r := range regions {
+		if w.focused(i, r) {
+			return UserInputIfSelectable(r.widget, ev, RenderBox{C: r.rect.W, R: r.rect.H}, focus, app)
+		}
+	}
+	return false
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
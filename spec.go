@@ -0,0 +1,156 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//======================================================================
+
+// InvalidStyleSpec is returned when a string can't be parsed as a color and style
+// specification - see ParseColorSpec and ParseStyleSpec.
+type InvalidStyleSpec struct {
+	Spec   string
+	Reason string
+}
+
+var _ error = InvalidStyleSpec{}
+
+func (e InvalidStyleSpec) Error() string {
+	return fmt.Sprintf("Style spec %q is invalid: %s", e.Spec, e.Reason)
+}
+
+// specSettings maps the settings allowed in a spec to styles. The first six are urwid's;
+// the rest are gowid's own.
+var specSettings = map[string]StyleAttrs{
+	"bold":             StyleBold,
+	"italics":          StyleItalic,
+	"underline":        StyleUnderline,
+	"blink":            StyleBlink,
+	"standout":         StyleReverse,
+	"strikethrough":    StyleStrikethrough,
+	"italic":           StyleItalic,
+	"reverse":          StyleReverse,
+	"dim":              StyleDim,
+	"double-underline": StyleDoubleUnderline,
+	"curly-underline":  StyleCurlyUnderline,
+}
+
+// ParseColorSpec parses an urwid display attribute string - a color and settings,
+// separated by commas, e.g. "dark blue,underline", "bold" or "#f80,bold,italics". The
+// color may be anything MakeColorSafe accepts, and may be omitted, in which case NoColor
+// is returned. The settings are urwid's - bold, italics, underline, blink, standout and
+// strikethrough - and gowid's italic, reverse, dim, double-underline and curly-underline.
+func ParseColorSpec(spec string) (IColor, StyleAttrs, error) {
+	var col IColor = NoColor{}
+	style := StyleNone
+	haveColor := false
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if s, ok := specSettings[strings.ToLower(part)]; ok {
+			style = style.MergeUnder(s)
+			continue
+		}
+		if haveColor {
+			return nil, StyleNone, errors.WithStack(InvalidStyleSpec{Spec: spec, Reason: "more than one color"})
+		}
+		c, err := MakeColorSafe(part)
+		if err != nil {
+			return nil, StyleNone, errors.WithStack(InvalidStyleSpec{Spec: spec, Reason: fmt.Sprintf("%q is not a color or setting", part)})
+		}
+		col = c
+		haveColor = true
+	}
+	return col, style, nil
+}
+
+// ParseStyleSpec parses a foreground and background in one string, separated by " on ",
+// e.g. "yellow,bold on dark blue", and returns them as a PaletteEntry. Each side is
+// parsed with ParseColorSpec, and the settings of both apply. If there is no " on ", the
+// background is NoColor.
+func ParseStyleSpec(spec string) (PaletteEntry, error) {
+	fgSpec, bgSpec := spec, ""
+	if i := strings.LastIndex(spec, " on "); i != -1 {
+		fgSpec, bgSpec = spec[:i], spec[i+len(" on "):]
+	}
+	fg, fgStyle, err := ParseColorSpec(fgSpec)
+	if err != nil {
+		return PaletteEntry{}, err
+	}
+	bg, bgStyle, err := ParseColorSpec(bgSpec)
+	if err != nil {
+		return PaletteEntry{}, err
+	}
+	return MakeStyledPaletteEntry(fg, bg, fgStyle.MergeUnder(bgStyle)), nil
+}
+
+// MakeStyleSpec is like ParseStyleSpec, but panics if spec is invalid.
+func MakeStyleSpec(spec string) PaletteEntry {
+	res, err := ParseStyleSpec(spec)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+//======================================================================
+
+// ReadPalette reads a palette with one entry per line, in the form
+//
+//	name: foreground[,settings] [on background]
+//
+// where the spec after the colon is parsed by ParseStyleSpec, e.g.
+//
+//	# Comments and blank lines are ignored
+//	title: yellow,bold on dark blue
+//	warning: light red,underline
+//
+// If any lines are invalid, the palette read from the rest is returned along with a
+// PaletteErrors holding a problem for each.
+func ReadPalette(r io.Reader) (Palette, error) {
+	res := make(Palette)
+	var errs PaletteErrors
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.Index(text, ":")
+		if i == -1 {
+			errs = append(errs, PaletteError{Name: text, Err: errors.Errorf("line %d: expected name: spec", line)})
+			continue
+		}
+		name := strings.TrimSpace(text[:i])
+		entry, err := ParseStyleSpec(strings.TrimSpace(text[i+1:]))
+		if err != nil {
+			errs = append(errs, PaletteError{Name: name, Err: errors.Errorf("line %d: %v", line, err)})
+			continue
+		}
+		res[name] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return res, errors.WithStack(err)
+	}
+	if len(errs) > 0 {
+		return res, errs
+	}
+	return res, nil
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,248 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+//======================================================================
+
+// IStateful is implemented by widgets whose view state - e.g. a list's scroll position,
+// or a pile's dimensions - can be saved in a Snapshot and restored, e.g. when the app
+// next starts. SaveState returns a value that can be marshaled to JSON; RestoreState is
+// given that JSON back.
+type IStateful interface {
+	SaveState() (interface{}, error)
+	RestoreState(data json.RawMessage, ctx *StateContext, app IApp) error
+}
+
+// StateContext is supplied when a snapshot is restored. Widgets that swap their
+// content, like holder, save the ID of the widget they hold; Widgets maps those IDs,
+// formatted as with fmt.Sprint, to the widgets to restore.
+type StateContext struct {
+	Widgets map[string]IWidget
+}
+
+// Widget returns the widget with the given ID, or nil.
+func (c *StateContext) Widget(id string) IWidget {
+	if c == nil {
+		return nil
+	}
+	return c.Widgets[id]
+}
+
+// Snapshot holds the view state of a widget tree - the focus path, and the state of
+// each IStateful widget that has an ID - in a form that can be saved as JSON. Widgets
+// are keyed by their IDs, so the state can be restored to a tree built afresh, as long
+// as the same IDs are used; give a widget an ID with WithID. Only string and numeric
+// IDs are used, since others, like the addresses from AddressProvidesID, don't survive
+// a restart.
+type Snapshot struct {
+	Focus   []FocusStep                `json:"focus,omitempty"`
+	Widgets map[string]json.RawMessage `json:"widgets,omitempty"`
+}
+
+// TakeSnapshot saves the view state of the widget tree rooted at root.
+func TakeSnapshot(root IWidget) (*Snapshot, error) {
+	res := &Snapshot{
+		Focus:   FocusPathByID(root),
+		Widgets: make(map[string]json.RawMessage),
+	}
+	var err error
+	forEachStateful(root, func(key string, w IStateful) bool {
+		var st interface{}
+		if st, err = w.SaveState(); err != nil {
+			err = fmt.Errorf("widget %s: %w", key, err)
+			return false
+		}
+		if res.Widgets[key], err = json.Marshal(st); err != nil {
+			err = fmt.Errorf("widget %s: %w", key, err)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Restore applies the snapshot to the widget tree rooted at root. Widgets whose IDs
+// aren't in the snapshot are left alone, as are saved states whose widgets have gone.
+// The focus path is restored last, as far as it still applies. If a widget can't
+// restore its state, the others are still restored, and the first error is returned.
+func (s *Snapshot) Restore(root IWidget, ctx *StateContext, app IApp) error {
+	var res error
+	forEachStateful(root, func(key string, w IStateful) bool {
+		if data, ok := s.Widgets[key]; ok {
+			if err := w.RestoreState(data, ctx, app); err != nil && res == nil {
+				res = fmt.Errorf("widget %s: %w", key, err)
+			}
+		}
+		return true
+	})
+	SetFocusPathByID(root, s.Focus, app)
+	return res
+}
+
+// forEachStateful calls fn for each widget in the tree that has a string or numeric ID
+// and is, or wraps, an IStateful widget. A holder whose content is restored is visited
+// before its new content.
+func forEachStateful(root IWidget, fn func(key string, w IStateful) bool) {
+	WalkWidgets(root, WidgetVisitor{
+		EnterFn: func(w IWidget, depth int) WalkAction {
+			key, ok := StateKeyOf(w)
+			if !ok {
+				return WalkContinue
+			}
+			// Find the stateful widget that this ID names - it or the widget it wraps
+			for sw := w; sw != nil; {
+				if st, ok := sw.(IStateful); ok {
+					if !fn(key, st) {
+						return WalkStop
+					}
+					break
+				}
+				cw, ok := sw.(IComposite)
+				if !ok {
+					break
+				}
+				sw = cw.SubWidget()
+			}
+			return WalkContinue
+		},
+	})
+}
+
+// StateKeyOf returns the widget's ID as a string, if it has a string or numeric ID.
+func StateKeyOf(w IWidget) (string, bool) {
+	id, ok := w.(IIdentity)
+	if !ok || id.ID() == nil {
+		return "", false
+	}
+	switch reflect.TypeOf(id.ID()).Kind() {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(id.ID()), true
+	default:
+		return "", false
+	}
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// SaveSnapshot saves the view state of the app's widgets - see TakeSnapshot.
+func (a *App) SaveSnapshot() (*Snapshot, error) {
+	return TakeSnapshot(a.SubWidget())
+}
+
+// RestoreSnapshot restores the view state of the app's widgets from a snapshot made
+// with SaveSnapshot - perhaps by a previous run of the app - and redraws.
+func (a *App) RestoreSnapshot(s *Snapshot, ctx *StateContext) error {
+	err := s.Restore(a.SubWidget(), ctx, a)
+	a.Redraw()
+	return err
+}
+
+//======================================================================
+
+// DimensionState is a widget dimension in a form that can be saved as JSON, for
+// containers like pile and columns that save their dimensions in a Snapshot.
+type DimensionState struct {
+	Kind string  `json:"kind"`
+	N    float64 `json:"n,omitempty"`
+	M    int     `json:"m,omitempty"`
+}
+
+// MakeDimensionState converts a dimension to a DimensionState. It returns false for a
+// kind of dimension it doesn't know.
+func MakeDimensionState(d IWidgetDimension) (DimensionState, bool) {
+	switch d := d.(type) {
+	case RenderFixed:
+		return DimensionState{Kind: "fixed"}, true
+	case RenderFlow:
+		return DimensionState{Kind: "flow"}, true
+	case RenderWithWeight:
+		return DimensionState{Kind: "weight", N: float64(d.W)}, true
+	case RenderWithUnits:
+		return DimensionState{Kind: "units", N: float64(d.U)}, true
+	case RenderWithRatio:
+		return DimensionState{Kind: "ratio", N: d.R}, true
+	case RenderFlowWith:
+		return DimensionState{Kind: "flowwith", N: float64(d.C)}, true
+	case RenderBox:
+		return DimensionState{Kind: "box", N: float64(d.C), M: d.R}, true
+	default:
+		return DimensionState{}, false
+	}
+}
+
+// Dimension converts the DimensionState back to a dimension. It returns false if the
+// kind isn't known.
+func (d DimensionState) Dimension() (IWidgetDimension, bool) {
+	switch d.Kind {
+	case "fixed":
+		return RenderFixed{}, true
+	case "flow":
+		return RenderFlow{}, true
+	case "weight":
+		return RenderWithWeight{W: int(d.N)}, true
+	case "units":
+		return RenderWithUnits{U: int(d.N)}, true
+	case "ratio":
+		return RenderWithRatio{R: d.N}, true
+	case "flowwith":
+		return RenderFlowWith{C: int(d.N)}, true
+	case "box":
+		return RenderBox{C: int(d.N), R: d.M}, true
+	default:
+		return nil, false
+	}
+}
+
+// SaveDimensions converts a container's dimensions for a snapshot. Dimensions of kinds
+// that MakeDimensionState doesn't know are saved as nil.
+func SaveDimensions(dims []IWidgetDimension) []*DimensionState {
+	res := make([]*DimensionState, len(dims))
+	for i, d := range dims {
+		if ds, ok := MakeDimensionState(d); ok {
+			res[i] = &ds
+		}
+	}
+	return res
+}
+
+// RestoreDimensions applies dimensions saved with SaveDimensions to a container's
+// current dimensions, returning the result. Saved dimensions that are nil or unknown
+// leave the current ones unchanged; if the container now has a different number of
+// children, the saved dimensions are ignored.
+func RestoreDimensions(cur []IWidgetDimension, data json.RawMessage) ([]IWidgetDimension, error) {
+	var saved []*DimensionState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	res := append([]IWidgetDimension(nil), cur...)
+	if len(saved) != len(cur) {
+		return res, nil
+	}
+	for i, ds := range saved {
+		if ds == nil {
+			continue
+		}
+		if d, ok := ds.Dimension(); ok {
+			res[i] = d
+		}
+	}
+	return res, nil
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,108 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+//======================================================================
+
+// StyleCondition decides, given the context a style is rendered in, which of two
+// ICellStylers a Conditional uses.
+type StyleCondition func(prov IRenderContext) bool
+
+// InColorModes returns a StyleCondition that is true when rendering in one of modes -
+// e.g. to use a gradient in 24-bit color, and something plainer otherwise.
+func InColorModes(modes ...ColorMode) StyleCondition {
+	return func(prov IRenderContext) bool {
+		mode := prov.GetColorMode()
+		for _, m := range modes {
+			if m == mode {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Conditional is an ICellStyler that behaves like Then when Cond is true in the context
+// it is rendered in, and like Else otherwise. A nil styler expresses no preference.
+type Conditional struct {
+	Cond StyleCondition
+	Then ICellStyler
+	Else ICellStyler
+}
+
+var _ ICellStyler = (*Conditional)(nil)
+
+// When returns an ICellStyler that styles like a when cond is true, and like b otherwise.
+// The condition is evaluated every time the style is used, so it can depend on the color
+// mode or on the state of the app, e.g.
+//
+//	gowid.When(gowid.InColorModes(gowid.ModeMonochrome), gowid.MakeStyledAs(gowid.StyleReverse), highlight)
+func When(cond StyleCondition, a, b ICellStyler) Conditional {
+	return Conditional{Cond: cond, Then: a, Else: b}
+}
+
+// GetStyle implements ICellStyler.
+func (a Conditional) GetStyle(prov IRenderContext) (x IColor, y IColor, z StyleAttrs) {
+	st := a.Else
+	if a.Cond(prov) {
+		st = a.Then
+	}
+	if st == nil {
+		return NoColor{}, NoColor{}, StyleNone
+	}
+	return st.GetStyle(prov)
+}
+
+//======================================================================
+
+// Composed is an ICellStyler that layers its Stylers in order, each on top of those before
+// it. A color from a later styler replaces an earlier one, unless it is NoColor, and the
+// styles are merged, so e.g. a later StyledAs(StyleBold) adds bold to the colors and
+// styles beneath it.
+type Composed struct {
+	Stylers []ICellStyler
+}
+
+var _ ICellStyler = (*Composed)(nil)
+
+// Compose returns an ICellStyler that layers stylers in order - see Composed.
+func Compose(stylers ...ICellStyler) Composed {
+	return Composed{Stylers: stylers}
+}
+
+// GetStyle implements ICellStyler.
+func (a Composed) GetStyle(prov IRenderContext) (x IColor, y IColor, z StyleAttrs) {
+	x, y, z = NoColor{}, NoColor{}, StyleNone
+	mode := prov.GetColorMode()
+	for _, st := range a.Stylers {
+		if st == nil {
+			continue
+		}
+		f, b, s := st.GetStyle(prov)
+		if hasColorPreference(f, mode) {
+			x = f
+		}
+		if hasColorPreference(b, mode) {
+			y = b
+		}
+		z = z.MergeUnder(s)
+	}
+	return
+}
+
+// hasColorPreference returns true if col is a color other than NoColor in mode.
+func hasColorPreference(col IColor, mode ColorMode) bool {
+	if col == nil {
+		return false
+	}
+	tc, ok := col.ToTCellColor(mode)
+	return ok && tc != ColorNone
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -7,6 +7,8 @@ package gowid
 
 import (
 	"fmt"
+	"reflect"
+	"runtime"
 	"strings"
 	"time"
 
@@ -404,6 +406,54 @@ type ISelectChild interface {
 	SelectChild(Selector) bool // Whether or not this widget will set focus.Selected for its selected child
 }
 
+// ISelectionStyler is implemented by container widgets that style their selected child
+// themselves, rather than leaving it to styling widgets wrapped around each child. The
+// argument is the Selector the child is rendered with; a nil result means no styling.
+type ISelectionStyler interface {
+	SelectionStyle(Selector) ICellStyler
+}
+
+// SelectionStyle returns the style for a child rendered with sel - focused if sel.Focus
+// is set, or selected if only sel.Selected is. If focused is nil, selected is used for
+// the focused child too. Containers can use it to implement ISelectionStyler.
+func SelectionStyle(sel Selector, selected, focused ICellStyler) ICellStyler {
+	switch {
+	case sel.Focus && focused != nil:
+		return focused
+	case sel.Selected:
+		return selected
+	default:
+		return nil
+	}
+}
+
+// ApplySelectionStyle styles c, the canvas of a child of w rendered with sel, if w
+// implements ISelectionStyler and provides a style for sel - see StyleCanvas.
+func ApplySelectionStyle(w interface{}, c IRangeOverCanvas, sel Selector, app IApp) {
+	if ss, ok := w.(ISelectionStyler); ok {
+		if st := ss.SelectionStyle(sel); st != nil {
+			StyleCanvas(c, st, app)
+		}
+	}
+}
+
+// StyleCanvas applies the colors and style of st to every cell of c, in place. As with
+// the styled widget, they are layered under the cell's own, so e.g. text that sets its
+// own foreground color keeps it.
+func StyleCanvas(c IRangeOverCanvas, st ICellStyler, app IApp) {
+	f, b, s := st.GetStyle(app)
+	fg, bg := ColorNone, ColorNone
+	if f != nil {
+		fg = ColorToTCellIn(f, ColorNone, app)
+	}
+	if b != nil {
+		bg = ColorToTCellIn(b, ColorNone, app)
+	}
+	RangeOverCanvas(c, CellRangeFunc(func(cell Cell) Cell {
+		return cell.WithForegroundColor(fg).WithBackgroundColor(bg).WithStyle(s).MergeDisplayAttrsUnder(cell)
+	}))
+}
+
 // IWidget is the interface of any object acting as a gowid widget.
 //
 // Render() is provided a size (cols, maybe rows), whether or not the widget
@@ -755,9 +805,15 @@ type HAlignLeft struct {
 	MarginRight int
 }
 
-func (h HAlignRight) ImplementsHAlignment()  {}
-func (h HAlignMiddle) ImplementsHAlignment() {}
-func (h HAlignLeft) ImplementsHAlignment()   {}
+// HAlignJustify stretches wrapped lines of text to fill the available width by widening
+// the gaps between words. The last line of each paragraph is aligned left. Widgets that
+// don't lay out text treat it like HAlignLeft.
+type HAlignJustify struct{}
+
+func (h HAlignRight) ImplementsHAlignment()   {}
+func (h HAlignMiddle) ImplementsHAlignment()  {}
+func (h HAlignLeft) ImplementsHAlignment()    {}
+func (h HAlignJustify) ImplementsHAlignment() {}
 
 type IVAlignment interface {
 	ImplementsVAlignment()
@@ -776,6 +832,143 @@ func (v VAlignTop) ImplementsVAlignment()    {}
 
 //======================================================================
 
+// ErrorPolicy determines what happens when a widget fails to render because of a
+// dimension mismatch - for example, a box-only widget given a flow render size.
+type ErrorPolicy int
+
+const (
+	// PanicOnRenderError lets the panic propagate, terminating the application. This
+	// is the default, and is most useful during development.
+	PanicOnRenderError ErrorPolicy = iota
+	// RecoverRenderError reports the error and renders a placeholder in place of the
+	// failed widget.
+	RecoverRenderError
+)
+
+func (p ErrorPolicy) String() string {
+	switch p {
+	case PanicOnRenderError:
+		return "panic"
+	case RecoverRenderError:
+		return "recover"
+	default:
+		return fmt.Sprintf("policy[%d]", int(p))
+	}
+}
+
+// IRenderErrorHandler is optionally implemented by an IApp. Container widgets use it
+// to decide whether to recover from a child widget's render error, and to report it.
+type IRenderErrorHandler interface {
+	RenderErrorPolicy() ErrorPolicy
+	HandleRenderError(w IWidget, size IRenderSize, err error)
+}
+
+// RenderErrorFunc is called by the App when a render error is recovered.
+type RenderErrorFunc func(app IApp, w IWidget, size IRenderSize, err error)
+
+// AsRenderError returns the error represented by the value recovered from a panic,
+// and true, if it is one gowid considers recoverable - a DimensionError, WidgetSizeError,
+// CanvasSizeWrong or failed type assertion. Errors wrapped with github.com/pkg/errors
+// are unwrapped to find the cause.
+func AsRenderError(r interface{}) (error, bool) {
+	err, ok := r.(error)
+	if !ok {
+		return nil, false
+	}
+	switch cause := errors.Cause(err).(type) {
+	case DimensionError, WidgetSizeError, CanvasSizeWrong:
+		return err, true
+	case *runtime.TypeAssertionError:
+		return cause, true
+	}
+	return nil, false
+}
+
+// RenderChild renders the widget, like w.Render(). But if the app's error policy is
+// RecoverRenderError, a recoverable render error is reported to the app and the
+// widget is replaced by an ErrorPlaceholder of the requested size. If the app is timing
+// renders, the render is timed - see IRenderTimer. Container widgets should use this to
+// render their children.
+func RenderChild(w IWidget, size IRenderSize, focus Selector, app IApp) (res ICanvas) {
+	if rt, ok := app.(IRenderTimer); ok && rt.RenderTimingOn() {
+		start := time.Now()
+		defer func() {
+			if res != nil {
+				rt.RecordRenderTiming(w, RenderTiming{
+					Size:     size,
+					Rendered: RenderBox{C: res.BoxColumns(), R: res.BoxRows()},
+					Duration: time.Since(start),
+				})
+			}
+		}()
+	}
+	if h, ok := app.(IRenderErrorHandler); ok && h.RenderErrorPolicy() == RecoverRenderError {
+		defer func() {
+			if r := recover(); r != nil {
+				res = renderPanicked(w, size, focus, app, r)
+			}
+		}()
+	}
+	return w.Render(size, focus, app)
+}
+
+// renderPanicked handles the value r recovered from a panic while rendering w, as
+// RenderChild does - returning an ErrorPlaceholder if the app recovers from the error,
+// and panicking with r otherwise.
+func renderPanicked(w IWidget, size IRenderSize, focus Selector, app IApp, r interface{}) ICanvas {
+	if h, ok := app.(IRenderErrorHandler); ok && h.RenderErrorPolicy() == RecoverRenderError {
+		if err, ok := AsRenderError(r); ok {
+			h.HandleRenderError(w, size, err)
+			return (&ErrorPlaceholder{Err: err}).Render(size, focus, app)
+		}
+	}
+	panic(r)
+}
+
+// ErrorPlaceholder is rendered in place of a widget that failed to render. It shows a
+// truncated description of the error, in reverse video so that it stands out.
+type ErrorPlaceholder struct {
+	Err error
+	RejectUserInput
+	NotSelectable
+}
+
+var _ IWidget = (*ErrorPlaceholder)(nil)
+
+func (w *ErrorPlaceholder) String() string {
+	return fmt.Sprintf("error[%v]", w.Err)
+}
+
+func (w *ErrorPlaceholder) Message() string {
+	return fmt.Sprintf("!%v!", w.Err)
+}
+
+func (w *ErrorPlaceholder) RenderSize(size IRenderSize, focus Selector, app IApp) IRenderBox {
+	switch sz := size.(type) {
+	case IRenderBox:
+		return RenderBox{C: sz.BoxColumns(), R: sz.BoxRows()}
+	case IRenderFlowWith:
+		return RenderBox{C: sz.FlowColumns(), R: 1}
+	default:
+		return RenderBox{C: len([]rune(w.Message())), R: 1}
+	}
+}
+
+func (w *ErrorPlaceholder) Render(size IRenderSize, focus Selector, app IApp) ICanvas {
+	box := w.RenderSize(size, focus, app)
+	cols, rows := box.BoxColumns(), box.BoxRows()
+	res := CanvasPoolOf(app).NewCanvasOfSizeExt(cols, rows, Cell{}.WithStyle(StyleReverse))
+	if rows > 0 {
+		msg := []rune(w.Message())
+		for i := 0; i < cols && i < len(msg); i++ {
+			res.SetCellAt(i, 0, res.CellAt(i, 0).WithRune(msg[i]))
+		}
+	}
+	return res
+}
+
+//======================================================================
+
 // CalculateRenderSizeFallback can be used by widgets that cannot easily compute a value
 // for RenderSize without actually rendering the widget and measuring the bounding box.
 // It assumes that if IRenderBox size is provided, then the widget's canvas when rendered
@@ -832,27 +1025,48 @@ func SubWidgetSize(w ICompositeWidget, size IRenderSize, focus Selector, app IAp
 // with an IRenderBox size argument equal to the size of the current terminal.
 func RenderRoot(w IWidget, t *App) {
 	maxX, maxY := t.TerminalSize()
-	canvas := w.Render(RenderBox{C: maxX, R: maxY}, Focused, t)
-
-	// tcell will apply its default style to empty cells. But because gowid's model
-	// is to layer styles, here we explicitly merge each canvas cell on top of a cell
-	// constructed with the tcell default style. Therefore if the tcell default applies
-	// an underline, for example, then each canvas cell will be merged on top of a cell
-	// with an underline. If the upper cell masks out underline, then it won't show. But
-	// if the upper cell doesn't mask out the underline, it will show.
+	t.geometryPending = t.geometryPending[:0]
+	t.acceleratorsPending = t.acceleratorsPending[:0]
+	t.cursorStylesPending = t.cursorStylesPending[:0]
+	t.clearRenderTimings()
+	canvas := RenderChild(w, RenderBox{C: maxX, R: maxY}, Focused, t)
+	t.collectGeometry(canvas)
+	t.collectAccelerators(canvas)
+	t.collectCursorStyle(canvas)
+
+	layerDefaultStyle(canvas, t)
+
+	t.drawDragGhost(canvas)
+
+	if t.displayFilter != nil {
+		RangeOverCanvas(canvas, t.displayFilter)
+	}
+
+	Draw(canvas, t, t.GetScreen())
+	t.recordFrame(canvas)
+	ReleaseCanvas(canvas)
+}
+
+// layerDefaultStyle merges each cell of the canvas over the app's "default" palette
+// entry, if it has one.
+//
+// tcell will apply its default style to empty cells. But because gowid's model
+// is to layer styles, here we explicitly merge each canvas cell on top of a cell
+// constructed with the tcell default style. Therefore if the tcell default applies
+// an underline, for example, then each canvas cell will be merged on top of a cell
+// with an underline. If the upper cell masks out underline, then it won't show. But
+// if the upper cell doesn't mask out the underline, it will show.
+func layerDefaultStyle(canvas IRangeOverCanvas, t *App) {
 	if paletteDefault, ok := t.CellStyler("default"); ok {
 		defFg := ColorDefault
 		defBg := ColorDefault
 		fgCol, bgCol, style := paletteDefault.GetStyle(t)
-		mode := t.GetColorMode()
-		defFg = IColorToTCell(fgCol, defFg, mode)
-		defBg = IColorToTCell(bgCol, defBg, mode)
+		defFg = ColorToTCellIn(fgCol, defFg, t)
+		defBg = ColorToTCellIn(bgCol, defBg, t)
 		RangeOverCanvas(canvas, CellRangeFunc(func(c Cell) Cell {
 			return MakeCell(c.codePoint, defFg, defBg, style).MergeDisplayAttrsUnder(c)
 		}))
 	}
-
-	Draw(canvas, t, t.GetScreen())
 }
 
 func FindNextSelectableFrom(w ICompositeMultipleDimensions, start int, dir Direction, wrap bool) (int, bool) {
@@ -1213,16 +1427,16 @@ func (k Key) String() string {
 	s := ""
 	m := []string{}
 	if k.mod&tcell.ModShift != 0 {
-		m = append(m, "Shift")
+		m = append(m, Translate("Shift"))
 	}
 	if k.mod&tcell.ModAlt != 0 {
-		m = append(m, "Alt")
+		m = append(m, Translate("Alt"))
 	}
 	if k.mod&tcell.ModMeta != 0 {
-		m = append(m, "Meta")
+		m = append(m, Translate("Meta"))
 	}
 	if k.mod&tcell.ModCtrl != 0 {
-		m = append(m, "Ctrl")
+		m = append(m, Translate("Ctrl"))
 	}
 
 	ok := false
@@ -1232,10 +1446,14 @@ func (k Key) String() string {
 		} else {
 			s = fmt.Sprintf("Key[%d,%d]", k.key, int(k.ch))
 		}
+	} else if strings.HasPrefix(s, "Ctrl-") {
+		s = Translate("Ctrl") + "-" + s[5:]
+	} else {
+		s = Translate(s)
 	}
 	if len(m) != 0 {
-		if k.mod&tcell.ModCtrl != 0 && strings.HasPrefix(s, "Ctrl-") {
-			s = s[5:]
+		if ctrl := Translate("Ctrl") + "-"; k.mod&tcell.ModCtrl != 0 && strings.HasPrefix(s, ctrl) {
+			s = s[len(ctrl):]
 		}
 		return fmt.Sprintf("%s+%s", strings.Join(m, "+"), s)
 	}
@@ -1765,6 +1983,140 @@ func SetFocusPath(w IWidget, path []interface{}, app IApp) FocusPathResult {
 	return res
 }
 
+// FocusStep is one level of a focus path found by FocusPathByID. ID identifies the
+// child in focus, if it or a widget it wraps implements IIdentity; Pos is the child's
+// position. String or numeric IDs let a path be saved, e.g. as JSON, and restored.
+type FocusStep struct {
+	ID  interface{} `json:"id,omitempty"`
+	Pos int         `json:"pos"`
+}
+
+// FocusPathByID is like FocusPath, but records the ID of the child in focus at each
+// level as well as its position. Unlike positions, IDs still identify the right
+// children after the widget tree is rebuilt - e.g. when data is refreshed - so the
+// path can be restored with SetFocusPathByID. Widgets without IDs of their own can be
+// given one with WithID.
+func FocusPathByID(w IWidget) []FocusStep {
+	res := make([]FocusStep, 0)
+	includeMe := true
+	for {
+		w = FindInHierarchy(w, includeMe, WidgetPredicate(func(w IWidget) bool {
+			_, ok := w.(IFocus)
+			return ok
+		}))
+		if w == nil {
+			break
+		}
+		includeMe = false
+		pos := w.(IFocus).Focus()
+		step := FocusStep{Pos: pos}
+		if cw, ok := w.(ICompositeMultiple); ok && pos >= 0 && pos < len(cw.SubWidgets()) {
+			step.ID = focusID(cw.SubWidgets()[pos])
+		}
+		res = append(res, step)
+	}
+	return res
+}
+
+// SetFocusPathByID applies a path made by FocusPathByID down the widget hierarchy
+// starting at w. At each level, the focus moves to the child with the step's ID, or,
+// if the step has no ID, to the step's position. If no child has the ID, the focus
+// moves to the step's position, if there is still a child there, and no more levels
+// are applied - the result's Succeeded field is false and FailedLevel is that level.
+func SetFocusPathByID(w IWidget, path []FocusStep, app IApp) FocusPathResult {
+	res := FocusPathResult{
+		Succeeded: true,
+	}
+	includeMe := true
+	for i, step := range path {
+		w = FindInHierarchy(w, includeMe, WidgetPredicate(func(w IWidget) bool {
+			_, ok := w.(IFocus)
+			return ok
+		}))
+		if w == nil {
+			res.Succeeded = false
+			res.FailedLevel = i
+			break
+		}
+		includeMe = false
+		wf := w.(IFocus)
+		pos := step.Pos
+		found := step.ID == nil
+		if cw, ok := w.(ICompositeMultiple); ok {
+			subs := cw.SubWidgets()
+			if !found {
+				for j, sub := range subs {
+					if sameID(focusID(sub), step.ID) {
+						pos, found = j, true
+						break
+					}
+				}
+			}
+			if pos < 0 || pos >= len(subs) {
+				res.Succeeded = false
+				res.FailedLevel = i
+				break
+			}
+		}
+		wf.SetFocus(app, pos)
+		if !found {
+			res.Succeeded = false
+			res.FailedLevel = i
+			break
+		}
+	}
+	return res
+}
+
+// focusID returns the ID of w, or of the first widget it wraps that has one, stopping
+// at the next widget that has a focus of its own - that widget's ID, e.g. from
+// AddressProvidesID, is unlikely to survive a rebuild, so wrap it with WithID instead.
+func focusID(w IWidget) interface{} {
+	for w != nil {
+		if _, ok := w.(IFocus); ok {
+			break
+		}
+		if id, ok := w.(IIdentity); ok && id.ID() != nil {
+			return id.ID()
+		}
+		cw, ok := w.(IComposite)
+		if !ok {
+			break
+		}
+		w = cw.SubWidget()
+	}
+	return nil
+}
+
+// sameID compares IDs without panicking if they can't be compared. Numbers of different
+// types are compared by value, so a path read back from JSON, whose numbers are all
+// float64, still matches.
+func sameID(a, b interface{}) bool {
+	if a == nil || b == nil || !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return false
+	}
+	if fa, ok := idNumber(a); ok {
+		if fb, ok := idNumber(b); ok {
+			return fa == fb
+		}
+	}
+	return a == b
+}
+
+func idNumber(id interface{}) (float64, bool) {
+	v := reflect.ValueOf(id)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
 //======================================================================
 
 type ICopyModeWidget interface {
@@ -0,0 +1,105 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"reflect"
+	"time"
+)
+
+//======================================================================
+
+// RenderTiming describes a widget's most recent render through RenderChild - the size it
+// was rendered with, the size of the canvas it produced and how long it took, including
+// the time taken by its children.
+type RenderTiming struct {
+	Size     IRenderSize
+	Rendered IRenderBox
+	Duration time.Duration
+}
+
+// IRenderTimer is implemented by an App that can time the widgets rendered through
+// RenderChild. Timing is off unless a debugging aid, like the inspector, turns it on;
+// the timings recorded in a frame are available until the next frame starts.
+type IRenderTimer interface {
+	SetRenderTiming(on bool)
+	RenderTimingOn() bool
+	RecordRenderTiming(w IWidget, t RenderTiming)
+	RenderTimingOf(w IWidget) (RenderTiming, bool)
+}
+
+// SetRenderTiming asks the app to time, or stop timing, the widgets rendered through
+// RenderChild. It does nothing if the app can't time widgets.
+func SetRenderTiming(on bool, app IApp) {
+	if rt, ok := app.(IRenderTimer); ok {
+		rt.SetRenderTiming(on)
+	}
+}
+
+// RenderTimingOf returns the timing of w's last render in the current or most recent
+// frame, and false if w wasn't timed or the app doesn't time widgets.
+func RenderTimingOf(w IWidget, app IApp) (RenderTiming, bool) {
+	if rt, ok := app.(IRenderTimer); ok {
+		return rt.RenderTimingOf(w)
+	}
+	return RenderTiming{}, false
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (a *App) SetRenderTiming(on bool) {
+	a.trackMu.Lock()
+	defer a.trackMu.Unlock()
+	if !on {
+		a.timings = nil
+	} else if a.timings == nil {
+		a.timings = make(map[IWidget]RenderTiming)
+	}
+}
+
+func (a *App) RenderTimingOn() bool {
+	a.trackMu.Lock()
+	defer a.trackMu.Unlock()
+	return a.timings != nil
+}
+
+// RecordRenderTiming records the timing of a widget's render. If the widget renders
+// more than once in a frame, the last render wins. Widgets that can't be compared, and
+// so can't be looked up, aren't recorded.
+func (a *App) RecordRenderTiming(w IWidget, t RenderTiming) {
+	if w == nil || !reflect.TypeOf(w).Comparable() {
+		return
+	}
+	a.trackMu.Lock()
+	defer a.trackMu.Unlock()
+	if a.timings != nil {
+		a.timings[w] = t
+	}
+}
+
+func (a *App) RenderTimingOf(w IWidget) (RenderTiming, bool) {
+	if w == nil || !reflect.TypeOf(w).Comparable() {
+		return RenderTiming{}, false
+	}
+	a.trackMu.Lock()
+	defer a.trackMu.Unlock()
+	res, ok := a.timings[w]
+	return res, ok
+}
+
+// clearRenderTimings discards the timings of the last frame, if timing is on.
+func (a *App) clearRenderTimings() {
+	a.trackMu.Lock()
+	defer a.trackMu.Unlock()
+	if a.timings != nil {
+		a.timings = make(map[IWidget]RenderTiming)
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,79 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+	"sync"
+)
+
+//======================================================================
+
+// ITranslator is implemented by types that translate the user-visible strings built into
+// gowid and its widgets - dialog button labels, key names like "Enter" and "Ctrl", and
+// messages like "no matches". Each string is passed in English, as written in the source;
+// a translator returns it unchanged if it has no translation. Formatted messages are
+// translated before formatting, so "lines %d-%d%s %d%%" is passed as is.
+type ITranslator interface {
+	Translate(msg string) string
+}
+
+// TranslatorFunc adapts a function to ITranslator.
+type TranslatorFunc func(msg string) string
+
+func (f TranslatorFunc) Translate(msg string) string {
+	return f(msg)
+}
+
+// TranslatorMap is an ITranslator that looks strings up in a map.
+type TranslatorMap map[string]string
+
+func (m TranslatorMap) Translate(msg string) string {
+	if res, ok := m[msg]; ok {
+		return res
+	}
+	return msg
+}
+
+var _ ITranslator = TranslatorFunc(nil)
+var _ ITranslator = TranslatorMap(nil)
+
+var (
+	translatorMtx sync.RWMutex
+	translator    ITranslator
+)
+
+// SetTranslator sets the translator used for gowid's built-in strings, so an app can
+// ship a non-English UI without forking widgets. Set it before building widgets - some,
+// like dialog buttons, translate their labels when they're made. Pass nil to go back to
+// English.
+func SetTranslator(t ITranslator) {
+	translatorMtx.Lock()
+	defer translatorMtx.Unlock()
+	translator = t
+}
+
+// Translate returns msg translated by the translator set with SetTranslator, or msg
+// itself if none is set.
+func Translate(msg string) string {
+	translatorMtx.RLock()
+	t := translator
+	translatorMtx.RUnlock()
+	if t == nil {
+		return msg
+	}
+	return t.Translate(msg)
+}
+
+// Translatef translates format, then formats it with args as fmt.Sprintf does.
+func Translatef(format string, args ...interface{}) string {
+	return fmt.Sprintf(Translate(format), args...)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,40 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"testing"
+
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate1(t *testing.T) {
+	assert.Equal(t, "Alt+Ctrl+Enter", MakeKeyExt2(tcell.ModCtrl|tcell.ModAlt, tcell.KeyEnter, 0).String())
+
+	SetTranslator(TranslatorMap{
+		"Ctrl":       "Strg",
+		"Alt":        "Alt",
+		"Enter":      "Eingabe",
+		"Need %dx%d": "Benötigt %dx%d",
+	})
+	defer SetTranslator(nil)
+
+	assert.Equal(t, "Alt+Strg+Eingabe", MakeKeyExt2(tcell.ModCtrl|tcell.ModAlt, tcell.KeyEnter, 0).String())
+	assert.Equal(t, "Strg-A", MakeKeyExt2(0, tcell.KeyCtrlA, 0).String())
+	assert.Equal(t, "Strg+A", MakeKeyExt2(tcell.ModCtrl, tcell.KeyCtrlA, 0).String())
+	assert.Equal(t, "x", MakeKeyExt2(0, tcell.KeyRune, 'x').String())
+	assert.Equal(t, "Benötigt 80x24", Translatef("Need %dx%d", 80, 24))
+	assert.Equal(t, "Cancel", Translate("Cancel"))
+
+	SetTranslator(TranslatorFunc(func(msg string) string { return "[" + msg + "]" }))
+	assert.Equal(t, "[Shift]|[Meta]", PrettyModMask(tcell.ModShift|tcell.ModMeta).String())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -104,19 +104,19 @@ func (p PrettyModMask) String() string {
 	mods := make([]string, 0)
 	m := int(p)
 	if m == int(tcell.ModNone) {
-		mods = append(mods, "None")
+		mods = append(mods, Translate("None"))
 	} else {
 		if m&int(tcell.ModShift) != 0 {
-			mods = append(mods, "Shift")
+			mods = append(mods, Translate("Shift"))
 		}
 		if m&int(tcell.ModCtrl) != 0 {
-			mods = append(mods, "Ctrl")
+			mods = append(mods, Translate("Ctrl"))
 		}
 		if m&int(tcell.ModAlt) != 0 {
-			mods = append(mods, "Alt")
+			mods = append(mods, Translate("Alt"))
 		}
 		if m&int(tcell.ModMeta) != 0 {
-			mods = append(mods, "Meta")
+			mods = append(mods, Translate("Meta"))
 		}
 	}
 	return strings.Join(mods, "|")
@@ -0,0 +1,232 @@
+// Copyright 2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gcla/gowid"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// Mode is the modal state of vim-style input.
+type Mode int
+
+const (
+	NormalMode Mode = iota
+	InsertMode
+	VisualMode
+)
+
+func (m Mode) String() string {
+	switch m {
+	case NormalMode:
+		return "normal"
+	case InsertMode:
+		return "insert"
+	case VisualMode:
+		return "visual"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// Command is a complete normal or visual mode command, composed from keystrokes like
+// "3j" or "d2w". Count is the count typed before the operator, and MotionCount the
+// count typed before the motion - each is 0 if not given. Operator is empty for a
+// plain motion or command. For a doubled operator like "dd", which applies to whole
+// lines, Motion is the operator again. In visual mode, an operator completes a
+// command on its own, with an empty Motion.
+type Command struct {
+	Count       int
+	Operator    string
+	MotionCount int
+	Motion      string
+}
+
+// Times is the number of times to apply the command's motion - the product of the
+// counts, each defaulting to 1.
+func (c Command) Times() int {
+	res := 1
+	if c.Count > 0 {
+		res = c.Count
+	}
+	if c.MotionCount > 0 {
+		res *= c.MotionCount
+	}
+	return res
+}
+
+// Linewise is true if the command is a doubled operator like "dd".
+func (c Command) Linewise() bool {
+	return c.Operator != "" && c.Operator == c.Motion
+}
+
+func (c Command) String() string {
+	var b strings.Builder
+	if c.Count > 0 {
+		fmt.Fprintf(&b, "%d", c.Count)
+	}
+	b.WriteString(c.Operator)
+	if c.MotionCount > 0 {
+		fmt.Fprintf(&b, "%d", c.MotionCount)
+	}
+	b.WriteString(c.Motion)
+	return b.String()
+}
+
+// ParseResult says what a Parser made of the latest keypress.
+type ParseResult int
+
+const (
+	// ParseNotMatched means the keypress doesn't start a command - it is not consumed.
+	ParseNotMatched ParseResult = iota
+	// ParsePending means the keypress was consumed and more are needed.
+	ParsePending
+	// ParseComplete means the keypress completed a command.
+	ParseComplete
+	// ParseInvalid means the keypress was consumed but the command can't be completed;
+	// the parser has been reset.
+	ParseInvalid
+)
+
+var (
+	DefaultMotions   = []string{"h", "j", "k", "l", "w", "b", "e", "0", "$", "gg", "G", "<C-f>", "<C-b>", "<Up>", "<Down>", "<Left>", "<Right>"}
+	DefaultOperators = []string{"d", "c", "y"}
+	DefaultCommands  = []string{"x", "X", "D", "C", "i", "a", "I", "A", "p", "P", "v"}
+)
+
+// Parser composes keypresses into commands. Motions, Operators and Commands are
+// key sequences in vim notation, e.g. "gg" or "<C-f>". Commands are standalone,
+// like "x" or "i" - they accept a count but not an operator.
+type Parser struct {
+	Motions   []string
+	Operators []string
+	Commands  []string
+	Visual    bool // if true, operators complete a command on their own
+	cmd       Command
+	keys      string
+	inMotion  bool // true once an operator has been typed
+}
+
+func NewParser() *Parser {
+	return &Parser{
+		Motions:   DefaultMotions,
+		Operators: DefaultOperators,
+		Commands:  DefaultCommands,
+	}
+}
+
+// Pending returns the keys typed towards the current command, e.g. "2d".
+func (p *Parser) Pending() string {
+	return p.cmd.String() + p.keys
+}
+
+// Reset discards any partial command.
+func (p *Parser) Reset() {
+	p.cmd = Command{}
+	p.keys = ""
+	p.inMotion = false
+}
+
+func (p *Parser) idle() bool {
+	return p.cmd == Command{} && p.keys == "" && !p.inMotion
+}
+
+// Feed adds a keypress to the current command. When the result is ParseComplete,
+// the command is returned and the parser is reset.
+func (p *Parser) Feed(k KeyPress) (Command, ParseResult) {
+	key := k.String()
+	if gowid.Key(k).Key() == tcell.KeyEscape {
+		if p.idle() {
+			return Command{}, ParseNotMatched
+		}
+		p.Reset()
+		return Command{}, ParseInvalid
+	}
+
+	// Counts - a 0 only counts if it continues a number
+	if p.keys == "" && len(key) == 1 && key[0] >= '0' && key[0] <= '9' {
+		count := &p.cmd.Count
+		if p.inMotion {
+			count = &p.cmd.MotionCount
+		}
+		if key != "0" || *count > 0 {
+			*count = *count*10 + int(key[0]-'0')
+			return Command{}, ParsePending
+		}
+	}
+
+	seq := p.keys + key
+
+	if !p.inMotion {
+		if contains(p.Operators, seq) {
+			if p.Visual {
+				p.cmd.Operator = seq
+				return p.complete()
+			}
+			p.cmd.Operator = seq
+			p.keys = ""
+			p.inMotion = true
+			return Command{}, ParsePending
+		}
+		if contains(p.Commands, seq) {
+			p.cmd.Motion = seq
+			return p.complete()
+		}
+	} else if seq == p.cmd.Operator {
+		p.cmd.Motion = seq
+		return p.complete()
+	}
+
+	if contains(p.Motions, seq) {
+		p.cmd.Motion = seq
+		return p.complete()
+	}
+
+	if hasPrefix(p.Motions, seq) || (!p.inMotion && (hasPrefix(p.Operators, seq) || hasPrefix(p.Commands, seq))) {
+		p.keys = seq
+		return Command{}, ParsePending
+	}
+
+	wasIdle := p.idle()
+	p.Reset()
+	if wasIdle {
+		return Command{}, ParseNotMatched
+	}
+	return Command{}, ParseInvalid
+}
+
+func (p *Parser) complete() (Command, ParseResult) {
+	res := p.cmd
+	p.Reset()
+	return res, ParseComplete
+}
+
+func contains(seqs []string, s string) bool {
+	for _, seq := range seqs {
+		if seq == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefix(seqs []string, s string) bool {
+	for _, seq := range seqs {
+		if len(seq) > len(s) && strings.HasPrefix(seq, s) {
+			return true
+		}
+	}
+	return false
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -166,7 +166,7 @@ func KeyPressFromTcell(k *tcell.EventKey) KeyPress {
 	mod := k.Modifiers()
 	tk := k.Key()
 	ch := k.Rune()
-	if tk >= tcell.KeyCtrlA && tk <= tcell.KeyCtrlZ {
+	if tk >= tcell.KeyCtrlA && tk <= tcell.KeyCtrlZ && !isTypeable(tk, mod) {
 		ch = rune(int(tk) + int('a') - 1)
 		tk = tcell.KeyRune
 	} else {
@@ -194,6 +194,16 @@ func KeyPressFromTcell(k *tcell.EventKey) KeyPress {
 	return KeyPress(gowid.MakeKeyExt2(mod, tk, ch))
 }
 
+// isTypeable returns true for the control keys that are typed without ctrl, as in
+// NewKeyPress - so e.g. enter matches KeyPressEnter, not ctrl-m.
+func isTypeable(tk tcell.Key, mod tcell.ModMask) bool {
+	switch tk {
+	case tcell.KeyBackspace, tcell.KeyTab, tcell.KeyEnter:
+		return mod&tcell.ModCtrl == 0
+	}
+	return false
+}
+
 func NewSimpleKeyPress(ch rune) KeyPress {
 	return NewKeyPress(tcell.KeyRune, ch, 0)
 }
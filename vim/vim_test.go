@@ -55,6 +55,69 @@ func TestVim1(t *testing.T) {
 			assert.Equal(t, kt.str, str)
 		}
 	}
+
+	// Keys typed without ctrl match their own names, not ctrl with a letter
+	assert.True(t, KeyIn(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), []KeyPress{KeyPressEnter}))
+	assert.True(t, KeyIn(tcell.NewEventKey(tcell.KeyCtrlN, 0, tcell.ModCtrl), []KeyPress{KeyCtrl('n')}))
+}
+
+func feedAll(p *Parser, keys string) ([]Command, ParseResult) {
+	var res ParseResult
+	cmds := make([]Command, 0)
+	for _, k := range VimStringToKeys(keys) {
+		var cmd Command
+		cmd, res = p.Feed(k)
+		if res == ParseComplete {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds, res
+}
+
+func TestParser1(t *testing.T) {
+	p := NewParser()
+	for _, tc := range []struct {
+		keys string
+		cmd  Command
+	}{
+		{"3j", Command{Count: 3, Motion: "j"}},
+		{"d2w", Command{Operator: "d", MotionCount: 2, Motion: "w"}},
+		{"2dd", Command{Count: 2, Operator: "d", Motion: "d"}},
+		{"gg", Command{Motion: "gg"}},
+		{"10x", Command{Count: 10, Motion: "x"}},
+		{"0", Command{Motion: "0"}},
+		{"y$", Command{Operator: "y", Motion: "$"}},
+		{"<C-f>", Command{Motion: "<C-f>"}},
+	} {
+		cmds, res := feedAll(p, tc.keys)
+		assert.Equal(t, ParseComplete, res, tc.keys)
+		assert.Equal(t, []Command{tc.cmd}, cmds, tc.keys)
+		assert.Equal(t, tc.keys, cmds[0].String())
+	}
+
+	assert.Equal(t, 6, Command{Count: 2, Operator: "d", MotionCount: 3, Motion: "w"}.Times())
+	assert.True(t, Command{Operator: "c", Motion: "c"}.Linewise())
+
+	// Not a command - not consumed
+	_, res := feedAll(p, "Z")
+	assert.Equal(t, ParseNotMatched, res)
+
+	// Operators don't take commands
+	_, res = feedAll(p, "2dx")
+	assert.Equal(t, ParseInvalid, res)
+	assert.Equal(t, "", p.Pending())
+
+	_, res = feedAll(p, "2dg")
+	assert.Equal(t, ParsePending, res)
+	assert.Equal(t, "2dg", p.Pending())
+	_, res = feedAll(p, "<Esc>")
+	assert.Equal(t, ParseInvalid, res)
+	_, res = feedAll(p, "<Esc>")
+	assert.Equal(t, ParseNotMatched, res)
+
+	p.Visual = true
+	cmds, _ := feedAll(p, "y")
+	assert.Equal(t, []Command{{Operator: "y"}}, cmds)
 }
 
 //======================================================================
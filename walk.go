@@ -0,0 +1,105 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+//======================================================================
+
+// WalkAction is returned by a visitor's Enter function to tell WalkWidgets how to
+// continue.
+type WalkAction int
+
+const (
+	WalkContinue     WalkAction = iota // Visit the widget's children
+	WalkSkipChildren                   // Don't visit the widget's children
+	WalkStop                           // End the walk
+)
+
+// IWidgetVisitor is implemented by types that visit a widget tree with WalkWidgets.
+// Enter is called for a widget before its children are visited, and Leave after them.
+// The root has depth 0.
+type IWidgetVisitor interface {
+	Enter(w IWidget, depth int) WalkAction
+	Leave(w IWidget, depth int)
+}
+
+// WidgetVisitor implements IWidgetVisitor with functions, either of which may be nil.
+type WidgetVisitor struct {
+	EnterFn func(w IWidget, depth int) WalkAction
+	LeaveFn func(w IWidget, depth int)
+}
+
+var _ IWidgetVisitor = WidgetVisitor{}
+
+func (v WidgetVisitor) Enter(w IWidget, depth int) WalkAction {
+	if v.EnterFn == nil {
+		return WalkContinue
+	}
+	return v.EnterFn(w, depth)
+}
+
+func (v WidgetVisitor) Leave(w IWidget, depth int) {
+	if v.LeaveFn != nil {
+		v.LeaveFn(w, depth)
+	}
+}
+
+// WalkWidgets visits every widget in the tree rooted at root, depth first. Unlike
+// FindInHierarchy, which follows the focus path, it descends all the children of
+// containers that expose them via ICompositeMultiple, and the child of each
+// IComposite. Widgets that create their children on demand, like lists, don't expose
+// them, so only what such a container holds directly is visited. If Enter returns
+// WalkSkipChildren, the widget's children are skipped but Leave is still called; if it
+// returns WalkStop, no more widgets are visited and no more Leave calls are made.
+// WalkWidgets returns false if the walk was stopped.
+func WalkWidgets(root IWidget, v IWidgetVisitor) bool {
+	return walkWidgets(root, 0, v)
+}
+
+func walkWidgets(w IWidget, depth int, v IWidgetVisitor) bool {
+	if w == nil {
+		return true
+	}
+	switch v.Enter(w, depth) {
+	case WalkStop:
+		return false
+	case WalkContinue:
+		switch cw := w.(type) {
+		case ICompositeMultiple:
+			for _, sub := range cw.SubWidgets() {
+				if !walkWidgets(sub, depth+1, v) {
+					return false
+				}
+			}
+		case IComposite:
+			if !walkWidgets(cw.SubWidget(), depth+1, v) {
+				return false
+			}
+		}
+	}
+	v.Leave(w, depth)
+	return true
+}
+
+// FindAll returns every widget in the tree rooted at w for which the predicate returns
+// true, in the order WalkWidgets visits them - e.g. to validate a UI, or to restyle
+// every widget of some type.
+func FindAll(w IWidget, pred WidgetPredicate) []IWidget {
+	res := make([]IWidget, 0)
+	WalkWidgets(w, WidgetVisitor{
+		EnterFn: func(w IWidget, depth int) WalkAction {
+			if pred(w) {
+				res = append(res, w)
+			}
+			return WalkContinue
+		},
+	})
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
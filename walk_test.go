@@ -0,0 +1,75 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package gowid
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestWalkWidgets1(t *testing.T) {
+	a, b, c := &leafWidget{name: "a"}, &leafWidget{name: "b"}, &leafWidget{name: "c"}
+	inner := &multiWidget{subs: []IWidget{b, c}}
+	root := &multiWidget{subs: []IWidget{Tag(a, "a"), inner}}
+
+	name := func(w IWidget) string {
+		switch w := w.(type) {
+		case *leafWidget:
+			return w.name
+		case *Metadata:
+			return "tag"
+		}
+		if w == IWidget(inner) {
+			return "inner"
+		}
+		return "root"
+	}
+
+	trace := make([]string, 0)
+	visitor := func(skip IWidget, stop IWidget) WidgetVisitor {
+		return WidgetVisitor{
+			EnterFn: func(w IWidget, depth int) WalkAction {
+				trace = append(trace, fmt.Sprintf("+%s%d", name(w), depth))
+				switch w {
+				case skip:
+					return WalkSkipChildren
+				case stop:
+					return WalkStop
+				}
+				return WalkContinue
+			},
+			LeaveFn: func(w IWidget, depth int) {
+				trace = append(trace, "-"+name(w))
+			},
+		}
+	}
+
+	assert.True(t, WalkWidgets(root, visitor(nil, nil)))
+	assert.Equal(t, []string{"+root0", "+tag1", "+a2", "-a", "-tag", "+inner1", "+b2", "-b", "+c2", "-c", "-inner", "-root"}, trace)
+
+	trace = trace[:0]
+	assert.True(t, WalkWidgets(root, visitor(inner, nil)))
+	assert.Equal(t, []string{"+root0", "+tag1", "+a2", "-a", "-tag", "+inner1", "-inner", "-root"}, trace)
+
+	trace = trace[:0]
+	assert.False(t, WalkWidgets(root, visitor(nil, b)))
+	assert.Equal(t, []string{"+root0", "+tag1", "+a2", "-a", "-tag", "+inner1", "+b2"}, trace)
+
+	found := FindAll(root, func(w IWidget) bool {
+		_, ok := w.(*leafWidget)
+		return ok
+	})
+	assert.Equal(t, []IWidget{a, b, c}, found)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,98 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package anchor provides a widget that records where its inner widget is rendered,
+// so that an overlay can position a popup next to it.
+package anchor
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+)
+
+//======================================================================
+
+type IAnchor interface {
+	AnchorName() string
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IAnchor
+}
+
+// Widget wraps another widget, marking the canvas it renders with its name - see
+// gowid.MarkRect. An overlay whose Anchor option is the same name places its top widget
+// next to this widget, provided this widget is rendered as part of the overlay's bottom
+// widget. The widget also opts in to the app's geometry tracking, so its screen area
+// can be found with gowid.GeometryOf.
+type Widget struct {
+	inner gowid.IWidget
+	name  string
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+	gowid.AddressProvidesID
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, name string) *Widget {
+	res := &Widget{
+		inner:     inner,
+		name:      name,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("anchor[%s:%v]", w.name, w.SubWidget())
+}
+
+func (w *Widget) AnchorName() string {
+	return w.name
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.inner
+}
+
+func (w *Widget) SetSubWidget(wi gowid.IWidget, app gowid.IApp) {
+	w.inner = wi
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+func (w *Widget) Selectable() bool {
+	return w.inner.Selectable()
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(w.inner, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	res := gowid.RenderChild(w.inner, size, focus, app)
+	gowid.MarkRect(res, w.name)
+	gowid.TrackGeometry(w, res, app)
+	return res
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return gowid.UserInputIfSelectable(w.inner, ev, size, focus, app)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
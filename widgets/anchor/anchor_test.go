@@ -0,0 +1,72 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package anchor
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestGeometry1(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(10, 3)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+
+	a1 := New(text.New("ab"), "a1")
+	a2 := New(text.New("cde"), "a2")
+	outer := New(columns.NewFixed(a1, text.New("-"), a2), "outer")
+	view := pile.NewFlow(text.New("top"), outer)
+
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen: screen,
+		View:   view,
+		Log:    logger,
+	})
+	assert.NoError(t, err)
+
+	_, ok := gowid.GeometryOf(a1.ID(), app)
+	assert.False(t, ok)
+
+	app.RedrawTerminal()
+
+	r, ok := gowid.GeometryOf(a1.ID(), app)
+	assert.True(t, ok)
+	assert.Equal(t, gowid.Rect{X: 0, Y: 1, W: 2, H: 1}, r)
+	r, ok = gowid.GeometryOf(a2.ID(), app)
+	assert.True(t, ok)
+	assert.Equal(t, gowid.Rect{X: 3, Y: 1, W: 3, H: 1}, r)
+	r, ok = gowid.GeometryOf(outer.ID(), app)
+	assert.True(t, ok)
+	assert.Equal(t, gowid.Rect{X: 0, Y: 1, W: 10, H: 1}, r)
+
+	// The innermost tracked widget wins
+	assert.Equal(t, gowid.IIdentity(a2), gowid.WidgetAt(4, 1, app))
+	assert.Equal(t, gowid.IIdentity(outer), gowid.WidgetAt(2, 1, app))
+	assert.Nil(t, gowid.WidgetAt(0, 0, app))
+
+	// Remove the anchors from the view - their geometry is gone after the next frame
+	view.SetSubWidgets([]gowid.IWidget{view.SubWidgets()[0]}, app)
+	app.RedrawTerminal()
+	_, ok = gowid.GeometryOf(a1.ID(), app)
+	assert.False(t, ok)
+	assert.Nil(t, gowid.WidgetAt(4, 1, app))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
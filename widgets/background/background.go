@@ -0,0 +1,240 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package background provides a widget that draws a repeating pattern, or a large
+// watermark, behind its child - useful for empty states and splash screens.
+package background
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+// Options can be supplied to New. Pattern is a tile, one string per row, repeated
+// across the whole area; rows shorter than the longest are padded with spaces.
+// Watermark is drawn once over the pattern, one string per row, placed according to
+// VAlign and HAlign - in the middle by default. Spaces in the watermark let the pattern
+// show through.
+type Options struct {
+	Pattern        []string
+	PatternStyle   gowid.ICellStyler
+	Watermark      []string
+	WatermarkStyle gowid.ICellStyler
+	VAlign         gowid.IVAlignment
+	HAlign         gowid.IHAlignment
+}
+
+type IBackground interface {
+	Pattern() []string
+	Watermark() []string
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IBackground
+}
+
+// Widget renders its child over the background. The background shows through wherever
+// the child's canvas has cells with no rune and no colors - e.g. the area a text
+// widget doesn't fill, or the cells of a null widget - so the child's own styling
+// should leave its background color unset. The widget renders at the child's size.
+type Widget struct {
+	gowid.IWidget
+	opts Options
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.VAlign == nil {
+		opt.VAlign = gowid.VAlignMiddle{}
+	}
+	if opt.HAlign == nil {
+		opt.HAlign = gowid.HAlignMiddle{}
+	}
+	res := &Widget{
+		IWidget: inner,
+		opts:    opt,
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("background[%v]", w.SubWidget())
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.IWidget
+}
+
+func (w *Widget) SetSubWidget(inner gowid.IWidget, app gowid.IApp) {
+	w.IWidget = inner
+	gowid.RunWidgetCallbacks(w, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+func (w *Widget) Pattern() []string {
+	return w.opts.Pattern
+}
+
+func (w *Widget) SetPattern(pattern []string, app gowid.IApp) {
+	w.opts.Pattern = pattern
+}
+
+func (w *Widget) Watermark() []string {
+	return w.opts.Watermark
+}
+
+func (w *Widget) SetWatermark(watermark []string, app gowid.IApp) {
+	w.opts.Watermark = watermark
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return w.SubWidget().RenderSize(size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	c := gowid.RenderChild(w.SubWidget(), size, focus, app)
+	res := gowid.CanvasPoolOf(app).NewCanvasOfSize(c.BoxColumns(), c.BoxRows())
+
+	if tile := makeCells(w.opts.Pattern, w.opts.PatternStyle, app); len(tile) > 0 && len(tile[0]) > 0 {
+		for y := 0; y < res.BoxRows(); y++ {
+			row := tile[y%len(tile)]
+			for x := 0; x < res.BoxColumns(); x++ {
+				res.SetCellAt(x, y, row[x%len(row)])
+			}
+		}
+		clipWide(res)
+	}
+
+	if mark := makeCells(w.opts.Watermark, w.opts.WatermarkStyle, app); len(mark) > 0 {
+		top := vOffset(w.opts.VAlign, res.BoxRows(), len(mark))
+		left := hOffset(w.opts.HAlign, res.BoxColumns(), len(mark[0]))
+		for y, row := range mark {
+			for x, cell := range row {
+				if cell.HasRune() && cell.Rune() == ' ' {
+					continue
+				}
+				if y+top >= 0 && y+top < res.BoxRows() && x+left >= 0 && x+left < res.BoxColumns() {
+					res.SetCellAt(x+left, y+top, cell)
+				}
+			}
+		}
+		clipWide(res)
+	}
+
+	res.MergeUnder(c, 0, 0, false)
+	return res
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return gowid.UserInputIfSelectable(w.IWidget, ev, size, focus, app)
+}
+
+//======================================================================
+
+// makeCells turns lines of text into rows of styled cells of equal width. A double-width
+// rune is followed by an empty cell, as in a rendered canvas.
+func makeCells(lines []string, styler gowid.ICellStyler, app gowid.IApp) [][]gowid.Cell {
+	if len(lines) == 0 {
+		return nil
+	}
+	blank := styledCell(' ', styler, app)
+	width := 0
+	for _, line := range lines {
+		if n := runewidth.StringWidth(line); n > width {
+			width = n
+		}
+	}
+	res := make([][]gowid.Cell, 0, len(lines))
+	for _, line := range lines {
+		row := make([]gowid.Cell, 0, width)
+		for _, r := range line {
+			row = append(row, styledCell(r, styler, app))
+			if runewidth.RuneWidth(r) == 2 {
+				row = append(row, gowid.Cell{})
+			}
+		}
+		for len(row) < width {
+			row = append(row, blank)
+		}
+		res = append(res, row)
+	}
+	return res
+}
+
+// clipWide replaces a double-width rune that would be cut off at the right-hand edge
+// of the canvas with a space.
+func clipWide(c gowid.ICanvas) {
+	x := c.BoxColumns() - 1
+	if x < 0 {
+		return
+	}
+	for y := 0; y < c.BoxRows(); y++ {
+		if cell := c.CellAt(x, y); cell.Width() == 2 {
+			c.SetCellAt(x, y, cell.WithRune(' '))
+		}
+	}
+}
+
+func styledCell(r rune, styler gowid.ICellStyler, app gowid.IApp) gowid.Cell {
+	res := gowid.CellFromRune(r)
+	if styler != nil {
+		f, b, s := styler.GetStyle(app)
+		res = res.MergeDisplayAttrsUnder(gowid.MakeCell(0,
+			gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+			gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+			s))
+	}
+	return res
+}
+
+func vOffset(align gowid.IVAlignment, height int, n int) int {
+	switch al := align.(type) {
+	case gowid.VAlignBottom:
+		return height - n - al.Margin
+	case gowid.VAlignTop:
+		return al.Margin
+	default:
+		return (height - n) / 2
+	}
+}
+
+func hOffset(align gowid.IHAlignment, width int, n int) int {
+	switch al := align.(type) {
+	case gowid.HAlignRight:
+		return width - n
+	case gowid.HAlignLeft:
+		return al.Margin
+	default:
+		return (width - n) / 2
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,50 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package background
+
+import (
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/null"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackground1(t *testing.T) {
+	w := New(text.New("hi"), Options{
+		Pattern: []string{". ", " ."},
+	})
+	c := w.Render(gowid.RenderBox{C: 6, R: 3}, gowid.Focused, gwtest.D)
+	// The text widget's canvas is padded with spaces to the text's width, which hide the pattern
+	assert.Equal(t, "hi. . \n   . .\n  . . ", c.String())
+
+	w = New(null.New(), Options{
+		Pattern:   []string{"-"},
+		Watermark: []string{"A B", "CDE"},
+	})
+	c = w.Render(gowid.RenderBox{C: 7, R: 4}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "-------\n--A-B--\n--CDE--\n-------", c.String())
+
+	w = New(null.New(), Options{
+		Watermark: []string{"日本"},
+		VAlign:    gowid.VAlignTop{},
+		HAlign:    gowid.HAlignRight{},
+	})
+	c = w.Render(gowid.RenderBox{C: 5, R: 2}, gowid.Focused, gwtest.D)
+	assert.Equal(t, " 日本\n     ", c.String())
+
+	w.SetWatermark(nil, gwtest.D)
+	w.SetPattern([]string{"日"}, gwtest.D)
+	c = w.Render(gowid.RenderBox{C: 5, R: 1}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "日日 ", c.String())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -85,7 +85,7 @@ func Render(w IBarGraph, size gowid.IRenderSize, focus gowid.Selector, app gowid
 	}
 
 	weight1 := gowid.RenderWithWeight{1}
-	bgTCellColor := gowid.IColorToTCell(w.GetAttrs()[0], gowid.ColorDefault, app.GetColorMode())
+	bgTCellColor := gowid.ColorToTCellIn(w.GetAttrs()[0], gowid.ColorDefault, app)
 
 	// TODO - check case when data is empty
 	dataIdxLimit := 0
@@ -98,7 +98,7 @@ func Render(w IBarGraph, size gowid.IRenderSize, focus gowid.Selector, app gowid
 		cols := make([]gowid.IContainerWidget, len(w.GetData()))
 		for i, d := range w.GetData() {
 			datum := d[dataIdx]
-			dataTCellColor := gowid.IColorToTCell(w.GetAttrs()[(i%(len(w.GetAttrs())-1))+1], gowid.ColorDefault, app.GetColorMode())
+			dataTCellColor := gowid.ColorToTCellIn(w.GetAttrs()[(i%(len(w.GetAttrs())-1))+1], gowid.ColorDefault, app)
 
 			bar := pile.New([]gowid.IContainerWidget{
 				&gowid.ContainerWidget{
@@ -0,0 +1,437 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package border provides a widget that draws a configurable border around an inner
+// widget. Compared to the framed widget, each edge can be turned on or off, a title
+// can be embedded in the top edge with a chosen alignment, and the border can be
+// styled differently when it has focus.
+package border
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+// Runes holds the runes used to draw each part of the border. Each rune is
+// expected to be one cell wide.
+type Runes struct {
+	Tl, Tr, Bl, Br rune
+	T, B, L, R     rune
+}
+
+var (
+	AsciiRunes   = Runes{'+', '+', '+', '+', '-', '-', '|', '|'}
+	LightRunes   = Runes{'┌', '┐', '└', '┘', '─', '─', '│', '│'}
+	RoundedRunes = Runes{'╭', '╮', '╰', '╯', '─', '─', '│', '│'}
+	DoubleRunes  = Runes{'╔', '╗', '╚', '╝', '═', '═', '║', '║'}
+	HeavyRunes   = Runes{'┏', '┓', '┗', '┛', '━', '━', '┃', '┃'}
+	nullRunes    = Runes{}
+)
+
+// Edges is a bitmask identifying edges of the border.
+type Edges uint8
+
+const (
+	EdgeTop Edges = 1 << iota
+	EdgeBottom
+	EdgeLeft
+	EdgeRight
+	EdgeNone Edges = 0
+	EdgeAll        = EdgeTop | EdgeBottom | EdgeLeft | EdgeRight
+)
+
+// Has returns true if all the edges in e2 are present in e.
+func (e Edges) Has(e2 Edges) bool {
+	return e&e2 == e2
+}
+
+func (e Edges) String() string {
+	res := ""
+	for _, v := range []struct {
+		e Edges
+		s string
+	}{{EdgeTop, "T"}, {EdgeBottom, "B"}, {EdgeLeft, "L"}, {EdgeRight, "R"}} {
+		if e.Has(v.e) {
+			res += v.s
+		}
+	}
+	return res
+}
+
+// Options configures the border. The zero value draws all four edges with
+// LightRunes and no title. HiddenEdges is expressed negatively so that the
+// zero value is useful. If FocusStyle is set, it is used in place of Style
+// when the widget is rendered with focus.
+type Options struct {
+	Runes       Runes
+	HiddenEdges Edges
+	Title       string
+	TitleAlign  gowid.IHAlignment
+	TitleStyle  gowid.ICellStyler
+	Style       gowid.ICellStyler
+	FocusStyle  gowid.ICellStyler
+}
+
+// For callback identification
+type Title struct{}
+type EdgesCB struct{}
+
+type IBorder interface {
+	Opts() Options
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IBorder
+}
+
+type Widget struct {
+	gowid.IWidget // Embed for Selectable method
+	Params        Options
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Runes == nullRunes {
+		opt.Runes = LightRunes
+	}
+
+	res := &Widget{
+		IWidget: inner,
+		Params:  opt,
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func NewRounded(inner gowid.IWidget) *Widget {
+	return New(inner, Options{Runes: RoundedRunes})
+}
+
+func NewDouble(inner gowid.IWidget) *Widget {
+	return New(inner, Options{Runes: DoubleRunes})
+}
+
+func NewHeavy(inner gowid.IWidget) *Widget {
+	return New(inner, Options{Runes: HeavyRunes})
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("border[%v]", w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.IWidget
+}
+
+func (w *Widget) SetSubWidget(wi gowid.IWidget, app gowid.IApp) {
+	w.IWidget = wi
+	gowid.RunWidgetCallbacks(w, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) Opts() Options {
+	return w.Params
+}
+
+func (w *Widget) OnSetTitle(f gowid.IWidgetChangedCallback) {
+	if w.Callbacks == nil {
+		w.Callbacks = gowid.NewCallbacks()
+	}
+	gowid.AddWidgetCallback(w, Title{}, f)
+}
+
+func (w *Widget) RemoveOnSetTitle(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w, Title{}, f)
+}
+
+func (w *Widget) OnSetEdges(f gowid.IWidgetChangedCallback) {
+	if w.Callbacks == nil {
+		w.Callbacks = gowid.NewCallbacks()
+	}
+	gowid.AddWidgetCallback(w, EdgesCB{}, f)
+}
+
+func (w *Widget) RemoveOnSetEdges(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w, EdgesCB{}, f)
+}
+
+func (w *Widget) Title() string {
+	return w.Params.Title
+}
+
+func (w *Widget) SetTitle(title string, app gowid.IApp) {
+	w.Params.Title = title
+	gowid.RunWidgetCallbacks(w, Title{}, app, w)
+}
+
+func (w *Widget) SetTitleAlign(align gowid.IHAlignment, app gowid.IApp) {
+	w.Params.TitleAlign = align
+}
+
+// Edges returns the set of edges currently drawn.
+func (w *Widget) Edges() Edges {
+	return EdgeAll &^ w.Params.HiddenEdges
+}
+
+// SetEdges determines which edges of the border are drawn.
+func (w *Widget) SetEdges(e Edges, app gowid.IApp) {
+	w.Params.HiddenEdges = EdgeAll &^ e
+	gowid.RunWidgetCallbacks(w, EdgesCB{}, app, w)
+}
+
+func (w *Widget) SetRunes(r Runes, app gowid.IApp) {
+	w.Params.Runes = r
+}
+
+func (w *Widget) SetStyle(s gowid.ICellStyler, app gowid.IApp) {
+	w.Params.Style = s
+}
+
+func (w *Widget) SetFocusStyle(s gowid.ICellStyler, app gowid.IApp) {
+	w.Params.FocusStyle = s
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return SubWidgetSize(w, size, focus, app)
+}
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+//======================================================================
+
+func edges(w IBorder) Edges {
+	return EdgeAll &^ w.Opts().HiddenEdges
+}
+
+// extents returns the number of columns and rows taken by the border.
+func extents(w IBorder) (int, int) {
+	e := edges(w)
+	cols, rows := 0, 0
+	if e.Has(EdgeLeft) {
+		cols++
+	}
+	if e.Has(EdgeRight) {
+		cols++
+	}
+	if e.Has(EdgeTop) {
+		rows++
+	}
+	if e.Has(EdgeBottom) {
+		rows++
+	}
+	return cols, rows
+}
+
+func SubWidgetSize(w IBorder, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	ecols, erows := extents(w)
+	var newSize gowid.IRenderSize
+	switch sz := size.(type) {
+	case gowid.IRenderFixed:
+		newSize = gowid.RenderFixed{}
+	case gowid.IRenderBox:
+		newSize = gowid.RenderBox{C: gwutil.Max(sz.BoxColumns()-ecols, 0), R: gwutil.Max(sz.BoxRows()-erows, 0)}
+	case gowid.IRenderFlowWith:
+		newSize = gowid.RenderFlowWith{C: gwutil.Max(sz.FlowColumns()-ecols, 0)}
+	default:
+		panic(gowid.WidgetSizeError{Widget: w, Size: size})
+	}
+	return newSize
+}
+
+func RenderSize(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	ss := w.SubWidgetSize(size, focus, app)
+	sdim := w.SubWidget().RenderSize(ss, focus, app)
+	ecols, erows := extents(w)
+	return gowid.RenderBox{C: sdim.BoxColumns() + ecols, R: sdim.BoxRows() + erows}
+}
+
+// styledCell returns a cell for the border rune r, using the focus style if
+// the widget has focus and one is configured.
+func styledCell(w IBorder, r rune, focus gowid.Selector, app gowid.IApp) gowid.Cell {
+	res := gowid.CellFromRune(r)
+	styler := w.Opts().Style
+	if focus.Focus && w.Opts().FocusStyle != nil {
+		styler = w.Opts().FocusStyle
+	}
+	return applyStyle(res, styler, app)
+}
+
+func applyStyle(c gowid.Cell, styler gowid.ICellStyler, app gowid.IApp) gowid.Cell {
+	if styler == nil {
+		return c
+	}
+	f, b, s := styler.GetStyle(app)
+	c = c.WithForegroundColor(gowid.ColorToTCellIn(f, gowid.ColorNone, app))
+	c = c.WithBackgroundColor(gowid.ColorToTCellIn(b, gowid.ColorNone, app))
+	return c.WithStyle(s)
+}
+
+// titleOffset computes the column at which the title should start, given a top
+// line of the given width and a title of width tlen.
+func titleOffset(align gowid.IHAlignment, width int, tlen int, corners int) int {
+	switch al := align.(type) {
+	case gowid.HAlignRight:
+		return width - corners - tlen
+	case gowid.HAlignMiddle:
+		return (width - tlen) / 2
+	case gowid.HAlignLeft:
+		return corners + al.Margin
+	default:
+		return corners
+	}
+}
+
+func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	e := edges(w)
+	runes := w.Opts().Runes
+	if runes == nullRunes {
+		runes = LightRunes
+	}
+
+	newSize := w.SubWidgetSize(size, focus, app)
+	res := gowid.RenderChild(w.SubWidget(), newSize, focus, app)
+	gowid.MakeCanvasRightSize(res, newSize)
+	rows := res.BoxRows()
+	ecols, _ := extents(w)
+	// Computed up front - if the inner canvas has no rows, appending the sides
+	// won't widen it.
+	cols := res.BoxColumns() + ecols
+
+	if e.Has(EdgeLeft) {
+		left := styledCell(w, runes.L, focus, app)
+		lc := gowid.CanvasPoolOf(app).NewCanvasOfSizeExt(1, rows, left)
+		lc.AppendRight(res, true)
+		res = lc
+	}
+	if e.Has(EdgeRight) {
+		right := gowid.CanvasPoolOf(app).NewCanvasOfSizeExt(1, rows, styledCell(w, runes.R, focus, app))
+		res.AppendRight(right, false)
+	}
+
+	mkHorizontal := func(edge, leftCorner, rightCorner rune) []gowid.Cell {
+		line := make([]gowid.Cell, cols)
+		hor := styledCell(w, edge, focus, app)
+		for i := 0; i < cols; i++ {
+			line[i] = hor
+		}
+		if cols > 0 {
+			if e.Has(EdgeLeft) {
+				line[0] = hor.WithRune(leftCorner)
+			}
+			if e.Has(EdgeRight) {
+				line[cols-1] = hor.WithRune(rightCorner)
+			}
+		}
+		return line
+	}
+
+	if e.Has(EdgeTop) {
+		top := mkHorizontal(runes.T, runes.Tl, runes.Tr)
+		if w.Opts().Title != "" {
+			corners := 0
+			if e.Has(EdgeLeft) {
+				corners = 1
+			}
+			renderTitle(w, top, corners, focus, app)
+		}
+		tc := gowid.NewCanvas()
+		tc.AppendLine(top, false)
+		tc.AppendBelow(res, true, false)
+		res = tc
+	}
+	if e.Has(EdgeBottom) {
+		res.AppendBelow(gowid.LineCanvas(mkHorizontal(runes.B, runes.Bl, runes.Br)), false, false)
+	}
+
+	return res
+}
+
+// renderTitle writes the title, padded with a space either side, into the top line,
+// truncating it if necessary so that it does not overwrite the corners.
+func renderTitle(w IWidget, top []gowid.Cell, corners int, focus gowid.Selector, app gowid.IApp) {
+	avail := len(top) - (2 * corners)
+	if avail <= 0 {
+		return
+	}
+	title := []rune(" " + w.Opts().Title + " ")
+	tlen := 0
+	n := 0
+	for ; n < len(title); n++ {
+		rw := runewidth.RuneWidth(title[n])
+		if tlen+rw > avail {
+			break
+		}
+		tlen += rw
+	}
+	title = title[:n]
+
+	pos := titleOffset(w.Opts().TitleAlign, len(top), tlen, corners)
+	pos = gwutil.LimitTo(corners, pos, len(top)-corners-tlen)
+	for _, r := range title {
+		c := top[pos].WithRune(r)
+		if w.Opts().TitleStyle != nil {
+			c = applyStyle(c, w.Opts().TitleStyle, app)
+		}
+		top[pos] = c
+		pos++
+		for i := 1; i < runewidth.RuneWidth(r); i++ {
+			top[pos] = c.WithNoRune()
+			pos++
+		}
+	}
+}
+
+func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	e := edges(w)
+	dx, dy := 0, 0
+	if e.Has(EdgeLeft) {
+		dx = -1
+	}
+	if e.Has(EdgeTop) {
+		dy = -1
+	}
+	subSize := w.SubWidgetSize(size, focus, app)
+	newev := gowid.TranslatedMouseEvent(ev, dx, dy)
+
+	if evm, ok := newev.(*tcell.EventMouse); ok {
+		ss := w.SubWidget().RenderSize(subSize, focus, app)
+		mx, my := evm.Position()
+		if my < ss.BoxRows() && my >= 0 && mx < ss.BoxColumns() && mx >= 0 {
+			return gowid.UserInputIfSelectable(w.SubWidget(), newev, subSize, focus, app)
+		}
+	} else {
+		return gowid.UserInputIfSelectable(w.SubWidget(), newev, subSize, focus, app)
+	}
+	return false
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
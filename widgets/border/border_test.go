@@ -0,0 +1,69 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package border
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestBorder1(t *testing.T) {
+	w := NewRounded(text.New("hello"))
+	c := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, strings.Join([]string{"╭─────╮", "│hello│", "╰─────╯"}, "\n"), c.String())
+
+	w.SetEdges(EdgeTop|EdgeBottom, gwtest.D)
+	c = w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, strings.Join([]string{"─────", "hello", "─────"}, "\n"), c.String())
+
+	w.SetEdges(EdgeLeft|EdgeTop, gwtest.D)
+	c = w.Render(gowid.RenderFlowWith{C: 4}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, strings.Join([]string{"╭───", "│hel", "│lo "}, "\n"), c.String())
+}
+
+func TestBorderTitle1(t *testing.T) {
+	w := New(text.New("hello world"), Options{Runes: AsciiRunes, Title: "ab"})
+	c := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, strings.Join([]string{"+ ab -------+", "|hello world|", "+-----------+"}, "\n"), c.String())
+
+	w.SetTitleAlign(gowid.HAlignRight{}, gwtest.D)
+	c = w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "+------- ab +", strings.Split(c.String(), "\n")[0])
+
+	w.SetTitleAlign(gowid.HAlignMiddle{}, gwtest.D)
+	c = w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "+--- ab ----+", strings.Split(c.String(), "\n")[0])
+
+	// Title is truncated to fit between the corners
+	w.SetTitle("a long title", gwtest.D)
+	c = w.Render(gowid.RenderBox{C: 6, R: 3}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "+ a l+", strings.Split(c.String(), "\n")[0])
+}
+
+func TestBorderFocus1(t *testing.T) {
+	w := New(text.New("x"), Options{
+		Style:      gowid.MakeForeground(gowid.ColorGreen),
+		FocusStyle: gowid.MakeForeground(gowid.ColorRed),
+	})
+	c := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, gowid.ColorGreen, c.CellAt(0, 0).ForegroundColor())
+	c = w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	assert.Equal(t, gowid.ColorRed, c.CellAt(0, 0).ForegroundColor())
+
+	gwtest.RenderBoxManyTimes(t, w, 2, 10, 2, 10)
+	gwtest.RenderFlowManyTimes(t, w, 2, 10)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,413 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package braille provides a sub-cell drawing surface and a widget to display it. Each
+// terminal cell is divided into a 2x4 grid of braille dots or a 2x2 grid of quadrant
+// blocks, allowing higher resolution plots and simple diagrams.
+package braille
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+)
+
+//======================================================================
+
+// Mode determines how each terminal cell is subdivided into pixels.
+type Mode int
+
+const (
+	// BrailleMode uses the unicode braille block - 2 pixels wide and 4 high per cell.
+	BrailleMode Mode = iota
+	// BlockMode uses unicode quadrant blocks - 2 pixels wide and 2 high per cell.
+	BlockMode
+)
+
+func (m Mode) String() string {
+	switch m {
+	case BrailleMode:
+		return "braille"
+	case BlockMode:
+		return "block"
+	default:
+		return fmt.Sprintf("mode[%d]", int(m))
+	}
+}
+
+// PixelsPerCell returns the number of horizontal and vertical pixels that make
+// up a single terminal cell in this mode.
+func (m Mode) PixelsPerCell() (int, int) {
+	switch m {
+	case BlockMode:
+		return 2, 2
+	default:
+		return 2, 4
+	}
+}
+
+// Braille dots are numbered down the left column then down the right, except
+// that the bottom row (dots 7 and 8) was added later, hence the irregular bits.
+var brailleBits = [4][2]uint8{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// Indexed by bitmask - top-left 1, top-right 2, bottom-left 4, bottom-right 8.
+var quadrantRunes = [16]rune{
+	' ', '▘', '▝', '▀', '▖', '▌', '▞', '▛', '▗', '▚', '▐', '▜', '▄', '▙', '▟', '█',
+}
+
+var quadrantBits = [2][2]uint8{
+	{0x01, 0x02},
+	{0x04, 0x08},
+}
+
+//======================================================================
+
+// Surface is a 2-dimensional array of pixels, backed by terminal cells. Pixel
+// coordinates start at (0,0) in the top-left. Drawing outside the bounds of the
+// surface is permitted and has no effect, so that callers can plot lines that
+// run off the edge without clipping them first. Each cell can track its own
+// foreground color - the color of the pen at the time the last pixel in that
+// cell was drawn.
+type Surface struct {
+	mode   Mode
+	cols   int
+	rows   int
+	bits   []uint8
+	colors []gowid.IColor
+	pen    gowid.IColor
+}
+
+// NewSurface returns a Surface that covers cols x rows terminal cells.
+func NewSurface(cols, rows int, mode Mode) *Surface {
+	cols = gwutil.Max(cols, 0)
+	rows = gwutil.Max(rows, 0)
+	return &Surface{
+		mode:   mode,
+		cols:   cols,
+		rows:   rows,
+		bits:   make([]uint8, cols*rows),
+		colors: make([]gowid.IColor, cols*rows),
+	}
+}
+
+func (s *Surface) String() string {
+	return fmt.Sprintf("surface[%dx%d %v]", s.cols, s.rows, s.mode)
+}
+
+// Mode returns the pixel layout of the surface.
+func (s *Surface) Mode() Mode {
+	return s.mode
+}
+
+// BoxColumns returns the width of the surface in terminal cells.
+func (s *Surface) BoxColumns() int {
+	return s.cols
+}
+
+// BoxRows returns the height of the surface in terminal cells.
+func (s *Surface) BoxRows() int {
+	return s.rows
+}
+
+// Width returns the width of the surface in pixels.
+func (s *Surface) Width() int {
+	px, _ := s.mode.PixelsPerCell()
+	return s.cols * px
+}
+
+// Height returns the height of the surface in pixels.
+func (s *Surface) Height() int {
+	_, py := s.mode.PixelsPerCell()
+	return s.rows * py
+}
+
+// SetPen sets the color applied to cells touched by subsequent drawing
+// operations. A nil color means the widget's default foreground is used.
+func (s *Surface) SetPen(c gowid.IColor) {
+	s.pen = c
+}
+
+// Pen returns the current pen color.
+func (s *Surface) Pen() gowid.IColor {
+	return s.pen
+}
+
+// Clear turns off every pixel and resets all cell colors.
+func (s *Surface) Clear() {
+	for i := 0; i < len(s.bits); i++ {
+		s.bits[i] = 0
+		s.colors[i] = nil
+	}
+}
+
+// locate returns the index of the cell containing pixel (x,y) and the bit
+// within that cell, or false if the pixel is out of bounds.
+func (s *Surface) locate(x, y int) (int, uint8, bool) {
+	if x < 0 || y < 0 || x >= s.Width() || y >= s.Height() {
+		return 0, 0, false
+	}
+	px, py := s.mode.PixelsPerCell()
+	idx := (y/py)*s.cols + (x / px)
+	var bit uint8
+	switch s.mode {
+	case BlockMode:
+		bit = quadrantBits[y%py][x%px]
+	default:
+		bit = brailleBits[y%py][x%px]
+	}
+	return idx, bit, true
+}
+
+// SetPixel turns on the pixel at (x,y).
+func (s *Surface) SetPixel(x, y int) {
+	if idx, bit, ok := s.locate(x, y); ok {
+		s.bits[idx] |= bit
+		if s.pen != nil {
+			s.colors[idx] = s.pen
+		}
+	}
+}
+
+// ClearPixel turns off the pixel at (x,y).
+func (s *Surface) ClearPixel(x, y int) {
+	if idx, bit, ok := s.locate(x, y); ok {
+		s.bits[idx] &^= bit
+	}
+}
+
+// Pixel returns true if the pixel at (x,y) is on.
+func (s *Surface) Pixel(x, y int) bool {
+	if idx, bit, ok := s.locate(x, y); ok {
+		return s.bits[idx]&bit != 0
+	}
+	return false
+}
+
+// Line draws a straight line from (x0,y0) to (x1,y1) inclusive, using
+// Bresenham's algorithm.
+func (s *Surface) Line(x0, y0, x1, y1 int) {
+	dx := gwutil.Abs(x1 - x0)
+	dy := -gwutil.Abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		s.SetPixel(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			break
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// Rect draws a rectangle with corners (x0,y0) and (x1,y1) inclusive. If fill
+// is true, the interior is drawn too.
+func (s *Surface) Rect(x0, y0, x1, y1 int, fill bool) {
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	if fill {
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				s.SetPixel(x, y)
+			}
+		}
+	} else {
+		s.Line(x0, y0, x1, y0)
+		s.Line(x0, y1, x1, y1)
+		s.Line(x0, y0, x0, y1)
+		s.Line(x1, y0, x1, y1)
+	}
+}
+
+// CellRune returns the rune that represents the pixels in the cell at (col,row).
+func (s *Surface) CellRune(col, row int) rune {
+	bits := s.bits[row*s.cols+col]
+	switch s.mode {
+	case BlockMode:
+		return quadrantRunes[bits&0x0f]
+	default:
+		return rune(0x2800 + int(bits))
+	}
+}
+
+// ToCanvas converts the surface to a gowid canvas of the same size in cells. Cells
+// with no pixels set are left empty so that they may be styled by widgets layered
+// underneath. Other cells are given the cell's pen color if set, or fg otherwise.
+func (s *Surface) ToCanvas(fg gowid.TCellColor, mode gowid.ColorMode) *gowid.Canvas {
+	res := gowid.NewCanvasOfSize(s.cols, s.rows)
+	for row := 0; row < s.rows; row++ {
+		for col := 0; col < s.cols; col++ {
+			idx := row*s.cols + col
+			if s.bits[idx] == 0 {
+				continue
+			}
+			cfg := fg
+			if s.colors[idx] != nil {
+				cfg = gowid.IColorToTCell(s.colors[idx], fg, mode)
+			}
+			res.SetCellAt(col, row, gowid.MakeCell(s.CellRune(col, row), cfg, gowid.ColorNone, gowid.StyleNone))
+		}
+	}
+	return res
+}
+
+//======================================================================
+
+// IDrawer is implemented by anything that can paint onto a Surface. The widget
+// invokes it each time it is rendered with a blank surface sized to fit.
+type IDrawer interface {
+	Draw(s *Surface, app gowid.IApp)
+}
+
+// DrawFunc allows a simple function to be used as an IDrawer.
+type DrawFunc func(s *Surface, app gowid.IApp)
+
+func (f DrawFunc) Draw(s *Surface, app gowid.IApp) {
+	f(s, app)
+}
+
+type IBraille interface {
+	Mode() Mode
+	Drawer() IDrawer
+	Style() gowid.ICellStyler
+}
+
+type IWidget interface {
+	gowid.IWidget
+	IBraille
+}
+
+// Widget renders an IDrawer onto a Surface sized to the render box, then
+// displays the result.
+type Widget struct {
+	drawer IDrawer
+	opt    Options
+	gowid.RejectUserInput
+	gowid.NotSelectable
+}
+
+// Options can be provided to New to control how the widget renders. If Style
+// is nil, pixels are drawn with the terminal's default foreground color.
+type Options struct {
+	Mode  Mode
+	Style gowid.ICellStyler
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(drawer IDrawer, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return &Widget{
+		drawer: drawer,
+		opt:    opt,
+	}
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("braille[%v]", w.opt.Mode)
+}
+
+func (w *Widget) Mode() Mode {
+	return w.opt.Mode
+}
+
+func (w *Widget) SetMode(m Mode, app gowid.IApp) {
+	w.opt.Mode = m
+}
+
+func (w *Widget) Drawer() IDrawer {
+	return w.drawer
+}
+
+func (w *Widget) SetDrawer(d IDrawer, app gowid.IApp) {
+	w.drawer = d
+}
+
+func (w *Widget) Style() gowid.ICellStyler {
+	return w.opt.Style
+}
+
+func (w *Widget) SetStyle(s gowid.ICellStyler, app gowid.IApp) {
+	w.opt.Style = s
+}
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func RenderSize(w gowid.IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	box, ok := size.(gowid.IRenderBox)
+	if !ok {
+		panic(gowid.WidgetSizeError{Widget: w, Size: size, Required: "gowid.IRenderBox"})
+	}
+	return gowid.RenderBox{C: box.BoxColumns(), R: box.BoxRows()}
+}
+
+func Render(w IBraille, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	box, ok := size.(gowid.IRenderBox)
+	if !ok {
+		panic(gowid.WidgetSizeError{Widget: w, Size: size, Required: "gowid.IRenderBox"})
+	}
+
+	surface := NewSurface(box.BoxColumns(), box.BoxRows(), w.Mode())
+	if w.Drawer() != nil {
+		w.Drawer().Draw(surface, app)
+	}
+
+	fg := gowid.ColorNone
+	bg := gowid.ColorNone
+	if w.Style() != nil {
+		f, b, _ := w.Style().GetStyle(app)
+		fg = gowid.ColorToTCellIn(f, gowid.ColorNone, app)
+		bg = gowid.ColorToTCellIn(b, gowid.ColorNone, app)
+	}
+
+	res := surface.ToCanvas(fg, app.GetColorMode())
+	if bg != gowid.ColorNone {
+		gowid.RangeOverCanvas(res, gowid.CellRangeFunc(func(c gowid.Cell) gowid.Cell {
+			return c.WithBackgroundColor(bg)
+		}))
+	}
+
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
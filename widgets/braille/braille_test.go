@@ -0,0 +1,79 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package braille
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestSurface1(t *testing.T) {
+	s := NewSurface(2, 1, BrailleMode)
+	assert.Equal(t, 4, s.Width())
+	assert.Equal(t, 4, s.Height())
+
+	s.SetPixel(0, 0)
+	assert.Equal(t, '⠁', s.CellRune(0, 0))
+	s.SetPixel(1, 3)
+	assert.Equal(t, '⢁', s.CellRune(0, 0))
+	assert.True(t, s.Pixel(1, 3))
+	s.ClearPixel(1, 3)
+	assert.False(t, s.Pixel(1, 3))
+
+	// Out of bounds is ignored
+	s.SetPixel(-1, 0)
+	s.SetPixel(4, 0)
+	assert.Equal(t, '⠀', s.CellRune(1, 0))
+
+	s.Clear()
+	s.Line(0, 0, 3, 3)
+	assert.True(t, s.Pixel(2, 2))
+	assert.Equal(t, "⠑⢄", s.ToCanvas(gowid.ColorNone, gowid.Mode256Colors).String())
+}
+
+func TestSurface2(t *testing.T) {
+	s := NewSurface(2, 2, BlockMode)
+	s.Rect(0, 0, 3, 3, false)
+	assert.Equal(t, "▛▜\n▙▟", s.ToCanvas(gowid.ColorNone, gowid.Mode256Colors).String())
+
+	s.Clear()
+	s.Rect(0, 0, 3, 0, true)
+	assert.Equal(t, "▀▀\n  ", s.ToCanvas(gowid.ColorNone, gowid.Mode256Colors).String())
+}
+
+func TestSurfacePen1(t *testing.T) {
+	s := NewSurface(2, 1, BlockMode)
+	s.SetPen(gowid.ColorRed)
+	s.SetPixel(0, 0)
+	c := s.ToCanvas(gowid.ColorGreen, gowid.Mode256Colors)
+	assert.Equal(t, gowid.ColorRed, c.CellAt(0, 0).ForegroundColor())
+	assert.False(t, c.CellAt(1, 0).HasRune())
+}
+
+func TestWidget1(t *testing.T) {
+	w := New(DrawFunc(func(s *Surface, app gowid.IApp) {
+		s.Line(0, s.Height()-1, s.Width()-1, s.Height()-1)
+	}), Options{Mode: BlockMode})
+
+	c := w.Render(gowid.RenderBox{C: 3, R: 2}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, strings.Join([]string{"   ", "▄▄▄"}, "\n"), c.String())
+
+	assert.Panics(t, func() {
+		w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	})
+
+	gwtest.RenderBoxManyTimes(t, w, 0, 10, 0, 10)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -11,6 +11,7 @@ import (
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/text"
 	tcell "github.com/gdamore/tcell/v2"
 )
 
@@ -88,6 +89,7 @@ type Options struct {
 type Widget struct {
 	inner gowid.IWidget
 	opts  Options
+	accel rune // Clicks the button when pressed with alt; 0 for none
 	*gowid.Callbacks
 	gowid.SubWidgetCallbacks
 	gowid.ClickCallbacks
@@ -148,10 +150,29 @@ func NewDecorated(inner gowid.IWidget, decoration Decoration) *Widget {
 	})
 }
 
+// NewWithAccelerator returns a button labelled with text that marks a keyboard
+// accelerator with an ampersand, e.g. "&Save". The marked character is underlined,
+// and pressing it with alt clicks the button while it is on the screen.
+func NewWithAccelerator(label string, opts ...Options) *Widget {
+	content, key := text.NewContentWithAccelerator(label)
+	res := New(text.NewFromContent(content), opts...)
+	res.accel = key
+	return res
+}
+
 func (w *Widget) String() string {
 	return fmt.Sprintf("button[%v]", w.SubWidget())
 }
 
+// Accelerator returns the key that clicks the button when pressed with alt, or 0.
+func (w *Widget) Accelerator() rune {
+	return w.accel
+}
+
+func (w *Widget) SetAccelerator(key rune, app gowid.IApp) {
+	w.accel = key
+}
+
 func (w *Widget) Click(app gowid.IApp) {
 	// No button clicked means a key was pressed
 	if app.GetMouseState().NoButtonClicked() || app.GetMouseState().LeftIsClicked() {
@@ -191,7 +212,9 @@ func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app go
 }
 
 func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
-	return Render(w, size, focus, app)
+	res := Render(w, size, focus, app)
+	gowid.TrackAccelerator(w.accel, w, res, app)
+	return res
 }
 
 func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
@@ -301,7 +324,7 @@ func UserInput(w IClickableIdentityWidget, ev interface{}, size gowid.IRenderSiz
 func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
 	newSize := w.SubWidgetSize(size, focus, app)
 
-	res := w.SubWidget().Render(newSize, focus, app)
+	res := gowid.RenderChild(w.SubWidget(), newSize, focus, app)
 	leftClicker := gowid.CellsFromString(w.LeftDec())
 	rightClicker := gowid.CellsFromString(w.RightDec())
 	res.ExtendLeft(leftClicker)
@@ -82,6 +82,20 @@ func TestButton2(t *testing.T) {
 	assert.Equal(t, strings.Join([]string{"1.2"}, "\n"), c1.String())
 }
 
+func TestAccelerator1(t *testing.T) {
+	w := NewWithAccelerator("Save &As")
+	assert.Equal(t, 'a', w.Accelerator())
+	c := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "<Save As>", c.String())
+	_, _, st := c.CellAt(6, 0).GetDisplayAttrs()
+	assert.NotEqual(t, tcell.AttrMask(0), st.OnOff&tcell.AttrUnderline)
+	_, _, st = c.CellAt(5, 0).GetDisplayAttrs()
+	assert.Equal(t, tcell.AttrMask(0), st.OnOff&tcell.AttrUnderline)
+
+	w = NewWithAccelerator("Plain")
+	assert.Equal(t, rune(0), w.Accelerator())
+}
+
 func TestCanvas13(t *testing.T) {
 	widget1a := text.New("hello world")
 	widget1 := New(widget1a)
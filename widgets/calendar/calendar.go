@@ -0,0 +1,380 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package calendar provides a widget that shows a month as a grid of days, from which
+// the user can pick a date.
+package calendar
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+const (
+	// Width is the number of columns the calendar occupies - seven days of two
+	// columns, separated by spaces.
+	Width = 7*3 - 1
+	// Height is the number of rows the calendar occupies - the month, the names of
+	// the days and up to six weeks.
+	Height = 8
+)
+
+// Options can be supplied to New. Date is the date shown initially; if zero, today is
+// used. If Min or Max is non-zero, dates before or after it can't be chosen. Styles
+// left nil take defaults.
+type Options struct {
+	Date          time.Time
+	Min           time.Time
+	Max           time.Time
+	WeekStart     time.Weekday
+	HeaderStyle   gowid.ICellStyler
+	CursorStyle   gowid.ICellStyler
+	SelectedStyle gowid.ICellStyler
+	TodayStyle    gowid.ICellStyler
+	DisabledStyle gowid.ICellStyler
+}
+
+var (
+	defaultHeaderStyle   = gowid.MakeStyledAs(gowid.StyleBold)
+	defaultCursorStyle   = gowid.MakeStyledAs(gowid.StyleReverse)
+	defaultSelectedStyle = gowid.MakeStyledAs(gowid.StyleBold.MergeUnder(gowid.StyleUnderline))
+	defaultTodayStyle    = gowid.MakeForeground(gowid.ColorYellow)
+	defaultDisabledStyle = gowid.MakeForeground(gowid.ColorDarkGray)
+)
+
+type ICalendar interface {
+	// Date returns the date under the cursor.
+	Date() time.Time
+	// Selected returns the date chosen by the user, and false if there isn't one.
+	Selected() (time.Time, bool)
+	WeekStart() time.Weekday
+	// Enabled returns true if the date can be chosen.
+	Enabled(date time.Time) bool
+}
+
+type IWidget interface {
+	gowid.IWidget
+	ICalendar
+}
+
+type SelectCB struct{}
+
+// Widget is a calendar showing the month containing its cursor. It is selectable - the
+// cursor keys move by a day or a week, page up and page down by a month, and enter or
+// space selects the date under the cursor. Clicking a day selects it, and clicking the
+// arrows either side of the month changes month.
+type Widget struct {
+	opts     Options
+	cursor   time.Time
+	selected time.Time
+	now      func() time.Time
+	*gowid.Callbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	res := &Widget{
+		opts:      opt,
+		now:       time.Now,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	date := opt.Date
+	if date.IsZero() {
+		date = res.now()
+	}
+	res.cursor = res.clamp(Day(date))
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("calendar[%s]", w.cursor.Format("2006-01-02"))
+}
+
+// Day returns midnight at the start of t's day, in t's location.
+func Day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Date() time.Time {
+	return w.cursor
+}
+
+// SetDate moves the cursor to the given date, or the nearest date that can be chosen.
+func (w *Widget) SetDate(date time.Time, app gowid.IApp) {
+	w.cursor = w.clamp(Day(date))
+}
+
+func (w *Widget) Selected() (time.Time, bool) {
+	return w.selected, !w.selected.IsZero()
+}
+
+// SetSelected selects the given date, if it can be chosen, moves the cursor to it, and
+// runs the select callbacks.
+func (w *Widget) SetSelected(date time.Time, app gowid.IApp) {
+	date = Day(date)
+	if !w.Enabled(date) {
+		return
+	}
+	w.cursor = date
+	w.selected = date
+	gowid.RunWidgetCallbacks(w.Callbacks, SelectCB{}, app, w)
+}
+
+// ClearSelected removes the selection, if there is one.
+func (w *Widget) ClearSelected(app gowid.IApp) {
+	w.selected = time.Time{}
+}
+
+func (w *Widget) WeekStart() time.Weekday {
+	return w.opts.WeekStart
+}
+
+func (w *Widget) Enabled(date time.Time) bool {
+	date = Day(date)
+	if !w.opts.Min.IsZero() && date.Before(Day(w.opts.Min)) {
+		return false
+	}
+	if !w.opts.Max.IsZero() && date.After(Day(w.opts.Max)) {
+		return false
+	}
+	return true
+}
+
+func (w *Widget) OnSelect(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, SelectCB{}, f)
+}
+
+func (w *Widget) RemoveOnSelect(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, SelectCB{}, f)
+}
+
+// clamp returns the nearest date to date that can be chosen.
+func (w *Widget) clamp(date time.Time) time.Time {
+	if !w.opts.Min.IsZero() && date.Before(Day(w.opts.Min)) {
+		return Day(w.opts.Min)
+	}
+	if !w.opts.Max.IsZero() && date.After(Day(w.opts.Max)) {
+		return Day(w.opts.Max)
+	}
+	return date
+}
+
+// addMonths moves date by n months, keeping the day of the month where possible - so
+// one month after January 31st is the last day of February.
+func addMonths(date time.Time, n int) time.Time {
+	y, m, d := date.Date()
+	first := time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, date.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, gwutil.Min(d, last)-1)
+}
+
+// firstCell returns the date shown in the top left of the grid for the cursor's month.
+func firstCell(w *Widget) time.Time {
+	first := w.cursor.AddDate(0, 0, 1-w.cursor.Day())
+	back := (int(first.Weekday()) - int(w.opts.WeekStart) + 7) % 7
+	return first.AddDate(0, 0, -back)
+}
+
+func styleOr(s gowid.ICellStyler, def gowid.ICellStyler) gowid.ICellStyler {
+	if s == nil {
+		return def
+	}
+	return s
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+func RenderSize(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	switch sz := size.(type) {
+	case gowid.IRenderBox:
+		return gowid.RenderBox{C: sz.BoxColumns(), R: sz.BoxRows()}
+	case gowid.IRenderFlowWith:
+		return gowid.RenderBox{C: sz.FlowColumns(), R: Height}
+	case gowid.IRenderFixed:
+		return gowid.RenderBox{C: Width, R: Height}
+	default:
+		panic(gowid.WidgetSizeError{Widget: w, Size: size})
+	}
+}
+
+// write sets the cells of line from x onwards to the runes of s, styled by layering
+// each of the stylers in turn.
+func write(line []gowid.Cell, x int, s string, app gowid.IApp, stylers ...gowid.ICellStyler) {
+	var attrs gowid.Cell
+	for _, st := range stylers {
+		f, b, a := st.GetStyle(app)
+		attrs = attrs.MergeDisplayAttrsUnder(gowid.MakeCell(0,
+			gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+			gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+			a))
+	}
+	for _, r := range s {
+		if x >= len(line) {
+			break
+		}
+		line[x] = attrs.WithRune(r)
+		x++
+	}
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	header := styleOr(w.opts.HeaderStyle, defaultHeaderStyle)
+	lines := make([][]gowid.Cell, Height)
+	for i := range lines {
+		lines[i] = make([]gowid.Cell, Width)
+	}
+
+	title := fmt.Sprintf("%s %d", gowid.Translate(w.cursor.Month().String()), w.cursor.Year())
+	write(lines[0], 0, "<", app, header)
+	write(lines[0], (Width-utf8.RuneCountInString(title))/2, title, app, header)
+	write(lines[0], Width-1, ">", app, header)
+	for i := 0; i < 7; i++ {
+		// Translated as e.g. "Mo", so a translation can choose its own abbreviation
+		name := gowid.Translate(time.Weekday((int(w.opts.WeekStart) + i) % 7).String()[:2])
+		write(lines[1], i*3, name, app, header)
+	}
+
+	today := Day(w.now().In(w.cursor.Location()))
+	selected, haveSelected := w.Selected()
+	date := firstCell(w)
+	for row := 0; row < 6; row++ {
+		for col := 0; col < 7; col++ {
+			if date.Month() == w.cursor.Month() {
+				stylers := make([]gowid.ICellStyler, 0, 4)
+				if !w.Enabled(date) {
+					stylers = append(stylers, styleOr(w.opts.DisabledStyle, defaultDisabledStyle))
+				}
+				if date.Equal(today) {
+					stylers = append(stylers, styleOr(w.opts.TodayStyle, defaultTodayStyle))
+				}
+				if haveSelected && date.Equal(selected) {
+					stylers = append(stylers, styleOr(w.opts.SelectedStyle, defaultSelectedStyle))
+				}
+				if focus.Focus && date.Equal(w.cursor) {
+					stylers = append(stylers, styleOr(w.opts.CursorStyle, defaultCursorStyle))
+				}
+				write(lines[row+2], col*3, fmt.Sprintf("%2d", date.Day()), app, stylers...)
+			}
+			date = date.AddDate(0, 0, 1)
+		}
+	}
+
+	res := gowid.NewCanvasWithLines(lines)
+	gowid.MakeCanvasRightSize(res, RenderSize(w, size, focus, app))
+	return res
+}
+
+// DateAt returns the date shown at column x and row y of the rendered calendar, and
+// false if there isn't one.
+func DateAt(w *Widget, x, y int) (time.Time, bool) {
+	if y < 2 || y >= Height || x < 0 || x >= Width || x%3 == 2 {
+		return time.Time{}, false
+	}
+	date := firstCell(w).AddDate(0, 0, (y-2)*7+x/3)
+	if date.Month() != w.cursor.Month() {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	date := w.cursor
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyLeft:
+			date = date.AddDate(0, 0, -1)
+		case tcell.KeyRight:
+			date = date.AddDate(0, 0, 1)
+		case tcell.KeyUp:
+			date = date.AddDate(0, 0, -7)
+		case tcell.KeyDown:
+			date = date.AddDate(0, 0, 7)
+		case tcell.KeyPgUp:
+			date = addMonths(date, -1)
+		case tcell.KeyPgDn:
+			date = addMonths(date, 1)
+		case tcell.KeyHome:
+			date = date.AddDate(0, 0, 1-date.Day())
+		case tcell.KeyEnd:
+			date = addMonths(date.AddDate(0, 0, 1-date.Day()), 1).AddDate(0, 0, -1)
+		case tcell.KeyEnter:
+			w.SetSelected(date, app)
+			return true
+		case tcell.KeyRune:
+			if ev.Rune() != ' ' {
+				return false
+			}
+			w.SetSelected(date, app)
+			return true
+		default:
+			return false
+		}
+	case *tcell.EventMouse:
+		if ev.Buttons() != tcell.Button1 {
+			return false
+		}
+		x, y := ev.Position()
+		switch {
+		case y == 0 && x == 0:
+			date = addMonths(date, -1)
+		case y == 0 && x == Width-1:
+			date = addMonths(date, 1)
+		default:
+			clicked, ok := DateAt(w, x, y)
+			if !ok || !w.Enabled(clicked) {
+				return false
+			}
+			w.SetSelected(clicked, app)
+			return true
+		}
+	default:
+		return false
+	}
+	date = w.clamp(date)
+	if date.Equal(w.cursor) {
+		return false
+	}
+	w.cursor = date
+	return true
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,98 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func key(k tcell.Key) *tcell.EventKey {
+	return tcell.NewEventKey(k, ' ', tcell.ModNone)
+}
+
+func TestCalendar1(t *testing.T) {
+	w := New(Options{Date: date(2022, time.February, 10), WeekStart: time.Monday})
+	w.now = func() time.Time { return date(2022, time.February, 14) }
+
+	c := w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	assert.Equal(t, strings.Join([]string{
+		"<  February 2022   >",
+		"Mo Tu We Th Fr Sa Su",
+		"    1  2  3  4  5  6",
+		" 7  8  9 10 11 12 13",
+		"14 15 16 17 18 19 20",
+		"21 22 23 24 25 26 27",
+		"28                  ",
+		"                    ",
+	}, "\n"), c.String())
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(9, 3).Style())
+	yellow := gowid.IColorToTCell(gowid.ColorYellow, gowid.ColorNone, gwtest.D.GetColorMode())
+	assert.Equal(t, yellow, c.CellAt(0, 4).ForegroundColor())
+
+	sz := gowid.RenderFixed{}
+	assert.True(t, w.UserInput(key(tcell.KeyDown), sz, gowid.Focused, gwtest.D))
+	assert.True(t, w.UserInput(key(tcell.KeyRight), sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, date(2022, time.February, 18), w.Date())
+
+	selected := 0
+	w.OnSelect(gowid.WidgetCallback{Name: "cb", WidgetChangedFunction: func(app gowid.IApp, w gowid.IWidget) {
+		selected++
+	}})
+	assert.True(t, w.UserInput(key(tcell.KeyEnter), sz, gowid.Focused, gwtest.D))
+	sel, ok := w.Selected()
+	assert.True(t, ok)
+	assert.Equal(t, date(2022, time.February, 18), sel)
+	assert.Equal(t, 1, selected)
+
+	// Clicking a day selects it
+	click := tcell.NewEventMouse(3, 2, tcell.Button1, 0)
+	assert.True(t, w.UserInput(click, sz, gowid.Focused, gwtest.D))
+	sel, _ = w.Selected()
+	assert.Equal(t, date(2022, time.February, 1), sel)
+	assert.Equal(t, 2, selected)
+
+	// Month end is clamped
+	w.SetDate(date(2022, time.January, 31), gwtest.D)
+	assert.True(t, w.UserInput(key(tcell.KeyPgDn), sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, date(2022, time.February, 28), w.Date())
+}
+
+func TestCalendarRange1(t *testing.T) {
+	w := New(Options{
+		Date: date(2022, time.March, 5),
+		Min:  date(2022, time.March, 3),
+		Max:  date(2022, time.March, 20),
+	})
+	sz := gowid.RenderFixed{}
+	assert.True(t, w.UserInput(key(tcell.KeyLeft), sz, gowid.Focused, gwtest.D))
+	assert.True(t, w.UserInput(key(tcell.KeyLeft), sz, gowid.Focused, gwtest.D))
+	assert.False(t, w.UserInput(key(tcell.KeyLeft), sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, date(2022, time.March, 3), w.Date())
+	assert.True(t, w.UserInput(key(tcell.KeyPgDn), sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, date(2022, time.March, 20), w.Date())
+
+	w.SetSelected(date(2022, time.March, 1), gwtest.D)
+	_, ok := w.Selected()
+	assert.False(t, ok)
+	assert.False(t, w.Enabled(date(2022, time.March, 21)))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
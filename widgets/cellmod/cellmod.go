@@ -15,6 +15,13 @@ type ICellMod interface {
 	Transform(gowid.Cell, gowid.Selector) gowid.Cell
 }
 
+// ICanvasMod is implemented by modifications that need to see the whole canvas, not
+// one cell at a time - e.g. to find text to redact. If a widget's mod implements it,
+// TransformCanvas is called instead of Transform.
+type ICanvasMod interface {
+	TransformCanvas(c gowid.ICanvas, focus gowid.Selector, app gowid.IApp)
+}
+
 type Func func(gowid.Cell, gowid.Selector) gowid.Cell
 
 func (f Func) Transform(cell gowid.Cell, focus gowid.Selector) gowid.Cell {
@@ -26,9 +33,8 @@ type IWidget interface {
 	ICellMod
 }
 
-// Widget that adjusts the palette used - if the rendering context provides for a foreground
-// color of red (when focused), this widget can provide a map from red -> green to change its
-// display
+// Widget applies a mod to each cell of its child's canvas every time it renders - e.g.
+// to desaturate it, recolor it, or redact parts of it. Combine mods with Chain.
 type Widget struct {
 	gowid.IWidget
 	mod ICellMod
@@ -47,6 +53,7 @@ func New(inner gowid.IWidget, mod ICellMod) *Widget {
 	var _ gowid.IWidget = res
 	var _ gowid.ICompositeWidget = res
 	var _ IWidget = res
+	var _ ICanvasMod = res
 	return res
 }
 
@@ -85,6 +92,16 @@ func (w *Widget) Transform(c gowid.Cell, focus gowid.Selector) gowid.Cell {
 	return w.Mod().Transform(c, focus)
 }
 
+// TransformCanvas applies the widget's mod to the canvas - with the mod's own
+// TransformCanvas, if it has one.
+func (w *Widget) TransformCanvas(c gowid.ICanvas, focus gowid.Selector, app gowid.IApp) {
+	if cm, ok := w.Mod().(ICanvasMod); ok {
+		cm.TransformCanvas(c, focus, app)
+	} else {
+		TransformCanvas(w.Mod(), c, focus)
+	}
+}
+
 func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
 	return w.SubWidget().RenderSize(size, focus, app)
 }
@@ -104,15 +121,24 @@ func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.S
 //''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
 
 func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
-	c := w.SubWidget().Render(size, focus, app)
+	c := gowid.RenderChild(w.SubWidget(), size, focus, app)
 
-	gowid.RangeOverCanvas(c, gowid.CellRangeFunc(func(cell gowid.Cell) gowid.Cell {
-		return w.Transform(cell, focus)
-	}))
+	if cm, ok := w.(ICanvasMod); ok {
+		cm.TransformCanvas(c, focus, app)
+	} else {
+		TransformCanvas(w, c, focus)
+	}
 
 	return c
 }
 
+// TransformCanvas applies the mod to each cell of the canvas in place.
+func TransformCanvas(mod ICellMod, c gowid.ICanvas, focus gowid.Selector) {
+	gowid.RangeOverCanvas(c, gowid.CellRangeFunc(func(cell gowid.Cell) gowid.Cell {
+		return mod.Transform(cell, focus)
+	}))
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
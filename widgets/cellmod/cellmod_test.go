@@ -0,0 +1,61 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package cellmod
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline1(t *testing.T) {
+	red := gowid.MakeTCellColorExt(tcell.ColorRed)
+	inner := styled.New(text.New("pin 1234 ok"), gowid.MakeForeground(red))
+
+	calls := 0
+	count := Func(func(c gowid.Cell, focus gowid.Selector) gowid.Cell {
+		calls++
+		return c
+	})
+	w := New(inner, Chain(
+		Recolor(map[tcell.Color]tcell.Color{tcell.ColorRed: tcell.ColorBlue}),
+		Redact{Pattern: regexp.MustCompile(`\d+`)},
+		Cached(count, 100),
+	))
+	sz := gowid.RenderFlowWith{C: 11}
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "pin **** ok", c.String())
+	assert.Equal(t, tcell.ColorBlue, c.CellAt(4, 0).ForegroundColor().ToTCell())
+	// "pin **** ok" has seven distinct cells
+	assert.Equal(t, 7, calls)
+
+	w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, 7, calls)
+
+	w.SetMod(Desaturate(1))
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "pin 1234 ok", c.String())
+	r, g, b := c.CellAt(0, 0).ForegroundColor().ToTCell().RGB()
+	assert.Equal(t, r, g)
+	assert.Equal(t, g, b)
+}
+
+func TestRedact1(t *testing.T) {
+	w := New(text.New("日本 token=abc"), Redact{Pattern: regexp.MustCompile(`token=\w+`), Rune: '#'})
+	c := w.Render(gowid.RenderFlowWith{C: 14}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "日本 #########", c.String())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,224 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package cellmod
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	tcell "github.com/gdamore/tcell/v2"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+//======================================================================
+
+// Pipeline is a sequence of mods applied in order, each to the result of the last. It
+// can be used wherever a single mod can. Rendered by a cellmod widget, its canvas mods,
+// such as Redact, see the canvas as the mods before them left it; called via Transform,
+// they're skipped.
+type Pipeline []ICellMod
+
+var _ ICellMod = Pipeline{}
+var _ ICanvasMod = Pipeline{}
+
+// Chain returns a pipeline of the supplied mods.
+func Chain(mods ...ICellMod) Pipeline {
+	return Pipeline(mods)
+}
+
+func (p Pipeline) Transform(c gowid.Cell, focus gowid.Selector) gowid.Cell {
+	for _, mod := range p {
+		c = mod.Transform(c, focus)
+	}
+	return c
+}
+
+func (p Pipeline) TransformCanvas(c gowid.ICanvas, focus gowid.Selector, app gowid.IApp) {
+	// Apply consecutive cell mods in one pass over the canvas
+	for i := 0; i < len(p); {
+		if cm, ok := p[i].(ICanvasMod); ok {
+			cm.TransformCanvas(c, focus, app)
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(p) {
+			if _, ok := p[j].(ICanvasMod); ok {
+				break
+			}
+			j++
+		}
+		TransformCanvas(p[i:j], c, focus)
+		i = j
+	}
+}
+
+//======================================================================
+
+// cellKey identifies a cell's content and attributes by value, for the cache.
+type cellKey struct {
+	r          rune
+	comb       string
+	fg, bg, ul tcell.Color
+	fgSet      bool
+	bgSet      bool
+	ulSet      bool
+	style      gowid.StyleAttrs
+	link       gowid.Hyperlink
+	focus      bool
+}
+
+func keyOf(c gowid.Cell, focus gowid.Selector) cellKey {
+	link, _ := c.Hyperlink()
+	return cellKey{
+		r:     c.Rune(),
+		comb:  string(c.Combining()),
+		fg:    c.ForegroundColor().ToTCell(),
+		bg:    c.BackgroundColor().ToTCell(),
+		ul:    c.UnderlineColor().ToTCell(),
+		fgSet: c.ForegroundColor() != gowid.ColorNone,
+		bgSet: c.BackgroundColor() != gowid.ColorNone,
+		ulSet: c.UnderlineColor() != gowid.ColorNone,
+		style: c.Style(),
+		link:  link,
+		focus: focus.Focus,
+	}
+}
+
+type cached struct {
+	mod   ICellMod
+	cache *lru.Cache
+}
+
+// Cached returns a mod that remembers the results of mod for the most recent size
+// distinct cells, so an expensive mod - e.g. one converting colors - is run once per
+// distinct cell rather than for every cell of every render. Only use it for mods whose
+// result depends on nothing but the cell and the focus.
+func Cached(mod ICellMod, size int) ICellMod {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &cached{mod: mod, cache: cache}
+}
+
+func (m *cached) Transform(c gowid.Cell, focus gowid.Selector) gowid.Cell {
+	key := keyOf(c, focus)
+	if res, ok := m.cache.Get(key); ok {
+		return res.(gowid.Cell)
+	}
+	res := m.mod.Transform(c, focus)
+	m.cache.Add(key, res)
+	return res
+}
+
+//======================================================================
+
+// Desaturate returns a mod that moves each cell's colors towards gray - all the way if
+// amount is 1. The terminal's default colors are left alone.
+func Desaturate(amount float64) Func {
+	return func(c gowid.Cell, focus gowid.Selector) gowid.Cell {
+		return mapColors(c, func(col tcell.Color) tcell.Color {
+			r, g, b := col.RGB()
+			if r < 0 {
+				return col
+			}
+			l := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			mix := func(v int32) int32 {
+				return int32(float64(v) + (l-float64(v))*amount + 0.5)
+			}
+			return tcell.NewRGBColor(mix(r), mix(g), mix(b))
+		})
+	}
+}
+
+// Recolor returns a mod that replaces each color in the map - foreground, background
+// or underline - with the color it maps to.
+func Recolor(colors map[tcell.Color]tcell.Color) Func {
+	return func(c gowid.Cell, focus gowid.Selector) gowid.Cell {
+		return mapColors(c, func(col tcell.Color) tcell.Color {
+			if to, ok := colors[col]; ok {
+				return to
+			}
+			return col
+		})
+	}
+}
+
+func mapColors(c gowid.Cell, fn func(tcell.Color) tcell.Color) gowid.Cell {
+	if col := c.ForegroundColor(); col != gowid.ColorNone {
+		c = c.WithForegroundColor(gowid.MakeTCellColorExt(fn(col.ToTCell())))
+	}
+	if col := c.BackgroundColor(); col != gowid.ColorNone {
+		c = c.WithBackgroundColor(gowid.MakeTCellColorExt(fn(col.ToTCell())))
+	}
+	if col := c.UnderlineColor(); col != gowid.ColorNone {
+		c = c.WithUnderlineColor(gowid.MakeTCellColorExt(fn(col.ToTCell())))
+	}
+	return c
+}
+
+//======================================================================
+
+// Redact is a canvas mod that hides the text on each line matching Pattern - e.g.
+// passwords or account numbers - by replacing each of its cells' runes with Rune, or
+// '*' if Rune is zero. The cells' styles are kept. Matches don't span lines.
+type Redact struct {
+	Pattern *regexp.Regexp
+	Rune    rune
+}
+
+var _ ICellMod = Redact{}
+var _ ICanvasMod = Redact{}
+
+// Transform leaves the cell alone - a single cell can't be matched against the pattern.
+func (m Redact) Transform(c gowid.Cell, focus gowid.Selector) gowid.Cell {
+	return c
+}
+
+func (m Redact) TransformCanvas(c gowid.ICanvas, focus gowid.Selector, app gowid.IApp) {
+	with := m.Rune
+	if with == 0 {
+		with = '*'
+	}
+	for y := 0; y < c.BoxRows(); y++ {
+		// Build the line's text, remembering the column of each byte
+		var b strings.Builder
+		cols := make([]int, 0, c.BoxColumns())
+		for x := 0; x < c.BoxColumns(); x++ {
+			cell := c.CellAt(x, y)
+			s := " "
+			if cell.HasRune() {
+				s = cell.Grapheme()
+			}
+			b.WriteString(s)
+			for i := 0; i < len(s); i++ {
+				cols = append(cols, x)
+			}
+			// Skip the cells covered by a wide rune
+			x += gwutil.Max(cell.Width(), 1) - 1
+		}
+		for _, m := range m.Pattern.FindAllStringIndex(b.String(), -1) {
+			last := -1
+			for i := m[0]; i < m[1]; i++ {
+				if x := cols[i]; x != last {
+					cell := c.CellAt(x, y)
+					if cell.HasRune() {
+						c.SetCellAt(x, y, cell.WithRune(with))
+					}
+					last = x
+				}
+			}
+		}
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -2,7 +2,8 @@
 // code is governed by the MIT license that can be found in the LICENSE
 // file.
 
-// Package checkbox provides a widget which can be checked or unchecked.
+// Package checkbox provides a widget which can be checked or unchecked, or shown as
+// indeterminate - e.g. for a "select all" box over a partially selected list.
 package checkbox
 
 import (
@@ -21,6 +22,13 @@ type IChecked interface {
 	IsChecked() bool
 }
 
+// IIndeterminate is implemented by checkable widgets with a third, indeterminate state.
+// Render draws IndeterminateDec in the middle for that state.
+type IIndeterminate interface {
+	State() CheckedState
+	IndeterminateDec() string
+}
+
 type IWidget interface {
 	gowid.IWidget
 	IChecked
@@ -28,6 +36,38 @@ type IWidget interface {
 
 //======================================================================
 
+// CheckedState is the state of a checkbox.
+type CheckedState int
+
+const (
+	Unchecked CheckedState = iota
+	Checked
+	Indeterminate
+)
+
+func (s CheckedState) String() string {
+	switch s {
+	case Unchecked:
+		return "unchecked"
+	case Checked:
+		return "checked"
+	case Indeterminate:
+		return "indeterminate"
+	default:
+		return fmt.Sprintf("checkedstate(%d)", int(s))
+	}
+}
+
+func stateOf(checked bool) CheckedState {
+	return gwutil.If(checked, Checked, Unchecked).(CheckedState)
+}
+
+// StateCB is the name under which callbacks are registered that run when the state
+// changes. The callback's extra argument is the new CheckedState.
+type StateCB struct{}
+
+//======================================================================
+
 type Decoration struct {
 	button.Decoration
 	Middle string
@@ -44,8 +84,9 @@ func (w *Decoration) SetMiddleDec(dec string, app gowid.IApp) {
 //======================================================================
 
 type Widget struct {
-	checked   bool
-	Callbacks *gowid.Callbacks
+	state         CheckedState
+	indeterminate string // Middle decoration for the indeterminate state; "" means "-"
+	Callbacks     *gowid.Callbacks
 	gowid.ClickCallbacks
 	Decoration
 	gowid.AddressProvidesID
@@ -55,7 +96,7 @@ type Widget struct {
 func New(isChecked bool) *Widget {
 	cb := gowid.NewCallbacks()
 	res := &Widget{
-		checked:        isChecked,
+		state:          stateOf(isChecked),
 		Callbacks:      cb,
 		ClickCallbacks: gowid.ClickCallbacks{CB: &cb},
 		Decoration:     Decoration{button.Decoration{"[", "]"}, "X"},
@@ -67,7 +108,7 @@ func New(isChecked bool) *Widget {
 func NewDecorated(isChecked bool, decoration Decoration) *Widget {
 	cb := gowid.NewCallbacks()
 	res := &Widget{
-		checked:        isChecked,
+		state:          stateOf(isChecked),
 		Callbacks:      cb,
 		ClickCallbacks: gowid.ClickCallbacks{CB: &cb},
 		Decoration:     decoration,
@@ -77,25 +118,106 @@ func NewDecorated(isChecked bool, decoration Decoration) *Widget {
 }
 
 func (w *Widget) String() string {
-	return fmt.Sprintf("checkbox[%s]", gwutil.If(w.IsChecked(), "X", " ").(string))
+	var s string
+	switch w.state {
+	case Checked:
+		s = "X"
+	case Indeterminate:
+		s = "-"
+	default:
+		s = " "
+	}
+	return fmt.Sprintf("checkbox[%s]", s)
 }
 
+// IsChecked returns true only if the checkbox is checked - not if it is indeterminate.
 func (w *Widget) IsChecked() bool {
-	return w.checked
+	return w.state == Checked
 }
 
 func (w *Widget) SetChecked(app gowid.IApp, val bool) {
-	w.setChecked(app, val)
+	w.setState(app, stateOf(val))
+}
+
+func (w *Widget) State() CheckedState {
+	return w.state
 }
 
-func (w *Widget) setChecked(app gowid.IApp, val bool) {
-	w.checked = val
+// SetState sets the state of the checkbox. The click callbacks are run, as for
+// SetChecked, and the state callbacks too if the state changed.
+func (w *Widget) SetState(app gowid.IApp, state CheckedState) {
+	w.setState(app, state)
+}
+
+func (w *Widget) setState(app gowid.IApp, state CheckedState) {
+	changed := state != w.state
+	w.state = state
 	gowid.RunWidgetCallbacks(*w.CB, gowid.ClickCB{}, app, w)
+	if changed {
+		gowid.RunWidgetCallbacks(w.Callbacks, StateCB{}, app, w, state)
+		gowid.Announce(app, w, gowid.Translate(state.String()))
+	}
+}
+
+func (w *Widget) OnStateChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, StateCB{}, f)
+}
+
+func (w *Widget) RemoveOnStateChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, StateCB{}, f)
+}
+
+// IndeterminateDec returns the middle decoration drawn for the indeterminate state -
+// by default, a "-" centered in the width of the checked decoration.
+func (w *Widget) IndeterminateDec() string {
+	if w.indeterminate != "" {
+		return w.indeterminate
+	}
+	n := len(w.MiddleDec())
+	if n == 0 {
+		return ""
+	}
+	res := []byte(gwutil.StringOfLength(' ', n))
+	res[(n-1)/2] = '-'
+	return string(res)
+}
+
+// SetIndeterminateDec sets the middle decoration drawn for the indeterminate state. It
+// should be as wide as the checked decoration.
+func (w *Widget) SetIndeterminateDec(dec string, app gowid.IApp) {
+	w.indeterminate = dec
+}
+
+type bindingID struct {
+	p *gowid.Property[bool]
+}
+
+// BindChecked keeps the checkbox and the property in step in both directions -
+// changes to the property check or uncheck the box, on the widget rendering
+// goroutine, and clicking the box sets the property.
+func (w *Widget) BindChecked(app gowid.IApp, p *gowid.Property[bool]) *gowid.Binding {
+	b := gowid.Bind(app, p, func(app gowid.IApp, checked bool) {
+		if checked != w.IsChecked() {
+			w.SetChecked(app, checked)
+		}
+	})
+	id := bindingID{p}
+	w.OnClick(gowid.MakeWidgetCallback(id, func(app gowid.IApp, w2 gowid.IWidget) {
+		if checked := w.IsChecked(); checked != p.Get() {
+			p.Set(checked)
+		}
+	}))
+	return gowid.NewBinding(func() {
+		b.Unbind()
+		w.RemoveOnClick(gowid.CallbackID{Name: id})
+	})
 }
 
+// Click checks the box if it is unchecked or indeterminate, and unchecks it otherwise.
+// Clicking never makes the box indeterminate - that is up to the application.
 func (w *Widget) Click(app gowid.IApp) {
 	if app.GetMouseState().NoButtonClicked() || app.GetMouseState().LeftIsClicked() {
-		w.setChecked(app, !w.IsChecked())
+		w.setState(app, stateOf(!w.IsChecked()))
 	}
 }
 
@@ -124,7 +246,9 @@ func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.S
 func Render(w IChecked, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
 	line := make([]gowid.Cell, 0)
 	line = append(line, gowid.CellsFromString(w.LeftDec())...)
-	if w.IsChecked() {
+	if ind, ok := w.(IIndeterminate); ok && ind.State() == Indeterminate {
+		line = append(line, gowid.CellsFromString(ind.IndeterminateDec())...)
+	} else if w.IsChecked() {
 		line = append(line, gowid.CellsFromString(w.MiddleDec())...)
 	} else {
 		line = append(line, gowid.CellsFromString(gwutil.StringOfLength(' ', len(w.MiddleDec())))...)
@@ -73,6 +73,37 @@ func TestCheckbox1(t *testing.T) {
 
 }
 
+func TestIndeterminate1(t *testing.T) {
+	w := New(false)
+	var states []CheckedState
+	w.OnStateChange(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		states = append(states, data[0].(CheckedState))
+	}})
+
+	w.SetState(gwtest.D, Indeterminate)
+	assert.Equal(t, Indeterminate, w.State())
+	assert.False(t, w.IsChecked())
+	assert.Equal(t, "[-]", w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D).String())
+	assert.Equal(t, "checkbox[-]", w.String())
+
+	// No callback if the state doesn't change
+	w.SetState(gwtest.D, Indeterminate)
+	assert.Equal(t, []CheckedState{Indeterminate}, states)
+
+	// Clicking an indeterminate box checks it
+	w.Click(gwtest.D)
+	assert.Equal(t, Checked, w.State())
+	w.Click(gwtest.D)
+	assert.Equal(t, Unchecked, w.State())
+	assert.Equal(t, []CheckedState{Indeterminate, Checked, Unchecked}, states)
+
+	w2 := NewDecorated(true, Decoration{button.Decoration{"[[", "]]"}, " X "})
+	w2.SetState(gwtest.D, Indeterminate)
+	assert.Equal(t, "[[ - ]]", w2.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D).String())
+	w2.SetIndeterminateDec("~~~", gwtest.D)
+	assert.Equal(t, "[[~~~]]", w2.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D).String())
+}
+
 var (
 	cb1 int
 	cb2 int
@@ -121,6 +152,27 @@ func TestCallbacks(t *testing.T) {
 	assert.Equal(t, cb2, 3)
 }
 
+func TestBindChecked1(t *testing.T) {
+	p := gowid.NewProperty(true)
+	w := New(false)
+	b := w.BindChecked(gwtest.D, p)
+	assert.True(t, w.IsChecked())
+
+	p.Set(false)
+	assert.False(t, w.IsChecked())
+
+	w.Click(gwtest.D)
+	assert.True(t, w.IsChecked())
+	assert.True(t, p.Get())
+
+	b.Unbind()
+	w.Click(gwtest.D)
+	assert.False(t, w.IsChecked())
+	assert.True(t, p.Get())
+	p.Set(true)
+	assert.False(t, w.IsChecked())
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
@@ -114,7 +114,7 @@ func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Se
 }
 
 func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
-	res := w.SubWidget().Render(gowid.SubWidgetSize(w, size, focus, app), focus, app)
+	res := gowid.RenderChild(w.SubWidget(), gowid.SubWidgetSize(w, size, focus, app), focus, app)
 
 	if w.ClickPending() {
 		gowid.RangeOverCanvas(res, gowid.CellRangeFunc(func(c gowid.Cell) gowid.Cell {
@@ -0,0 +1,98 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+//go:build chroma
+// +build chroma
+
+// This file adapts chroma's lexers, which cover hundreds of languages. It is only built
+// with the "chroma" tag, so that programs using the code widget without it don't link
+// chroma's lexers in.
+
+package code
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+//======================================================================
+
+// ChromaLexer adapts a chroma lexer to ILexer.
+type ChromaLexer struct {
+	chroma.Lexer
+}
+
+var _ ILexer = ChromaLexer{}
+
+// NewChromaLexer returns the chroma lexer for the named language e.g. "go" or "python".
+// If there is none, chroma's fallback lexer, which does no highlighting, is used.
+func NewChromaLexer(name string) ChromaLexer {
+	l := lexers.Get(name)
+	if l == nil {
+		l = lexers.Fallback
+	}
+	return ChromaLexer{chroma.Coalesce(l)}
+}
+
+// NewChromaLexerForFile returns the chroma lexer for the file name e.g. "main.go".
+func NewChromaLexerForFile(filename string) ChromaLexer {
+	l := lexers.Match(filename)
+	if l == nil {
+		l = lexers.Fallback
+	}
+	return ChromaLexer{chroma.Coalesce(l)}
+}
+
+// Tokenize implements ILexer. If chroma fails, the source is not highlighted.
+func (l ChromaLexer) Tokenize(src string) []Token {
+	it, err := l.Lexer.Tokenise(nil, src)
+	if err != nil {
+		return PlainLexer.Tokenize(src)
+	}
+	res := make([]Token, 0, 64)
+	for tok := it(); tok != chroma.EOF; tok = it() {
+		res = append(res, Token{Class: chromaClass(tok.Type), Text: tok.Value})
+	}
+	// Some lexers add a final newline to the source - drop it, so the text is unchanged
+	if n := len(res); n > 0 && !strings.HasSuffix(src, "\n") {
+		res[n-1].Text = strings.TrimSuffix(res[n-1].Text, "\n")
+		if res[n-1].Text == "" {
+			res = res[:n-1]
+		}
+	}
+	return res
+}
+
+func chromaClass(t chroma.TokenType) TokenClass {
+	switch {
+	case t == chroma.KeywordType:
+		return Type
+	case t.InCategory(chroma.Keyword):
+		return Keyword
+	case t == chroma.NameBuiltin, t == chroma.NameClass:
+		return Type
+	case t.InCategory(chroma.Name):
+		return Name
+	case t.InSubCategory(chroma.LiteralString):
+		return String
+	case t.InSubCategory(chroma.LiteralNumber):
+		return Number
+	case t.InCategory(chroma.Comment):
+		return Comment
+	case t.InCategory(chroma.Operator):
+		return Operator
+	case t.InCategory(chroma.Punctuation):
+		return Punctuation
+	default:
+		return Text
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
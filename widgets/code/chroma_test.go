@@ -0,0 +1,45 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+//go:build chroma
+// +build chroma
+
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChromaLexer1(t *testing.T) {
+	toks := NewChromaLexer("go").Tokenize("func f() int { return 42 } // done")
+	classes := make(map[string]TokenClass)
+	for _, tok := range toks {
+		classes[tok.Text] = tok.Class
+	}
+	assert.Equal(t, Keyword, classes["func"])
+	assert.Equal(t, Type, classes["int"])
+	assert.Equal(t, Keyword, classes["return"])
+	assert.Equal(t, Name, classes["f"])
+	assert.Equal(t, Number, classes["42"])
+	assert.Equal(t, Comment, classes["// done"])
+
+	// The source is kept, whatever the language
+	for _, l := range []ILexer{NewChromaLexer("go"), NewChromaLexer("no such language"), NewChromaLexerForFile("main.py")} {
+		for _, src := range []string{"x = 1\n", "x = 1"} {
+			res := ""
+			for _, tok := range l.Tokenize(src) {
+				res += tok.Text
+			}
+			assert.Equal(t, src, res)
+		}
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,368 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package code provides a read-only source code viewer with syntax highlighting, line
+// numbers, horizontal scrolling and a highlighted current line. Highlighting is done by
+// a pluggable lexer; built with the "chroma" tag, NewChromaLexer adapts the lexers of
+// github.com/alecthomas/chroma.
+package code
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// Theme maps token classes to styles. Classes not in the theme are not styled.
+type Theme map[TokenClass]gowid.ICellStyler
+
+// DefaultTheme is used if Options.Theme is nil.
+var DefaultTheme = Theme{
+	Keyword: gowid.MakeStyledPaletteEntry(gowid.ColorBlue, gowid.NoColor{}, gowid.StyleBold),
+	Type:    gowid.MakeForeground(gowid.ColorCyan),
+	String:  gowid.MakeForeground(gowid.ColorGreen),
+	Number:  gowid.MakeForeground(gowid.ColorMagenta),
+	Comment: gowid.MakeStyledPaletteEntry(gowid.ColorDarkGray, gowid.NoColor{}, gowid.StyleItalic),
+}
+
+// Options can be supplied to New. If Lexer is nil, the source is not highlighted. If
+// TabWidth is zero, 4 is used.
+type Options struct {
+	Lexer            ILexer
+	Theme            Theme
+	LineNumbers      bool
+	LineNumberStyle  gowid.ICellStyler
+	CurrentLineStyle gowid.ICellStyler
+	TabWidth         int
+}
+
+type ICode interface {
+	// Lines returns the number of lines of source.
+	Lines() int
+	CurrentLine() int
+	HorizontalOffset() int
+}
+
+type IWidget interface {
+	gowid.IWidget
+	ICode
+}
+
+type LineCB struct{}
+
+// Widget displays source code. It is selectable - the cursor keys and mouse wheel move
+// the current line and scroll horizontally.
+type Widget struct {
+	source string
+	opts   Options
+	lines  []*text.Content
+	width  int // widest line
+	cur    int
+	top    int
+	xoff   int
+	*gowid.Callbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(source string, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	res := &Widget{
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.setSource(source)
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("code[%d/%d]", w.cur+1, len(w.lines))
+}
+
+func (w *Widget) Source() string {
+	return w.source
+}
+
+// SetSource replaces the code displayed. The current line is kept if it still exists.
+func (w *Widget) SetSource(source string, app gowid.IApp) {
+	w.setSource(source)
+	w.SetCurrentLine(w.cur, app)
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+// SetOpts changes the widget's options, e.g. to switch lexer or theme. The source is
+// highlighted again.
+func (w *Widget) SetOpts(opts Options, app gowid.IApp) {
+	w.opts = opts
+	w.setSource(w.source)
+}
+
+func (w *Widget) Lines() int {
+	return len(w.lines)
+}
+
+// CurrentLine returns the zero-based index of the highlighted line.
+func (w *Widget) CurrentLine() int {
+	return w.cur
+}
+
+func (w *Widget) SetCurrentLine(line int, app gowid.IApp) {
+	line = gwutil.Max(0, gwutil.Min(line, len(w.lines)-1))
+	if line != w.cur {
+		w.cur = line
+		gowid.RunWidgetCallbacks(w.Callbacks, LineCB{}, app, w)
+	}
+}
+
+// HorizontalOffset returns the number of columns of source scrolled off the left of the widget.
+func (w *Widget) HorizontalOffset() int {
+	return w.xoff
+}
+
+func (w *Widget) SetHorizontalOffset(x int, app gowid.IApp) {
+	w.xoff = gwutil.Max(0, gwutil.Min(x, w.width-1))
+}
+
+func (w *Widget) OnLineChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, LineCB{}, f)
+}
+
+func (w *Widget) RemoveOnLineChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, LineCB{}, f)
+}
+
+func (w *Widget) tabWidth() int {
+	if w.opts.TabWidth <= 0 {
+		return 4
+	}
+	return w.opts.TabWidth
+}
+
+func (w *Widget) theme() Theme {
+	if w.opts.Theme == nil {
+		return DefaultTheme
+	}
+	return w.opts.Theme
+}
+
+// setSource tokenizes the source and splits the tokens into lines of styled content,
+// expanding tabs.
+func (w *Widget) setSource(source string) {
+	w.source = source
+	var toks []Token
+	if w.opts.Lexer != nil {
+		toks = w.opts.Lexer.Tokenize(source)
+	} else {
+		toks = PlainLexer.Tokenize(source)
+	}
+	theme := w.theme()
+	tw := w.tabWidth()
+
+	w.lines = w.lines[:0]
+	w.width = 0
+	segs := make([]text.ContentSegment, 0, 16)
+	col := 0
+	endLine := func() {
+		content := text.NewContent(segs)
+		w.lines = append(w.lines, content)
+		w.width = gwutil.Max(w.width, content.Width())
+		segs = segs[:0]
+		col = 0
+	}
+	for _, tok := range toks {
+		parts := strings.Split(tok.Text, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				endLine()
+			}
+			if part == "" {
+				continue
+			}
+			var expanded strings.Builder
+			for _, r := range part {
+				if r == '\t' {
+					n := tw - col%tw
+					expanded.WriteString(strings.Repeat(" ", n))
+					col += n
+				} else {
+					expanded.WriteRune(r)
+					col++
+				}
+			}
+			segs = append(segs, text.StyledContent(expanded.String(), theme[tok.Class]))
+		}
+	}
+	endLine()
+	w.cur = gwutil.Min(w.cur, len(w.lines)-1)
+	w.xoff = gwutil.Min(w.xoff, gwutil.Max(0, w.width-1))
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+// gutterWidth returns the number of columns used for line numbers, including a space
+// separating them from the code.
+func gutterWidth(w *Widget) int {
+	if !w.opts.LineNumbers {
+		return 0
+	}
+	return len(strconv.Itoa(len(w.lines))) + 1
+}
+
+// topLine returns the first line to display in a widget of the given height, keeping
+// the current line in view.
+func topLine(w *Widget, rows int) int {
+	top := w.top
+	if w.cur < top {
+		top = w.cur
+	} else if w.cur >= top+rows {
+		top = w.cur - rows + 1
+	}
+	return gwutil.Max(0, gwutil.Min(top, len(w.lines)-rows))
+}
+
+func RenderSize(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	switch sz := size.(type) {
+	case gowid.IRenderBox:
+		return gowid.RenderBox{C: sz.BoxColumns(), R: sz.BoxRows()}
+	case gowid.IRenderFlowWith:
+		return gowid.RenderBox{C: sz.FlowColumns(), R: len(w.lines)}
+	case gowid.IRenderFixed:
+		return gowid.RenderBox{C: gutterWidth(w) + w.width, R: len(w.lines)}
+	default:
+		panic(gowid.WidgetSizeError{Widget: w, Size: size})
+	}
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	box := RenderSize(w, size, focus, app)
+	cols, rows := box.BoxColumns(), box.BoxRows()
+	gutter := gwutil.Min(gutterWidth(w), cols)
+	top := topLine(w, rows)
+
+	var hl gowid.Cell
+	if w.opts.CurrentLineStyle != nil {
+		f, b, s := w.opts.CurrentLineStyle.GetStyle(app)
+		hl = gowid.MakeCell(0,
+			gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+			gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+			s)
+	}
+
+	res := gowid.NewCanvas()
+	for y := 0; y < rows; y++ {
+		line := make([]gowid.Cell, cols)
+		idx := top + y
+		if idx < len(w.lines) {
+			if gutter > 0 {
+				num := text.NewContent([]text.ContentSegment{
+					text.StyledContent(fmt.Sprintf("%*d ", gutter-1, idx+1), w.opts.LineNumberStyle),
+				})
+				num.RangeOver(0, gwutil.Min(gutter, num.Length()), app, &text.ContentToCellArray{Cells: line[:gutter]})
+			}
+			content := w.lines[idx]
+			cells := make([]gowid.Cell, content.Width())
+			content.RangeOver(0, content.Length(), app, &text.ContentToCellArray{Cells: cells})
+			if w.xoff < len(cells) {
+				copy(line[gutter:], cells[w.xoff:])
+			}
+			if idx == w.cur && w.opts.CurrentLineStyle != nil {
+				for x := gutter; x < cols; x++ {
+					line[x] = hl.MergeUnder(line[x])
+				}
+			}
+		}
+		res.AppendLine(line, false)
+	}
+	return res
+}
+
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	rows := RenderSize(w, size, focus, app).BoxRows()
+	line := w.cur
+	xoff := w.xoff
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyUp:
+			line--
+		case tcell.KeyDown:
+			line++
+		case tcell.KeyPgUp:
+			line -= gwutil.Max(1, rows-1)
+		case tcell.KeyPgDn:
+			line += gwutil.Max(1, rows-1)
+		case tcell.KeyHome:
+			line = 0
+			xoff = 0
+		case tcell.KeyEnd:
+			line = len(w.lines) - 1
+		case tcell.KeyLeft:
+			xoff--
+		case tcell.KeyRight:
+			xoff++
+		default:
+			return false
+		}
+	case *tcell.EventMouse:
+		switch ev.Buttons() {
+		case tcell.WheelUp:
+			line--
+		case tcell.WheelDown:
+			line++
+		case tcell.WheelLeft:
+			xoff--
+		case tcell.WheelRight:
+			xoff++
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	line = gwutil.Max(0, gwutil.Min(line, len(w.lines)-1))
+	xoff = gwutil.Max(0, gwutil.Min(xoff, w.width-1))
+	if line == w.cur && xoff == w.xoff {
+		// Let an enclosing widget use the input e.g. to move focus
+		return false
+	}
+	w.SetCurrentLine(line, app)
+	w.SetHorizontalOffset(xoff, app)
+	w.top = topLine(w, rows)
+	return true
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
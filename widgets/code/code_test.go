@@ -0,0 +1,83 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package code
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestLexer1(t *testing.T) {
+	src := "func f(s string) int {\n\t/* x\n y */ return 0x1f // \"no\"\n}"
+	toks := NewGoLexer().Tokenize(src)
+
+	var all strings.Builder
+	classes := make(map[string]TokenClass)
+	for _, tok := range toks {
+		all.WriteString(tok.Text)
+		classes[strings.TrimSpace(tok.Text)] = tok.Class
+	}
+	assert.Equal(t, src, all.String())
+	assert.Equal(t, Keyword, classes["func"])
+	assert.Equal(t, Type, classes["string"])
+	assert.Equal(t, Name, classes["s"])
+	assert.Equal(t, Comment, classes["/* x\n y */"])
+	assert.Equal(t, Number, classes["0x1f"])
+	assert.Equal(t, Comment, classes["// \"no\""])
+
+	toks = NewGoLexer().Tokenize(`"a\"b" 'c`)
+	assert.Equal(t, Token{Class: String, Text: `"a\"b"`}, toks[0])
+	assert.Equal(t, Token{Class: String, Text: `'c`}, toks[2])
+}
+
+func TestCode1(t *testing.T) {
+	w := New("package main\n\nfunc\tmain() {\n}\n// end", Options{
+		Lexer:            NewGoLexer(),
+		LineNumbers:      true,
+		CurrentLineStyle: gowid.MakeBackground(gowid.ColorRed),
+	})
+	assert.Equal(t, 5, w.Lines())
+
+	c := w.Render(gowid.RenderBox{C: 12, R: 3}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "1 package ma\n2           \n3 func    ma", c.String())
+	red := gowid.IColorToTCell(gowid.ColorRed, gowid.ColorNone, gwtest.D.GetColorMode())
+	assert.Equal(t, red, c.CellAt(2, 0).BackgroundColor())
+	assert.Equal(t, gowid.ColorNone, c.CellAt(0, 0).BackgroundColor())
+	assert.Equal(t, gowid.ColorNone, c.CellAt(2, 1).BackgroundColor())
+	assert.Equal(t, gowid.StyleBold, c.CellAt(2, 0).Style())
+
+	down := tcell.NewEventKey(tcell.KeyDown, ' ', tcell.ModNone)
+	for i := 0; i < 3; i++ {
+		assert.True(t, w.UserInput(down, gowid.RenderBox{C: 12, R: 3}, gowid.Focused, gwtest.D))
+	}
+	assert.Equal(t, 3, w.CurrentLine())
+	c = w.Render(gowid.RenderBox{C: 12, R: 3}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "2           \n3 func    ma\n4 }         ", c.String())
+
+	right := tcell.NewEventKey(tcell.KeyRight, ' ', tcell.ModNone)
+	assert.True(t, w.UserInput(right, gowid.RenderBox{C: 12, R: 3}, gowid.Focused, gwtest.D))
+	c = w.Render(gowid.RenderBox{C: 12, R: 3}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "2           \n3 unc    mai\n4           ", c.String())
+
+	end := tcell.NewEventKey(tcell.KeyEnd, ' ', tcell.ModNone)
+	assert.True(t, w.UserInput(end, gowid.RenderBox{C: 12, R: 3}, gowid.Focused, gwtest.D))
+	assert.False(t, w.UserInput(down, gowid.RenderBox{C: 12, R: 3}, gowid.Focused, gwtest.D))
+
+	c = w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	assert.Equal(t, 2+16, c.BoxColumns())
+	assert.Equal(t, 5, c.BoxRows())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,202 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package code
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//======================================================================
+
+// TokenClass categorizes a token for highlighting. The widget's theme maps each class to
+// a style.
+type TokenClass int
+
+const (
+	Text TokenClass = iota
+	Keyword
+	Type
+	Name
+	String
+	Number
+	Comment
+	Operator
+	Punctuation
+)
+
+// Token is a piece of source text and its class. It may span lines, e.g. a block comment.
+type Token struct {
+	Class TokenClass
+	Text  string
+}
+
+// ILexer splits source text into tokens for highlighting. The concatenation of the tokens'
+// text must be the source text.
+type ILexer interface {
+	Tokenize(src string) []Token
+}
+
+// LexerFunc adapts a function to ILexer.
+type LexerFunc func(src string) []Token
+
+var _ ILexer = LexerFunc(nil)
+
+func (f LexerFunc) Tokenize(src string) []Token {
+	return f(src)
+}
+
+// PlainLexer returns the source as a single token of class Text.
+var PlainLexer = LexerFunc(func(src string) []Token {
+	return []Token{{Class: Text, Text: src}}
+})
+
+//======================================================================
+
+// SimpleLexer is a configurable lexer that handles the lexical conventions shared by
+// C-like languages - identifiers and keywords, numbers, quoted strings, and line and
+// block comments. It doesn't try to understand the language's grammar.
+type SimpleLexer struct {
+	Keywords     []string
+	Types        []string
+	LineComment  string    // e.g. "//"; empty if the language has none
+	BlockComment [2]string // e.g. {"/*", "*/"}; empty if the language has none
+	Quotes       string    // characters that delimit strings, e.g. "\"'`"
+	RawQuotes    string    // quotes whose strings have no escapes and may span lines
+	keywords     map[string]TokenClass
+}
+
+var _ ILexer = (*SimpleLexer)(nil)
+
+// NewGoLexer returns a SimpleLexer for Go source.
+func NewGoLexer() *SimpleLexer {
+	return &SimpleLexer{
+		Keywords: []string{
+			"break", "case", "chan", "const", "continue", "default", "defer", "else",
+			"fallthrough", "for", "func", "go", "goto", "if", "import", "interface", "map",
+			"package", "range", "return", "select", "struct", "switch", "type", "var",
+			"true", "false", "nil", "iota",
+		},
+		Types: []string{
+			"bool", "byte", "complex64", "complex128", "error", "float32", "float64",
+			"int", "int8", "int16", "int32", "int64", "rune", "string",
+			"uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "any",
+		},
+		LineComment:  "//",
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "\"'`",
+		RawQuotes:    "`",
+	}
+}
+
+func (l *SimpleLexer) classOf(word string) TokenClass {
+	if l.keywords == nil {
+		l.keywords = make(map[string]TokenClass, len(l.Keywords)+len(l.Types))
+		for _, k := range l.Keywords {
+			l.keywords[k] = Keyword
+		}
+		for _, t := range l.Types {
+			l.keywords[t] = Type
+		}
+	}
+	if c, ok := l.keywords[word]; ok {
+		return c
+	}
+	return Name
+}
+
+// Tokenize implements ILexer.
+func (l *SimpleLexer) Tokenize(src string) []Token {
+	res := make([]Token, 0, len(src)/4)
+	add := func(class TokenClass, n int) {
+		// Merge adjacent tokens of the same class
+		if len(res) > 0 && res[len(res)-1].Class == class && class != Name {
+			res[len(res)-1].Text += src[:n]
+		} else {
+			res = append(res, Token{Class: class, Text: src[:n]})
+		}
+		src = src[n:]
+	}
+
+	for len(src) > 0 {
+		r, size := utf8.DecodeRuneInString(src)
+		switch {
+		case l.LineComment != "" && strings.HasPrefix(src, l.LineComment):
+			end := strings.IndexByte(src, '\n')
+			if end == -1 {
+				end = len(src)
+			}
+			add(Comment, end)
+		case l.BlockComment[0] != "" && strings.HasPrefix(src, l.BlockComment[0]):
+			end := strings.Index(src[len(l.BlockComment[0]):], l.BlockComment[1])
+			if end == -1 {
+				end = len(src)
+			} else {
+				end += len(l.BlockComment[0]) + len(l.BlockComment[1])
+			}
+			add(Comment, end)
+		case strings.ContainsRune(l.Quotes, r):
+			add(String, l.stringLength(src, r, size))
+		case unicode.IsDigit(r):
+			add(Number, lengthWhile(src, func(r rune) bool {
+				return r == '.' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+			}))
+		case r == '_' || unicode.IsLetter(r):
+			n := lengthWhile(src, func(r rune) bool {
+				return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+			})
+			add(l.classOf(src[:n]), n)
+		case unicode.IsSpace(r):
+			add(Text, lengthWhile(src, unicode.IsSpace))
+		case strings.ContainsRune("+-*/%=&|^<>!:~?", r):
+			add(Operator, size)
+		case strings.ContainsRune("()[]{},;.", r):
+			add(Punctuation, size)
+		default:
+			add(Text, size)
+		}
+	}
+	return res
+}
+
+// stringLength returns the length of the string literal at the start of src, opened by
+// quote. An unterminated string ends at the end of the line.
+func (l *SimpleLexer) stringLength(src string, quote rune, size int) int {
+	raw := strings.ContainsRune(l.RawQuotes, quote)
+	for i := size; i < len(src); {
+		r, n := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case r == quote:
+			return i + n
+		case r == '\\' && !raw:
+			i += n
+			if i < len(src) {
+				_, n = utf8.DecodeRuneInString(src[i:])
+			} else {
+				n = 0
+			}
+		case r == '\n' && !raw:
+			return i
+		}
+		i += n
+	}
+	return len(src)
+}
+
+func lengthWhile(src string, f func(rune) bool) int {
+	for i, r := range src {
+		if !f(r) {
+			return i
+		}
+	}
+	return len(src)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -6,6 +6,7 @@
 package columns
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -38,6 +39,9 @@ type Widget struct {
 	prefCol      int    // caches the last set prefered col. Passes it on if widget hasn't changed focus
 	widthHelper  []bool // optimizations to save frequent array allocations during use
 	widthHelper2 []bool
+	hidden       []bool // children hidden with SetHidden; nil if none have been
+	unhideFocus  int    // the hidden child that had focus, restored when it is shown
+	hideFocus    int    // where focus moved when that child was hidden
 	opt          Options
 	*gowid.Callbacks
 	gowid.AddressProvidesID
@@ -51,6 +55,9 @@ type Options struct {
 	DoNotSetSelected bool // Whether or not to set the focus.Selected field for the selected child
 	LeftKeys         []vim.KeyPress
 	RightKeys        []vim.KeyPress
+	ConcurrentRender bool              // render children in parallel - only if they are safe to, see gowid.RenderConcurrently
+	SelectedStyle    gowid.ICellStyler // applied to the selected child when the columns widget isn't in focus
+	FocusedStyle     gowid.ICellStyler // applied to the selected child when in focus; if nil, SelectedStyle is used
 }
 
 func New(widgets []gowid.IContainerWidget, opts ...Options) *Widget {
@@ -72,6 +79,8 @@ func New(widgets []gowid.IContainerWidget, opts ...Options) *Widget {
 		widgets:      widgets,
 		focus:        -1,
 		prefCol:      -1,
+		unhideFocus:  -1,
+		hideFocus:    -1,
 		widthHelper:  make([]bool, len(widgets)),
 		widthHelper2: make([]bool, len(widgets)),
 		opt:          opt,
@@ -89,6 +98,7 @@ func New(widgets []gowid.IContainerWidget, opts ...Options) *Widget {
 	var _ IWidget = res
 	var _ gowid.ICompositeMultipleDimensions = res
 	var _ gowid.ICompositeMultipleWidget = res
+	var _ gowid.IStateful = res
 
 	return res
 }
@@ -165,6 +175,8 @@ func (w *Widget) SetSubWidgets(widgets []gowid.IWidget, app gowid.IApp) {
 	}
 	w.widthHelper = make([]bool, len(widgets))
 	w.widthHelper2 = make([]bool, len(widgets))
+	w.hidden = nil
+	w.unhideFocus = -1
 	oldFocus := w.Focus()
 	w.widgets = ws
 	w.SetFocus(app, oldFocus)
@@ -186,12 +198,77 @@ func (w *Widget) SetDimensions(dimensions []gowid.IWidgetDimension, app gowid.IA
 	gowid.RunWidgetCallbacks(w.Callbacks, gowid.DimensionsCB{}, app, w)
 }
 
+// SaveState implements gowid.IStateful, saving the children's dimensions.
+func (w *Widget) SaveState() (interface{}, error) {
+	return gowid.SaveDimensions(w.Dimensions()), nil
+}
+
+// RestoreState implements gowid.IStateful, restoring the children's dimensions - unless
+// the number of children has changed.
+func (w *Widget) RestoreState(data json.RawMessage, ctx *gowid.StateContext, app gowid.IApp) error {
+	dims, err := gowid.RestoreDimensions(w.Dimensions(), data)
+	if err != nil {
+		return err
+	}
+	w.SetDimensions(dims, app)
+	return nil
+}
+
+// Hidden returns true if the i'th child has been hidden with SetHidden.
+func (w *Widget) Hidden(i int) bool {
+	return i >= 0 && i < len(w.hidden) && w.hidden[i]
+}
+
+// SetHidden hides or shows the i'th child. A hidden child stays in the widget's
+// list of children and keeps its dimension and state, but it is given no columns
+// and can't take focus. If the focus child is hidden, focus moves to the next
+// selectable child, and returns when the child is shown again - unless focus has
+// been moved elsewhere in the meantime. The hidden state is reset by SetSubWidgets.
+func (w *Widget) SetHidden(i int, hidden bool, app gowid.IApp) {
+	if i < 0 || i >= len(w.widgets) || w.Hidden(i) == hidden {
+		return
+	}
+	if w.hidden == nil {
+		w.hidden = make([]bool, len(w.widgets))
+	}
+	w.hidden[i] = hidden
+	if hidden {
+		if i == w.focus {
+			next, ok := w.FindNextSelectable(1, true)
+			if !ok {
+				next = -1
+			}
+			w.focus = next
+			w.prefCol = -1
+			w.unhideFocus, w.hideFocus = i, next
+			gowid.RunWidgetCallbacks(w.Callbacks, gowid.FocusCB{}, app, w)
+		}
+	} else if i == w.unhideFocus {
+		w.unhideFocus = -1
+		if w.focus == w.hideFocus {
+			w.SetFocus(app, i)
+		}
+	}
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.DimensionsCB{}, app, w)
+}
+
 func (w *Widget) Selectable() bool {
-	return gowid.SelectableIfAnySubWidgetsAre(w)
+	for i, widget := range w.widgets {
+		if !w.Hidden(i) && widget.Selectable() {
+			return true
+		}
+	}
+	return false
 }
 
 func (w *Widget) FindNextSelectable(dir gowid.Direction, wrap bool) (int, bool) {
-	return gowid.FindNextSelectableFrom(w, w.Focus(), dir, wrap)
+	dup := gowid.CopyWidgets(w.SubWidgets())
+	for i := range dup {
+		if w.Hidden(i) {
+			dup[i] = nil
+		}
+	}
+	return gowid.FindNextSelectableWidget(dup, w.Focus(), dir, wrap)
 }
 
 func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
@@ -252,13 +329,13 @@ func (w *Widget) SetPreferedPosition(cols int, app gowid.IApp) {
 	colLeft := col - 1
 	colRight := col
 	for colLeft >= 0 || colRight < len(w.widgets) {
-		if colRight < len(w.widgets) && w.widgets[colRight].Selectable() {
+		if colRight < len(w.widgets) && !w.Hidden(colRight) && w.widgets[colRight].Selectable() {
 			w.SetFocus(app, colRight)
 			break
 		} else {
 			colRight++
 		}
-		if colLeft >= 0 && w.widgets[colLeft].Selectable() {
+		if colLeft >= 0 && !w.Hidden(colLeft) && w.widgets[colLeft].Selectable() {
 			w.SetFocus(app, colLeft)
 			break
 		} else {
@@ -276,6 +353,48 @@ func (w *Widget) KeyIsRight(evk *tcell.EventKey) bool {
 	return vim.KeyIn(evk, w.opt.RightKeys)
 }
 
+// IHidden is implemented by widgets that can hide some of their children. The
+// layout functions in this package give a hidden child no columns.
+type IHidden interface {
+	Hidden(i int) bool
+}
+
+var _ IHidden = (*Widget)(nil)
+
+func isHidden(w interface{}, i int) bool {
+	if h, ok := w.(IHidden); ok {
+		return h.Hidden(i)
+	}
+	return false
+}
+
+// IConcurrentRender is implemented by widgets that can render their children in
+// parallel - see gowid.RenderConcurrently.
+type IConcurrentRender interface {
+	ConcurrentRender() bool
+}
+
+var _ IConcurrentRender = (*Widget)(nil)
+
+func (w *Widget) ConcurrentRender() bool {
+	return w.opt.ConcurrentRender
+}
+
+var _ gowid.ISelectionStyler = (*Widget)(nil)
+
+// SelectionStyle returns the style for a child rendered with sel - see Options.SelectedStyle
+// and Options.FocusedStyle.
+func (w *Widget) SelectionStyle(sel gowid.Selector) gowid.ICellStyler {
+	return gowid.SelectionStyle(sel, w.opt.SelectedStyle, w.opt.FocusedStyle)
+}
+
+func isConcurrent(w interface{}) bool {
+	if c, ok := w.(IConcurrentRender); ok {
+		return c.ConcurrentRender()
+	}
+	return false
+}
+
 type IWidthHelper interface {
 	WidthHelpers() ([]bool, []bool)
 }
@@ -337,7 +456,7 @@ func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Se
 
 	forChild := false
 
-	if subfocus != -1 {
+	if subfocus != -1 && !isHidden(w, subfocus) {
 		res = true
 		if evm, ok := ev.(*tcell.EventMouse); ok {
 			curX := 0
@@ -380,7 +499,7 @@ func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Se
 		}
 	}
 
-	if !forChild && w.Focus() != -1 {
+	if !forChild && w.Focus() != -1 && !isHidden(w, w.Focus()) {
 		res = false
 		if evk, ok := ev.(*tcell.EventKey); ok {
 
@@ -427,7 +546,94 @@ type ICompositeMultipleDimensionsExt interface {
 }
 
 func WidgetWidths(w ICompositeMultipleDimensionsExt, size gowid.IRenderSize, focus gowid.Selector, focusIdx int, app gowid.IApp) []int {
-	return widgetWidthsExt(w, w.SubWidgets(), w.Dimensions(), size, focus, focusIdx, app)
+	subs, dims := w.SubWidgets(), w.Dimensions()
+	for _, dim := range dims {
+		if _, ok := dim.(Span); ok {
+			return spanWidths(w, subs, dims, size, focus, focusIdx, app)
+		}
+	}
+	return widgetWidthsExt(w, subs, dims, size, focus, focusIdx, app)
+}
+
+// Span is the dimension of a child that spans several column slots, like an HTML table
+// cell's colspan. Each slot has a dimension of its own, and the child's width is the sum
+// of the slots' widths, worked out alongside the other children's - so a header row
+// whose children span the same slot dimensions as a body row's children lines up with
+// the body. A fixed slot takes the width of the child rendered fixed. A spanning child is
+// rendered flow, in the columns it's given.
+type Span struct {
+	Dims []gowid.IWidgetDimension
+}
+
+// NewSpan returns a dimension spanning slots with the given dimensions.
+func NewSpan(dims ...gowid.IWidgetDimension) Span {
+	return Span{Dims: dims}
+}
+
+// SpanOf returns a dimension spanning n slots, each with the same dimension - e.g. to
+// span n equally weighted columns.
+func SpanOf(n int, dim gowid.IWidgetDimension) Span {
+	dims := make([]gowid.IWidgetDimension, n)
+	for i := range dims {
+		dims[i] = dim
+	}
+	return Span{Dims: dims}
+}
+
+func (s Span) ImplementsWidgetDimension() {}
+func (s Span) Flow()                      {}
+
+func (s Span) String() string {
+	dims := make([]string, len(s.Dims))
+	for i, d := range s.Dims {
+		dims[i] = fmt.Sprintf("%v", d)
+	}
+	return fmt.Sprintf("span(%s)", strings.Join(dims, ","))
+}
+
+// spanSlots stands in for a columns widget while the widths of its slots are worked out,
+// mapping each slot back to the child that spans it.
+type spanSlots struct {
+	gowid.ISelectChild
+	owners []int
+}
+
+func (s spanSlots) Hidden(i int) bool {
+	return isHidden(s.ISelectChild, s.owners[i])
+}
+
+// spanWidths works out the widths of a widget's children when some span several slots,
+// by laying out the slots as though each were a child, then adding up each child's.
+func spanWidths(w gowid.ISelectChild, subs []gowid.IWidget, dims []gowid.IWidgetDimension, size gowid.IRenderSize, focus gowid.Selector, focusIdx int, app gowid.IApp) []int {
+	slotSubs := make([]gowid.IWidget, 0, len(subs))
+	slotDims := make([]gowid.IWidgetDimension, 0, len(dims))
+	owners := make([]int, 0, len(dims))
+	slotFocus := -1
+	var addSlots func(i int, dim gowid.IWidgetDimension)
+	addSlots = func(i int, dim gowid.IWidgetDimension) {
+		if span, ok := dim.(Span); ok {
+			for _, d := range span.Dims {
+				addSlots(i, d)
+			}
+			return
+		}
+		if i == focusIdx && slotFocus == -1 {
+			slotFocus = len(slotSubs)
+		}
+		slotSubs = append(slotSubs, subs[i])
+		slotDims = append(slotDims, dim)
+		owners = append(owners, i)
+	}
+	for i := range subs {
+		addSlots(i, dims[i])
+	}
+
+	slotWidths := widgetWidthsExt(spanSlots{ISelectChild: w, owners: owners}, slotSubs, slotDims, size, focus, slotFocus, app)
+	res := make([]int, len(subs))
+	for j, width := range slotWidths {
+		res[owners[j]] += width
+	}
+	return res
 }
 
 // Precompute dims and subs
@@ -476,6 +682,11 @@ func widgetWidthsExt(w gowid.ISelectChild, subs []gowid.IWidget, dims []gowid.IW
 		// This doesn't support IRenderFlow. That type comes with an associated width e.g.
 		// "Flow with 25 columns". We don't have any way to apportion those columns amongst
 		// the overall width for the widget.
+		if isHidden(w, i) {
+			widthHelper[i] = true
+			widthHelper2[i] = true
+			continue
+		}
 		switch w2 := dims[i].(type) {
 		case gowid.IRenderFixed:
 			c := gowid.RenderSize(subs[i], gowid.RenderFixed{}, focus.SelectIf(w.SelectChild(focus) && i == focusIdx), app)
@@ -594,6 +805,11 @@ func widgetWidthsExt(w gowid.ISelectChild, subs []gowid.IWidget, dims []gowid.IW
 }
 
 func RenderSize(w gowid.ICompositeMultipleWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	// Render fills the box, so there's no need to size the children
+	if box, ok := size.(gowid.IRenderBox); ok {
+		return gowid.RenderBox{C: box.BoxColumns(), R: box.BoxRows()}
+	}
+
 	subfocus := w.Focus()
 	sizes := w.RenderedSubWidgetsSizes(size, focus, subfocus, app)
 
@@ -634,6 +850,7 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 			res.AppendBelow(fc, false, false)
 		}
 		res.AppendRight(canvases[i], i == subfocus)
+		gowid.ReleaseCanvas(canvases[i])
 	}
 
 	if cols, ok := size.(gowid.IColumns); ok {
@@ -664,39 +881,73 @@ func RenderSubWidgets(w IWidget, size gowid.IRenderSize, focus gowid.Selector, f
 
 	weights := w.WidgetWidths(size, focus, focusIdx, app)
 
+	// Render the children at idxs, with the sizes at the same index in ssizes - in parallel
+	// if the widget asks for it
+	render := func(idxs []int, ssizes []gowid.IRenderSize) {
+		focuses := make([]gowid.Selector, len(idxs))
+		for j, i := range idxs {
+			focuses[j] = focus.SelectIf(w.SelectChild(focus) && i == focusIdx)
+		}
+		if isConcurrent(w) && len(idxs) > 1 {
+			ws := make([]gowid.IWidget, len(idxs))
+			for j, i := range idxs {
+				ws[j] = subs[i]
+			}
+			for j, c := range gowid.RenderConcurrently(ws, ssizes, focuses, app) {
+				canvases[idxs[j]] = c
+			}
+		} else {
+			for j, i := range idxs {
+				canvases[i] = gowid.RenderChild(subs[i], ssizes[j], focuses[j], app)
+			}
+		}
+		for j, i := range idxs {
+			gowid.ApplySelectionStyle(w, canvases[i], focuses[j], app)
+		}
+	}
+
 	maxes := make([]int, 0, l)
 	ssizes := make([]gowid.IRenderSize, 0, l)
+	others := make([]int, 0, l)
+	osizes := make([]gowid.IRenderSize, 0, l)
 	curMax := -1
 
 	for i := 0; i < l; i++ {
+		if isHidden(w, i) {
+			canvases[i] = gowid.NewCanvas()
+			continue
+		}
 		subSize := w.SubWidgetSize(size, weights[i], subs[i], dims[i])
 		if _, ok := dims[i].(gowid.IRenderMax); ok {
 			maxes = append(maxes, i)
 			ssizes = append(ssizes, subSize)
 		} else {
-			canvases[i] = subs[i].Render(subSize, focus.SelectIf(w.SelectChild(focus) && i == focusIdx), app)
-			if canvases[i].BoxRows() > curMax {
-				curMax = canvases[i].BoxRows()
-			}
+			others = append(others, i)
+			osizes = append(osizes, subSize)
 		}
 	}
 
-	if curMax == -1 {
+	render(others, osizes)
+	for _, i := range others {
+		if canvases[i].BoxRows() > curMax {
+			curMax = canvases[i].BoxRows()
+		}
+	}
+
+	if curMax == -1 && len(maxes) > 0 {
 		panic(AllChildrenMaxDimension)
 	}
 
 	for j := 0; j < len(maxes); j++ {
-		i := maxes[j]
-		var mss gowid.IRenderSize = ssizes[j]
-		switch css := mss.(type) {
+		switch css := ssizes[j].(type) {
 		case gowid.IRenderFlowWith:
-			mss = gowid.MakeRenderBox(css.FlowColumns(), curMax)
+			ssizes[j] = gowid.MakeRenderBox(css.FlowColumns(), curMax)
 		case gowid.IRenderBox:
-			mss = gowid.MakeRenderBox(css.BoxColumns(), curMax)
+			ssizes[j] = gowid.MakeRenderBox(css.BoxColumns(), curMax)
 		default:
 		}
-		canvases[i] = subs[i].Render(mss, focus.SelectIf(w.SelectChild(focus) && i == focusIdx), app)
 	}
+	render(maxes, ssizes)
 
 	return canvases
 }
@@ -716,6 +967,10 @@ func RenderedSubWidgetsSizes(w IWidget, size gowid.IRenderSize, focus gowid.Sele
 	curMax := -1
 
 	for i := 0; i < l; i++ {
+		if isHidden(w, i) {
+			res[i] = gowid.RenderBox{}
+			continue
+		}
 		subSize := w.SubWidgetSize(size, weights[i], subs[i], dims[i])
 
 		if _, ok := dims[i].(gowid.IRenderMax); ok {
@@ -731,7 +986,7 @@ func RenderedSubWidgetsSizes(w IWidget, size gowid.IRenderSize, focus gowid.Sele
 		}
 	}
 
-	if curMax == -1 {
+	if curMax == -1 && len(maxes) > 0 {
 		panic(AllChildrenMaxDimension)
 	}
 
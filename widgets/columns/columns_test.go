@@ -279,6 +279,157 @@ func TestColumns7(t *testing.T) {
 	assert.Equal(t, "aaabb \na     ", c.String())
 }
 
+func TestColumnsHidden1(t *testing.T) {
+	w := New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{selectable.New(fill.New('x')), gowid.RenderWithUnits{U: 2}},
+		&gowid.ContainerWidget{selectable.New(fill.New('y')), gowid.RenderWithWeight{1}},
+		&gowid.ContainerWidget{selectable.New(fill.New('z')), gowid.RenderWithUnits{U: 1}},
+	})
+	sz := gowid.RenderBox{C: 6, R: 2}
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "xxyyyz\nxxyyyz", c.String())
+
+	w.SetHidden(0, true, gwtest.D)
+	assert.True(t, w.Hidden(0))
+	assert.Equal(t, 1, w.Focus())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "yyyyyz\nyyyyyz", c.String())
+	assert.Equal(t, gowid.RenderWithUnits{U: 2}, w.Dimensions()[0])
+
+	// Focus can't move onto the hidden column
+	evleft := tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone)
+	assert.False(t, w.UserInput(evleft, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, 1, w.Focus())
+
+	// Focus returns to the column when it is shown
+	w.SetHidden(0, false, gwtest.D)
+	assert.Equal(t, 0, w.Focus())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "xxyyyz\nxxyyyz", c.String())
+
+	// ...unless focus moved elsewhere in the meantime
+	w.SetHidden(0, true, gwtest.D)
+	w.SetFocus(gwtest.D, 2)
+	w.SetHidden(0, false, gwtest.D)
+	assert.Equal(t, 2, w.Focus())
+
+	w.SetHidden(0, true, gwtest.D)
+	w.SetHidden(1, true, gwtest.D)
+	w.SetHidden(2, true, gwtest.D)
+	assert.False(t, w.Selectable())
+	assert.Equal(t, -1, w.Focus())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "      \n      ", c.String())
+}
+
+func TestColumnsSpan1(t *testing.T) {
+	slots := []gowid.IWidgetDimension{
+		gowid.RenderWithUnits{U: 2},
+		gowid.RenderWithWeight{W: 1},
+		gowid.RenderWithWeight{W: 2},
+		gowid.RenderWithUnits{U: 1},
+	}
+	body := New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{text.New("a"), slots[0]},
+		&gowid.ContainerWidget{text.New("b"), slots[1]},
+		&gowid.ContainerWidget{text.New("c"), slots[2]},
+		&gowid.ContainerWidget{text.New("d"), slots[3]},
+	})
+	header := New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{text.New("H"), NewSpan(slots[0], slots[1])},
+		&gowid.ContainerWidget{text.New("I"), NewSpan(slots[2:]...)},
+	})
+
+	sz := gowid.RenderFlowWith{C: 11}
+	assert.Equal(t, []int{2, 3, 5, 1}, body.WidgetWidths(sz, gowid.Focused, 0, gwtest.D))
+	assert.Equal(t, []int{5, 6}, header.WidgetWidths(sz, gowid.Focused, 0, gwtest.D))
+	assert.Equal(t, "a b  c    d", body.Render(sz, gowid.Focused, gwtest.D).String())
+	assert.Equal(t, "H    I     ", header.Render(sz, gowid.Focused, gwtest.D).String())
+
+	// Hiding a spanning child hides all its slots
+	header.SetHidden(0, true, gwtest.D)
+	assert.Equal(t, []int{0, 11}, header.WidgetWidths(sz, gowid.Focused, 0, gwtest.D))
+
+	w := New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{fill.New('x'), SpanOf(3, gowid.RenderWithWeight{W: 1})},
+		&gowid.ContainerWidget{fill.New('y'), gowid.RenderWithWeight{W: 1}},
+	})
+	c := w.Render(gowid.RenderBox{C: 8, R: 1}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "xxxxxxyy", c.String())
+	assert.Equal(t, "span(weight(1),weight(1),weight(1))", SpanOf(3, gowid.RenderWithWeight{W: 1}).String())
+}
+
+// panics panics when rendered, counting its renders.
+type panics struct {
+	gowid.IWidget
+	renders int
+}
+
+func (w *panics) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	w.renders++
+	panic("can't render")
+}
+
+func TestColumnsConcurrent1(t *testing.T) {
+	mk := func(concurrent bool) *Widget {
+		return New([]gowid.IContainerWidget{
+			&gowid.ContainerWidget{text.New("a\nb\nc"), gowid.RenderWithWeight{W: 1}},
+			&gowid.ContainerWidget{text.New("d"), gowid.RenderWithWeight{W: 1}},
+			&gowid.ContainerWidget{fill.New('x'), gowid.RenderWithUnits{U: 2}},
+			&gowid.ContainerWidget{fill.New('y'), gowid.RenderWithWeight{W: 1}},
+		}, Options{ConcurrentRender: concurrent})
+	}
+	sz := gowid.RenderFlowWith{C: 8}
+
+	w := mk(true)
+	assert.True(t, w.ConcurrentRender())
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "a d xxyy\nb       \nc       ", c.String())
+	assert.Equal(t, c.String(), mk(false).Render(sz, gowid.Focused, gwtest.D).String())
+
+	// A child panicking on another goroutine panics on this one, as it would without
+	// concurrency, without being rendered again
+	p := &panics{IWidget: text.New("b")}
+	w.SetSubWidgets([]gowid.IWidget{
+		&gowid.ContainerWidget{text.New("a"), gowid.RenderWithWeight{W: 1}},
+		&gowid.ContainerWidget{p, gowid.RenderWithWeight{W: 1}},
+	}, gwtest.D)
+	assert.PanicsWithValue(t, "can't render", func() { w.Render(sz, gowid.Focused, gwtest.D) })
+	assert.Equal(t, 1, p.renders)
+}
+
+func TestColumnsSelectionStyle1(t *testing.T) {
+	w := New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{selectable.New(text.New("a")), gowid.RenderWithUnits{U: 1}},
+		&gowid.ContainerWidget{selectable.New(text.New("b")), gowid.RenderWithUnits{U: 1}},
+	}, Options{
+		SelectedStyle: gowid.MakePaletteEntry(gowid.ColorRed, gowid.NoColor{}),
+		FocusedStyle:  gowid.MakeStyledAs(gowid.StyleReverse),
+	})
+	sz := gowid.RenderFixed{}
+
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(0, 0).Style())
+	assert.Equal(t, gowid.StyleNone, c.CellAt(1, 0).Style())
+
+	c = w.Render(sz, gowid.Selected, gwtest.D)
+	fg, _, st := c.CellAt(0, 0).GetDisplayAttrs()
+	assert.Equal(t, gowid.ColorRed, fg)
+	assert.Equal(t, gowid.StyleNone, st)
+	fg, _, _ = c.CellAt(1, 0).GetDisplayAttrs()
+	assert.Equal(t, gowid.ColorNone, fg)
+
+	// If the columns widget isn't selected, neither is its child
+	c = w.Render(sz, gowid.NotSelected, gwtest.D)
+	fg, _, _ = c.CellAt(0, 0).GetDisplayAttrs()
+	assert.Equal(t, gowid.ColorNone, fg)
+
+	w.SetFocus(gwtest.D, 1)
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, gowid.StyleNone, c.CellAt(0, 0).Style())
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(1, 0).Style())
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
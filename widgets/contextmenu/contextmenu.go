@@ -0,0 +1,376 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package contextmenu provides a widget that opens a popup menu when it is
+// right-clicked, or when a key is pressed. Menu items can open nested submenus.
+package contextmenu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/vim"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/menu"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+// Item is an entry in a context menu. If Items is not empty, choosing the entry opens
+// a submenu with those items; otherwise the menu is closed and Action, if not nil, is
+// called. An ampersand in the Label marks an accelerator, e.g. "&Copy" - the letter is
+// underlined, and pressing it with alt chooses the entry while the menu is open.
+type Item struct {
+	Label  string
+	Action func(app gowid.IApp)
+	Items  []Item
+}
+
+func (i Item) String() string {
+	return fmt.Sprintf("item[%s]", i.Label)
+}
+
+// Options can be supplied to New. Width is the width of each menu - if zero, menus are
+// made wide enough for their labels. OpenKeys open the menu at the widget's top-left
+// corner, and default to shift-F10. Menu items are rendered with Style, and the focus
+// item with FocusStyle. If Style is nil, items are shown in reverse video, except for
+// the focus item.
+type Options struct {
+	Width      int
+	OpenKeys   []vim.KeyPress
+	Style      gowid.ICellStyler
+	FocusStyle gowid.ICellStyler
+}
+
+var DefaultOpenKeys = []vim.KeyPress{
+	vim.NewKeyPress(tcell.KeyF10, 0, tcell.ModShift),
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	Open(x, y int, app gowid.IApp)
+	Close(app gowid.IApp)
+	IsOpen() bool
+}
+
+// SelectCB is the name under which callbacks are registered that run when a menu item
+// without a submenu is chosen. The callback's extra argument is the Item.
+type SelectCB struct{}
+
+// Widget wraps another widget, opening a menu when it is right-clicked. The menus are
+// registered with the app while they are open, so they are drawn over the whole view.
+type Widget struct {
+	inner gowid.IWidget
+	items []Item
+	opts  Options
+	root  *level // The open menu, or nil
+	namer menu.ISiteName
+	x, y  int // Where the menu opens, relative to the widget
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+	gowid.AddressProvidesID
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+var _ menu.ISite = (*Widget)(nil)
+
+func New(inner gowid.IWidget, items []Item, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.OpenKeys == nil {
+		opt.OpenKeys = DefaultOpenKeys
+	}
+	if opt.Style == nil {
+		opt.Style = gowid.MakeStyledAs(gowid.StyleReverse)
+	}
+	res := &Widget{
+		inner:     inner,
+		items:     items,
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("contextmenu[%v]", w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.inner
+}
+
+func (w *Widget) SetSubWidget(wi gowid.IWidget, app gowid.IApp) {
+	w.inner = wi
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+// Selectable returns true if the widget has a menu to open, so that it receives
+// right-clicks and the open keys even if the inner widget is not selectable.
+func (w *Widget) Selectable() bool {
+	return len(w.items) > 0 || w.inner.Selectable()
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Items() []Item {
+	return w.items
+}
+
+// SetItems changes the menu's items. They take effect the next time the menu opens.
+func (w *Widget) SetItems(items []Item, app gowid.IApp) {
+	w.items = items
+}
+
+// Namer lets the widget act as the site at which the menu opens.
+func (w *Widget) Namer() menu.ISiteName {
+	return w.namer
+}
+
+func (w *Widget) SetNamer(m menu.ISiteName, app gowid.IApp) {
+	w.namer = m
+}
+
+// IsOpen returns true if the menu is open.
+func (w *Widget) IsOpen() bool {
+	return w.root != nil
+}
+
+// Open opens the menu with its top-left corner at column x and row y of the widget,
+// closing it first if it's already open.
+func (w *Widget) Open(x, y int, app gowid.IApp) {
+	w.Close(app)
+	if len(w.items) == 0 {
+		return
+	}
+	w.x, w.y = x, y
+	w.root = w.newLevel(w.items, nil)
+	w.root.menu.Open(w, app)
+}
+
+// Close closes the menu and any open submenus.
+func (w *Widget) Close(app gowid.IApp) {
+	if w.root != nil {
+		w.root.menu.Close(app)
+	}
+}
+
+func (w *Widget) OnSelect(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, SelectCB{}, f)
+}
+
+func (w *Widget) RemoveOnSelect(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, SelectCB{}, f)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(w.inner, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	res := gowid.RenderChild(w.inner, size, focus, app)
+	if w.namer != nil {
+		res.SetMark(w.namer.Name(), w.x, w.y)
+	}
+	return res
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+// UserInput opens the menu when the right mouse button is pressed over the widget.
+// Other input goes to the inner widget first; if it isn't used, the open keys open
+// the menu.
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if evm, ok := ev.(*tcell.EventMouse); ok && evm.Buttons() == tcell.Button3 {
+		x, y := evm.Position()
+		box := gowid.RenderSize(w, size, focus, app)
+		if x >= 0 && y >= 0 && x < box.BoxColumns() && y < box.BoxRows() {
+			if !app.GetLastMouseState().RightIsClicked() {
+				w.Open(x, y, app)
+			}
+			return true
+		}
+	}
+	if gowid.UserInputIfSelectable(w.inner, ev, size, focus, app) {
+		return true
+	}
+	if evk, ok := ev.(*tcell.EventKey); ok && vim.KeyIn(evk, w.opts.OpenKeys) {
+		w.Open(0, 0, app)
+		return true
+	}
+	return false
+}
+
+//======================================================================
+
+// level is one open menu - the top-level menu, or a submenu.
+type level struct {
+	owner  *Widget
+	items  []Item
+	menu   *menu.Widget
+	sites  []*menu.SiteWidget // Where each item's submenu opens
+	rows   *pile.Widget
+	parent *level
+	child  *level // The open submenu, or nil
+}
+
+func (w *Widget) newLevel(items []Item, parent *level) *level {
+	res := &level{
+		owner:  w,
+		items:  items,
+		sites:  make([]*menu.SiteWidget, len(items)),
+		parent: parent,
+	}
+
+	width := w.opts.Width
+	if width <= 0 {
+		for _, item := range items {
+			label, _, _ := gowid.ParseAccelerator(item.Label)
+			width = gwutil.Max(width, runewidth.StringWidth(label))
+		}
+		width += 4 // leading space, and submenu marker with a space either side
+	}
+
+	rows := make([]interface{}, len(items))
+	for i, item := range items {
+		i := i
+		// Pad the label so the row covers whatever is under the menu
+		plain, _, _ := gowid.ParseAccelerator(item.Label)
+		label := " " + item.Label
+		label += strings.Repeat(" ", gwutil.Max(0, width-3-runewidth.StringWidth(plain)))
+		btn := button.NewWithAccelerator(label, button.Options{Decoration: button.BareDecoration})
+		btn.OnClick(gowid.WidgetCallback{"cb", func(app gowid.IApp, _ gowid.IWidget) {
+			res.choose(i, app)
+		}})
+		marker := "  "
+		if len(item.Items) > 0 {
+			marker = "> "
+		}
+		res.sites[i] = menu.NewSite()
+		row := columns.New([]gowid.IContainerWidget{
+			&gowid.ContainerWidget{IWidget: btn, D: gowid.RenderWithWeight{W: 1}},
+			&gowid.ContainerWidget{IWidget: text.New(marker), D: gowid.RenderFixed{}},
+			&gowid.ContainerWidget{IWidget: res.sites[i], D: gowid.RenderFixed{}},
+		})
+		rows[i] = styled.NewExt(row, w.opts.Style, w.opts.FocusStyle)
+	}
+	res.rows = pile.NewFlow(rows...)
+
+	depth := 0
+	for p := parent; p != nil; p = p.parent {
+		depth++
+	}
+	res.menu = menu.New(fmt.Sprintf("contextmenu-%p-%d", w, depth), &levelWidget{res.rows, res},
+		gowid.RenderWithUnits{U: width},
+		menu.Options{
+			OpenCloser:   menu.OpenerFunc(res.openClose),
+			KeepOnScreen: true,
+		},
+	)
+	return res
+}
+
+// openClose registers the level's menu with the app while it is open.
+func (l *level) openClose(open bool, mu *menu.Widget, site menu.ISite, app gowid.IApp) bool {
+	if open {
+		if !mu.IsOpen() {
+			app.RegisterMenu(mu)
+		}
+		mu.OpenImpl(site, app)
+		return true
+	}
+	if !mu.IsOpen() {
+		return false
+	}
+	if l.child != nil {
+		l.child.menu.Close(app)
+	}
+	mu.CloseImpl(app)
+	app.UnregisterMenu(mu)
+	if l.parent == nil {
+		if l.owner.root == l {
+			l.owner.root = nil
+		}
+	} else if l.parent.child == l {
+		l.parent.child = nil
+	}
+	return true
+}
+
+// choose opens the item's submenu, or closes all the menus and runs the item's action.
+func (l *level) choose(i int, app gowid.IApp) {
+	item := l.items[i]
+	if len(item.Items) > 0 {
+		l.openChild(i, app)
+		return
+	}
+	l.owner.Close(app)
+	if item.Action != nil {
+		item.Action(app)
+	}
+	gowid.RunWidgetCallbacks(l.owner.Callbacks, SelectCB{}, app, l.owner, item)
+}
+
+func (l *level) openChild(i int, app gowid.IApp) {
+	if l.child != nil {
+		l.child.menu.Close(app)
+	}
+	l.child = l.owner.newLevel(l.items[i].Items, l)
+	l.child.menu.Open(l.sites[i], app)
+}
+
+// levelWidget holds the rows of a menu, and opens the focus item's submenu with the
+// right cursor key.
+type levelWidget struct {
+	gowid.IWidget
+	level *level
+}
+
+func (w *levelWidget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if evk, ok := ev.(*tcell.EventKey); ok && evk.Key() == tcell.KeyRight {
+		if i := w.level.rows.Focus(); i >= 0 && len(w.level.items[i].Items) > 0 {
+			w.level.openChild(i, app)
+			return true
+		}
+	}
+	return w.IWidget.UserInput(ev, size, focus, app)
+}
+
+func (w *levelWidget) String() string {
+	labels := make([]string, len(w.level.items))
+	for i, item := range w.level.items {
+		labels[i], _, _ = gowid.ParseAccelerator(item.Label)
+	}
+	return fmt.Sprintf("contextmenu-level[%s]", strings.Join(labels, ","))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
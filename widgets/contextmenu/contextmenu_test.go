@@ -0,0 +1,118 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package contextmenu
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/fill"
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func screenRows(screen tcell.SimulationScreen) []string {
+	cells, width, height := screen.GetContents()
+	res := make([]string, height)
+	for y := 0; y < height; y++ {
+		var sb strings.Builder
+		for x := 0; x < width; x++ {
+			r := cells[y*width+x].Runes
+			if len(r) == 0 {
+				sb.WriteRune(' ')
+			} else {
+				sb.WriteRune(r[0])
+			}
+		}
+		res[y] = sb.String()
+	}
+	return res
+}
+
+func TestContextMenu1(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(20, 5)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+
+	var chosen []string
+	action := func(name string) func(gowid.IApp) {
+		return func(app gowid.IApp) {
+			chosen = append(chosen, name)
+		}
+	}
+
+	cm := New(fill.New('.'), []Item{
+		{Label: "Cut", Action: action("cut")},
+		{Label: "More", Items: []Item{
+			{Label: "Zap", Action: action("zap")},
+		}},
+	})
+	selected := 0
+	cm.OnSelect(gowid.WidgetCallback{"cb", func(app gowid.IApp, w gowid.IWidget) {
+		selected++
+	}})
+
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen: screen,
+		View:   cm,
+		Log:    logger,
+	})
+	assert.NoError(t, err)
+	app.RedrawTerminal()
+	assert.Equal(t, "....................", screenRows(screen)[1])
+
+	app.HandleTCellEvent(tcell.NewEventMouse(2, 1, tcell.Button3, 0), gowid.IgnoreUnhandledInput)
+	app.HandleTCellEvent(tcell.NewEventMouse(2, 1, tcell.ButtonNone, 0), gowid.IgnoreUnhandledInput)
+	app.RedrawTerminal()
+	assert.True(t, cm.IsOpen())
+	rows := screenRows(screen)
+	assert.Equal(t, ".. Cut    ..........", rows[1])
+	assert.Equal(t, ".. More > ..........", rows[2])
+
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	app.RedrawTerminal()
+	rows = screenRows(screen)
+	assert.Equal(t, ".. More >  Zap   ...", rows[2])
+
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	app.RedrawTerminal()
+	assert.False(t, cm.IsOpen())
+	assert.Equal(t, []string{"zap"}, chosen)
+	assert.Equal(t, 1, selected)
+	assert.Equal(t, "....................", screenRows(screen)[2])
+
+	// Open from the keyboard, at the top-left corner
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyF10, 0, tcell.ModShift), gowid.IgnoreUnhandledInput)
+	app.RedrawTerminal()
+	assert.True(t, cm.IsOpen())
+	assert.Equal(t, " Cut    ............", screenRows(screen)[0])
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	assert.False(t, cm.IsOpen())
+	assert.Equal(t, []string{"zap", "cut"}, chosen)
+	assert.Equal(t, 2, selected)
+
+	// Near the bottom-right corner, the menu is moved so it fits on the screen
+	app.HandleTCellEvent(tcell.NewEventMouse(18, 4, tcell.Button3, 0), gowid.IgnoreUnhandledInput)
+	app.HandleTCellEvent(tcell.NewEventMouse(18, 4, tcell.ButtonNone, 0), gowid.IgnoreUnhandledInput)
+	rows = screenRows(screen)
+	assert.Equal(t, "............ Cut    ", rows[2])
+	assert.Equal(t, "............ More > ", rows[3])
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	assert.False(t, cm.IsOpen())
+	assert.Equal(t, "....................", screenRows(screen)[3])
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
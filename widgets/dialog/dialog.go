@@ -51,6 +51,12 @@ type IModal interface {
 	IsModal() bool
 }
 
+// IBackdrop is implemented by dialogs that blend the widgets behind them when open -
+// e.g. to dim them.
+type IBackdrop interface {
+	Backdrop() gowid.Blend
+}
+
 type IMaximizer interface {
 	IsMaxed() bool
 	Maximize(gowid.IApp)
@@ -79,10 +85,13 @@ var _ IWidget = (*Widget)(nil)
 var _ IMaximizer = (*Widget)(nil)
 var _ ISwitchFocus = (*Widget)(nil)
 var _ IModal = (*Widget)(nil)
+var _ IBackdrop = (*Widget)(nil)
 
 type Options struct {
 	Buttons         []Button
 	NoShadow        bool
+	DimShadow       bool // Dim what's underneath rather than drawing a solid shadow
+	Rounded         bool // Use a frame with rounded corners
 	NoEscapeClose   bool
 	ButtonStyle     gowid.ICellStyler
 	BackgroundStyle gowid.ICellStyler
@@ -92,6 +101,7 @@ type Options struct {
 	Modal           bool
 	TabToButtons    bool
 	StartIdx        int
+	Backdrop        gowid.Blend // Blend applied to what's behind the dialog, e.g. BlendDarken to dim it
 }
 
 type Button struct {
@@ -174,7 +184,7 @@ func New(content gowid.IWidget, opts ...Options) *Widget {
 
 	if len(opts) > 0 {
 		for i, b := range opts[0].Buttons {
-			bw := button.New(text.New(b.Msg))
+			bw := button.New(text.New(gowid.Translate(b.Msg)))
 			if b.Action == nil {
 				bw.OnClick(gowid.WidgetCallback{fmt.Sprintf("cb-%d", i),
 					func(app gowid.IApp, widget gowid.IWidget) {
@@ -217,6 +227,9 @@ func New(content gowid.IWidget, opts ...Options) *Widget {
 			Frame: framed.UnicodeAltFrame,
 			Style: borderStyle,
 		}
+		if opt.Rounded {
+			frameOpts.Frame = framed.RoundedFrame
+		}
 		d = framed.New(d, frameOpts)
 	}
 
@@ -228,7 +241,7 @@ func New(content gowid.IWidget, opts ...Options) *Widget {
 	)
 
 	if !opt.NoShadow {
-		d = shadow.New(d, 1)
+		d = shadow.NewExt(d, 1, shadow.Options{Dim: opt.DimShadow})
 	}
 
 	res = &Widget{
@@ -273,6 +286,10 @@ func (w *Widget) IsModal() bool {
 	return w.Options.Modal
 }
 
+func (w *Widget) Backdrop() gowid.Blend {
+	return w.Options.Backdrop
+}
+
 func (w *Widget) SwitchFocus(app gowid.IApp) {
 	f := w.content.Focus()
 	if f == 0 {
@@ -403,10 +420,15 @@ type IOpenExt interface {
 }
 
 func OpenExt(w IOpenExt, container gowid.ISettableComposite, width gowid.IWidgetDimension, height gowid.IWidgetDimension, app gowid.IApp) {
+	var backdrop gowid.Blend
+	if wb, ok := w.(IBackdrop); ok {
+		backdrop = wb.Backdrop()
+	}
 	ov := overlay.New(w, container.SubWidget(),
 		gowid.VAlignMiddle{}, height, // Intended to mean use as much vertical space as you need
 		gowid.HAlignMiddle{}, width, overlay.Options{
 			IgnoreLowerStyle: true,
+			BottomBlend:      backdrop,
 		})
 
 	if _, ok := width.(gowid.IRenderFixed); ok {
@@ -0,0 +1,514 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package diff provides a widget that shows the differences between two texts, either
+// as a unified diff or side by side, with changes within lines highlighted.
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+type Mode int
+
+const (
+	Unified Mode = iota
+	SideBySide
+)
+
+// Options can be supplied to New. Context is the number of unchanged lines shown
+// around each change; if zero, 3 is used, and if negative, none are. Styles left nil
+// take defaults. The highlight styles are used for the parts of a changed line that
+// differ from the line it replaces.
+type Options struct {
+	Mode                 Mode
+	Context              int
+	LineNumbers          bool
+	TabWidth             int
+	HeaderStyle          gowid.ICellStyler
+	DeleteStyle          gowid.ICellStyler
+	InsertStyle          gowid.ICellStyler
+	DeleteHighlightStyle gowid.ICellStyler
+	InsertHighlightStyle gowid.ICellStyler
+}
+
+type IDiff interface {
+	Mode() Mode
+	Hunks() []Hunk
+	// CurrentHunk returns the index of the hunk at the top of the view.
+	CurrentHunk() int
+}
+
+type IWidget interface {
+	gowid.IWidget
+	IDiff
+}
+
+type ModeCB struct{}
+
+// side is one line of one of the texts, as displayed in a row.
+type side struct {
+	kind  OpKind
+	no    int    // one-based line number; 0 if there's no line on this side
+	text  []rune // after tab expansion
+	marks []bool // runes that differ from the paired line, if any
+}
+
+// row is one line of the rendered diff. In unified mode only left is used.
+type row struct {
+	header string
+	left   side
+	right  side
+}
+
+// Widget displays a diff. It is selectable - the cursor keys and mouse wheel scroll,
+// and "n" and "p" move to the next and previous hunk.
+type Widget struct {
+	a, b   []string // the old and new lines
+	opts   Options
+	ops    []Op
+	hunks  []Hunk
+	rows   []row
+	starts []int // row of each hunk's header
+	top    int
+	*gowid.Callbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+// New returns a widget showing the differences between two texts, compared line by line.
+func New(oldText, newText string, opts ...Options) *Widget {
+	return NewFromLines(splitLines(oldText), splitLines(newText), opts...)
+}
+
+func NewFromLines(oldLines, newLines []string, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	res := &Widget{
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.setLines(oldLines, newLines)
+	return res
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("diff[%d hunks]", len(w.hunks))
+}
+
+// SetText replaces the texts compared.
+func (w *Widget) SetText(oldText, newText string, app gowid.IApp) {
+	w.SetLines(splitLines(oldText), splitLines(newText), app)
+}
+
+func (w *Widget) SetLines(oldLines, newLines []string, app gowid.IApp) {
+	w.setLines(oldLines, newLines)
+	w.top = 0
+}
+
+func (w *Widget) setLines(oldLines, newLines []string) {
+	w.a, w.b = oldLines, newLines
+	w.ops = Compute(oldLines, newLines)
+	context := w.opts.Context
+	if context == 0 {
+		context = 3
+	} else if context < 0 {
+		context = 0
+	}
+	w.hunks = Hunks(w.ops, context)
+	w.layout()
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Mode() Mode {
+	return w.opts.Mode
+}
+
+// SetMode switches between unified and side-by-side views, keeping the current hunk
+// in view.
+func (w *Widget) SetMode(mode Mode, app gowid.IApp) {
+	if mode == w.opts.Mode {
+		return
+	}
+	hunk := w.CurrentHunk()
+	w.opts.Mode = mode
+	w.layout()
+	if hunk < len(w.starts) {
+		w.top = w.starts[hunk]
+	}
+	gowid.RunWidgetCallbacks(w.Callbacks, ModeCB{}, app, w)
+}
+
+func (w *Widget) OnModeChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, ModeCB{}, f)
+}
+
+func (w *Widget) RemoveOnModeChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, ModeCB{}, f)
+}
+
+func (w *Widget) Ops() []Op {
+	return w.ops
+}
+
+func (w *Widget) Hunks() []Hunk {
+	return w.hunks
+}
+
+func (w *Widget) CurrentHunk() int {
+	res := 0
+	for i, start := range w.starts {
+		if start <= w.top {
+			res = i
+		}
+	}
+	return res
+}
+
+// NextHunk scrolls the next hunk to the top of the view. It returns false if there
+// are no more hunks.
+func (w *Widget) NextHunk(app gowid.IApp) bool {
+	for _, start := range w.starts {
+		if start > w.top {
+			w.top = start
+			return true
+		}
+	}
+	return false
+}
+
+// PrevHunk scrolls the previous hunk to the top of the view. It returns false if
+// there are no earlier hunks.
+func (w *Widget) PrevHunk(app gowid.IApp) bool {
+	for i := len(w.starts) - 1; i >= 0; i-- {
+		if w.starts[i] < w.top {
+			w.top = w.starts[i]
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Widget) tabWidth() int {
+	if w.opts.TabWidth <= 0 {
+		return 4
+	}
+	return w.opts.TabWidth
+}
+
+func (w *Widget) expand(line string) []rune {
+	return []rune(strings.ReplaceAll(line, "\t", strings.Repeat(" ", w.tabWidth())))
+}
+
+// layout builds the rows to display from the hunks.
+func (w *Widget) layout() {
+	w.rows = w.rows[:0]
+	w.starts = w.starts[:0]
+	for _, h := range w.hunks {
+		w.starts = append(w.starts, len(w.rows))
+		w.rows = append(w.rows, row{
+			header: fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines),
+		})
+		ops := w.ops[h.From:h.To]
+		for i := 0; i < len(ops); {
+			if ops[i].Kind == Equal {
+				l := side{kind: Equal, no: ops[i].A + 1, text: w.expand(w.a[ops[i].A])}
+				r := side{kind: Equal, no: ops[i].B + 1, text: w.expand(w.b[ops[i].B])}
+				w.rows = append(w.rows, row{left: l, right: r})
+				i++
+				continue
+			}
+			dels := make([]side, 0, 4)
+			ins := make([]side, 0, 4)
+			for ; i < len(ops) && ops[i].Kind != Equal; i++ {
+				if ops[i].Kind == Delete {
+					dels = append(dels, side{kind: Delete, no: ops[i].A + 1, text: w.expand(w.a[ops[i].A])})
+				} else {
+					ins = append(ins, side{kind: Insert, no: ops[i].B + 1, text: w.expand(w.b[ops[i].B])})
+				}
+			}
+			for j := 0; j < len(dels) && j < len(ins); j++ {
+				dels[j].marks, ins[j].marks = intraLine(dels[j].text, ins[j].text)
+			}
+			if w.opts.Mode == SideBySide {
+				for j := 0; j < len(dels) || j < len(ins); j++ {
+					var r row
+					if j < len(dels) {
+						r.left = dels[j]
+					}
+					if j < len(ins) {
+						r.right = ins[j]
+					}
+					w.rows = append(w.rows, r)
+				}
+			} else {
+				for _, s := range append(dels, ins...) {
+					w.rows = append(w.rows, row{left: s})
+				}
+			}
+		}
+	}
+}
+
+// intraLine marks the runes of a and b that differ, if the lines are similar enough
+// for that to be useful; otherwise it returns nil.
+func intraLine(a, b []rune) ([]bool, []bool) {
+	ops := Compute(a, b)
+	same := 0
+	for _, op := range ops {
+		if op.Kind == Equal {
+			same++
+		}
+	}
+	if same*2 < gwutil.Max(len(a), len(b)) {
+		return nil, nil
+	}
+	am := make([]bool, len(a))
+	bm := make([]bool, len(b))
+	for _, op := range ops {
+		switch op.Kind {
+		case Delete:
+			am[op.A] = true
+		case Insert:
+			bm[op.B] = true
+		}
+	}
+	return am, bm
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+var (
+	defaultHeaderStyle          = gowid.MakeForeground(gowid.ColorCyan)
+	defaultDeleteStyle          = gowid.MakeForeground(gowid.ColorRed)
+	defaultInsertStyle          = gowid.MakeForeground(gowid.ColorGreen)
+	defaultDeleteHighlightStyle = gowid.MakeStyledPaletteEntry(gowid.ColorRed, gowid.NoColor{}, gowid.StyleReverse)
+	defaultInsertHighlightStyle = gowid.MakeStyledPaletteEntry(gowid.ColorGreen, gowid.NoColor{}, gowid.StyleReverse)
+)
+
+func styleOr(s gowid.ICellStyler, def gowid.ICellStyler) gowid.ICellStyler {
+	if s == nil {
+		return def
+	}
+	return s
+}
+
+// styles returns the styles for the text and highlighted text of a line of the given kind.
+func (w *Widget) styles(kind OpKind) (gowid.ICellStyler, gowid.ICellStyler) {
+	switch kind {
+	case Delete:
+		return styleOr(w.opts.DeleteStyle, defaultDeleteStyle), styleOr(w.opts.DeleteHighlightStyle, defaultDeleteHighlightStyle)
+	case Insert:
+		return styleOr(w.opts.InsertStyle, defaultInsertStyle), styleOr(w.opts.InsertHighlightStyle, defaultInsertHighlightStyle)
+	default:
+		return nil, nil
+	}
+}
+
+// numberWidth returns the number of columns needed for the largest line number.
+func numberWidth(w *Widget) int {
+	if !w.opts.LineNumbers {
+		return 0
+	}
+	return len(strconv.Itoa(gwutil.Max(len(w.a), len(w.b))))
+}
+
+// lineNumber formats a line number to fill width columns followed by a space, or just
+// the space if the line is absent on this side.
+func lineNumber(no int, width int) string {
+	if width == 0 {
+		return ""
+	}
+	if no == 0 {
+		return strings.Repeat(" ", width+1)
+	}
+	return fmt.Sprintf("%*d ", width, no)
+}
+
+// sideContent returns the content for one side of a row, with the line number(s) given
+// as prefix.
+func (w *Widget) sideContent(s side, prefix string) *text.Content {
+	style, hl := w.styles(s.kind)
+	segs := []text.ContentSegment{text.StyledContent(prefix, style)}
+	for i := 0; i < len(s.text); {
+		j := i + 1
+		marked := s.marks != nil && s.marks[i]
+		for j < len(s.text) && (s.marks != nil && s.marks[j]) == marked {
+			j++
+		}
+		st := style
+		if marked {
+			st = hl
+		}
+		segs = append(segs, text.StyledContent(string(s.text[i:j]), st))
+		i = j
+	}
+	return text.NewContent(segs)
+}
+
+// fill renders content into cells, clipping it if it's too wide.
+func fill(cells []gowid.Cell, content *text.Content, app gowid.IApp) {
+	all := make([]gowid.Cell, content.Width())
+	content.RangeOver(0, content.Length(), app, &text.ContentToCellArray{Cells: all})
+	copy(cells, all)
+}
+
+func RenderSize(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	switch sz := size.(type) {
+	case gowid.IRenderBox:
+		return gowid.RenderBox{C: sz.BoxColumns(), R: sz.BoxRows()}
+	case gowid.IRenderFlowWith:
+		return gowid.RenderBox{C: sz.FlowColumns(), R: len(w.rows)}
+	default:
+		panic(gowid.WidgetSizeError{Widget: w, Size: size, Required: "gowid.IRenderBox or gowid.IRenderFlowWith"})
+	}
+}
+
+// topRow returns the first row to display in a widget of the given height.
+func topRow(w *Widget, rows int) int {
+	return gwutil.Max(0, gwutil.Min(w.top, len(w.rows)-rows))
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	box := RenderSize(w, size, focus, app)
+	cols, rows := box.BoxColumns(), box.BoxRows()
+	nw := numberWidth(w)
+	top := topRow(w, rows)
+	// In side-by-side mode, the left half is the old text, then a separator
+	half := (cols - 1) / 2
+
+	res := gowid.NewCanvas()
+	for y := 0; y < rows; y++ {
+		line := make([]gowid.Cell, cols)
+		if top+y < len(w.rows) {
+			r := w.rows[top+y]
+			switch {
+			case r.header != "":
+				fill(line, text.NewContent([]text.ContentSegment{
+					text.StyledContent(r.header, styleOr(w.opts.HeaderStyle, defaultHeaderStyle)),
+				}), app)
+			case w.opts.Mode == SideBySide:
+				if r.left.no != 0 {
+					fill(line[:half], w.sideContent(r.left, lineNumber(r.left.no, nw)), app)
+				}
+				if half < cols {
+					line[half] = gowid.CellFromRune('│')
+				}
+				if r.right.no != 0 && half+1 < cols {
+					fill(line[half+1:], w.sideContent(r.right, lineNumber(r.right.no, nw)), app)
+				}
+			default:
+				s := r.left
+				var oldNo, newNo int
+				var mark string
+				switch s.kind {
+				case Equal:
+					oldNo, newNo, mark = s.no, r.right.no, " "
+				case Delete:
+					oldNo, mark = s.no, "-"
+				case Insert:
+					newNo, mark = s.no, "+"
+				}
+				prefix := lineNumber(oldNo, nw) + lineNumber(newNo, nw) + mark
+				fill(line, w.sideContent(s, prefix), app)
+			}
+		}
+		res.AppendLine(line, false)
+	}
+	return res
+}
+
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	rows := RenderSize(w, size, focus, app).BoxRows()
+	w.top = topRow(w, rows)
+	top := w.top
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyUp:
+			top--
+		case tcell.KeyDown:
+			top++
+		case tcell.KeyPgUp:
+			top -= gwutil.Max(1, rows-1)
+		case tcell.KeyPgDn:
+			top += gwutil.Max(1, rows-1)
+		case tcell.KeyHome:
+			top = 0
+		case tcell.KeyEnd:
+			top = len(w.rows)
+		case tcell.KeyRune:
+			switch ev.Rune() {
+			case 'n':
+				return w.NextHunk(app)
+			case 'p':
+				return w.PrevHunk(app)
+			default:
+				return false
+			}
+		default:
+			return false
+		}
+	case *tcell.EventMouse:
+		switch ev.Buttons() {
+		case tcell.WheelUp:
+			top--
+		case tcell.WheelDown:
+			top++
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	top = gwutil.Max(0, gwutil.Min(top, len(w.rows)-rows))
+	if top == w.top {
+		return false
+	}
+	w.top = top
+	return true
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
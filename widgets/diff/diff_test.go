@@ -0,0 +1,118 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func apply(a, b []string, ops []Op) []string {
+	res := make([]string, 0)
+	for _, op := range ops {
+		switch op.Kind {
+		case Equal:
+			res = append(res, a[op.A])
+		case Insert:
+			res = append(res, b[op.B])
+		}
+	}
+	return res
+}
+
+func TestCompute1(t *testing.T) {
+	for _, c := range [][2]string{
+		{"abcabba", "cbabac"},
+		{"", "abc"},
+		{"abc", ""},
+		{"", ""},
+		{"same", "same"},
+	} {
+		a, b := strings.Split(c[0], ""), strings.Split(c[1], "")
+		ops := Compute(a, b)
+		assert.Equal(t, b, apply(a, b, ops), c)
+	}
+	// abcabba -> cbabac needs 5 edits
+	ops := Compute([]rune("abcabba"), []rune("cbabac"))
+	edits := 0
+	for _, op := range ops {
+		if op.Kind != Equal {
+			edits++
+		}
+	}
+	assert.Equal(t, 5, edits)
+}
+
+func TestHunks1(t *testing.T) {
+	a := strings.Split("1 2 3 4 5 6 7 8 9 10 11 12", " ")
+	b := strings.Split("1 2 x 4 5 6 7 8 9 10 11 y", " ")
+	hunks := Hunks(Compute(a, b), 2)
+	assert.Equal(t, 2, len(hunks))
+	assert.Equal(t, Hunk{From: 0, To: 6, OldStart: 1, OldLines: 5, NewStart: 1, NewLines: 5}, hunks[0])
+	assert.Equal(t, 10, hunks[1].OldStart)
+	assert.Equal(t, 3, hunks[1].OldLines)
+
+	hunks = Hunks(Compute(a, b), 4)
+	assert.Equal(t, 1, len(hunks))
+}
+
+func TestDiff1(t *testing.T) {
+	w := New("one\ntwo\nthree\n", "one\ntwo!\nthree\nfour\n", Options{Context: 1})
+	c := w.Render(gowid.RenderFlowWith{C: 18}, gowid.Focused, gwtest.D)
+	assert.Equal(t, strings.Join([]string{
+		"@@ -1,3 +1,4 @@   ",
+		" one              ",
+		"-two              ",
+		"+two!             ",
+		" three            ",
+		"+four             ",
+	}, "\n"), c.String())
+
+	red := gowid.IColorToTCell(gowid.ColorRed, gowid.ColorNone, gwtest.D.GetColorMode())
+	assert.Equal(t, red, c.CellAt(0, 2).ForegroundColor())
+	assert.Equal(t, gowid.StyleNone, c.CellAt(1, 3).Style())
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(4, 3).Style())
+
+	w.SetMode(SideBySide, gwtest.D)
+	assert.Equal(t, SideBySide, w.Mode())
+	w2 := New("one\ntwo\nthree\n", "one\ntwo!\nthree\nfour\n", Options{Context: 1, Mode: SideBySide, LineNumbers: true})
+	c = w2.Render(gowid.RenderFlowWith{C: 17}, gowid.Focused, gwtest.D)
+	assert.Equal(t, strings.Join([]string{
+		"@@ -1,3 +1,4 @@  ",
+		"1 one   │1 one   ",
+		"2 two   │2 two!  ",
+		"3 three │3 three ",
+		"        │4 four  ",
+	}, "\n"), c.String())
+}
+
+func TestNavigate1(t *testing.T) {
+	a := strings.Split("1 2 3 4 5 6 7 8 9 10 11 12", " ")
+	b := strings.Split("x 2 3 4 5 6 7 8 9 10 11 y", " ")
+	w := NewFromLines(a, b, Options{Context: 1})
+	assert.Equal(t, 2, len(w.Hunks()))
+	sz := gowid.RenderBox{C: 10, R: 2}
+	n := tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone)
+	p := tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone)
+	assert.True(t, w.UserInput(n, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, 1, w.CurrentHunk())
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "@@ -11,2 +", strings.Split(c.String(), "\n")[0])
+	assert.False(t, w.UserInput(n, sz, gowid.Focused, gwtest.D))
+	assert.True(t, w.UserInput(p, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, 0, w.CurrentHunk())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
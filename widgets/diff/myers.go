@@ -0,0 +1,195 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package diff
+
+//======================================================================
+
+type OpKind int
+
+const (
+	Equal OpKind = iota
+	Delete
+	Insert
+)
+
+// Op is one step of an edit script turning sequence a into sequence b. A is the index
+// into a of an Equal or Delete element, B is the index into b of an Equal or Insert
+// element; the other index is the position at which the op applies.
+type Op struct {
+	Kind OpKind
+	A    int
+	B    int
+}
+
+// Compute returns a shortest edit script turning a into b, using Myers' O(ND)
+// algorithm. Within each run of changes, deletes come before inserts.
+func Compute[T comparable](a, b []T) []Op {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+	trace := make([][]int, 0, 16)
+
+found:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // move down i.e. insert
+			} else {
+				x = v[off+k-1] + 1 // move right i.e. delete
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break found
+			}
+		}
+	}
+
+	// Walk back through the trace to recover the path, collecting ops in reverse
+	res := make([]Op, 0, max)
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			res = append(res, Op{Kind: Equal, A: x, B: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				res = append(res, Op{Kind: Insert, A: x, B: prevY})
+			} else {
+				res = append(res, Op{Kind: Delete, A: prevX, B: y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return sortChanges(res)
+}
+
+// sortChanges reorders each run of non-equal ops so its deletes precede its inserts.
+func sortChanges(ops []Op) []Op {
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == Equal {
+			i++
+			continue
+		}
+		j := i
+		for j < len(ops) && ops[j].Kind != Equal {
+			j++
+		}
+		run := make([]Op, 0, j-i)
+		for _, op := range ops[i:j] {
+			if op.Kind == Delete {
+				run = append(run, op)
+			}
+		}
+		for _, op := range ops[i:j] {
+			if op.Kind == Insert {
+				run = append(run, op)
+			}
+		}
+		copy(ops[i:j], run)
+		i = j
+	}
+	return ops
+}
+
+//======================================================================
+
+// Hunk is a group of changes with surrounding context. From and To index the ops
+// returned by Compute. Line numbers are one-based, as in a unified diff header.
+type Hunk struct {
+	From, To int
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+}
+
+// Hunks groups the changes in ops into hunks, with up to context equal ops either side
+// of each change. Changes separated by no more than twice the context are put in the
+// same hunk.
+func Hunks(ops []Op, context int) []Hunk {
+	res := make([]Hunk, 0, 8)
+	for i := 0; i < len(ops); i++ {
+		if ops[i].Kind == Equal {
+			continue
+		}
+		from := i - context
+		if from < 0 {
+			from = 0
+		}
+		if len(res) > 0 && from <= res[len(res)-1].To {
+			// Close enough to extend the previous hunk
+			from = res[len(res)-1].From
+			res = res[:len(res)-1]
+		}
+		// Find the end of this run of changes
+		for i < len(ops) && ops[i].Kind != Equal {
+			i++
+		}
+		to := i + context
+		if to > len(ops) {
+			to = len(ops)
+		}
+		res = append(res, makeHunk(ops, from, to))
+		i--
+	}
+	return res
+}
+
+func makeHunk(ops []Op, from, to int) Hunk {
+	res := Hunk{From: from, To: to}
+	if from < len(ops) {
+		res.OldStart = ops[from].A + 1
+		res.NewStart = ops[from].B + 1
+	}
+	for _, op := range ops[from:to] {
+		switch op.Kind {
+		case Equal:
+			res.OldLines++
+			res.NewLines++
+		case Delete:
+			res.OldLines++
+		case Insert:
+			res.NewLines++
+		}
+	}
+	// By convention an empty range starts at the line before
+	if res.OldLines == 0 {
+		res.OldStart--
+	}
+	if res.NewLines == 0 {
+		res.NewStart--
+	}
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -3,6 +3,7 @@
 // file.
 
 // Package disable provides a widget that forces its inner widget to be disable (or enabled).
+// A disabled widget can't be selected, ignores input, and is rendered greyed out.
 package disable
 
 import (
@@ -13,6 +14,19 @@ import (
 
 //======================================================================
 
+// DefaultStyle is applied to the inner widget's canvas when it's disabled, unless
+// Options specifies otherwise - dark grey, dim text.
+var DefaultStyle gowid.ICellStyler = gowid.MakeStyledPaletteEntry(gowid.ColorDarkGray, gowid.NoColor{}, gowid.StyleDim)
+
+// Options can be supplied to the constructors. Style is applied over every cell of the
+// inner widget's canvas while it's disabled, so a whole section of a form can be
+// greyed out in one place; DefaultStyle is used if it's nil. If NoStyle is true, the
+// inner widget renders unchanged.
+type Options struct {
+	Style   gowid.ICellStyler
+	NoStyle bool
+}
+
 // If you would like a non-selectable widget like TextWidget to be selectable
 // in some context, wrap it in Widget
 //
@@ -21,24 +35,33 @@ type Widget struct {
 	*gowid.Callbacks
 	gowid.SubWidgetCallbacks
 	isDisabled bool
+	opts       Options
 }
 
-func New(w gowid.IWidget) *Widget {
-	return NewWith(w, true)
+func New(w gowid.IWidget, opts ...Options) *Widget {
+	return NewWith(w, true, opts...)
 }
 
-func NewDisabled(w gowid.IWidget) *Widget {
-	return NewWith(w, true)
+func NewDisabled(w gowid.IWidget, opts ...Options) *Widget {
+	return NewWith(w, true, opts...)
 }
 
-func NewEnabled(w gowid.IWidget) *Widget {
-	return NewWith(w, false)
+func NewEnabled(w gowid.IWidget, opts ...Options) *Widget {
+	return NewWith(w, false, opts...)
 }
 
-func NewWith(w gowid.IWidget, isDisabled bool) *Widget {
+func NewWith(w gowid.IWidget, isDisabled bool, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Style == nil {
+		opt.Style = DefaultStyle
+	}
 	res := &Widget{
 		IWidget:    w,
 		isDisabled: isDisabled,
+		opts:       opt,
 	}
 	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
 	var _ gowid.ICompositeWidget = res
@@ -57,6 +80,14 @@ func (w *Widget) Set(val bool) {
 	w.isDisabled = val
 }
 
+func (w *Widget) Disabled() bool {
+	return w.isDisabled
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
 func (w *Widget) String() string {
 	return fmt.Sprintf("disabled[d=%v,%v]", w.isDisabled, w.SubWidget())
 }
@@ -85,6 +116,29 @@ func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.S
 	return w.SubWidget().UserInput(ev, size, focus, app)
 }
 
+// Render renders the inner widget. If it's disabled, it's rendered without the focus,
+// and with the disabled style applied to every cell.
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	if !w.isDisabled {
+		return gowid.RenderChild(w.SubWidget(), size, focus, app)
+	}
+	res := gowid.RenderChild(w.SubWidget(), size, gowid.NotSelected, app)
+	if w.opts.NoStyle {
+		return res
+	}
+	f, b, st := w.opts.Style.GetStyle(app)
+	style := gowid.MakeCell(0,
+		gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+		gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+		st)
+	for y := 0; y < res.BoxRows(); y++ {
+		for x := 0; x < res.BoxColumns(); x++ {
+			res.SetCellAt(x, y, res.CellAt(x, y).MergeDisplayAttrsUnder(style))
+		}
+	}
+	return res
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
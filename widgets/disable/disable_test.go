@@ -0,0 +1,56 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package disable
+
+import (
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisable1(t *testing.T) {
+	clicked := false
+	btn := button.New(text.New("ok"))
+	btn.OnClick(gowid.WidgetCallback{Name: "cb", WidgetChangedFunction: func(app gowid.IApp, w gowid.IWidget) {
+		clicked = true
+	}})
+	w := New(btn)
+	sz := gowid.RenderFixed{}
+	enter := tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+
+	assert.False(t, w.Selectable())
+	assert.False(t, w.UserInput(enter, sz, gowid.Focused, gwtest.D))
+	assert.False(t, clicked)
+
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "<ok>", c.String())
+	grey := gowid.IColorToTCell(gowid.ColorDarkGray, gowid.ColorNone, gwtest.D.GetColorMode())
+	for x := 0; x < c.BoxColumns(); x++ {
+		assert.Equal(t, grey, c.CellAt(x, 0).ForegroundColor())
+		assert.Equal(t, tcell.AttrDim, c.CellAt(x, 0).Style().OnOff&tcell.AttrDim)
+	}
+
+	w.Enable()
+	assert.True(t, w.Selectable())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, gowid.ColorNone, c.CellAt(1, 0).ForegroundColor())
+	assert.True(t, w.UserInput(enter, sz, gowid.Focused, gwtest.D))
+	assert.True(t, clicked)
+
+	w = NewDisabled(text.New("x"), Options{NoStyle: true})
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, gowid.ColorNone, c.CellAt(0, 0).ForegroundColor())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
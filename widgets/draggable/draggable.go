@@ -0,0 +1,188 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package draggable provides a widget that can be dragged with the mouse and dropped
+// on a droptarget widget, carrying a payload.
+package draggable
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// Options can be supplied to New. If NoGhost is false, an image of the widget
+// follows the mouse during a drag, styled with GhostStyle if it is not nil.
+// DraggingStyle, if not nil, is applied to the widget itself while it is dragged.
+type Options struct {
+	NoGhost       bool
+	GhostStyle    gowid.ICellStyler
+	DraggingStyle gowid.ICellStyler
+}
+
+type IDraggable interface {
+	Payload() gowid.DragPayload
+	Dragging() bool
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IDraggable
+}
+
+// DragEndCB is the name under which drag end callbacks are registered. The
+// callback's extra argument is the *gowid.Drag - check its Dropped() method to see
+// if a target accepted it.
+type DragEndCB struct{}
+
+// Widget wraps another widget, letting the user drag it with the left mouse button.
+// Input is passed on to the inner widget, so a draggable button can still be clicked.
+type Widget struct {
+	inner   gowid.IWidget
+	payload gowid.DragPayload
+	opts    Options
+	drag    *gowid.Drag // The most recent drag of the widget
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+	gowid.AddressProvidesID
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+var _ gowid.IDragSource = (*Widget)(nil)
+
+func New(inner gowid.IWidget, payload gowid.DragPayload, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	res := &Widget{
+		inner:     inner,
+		payload:   payload,
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("draggable[%v]", w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.inner
+}
+
+func (w *Widget) SetSubWidget(wi gowid.IWidget, app gowid.IApp) {
+	w.inner = wi
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+func (w *Widget) Payload() gowid.DragPayload {
+	return w.payload
+}
+
+func (w *Widget) SetPayload(payload gowid.DragPayload, app gowid.IApp) {
+	w.payload = payload
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+// Dragging returns true while the widget is being dragged.
+func (w *Widget) Dragging() bool {
+	return w.drag != nil && w.drag.Active()
+}
+
+// DragEnded is called by the app when a drag of this widget finishes.
+func (w *Widget) DragEnded(d *gowid.Drag, app gowid.IApp) {
+	w.drag = nil
+	gowid.RunWidgetCallbacks(w.Callbacks, DragEndCB{}, app, w, d)
+}
+
+func (w *Widget) OnDragEnd(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, DragEndCB{}, f)
+}
+
+func (w *Widget) RemoveOnDragEnd(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, DragEndCB{}, f)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(w.SubWidget(), size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+// styleCanvas applies the styler's colors and style on top of each cell of the canvas.
+func styleCanvas(c gowid.ICanvas, styler gowid.ICellStyler, app gowid.IApp) {
+	f, b, s := styler.GetStyle(app)
+	mod := gowid.MakeCell(0,
+		gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+		gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+		s)
+	gowid.RangeOverCanvas(c, gowid.CellRangeFunc(func(cell gowid.Cell) gowid.Cell {
+		return cell.MergeDisplayAttrsUnder(mod)
+	}))
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	res := gowid.RenderChild(w.SubWidget(), size, focus, app)
+	if w.Dragging() && w.opts.DraggingStyle != nil {
+		styleCanvas(res, w.opts.DraggingStyle, app)
+	}
+	return res
+}
+
+// UserInput passes the event to the inner widget, then starts a drag if the left
+// mouse button has just been pressed.
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	res := gowid.UserInputIfSelectable(w.SubWidget(), ev, size, focus, app)
+	ev2, ok := ev.(*tcell.EventMouse)
+	if !ok || ev2.Buttons() != tcell.Button1 || app.GetLastMouseState().LeftIsClicked() {
+		return res
+	}
+	d := &gowid.Drag{
+		Payload: w.payload,
+		Source:  w,
+	}
+	d.HotX, d.HotY = ev2.Position()
+	if !w.opts.NoGhost {
+		d.Ghost = gowid.RenderChild(w.SubWidget(), size, gowid.NotSelected, app)
+		if w.opts.GhostStyle != nil {
+			styleCanvas(d.Ghost, w.opts.GhostStyle, app)
+		}
+	}
+	if gowid.StartDrag(d, app) {
+		w.drag = d
+		res = true
+	}
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
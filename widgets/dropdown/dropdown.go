@@ -0,0 +1,374 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package dropdown provides a select widget - it shows the current choice, and opens
+// a popup list of the other choices when activated.
+package dropdown
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/menu"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+// Option is one of the choices. Value is for the application's use. Disabled options
+// are shown in the list but can't be chosen.
+type Option struct {
+	Label    string
+	Value    interface{}
+	Disabled bool
+}
+
+func (o Option) String() string {
+	return fmt.Sprintf("option[%s]", o.Label)
+}
+
+// Options can be supplied to New. Selected is the index of the initial choice. Items
+// in the popup list are rendered with Style, the focus item with FocusStyle, and
+// disabled items with DisabledStyle. If Style is nil, items are shown in reverse
+// video, except for the focus item; if DisabledStyle is nil, disabled items are dim.
+// Letters typed within TypeAheadDelay of each other - by default, one second - are
+// matched against the start of the labels.
+type Options struct {
+	Selected       int
+	Style          gowid.ICellStyler
+	FocusStyle     gowid.ICellStyler
+	DisabledStyle  gowid.ICellStyler
+	TypeAheadDelay time.Duration
+}
+
+type IWidget interface {
+	gowid.IWidget
+	Selected() int
+	SetSelected(i int, app gowid.IApp)
+	Open(app gowid.IApp)
+	Close(app gowid.IApp)
+	IsOpen() bool
+}
+
+// ChangeCB is the name under which callbacks are registered that run when the choice
+// changes. The callback's extra argument is the chosen Option.
+type ChangeCB struct{}
+
+// Widget shows the current choice. Clicking it, or pressing enter or space, opens the
+// list of options below it - above it if there's no room. Typing the start of a label
+// chooses the matching option, or moves to it if the list is open.
+type Widget struct {
+	options  []Option
+	selected int
+	opts     Options
+	label    *text.Widget
+	view     *columns.Widget
+	site     *menu.SiteWidget
+	menu     *menu.Widget
+	rows     *pile.Widget
+	open     bool
+	typed    string
+	typedAt  time.Time
+	now      func() time.Time
+	*gowid.Callbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(options []Option, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Style == nil {
+		opt.Style = gowid.MakeStyledAs(gowid.StyleReverse)
+	}
+	if opt.DisabledStyle == nil {
+		opt.DisabledStyle = gowid.MakeStyledAs(gowid.StyleReverse.MergeUnder(gowid.StyleDim))
+	}
+	if opt.TypeAheadDelay <= 0 {
+		opt.TypeAheadDelay = time.Second
+	}
+	res := &Widget{
+		options:   options,
+		selected:  -1,
+		opts:      opt,
+		label:     text.New(""),
+		site:      menu.NewSite(menu.SiteOptions{YOffset: 1}),
+		now:       time.Now,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	if opt.Selected >= 0 && opt.Selected < len(options) {
+		res.selected = opt.Selected
+	}
+
+	btn := button.NewBare(res.label)
+	btn.OnClick(gowid.WidgetCallback{"cb", func(app gowid.IApp, _ gowid.IWidget) {
+		res.Open(app)
+	}})
+	res.view = columns.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{IWidget: res.site, D: gowid.RenderFixed{}},
+		&gowid.ContainerWidget{IWidget: btn, D: gowid.RenderWithWeight{W: 1}},
+		&gowid.ContainerWidget{IWidget: text.New(" ▾"), D: gowid.RenderFixed{}},
+	})
+	res.updateLabel(nil)
+	res.build()
+	return res
+}
+
+func (w *Widget) String() string {
+	o, _ := w.SelectedOption()
+	return fmt.Sprintf("dropdown[%s]", o.Label)
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Options() []Option {
+	return w.options
+}
+
+// SetOptions replaces the options, closing the list if it's open. The choice is kept
+// if it's still a valid index.
+func (w *Widget) SetOptions(options []Option, app gowid.IApp) {
+	w.Close(app)
+	w.options = options
+	if w.selected >= len(options) {
+		w.selected = -1
+	}
+	w.updateLabel(app)
+	w.build()
+}
+
+// Selected returns the index of the current choice, or -1 if there isn't one.
+func (w *Widget) Selected() int {
+	return w.selected
+}
+
+// SelectedOption returns the current choice, and false if there isn't one.
+func (w *Widget) SelectedOption() (Option, bool) {
+	if w.selected < 0 {
+		return Option{}, false
+	}
+	return w.options[w.selected], true
+}
+
+// SetSelected chooses the option at index i, if it isn't disabled, and runs the change
+// callbacks if the choice changed.
+func (w *Widget) SetSelected(i int, app gowid.IApp) {
+	if i < 0 || i >= len(w.options) || w.options[i].Disabled || i == w.selected {
+		return
+	}
+	w.selected = i
+	w.updateLabel(app)
+	gowid.RunWidgetCallbacks(w.Callbacks, ChangeCB{}, app, w, w.options[i])
+}
+
+func (w *Widget) OnChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, ChangeCB{}, f)
+}
+
+func (w *Widget) RemoveOnChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, ChangeCB{}, f)
+}
+
+// IsOpen returns true if the list of options is open.
+func (w *Widget) IsOpen() bool {
+	return w.open
+}
+
+// Open opens the list of options, with the focus on the current choice.
+func (w *Widget) Open(app gowid.IApp) {
+	if w.open || len(w.options) == 0 {
+		return
+	}
+	if w.selected >= 0 {
+		w.rows.SetFocus(app, w.selected)
+	}
+	w.menu.Open(w.site, app)
+}
+
+// Close closes the list of options.
+func (w *Widget) Close(app gowid.IApp) {
+	if w.open {
+		w.menu.Close(app)
+	}
+}
+
+// width returns the number of columns needed for the longest label.
+func (w *Widget) width() int {
+	res := 0
+	for _, o := range w.options {
+		res = gwutil.Max(res, runewidth.StringWidth(o.Label))
+	}
+	return res
+}
+
+func pad(s string, n int) string {
+	return s + strings.Repeat(" ", gwutil.Max(0, n-runewidth.StringWidth(s)))
+}
+
+// updateLabel shows the current choice, padded so the widget's width doesn't change.
+func (w *Widget) updateLabel(app gowid.IApp) {
+	label := ""
+	if w.selected >= 0 {
+		label = w.options[w.selected].Label
+	}
+	w.label.SetText(pad(label, w.width()), app)
+}
+
+// build makes the popup list for the current options.
+func (w *Widget) build() {
+	width := w.width() + 2
+	rows := make([]interface{}, len(w.options))
+	for i, o := range w.options {
+		i := i
+		// Pad the label so the row covers whatever is under the list
+		label := text.New(pad(" "+o.Label, width))
+		if o.Disabled {
+			rows[i] = styled.New(label, w.opts.DisabledStyle)
+			continue
+		}
+		btn := button.NewBare(label)
+		btn.OnClick(gowid.WidgetCallback{"cb", func(app gowid.IApp, _ gowid.IWidget) {
+			w.Close(app)
+			w.SetSelected(i, app)
+		}})
+		rows[i] = styled.NewExt(btn, w.opts.Style, w.opts.FocusStyle)
+	}
+	w.rows = pile.NewFlow(rows...)
+	w.menu = menu.New(fmt.Sprintf("dropdown-%p", w), &listWidget{w.rows, w},
+		gowid.RenderWithUnits{U: width},
+		menu.Options{
+			OpenCloser:   menu.OpenerFunc(w.openClose),
+			KeepOnScreen: true,
+		},
+	)
+}
+
+// openClose registers the list with the app while it is open.
+func (w *Widget) openClose(open bool, mu *menu.Widget, site menu.ISite, app gowid.IApp) bool {
+	if open {
+		if !w.open {
+			app.RegisterMenu(mu)
+			w.open = true
+		}
+		mu.OpenImpl(site, app)
+		return true
+	}
+	if !w.open {
+		return false
+	}
+	mu.CloseImpl(app)
+	app.UnregisterMenu(mu)
+	w.open = false
+	return true
+}
+
+// typeAhead adds r to the text typed so far, and returns the index of the first
+// enabled option, starting from the one at index from, whose label starts with it,
+// or -1. Typing the same letter repeatedly moves through the options starting with it.
+func (w *Widget) typeAhead(r rune, from int) int {
+	now := w.now()
+	if now.Sub(w.typedAt) > w.opts.TypeAheadDelay {
+		w.typed = ""
+	}
+	w.typedAt = now
+	w.typed += string(unicode.ToLower(r))
+
+	prefix := w.typed
+	start := from
+	if strings.Trim(w.typed, string(unicode.ToLower(r))) == "" {
+		// The same letter again - look for the next option
+		prefix = string(unicode.ToLower(r))
+		start = from + 1
+	}
+	n := len(w.options)
+	for k := 0; k < n; k++ {
+		i := (gwutil.Max(start, 0) + k) % n
+		o := w.options[i]
+		if !o.Disabled && strings.HasPrefix(strings.ToLower(o.Label), prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(w.view, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return w.view.Render(size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+func isTypeAheadKey(ev interface{}) (rune, bool) {
+	if evk, ok := ev.(*tcell.EventKey); ok && evk.Key() == tcell.KeyRune && evk.Modifiers() == 0 {
+		if r := evk.Rune(); r != ' ' && unicode.IsPrint(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// UserInput opens the list when the widget is clicked or enter or space is pressed,
+// and chooses an option by type-ahead.
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if r, ok := isTypeAheadKey(ev); ok {
+		if i := w.typeAhead(r, w.selected); i != -1 {
+			w.SetSelected(i, app)
+		}
+		return true
+	}
+	res := w.view.UserInput(ev, size, focus, app)
+	w.view.SetFocus(app, 1)
+	return res
+}
+
+// listWidget holds the rows of the popup list, and moves the focus by type-ahead.
+type listWidget struct {
+	gowid.IWidget
+	dropdown *Widget
+}
+
+func (w *listWidget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if r, ok := isTypeAheadKey(ev); ok {
+		if i := w.dropdown.typeAhead(r, w.dropdown.rows.Focus()); i != -1 {
+			w.dropdown.rows.SetFocus(app, i)
+		}
+		return true
+	}
+	return w.IWidget.UserInput(ev, size, focus, app)
+}
+
+func (w *listWidget) String() string {
+	return fmt.Sprintf("dropdown-list[%d]", len(w.dropdown.options))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
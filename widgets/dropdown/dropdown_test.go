@@ -0,0 +1,123 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package dropdown
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func screenRows(screen tcell.SimulationScreen) []string {
+	cells, width, height := screen.GetContents()
+	res := make([]string, height)
+	for y := 0; y < height; y++ {
+		var sb strings.Builder
+		for x := 0; x < width; x++ {
+			if r := cells[y*width+x].Runes; len(r) > 0 {
+				sb.WriteRune(r[0])
+			} else {
+				sb.WriteRune(' ')
+			}
+		}
+		res[y] = sb.String()
+	}
+	return res
+}
+
+func TestDropdown1(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(12, 6)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+
+	dd := New([]Option{
+		{Label: "Apple", Value: 1},
+		{Label: "Banana", Value: 2},
+		{Label: "Blueberry", Value: 3, Disabled: true},
+		{Label: "Cherry", Value: 4},
+	})
+	now := time.Now()
+	dd.now = func() time.Time { return now }
+	var chosen []interface{}
+	dd.OnChange(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, _ gowid.IWidget, data ...interface{}) {
+		chosen = append(chosen, data[0].(Option).Value)
+	}})
+
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen: screen,
+		View:   pile.NewFlow(dd, text.New("below")),
+		Log:    logger,
+	})
+	assert.NoError(t, err)
+	app.RedrawTerminal()
+	assert.Equal(t, "Apple      ▾", screenRows(screen)[0])
+
+	keyEv := func(k tcell.Key) {
+		app.HandleTCellEvent(tcell.NewEventKey(k, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	}
+	runeEv := func(r rune) {
+		app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	}
+
+	keyEv(tcell.KeyEnter)
+	assert.True(t, dd.IsOpen())
+	assert.Equal(t, []string{
+		"Apple      ▾",
+		" Apple      ",
+		" Banana     ",
+		" Blueberry  ",
+		" Cherry     ",
+		"            ",
+	}, screenRows(screen))
+
+	// The disabled option is skipped
+	keyEv(tcell.KeyDown)
+	keyEv(tcell.KeyDown)
+	keyEv(tcell.KeyEnter)
+	assert.False(t, dd.IsOpen())
+	assert.Equal(t, 3, dd.Selected())
+	assert.Equal(t, []interface{}{4}, chosen)
+	assert.Equal(t, []string{"Cherry     ▾", "below       "}, screenRows(screen)[0:2])
+
+	// Type-ahead while closed
+	runeEv('b')
+	assert.Equal(t, 1, dd.Selected())
+	now = now.Add(2 * time.Second)
+	runeEv('a')
+	assert.Equal(t, 0, dd.Selected())
+	runeEv('x')
+	assert.Equal(t, 0, dd.Selected())
+	assert.Equal(t, []interface{}{4, 2, 1}, chosen)
+
+	// Type-ahead while open moves the focus; escape closes without choosing
+	now = now.Add(2 * time.Second)
+	keyEv(tcell.KeyEnter)
+	runeEv('c')
+	runeEv('h')
+	assert.Equal(t, 3, dd.rows.Focus())
+	keyEv(tcell.KeyEscape)
+	assert.False(t, dd.IsOpen())
+	assert.Equal(t, 0, dd.Selected())
+
+	dd.SetSelected(2, app)
+	assert.Equal(t, 0, dd.Selected())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,173 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package droptarget provides a widget that receives payloads dragged onto it, e.g.
+// from a draggable widget.
+package droptarget
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+)
+
+//======================================================================
+
+// Options can be supplied to New. Kinds lists the payload kinds accepted - if empty,
+// any kind is accepted. If Accept is not nil, it decides instead. HoverStyle, if not
+// nil, is applied to the widget while an acceptable drag is over it.
+type Options struct {
+	Kinds      []string
+	Accept     func(d *gowid.Drag, app gowid.IApp) bool
+	HoverStyle gowid.ICellStyler
+}
+
+// Dropped is passed as the extra argument to drop callbacks. X and Y are the mouse
+// pointer's coordinates within the widget when the payload was dropped.
+type Dropped struct {
+	Drag *gowid.Drag
+	X, Y int
+}
+
+func (d Dropped) String() string {
+	return fmt.Sprintf("dropped[%v@%d,%d]", d.Drag.Payload, d.X, d.Y)
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	gowid.IDropTarget
+}
+
+// DropCB is the name under which drop callbacks are registered.
+type DropCB struct{}
+
+// Widget wraps another widget, making it a target for drags. Input is passed on to
+// the inner widget unless it is part of a drag that the widget accepts.
+type Widget struct {
+	inner gowid.IWidget
+	opts  Options
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+	gowid.AddressProvidesID
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	res := &Widget{
+		inner:     inner,
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("droptarget[%v]", w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.inner
+}
+
+func (w *Widget) SetSubWidget(wi gowid.IWidget, app gowid.IApp) {
+	w.inner = wi
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+// AcceptsDrop returns true if the drag's payload is acceptable, according to the
+// widget's options.
+func (w *Widget) AcceptsDrop(d *gowid.Drag, app gowid.IApp) bool {
+	if w.opts.Accept != nil {
+		return w.opts.Accept(d, app)
+	}
+	if len(w.opts.Kinds) == 0 {
+		return true
+	}
+	for _, k := range w.opts.Kinds {
+		if k == d.Payload.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Drop runs the widget's drop callbacks.
+func (w *Widget) Drop(d *gowid.Drag, x, y int, app gowid.IApp) bool {
+	gowid.RunWidgetCallbacks(w.Callbacks, DropCB{}, app, w, Dropped{Drag: d, X: x, Y: y})
+	return true
+}
+
+// Hovered returns true if an acceptable drag is over the widget.
+func (w *Widget) Hovered(app gowid.IApp) bool {
+	d := gowid.CurrentDrag(app)
+	return d != nil && d.Target() == gowid.IDropTarget(w)
+}
+
+func (w *Widget) OnDrop(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, DropCB{}, f)
+}
+
+func (w *Widget) RemoveOnDrop(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, DropCB{}, f)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(w.SubWidget(), size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	res := gowid.RenderChild(w.SubWidget(), size, focus, app)
+	if w.opts.HoverStyle != nil && w.Hovered(app) {
+		f, b, s := w.opts.HoverStyle.GetStyle(app)
+		mod := gowid.MakeCell(0,
+			gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+			gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+			s)
+		gowid.RangeOverCanvas(res, gowid.CellRangeFunc(func(c gowid.Cell) gowid.Cell {
+			return c.MergeDisplayAttrsUnder(mod)
+		}))
+	}
+	return res
+}
+
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if gowid.DropTargetUserInput(w, ev, size, app) {
+		return true
+	}
+	return gowid.UserInputIfSelectable(w.SubWidget(), ev, size, focus, app)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,107 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE file.
+
+package droptarget
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/draggable"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestDragDrop1(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(10, 1)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+
+	src := draggable.New(text.New("ab"), gowid.DragPayload{Kind: "letters", Value: "ab"})
+	tgt := New(text.New("....."), Options{Kinds: []string{"letters"}})
+	other := New(text.New("..."), Options{Kinds: []string{"numbers"}})
+	cols := columns.NewFixed(src, tgt, other)
+
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen: screen,
+		View:   cols,
+		Log:    logger,
+	})
+	assert.NoError(t, err)
+
+	var dropped []Dropped
+	tgt.OnDrop(gowid.MakeWidgetCallbackExt("test", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		dropped = append(dropped, data[0].(Dropped))
+	}))
+	ended := 0
+	src.OnDragEnd(gowid.MakeWidgetCallbackExt("test", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		ended++
+	}))
+
+	row := func() string {
+		cells, _, _ := screen.GetContents()
+		res := make([]rune, 0, 10)
+		for _, c := range cells {
+			res = append(res, c.Runes[0])
+		}
+		return string(res)
+	}
+
+	mouse := func(x int, b tcell.ButtonMask) {
+		app.HandleTCellEvent(tcell.NewEventMouse(x, 0, b, 0), gowid.IgnoreUnhandledInput)
+	}
+
+	// A click without movement doesn't drag
+	mouse(1, tcell.Button1)
+	mouse(1, tcell.ButtonNone)
+	assert.Nil(t, app.CurrentDrag())
+	assert.Equal(t, 0, ended)
+
+	mouse(1, tcell.Button1)
+	assert.NotNil(t, app.CurrentDrag())
+	assert.False(t, app.CurrentDrag().Active())
+	mouse(4, tcell.Button1)
+	assert.True(t, src.Dragging())
+	assert.Equal(t, gowid.IDropTarget(tgt), app.CurrentDrag().Target())
+	// The ghost is drawn with the pointer where the drag started within the widget
+	assert.Equal(t, "ab.ab.....", row())
+
+	// Not accepted
+	mouse(8, tcell.Button1)
+	assert.Nil(t, app.CurrentDrag().Target())
+	assert.Equal(t, "ab.....ab.", row())
+
+	mouse(5, tcell.Button1)
+	mouse(5, tcell.ButtonNone)
+	assert.Nil(t, app.CurrentDrag())
+	assert.False(t, src.Dragging())
+	assert.Equal(t, 1, ended)
+	assert.Equal(t, 1, len(dropped))
+	assert.Equal(t, "ab", dropped[0].Drag.Payload.Value)
+	assert.Equal(t, 3, dropped[0].X)
+	assert.True(t, dropped[0].Drag.Dropped())
+	assert.Equal(t, "ab........", row())
+
+	// Escape cancels
+	mouse(0, tcell.Button1)
+	mouse(3, tcell.Button1)
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyEscape, 0, 0), gowid.IgnoreUnhandledInput)
+	assert.Nil(t, app.CurrentDrag())
+	assert.Equal(t, 2, ended)
+	mouse(3, tcell.ButtonNone)
+	assert.Equal(t, 1, len(dropped))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -8,11 +8,11 @@ package edit
 import (
 	"fmt"
 	"io"
-	"unicode"
 	"unicode/utf8"
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/vim"
 	"github.com/gcla/gowid/widgets/text"
 	tcell "github.com/gdamore/tcell/v2"
 	"github.com/pkg/errors"
@@ -35,6 +35,19 @@ type IMask interface {
 	MaskChr() rune
 }
 
+// IPlaceholder is implemented by edit widgets that show ghost text, in its own style,
+// while they are empty - e.g. a hint of what to type. MakeText uses it if present.
+type IPlaceholder interface {
+	Placeholder() string
+	PlaceholderStyle() gowid.ICellStyler
+}
+
+// IMaskStyle is implemented by edit widgets that style the characters shown in place of
+// masked text. MakeText uses it if present.
+type IMaskStyle interface {
+	MaskStyle() gowid.ICellStyler
+}
+
 type IPaste interface {
 	PasteState(...bool) bool
 	AddKey(*tcell.EventKey)
@@ -50,6 +63,7 @@ type Mask struct {
 type Text struct{}
 type Caption struct{}
 type Cursor struct{}
+type Submit struct{}
 
 func DisabledMask() Mask {
 	return Mask{Chr: 'x', Enable: false}
@@ -84,6 +98,16 @@ type Widget struct {
 	IMask
 	caption      string
 	text         string
+	placeholder  string
+	phStyle      gowid.ICellStyler
+	maskStyle    gowid.ICellStyler
+	revealKeys   []vim.KeyPress
+	revealed     bool
+	history      *History
+	keyMap       *KeyMap
+	killRing     *KillRing
+	hist         historyState
+	submitKeys   []vim.KeyPress
 	paste        bool
 	readonly     bool
 	pastedKeys   []*tcell.EventKey
@@ -98,6 +122,9 @@ var _ io.Reader = (*Widget)(nil)
 var _ gowid.IWidget = (*Widget)(nil)
 var _ IPaste = (*Widget)(nil)
 var _ IReadOnly = (*Widget)(nil)
+var _ IPlaceholder = (*Widget)(nil)
+var _ IMaskStyle = (*Widget)(nil)
+var _ IReadline = (*Widget)(nil)
 
 // Writer embeds an EditWidget and provides the io.Writer interface. An gowid.IApp needs to
 // be provided too because the widget's SetText() function requires it in order to issue
@@ -112,6 +139,19 @@ type Options struct {
 	Text     string
 	Mask     IMask
 	ReadOnly bool
+	// Placeholder is shown after the caption while the text is empty, styled with
+	// PlaceholderStyle - the palette entry "edit-placeholder" if nil.
+	Placeholder      string
+	PlaceholderStyle gowid.ICellStyler
+	MaskStyle        gowid.ICellStyler // styles the mask characters; the palette entry "edit-mask" if nil
+	RevealKeys       []vim.KeyPress    // toggle showing masked text, e.g. for a password; none if nil
+	// History makes the widget a prompt, e.g. for a REPL. The submit keys - enter, if
+	// SubmitKeys is nil - add the text to the history and clear it, running the OnSubmit
+	// callbacks; up and down recall entries when they can't move the cursor.
+	History    *History
+	SubmitKeys []vim.KeyPress
+	KeyMap     *KeyMap   // keys for the readline-style operations; DefaultKeyMap if nil
+	KillRing   *KillRing // holds the text cut, to yank back; DefaultKillRing if nil
 }
 
 func New(args ...Options) *Widget {
@@ -122,12 +162,36 @@ func New(args ...Options) *Widget {
 	if opt.Mask == nil {
 		opt.Mask = DisabledMask()
 	}
+	if opt.PlaceholderStyle == nil {
+		opt.PlaceholderStyle = gowid.MakePaletteRef("edit-placeholder")
+	}
+	if opt.MaskStyle == nil {
+		opt.MaskStyle = gowid.MakePaletteRef("edit-mask")
+	}
+	if opt.KeyMap == nil {
+		opt.KeyMap = &DefaultKeyMap
+	}
+	if opt.KillRing == nil {
+		opt.KillRing = DefaultKillRing
+	}
+	if opt.SubmitKeys == nil && opt.History != nil {
+		opt.SubmitKeys = []vim.KeyPress{vim.KeyPressEnter}
+	}
 	res := &Widget{
 		IMask:        opt.Mask,
 		caption:      opt.Caption,
 		text:         opt.Text,
+		placeholder:  opt.Placeholder,
+		phStyle:      opt.PlaceholderStyle,
+		maskStyle:    opt.MaskStyle,
+		revealKeys:   opt.RevealKeys,
+		history:      opt.History,
+		hist:         historyState{index: -1},
+		submitKeys:   opt.SubmitKeys,
+		keyMap:       opt.KeyMap,
+		killRing:     opt.KillRing,
 		readonly:     opt.ReadOnly,
-		cursorPos:    len(opt.Text),
+		cursorPos:    utf8.RuneCountInString(opt.Text),
 		pastedKeys:   make([]*tcell.EventKey, 0, 100),
 		linesFromTop: 0,
 		Callbacks:    gowid.NewCallbacks(),
@@ -199,6 +263,40 @@ func (w *Widget) SetText(text string, app gowid.IApp) {
 	gowid.RunWidgetCallbacks(w.Callbacks, Text{}, app, w)
 }
 
+// UseMask returns true if the widget's mask is enabled and the text hasn't been revealed
+// with SetRevealed or one of the reveal keys.
+func (w *Widget) UseMask() bool {
+	return w.IMask.UseMask() && !w.revealed
+}
+
+// Revealed returns true if masked text is being shown.
+func (w *Widget) Revealed() bool {
+	return w.revealed
+}
+
+// SetRevealed determines whether masked text is shown - e.g. to let the user check a
+// password. It has no effect on a widget without a mask.
+func (w *Widget) SetRevealed(revealed bool, app gowid.IApp) {
+	w.revealed = revealed
+}
+
+func (w *Widget) Placeholder() string {
+	return w.placeholder
+}
+
+// SetPlaceholder sets the text shown while the widget is empty.
+func (w *Widget) SetPlaceholder(text string, app gowid.IApp) {
+	w.placeholder = text
+}
+
+func (w *Widget) PlaceholderStyle() gowid.ICellStyler {
+	return w.phStyle
+}
+
+func (w *Widget) MaskStyle() gowid.ICellStyler {
+	return w.maskStyle
+}
+
 func (w *Widget) LinesFromTop() int {
 	return w.linesFromTop
 }
@@ -216,7 +314,7 @@ func (w *Widget) SetCaption(text string, app gowid.IApp) {
 	gowid.RunWidgetCallbacks(w.Callbacks, Caption{}, app, w)
 }
 
-//func (w *Widget) PasteState(b ...bool) []*tcell.EventKey {
+// func (w *Widget) PasteState(b ...bool) []*tcell.EventKey {
 func (w *Widget) PasteState(b ...bool) bool {
 	if len(b) > 0 {
 		w.paste = b[0]
@@ -294,6 +392,15 @@ func (w *Widget) MakeText() text.IWidget {
 }
 
 func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if evk, ok := ev.(*tcell.EventKey); ok && w.IMask.UseMask() && vim.KeyIn(evk, w.revealKeys) {
+		w.SetRevealed(!w.revealed, app)
+		return true
+	}
+	if evk, ok := ev.(*tcell.EventKey); ok && w.history != nil && !w.PasteState() {
+		if done, res := w.historyInput(evk, size, focus, app); done {
+			return res
+		}
+	}
 	return UserInput(w, ev, size, focus, app)
 }
 
@@ -318,21 +425,28 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 }
 
 func MakeText(w IWidget) text.IWidget {
-	var txt string
-	if w.UseMask() {
-		arr := make([]rune, len(w.Text()))
+	segs := []text.ContentSegment{text.StringContent(w.Caption())}
+	switch {
+	case w.Text() == "":
+		if ph, ok := w.(IPlaceholder); ok && ph.Placeholder() != "" {
+			segs = append(segs, text.StyledContent(ph.Placeholder(), ph.PlaceholderStyle()))
+		}
+	case w.UseMask():
+		// One mask character per rune, so the cursor lines up
+		arr := make([]rune, utf8.RuneCountInString(w.Text()))
 		for i := 0; i < len(arr); i++ {
 			arr[i] = w.MaskChr()
 		}
-		txt = string(arr)
-	} else {
-		txt = w.Text()
+		if ms, ok := w.(IMaskStyle); ok {
+			segs = append(segs, text.StyledContent(string(arr), ms.MaskStyle()))
+		} else {
+			segs = append(segs, text.StringContent(string(arr)))
+		}
+	default:
+		segs = append(segs, text.StringContent(w.Text()))
 	}
 
-	//txt = w.Caption() + "\u00A0" + txt
-	txt = w.Caption() + txt
-
-	tw := text.New(txt)
+	tw := text.NewFromContent(text.NewContent(segs))
 	tw.SetLinesFromTop(w.LinesFromTop(), nil)
 
 	cu := &text.SimpleCursor{-1}
@@ -533,6 +647,10 @@ func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Se
 			}
 		}
 
+		if !handled {
+			handled = readlineInput(w, ev, app)
+		}
+
 		if !handled {
 			handled = pasteableKeyInput(w, ev, size, focus, app)
 		}
@@ -576,36 +694,9 @@ func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Se
 						w.SetText(string(r[0:w.CursorPos()])+string(r[w.CursorPos()+1:]), app)
 					}
 				}
-			case tcell.KeyCtrlK:
-				if !readOnly {
-					r := []rune(w.Text())
-					w.SetText(string(r[0:w.CursorPos()]), app)
-				}
-			case tcell.KeyCtrlU:
-				if !readOnly {
-					r := []rune(w.Text())
-					w.SetText(string(r[w.CursorPos():]), app)
-					w.SetCursorPos(0, app)
-				}
 			case tcell.KeyHome:
 				w.SetCursorPos(0, app)
 				w.SetLinesFromTop(0, app)
-			case tcell.KeyCtrlW:
-				if !readOnly {
-					txt := []rune(w.Text())
-					origcp := w.CursorPos()
-					cp := origcp
-					for cp > 0 && unicode.IsSpace(txt[cp-1]) {
-						cp--
-					}
-					for cp > 0 && !unicode.IsSpace(txt[cp-1]) {
-						cp--
-					}
-					if cp != origcp {
-						w.SetText(string(txt[0:cp])+string(txt[origcp:]), app)
-						w.SetCursorPos(cp, app)
-					}
-				}
 			case tcell.KeyCtrlA:
 				// Would be nice to use a slice here, something that doesn't copy
 				// TODO: terrible O(n) behavior :-(
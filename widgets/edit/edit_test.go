@@ -12,6 +12,7 @@ import (
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/vim"
 	tcell "github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 )
@@ -190,6 +191,147 @@ func TestEdit1(t *testing.T) {
 
 }
 
+func TestPlaceholder1(t *testing.T) {
+	w := New(Options{Caption: "Name:", Placeholder: "your name", PlaceholderStyle: gowid.MakeStyledAs(gowid.StyleUnderline)})
+	sz := gowid.RenderFlowWith{C: 15}
+	c1 := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "Name:your name ", c1.String())
+	assert.Equal(t, gowid.StyleUnderline, c1.CellAt(5, 0).Style())
+	assert.Equal(t, 5, c1.CursorCoords().X)
+
+	w.UserInput(tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	c1 = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "Name:q         ", c1.String())
+	assert.Equal(t, gowid.StyleNone, c1.CellAt(5, 0).Style())
+}
+
+func TestMask1(t *testing.T) {
+	ctrlr := vim.NewKeyPress(tcell.KeyRune, 'r', tcell.ModCtrl)
+	w := New(Options{Text: "现在ab", Mask: MakeMask('*'), RevealKeys: []vim.KeyPress{ctrlr}})
+	sz := gowid.RenderFlowWith{C: 6}
+	c1 := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "****  ", c1.String())
+	assert.Equal(t, 4, c1.CursorCoords().X)
+
+	assert.True(t, w.UserInput(tcell.NewEventKey(tcell.KeyCtrlR, 0, tcell.ModCtrl), sz, gowid.Focused, gwtest.D))
+	assert.True(t, w.Revealed())
+	c1 = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "现在ab", c1.String())
+
+	w.SetRevealed(false, gwtest.D)
+	assert.True(t, w.UseMask())
+
+	// Without a mask, the reveal keys aren't consumed
+	w = New(Options{RevealKeys: []vim.KeyPress{ctrlr}})
+	assert.False(t, w.UserInput(tcell.NewEventKey(tcell.KeyCtrlR, 0, tcell.ModCtrl), sz, gowid.Focused, gwtest.D))
+}
+
+func TestHistory1(t *testing.T) {
+	h := NewHistory(3, "ls", "cd /tmp", "ls -l")
+	w := New(Options{Caption: "> ", History: h})
+	sz := gowid.RenderFlowWith{C: 20}
+	key := func(k tcell.Key, r rune) bool {
+		return w.UserInput(tcell.NewEventKey(k, r, tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	}
+
+	var submitted []string
+	w.OnSubmit(gowid.MakeWidgetCallbackExt("test", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		submitted = append(submitted, data[0].(string))
+	}))
+
+	assert.True(t, key(tcell.KeyUp, 0))
+	assert.Equal(t, "ls -l", w.Text())
+	assert.True(t, key(tcell.KeyUp, 0))
+	assert.Equal(t, "cd /tmp", w.Text())
+	assert.True(t, key(tcell.KeyDown, 0))
+	assert.True(t, key(tcell.KeyDown, 0))
+	assert.Equal(t, "", w.Text())
+	assert.False(t, key(tcell.KeyDown, 0))
+
+	// Recall is filtered by the text typed first
+	key(tcell.KeyRune, 'l')
+	assert.True(t, key(tcell.KeyUp, 0))
+	assert.Equal(t, "ls -l", w.Text())
+	assert.True(t, key(tcell.KeyUp, 0))
+	assert.Equal(t, "ls", w.Text())
+	assert.False(t, key(tcell.KeyUp, 0))
+	assert.True(t, key(tcell.KeyDown, 0))
+	assert.True(t, key(tcell.KeyDown, 0))
+	assert.Equal(t, "l", w.Text())
+
+	key(tcell.KeyRune, 's')
+	assert.True(t, key(tcell.KeyEnter, 0))
+	assert.Equal(t, "", w.Text())
+	assert.Equal(t, []string{"ls"}, submitted)
+	assert.Equal(t, []string{"cd /tmp", "ls -l", "ls"}, h.Entries())
+
+	var b strings.Builder
+	assert.NoError(t, h.Save(&b))
+	h2, err := LoadHistory(strings.NewReader(b.String()+"junk\n\"a\\nb\"\n"), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ls -l", "ls", "a\nb"}, h2.Entries())
+
+	// Without a history, enter still starts a new line
+	w = New()
+	assert.True(t, key(tcell.KeyEnter, 0))
+	assert.Equal(t, "\n", w.Text())
+}
+
+func TestReadline1(t *testing.T) {
+	ring := NewKillRing(10)
+	w := New(Options{Text: "echo foo-bar baz", KillRing: ring})
+	sz := gowid.RenderFlowWith{C: 20}
+	key := func(k tcell.Key, r rune, mod tcell.ModMask) bool {
+		return w.UserInput(tcell.NewEventKey(k, r, mod), sz, gowid.Focused, gwtest.D)
+	}
+
+	// Word motion stops at punctuation
+	key(tcell.KeyRune, 'b', tcell.ModAlt)
+	assert.Equal(t, 13, w.CursorPos())
+	key(tcell.KeyRune, 'b', tcell.ModAlt)
+	assert.Equal(t, 9, w.CursorPos())
+	key(tcell.KeyRune, 'f', tcell.ModAlt)
+	assert.Equal(t, 12, w.CursorPos())
+
+	// Consecutive kills make one entry
+	key(tcell.KeyCtrlW, 0, tcell.ModCtrl)
+	assert.Equal(t, "echo  baz", w.Text())
+	key(tcell.KeyCtrlW, 0, tcell.ModCtrl)
+	assert.Equal(t, " baz", w.Text())
+	assert.Equal(t, []string{"echo foo-bar"}, ring.Entries())
+
+	key(tcell.KeyRune, 'd', tcell.ModAlt)
+	assert.Equal(t, "", w.Text())
+	assert.Equal(t, []string{"echo foo-bar baz"}, ring.Entries())
+
+	// A kill elsewhere starts a new entry
+	w.SetText("ls -l", gwtest.D)
+	w.SetCursorPos(5, gwtest.D)
+	key(tcell.KeyCtrlW, 0, tcell.ModCtrl)
+	assert.Equal(t, "ls ", w.Text())
+	assert.Equal(t, []string{"echo foo-bar baz", "-l"}, ring.Entries())
+
+	// Yank, then cycle back through the ring
+	key(tcell.KeyCtrlY, 0, tcell.ModCtrl)
+	assert.Equal(t, "ls -l", w.Text())
+	key(tcell.KeyRune, 'y', tcell.ModAlt)
+	assert.Equal(t, "ls echo foo-bar baz", w.Text())
+	assert.Equal(t, 19, w.CursorPos())
+
+	w.SetCursorPos(7, gwtest.D)
+	key(tcell.KeyCtrlK, 0, tcell.ModCtrl)
+	assert.Equal(t, "ls echo", w.Text())
+	key(tcell.KeyCtrlU, 0, tcell.ModCtrl)
+	assert.Equal(t, "", w.Text())
+	assert.Equal(t, "ls echo foo-bar baz", ring.Entries()[2])
+
+	// An empty key map disables the bindings
+	w = New(Options{Text: "ab", KeyMap: &KeyMap{}, KillRing: ring})
+	assert.False(t, key(tcell.KeyCtrlK, 0, tcell.ModCtrl))
+	key(tcell.KeyRune, 'd', tcell.ModAlt)
+	assert.Equal(t, "abd", w.Text())
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
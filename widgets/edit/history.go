@@ -0,0 +1,224 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package edit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/vim"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// History holds the entries submitted in an edit widget - e.g. the commands typed at a
+// REPL prompt - oldest first, for the widget to recall with up and down. Several widgets
+// can share one. The number of entries kept can be limited, in which case the oldest are
+// dropped.
+type History struct {
+	entries []string
+	limit   int
+}
+
+// NewHistory returns a history keeping at most limit entries, or any number if limit is
+// zero, starting with the entries given - e.g. those saved by a previous run.
+func NewHistory(limit int, entries ...string) *History {
+	res := &History{limit: limit}
+	for _, e := range entries {
+		res.Add(e)
+	}
+	return res
+}
+
+// Add appends an entry to the history, returning false if it wasn't added because it is
+// empty or the same as the latest entry.
+func (h *History) Add(entry string) bool {
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return false
+	}
+	h.entries = append(h.entries, entry)
+	if h.limit > 0 && len(h.entries) > h.limit {
+		h.entries = append(h.entries[:0:0], h.entries[len(h.entries)-h.limit:]...)
+	}
+	return true
+}
+
+// Entries returns the history's entries, oldest first.
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// Save writes the history's entries, oldest first, one to a line, quoted as JSON strings
+// so entries can span lines. LoadHistory reads them back.
+func (h *History) Save(out io.Writer) error {
+	for _, e := range h.entries {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadHistory reads entries written by Save into a new history keeping at most limit
+// entries. Lines that can't be read as entries are skipped.
+func LoadHistory(in io.Reader, limit int) (*History, error) {
+	res := NewHistory(limit)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var e string
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			res.Add(e)
+		}
+	}
+	return res, scanner.Err()
+}
+
+// historyState tracks a widget's place while recalling entries from its history.
+type historyState struct {
+	index    int    // the entry recalled, or -1 if the text is the user's own
+	draft    string // the text typed before recalling began, restored after the newest entry
+	recalled string // the text of the entry recalled, to notice the user editing it
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// History returns the widget's history, or nil if it has none.
+func (w *Widget) History() *History {
+	return w.history
+}
+
+// SetHistory sets the history the widget recalls entries from and submits to. If nil,
+// the widget has no history.
+func (w *Widget) SetHistory(h *History, app gowid.IApp) {
+	w.history = h
+	w.hist = historyState{index: -1}
+}
+
+// Submit adds the widget's text to its history, clears the text, and runs the
+// OnSubmit callbacks with the text submitted. It is called when one of the submit keys
+// is pressed.
+func (w *Widget) Submit(app gowid.IApp) {
+	txt := w.Text()
+	if w.history != nil {
+		w.history.Add(txt)
+	}
+	w.hist = historyState{index: -1}
+	w.SetText("", app)
+	w.SetCursorPos(0, app)
+	w.SetLinesFromTop(0, app)
+	gowid.RunWidgetCallbacks(w.Callbacks, Submit{}, app, w, txt)
+}
+
+func (w *Widget) OnSubmit(cb gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, Submit{}, cb)
+}
+
+func (w *Widget) RemoveOnSubmit(cb gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, Submit{}, cb)
+}
+
+// RecallPrevious replaces the text with the previous entry in the history that starts
+// with the text typed before recalling began - so typing a prefix and pressing up finds
+// the commands that start with it. It returns false if there is no such entry.
+func (w *Widget) RecallPrevious(app gowid.IApp) bool {
+	return w.recall(-1, app)
+}
+
+// RecallNext replaces the text with the next entry in the history that starts with the
+// text typed before recalling began, or, after the newest, with that text itself. It
+// returns false if the text is already the user's own.
+func (w *Widget) RecallNext(app gowid.IApp) bool {
+	return w.recall(1, app)
+}
+
+func (w *Widget) recall(dir int, app gowid.IApp) bool {
+	if w.history == nil {
+		return false
+	}
+	entries := w.history.Entries()
+	// Recalling starts afresh if the user has edited the entry recalled, or the history
+	// has been trimmed since
+	if w.hist.index >= len(entries) || (w.hist.index != -1 && w.Text() != w.hist.recalled) {
+		w.hist.index = -1
+	}
+	if w.hist.index == -1 {
+		if dir > 0 {
+			return false
+		}
+		w.hist.draft = w.Text()
+		w.hist.index = len(entries)
+	}
+
+	i := w.hist.index + dir
+	for ; i >= 0 && i < len(entries); i += dir {
+		if strings.HasPrefix(entries[i], w.hist.draft) && entries[i] != w.Text() {
+			break
+		}
+	}
+	var txt string
+	switch {
+	case i < 0:
+		if w.hist.index == len(entries) {
+			w.hist.index = -1
+		}
+		return false
+	case i >= len(entries):
+		txt = w.hist.draft
+		w.hist.index = -1
+	default:
+		txt = entries[i]
+		w.hist.index = i
+		w.hist.recalled = txt
+	}
+	w.SetText(txt, app)
+	w.SetCursorPos(utf8.RuneCountInString(txt), app)
+	return true
+}
+
+// historyInput handles the submit keys, and the up and down keys by recalling entries if
+// they can't move the cursor, for a widget with a history. The first result is true if
+// the event was handled here, and the second is the result for UserInput.
+func (w *Widget) historyInput(ev *tcell.EventKey, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) (bool, bool) {
+	if isReadOnly(w) {
+		return false, false
+	}
+	if vim.KeyIn(ev, w.submitKeys) {
+		w.Submit(app)
+		return true, true
+	}
+	switch ev.Key() {
+	case tcell.KeyUp, tcell.KeyCtrlP:
+		if w.UpLines(size, false, app) {
+			return true, true
+		}
+		return true, w.RecallPrevious(app)
+	case tcell.KeyDown, tcell.KeyCtrlN:
+		if w.DownLines(size, false, app) {
+			return true, true
+		}
+		return true, w.RecallNext(app)
+	}
+	return false, false
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
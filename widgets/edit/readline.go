@@ -0,0 +1,238 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package edit
+
+import (
+	"unicode"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/vim"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// KeyMap holds the keys for the edit widget's readline-style operations. An operation
+// with no keys is disabled, so the keys reach the widget's parent instead - or, for alt
+// with a letter, insert the letter.
+type KeyMap struct {
+	KillToEnd     []vim.KeyPress // cut from the cursor to the end of the line
+	KillToStart   []vim.KeyPress // cut from the start of the line to the cursor
+	KillWordBack  []vim.KeyPress // cut back to the start of a space-separated word
+	KillWordAhead []vim.KeyPress // cut forward to the end of a word
+	Yank          []vim.KeyPress // paste the text cut last
+	YankPop       []vim.KeyPress // straight after a yank, replace it with the text cut before
+	WordAhead     []vim.KeyPress // move to the end of the next word
+	WordBack      []vim.KeyPress // move to the start of the previous word
+}
+
+// DefaultKeyMap has the emacs/readline bindings, and is used by widgets not given a
+// KeyMap.
+var DefaultKeyMap = KeyMap{
+	KillToEnd:     []vim.KeyPress{vim.KeyCtrl('k')},
+	KillToStart:   []vim.KeyPress{vim.KeyCtrl('u')},
+	KillWordBack:  []vim.KeyPress{vim.KeyCtrl('w')},
+	KillWordAhead: []vim.KeyPress{vim.NewKeyPress(tcell.KeyRune, 'd', tcell.ModAlt)},
+	Yank:          []vim.KeyPress{vim.KeyCtrl('y')},
+	YankPop:       []vim.KeyPress{vim.NewKeyPress(tcell.KeyRune, 'y', tcell.ModAlt)},
+	WordAhead:     []vim.KeyPress{vim.NewKeyPress(tcell.KeyRune, 'f', tcell.ModAlt)},
+	WordBack:      []vim.KeyPress{vim.NewKeyPress(tcell.KeyRune, 'b', tcell.ModAlt)},
+}
+
+// DefaultKillRingSize is the number of entries kept by DefaultKillRing.
+const DefaultKillRingSize = 30
+
+// DefaultKillRing is shared by widgets not given a KillRing, so text cut from one can be
+// yanked into another, as in readline.
+var DefaultKillRing = NewKillRing(DefaultKillRingSize)
+
+// KillRing holds the text cut from edit widgets, most recent last, to be yanked back.
+// Consecutive kills in the same place are joined into one entry.
+type KillRing struct {
+	entries []string
+	limit   int
+	kill    ringMark // the state of the widget after the last kill
+	yank    ringMark // the state of the widget after the last yank
+	yankIdx int      // the entry yanked last
+}
+
+// ringMark records a widget's text and cursor after a kill or yank, so the next one can
+// tell if it follows straight on. For a yank, start is where the yanked text begins.
+type ringMark struct {
+	w     IWidget
+	text  string
+	pos   int
+	start int
+}
+
+func (m ringMark) matches(w IWidget) bool {
+	return m.w == w && w.Text() == m.text && w.CursorPos() == m.pos
+}
+
+// NewKillRing returns a kill ring keeping at most limit entries, or any number if limit
+// is zero.
+func NewKillRing(limit int) *KillRing {
+	return &KillRing{limit: limit}
+}
+
+// Add puts text on the ring as its most recent entry.
+func (r *KillRing) Add(text string) {
+	r.entries = append(r.entries, text)
+	if r.limit > 0 && len(r.entries) > r.limit {
+		r.entries = append(r.entries[:0:0], r.entries[len(r.entries)-r.limit:]...)
+	}
+}
+
+// Entries returns the ring's entries, oldest first.
+func (r *KillRing) Entries() []string {
+	return r.entries
+}
+
+// IReadline is implemented by edit widgets that choose the keys for, and the kill ring
+// used by, their readline-style operations. Widgets that don't implement it use
+// DefaultKeyMap and DefaultKillRing.
+type IReadline interface {
+	KeyMap() *KeyMap
+	KillRing() *KillRing
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) KeyMap() *KeyMap {
+	return w.keyMap
+}
+
+func (w *Widget) KillRing() *KillRing {
+	return w.killRing
+}
+
+//======================================================================
+
+// readlineInput handles the keys of the widget's KeyMap, returning true if the event is
+// consumed. Motions work in read-only widgets; kills and yanks don't.
+func readlineInput(w IWidget, ev *tcell.EventKey, app gowid.IApp) bool {
+	km, ring := &DefaultKeyMap, DefaultKillRing
+	if rl, ok := w.(IReadline); ok {
+		km, ring = rl.KeyMap(), rl.KillRing()
+	}
+	txt := []rune(w.Text())
+	pos := w.CursorPos()
+	readOnly := isReadOnly(w)
+
+	switch {
+	case vim.KeyIn(ev, km.WordAhead):
+		w.SetCursorPos(wordEnd(txt, pos), app)
+	case vim.KeyIn(ev, km.WordBack):
+		w.SetCursorPos(wordStart(txt, pos), app)
+	case readOnly:
+		return false
+	case vim.KeyIn(ev, km.KillToEnd):
+		end := pos
+		for end < len(txt) && txt[end] != '\n' {
+			end++
+		}
+		// At the end of a line, kill the newline
+		if end == pos && end < len(txt) {
+			end++
+		}
+		killText(w, ring, pos, end, app)
+	case vim.KeyIn(ev, km.KillToStart):
+		start := pos
+		for start > 0 && txt[start-1] != '\n' {
+			start--
+		}
+		killText(w, ring, start, pos, app)
+	case vim.KeyIn(ev, km.KillWordBack):
+		start := pos
+		for start > 0 && unicode.IsSpace(txt[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(txt[start-1]) {
+			start--
+		}
+		killText(w, ring, start, pos, app)
+	case vim.KeyIn(ev, km.KillWordAhead):
+		killText(w, ring, pos, wordEnd(txt, pos), app)
+	case vim.KeyIn(ev, km.Yank):
+		if len(ring.entries) > 0 {
+			ring.yankIdx = len(ring.entries) - 1
+			yankText(w, ring, pos, pos, app)
+		}
+	case vim.KeyIn(ev, km.YankPop):
+		if len(ring.entries) == 0 || !ring.yank.matches(w) {
+			return false
+		}
+		ring.yankIdx = (ring.yankIdx + len(ring.entries) - 1) % len(ring.entries)
+		yankText(w, ring, ring.yank.start, pos, app)
+	default:
+		return false
+	}
+	return true
+}
+
+// killText cuts the text from rune start up to end, leaving the cursor at start. If the
+// previous kill was in the same place, the text is joined to its ring entry.
+func killText(w IWidget, ring *KillRing, start, end int, app gowid.IApp) {
+	if start == end {
+		return
+	}
+	txt := []rune(w.Text())
+	killed := string(txt[start:end])
+	if ring.kill.matches(w) && len(ring.entries) > 0 {
+		last := len(ring.entries) - 1
+		if end == w.CursorPos() {
+			ring.entries[last] = killed + ring.entries[last]
+		} else {
+			ring.entries[last] += killed
+		}
+	} else {
+		ring.Add(killed)
+	}
+	w.SetText(string(txt[:start])+string(txt[end:]), app)
+	w.SetCursorPos(start, app)
+	ring.kill = ringMark{w: w, text: w.Text(), pos: start}
+}
+
+// yankText replaces the text from rune start up to end with the ring entry being yanked,
+// leaving the cursor after it.
+func yankText(w IWidget, ring *KillRing, start, end int, app gowid.IApp) {
+	txt := []rune(w.Text())
+	yanked := []rune(ring.entries[ring.yankIdx])
+	w.SetText(string(txt[:start])+string(yanked)+string(txt[end:]), app)
+	w.SetCursorPos(start+len(yanked), app)
+	ring.yank = ringMark{w: w, text: w.Text(), pos: start + len(yanked), start: start}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordEnd returns the position after the end of the word at or after pos.
+func wordEnd(txt []rune, pos int) int {
+	for pos < len(txt) && !isWordRune(txt[pos]) {
+		pos++
+	}
+	for pos < len(txt) && isWordRune(txt[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// wordStart returns the position of the start of the word before pos.
+func wordStart(txt []rune, pos int) int {
+	for pos > 0 && !isWordRune(txt[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordRune(txt[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,188 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package focusring provides a widget that highlights its child when the child, or
+// something in the child's subtree, holds the focus. The highlight can be a border,
+// a style applied to the whole widget, or both. Instead of each application wiring
+// up styled-on-focus widgets by hand, any widget can be wrapped in a focus ring.
+package focusring
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/border"
+	"github.com/gcla/gowid/widgets/styled"
+)
+
+//======================================================================
+
+// Options determines how the focus ring is drawn. If Border is true, a border
+// is drawn around the child using Runes, styled with BorderFocusStyle when the
+// child holds focus and BorderStyle otherwise. FocusStyle and NotFocusStyle are
+// applied to the whole widget, including any border, underneath the child's own
+// styling - typically they would be used to change the background.
+type Options struct {
+	Border           bool
+	Runes            border.Runes
+	BorderStyle      gowid.ICellStyler
+	BorderFocusStyle gowid.ICellStyler
+	FocusStyle       gowid.ICellStyler
+	NotFocusStyle    gowid.ICellStyler
+}
+
+type IFocusRing interface {
+	Opts() Options
+	HasFocus() bool
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IFocusRing
+}
+
+// Widget tracks whether its subtree held focus the last time it was rendered. When
+// that changes, the FocusCB callbacks are run with the new state as an extra
+// argument, so an application can react to focus entering or leaving a region.
+type Widget struct {
+	gowid.IWidget
+	opts     Options
+	hasFocus bool
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+	gowid.FocusCallbacks
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	res := &Widget{
+		IWidget: inner,
+		opts:    opt,
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	res.FocusCallbacks = gowid.FocusCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+// NewBordered returns a focus ring that draws a light border around the child,
+// styled with notFocus or focus depending on where the focus lies.
+func NewBordered(inner gowid.IWidget, notFocus, focus gowid.ICellStyler) *Widget {
+	return New(inner, Options{
+		Border:           true,
+		BorderStyle:      notFocus,
+		BorderFocusStyle: focus,
+	})
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("focusring[%v]", w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.IWidget
+}
+
+func (w *Widget) SetSubWidget(inner gowid.IWidget, app gowid.IApp) {
+	w.IWidget = inner
+	gowid.RunWidgetCallbacks(w, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) SetOpts(opts Options, app gowid.IApp) {
+	w.opts = opts
+}
+
+// HasFocus returns true if the subtree held focus when last rendered.
+func (w *Widget) HasFocus() bool {
+	return w.hasFocus
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return SubWidgetSize(w, size, focus, app)
+}
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	holds := HoldsFocus(w, focus)
+	if holds != w.hasFocus {
+		w.hasFocus = holds
+		gowid.RunWidgetCallbacks(w.Callbacks, gowid.FocusCB{}, app, w, holds)
+	}
+	return Render(w, size, focus, app)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// HoldsFocus returns true if the widget is rendered with focus and its child is
+// selectable - so the focus really does rest somewhere in the child's subtree.
+func HoldsFocus(w gowid.IComposite, focus gowid.Selector) bool {
+	return focus.Focus && w.SubWidget().Selectable()
+}
+
+// decorated returns the child wrapped in the border, if configured. The border
+// is styled according to whether the child holds focus.
+func decorated(w IWidget, focus gowid.Selector) gowid.IWidget {
+	res := w.SubWidget()
+	opts := w.Opts()
+	if opts.Border {
+		style := opts.BorderStyle
+		if HoldsFocus(w, focus) {
+			style = opts.BorderFocusStyle
+		}
+		res = border.New(res, border.Options{
+			Runes: opts.Runes,
+			Style: style,
+		})
+	}
+	return res
+}
+
+func SubWidgetSize(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	if b, ok := decorated(w, focus).(gowid.ICompositeWidget); ok && w.Opts().Border {
+		return b.SubWidgetSize(size, focus, app)
+	}
+	return size
+}
+
+func RenderSize(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return decorated(w, focus).RenderSize(size, focus, app)
+}
+
+func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return gowid.UserInputIfSelectable(decorated(w, focus), ev, size, focus, app)
+}
+
+func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	res := decorated(w, focus)
+	style := w.Opts().NotFocusStyle
+	if HoldsFocus(w, focus) {
+		style = w.Opts().FocusStyle
+	}
+	if style != nil {
+		res = styled.New(res, style)
+	}
+	return gowid.RenderChild(res, size, focus, app)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
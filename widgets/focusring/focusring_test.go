@@ -0,0 +1,107 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package focusring
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/border"
+	"github.com/gcla/gowid/widgets/selectable"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestFocusRing1(t *testing.T) {
+	w := New(selectable.New(text.New("ab")), Options{
+		Border:           true,
+		Runes:            border.AsciiRunes,
+		BorderStyle:      gowid.MakeForeground(gowid.ColorGreen),
+		BorderFocusStyle: gowid.MakeForeground(gowid.ColorRed),
+		FocusStyle:       gowid.MakeBackground(gowid.ColorBlue),
+	})
+
+	var changes []bool
+	w.OnFocusChanged(gowid.MakeWidgetCallbackExt("cb", func(app gowid.IApp, widget gowid.IWidget, data ...interface{}) {
+		changes = append(changes, data[0].(bool))
+	}))
+
+	c := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, strings.Join([]string{"+--+", "|ab|", "+--+"}, "\n"), c.String())
+	assert.Equal(t, gowid.ColorGreen, c.CellAt(0, 0).ForegroundColor())
+	assert.Equal(t, gowid.ColorNone, c.CellAt(1, 1).BackgroundColor())
+	assert.False(t, w.HasFocus())
+	assert.Equal(t, 0, len(changes))
+
+	c = w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	assert.Equal(t, gowid.ColorRed, c.CellAt(0, 0).ForegroundColor())
+	assert.Equal(t, gowid.ColorBlue, c.CellAt(1, 1).BackgroundColor())
+	assert.True(t, w.HasFocus())
+	assert.Equal(t, []bool{true}, changes)
+
+	w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, []bool{true, false}, changes)
+
+	gwtest.RenderBoxManyTimes(t, w, 2, 10, 2, 10)
+}
+
+func TestFocusRing2(t *testing.T) {
+	// Not selectable - so never holds focus
+	w := New(text.New("ab"), Options{FocusStyle: gowid.MakeBackground(gowid.ColorBlue)})
+	c := w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "ab", c.String())
+	assert.Equal(t, gowid.ColorNone, c.CellAt(0, 0).BackgroundColor())
+	assert.False(t, w.HasFocus())
+}
+
+// boxOnlyWidget can only be rendered in a box.
+type boxOnlyWidget struct {
+	*text.Widget
+}
+
+func (w *boxOnlyWidget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	if _, ok := size.(gowid.IRenderBox); !ok {
+		panic(gowid.WidgetSizeError{Widget: w, Size: size, Required: "IRenderBox"})
+	}
+	return w.Widget.Render(size, focus, app)
+}
+
+func TestRenderError1(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	w := New(&boxOnlyWidget{text.New("ab")})
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen: screen,
+		View:   w,
+		Log:    logger,
+	})
+	assert.NoError(t, err)
+	sz := gowid.RenderFlowWith{C: 4}
+
+	assert.Panics(t, func() {
+		w.Render(sz, gowid.Focused, app)
+	})
+
+	// The child is rendered through the app's error policy
+	app.SetRenderErrorPolicy(gowid.RecoverRenderError)
+	c := w.Render(sz, gowid.Focused, app)
+	assert.Equal(t, "!Wid", c.String())
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(0, 0).Style())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -28,6 +28,7 @@ var (
 	UnicodeFrame     = FrameRunes{'┏', '┓', '┗', '┛', '━', '━', '┃', '┃'}
 	UnicodeAltFrame  = FrameRunes{'▛', '▜', '▙', '▟', '▀', '▄', '▌', '▐'}
 	UnicodeAlt2Frame = FrameRunes{'╔', '╗', '╚', '╝', '═', '═', '║', '║'}
+	RoundedFrame     = FrameRunes{'╭', '╮', '╰', '╯', '─', '─', '│', '│'}
 	SpaceFrame       = FrameRunes{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '}
 	nullFrame        = FrameRunes{}
 )
@@ -114,6 +115,13 @@ func NewUnicodeAlt2(inner gowid.IWidget) *Widget {
 	return New(inner, params)
 }
 
+func NewRounded(inner gowid.IWidget) *Widget {
+	params := Options{
+		Frame: RoundedFrame,
+	}
+	return New(inner, params)
+}
+
 func NewSpace(inner gowid.IWidget) *Widget {
 	params := Options{
 		Frame: SpaceFrame,
@@ -229,7 +237,7 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 	tmp := gowid.NewCanvas()
 	newSize := w.SubWidgetSize(size, focus, app)
 
-	innerCanvas := w.SubWidget().Render(newSize, focus, app)
+	innerCanvas := gowid.RenderChild(w.SubWidget(), newSize, focus, app)
 	innerLines := innerCanvas.BoxRows()
 	maxCol := innerCanvas.BoxColumns()
 
@@ -247,7 +255,7 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 	rightver = gowid.CellFromRune(frame.R)
 	if w.Opts().Style != nil {
 		f, _, _ := w.Opts().Style.GetStyle(app)
-		fc := gowid.IColorToTCell(f, gowid.ColorNone, app.GetColorMode())
+		fc := gowid.ColorToTCellIn(f, gowid.ColorNone, app)
 		tophor = tophor.WithForegroundColor(fc)
 		bottomhor = bottomhor.WithForegroundColor(fc)
 		leftver = leftver.WithForegroundColor(fc)
@@ -311,7 +319,7 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 		res.Lines[resl-1][len(res.Lines[0])-wid] = res.Lines[resl-1][len(res.Lines[0])-wid].WithRune(frame.Br)
 
 		if titleWidget != nil {
-			titleCanvas := titleWidget.Render(gowid.RenderFixed{}, gowid.NotSelected, app)
+			titleCanvas := gowid.RenderChild(titleWidget, gowid.RenderFixed{}, gowid.NotSelected, app)
 			res.MergeUnder(titleCanvas, 2, 0, false)
 		}
 	}
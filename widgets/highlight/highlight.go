@@ -0,0 +1,259 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package highlight provides a widget that styles the parts of its child's rendered
+// text that match regular expressions - e.g. to highlight search hits or IP addresses
+// in a log.
+package highlight
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+)
+
+//======================================================================
+
+// Pattern pairs a regular expression with the style applied to its matches.
+type Pattern struct {
+	Regexp *regexp.Regexp
+	Style  gowid.ICellStyler
+}
+
+// Options can be supplied to New. If UnderStyle is true, a match's style is applied
+// underneath the child's own styling, so the child's colors take precedence where set;
+// by default the match's style wins. If FocusOnly is true, matches are only
+// highlighted when the widget is in focus.
+type Options struct {
+	Patterns   []Pattern
+	UnderStyle bool
+	FocusOnly  bool
+}
+
+type IHighlighter interface {
+	Patterns() []Pattern
+	SetPatterns(patterns []Pattern, app gowid.IApp)
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IHighlighter
+}
+
+// PatternsCB is the name under which callbacks are registered that run when the
+// patterns change.
+type PatternsCB struct{}
+
+// Widget renders its child, then styles each match of its patterns, which are applied
+// in order so that later patterns take precedence. Matches are found line by line in
+// the rendered canvas, so a match can't span a line break, even one introduced by
+// wrapping.
+type Widget struct {
+	gowid.IWidget
+	patterns []Pattern
+	opts     Options
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	res := &Widget{
+		IWidget:  inner,
+		patterns: opt.Patterns,
+		opts:     opt,
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("highlight[%d patterns,%v]", len(w.patterns), w.SubWidget())
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.IWidget
+}
+
+func (w *Widget) SetSubWidget(inner gowid.IWidget, app gowid.IApp) {
+	w.IWidget = inner
+	gowid.RunWidgetCallbacks(w, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+func (w *Widget) Patterns() []Pattern {
+	return w.patterns
+}
+
+func (w *Widget) SetPatterns(patterns []Pattern, app gowid.IApp) {
+	w.patterns = patterns
+	w.runCallbacks(app)
+}
+
+// Add appends a pattern that styles matches of the regular expression expr. It
+// returns an error if expr doesn't compile.
+func (w *Widget) Add(expr string, style gowid.ICellStyler, app gowid.IApp) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	w.patterns = append(w.patterns, Pattern{Regexp: re, Style: style})
+	w.runCallbacks(app)
+	return nil
+}
+
+// AddLiteral appends a pattern that styles occurrences of s, ignoring case - suitable
+// for highlighting what the user searched for.
+func (w *Widget) AddLiteral(s string, style gowid.ICellStyler, app gowid.IApp) {
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(s))
+	w.patterns = append(w.patterns, Pattern{Regexp: re, Style: style})
+	w.runCallbacks(app)
+}
+
+// Clear removes every pattern.
+func (w *Widget) Clear(app gowid.IApp) {
+	w.patterns = nil
+	w.runCallbacks(app)
+}
+
+func (w *Widget) OnPatternsChanged(f gowid.IWidgetChangedCallback) {
+	if w.Callbacks == nil {
+		w.Callbacks = gowid.NewCallbacks()
+	}
+	gowid.AddWidgetCallback(w.Callbacks, PatternsCB{}, f)
+}
+
+func (w *Widget) RemoveOnPatternsChanged(f gowid.IIdentity) {
+	if w.Callbacks == nil {
+		return
+	}
+	gowid.RemoveWidgetCallback(w.Callbacks, PatternsCB{}, f)
+}
+
+func (w *Widget) runCallbacks(app gowid.IApp) {
+	if w.Callbacks != nil {
+		gowid.RunWidgetCallbacks(w.Callbacks, PatternsCB{}, app, w)
+	}
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return w.SubWidget().RenderSize(size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	canvas := gowid.RenderChild(w.SubWidget(), size, focus, app)
+	if len(w.patterns) == 0 || (w.opts.FocusOnly && !focus.Focus) {
+		return canvas
+	}
+	Apply(canvas, w.patterns, w.opts.UnderStyle, app)
+	return canvas
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return gowid.UserInputIfSelectable(w.IWidget, ev, size, focus, app)
+}
+
+//======================================================================
+
+// Apply styles the matches of patterns in each row of canvas, in place. If under is
+// true, the canvas's own styling takes precedence over a match's. It's used by Widget,
+// and can be used by widgets that highlight their own canvases.
+func Apply(canvas gowid.ICanvas, patterns []Pattern, under bool, app gowid.IApp) {
+	for y := 0; y < canvas.BoxRows(); y++ {
+		applyToLine(canvas, y, patterns, under, app)
+	}
+}
+
+// applyToLine styles the matches in row y of canvas. The row's graphemes are joined
+// into a string, remembering where each starts, so that the byte offsets of a match
+// can be mapped back to the columns it covers.
+func applyToLine(canvas gowid.ICanvas, y int, patterns []Pattern, under bool, app gowid.IApp) {
+	var line strings.Builder
+	offsets := make([]int, 0, canvas.BoxColumns())
+	cols := make([]int, 0, canvas.BoxColumns())
+	for x := 0; x < canvas.BoxColumns(); {
+		c := canvas.CellAt(x, y)
+		offsets = append(offsets, line.Len())
+		cols = append(cols, x)
+		line.WriteString(c.Grapheme())
+		x += gwutil.Max(c.Width(), 1)
+	}
+	offsets = append(offsets, line.Len())
+	cols = append(cols, canvas.BoxColumns())
+
+	// grapheme returns the index of the grapheme containing byte offset i
+	grapheme := func(i int) int {
+		lo, hi := 0, len(offsets)-1
+		for lo < hi-1 {
+			mid := (lo + hi) / 2
+			if offsets[mid] <= i {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		return lo
+	}
+
+	s := line.String()
+	for _, p := range patterns {
+		if p.Regexp == nil || p.Style == nil {
+			continue
+		}
+		f, b, st := p.Style.GetStyle(app)
+		var fg, bg gowid.TCellColor
+		if f != nil {
+			fg = gowid.ColorToTCellIn(f, gowid.ColorNone, app)
+		}
+		if b != nil {
+			bg = gowid.ColorToTCellIn(b, gowid.ColorNone, app)
+		}
+		for _, m := range p.Regexp.FindAllStringIndex(s, -1) {
+			if m[0] == m[1] {
+				continue
+			}
+			start, end := grapheme(m[0]), grapheme(m[1]-1)+1
+			for x := cols[start]; x < cols[end]; x++ {
+				orig := canvas.CellAt(x, y)
+				c := orig
+				if f != nil {
+					c = c.WithForegroundColor(fg)
+				}
+				if b != nil {
+					c = c.WithBackgroundColor(bg)
+				}
+				if under {
+					c = c.WithStyle(st).MergeDisplayAttrsUnder(orig)
+				} else {
+					c = orig.MergeDisplayAttrsUnder(c.WithStyle(st))
+				}
+				canvas.SetCellAt(x, y, c)
+			}
+		}
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,62 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package highlight
+
+import (
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// bold returns a string with a 'b' for each bold cell of the first row of c, and a '.'
+// otherwise.
+func bold(c gowid.ICanvas) string {
+	res := make([]byte, c.BoxColumns())
+	for x := range res {
+		if c.CellAt(x, 0).Style().OnOff&tcell.AttrBold != 0 {
+			res[x] = 'b'
+		} else {
+			res[x] = '.'
+		}
+	}
+	return string(res)
+}
+
+func TestHighlight1(t *testing.T) {
+	w := New(text.New("from 10.0.0.1 日本 to 10.0.0.2"))
+	sz := gowid.RenderFixed{}
+
+	c := w.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "from 10.0.0.1 日本 to 10.0.0.2", c.String())
+	assert.Equal(t, "..............................", bold(c))
+
+	err := w.Add(`\d+\.\d+\.\d+\.\d+`, gowid.MakeStyledAs(gowid.StyleBold), gwtest.D)
+	assert.NoError(t, err)
+	c = w.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, ".....bbbbbbbb.........bbbbbbbb", bold(c))
+
+	// The wide runes each cover two columns
+	w.SetPatterns(nil, gwtest.D)
+	w.AddLiteral("日本", gowid.MakeStyledAs(gowid.StyleBold), gwtest.D)
+	c = w.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "..............bbbb............", bold(c))
+
+	assert.Error(t, w.Add(`(`, gowid.MakeStyledAs(gowid.StyleBold), gwtest.D))
+
+	w.Clear(gwtest.D)
+	assert.Equal(t, 0, len(w.Patterns()))
+	c = w.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "..............................", bold(c))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
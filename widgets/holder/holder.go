@@ -6,6 +6,7 @@
 package holder
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/gcla/gowid"
@@ -27,6 +28,7 @@ func New(w gowid.IWidget) *Widget {
 	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
 	var _ gowid.IWidget = res
 	var _ gowid.ICompositeWidget = res
+	var _ gowid.IStateful = res
 	return res
 }
 
@@ -47,6 +49,30 @@ func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app
 	return w.SubWidget().RenderSize(size, focus, app)
 }
 
+type state struct {
+	Widget string `json:"widget,omitempty"`
+}
+
+// SaveState implements gowid.IStateful. It saves the ID of the widget held, if it has
+// one that can be saved - see gowid.StateKeyOf.
+func (w *Widget) SaveState() (interface{}, error) {
+	key, _ := gowid.StateKeyOf(w.SubWidget())
+	return state{Widget: key}, nil
+}
+
+// RestoreState implements gowid.IStateful. It holds the context's widget with the saved
+// ID, if there is one.
+func (w *Widget) RestoreState(data json.RawMessage, ctx *gowid.StateContext, app gowid.IApp) error {
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	if sub := ctx.Widget(st.Widget); sub != nil && st.Widget != "" {
+		w.SetSubWidget(sub, app)
+	}
+	return nil
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
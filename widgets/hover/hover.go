@@ -0,0 +1,129 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package hover provides a widget that changes the style of its inner widget
+// while the mouse is over it - e.g. to highlight buttons or list rows.
+package hover
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/styled"
+)
+
+//======================================================================
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	gowid.IHoverTarget
+	Hovered() bool
+}
+
+// HoverCB is the name under which callbacks are registered that run when the mouse
+// moves over the widget, or off it.
+type HoverCB struct{}
+
+// Widget wraps another widget, rendering it with a style while the mouse is over it.
+// The App tells the widget when the mouse enters and leaves - see gowid.IHoverTarget.
+type Widget struct {
+	inner   gowid.IWidget
+	styled  *styled.Widget // Renders inner with the hover style
+	hovered bool
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+	gowid.AddressProvidesID
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+// New returns a widget that renders inner with styler applied while the mouse is over
+// it. If styler is nil, the widget renders inner unchanged but still runs its hover
+// callbacks.
+func New(inner gowid.IWidget, styler gowid.ICellStyler) *Widget {
+	res := &Widget{
+		inner:     inner,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	if styler != nil {
+		res.styled = styled.New(inner, styler)
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("hover[%v]", w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.inner
+}
+
+func (w *Widget) SetSubWidget(wi gowid.IWidget, app gowid.IApp) {
+	w.inner = wi
+	if w.styled != nil {
+		w.styled.SetSubWidget(wi, app)
+	}
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+func (w *Widget) Selectable() bool {
+	return w.inner.Selectable()
+}
+
+// Hovered returns true if the mouse is over the widget.
+func (w *Widget) Hovered() bool {
+	return w.hovered
+}
+
+func (w *Widget) MouseEnter(app gowid.IApp) {
+	w.hovered = true
+	gowid.RunWidgetCallbacks(w.Callbacks, HoverCB{}, app, w)
+}
+
+func (w *Widget) MouseLeave(app gowid.IApp) {
+	w.hovered = false
+	gowid.RunWidgetCallbacks(w.Callbacks, HoverCB{}, app, w)
+}
+
+func (w *Widget) OnHover(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, HoverCB{}, f)
+}
+
+func (w *Widget) RemoveOnHover(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, HoverCB{}, f)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(w.inner, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	var res gowid.ICanvas
+	if w.hovered && w.styled != nil {
+		res = gowid.RenderChild(w.styled, size, focus, app)
+	} else {
+		res = gowid.RenderChild(w.inner, size, focus, app)
+	}
+	gowid.TrackGeometry(w, res, app)
+	return res
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return gowid.UserInputIfSelectable(w.inner, ev, size, focus, app)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
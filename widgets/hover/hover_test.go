@@ -0,0 +1,82 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package hover
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestHover1(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(5, 3)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+
+	reverse := gowid.MakeStyledAs(gowid.StyleReverse)
+	h1 := New(text.New("one"), reverse)
+	h2 := New(text.New("two"), reverse)
+	outer := New(pile.NewFlow(h1, h2), nil)
+	changes := 0
+	outer.OnHover(gowid.WidgetCallback{"cb", func(app gowid.IApp, w gowid.IWidget) {
+		changes++
+	}})
+
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen:            screen,
+		View:              pile.NewFlow(outer, text.New("three")),
+		Log:               logger,
+		EnableMouseMotion: true,
+	})
+	assert.NoError(t, err)
+	app.RedrawTerminal()
+
+	reversed := func(x, y int) bool {
+		cells, width, _ := screen.GetContents()
+		_, _, attr := cells[y*width+x].Style.Decompose()
+		return attr&tcell.AttrReverse != 0
+	}
+
+	move := func(x, y int) {
+		app.HandleTCellEvent(tcell.NewEventMouse(x, y, tcell.ButtonNone, 0), gowid.IgnoreUnhandledInput)
+	}
+
+	move(1, 0)
+	assert.True(t, h1.Hovered())
+	assert.False(t, h2.Hovered())
+	assert.True(t, outer.Hovered())
+	assert.True(t, gowid.MouseOver(h1.ID(), app))
+	assert.True(t, reversed(0, 0))
+	assert.False(t, reversed(0, 1))
+
+	move(2, 1)
+	assert.False(t, h1.Hovered())
+	assert.True(t, h2.Hovered())
+	assert.True(t, outer.Hovered())
+	assert.False(t, reversed(0, 0))
+	assert.True(t, reversed(0, 1))
+	assert.Equal(t, 1, changes)
+
+	move(2, 2)
+	assert.False(t, h2.Hovered())
+	assert.False(t, outer.Hovered())
+	assert.False(t, reversed(0, 1))
+	assert.Equal(t, 2, changes)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
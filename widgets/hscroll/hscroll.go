@@ -0,0 +1,255 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package hscroll provides a widget that displays a horizontal window onto a wider
+// widget, scrolled with the left and right keys or the mouse wheel.
+package hscroll
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// Options can be supplied to New. If Width is zero, the inner widget is rendered
+// fixed i.e. at its natural width - suitable for text that shouldn't be wrapped.
+// Otherwise, it's rendered with Width columns - needed for flow and box widgets
+// like lists. Step is the number of columns scrolled by each key press, and
+// defaults to 1.
+type Options struct {
+	Width int
+	Step  int
+}
+
+// IHorizontalScroll is implemented by widgets that display part of something wider.
+// Like the vscroll package's IVerticalScrollbar, the extent is split into three - the
+// columns hidden to the left, the columns visible, and the columns hidden to the right.
+type IHorizontalScroll interface {
+	GetLeft() int
+	GetMiddle() int
+	GetRight() int
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IHorizontalScroll
+	Offset() int
+}
+
+// OffsetCB is the name under which callbacks are registered that run when the widget
+// scrolls.
+type OffsetCB struct{}
+
+type Widget struct {
+	inner gowid.IWidget
+	opts  Options
+	xoff  int
+	width int // Width of the inner widget when last rendered
+	cols  int // Columns displayed when last rendered
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Step <= 0 {
+		opt.Step = 1
+	}
+	res := &Widget{
+		inner:     inner,
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("hscroll[%d:%v]", w.xoff, w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.inner
+}
+
+func (w *Widget) SetSubWidget(wi gowid.IWidget, app gowid.IApp) {
+	w.inner = wi
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return SubWidgetSize(w, size, focus, app)
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+// Offset returns the number of columns of the inner widget scrolled off the left.
+func (w *Widget) Offset() int {
+	return w.xoff
+}
+
+// SetOffset scrolls so that column x of the inner widget is at the left. The offset
+// is limited when the widget is next rendered, so the inner widget fills the view
+// if it can.
+func (w *Widget) SetOffset(x int, app gowid.IApp) {
+	x = gwutil.Max(0, x)
+	if x != w.xoff {
+		w.xoff = x
+		gowid.RunWidgetCallbacks(w.Callbacks, OffsetCB{}, app, w)
+	}
+}
+
+// ScrollLeft scrolls n columns to the left.
+func (w *Widget) ScrollLeft(n int, app gowid.IApp) {
+	w.SetOffset(w.xoff-n, app)
+}
+
+// ScrollRight scrolls n columns to the right, stopping when the right edge of the
+// inner widget is visible.
+func (w *Widget) ScrollRight(n int, app gowid.IApp) {
+	w.SetOffset(gwutil.Min(w.xoff+n, gwutil.Max(0, w.width-w.cols)), app)
+}
+
+func (w *Widget) GetLeft() int {
+	return w.xoff
+}
+
+func (w *Widget) GetMiddle() int {
+	return gwutil.Min(w.cols, w.width-w.xoff)
+}
+
+func (w *Widget) GetRight() int {
+	return gwutil.Max(0, w.width-w.xoff-w.cols)
+}
+
+func (w *Widget) OnOffsetChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, OffsetCB{}, f)
+}
+
+func (w *Widget) RemoveOnOffsetChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, OffsetCB{}, f)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+func SubWidgetSize(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	if w.opts.Width <= 0 {
+		return gowid.RenderFixed{}
+	}
+	if box, ok := size.(gowid.IRenderBox); ok {
+		return gowid.RenderBox{C: w.opts.Width, R: box.BoxRows()}
+	}
+	return gowid.RenderFlowWith{C: w.opts.Width}
+}
+
+func RenderSize(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	switch sz := size.(type) {
+	case gowid.IRenderBox:
+		return gowid.RenderBox{C: sz.BoxColumns(), R: sz.BoxRows()}
+	case gowid.IRenderFlowWith:
+		inner := gowid.RenderSize(w.SubWidget(), SubWidgetSize(w, size, focus, app), focus, app)
+		return gowid.RenderBox{C: sz.FlowColumns(), R: inner.BoxRows()}
+	case gowid.IRenderFixed:
+		return gowid.RenderSize(w.SubWidget(), SubWidgetSize(w, size, focus, app), focus, app)
+	default:
+		panic(gowid.WidgetSizeError{Widget: w, Size: size})
+	}
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	res := gowid.RenderChild(w.SubWidget(), SubWidgetSize(w, size, focus, app), focus, app)
+	w.width = res.BoxColumns()
+	w.cols = RenderSize(w, size, focus, app).BoxColumns()
+
+	xoff := w.xoff
+	// Keep the cursor in view, e.g. if the inner widget is an edit
+	if focus.Focus && res.CursorEnabled() {
+		x := res.CursorCoords().X
+		if x < xoff {
+			xoff = x
+		} else if x >= xoff+w.cols {
+			xoff = x - w.cols + 1
+		}
+	}
+	xoff = gwutil.Max(0, gwutil.Min(xoff, w.width-w.cols))
+	w.SetOffset(xoff, app)
+
+	if w.xoff > 0 {
+		res.TrimLeft(w.width - w.xoff)
+	}
+	gowid.MakeCanvasRightSize(res, size)
+	return res
+}
+
+// UserInput passes input to the inner widget, translating mouse events by the
+// offset. If the inner widget doesn't use the input, left and right keys and the
+// horizontal mouse wheel scroll the widget.
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	box := RenderSize(w, size, focus, app)
+	w.cols = box.BoxColumns()
+	w.width = gowid.RenderSize(w.SubWidget(), SubWidgetSize(w, size, focus, app), focus, app).BoxColumns()
+
+	if evm, ok := ev.(*tcell.EventMouse); ok {
+		mx, my := evm.Position()
+		if mx < 0 || my < 0 || mx >= box.BoxColumns() || my >= box.BoxRows() {
+			return false
+		}
+		ev = gowid.TranslatedMouseEvent(ev, w.xoff, 0)
+	}
+	if gowid.UserInputIfSelectable(w.SubWidget(), ev, SubWidgetSize(w, size, focus, app), focus, app) {
+		return true
+	}
+
+	xoff := w.xoff
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyLeft:
+			w.ScrollLeft(w.opts.Step, app)
+		case tcell.KeyRight:
+			w.ScrollRight(w.opts.Step, app)
+		}
+	case *tcell.EventMouse:
+		switch ev.Buttons() {
+		case tcell.WheelLeft:
+			w.ScrollLeft(w.opts.Step, app)
+		case tcell.WheelRight:
+			w.ScrollRight(w.opts.Step, app)
+		}
+	}
+	return xoff != w.xoff
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
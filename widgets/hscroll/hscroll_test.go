@@ -0,0 +1,70 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package hscroll
+
+import (
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/selectable"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestHScroll1(t *testing.T) {
+	w := New(text.New("abcdefgh\nij"), Options{Step: 2})
+	sz := gowid.RenderFlowWith{C: 5}
+
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "abcde\nij   ", c.String())
+	assert.Equal(t, []int{0, 5, 3}, []int{w.GetLeft(), w.GetMiddle(), w.GetRight()})
+
+	right := tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone)
+	left := tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone)
+	assert.True(t, w.UserInput(right, sz, gowid.Focused, gwtest.D))
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "cdefg\n     ", c.String())
+
+	assert.True(t, w.UserInput(right, sz, gowid.Focused, gwtest.D))
+	assert.False(t, w.UserInput(right, sz, gowid.Focused, gwtest.D))
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "defgh\n     ", c.String())
+	assert.Equal(t, []int{3, 5, 0}, []int{w.GetLeft(), w.GetMiddle(), w.GetRight()})
+
+	assert.True(t, w.UserInput(left, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, 1, w.Offset())
+
+	// Wider than the inner widget
+	c = w.Render(gowid.RenderFlowWith{C: 10}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "abcdefgh  \nij        ", c.String())
+	assert.Equal(t, 0, w.Offset())
+}
+
+func TestHScrollList1(t *testing.T) {
+	walker := list.NewSimpleListWalker([]gowid.IWidget{
+		selectable.New(text.New("0123456789")),
+		selectable.New(text.New("abcdefghij")),
+	})
+	w := New(list.New(walker), Options{Width: 10})
+	sz := gowid.RenderBox{C: 4, R: 2}
+	w.SetOffset(6, gwtest.D)
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "6789\nghij", c.String())
+
+	// The list still moves focus with the keyboard
+	down := tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	assert.True(t, w.UserInput(down, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, list.ListPos(1), walker.Focus())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
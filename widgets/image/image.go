@@ -0,0 +1,519 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package image provides a widget that displays an image.Image in terminal cells, using
+// half blocks or braille dots - e.g. for a logo or a preview.
+package image
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+)
+
+//======================================================================
+
+// Mode determines how the image's pixels are drawn with terminal cells.
+type Mode int
+
+const (
+	// HalfBlockMode draws two pixels per cell, one above the other, with the upper half
+	// block - the top pixel is the cell's foreground color and the bottom its background.
+	HalfBlockMode Mode = iota
+	// BrailleMode draws 2x4 pixels per cell with braille dots. A dot is shown if its
+	// pixel is bright enough, and the cell is colored with the average of those pixels.
+	BrailleMode
+)
+
+func (m Mode) String() string {
+	switch m {
+	case HalfBlockMode:
+		return "halfblock"
+	case BrailleMode:
+		return "braille"
+	default:
+		return fmt.Sprintf("mode[%d]", int(m))
+	}
+}
+
+// PixelsPerCell returns the number of horizontal and vertical pixels drawn in a single
+// terminal cell in this mode.
+func (m Mode) PixelsPerCell() (int, int) {
+	switch m {
+	case BrailleMode:
+		return 2, 4
+	default:
+		return 1, 2
+	}
+}
+
+// Dither determines how colors the terminal can't show, and pixels that are neither
+// clearly on or off, are approximated.
+type Dither int
+
+const (
+	// NoDither uses the closest color the terminal can show for each pixel.
+	NoDither Dither = iota
+	// OrderedDither offsets each pixel by a fixed pattern before finding the closest
+	// color, which gives an even texture that doesn't change as the image moves.
+	OrderedDither
+	// DiffusionDither spreads the difference between each pixel and its closest color
+	// over its neighbors (Floyd-Steinberg), which gives smoother gradients.
+	DiffusionDither
+)
+
+func (d Dither) String() string {
+	switch d {
+	case NoDither:
+		return "none"
+	case OrderedDither:
+		return "ordered"
+	case DiffusionDither:
+		return "diffusion"
+	default:
+		return fmt.Sprintf("dither[%d]", int(d))
+	}
+}
+
+// Braille dots are numbered down the left column then down the right, except
+// that the bottom row (dots 7 and 8) was added later, hence the irregular bits.
+var brailleBits = [4][2]uint8{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// Indexed by top pixel on, then bottom pixel on - for monochrome terminals.
+var halfBlockRunes = [2][2]rune{
+	{' ', '▄'},
+	{'▀', '█'},
+}
+
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+//======================================================================
+
+type IImage interface {
+	Image() image.Image
+	Opts() Options
+}
+
+type IWidget interface {
+	gowid.IWidget
+	IImage
+}
+
+// Options can be supplied to New. Dither is applied when the terminal can't show the
+// image's colors exactly. Unless Stretch is true, the image keeps its aspect ratio, and
+// is centered in the space it's rendered in. CellAspect is the height of a terminal
+// cell divided by its width - 2 if zero. In BrailleMode, a dot is shown if the
+// brightness of its pixel, from 0 to 1, is at least Threshold - 0.5 if zero. Pixels
+// less than half opaque aren't drawn, so the widgets beneath show through.
+type Options struct {
+	Mode       Mode
+	Dither     Dither
+	Stretch    bool
+	CellAspect float64
+	Threshold  float64
+}
+
+// Widget displays an image. If rendered as a flow widget, it's as high as needed to
+// keep the image's aspect ratio; if rendered as a fixed widget, one image pixel is
+// drawn per cell pixel.
+type Widget struct {
+	img   image.Image
+	opt   Options
+	cache *samples // The image resampled for the size last rendered
+	gowid.RejectUserInput
+	gowid.NotSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(img image.Image, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return &Widget{
+		img: img,
+		opt: opt,
+	}
+}
+
+func (w *Widget) String() string {
+	if w.img == nil {
+		return fmt.Sprintf("image[%v]", w.opt.Mode)
+	}
+	b := w.img.Bounds()
+	return fmt.Sprintf("image[%dx%d %v]", b.Dx(), b.Dy(), w.opt.Mode)
+}
+
+func (w *Widget) Image() image.Image {
+	return w.img
+}
+
+func (w *Widget) SetImage(img image.Image, app gowid.IApp) {
+	w.img = img
+	w.cache = nil
+}
+
+func (w *Widget) Opts() Options {
+	return w.opt
+}
+
+func (w *Widget) SetOpts(opt Options, app gowid.IApp) {
+	w.opt = opt
+	w.cache = nil
+}
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	cols, rows := renderCells(w, size)
+	px, py := w.opt.Mode.PixelsPerCell()
+	tw, th, ox, oy := fit(w, cols, rows)
+	s := w.cache
+	if s == nil || s.w != tw || s.h != th {
+		s = resample(w.img, tw, th)
+		w.cache = s
+	}
+	return render(s, w.opt, cols, rows, px, py, ox, oy, app)
+}
+
+//======================================================================
+
+func cellAspect(opt Options) float64 {
+	if opt.CellAspect <= 0 {
+		return 2
+	}
+	return opt.CellAspect
+}
+
+// renderCells returns the size in cells of the widget when rendered with size.
+func renderCells(w IImage, size gowid.IRenderSize) (int, int) {
+	var iw, ih int
+	if w.Image() != nil {
+		b := w.Image().Bounds()
+		iw, ih = b.Dx(), b.Dy()
+	}
+	px, py := w.Opts().Mode.PixelsPerCell()
+	switch sz := size.(type) {
+	case gowid.IRenderBox:
+		return sz.BoxColumns(), sz.BoxRows()
+	case gowid.IRenderFlowWith:
+		cols := sz.FlowColumns()
+		if iw == 0 || ih == 0 {
+			return cols, 0
+		}
+		rows := int(math.Ceil(float64(cols) * float64(ih) / (float64(iw) * cellAspect(w.Opts()))))
+		return cols, gwutil.Max(1, rows)
+	default:
+		return (iw + px - 1) / px, (ih + py - 1) / py
+	}
+}
+
+// fit returns the size in cell pixels of the image drawn in cols x rows cells, and the
+// offset at which it's drawn.
+func fit(w IImage, cols, rows int) (tw, th, ox, oy int) {
+	px, py := w.Opts().Mode.PixelsPerCell()
+	gw, gh := cols*px, rows*py
+	if w.Image() == nil || w.Image().Bounds().Empty() || gw == 0 || gh == 0 {
+		return 0, 0, 0, 0
+	}
+	if w.Opts().Stretch {
+		return gw, gh, 0, 0
+	}
+	b := w.Image().Bounds()
+	iw, ih := float64(b.Dx()), float64(b.Dy())
+	// The width and height of a cell pixel, in units of a cell's width
+	pw, ph := 1/float64(px), cellAspect(w.Opts())/float64(py)
+	tw = gw
+	th = int(math.Round(float64(gw) * pw * ih / iw / ph))
+	if th > gh {
+		th = gh
+		tw = int(math.Round(float64(gh) * ph * iw / ih / pw))
+	}
+	tw, th = gwutil.Max(1, gwutil.Min(tw, gw)), gwutil.Max(1, th)
+	return tw, th, (gw - tw) / 2, (gh - th) / 2
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// pixel is a color with components from 0 to 255, which is drawn only if on is true.
+type pixel struct {
+	r, g, b float64
+	on      bool
+}
+
+func (p pixel) luminance() float64 {
+	return (0.2126*p.r + 0.7152*p.g + 0.0722*p.b) / 255
+}
+
+// samples is an image resampled to w x h pixels.
+type samples struct {
+	w, h   int
+	pixels []pixel
+}
+
+func (s *samples) at(x, y int) pixel {
+	if x < 0 || y < 0 || x >= s.w || y >= s.h {
+		return pixel{}
+	}
+	return s.pixels[y*s.w+x]
+}
+
+// resample scales img to w x h pixels, averaging the image's pixels that fall in each.
+func resample(img image.Image, w, h int) *samples {
+	res := &samples{w: w, h: h, pixels: make([]pixel, w*h)}
+	if w == 0 || h == 0 {
+		return res
+	}
+	b := img.Bounds()
+	iw, ih := b.Dx(), b.Dy()
+	for ty := 0; ty < h; ty++ {
+		sy0 := b.Min.Y + ty*ih/h
+		sy1 := gwutil.Max(sy0+1, b.Min.Y+(ty+1)*ih/h)
+		for tx := 0; tx < w; tx++ {
+			sx0 := b.Min.X + tx*iw/w
+			sx1 := gwutil.Max(sx0+1, b.Min.X+(tx+1)*iw/w)
+			var r, g, bl, a float64
+			n := 0
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					// Premultiplied by alpha
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+float64(pr), g+float64(pg), bl+float64(pb), a+float64(pa)
+					n++
+				}
+			}
+			if a == 0 {
+				continue
+			}
+			res.pixels[ty*w+tx] = pixel{
+				r:  r / a * 255,
+				g:  g / a * 255,
+				b:  bl / a * 255,
+				on: a/float64(n) >= 0x8000,
+			}
+		}
+	}
+	return res
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// quantizer finds the terminal colors for pixels, dithering as configured.
+type quantizer struct {
+	dither Dither
+	spread float64 // The size of the ordered dither's offsets, per color component
+	app    gowid.IApp
+	errs   map[[2]int][3]float64 // Diffused error still to be added to pixels
+}
+
+func newQuantizer(dither Dither, app gowid.IApp) *quantizer {
+	res := &quantizer{
+		dither: dither,
+		app:    app,
+		errs:   make(map[[2]int][3]float64),
+	}
+	// Roughly the distance between neighboring colors the terminal can show
+	switch app.GetColorMode() {
+	case gowid.Mode256Colors:
+		res.spread = 51
+	case gowid.Mode88Colors:
+		res.spread = 85
+	case gowid.Mode16Colors, gowid.Mode8Colors:
+		res.spread = 128
+	}
+	return res
+}
+
+// color returns the terminal color for the pixel at (x,y), which must be visited in
+// order, row by row.
+func (q *quantizer) color(p pixel, x, y int) gowid.TCellColor {
+	in := [3]float64{p.r, p.g, p.b}
+	switch q.dither {
+	case OrderedDither:
+		d := ((bayer4[y%4][x%4]+0.5)/16 - 0.5) * q.spread
+		for i := range in {
+			in[i] += d
+		}
+	case DiffusionDither:
+		e := q.errs[[2]int{x, y}]
+		delete(q.errs, [2]int{x, y})
+		for i := range in {
+			in[i] += e[i]
+		}
+	}
+	for i := range in {
+		in[i] = math.Max(0, math.Min(255, in[i]))
+	}
+	col := gowid.RGBColor{Red: int(math.Round(in[0])), Green: int(math.Round(in[1])), Blue: int(math.Round(in[2]))}
+	res := gowid.ColorToTCellIn(col, gowid.ColorNone, q.app)
+	if q.dither == DiffusionDither {
+		r, g, b := res.ToTCell().RGB()
+		out := [3]float64{float64(r), float64(g), float64(b)}
+		for i := range in {
+			e := in[i] - out[i]
+			q.diffuse(x+1, y, i, e*7/16)
+			q.diffuse(x-1, y+1, i, e*3/16)
+			q.diffuse(x, y+1, i, e*5/16)
+			q.diffuse(x+1, y+1, i, e*1/16)
+		}
+	}
+	return res
+}
+
+// lit returns true if the pixel at (x,y) should be drawn, on a terminal that can only
+// show it or not - the pixels must be visited in order, row by row.
+func (q *quantizer) lit(p pixel, x, y int, threshold float64) bool {
+	if !p.on {
+		return false
+	}
+	l := p.luminance()
+	switch q.dither {
+	case OrderedDither:
+		l += (bayer4[y%4][x%4]+0.5)/16 - 0.5
+	case DiffusionDither:
+		l += q.errs[[2]int{x, y}][0]
+		delete(q.errs, [2]int{x, y})
+	}
+	res := l >= threshold
+	if q.dither == DiffusionDither {
+		e := l
+		if res {
+			e -= 1
+		}
+		q.diffuse(x+1, y, 0, e*7/16)
+		q.diffuse(x-1, y+1, 0, e*3/16)
+		q.diffuse(x, y+1, 0, e*5/16)
+		q.diffuse(x+1, y+1, 0, e*1/16)
+	}
+	return res
+}
+
+func (q *quantizer) diffuse(x, y, i int, e float64) {
+	k := [2]int{x, y}
+	v := q.errs[k]
+	v[i] += e
+	q.errs[k] = v
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// render draws the resampled image, offset by (ox,oy) cell pixels, in cols x rows cells.
+func render(s *samples, opt Options, cols, rows, px, py, ox, oy int, app gowid.IApp) *gowid.Canvas {
+	res := gowid.NewCanvasOfSize(cols, rows)
+	mono := app.GetColorMode() == gowid.ModeMonochrome
+	threshold := opt.Threshold
+	if threshold == 0 {
+		threshold = 0.5
+	}
+
+	// Decide each pixel first, in order, so that diffused errors reach the right pixels
+	q := newQuantizer(opt.Dither, app)
+	lit := make([]bool, s.w*s.h)
+	colors := make([]gowid.TCellColor, s.w*s.h)
+	for y := 0; y < s.h; y++ {
+		for x := 0; x < s.w; x++ {
+			p := s.at(x, y)
+			switch {
+			case mono || opt.Mode == BrailleMode:
+				lit[y*s.w+x] = q.lit(p, x, y, threshold)
+			case p.on:
+				lit[y*s.w+x] = true
+				colors[y*s.w+x] = q.color(p, x, y)
+			}
+		}
+	}
+	isLit := func(x, y int) bool {
+		x, y = x-ox, y-oy
+		return x >= 0 && y >= 0 && x < s.w && y < s.h && lit[y*s.w+x]
+	}
+
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			x, y := col*px, row*py
+			switch {
+			case opt.Mode == BrailleMode:
+				var bits uint8
+				var r, g, b float64
+				n := 0
+				for dy := 0; dy < py; dy++ {
+					for dx := 0; dx < px; dx++ {
+						if isLit(x+dx, y+dy) {
+							bits |= brailleBits[dy][dx]
+							p := s.at(x+dx-ox, y+dy-oy)
+							r, g, b = r+p.r, g+p.g, b+p.b
+							n++
+						}
+					}
+				}
+				if n == 0 {
+					continue
+				}
+				fg := gowid.ColorNone
+				if !mono {
+					col := gowid.RGBColor{Red: int(r / float64(n)), Green: int(g / float64(n)), Blue: int(b / float64(n))}
+					fg = gowid.ColorToTCellIn(col, gowid.ColorNone, app)
+				}
+				res.SetCellAt(col, row, gowid.MakeCell(rune(0x2800+int(bits)), fg, gowid.ColorNone, gowid.StyleNone))
+
+			case mono:
+				top, bottom := isLit(x, y), isLit(x, y+1)
+				if top || bottom {
+					res.SetCellAt(col, row, gowid.CellFromRune(halfBlockRunes[b2i(top)][b2i(bottom)]))
+				}
+
+			default:
+				top, bottom := isLit(x, y), isLit(x, y+1)
+				colorAt := func(y int) gowid.TCellColor {
+					return colors[(y-oy)*s.w+x-ox]
+				}
+				switch {
+				case top && bottom:
+					res.SetCellAt(col, row, gowid.MakeCell('▀', colorAt(y), colorAt(y+1), gowid.StyleNone))
+				case top:
+					res.SetCellAt(col, row, gowid.MakeCell('▀', colorAt(y), gowid.ColorNone, gowid.StyleNone))
+				case bottom:
+					res.SetCellAt(col, row, gowid.MakeCell('▄', colorAt(y+1), gowid.ColorNone, gowid.StyleNone))
+				}
+			}
+		}
+	}
+	return res
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+//======================================================================
+
+func RenderSize(w IImage, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	cols, rows := renderCells(w, size)
+	return gowid.RenderBox{C: cols, R: rows}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
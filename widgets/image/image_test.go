@@ -0,0 +1,117 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package image
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func solid(w, h int, c color.Color) *image.RGBA {
+	res := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			res.Set(x, y, c)
+		}
+	}
+	return res
+}
+
+func TestHalfBlock1(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{0xff, 0, 0, 0xff})
+	img.Set(1, 0, color.RGBA{0, 0xff, 0, 0xff})
+	img.Set(0, 1, color.RGBA{0, 0, 0xff, 0xff})
+	// (1,1) is transparent
+	w := New(img)
+
+	assert.Equal(t, gowid.RenderBox{C: 2, R: 1}, w.RenderSize(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D))
+	c := gowid.RenderCanvas(w, 2, gowid.Mode24BitColors, gowid.ExportOptions{Rows: 1})
+	assert.Equal(t, "▀▀", c.String())
+	red, _ := gowid.MakeRGBColorExt(0xff, 0, 0).ToTCellColor(gowid.Mode24BitColors)
+	green, _ := gowid.MakeRGBColorExt(0, 0xff, 0).ToTCellColor(gowid.Mode24BitColors)
+	blue, _ := gowid.MakeRGBColorExt(0, 0, 0xff).ToTCellColor(gowid.Mode24BitColors)
+	assert.Equal(t, red, c.CellAt(0, 0).ForegroundColor())
+	assert.Equal(t, blue, c.CellAt(0, 0).BackgroundColor())
+	assert.Equal(t, green, c.CellAt(1, 0).ForegroundColor())
+	assert.Equal(t, gowid.ColorNone, c.CellAt(1, 0).BackgroundColor())
+
+	// On a monochrome terminal, pixels are drawn if they're bright enough - only the green one
+	assert.Equal(t, " ▀", gowid.RenderToString(w, 2, gowid.ModeMonochrome, gowid.ExportOptions{Rows: 1}))
+}
+
+func TestAspect1(t *testing.T) {
+	w := New(solid(4, 2, color.White))
+	// Flow - as high as needed to keep the aspect ratio
+	assert.Equal(t, gowid.RenderBox{C: 4, R: 1}, w.RenderSize(gowid.RenderFlowWith{C: 4}, gowid.NotSelected, gwtest.D))
+	assert.Equal(t, "▀▀▀▀", gowid.RenderToString(w, 4, gowid.Mode256Colors))
+
+	// Centered in a box that's too high
+	assert.Equal(t, "    \n▄▄▄▄\n▀▀▀▀\n    ", gowid.RenderToString(w, 4, gowid.Mode256Colors, gowid.ExportOptions{Rows: 4}))
+	// ...or too wide
+	assert.Equal(t, "  ▀▀▀▀  ", gowid.RenderToString(w, 8, gowid.Mode256Colors, gowid.ExportOptions{Rows: 1}))
+
+	w.SetOpts(Options{Stretch: true}, gwtest.D)
+	assert.Equal(t, "▀▀▀▀▀▀▀▀", gowid.RenderToString(w, 8, gowid.Mode256Colors, gowid.ExportOptions{Rows: 1}))
+}
+
+func TestBraille1(t *testing.T) {
+	img := solid(2, 4, color.Black)
+	img.Set(0, 0, color.White)
+	img.Set(1, 3, color.White)
+	w := New(img, Options{Mode: BrailleMode})
+	assert.Equal(t, gowid.RenderBox{C: 1, R: 1}, w.RenderSize(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D))
+	c := gowid.RenderCanvas(w, 1, gowid.Mode24BitColors, gowid.ExportOptions{Rows: 1})
+	assert.Equal(t, "⢁", c.String())
+	white, _ := gowid.MakeRGBColorExt(0xff, 0xff, 0xff).ToTCellColor(gowid.Mode24BitColors)
+	assert.Equal(t, white, c.CellAt(0, 0).ForegroundColor())
+
+	w.SetOpts(Options{Mode: BrailleMode, Threshold: 0.01}, gwtest.D)
+	img.Set(0, 1, color.Gray{0x10})
+	w.SetImage(img, gwtest.D)
+	assert.Equal(t, "⢃", gowid.RenderToString(w, 1, gowid.Mode24BitColors, gowid.ExportOptions{Rows: 1}))
+}
+
+func TestDither1(t *testing.T) {
+	// A mid gray can only be shown by a pattern on a monochrome terminal
+	gray := solid(4, 8, color.Gray{0x80})
+	plain := gowid.RenderToString(New(gray, Options{Mode: BrailleMode}), 2, gowid.ModeMonochrome, gowid.ExportOptions{Rows: 2})
+	assert.Equal(t, "⣿⣿\n⣿⣿", plain)
+
+	for _, d := range []Dither{OrderedDither, DiffusionDither} {
+		s := gowid.RenderToString(New(gray, Options{Mode: BrailleMode, Dither: d}), 2, gowid.ModeMonochrome, gowid.ExportOptions{Rows: 2})
+		dots := 0
+		for _, r := range s {
+			if r >= 0x2800 && r <= 0x28ff {
+				for b := r - 0x2800; b != 0; b >>= 1 {
+					dots += int(b & 1)
+				}
+			}
+		}
+		// About half the dots are shown
+		assert.True(t, dots >= 12 && dots <= 20, "%v: %d dots", d, dots)
+	}
+
+	// In color, dithering picks neighboring colors so the average is closer
+	w := New(solid(8, 2, color.RGBA{0x80, 0x80, 0x80, 0xff}), Options{Dither: DiffusionDither})
+	c := gowid.RenderCanvas(w, 8, gowid.Mode8Colors, gowid.ExportOptions{Rows: 1})
+	seen := make(map[gowid.TCellColor]bool)
+	for x := 0; x < 8; x++ {
+		seen[c.CellAt(x, 0).ForegroundColor()] = true
+	}
+	assert.True(t, len(seen) > 1)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,468 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package inspector provides a debugging overlay that shows the widget hierarchy of an
+// application - each widget's type, render size, whether it's on the focus path and how
+// long it took to render - and highlights the screen area of the widget selected in the
+// tree.
+package inspector
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gcla/gowid"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// DefaultKey toggles the inspector unless Options.Key is set.
+var DefaultKey gowid.IKey = gowid.MakeKeyExt(tcell.KeyF12)
+
+// Options can be supplied to New. Width is the number of columns used by the tree
+// panel, 48 by default. SelectedStyle is applied to the selected row of the tree and
+// HighlightStyle to the selected widget's screen area; both are reverse video if nil.
+type Options struct {
+	Key            gowid.IKey
+	Width          int
+	SelectedStyle  gowid.ICellStyler
+	HighlightStyle gowid.ICellStyler
+}
+
+// Node describes one widget in the hierarchy, as found the last time the inspector
+// rendered. Size is the size the widget was rendered with, and Rendered the size of the
+// canvas it produced; both are nil if the inspector couldn't work out the size its
+// parent gives it. Focused is true if the widget is on the focus path. Area is the
+// widget's position within the inspector's canvas, valid if HasArea is true.
+type Node struct {
+	Widget   gowid.IWidget
+	Depth    int
+	Parent   int
+	Focused  bool
+	Size     gowid.IRenderSize
+	Rendered gowid.IRenderBox
+	Duration time.Duration
+	Area     gowid.Rect
+	HasArea  bool
+}
+
+// iRenderedSizes is implemented by containers like pile and columns that lay out their
+// children one after the other.
+type iRenderedSizes interface {
+	RenderedSubWidgetsSizes(size gowid.IRenderSize, focus gowid.Selector, focusIdx int, app gowid.IApp) []gowid.IRenderBox
+}
+
+// iWidths is implemented by containers that lay out their children left to right.
+type iWidths interface {
+	WidgetWidths(size gowid.IRenderSize, focus gowid.Selector, focusIdx int, app gowid.IApp) []int
+}
+
+type IInspector interface {
+	IsOpen() bool
+	Nodes() []Node
+	Selected() int
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IInspector
+}
+
+// Widget wraps an application's top-level widget. When the toggle key is pressed, it
+// draws a panel listing the widget hierarchy over the view; the arrow keys, page keys,
+// home and end move through the tree, left moves to the parent widget and escape closes
+// the panel. Other input goes to the view as usual.
+//
+// While open, the inspector asks the app to time the widgets rendered through
+// gowid.RenderChild (see gowid.IRenderTimer), and shows each widget's size and duration
+// from that render - so durations include the widget's children. A widget the app
+// didn't time is shown with the size its parent gives it, from SubWidgetSize or, for
+// pile and columns, from their children's rendered sizes. A widget's screen area is
+// known if it's a tracked widget (see gowid.TrackGeometry), if it fills its parent, or
+// if its parent is a pile or columns whose area is known.
+type Widget struct {
+	gowid.IWidget
+	opts     Options
+	open     bool
+	nodes    []Node
+	selected int
+	top      int
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Key == nil {
+		opt.Key = DefaultKey
+	}
+	if opt.Width == 0 {
+		opt.Width = 48
+	}
+	if opt.SelectedStyle == nil {
+		opt.SelectedStyle = gowid.MakeStyledAs(gowid.StyleReverse)
+	}
+	if opt.HighlightStyle == nil {
+		opt.HighlightStyle = gowid.MakeStyledAs(gowid.StyleReverse)
+	}
+	res := &Widget{
+		IWidget: inner,
+		opts:    opt,
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("inspector[%v]", w.SubWidget())
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.IWidget
+}
+
+func (w *Widget) SetSubWidget(inner gowid.IWidget, app gowid.IApp) {
+	w.IWidget = inner
+	gowid.RunWidgetCallbacks(w, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+func (w *Widget) IsOpen() bool {
+	return w.open
+}
+
+func (w *Widget) SetOpen(open bool, app gowid.IApp) {
+	w.open = open
+	gowid.SetRenderTiming(open, app)
+	if !open {
+		w.nodes = nil
+	}
+}
+
+// Nodes returns the widget hierarchy, depth first, as found the last time the
+// inspector rendered while open.
+func (w *Widget) Nodes() []Node {
+	return w.nodes
+}
+
+// Selected returns the index in Nodes of the widget selected in the tree.
+func (w *Widget) Selected() int {
+	return w.selected
+}
+
+func (w *Widget) SetSelected(i int, app gowid.IApp) {
+	w.selected = i
+}
+
+// Selectable returns true so that the toggle key always reaches the inspector.
+func (w *Widget) Selectable() bool {
+	return true
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return w.SubWidget().RenderSize(size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	if w.open {
+		gowid.SetRenderTiming(true, app)
+	}
+	start := time.Now()
+	c := gowid.RenderChild(w.SubWidget(), size, focus, app)
+	if !w.open {
+		return c
+	}
+
+	root := Node{
+		Widget:   w.SubWidget(),
+		Parent:   -1,
+		Focused:  focus.Focus,
+		Size:     size,
+		Rendered: gowid.RenderBox{C: c.BoxColumns(), R: c.BoxRows()},
+		Duration: time.Since(start),
+		Area:     gowid.Rect{W: c.BoxColumns(), H: c.BoxRows()},
+		HasArea:  true,
+	}
+	w.nodes = make([]Node, 0, len(w.nodes))
+	w.walk(root, app)
+
+	if w.selected >= len(w.nodes) {
+		w.selected = len(w.nodes) - 1
+	}
+	if w.selected < 0 {
+		w.selected = 0
+	}
+
+	var area gowid.Rect
+	if sel := w.nodes[w.selected]; sel.HasArea {
+		area = sel.Area.Intersect(gowid.Rect{W: c.BoxColumns(), H: c.BoxRows()})
+		for y := area.Y; y < area.Y+area.H; y++ {
+			for x := area.X; x < area.X+area.W; x++ {
+				c.SetCellAt(x, y, styled(c.CellAt(x, y), w.opts.HighlightStyle, app))
+			}
+		}
+	}
+
+	w.drawPanel(c, area, app)
+	return c
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if evk, ok := ev.(*tcell.EventKey); ok {
+		if gowid.KeysEqual(evk, w.opts.Key) {
+			w.SetOpen(!w.open, app)
+			return true
+		}
+		if w.open && len(w.nodes) > 0 {
+			handled := true
+			switch evk.Key() {
+			case tcell.KeyUp:
+				w.selected--
+			case tcell.KeyDown:
+				w.selected++
+			case tcell.KeyPgUp:
+				w.selected -= 10
+			case tcell.KeyPgDn:
+				w.selected += 10
+			case tcell.KeyHome:
+				w.selected = 0
+			case tcell.KeyEnd:
+				w.selected = len(w.nodes) - 1
+			case tcell.KeyLeft:
+				if p := w.nodes[w.selected].Parent; p >= 0 {
+					w.selected = p
+				}
+			case tcell.KeyEscape:
+				w.SetOpen(false, app)
+			default:
+				handled = false
+			}
+			if handled {
+				if w.selected < 0 {
+					w.selected = 0
+				} else if w.selected >= len(w.nodes) {
+					w.selected = len(w.nodes) - 1
+				}
+				return true
+			}
+		}
+	}
+	return gowid.UserInputIfSelectable(w.IWidget, ev, size, focus, app)
+}
+
+//======================================================================
+
+// walk appends n and its descendants to the inspector's nodes.
+func (w *Widget) walk(n Node, app gowid.IApp) {
+	idx := len(w.nodes)
+	w.nodes = append(w.nodes, n)
+	if n.Widget == nil {
+		return
+	}
+
+	focus := gowid.NotSelected
+	if n.Focused {
+		focus = gowid.Focused
+	}
+
+	switch wc := n.Widget.(type) {
+	case gowid.ICompositeMultiple:
+		subs := wc.SubWidgets()
+		focusIdx := -1
+		if f, ok := wc.(gowid.IGetFocus); ok {
+			focusIdx = f.Focus()
+		}
+		var boxes []gowid.IRenderBox
+		if r, ok := wc.(iRenderedSizes); ok && n.Size != nil {
+			boxes = r.RenderedSubWidgetsSizes(n.Size, focus, focusIdx, app)
+		}
+		_, horizontal := wc.(iWidths)
+		offset := 0
+		for i, sub := range subs {
+			// Skip the wrapper that holds the child's dimension - the container renders,
+			// and so the app times, the wrapper
+			timed := sub
+			if cw, ok := sub.(gowid.IContainerWidget); ok {
+				sub = cw.SubWidget()
+			}
+			child := Node{Widget: sub, Depth: n.Depth + 1, Parent: idx, Focused: n.Focused && i == focusIdx}
+			if i < len(boxes) && boxes[i] != nil {
+				child.Size = gowid.RenderBox{C: boxes[i].BoxColumns(), R: boxes[i].BoxRows()}
+				if n.HasArea {
+					child.Area, child.HasArea = gowid.Rect{X: n.Area.X, Y: n.Area.Y + offset, W: boxes[i].BoxColumns(), H: boxes[i].BoxRows()}, true
+					if horizontal {
+						child.Area.X, child.Area.Y = n.Area.X+offset, n.Area.Y
+					}
+				}
+				if horizontal {
+					offset += boxes[i].BoxColumns()
+				} else {
+					offset += boxes[i].BoxRows()
+				}
+			}
+			w.walk(w.measure(child, timed, app), app)
+		}
+	case gowid.IComposite:
+		child := Node{Widget: wc.SubWidget(), Depth: n.Depth + 1, Parent: idx, Focused: n.Focused}
+		if s, ok := wc.(gowid.ISubWidgetSize); ok && n.Size != nil {
+			child.Size = s.SubWidgetSize(n.Size, focus, app)
+		}
+		child = w.measure(child, child.Widget, app)
+		// A child rendered at its parent's size covers the parent's area
+		if n.HasArea && child.Rendered != nil && child.Rendered.BoxColumns() == n.Area.W && child.Rendered.BoxRows() == n.Area.H {
+			child.Area, child.HasArea = n.Area, true
+		}
+		w.walk(child, app)
+	}
+}
+
+// measure fills in the node's size and duration from the last render of timed - its
+// widget, or the wrapper its parent renders - if the app timed it, and looks up its
+// screen area if the widget's geometry is tracked.
+func (w *Widget) measure(n Node, timed gowid.IWidget, app gowid.IApp) Node {
+	if n.Widget == nil {
+		return n
+	}
+	if t, ok := gowid.RenderTimingOf(timed, app); ok {
+		n.Size, n.Rendered, n.Duration = t.Size, t.Rendered, t.Duration
+	}
+	if id, ok := n.Widget.(gowid.IIdentity); ok {
+		if r, ok := gowid.GeometryOf(id.ID(), app); ok {
+			n.Area, n.HasArea = r, true
+		}
+	}
+	return n
+}
+
+// drawPanel draws the tree over the canvas - on the right, unless that would hide the
+// highlighted area and the left wouldn't.
+func (w *Widget) drawPanel(c gowid.ICanvas, area gowid.Rect, app gowid.IApp) {
+	cols, rows := c.BoxColumns(), c.BoxRows()
+	width := w.opts.Width
+	if width > cols {
+		width = cols
+	}
+	if width <= 0 || rows <= 0 {
+		return
+	}
+	left := cols - width
+	if !area.Intersect(gowid.Rect{X: left, W: width, H: rows}).Empty() &&
+		area.Intersect(gowid.Rect{W: width, H: rows}).Empty() {
+		left = 0
+	}
+
+	// The last row describes the selected widget
+	visible := rows - 1
+	if visible < 1 {
+		visible = 1
+	}
+	if w.selected < w.top {
+		w.top = w.selected
+	} else if w.selected >= w.top+visible {
+		w.top = w.selected - visible + 1
+	}
+	if w.top > len(w.nodes)-visible {
+		w.top = len(w.nodes) - visible
+	}
+	if w.top < 0 {
+		w.top = 0
+	}
+
+	for y := 0; y < rows; y++ {
+		var line string
+		var style gowid.ICellStyler
+		switch {
+		case y == rows-1 && rows > 1:
+			line = describeArea(w.nodes[w.selected])
+		case w.top+y < len(w.nodes):
+			line = describeNode(w.nodes[w.top+y])
+			if w.top+y == w.selected {
+				style = w.opts.SelectedStyle
+			}
+		}
+		runes := []rune(line)
+		for x := 0; x < width; x++ {
+			r := ' '
+			if x < len(runes) {
+				r = runes[x]
+			}
+			c.SetCellAt(left+x, y, styled(gowid.CellFromRune(r), style, app))
+		}
+	}
+}
+
+// describeNode returns the node's row in the tree, e.g. "  *pile.Widget box 80x24 1.2ms",
+// where the asterisk marks a widget on the focus path. Tags follow the type of a widget
+// that carries metadata, e.g. "gowid.Metadata #status".
+func describeNode(n Node) string {
+	mark := " "
+	if n.Focused {
+		mark = "*"
+	}
+	size := "?"
+	if n.Size != nil && n.Rendered != nil {
+		kind := "fixed"
+		switch n.Size.(type) {
+		case gowid.IRenderBox:
+			kind = "box"
+		case gowid.IRenderFlowWith:
+			kind = "flow"
+		}
+		size = fmt.Sprintf("%s %dx%d", kind, n.Rendered.BoxColumns(), n.Rendered.BoxRows())
+	}
+	dur := "-"
+	if n.Rendered != nil {
+		dur = n.Duration.Round(time.Microsecond).String()
+	}
+	tags := ""
+	if md, ok := n.Widget.(gowid.IMetadata); ok && len(md.Tags()) > 0 {
+		tags = fmt.Sprintf(" #%s", strings.Join(md.Tags(), " #"))
+	}
+	return fmt.Sprintf("%s%s%s%s %s %s", strings.Repeat(" ", n.Depth), mark, typeName(n.Widget), tags, size, dur)
+}
+
+func describeArea(n Node) string {
+	if !n.HasArea {
+		return fmt.Sprintf("%s: area unknown", typeName(n.Widget))
+	}
+	return fmt.Sprintf("%s: %v", typeName(n.Widget), n.Area)
+}
+
+func typeName(w gowid.IWidget) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", w), "*")
+}
+
+func styled(cell gowid.Cell, styler gowid.ICellStyler, app gowid.IApp) gowid.Cell {
+	if styler == nil {
+		return cell
+	}
+	f, b, s := styler.GetStyle(app)
+	return cell.MergeDisplayAttrsUnder(gowid.MakeCell(0,
+		gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+		gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+		s))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
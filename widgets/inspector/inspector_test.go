@@ -0,0 +1,118 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package inspector
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspector1(t *testing.T) {
+	view := pile.NewFlow(
+		text.New("top"),
+		columns.NewFixed(text.New("ab"), text.New("cd")),
+	)
+	w := New(view, Options{Width: 30})
+	sz := gowid.RenderBox{C: 40, R: 4}
+
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "top", strings.TrimRight(c.String()[:40], " "))
+	assert.False(t, w.IsOpen())
+
+	press := func(k tcell.Key) {
+		w.UserInput(tcell.NewEventKey(k, 0, tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	}
+
+	press(tcell.KeyF12)
+	assert.True(t, w.IsOpen())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+
+	nodes := w.Nodes()
+	assert.Equal(t, 5, len(nodes))
+	assert.Equal(t, 0, nodes[0].Depth)
+	assert.Equal(t, 2, nodes[3].Depth)
+	assert.Equal(t, 2, nodes[3].Parent)
+	assert.Equal(t, gowid.Rect{X: 0, Y: 1, W: 40, H: 1}, nodes[2].Area)
+	assert.Equal(t, gowid.Rect{X: 2, Y: 1, W: 2, H: 1}, nodes[4].Area)
+	assert.True(t, nodes[4].HasArea)
+
+	rows := strings.Split(c.String(), "\n")
+	assert.True(t, strings.HasPrefix(rows[0][10:], "*pile.Widget box 40x4"), rows[0])
+	assert.True(t, strings.HasPrefix(rows[3][10:], "pile.Widget: 40x4@0,0"), rows[3])
+	// The whole view is highlighted
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(0, 0).Style())
+
+	press(tcell.KeyEnd)
+	press(tcell.KeyLeft)
+	assert.Equal(t, 2, w.Selected())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(0, 1).Style())
+	assert.NotEqual(t, gowid.StyleReverse, c.CellAt(0, 0).Style())
+
+	press(tcell.KeyEscape)
+	assert.False(t, w.IsOpen())
+
+	assert.Equal(t, " gowid.Metadata #title #main ? -", describeNode(Node{Widget: gowid.Tag(text.New("x"), "title", "main")}))
+}
+
+// countingWidget counts its renders.
+type countingWidget struct {
+	*text.Widget
+	renders int
+}
+
+func (w *countingWidget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	w.renders++
+	return w.Widget.Render(size, focus, app)
+}
+
+func TestInspectorTiming1(t *testing.T) {
+	leaf := &countingWidget{Widget: text.New("ab")}
+	view := pile.NewFlow(text.New("top"), pile.NewFlow(pile.NewFlow(leaf)))
+	w := New(view, Options{Width: 30})
+
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(40, 4)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	app, err := gowid.NewApp(gowid.AppArgs{Screen: screen, View: w, Log: logger})
+	assert.NoError(t, err)
+
+	w.SetOpen(true, app)
+	app.RedrawTerminal()
+
+	// The leaf is rendered once a frame, however deep it is, and timed by the app
+	assert.Equal(t, 1, leaf.renders)
+	nodes := w.Nodes()
+	n := nodes[len(nodes)-1]
+	assert.Equal(t, leaf, n.Widget)
+	assert.Equal(t, gowid.RenderBox{C: 40, R: 1}, n.Rendered)
+	assert.Equal(t, gowid.RenderFlowWith{C: 40}, n.Size)
+	assert.True(t, app.RenderTimingOn())
+
+	w.SetOpen(false, app)
+	app.RedrawTerminal()
+	assert.Equal(t, 2, leaf.renders)
+	assert.False(t, app.RenderTimingOn())
+	_, ok := gowid.RenderTimingOf(leaf, app)
+	assert.False(t, ok)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,654 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package jsontree provides a widget that displays a JSON document, or any Go value
+// that can be marshaled to JSON, as a tree whose objects and arrays can be expanded
+// and collapsed.
+package jsontree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/gcla/gowid/widgets/tree"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// Kind is the JSON type of a node.
+type Kind int
+
+const (
+	Null Kind = iota
+	Bool
+	Number
+	String
+	Object
+	Array
+)
+
+var kindNames = map[Kind]string{
+	Null:   "null",
+	Bool:   "bool",
+	Number: "number",
+	String: "string",
+	Object: "object",
+	Array:  "array",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+//======================================================================
+
+// Node is one value in the document. It implements tree.ICollapsible; only objects and
+// arrays have children.
+type Node struct {
+	key       string
+	index     int // position among the parent's children
+	kind      Kind
+	scalar    string // the JSON encoding of a scalar
+	children  []*Node
+	parent    *Node
+	depth     int
+	collapsed bool
+}
+
+var _ tree.ICollapsible = (*Node)(nil)
+
+type nodeIterator struct {
+	node *Node
+	cur  int
+}
+
+func (i *nodeIterator) Value() tree.IModel {
+	return i.node.children[i.cur]
+}
+
+func (i *nodeIterator) Next() bool {
+	i.cur++
+	return !i.node.collapsed && i.cur < len(i.node.children)
+}
+
+// Key returns the node's key if its parent is an object, or the empty string.
+func (n *Node) Key() string {
+	return n.key
+}
+
+// Index returns the node's position within its parent.
+func (n *Node) Index() int {
+	return n.index
+}
+
+func (n *Node) Kind() Kind {
+	return n.kind
+}
+
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// Nodes returns the node's children, whether or not it is collapsed.
+func (n *Node) Nodes() []*Node {
+	return n.children
+}
+
+// Leaf returns a short description of the node, used by tree.IModel.
+func (n *Node) Leaf() string {
+	return n.label() + n.summary()
+}
+
+func (n *Node) Children() tree.IIterator {
+	return &nodeIterator{node: n, cur: -1}
+}
+
+func (n *Node) IsCollapsed() bool {
+	return n.collapsed
+}
+
+func (n *Node) SetCollapsed(app gowid.IApp, collapsed bool) {
+	n.collapsed = collapsed
+}
+
+func (n *Node) String() string {
+	return n.JSON()
+}
+
+// JSON returns the compact JSON encoding of the node, with object keys in document
+// order.
+func (n *Node) JSON() string {
+	var buf bytes.Buffer
+	n.write(&buf)
+	return buf.String()
+}
+
+// PrettyJSON returns the JSON encoding of the node, indented.
+func (n *Node) PrettyJSON() string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(n.JSON()), "", "  "); err != nil {
+		return n.JSON()
+	}
+	return buf.String()
+}
+
+// Text returns the value of a scalar as displayed - strings unquoted - or the empty
+// string for an object or array.
+func (n *Node) Text() string {
+	if n.kind == String {
+		var s string
+		json.Unmarshal([]byte(n.scalar), &s)
+		return s
+	}
+	return n.scalar
+}
+
+var identRE = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Path returns a JSONPath expression locating the node in the document, e.g.
+// $.items[2].name.
+func (n *Node) Path() string {
+	if n.parent == nil {
+		return "$"
+	}
+	switch {
+	case n.parent.kind == Array:
+		return fmt.Sprintf("%s[%d]", n.parent.Path(), n.index)
+	case identRE.MatchString(n.key):
+		return n.parent.Path() + "." + n.key
+	default:
+		return n.parent.Path() + "[" + strconv.Quote(n.key) + "]"
+	}
+}
+
+func (n *Node) write(buf *bytes.Buffer) {
+	switch n.kind {
+	case Object:
+		buf.WriteByte('{')
+		for i, c := range n.children {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(c.key)
+			buf.Write(k)
+			buf.WriteByte(':')
+			c.write(buf)
+		}
+		buf.WriteByte('}')
+	case Array:
+		buf.WriteByte('[')
+		for i, c := range n.children {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			c.write(buf)
+		}
+		buf.WriteByte(']')
+	default:
+		buf.WriteString(n.scalar)
+	}
+}
+
+// label is the key or array index shown before the value.
+func (n *Node) label() string {
+	switch {
+	case n.parent == nil:
+		return ""
+	case n.parent.kind == Array:
+		return fmt.Sprintf("%d: ", n.index)
+	default:
+		k, _ := json.Marshal(n.key)
+		return string(k) + ": "
+	}
+}
+
+// summary is the value shown for the node.
+func (n *Node) summary() string {
+	switch n.kind {
+	case Object:
+		return fmt.Sprintf("{} %d %s", len(n.children), plural(len(n.children), "key", "keys"))
+	case Array:
+		return fmt.Sprintf("[] %d %s", len(n.children), plural(len(n.children), "item", "items"))
+	default:
+		return n.scalar
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return gowid.Translate(one)
+	}
+	return gowid.Translate(many)
+}
+
+//======================================================================
+
+// Parse reads a JSON document, keeping the order of object keys.
+func Parse(r io.Reader) (*Node, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	root, err := parse(dec, tok, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+	return root, nil
+}
+
+func parse(dec *json.Decoder, tok json.Token, parent *Node, index int) (*Node, error) {
+	res := &Node{
+		parent: parent,
+		index:  index,
+	}
+	if parent != nil {
+		res.depth = parent.depth + 1
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			res.kind = Object
+		} else {
+			res.kind = Array
+		}
+		for dec.More() {
+			var key string
+			if res.kind == Object {
+				ktok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key = ktok.(string)
+			}
+			vtok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			child, err := parse(dec, vtok, res, len(res.children))
+			if err != nil {
+				return nil, err
+			}
+			child.key = key
+			res.children = append(res.children, child)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case nil:
+		res.kind = Null
+		res.scalar = "null"
+	case bool:
+		res.kind = Bool
+		res.scalar = strconv.FormatBool(t)
+	case json.Number:
+		res.kind = Number
+		res.scalar = t.String()
+	case string:
+		res.kind = String
+		b, _ := json.Marshal(t)
+		res.scalar = string(b)
+	}
+	return res, nil
+}
+
+//======================================================================
+
+// DefaultStyles styles values with palette entries named "jsontree-" followed by the
+// kind, e.g. "jsontree-string".
+var DefaultStyles = map[Kind]gowid.ICellStyler{
+	Null:   gowid.MakePaletteRef("jsontree-null"),
+	Bool:   gowid.MakePaletteRef("jsontree-bool"),
+	Number: gowid.MakePaletteRef("jsontree-number"),
+	String: gowid.MakePaletteRef("jsontree-string"),
+	Object: gowid.MakePaletteRef("jsontree-object"),
+	Array:  gowid.MakePaletteRef("jsontree-array"),
+}
+
+// Options can be supplied to New. Objects and arrays at depth CollapseDepth or deeper
+// start collapsed - the root is at depth 0; if CollapseDepth is zero, everything starts
+// expanded. If Styles is nil, DefaultStyles is used; if KeyStyle is nil, the palette
+// entry "jsontree-key" is used. The focus row is rendered with FocusStyle, reverse
+// video by default, and is altered by CopySelected when chosen in copy mode.
+type Options struct {
+	CollapseDepth int
+	Styles        map[Kind]gowid.ICellStyler
+	KeyStyle      gowid.ICellStyler
+	FocusStyle    gowid.ICellStyler
+	CopySelected  gowid.IClipboardSelected
+}
+
+type IWidget interface {
+	gowid.IWidget
+	Root() *Node
+	FocusNode() *Node
+}
+
+// Widget displays a JSON document as a tree, one value per line. Enter or space
+// expands or collapses the object or array in focus, right expands it and left
+// collapses it or moves to its parent. After Search, n and N move to the next and
+// previous match.
+type Widget struct {
+	*list.Widget
+	root   *Node
+	walker *tree.TreeWalker
+	query  string
+	opts   Options
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+// New returns a widget displaying root, which can be built with Parse.
+func New(root *Node, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Styles == nil {
+		opt.Styles = DefaultStyles
+	}
+	if opt.KeyStyle == nil {
+		opt.KeyStyle = gowid.MakePaletteRef("jsontree-key")
+	}
+	if opt.FocusStyle == nil {
+		opt.FocusStyle = gowid.MakeStyledAs(gowid.StyleReverse)
+	}
+	if opt.CopySelected == nil {
+		opt.CopySelected = styled.BlinkIfSelectedForCopy{}
+	}
+
+	if opt.CollapseDepth > 0 {
+		walk(root, func(n *Node) bool {
+			n.collapsed = len(n.children) > 0 && n.depth >= opt.CollapseDepth
+			return true
+		})
+	}
+
+	res := &Widget{
+		root: root,
+		opts: opt,
+	}
+	res.walker = tree.NewWalker(root, tree.NewPos(),
+		tree.WidgetMakerFunction(func(pos tree.IPos, tr tree.IModel) gowid.IWidget {
+			return res.makeRow(tr.(*Node))
+		}),
+		tree.DecoratorFunction(func(pos tree.IPos, tr tree.IModel, wmaker tree.IWidgetMaker) gowid.IWidget {
+			return wmaker.MakeWidget(pos, tr)
+		}),
+	)
+	res.Widget = list.New(res.walker)
+	return res
+}
+
+// NewFromJSON parses the JSON document in data and returns a widget displaying it.
+func NewFromJSON(data []byte, opts ...Options) (*Widget, error) {
+	root, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return New(root, opts...), nil
+}
+
+// NewFromValue returns a widget displaying v, which is first marshaled to JSON, so
+// struct fields appear in order and honor their json tags.
+func NewFromValue(v interface{}, opts ...Options) (*Widget, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromJSON(data, opts...)
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("jsontree[%s]", w.FocusNode().Path())
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Root() *Node {
+	return w.root
+}
+
+// FocusNode returns the node on the focus row.
+func (w *Widget) FocusNode() *Node {
+	return w.walker.Focus().(tree.IPos).GetSubStructure(w.root).(*Node)
+}
+
+// SetFocusNode moves the focus to n, expanding its ancestors so that it is visible.
+func (w *Widget) SetFocusNode(n *Node, app gowid.IApp) {
+	indices := make([]int, n.depth)
+	for cur := n; cur.parent != nil; cur = cur.parent {
+		cur.parent.collapsed = false
+		indices[cur.depth-1] = cur.index
+	}
+	w.walker.SetFocus(tree.NewPosExt(indices), app)
+}
+
+// Expand expands or collapses every object and array in the document.
+func (w *Widget) Expand(expand bool, app gowid.IApp) {
+	walk(w.root, func(n *Node) bool {
+		n.collapsed = !expand && len(n.children) > 0
+		return true
+	})
+	if !expand {
+		w.walker.SetFocus(tree.NewPos(), app)
+	}
+}
+
+// Search moves the focus to the next node, after the focus node and wrapping around
+// the document, whose key or value contains query, ignoring case. It returns false if
+// there's no such node.
+func (w *Widget) Search(query string, app gowid.IApp) bool {
+	w.query = query
+	return w.search(1, app)
+}
+
+// SearchNext repeats the last search.
+func (w *Widget) SearchNext(app gowid.IApp) bool {
+	return w.search(1, app)
+}
+
+// SearchPrevious repeats the last search backwards.
+func (w *Widget) SearchPrevious(app gowid.IApp) bool {
+	return w.search(-1, app)
+}
+
+func (w *Widget) search(dir int, app gowid.IApp) bool {
+	if w.query == "" {
+		return false
+	}
+	query := strings.ToLower(w.query)
+	nodes := make([]*Node, 0)
+	walk(w.root, func(n *Node) bool {
+		nodes = append(nodes, n)
+		return true
+	})
+	cur := 0
+	focus := w.FocusNode()
+	for i, n := range nodes {
+		if n == focus {
+			cur = i
+			break
+		}
+	}
+	for i := 1; i <= len(nodes); i++ {
+		n := nodes[(cur+dir*i+len(nodes)*2)%len(nodes)]
+		if strings.Contains(strings.ToLower(n.key), query) || strings.Contains(strings.ToLower(n.Text()), query) {
+			w.SetFocusNode(n, app)
+			return true
+		}
+	}
+	return false
+}
+
+// walk calls fn on n and its descendants, depth first, while fn returns true.
+func walk(n *Node, fn func(*Node) bool) bool {
+	if !fn(n) {
+		return false
+	}
+	for _, c := range n.children {
+		if !walk(c, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *Widget) makeRow(n *Node) gowid.IWidget {
+	marker := "  "
+	if len(n.children) > 0 {
+		if n.collapsed {
+			marker = "▸ "
+		} else {
+			marker = "▾ "
+		}
+	}
+	segs := []text.ContentSegment{
+		text.StringContent(strings.Repeat("  ", n.depth) + marker),
+	}
+	if n.parent != nil {
+		label := n.label()
+		segs = append(segs,
+			text.StyledContent(label[:len(label)-2], w.opts.KeyStyle),
+			text.StringContent(": "),
+		)
+	}
+	segs = append(segs, text.StyledContent(n.summary(), w.opts.Styles[n.kind]))
+	return &row{
+		Widget: text.NewFromContent(text.NewContent(segs)),
+		node:   n,
+		tree:   w,
+	}
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if evk, ok := ev.(*tcell.EventKey); ok {
+		n := w.FocusNode()
+		switch {
+		case evk.Key() == tcell.KeyEnter || (evk.Key() == tcell.KeyRune && evk.Rune() == ' '):
+			if len(n.children) > 0 {
+				n.collapsed = !n.collapsed
+				return true
+			}
+		case evk.Key() == tcell.KeyRight:
+			if len(n.children) > 0 && n.collapsed {
+				n.collapsed = false
+				return true
+			}
+		case evk.Key() == tcell.KeyLeft:
+			if len(n.children) > 0 && !n.collapsed {
+				n.collapsed = true
+				return true
+			} else if n.parent != nil {
+				w.SetFocusNode(n.parent, app)
+				return true
+			}
+		case evk.Key() == tcell.KeyRune && evk.Rune() == 'n':
+			if w.SearchNext(app) {
+				return true
+			}
+		case evk.Key() == tcell.KeyRune && evk.Rune() == 'N':
+			if w.SearchPrevious(app) {
+				return true
+			}
+		}
+	}
+	return w.Widget.UserInput(ev, size, focus, app)
+}
+
+//======================================================================
+
+// row displays one node. In copy mode it offers the node's value and path.
+type row struct {
+	*text.Widget
+	node *Node
+	tree *Widget
+}
+
+var _ gowid.IIdentityWidget = (*row)(nil)
+var _ gowid.IClipboard = (*row)(nil)
+
+func (w *row) ID() interface{} {
+	return w.node
+}
+
+func (w *row) Selectable() bool {
+	return true
+}
+
+func (w *row) Clips(app gowid.IApp) []gowid.ICopyResult {
+	res := []gowid.ICopyResult{
+		gowid.CopyResult{
+			Name: "Value",
+			Val:  w.node.JSON(),
+		},
+	}
+	if len(w.node.children) > 0 {
+		res = append(res, gowid.CopyResult{
+			Name: "Value (indented)",
+			Val:  w.node.PrettyJSON(),
+		})
+	} else if w.node.kind == String {
+		res = append(res, gowid.CopyResult{
+			Name: "Text",
+			Val:  w.node.Text(),
+		})
+	}
+	res = append(res, gowid.CopyResult{
+		Name: "Path",
+		Val:  w.node.Path(),
+	})
+	return res
+}
+
+func (w *row) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	claimed := false
+	if _, ok := ev.(gowid.CopyModeEvent); ok {
+		if app.InCopyMode() && app.CopyLevel() <= app.CopyModeClaimedAt() {
+			app.CopyModeClaimedAt(app.CopyLevel())
+			app.CopyModeClaimedBy(w)
+			claimed = true
+		}
+	} else if evc, ok := ev.(gowid.CopyModeClipsEvent); ok {
+		evc.Action.Collect(w.Clips(app))
+		claimed = true
+	}
+	return claimed
+}
+
+func (w *row) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	if !focus.Focus {
+		return w.Widget.Render(size, focus, app)
+	}
+	var res gowid.IWidget = styled.New(w.Widget, w.tree.opts.FocusStyle)
+	if app.InCopyMode() && app.CopyModeClaimedBy().ID() == w.ID() {
+		res = w.tree.opts.CopySelected.AlterWidget(res, app)
+	}
+	return res.Render(size, focus, app)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
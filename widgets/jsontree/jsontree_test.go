@@ -0,0 +1,101 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package jsontree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func render(w gowid.IWidget, rows int) string {
+	c := w.Render(gowid.RenderBox{C: 30, R: rows}, gowid.NotSelected, gwtest.D)
+	lines := strings.Split(c.String(), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestJSONTree1(t *testing.T) {
+	w, err := NewFromJSON([]byte(`{"zeta": 1.5, "name": "gowid", "tags": ["tui", null], "ok": true, "a b": {}}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, strings.TrimSuffix(`
+▾ {} 5 keys
+    "zeta": 1.5
+    "name": "gowid"
+  ▾ "tags": [] 2 items
+      0: "tui"
+      1: null
+    "ok": true
+    "a b": {} 0 keys
+`[1:], "\n"), render(w, 8))
+
+	assert.Equal(t, `{"zeta":1.5,"name":"gowid","tags":["tui",null],"ok":true,"a b":{}}`, w.Root().JSON())
+	assert.Equal(t, "$.tags[1]", w.Root().Nodes()[2].Nodes()[1].Path())
+	assert.Equal(t, `$["a b"]`, w.Root().Nodes()[4].Path())
+
+	assert.True(t, w.Search("TUI", gwtest.D))
+	assert.Equal(t, "$.tags[0]", w.FocusNode().Path())
+	assert.Equal(t, "tui", w.FocusNode().Text())
+
+	// Left moves to the parent, then collapses it
+	sz := gowid.RenderBox{C: 30, R: 8}
+	w.UserInput(tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "$.tags", w.FocusNode().Path())
+	w.UserInput(tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	assert.True(t, w.FocusNode().IsCollapsed())
+	assert.Equal(t, strings.TrimSuffix(`
+  ▸ "tags": [] 2 items
+    "ok": true
+    "a b": {} 0 keys
+`[1:], "\n"), render(w, 3))
+
+	// Searching again finds the match inside the collapsed array, and expands it
+	w.UserInput(tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "$.tags[0]", w.FocusNode().Path())
+	assert.False(t, w.Root().Nodes()[2].IsCollapsed())
+
+	clips := (&row{node: w.Root().Nodes()[2], tree: w}).Clips(gwtest.D)
+	assert.Equal(t, 3, len(clips))
+	assert.Equal(t, `["tui",null]`, clips[0].ClipValue())
+	assert.Equal(t, "$.tags", clips[2].ClipValue())
+
+	_, err = NewFromJSON([]byte(`{"a": 1} 2`))
+	assert.Error(t, err)
+}
+
+func TestJSONTreeValue1(t *testing.T) {
+	type inner struct {
+		B []int `json:"b"`
+	}
+	v := struct {
+		Z string `json:"z"`
+		A inner  `json:"a"`
+	}{"x", inner{[]int{1, 2}}}
+
+	w, err := NewFromValue(v, Options{CollapseDepth: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimSuffix(`
+▾ {} 2 keys
+    "z": "x"
+  ▸ "a": {} 1 key
+`[1:], "\n"), render(w, 3))
+
+	w.Expand(true, gwtest.D)
+	assert.False(t, w.Root().Nodes()[1].IsCollapsed())
+	assert.Equal(t, "[\n  1,\n  2\n]", w.Root().Nodes()[1].Nodes()[0].PrettyJSON())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
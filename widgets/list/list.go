@@ -6,6 +6,7 @@
 package list
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/gcla/gowid"
@@ -70,6 +71,14 @@ type IWalkerEnd interface {
 	Last() IWalkerPosition // nil possible if empty
 }
 
+// IReorderableWalker is implemented by an IWalker whose items can be rearranged by
+// the user. Move moves the item at from to position to, shifting the items in
+// between, and returns false if it can't. The list sets the focus afterwards.
+type IReorderableWalker interface {
+	IWalker
+	Move(from, to IWalkerPosition, app gowid.IApp) bool
+}
+
 //======================================================================
 
 type WidgetIsUnboundedError struct {
@@ -117,6 +126,7 @@ type SimpleListWalker struct {
 
 var _ IBoundedWalker = (*SimpleListWalker)(nil)
 var _ IWalkerHome = (*SimpleListWalker)(nil)
+var _ IReorderableWalker = (*SimpleListWalker)(nil)
 
 func NewSimpleListWalker(widgets []gowid.IWidget) *SimpleListWalker {
 	res := &SimpleListWalker{
@@ -186,6 +196,21 @@ func (w *SimpleListWalker) Previous(ipos IWalkerPosition) IWalkerPosition {
 	}
 }
 
+func (w *SimpleListWalker) Move(ifrom, ito IWalkerPosition, app gowid.IApp) bool {
+	from, to := int(ifrom.(ListPos)), int(ito.(ListPos))
+	if from < 0 || from >= len(w.Widgets) || to < 0 || to >= len(w.Widgets) {
+		return false
+	}
+	moved := w.Widgets[from]
+	if from < to {
+		copy(w.Widgets[from:to], w.Widgets[from+1:to+1])
+	} else {
+		copy(w.Widgets[to+1:from+1], w.Widgets[to:from])
+	}
+	w.Widgets[to] = moved
+	return true
+}
+
 //======================================================================
 
 type IListFns interface {
@@ -203,21 +228,84 @@ type Widget struct {
 	walker IWalker
 	// This says how many lines to cut from the top of the widget rendered at the top of the listbox.
 	// It might be too big to be rendered fully in the space.
-	st      state
-	options Options
+	st       state
+	options  Options
+	dragFrom IWalkerPosition // The item being dragged with the mouse, if reordering
+	dragged  bool
+	viewport *Viewport // The part of the list in view when last rendered
 	gowid.AddressProvidesID
 	*gowid.Callbacks
 	gowid.FocusCallbacks
 	gowid.IsSelectable
 }
 
+var _ gowid.ISelectionStyler = (*Widget)(nil)
+
 type Options struct {
-	//SelectedStyle gowid.ICellStyler // apply a style to the selected widget - orthogonal to focus styling
+	SelectedStyle    gowid.ICellStyler // applied to the focus widget when the list isn't in focus
+	FocusedStyle     gowid.ICellStyler // applied to the focus widget when the list is; if nil, SelectedStyle is used
 	DownKeys         []vim.KeyPress
 	UpKeys           []vim.KeyPress
 	DoNotSetSelected bool // Whether or not to set the focus.Selected field for the selected child
+	Reorderable      bool // If true, the focus item can be moved with the keyboard or dragged with the mouse
+	MoveUpKeys       []vim.KeyPress
+	MoveDownKeys     []vim.KeyPress
+}
+
+// MovedCB is the name under which callbacks are registered that run when the user
+// moves an item in a reorderable list.
+type MovedCB struct{}
+
+// Moved is passed as the extra argument to moved callbacks.
+type Moved struct {
+	From IWalkerPosition
+	To   IWalkerPosition
+}
+
+func (m Moved) String() string {
+	return fmt.Sprintf("moved[%v -> %v]", m.From, m.To)
+}
+
+// ViewportChangedCB is the name under which callbacks are registered that run when a
+// render changes the part of the list in view.
+type ViewportChangedCB struct{}
+
+// Viewport describes the part of the list in view, and is passed as the extra argument
+// to viewport callbacks. First and Last are the positions of the first and last widgets
+// at least partly in view, or nil if the list is empty. If the walker is an
+// IBoundedWalker whose positions are IBoundedWalkerPositions, Bounded is true, and Start
+// and End say how far through the list the view begins and ends, from 0 to 1 - a widget
+// partly in view counts by the fraction of its lines shown. A scrollbar can be drawn
+// from these without calling CalculateOnScreen.
+type Viewport struct {
+	First   IWalkerPosition
+	Last    IWalkerPosition
+	Start   float64
+	End     float64
+	Bounded bool
+}
+
+func (v Viewport) String() string {
+	return fmt.Sprintf("viewport[%v-%v,%.3f-%.3f]", v.First, v.Last, v.Start, v.End)
+}
+
+// Equal returns true if the viewports cover the same part of the list.
+func (v Viewport) Equal(other Viewport) bool {
+	samePos := func(a, b IWalkerPosition) bool {
+		if a == nil || b == nil {
+			return a == nil && b == nil
+		}
+		return a.Equal(b)
+	}
+	return samePos(v.First, other.First) && samePos(v.Last, other.Last) &&
+		v.Start == other.Start && v.End == other.End && v.Bounded == other.Bounded
 }
 
+var (
+	DefaultMoveUpKeys   = []vim.KeyPress{vim.NewKeyPress(tcell.KeyUp, 0, tcell.ModAlt)}
+	DefaultMoveDownKeys = []vim.KeyPress{vim.NewKeyPress(tcell.KeyDown, 0, tcell.ModAlt)}
+)
+
 type IndexedWidget struct {
 	*Widget
 	walker IBoundedWalker
@@ -240,6 +328,12 @@ func New(walker IWalker, opts ...Options) *Widget {
 	if opt.UpKeys == nil {
 		opt.UpKeys = vim.AllUpKeys
 	}
+	if opt.MoveUpKeys == nil {
+		opt.MoveUpKeys = DefaultMoveUpKeys
+	}
+	if opt.MoveDownKeys == nil {
+		opt.MoveDownKeys = DefaultMoveDownKeys
+	}
 	res := &Widget{
 		walker:  walker,
 		options: opt,
@@ -248,6 +342,7 @@ func New(walker IWalker, opts ...Options) *Widget {
 	res.goToTop()
 
 	var _ gowid.IWidget = res
+	var _ gowid.IStateful = res
 
 	return res
 }
@@ -294,6 +389,51 @@ func (w *Widget) SetState(st interface{}, app gowid.IApp) {
 	}
 }
 
+// savedState is the list's view state as saved in a gowid.Snapshot. Focus is saved only
+// if the walker's positions are ListPos values, as with SimpleListWalker.
+type savedState struct {
+	Focus       *int    `json:"focus,omitempty"`
+	LinesOffTop int     `json:"linesofftop,omitempty"`
+	Ratio       float32 `json:"ratio"`
+	RatioValid  bool    `json:"ratiovalid"`
+}
+
+// SaveState implements gowid.IStateful, saving the focus position and scroll offset.
+func (w *Widget) SaveState() (interface{}, error) {
+	res := savedState{
+		LinesOffTop: w.st.linesOffTop,
+		Ratio:       w.st.topToBottomRatio,
+		RatioValid:  w.st.topToBottomRatioValid,
+	}
+	if pos, ok := w.Walker().Focus().(ListPos); ok {
+		focus := int(pos)
+		res.Focus = &focus
+	}
+	return res, nil
+}
+
+// RestoreState implements gowid.IStateful. The focus position is restored only if it
+// is still in the list.
+func (w *Widget) RestoreState(data json.RawMessage, ctx *gowid.StateContext, app gowid.IApp) error {
+	var st savedState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	if _, ok := w.Walker().Focus().(ListPos); ok && st.Focus != nil {
+		inRange := *st.Focus >= 0
+		if bw, ok := w.Walker().(IBoundedWalker); ok {
+			inRange = inRange && *st.Focus < bw.Length()
+		}
+		if inRange {
+			w.Walker().SetFocus(ListPos(*st.Focus), app)
+		}
+	}
+	w.st.linesOffTop = st.LinesOffTop
+	w.st.topToBottomRatio = st.Ratio
+	w.st.topToBottomRatioValid = st.RatioValid
+	return nil
+}
+
 func (w *Widget) GoToTop(app gowid.IApp) {
 	w.goToTop()
 }
@@ -326,8 +466,407 @@ func (w *Widget) InMiddle() bool {
 	return w.st.topToBottomRatioValid && gwutil.AlmostEqual(float64(w.st.topToBottomRatio), 0.5)
 }
 
+// ScrollBy scrolls the list's view by the given number of lines - down if lines is
+// positive, up if negative - as far as the list's contents allow. Unlike the keys and
+// mouse wheel, which move the focus and scroll only as far as needed to show it, this
+// moves the view precisely. The focus stays put while it is still fully in view;
+// otherwise it moves to the first selectable widget that is, in the direction of the
+// scroll, and the scroll stops short if there isn't one. It returns true if the view
+// moved. The size must have a number of rows.
+func (w *Widget) ScrollBy(lines int, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	rows, ok := size.(gowid.IRows)
+	if !ok || lines == 0 {
+		return false
+	}
+	screen := rows.Rows()
+	subSize := SubWidgetSize(w, size, focus, app)
+
+	top, middle, _ := w.RenderSubwidgets(size, focus, app)
+	if middle.Widget == nil {
+		return false
+	}
+	// The row of the focus widget's first line, negative if the widget is too tall to fit
+	// and lines are cut from its top
+	above := 0
+	if middle.IsChopped() {
+		above = -gwutil.Min(w.st.linesOffTop, middle.FullCanvasLines-screen)
+	} else {
+		for _, r := range top {
+			above += r.Canvas.BoxRows()
+		}
+	}
+	height := middle.FullCanvasLines
+	before, after := w.linesAround(w.Walker().Focus(), subSize, screen+gwutil.Abs(lines)+gwutil.Abs(above), app)
+	target := clampView(above-lines, height, screen, before, after)
+	if target == above {
+		return false
+	}
+
+	if fitsView(target, height, screen) {
+		w.placeFocus(target, height, screen)
+		return true
+	}
+
+	// The focus widget is leaving the view, so find a widget in view to take the focus
+	cur := w.Walker().Focus()
+	if lines > 0 {
+		y := target + gowid.RenderSize(middle.Widget, subSize, gowid.NotSelected, app).BoxRows()
+		for pos := w.Walker().Next(cur); y < screen; pos = w.Walker().Next(pos) {
+			next := w.Walker().At(pos)
+			if next == nil {
+				break
+			}
+			if next.Selectable() {
+				if h := gowid.RenderSize(next, subSize, focus, app).BoxRows(); fitsView(y, h, screen) {
+					w.focusAndPlace(pos, next, y, h, screen, app)
+					return true
+				}
+			}
+			y += gowid.RenderSize(next, subSize, gowid.NotSelected, app).BoxRows()
+		}
+	} else {
+		y := target
+		for pos := w.Walker().Previous(cur); y > 0; pos = w.Walker().Previous(pos) {
+			prev := w.Walker().At(pos)
+			if prev == nil {
+				break
+			}
+			y -= gowid.RenderSize(prev, subSize, gowid.NotSelected, app).BoxRows()
+			if prev.Selectable() {
+				if h := gowid.RenderSize(prev, subSize, focus, app).BoxRows(); fitsView(y, h, screen) {
+					w.focusAndPlace(pos, prev, y, h, screen, app)
+					return true
+				}
+			}
+		}
+	}
+
+	// Nothing else can take the focus, so scroll only as far as keeps it in view
+	target = gwutil.Max(gwutil.Min(0, screen-height), gwutil.Min(gwutil.Max(0, screen-height), target))
+	if target == above {
+		return false
+	}
+	w.placeFocus(target, height, screen)
+	return true
+}
+
+// ScrollTo moves the focus to pos, and scrolls the view to align the focus widget with
+// its top, middle or bottom - as far as the list's contents allow. A margin given with
+// gowid.VAlignTop or gowid.VAlignBottom leaves that many lines between the widget and the
+// edge of the view. It returns false if there is no widget at pos. If the size has no
+// number of rows, the whole list is in view, so only the focus is moved.
+func (w *Widget) ScrollTo(pos IWalkerPosition, align gowid.IVAlignment, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	target := w.Walker().At(pos)
+	if target == nil {
+		return false
+	}
+	rows, ok := size.(gowid.IRows)
+	if !ok {
+		w.focusAndPlace(pos, target, 0, 0, 0, app)
+		return true
+	}
+	screen := rows.Rows()
+	subSize := SubWidgetSize(w, size, focus, app)
+	height := gowid.RenderSize(target, subSize, focus, app).BoxRows()
+
+	var above int
+	switch a := align.(type) {
+	case gowid.VAlignTop:
+		above = a.Margin
+	case gowid.VAlignBottom:
+		above = screen - height - a.Margin
+	default:
+		above = (screen - height) / 2
+	}
+
+	before, after := w.linesAround(pos, subSize, screen+gwutil.Abs(above)+height, app)
+	w.focusAndPlace(pos, target, clampView(above, height, screen, before, after), height, screen, app)
+	return true
+}
+
+// focusAndPlace moves the focus to the widget at pos, whose first line is to be shown in
+// the given row of the view, and runs the focus callbacks if the focus changed.
+func (w *Widget) focusAndPlace(pos IWalkerPosition, widget gowid.IWidget, above, height, screen int, app gowid.IApp) {
+	old := w.Walker().Focus()
+	w.Walker().SetFocus(pos, app)
+	if screen > 0 {
+		w.placeFocus(above, height, screen)
+	}
+	if !old.Equal(pos) {
+		gowid.RunWidgetCallbacks(w, gowid.FocusCB{}, app, widget)
+	}
+}
+
+// placeFocus sets the list's state so the focus widget's first line is shown in the given
+// row of the view - a negative row cuts that many lines from the top of a widget too tall
+// to fit.
+func (w *Widget) placeFocus(above, height, screen int) {
+	switch {
+	case above <= 0:
+		w.goToTop()
+		w.st.linesOffTop = -above
+	case above+height == screen:
+		w.GoToBottom(nil)
+		w.st.linesOffTop = 0
+	default:
+		w.st.topToBottomRatioValid = true
+		w.st.topToBottomRatio = float32(above) / float32(screen)
+		w.st.linesOffTop = 0
+	}
+}
+
+// linesAround returns the number of lines taken by the widgets before and after the
+// widget at cur, rendered without focus, counting no further than limit in each direction.
+func (w *Widget) linesAround(cur IWalkerPosition, subSize gowid.IRenderSize, limit int, app gowid.IApp) (before, after int) {
+	for pos := w.Walker().Previous(cur); before < limit; pos = w.Walker().Previous(pos) {
+		prev := w.Walker().At(pos)
+		if prev == nil {
+			break
+		}
+		before += gowid.RenderSize(prev, subSize, gowid.NotSelected, app).BoxRows()
+	}
+	for pos := w.Walker().Next(cur); after < limit; pos = w.Walker().Next(pos) {
+		next := w.Walker().At(pos)
+		if next == nil {
+			break
+		}
+		after += gowid.RenderSize(next, subSize, gowid.NotSelected, app).BoxRows()
+	}
+	return
+}
+
+// clampView limits the row of the focus widget's first line so the view neither scrolls
+// above the first widget nor leaves blank lines below the last, given the lines before
+// and after the focus widget. If the list is shorter than the view, it starts at the top.
+func clampView(above, height, screen, before, after int) int {
+	lo := screen - height - after
+	hi := before
+	if lo > hi {
+		return hi
+	}
+	return gwutil.Max(lo, gwutil.Min(hi, above))
+}
+
+// fitsView returns true if a widget of the given height whose first line is in the given
+// row is entirely in view, or fills the view if it is too tall to fit.
+func fitsView(above, height, screen int) bool {
+	if height > screen {
+		return above <= 0 && above+height >= screen
+	}
+	return above >= 0 && above+height <= screen
+}
+
+// MoveFocusedUp moves the focus item up one place, if the walker is an
+// IReorderableWalker. It returns false if the item can't be moved.
+func (w *Widget) MoveFocusedUp(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	cur := w.Walker().Focus()
+	return w.moveItem(cur, w.Walker().Previous(cur), gwutil.NoneInt(), size, focus, app)
+}
+
+// MoveFocusedDown moves the focus item down one place, if the walker is an
+// IReorderableWalker. It returns false if the item can't be moved.
+func (w *Widget) MoveFocusedDown(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	cur := w.Walker().Focus()
+	return w.moveItem(cur, w.Walker().Next(cur), gwutil.NoneInt(), size, focus, app)
+}
+
+// moveItem moves the item at from to position to, which becomes the focus. The
+// moved item is displayed from screen row row if provided; otherwise it's kept where
+// it was, relative to the items it passes.
+func (w *Widget) moveItem(from, to IWalkerPosition, row gwutil.IntOption, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	walker, ok := w.Walker().(IReorderableWalker)
+	if !ok || w.Walker().At(from) == nil || w.Walker().At(to) == nil {
+		return false
+	}
+	if !walker.Move(from, to, app) {
+		return false
+	}
+
+	var subRenderSize gowid.IRenderSize = gowid.RenderFixed{}
+	if cols, ok := size.(gowid.IColumns); ok {
+		subRenderSize = gowid.RenderFlowWith{C: cols.Columns()}
+	}
+
+	// The items passed now lie between from and to
+	down := to.GreaterThan(from)
+	passed := 0
+	for pos := from; !pos.Equal(to); {
+		passed += gowid.RenderSize(walker.At(pos), subRenderSize, gowid.NotSelected, app).BoxRows()
+		if down {
+			pos = walker.Next(pos)
+		} else {
+			pos = walker.Previous(pos)
+		}
+	}
+
+	walker.SetFocus(to, app)
+
+	if rows, ok := size.(gowid.IRows); ok && rows.Rows() > 0 {
+		screenLines := rows.Rows()
+		focusLines := gowid.RenderSize(walker.At(to), subRenderSize, focus, app).BoxRows()
+		var above int
+		switch {
+		case !row.IsNone():
+			above = row.Val()
+		case w.AtBottom():
+			above = screenLines - focusLines - passed
+		case down:
+			above = gwutil.RoundFloatToInt(float32(screenLines)*w.st.topToBottomRatio) + passed
+		default:
+			above = gwutil.RoundFloatToInt(float32(screenLines)*w.st.topToBottomRatio) - passed
+		}
+		switch {
+		case above <= 0:
+			w.goToTop()
+		case above+focusLines >= screenLines:
+			w.GoToBottom(app)
+		default:
+			w.st.topToBottomRatioValid = true
+			w.st.topToBottomRatio = float32(above) / float32(screenLines)
+			w.st.linesOffTop = 0
+		}
+	}
+
+	gowid.RunWidgetCallbacks(w, MovedCB{}, app, w, Moved{From: from, To: to})
+	return true
+}
+
+// reorderByMouse drags the item under the pointer when the left button is pressed,
+// moving it to each row the pointer is held over. It returns true if the event was
+// used to reorder the list.
+func (w *Widget) reorderByMouse(ev *tcell.EventMouse, all []SubRenders, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	_, my := ev.Position()
+	var under IWalkerPosition
+	var underY, underRows, fromRows int
+	curY := 0
+	for _, r := range all {
+		if my >= curY && my < curY+r.Canvas.BoxRows() {
+			under, underY, underRows = r.Position, curY, r.Canvas.BoxRows()
+		}
+		if w.dragFrom != nil && r.Position.Equal(w.dragFrom) {
+			fromRows = r.Canvas.BoxRows()
+		}
+		curY += r.Canvas.BoxRows()
+	}
+	switch ev.Buttons() {
+	case tcell.Button1:
+		if !app.GetLastMouseState().LeftIsClicked() {
+			w.dragFrom, w.dragged = under, false
+			return false
+		}
+		if w.dragFrom == nil || under == nil || under.Equal(w.dragFrom) {
+			return w.dragged
+		}
+		// Put the dragged item where the pointer is
+		row := underY
+		if under.GreaterThan(w.dragFrom) {
+			row += underRows - fromRows
+		}
+		if w.moveItem(w.dragFrom, under, gwutil.SomeInt(row), size, focus, app) {
+			w.dragFrom, w.dragged = under, true
+		}
+		return true
+	case tcell.ButtonNone:
+		dragged := w.dragged
+		w.dragFrom, w.dragged = nil, false
+		return dragged
+	}
+	return false
+}
+
+func (w *Widget) OnMoved(f gowid.IWidgetChangedCallback) {
+	if w.Callbacks == nil {
+		w.Callbacks = gowid.NewCallbacks()
+	}
+	gowid.AddWidgetCallback(w.Callbacks, MovedCB{}, f)
+}
+
+func (w *Widget) RemoveOnMoved(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, MovedCB{}, f)
+}
+
+func (w *Widget) OnViewportChanged(f gowid.IWidgetChangedCallback) {
+	if w.Callbacks == nil {
+		w.Callbacks = gowid.NewCallbacks()
+	}
+	gowid.AddWidgetCallback(w.Callbacks, ViewportChangedCB{}, f)
+}
+
+func (w *Widget) RemoveOnViewportChanged(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, ViewportChangedCB{}, f)
+}
+
+// Viewport returns the part of the list in view when it was last rendered, and false if
+// it hasn't been rendered.
+func (w *Widget) Viewport() (Viewport, bool) {
+	if w.viewport == nil {
+		return Viewport{}, false
+	}
+	return *w.viewport, true
+}
+
+// viewportRendered records the part of the list in view after a render, and runs the
+// viewport callbacks if it has changed. The callbacks run during the render, so should
+// use app.Run to change the widget tree.
+func (w *Widget) viewportRendered(top []SubRenders, middle SubRenders, bottom []SubRenders, app gowid.IApp) {
+	v := w.makeViewport(top, middle, bottom)
+	if w.viewport != nil && w.viewport.Equal(v) {
+		return
+	}
+	w.viewport = &v
+	gowid.RunWidgetCallbacks(w, ViewportChangedCB{}, app, w, v)
+}
+
+// makeViewport describes the part of the list in view from the widgets rendered by
+// RenderSubwidgets.
+func (w *Widget) makeViewport(top []SubRenders, middle SubRenders, bottom []SubRenders) Viewport {
+	if middle.Widget == nil {
+		return Viewport{}
+	}
+	first, last := middle, middle
+	if len(top) > 0 {
+		first = top[len(top)-1]
+	}
+	if len(bottom) > 0 {
+		last = bottom[len(bottom)-1]
+	}
+	res := Viewport{First: first.Position, Last: last.Position}
+
+	bw, ok := w.Walker().(IBoundedWalker)
+	if !ok || bw.Length() == 0 {
+		return res
+	}
+	firstIdx, ok1 := first.Position.(IBoundedWalkerPosition)
+	lastIdx, ok2 := last.Position.(IBoundedWalkerPosition)
+	if !ok1 || !ok2 {
+		return res
+	}
+	// Widgets above the focus widget are cut at the top, and widgets below it at the
+	// bottom. The focus widget is cut only if it is too tall to fit, and then at the top
+	// by the lines scrolled past, as in RenderSubwidgets.
+	var cutTop, cutBottom int
+	if middle.IsChopped() {
+		cutTop = gwutil.Min(w.st.linesOffTop, middle.FullCanvasLines-middle.Canvas.BoxRows())
+		cutBottom = middle.FullCanvasLines - middle.Canvas.BoxRows() - cutTop
+	} else {
+		cutTop = first.FullCanvasLines - first.Canvas.BoxRows()
+		cutBottom = last.FullCanvasLines - last.Canvas.BoxRows()
+	}
+	fraction := func(lines int, r SubRenders) float64 {
+		if r.FullCanvasLines == 0 {
+			return 0
+		}
+		return float64(lines) / float64(r.FullCanvasLines)
+	}
+	n := float64(bw.Length())
+	res.Bounded = true
+	res.Start = (float64(firstIdx.ToInt()) + fraction(cutTop, first)) / n
+	res.End = (float64(lastIdx.ToInt()+1) - fraction(cutBottom, last)) / n
+	return res
+}
+
 func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
-	return gowid.CalculateRenderSizeFallback(w, size, focus, app)
+	return RenderSize(w, size, focus, app)
 }
 
 func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
@@ -346,6 +885,12 @@ func (w *Widget) SelectChild(f gowid.Selector) bool {
 	return !w.options.DoNotSetSelected && f.Selected
 }
 
+// SelectionStyle returns the style for the focus widget rendered with sel - see
+// Options.SelectedStyle and Options.FocusedStyle.
+func (w *Widget) SelectionStyle(sel gowid.Selector) gowid.ICellStyler {
+	return gowid.SelectionStyle(sel, w.options.SelectedStyle, w.options.FocusedStyle)
+}
+
 //''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
 
 type SubRenders struct {
@@ -387,13 +932,14 @@ func (w *Widget) RenderSubwidgets(size gowid.IRenderSize, focus gowid.Selector,
 			linesNeeded = rows.Rows()
 		}
 		var c gowid.ICanvas
-		//foobar := styled.New(curWidget, gowid.MakeStyledAs(gowid.StyleReverse))
 		var curToRender gowid.IWidget = curWidget
+		curFocus := focus.SelectIf(w.SelectChild(focus))
 		if haveCols {
-			c = curToRender.Render(gowid.RenderFlowWith{C: cols.Columns()}, focus.SelectIf(w.SelectChild(focus)), app)
+			c = gowid.RenderChild(curToRender, gowid.RenderFlowWith{C: cols.Columns()}, curFocus, app)
 		} else {
-			c = curToRender.Render(gowid.RenderFixed{}, focus.SelectIf(w.SelectChild(focus)), app)
+			c = gowid.RenderChild(curToRender, gowid.RenderFixed{}, curFocus, app)
 		}
+		gowid.ApplySelectionStyle(w, c, curFocus, app)
 		creallines := c.BoxRows()
 		middle = SubRenders{curWidget, curPos, c, creallines}
 
@@ -441,9 +987,9 @@ func (w *Widget) RenderSubwidgets(size gowid.IRenderSize, focus gowid.Selector,
 				} else {
 					var upC gowid.ICanvas
 					if haveCols {
-						upC = upWidget.Render(gowid.RenderFlowWith{C: cols.Columns()}, gowid.NotSelected, app)
+						upC = gowid.RenderChild(upWidget, gowid.RenderFlowWith{C: cols.Columns()}, gowid.NotSelected, app)
 					} else {
-						upC = upWidget.Render(gowid.RenderFixed{}, gowid.NotSelected, app)
+						upC = gowid.RenderChild(upWidget, gowid.RenderFixed{}, gowid.NotSelected, app)
 					}
 					upreallines := upC.BoxRows()
 					if haveLinesNeeded {
@@ -468,9 +1014,9 @@ func (w *Widget) RenderSubwidgets(size gowid.IRenderSize, focus gowid.Selector,
 				} else {
 					var downC gowid.ICanvas
 					if haveCols {
-						downC = downWidget.Render(gowid.RenderFlowWith{C: cols.Columns()}, gowid.NotSelected, app)
+						downC = gowid.RenderChild(downWidget, gowid.RenderFlowWith{C: cols.Columns()}, gowid.NotSelected, app)
 					} else {
-						downC = downWidget.Render(gowid.RenderFixed{}, gowid.NotSelected, app)
+						downC = gowid.RenderChild(downWidget, gowid.RenderFixed{}, gowid.NotSelected, app)
 					}
 					downreallines := downC.BoxRows()
 					if haveLinesNeeded {
@@ -533,11 +1079,26 @@ func CalculateOnScreen(w IListFns, size gowid.IRenderSize, focus gowid.Selector,
 	return tc, mc, bc, nil
 }
 
+// RenderSize returns the size of the canvas Render would make for the list. Rendered in a
+// box, that's the box, worked out without visiting the list's widgets. Otherwise the list
+// shows all its widgets, so its size is that of the rendered canvas - the walker may be
+// large, or make its widgets on demand, so there's no quicker way.
+func RenderSize(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	if box, ok := size.(gowid.IRenderBox); ok {
+		return gowid.RenderBox{C: box.BoxColumns(), R: box.BoxRows()}
+	}
+	return gowid.CalculateRenderSizeFallback(w, size, focus, app)
+}
+
 func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
 	rows, haveRows := size.(gowid.IRows)
 
 	top, middle, bottom := w.RenderSubwidgets(size, focus, app)
 
+	if vt, ok := w.(iViewportTracker); ok {
+		vt.viewportRendered(top, middle, bottom, app)
+	}
+
 	topC := gowid.NewCanvas()
 	bottomC := gowid.NewCanvas()
 	for i := len(top); i > 0; i-- {
@@ -556,6 +1117,12 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 	return topC
 }
 
+// iViewportTracker is implemented by lists that report the part of the list in view
+// after each render.
+type iViewportTracker interface {
+	viewportRendered(top []SubRenders, middle SubRenders, bottom []SubRenders, app gowid.IApp)
+}
+
 func calcPrefPosition(curw gowid.IWidget) gwutil.IntOption {
 	// Repeatedly unpack composite widgets until I have to stop. Look as I unpack for something that
 	// exports a prefered column API. The widget might be ContainerWidget/StyledWidget/...
@@ -675,6 +1242,15 @@ func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.S
 	}
 	startPosition := position
 
+	if evk, ok := ev.(*tcell.EventKey); ok && w.options.Reorderable {
+		switch {
+		case vim.KeyIn(evk, w.options.MoveUpKeys):
+			return w.MoveFocusedUp(size, focus, app)
+		case vim.KeyIn(evk, w.options.MoveDownKeys):
+			return w.MoveFocusedDown(size, focus, app)
+		}
+	}
+
 	dirMoved := 0
 	if evm, ok := ev.(*tcell.EventMouse); ok {
 		initTopMiddleBottom()
@@ -684,6 +1260,10 @@ func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.S
 		// infinite listboxes...
 		calculateScreenLines()
 
+		if w.options.Reorderable && w.reorderByMouse(evm, all, size, focus, app) {
+			return true
+		}
+
 		_, my := evm.Position()
 		curY := 0
 
@@ -371,6 +371,234 @@ func TestDisabled1(t *testing.T) {
 	assert.Equal(t, 3, fpos)
 }
 
+func TestReorder1(t *testing.T) {
+	defer gwtest.ClearTestApp()
+
+	walker := NewSimpleListWalker([]gowid.IWidget{
+		selectable.New(text.New("a")),
+		selectable.New(text.New("b")),
+		selectable.New(text.New("c")),
+	})
+	sz := gowid.RenderBox{C: 1, R: 3}
+	lb := New(walker, Options{Reorderable: true})
+
+	var moves []Moved
+	lb.OnMoved(gowid.MakeWidgetCallbackExt("test", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		moves = append(moves, data[0].(Moved))
+	}))
+
+	altDown := tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModAlt)
+	altUp := tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModAlt)
+
+	assert.True(t, lb.UserInput(altDown, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "b\na\nc", lb.Render(sz, gowid.Focused, gwtest.D).String())
+	assert.Equal(t, ListPos(1), walker.Focus())
+	assert.Equal(t, []Moved{{From: ListPos(0), To: ListPos(1)}}, moves)
+
+	assert.True(t, lb.UserInput(altDown, sz, gowid.Focused, gwtest.D))
+	assert.False(t, lb.UserInput(altDown, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "b\nc\na", lb.Render(sz, gowid.Focused, gwtest.D).String())
+
+	assert.True(t, lb.UserInput(altUp, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "b\na\nc", lb.Render(sz, gowid.Focused, gwtest.D).String())
+
+	// Drag "c" to the top with the mouse
+	gwtest.D.SetLastMouseState(gowid.MouseState{})
+	lb.UserInput(tcell.NewEventMouse(0, 2, tcell.Button1, 0), sz, gowid.Focused, gwtest.D)
+	gwtest.D.SetLastMouseState(gowid.MouseState{MouseLeftClicked: true})
+	assert.True(t, lb.UserInput(tcell.NewEventMouse(0, 1, tcell.Button1, 0), sz, gowid.Focused, gwtest.D))
+	assert.True(t, lb.UserInput(tcell.NewEventMouse(0, 0, tcell.Button1, 0), sz, gowid.Focused, gwtest.D))
+	assert.True(t, lb.UserInput(tcell.NewEventMouse(0, 0, tcell.ButtonNone, 0), sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "c\nb\na", lb.Render(sz, gowid.Focused, gwtest.D).String())
+	assert.Equal(t, ListPos(0), walker.Focus())
+	assert.Equal(t, 5, len(moves))
+
+	// Not reorderable
+	lb2 := New(NewSimpleListWalker([]gowid.IWidget{selectable.New(text.New("a")), selectable.New(text.New("b"))}))
+	assert.False(t, lb2.UserInput(altDown, gowid.RenderBox{C: 1, R: 2}, gowid.Focused, gwtest.D))
+}
+
+func TestScrollBy1(t *testing.T) {
+	widgets := make([]gowid.IWidget, 10)
+	for i := range widgets {
+		widgets[i] = selectable.New(text.New(fmt.Sprintf("%d", i)))
+	}
+	lb := New(NewSimpleListWalker(widgets))
+	sz := gowid.RenderBox{C: 1, R: 3}
+	view := func() string {
+		return lb.Render(sz, gowid.Focused, gwtest.D).String()
+	}
+
+	// The focus leaves the view, so the first widget in view takes it
+	assert.True(t, lb.ScrollBy(2, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "2\n3\n4", view())
+	assert.Equal(t, ListPos(2), lb.Walker().Focus())
+
+	assert.True(t, lb.ScrollBy(1, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "3\n4\n5", view())
+	assert.Equal(t, ListPos(3), lb.Walker().Focus())
+
+	// The focus is still in view, so it stays put
+	assert.True(t, lb.ScrollBy(-1, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "2\n3\n4", view())
+	assert.Equal(t, ListPos(3), lb.Walker().Focus())
+
+	// The view stops at the end of the list
+	assert.True(t, lb.ScrollBy(100, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "7\n8\n9", view())
+	assert.Equal(t, ListPos(7), lb.Walker().Focus())
+	assert.False(t, lb.ScrollBy(1, sz, gowid.Focused, gwtest.D))
+
+	assert.True(t, lb.ScrollTo(ListPos(5), gowid.VAlignMiddle{}, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "4\n5\n6", view())
+	assert.Equal(t, ListPos(5), lb.Walker().Focus())
+
+	assert.True(t, lb.ScrollTo(ListPos(9), gowid.VAlignTop{}, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "7\n8\n9", view())
+
+	assert.True(t, lb.ScrollTo(ListPos(0), gowid.VAlignBottom{}, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "0\n1\n2", view())
+	assert.False(t, lb.ScrollTo(ListPos(10), gowid.VAlignTop{}, sz, gowid.Focused, gwtest.D))
+
+	// A widget too tall to fit is scrolled through line by line
+	lb = New(NewSimpleListWalker([]gowid.IWidget{
+		selectable.New(text.New("a\nb\nc\nd")),
+		selectable.New(text.New("e")),
+	}))
+	assert.True(t, lb.ScrollBy(1, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "b\nc\nd", view())
+	assert.Equal(t, ListPos(0), lb.Walker().Focus())
+	assert.True(t, lb.ScrollBy(1, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, "c\nd\ne", view())
+	assert.Equal(t, ListPos(1), lb.Walker().Focus())
+}
+
+func TestViewport1(t *testing.T) {
+	widgets := make([]gowid.IWidget, 10)
+	for i := range widgets {
+		widgets[i] = selectable.New(text.New(fmt.Sprintf("%d", i)))
+	}
+	lb := New(NewSimpleListWalker(widgets))
+	sz := gowid.RenderBox{C: 1, R: 3}
+
+	var views []Viewport
+	lb.OnViewportChanged(gowid.MakeWidgetCallbackExt("test", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		views = append(views, data[0].(Viewport))
+	}))
+	_, ok := lb.Viewport()
+	assert.False(t, ok)
+
+	lb.Render(sz, gowid.Focused, gwtest.D)
+	lb.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, 1, len(views))
+	assert.Equal(t, Viewport{First: ListPos(0), Last: ListPos(2), Start: 0, End: 0.3, Bounded: true}, views[0])
+
+	lb.ScrollBy(2, sz, gowid.Focused, gwtest.D)
+	lb.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, 2, len(views))
+	v, ok := lb.Viewport()
+	assert.True(t, ok)
+	assert.Equal(t, ListPos(2), v.First)
+	assert.Equal(t, ListPos(4), v.Last)
+	assert.InDelta(t, 0.2, v.Start, 0.0001)
+	assert.InDelta(t, 0.5, v.End, 0.0001)
+
+	// A widget too tall to fit counts by the fraction of its lines in view
+	lb = New(NewSimpleListWalker([]gowid.IWidget{
+		selectable.New(text.New("a\nb\nc\nd")),
+		selectable.New(text.New("e")),
+	}))
+	lb.ScrollBy(1, sz, gowid.Focused, gwtest.D)
+	lb.Render(sz, gowid.Focused, gwtest.D)
+	v, _ = lb.Viewport()
+	assert.Equal(t, ListPos(0), v.First)
+	assert.Equal(t, ListPos(0), v.Last)
+	assert.InDelta(t, 0.125, v.Start, 0.0001)
+	assert.InDelta(t, 0.5, v.End, 0.0001)
+}
+
+func TestRenderSize1(t *testing.T) {
+	walker := NewSimpleListWalker([]gowid.IWidget{
+		text.New("one"),
+		text.New("two three four"),
+		text.New("five\nsix"),
+	})
+	walker.SetFocus(ListPos(1), gwtest.D)
+	w := New(walker)
+	for _, sz := range []gowid.IRenderSize{
+		gowid.RenderFixed{},
+		gowid.RenderFlowWith{C: 5},
+		gowid.RenderFlowWith{C: 20},
+		gowid.RenderBox{C: 4, R: 2},
+	} {
+		c := w.Render(sz, gowid.Focused, gwtest.D)
+		assert.Equal(t, gowid.RenderBox{C: c.BoxColumns(), R: c.BoxRows()}, w.RenderSize(sz, gowid.Focused, gwtest.D), "%v", sz)
+	}
+
+	empty := New(NewSimpleListWalker([]gowid.IWidget{}))
+	assert.Equal(t, gowid.RenderBox{}, empty.RenderSize(gowid.RenderFlowWith{C: 5}, gowid.Focused, gwtest.D))
+}
+
+func TestListSelectionStyle1(t *testing.T) {
+	walker := NewSimpleListWalker([]gowid.IWidget{
+		selectable.New(text.New("a")),
+		selectable.New(text.New("b")),
+	})
+	lb := New(walker, Options{
+		SelectedStyle: gowid.MakeStyledAs(gowid.StyleUnderline),
+		FocusedStyle:  gowid.MakeStyledAs(gowid.StyleReverse),
+	})
+	sz := gowid.RenderBox{C: 1, R: 2}
+
+	c := lb.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(0, 0).Style())
+	assert.Equal(t, gowid.StyleNone, c.CellAt(0, 1).Style())
+
+	c = lb.Render(sz, gowid.Selected, gwtest.D)
+	assert.Equal(t, gowid.StyleUnderline, c.CellAt(0, 0).Style())
+
+	c = lb.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, gowid.StyleNone, c.CellAt(0, 0).Style())
+
+	lb = New(walker, Options{SelectedStyle: gowid.MakeStyledAs(gowid.StyleUnderline), DoNotSetSelected: true})
+	c = lb.Render(sz, gowid.Selected, gwtest.D)
+	assert.Equal(t, gowid.StyleNone, c.CellAt(0, 0).Style())
+}
+
+// endlessWalker has a widget at every position, in both directions.
+type endlessWalker struct {
+	focus ListPos
+}
+
+func (w *endlessWalker) At(pos IWalkerPosition) gowid.IWidget {
+	return text.New(fmt.Sprintf("%d", pos.(ListPos)))
+}
+
+func (w *endlessWalker) Focus() IWalkerPosition {
+	return w.focus
+}
+
+func (w *endlessWalker) SetFocus(pos IWalkerPosition, app gowid.IApp) {
+	w.focus = pos.(ListPos)
+}
+
+func (w *endlessWalker) Next(pos IWalkerPosition) IWalkerPosition {
+	return pos.(ListPos) + 1
+}
+
+func (w *endlessWalker) Previous(pos IWalkerPosition) IWalkerPosition {
+	return pos.(ListPos) - 1
+}
+
+func TestRenderSize2(t *testing.T) {
+	// Rendered in a box, an endless list's size is found without walking it
+	w := New(&endlessWalker{})
+	sz := gowid.RenderBox{C: 3, R: 2}
+	assert.Equal(t, gowid.RenderBox{C: 3, R: 2}, w.RenderSize(sz, gowid.Focused, gwtest.D))
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "0  \n1  ", c.String())
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
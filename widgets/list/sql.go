@@ -0,0 +1,331 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package list
+
+import (
+	"database/sql"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/text"
+)
+
+//======================================================================
+
+// SQLQueryFunc returns the rows of a result set starting at offset, and at most limit
+// of them - typically by running a query with LIMIT and OFFSET clauses.
+type SQLQueryFunc func(offset, limit int) (*sql.Rows, error)
+
+// SQLRowFunc builds the widget for the row at position pos. The values are those
+// scanned from the row, in the order of the result set's columns; []byte values are
+// converted to strings.
+type SQLRowFunc func(pos int, columns []string, values []interface{}) gowid.IWidget
+
+type SQLWalkerOptions struct {
+	PageSize    int                         // rows fetched at a time; 100 if zero
+	CachedPages int                         // pages kept by a query walker; 10 if zero
+	Placeholder func(pos int) gowid.IWidget // displayed while a row loads
+}
+
+// SQLWalker is an IBoundedWalker over the results of a SQL query. Rows are fetched a
+// page at a time as the list asks for them; until a page arrives, its rows are
+// displayed using a placeholder widget. Fetching happens on a separate goroutine and
+// the results are handed to the widget goroutine with app.Run(); if no app is
+// provided, rows are fetched synchronously instead.
+//
+// A walker built with NewSQLQueryWalker re-runs the query for each page and keeps
+// only the most recently used pages. A walker built with NewSQLRowsWalker reads
+// forward through a single cursor, so it keeps every row read; its length is the
+// number of rows read so far, plus a placeholder row while more remain.
+type SQLWalker struct {
+	app     gowid.IApp
+	query   SQLQueryFunc
+	rows    *sql.Rows
+	row     SQLRowFunc
+	length  int
+	done    bool
+	columns []string
+	pages   map[int][]gowid.IWidget
+	used    []int // page indices, least recently used first
+	loading map[int]bool
+	err     error
+	focus   ListPos
+	opt     SQLWalkerOptions
+}
+
+var _ IBoundedWalker = (*SQLWalker)(nil)
+var _ IWalkerHome = (*SQLWalker)(nil)
+var _ IWalkerEnd = (*SQLWalker)(nil)
+
+// NewSQLQueryWalker returns a walker over count rows, fetched in pages by calling
+// query.
+func NewSQLQueryWalker(app gowid.IApp, count int, query SQLQueryFunc, row SQLRowFunc, opts ...SQLWalkerOptions) *SQLWalker {
+	res := newSQLWalker(app, row, opts...)
+	res.query = query
+	res.length = count
+	res.done = true
+	return res
+}
+
+// NewSQLRowsWalker returns a walker that reads forward through rows, which it closes
+// once exhausted.
+func NewSQLRowsWalker(app gowid.IApp, rows *sql.Rows, row SQLRowFunc, opts ...SQLWalkerOptions) *SQLWalker {
+	res := newSQLWalker(app, row, opts...)
+	res.rows = rows
+	return res
+}
+
+func newSQLWalker(app gowid.IApp, row SQLRowFunc, opts ...SQLWalkerOptions) *SQLWalker {
+	var opt SQLWalkerOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.PageSize <= 0 {
+		opt.PageSize = 100
+	}
+	if opt.CachedPages <= 0 {
+		opt.CachedPages = 10
+	}
+	if opt.Placeholder == nil {
+		opt.Placeholder = func(int) gowid.IWidget {
+			return text.New("…")
+		}
+	}
+	return &SQLWalker{
+		app:     app,
+		row:     row,
+		pages:   make(map[int][]gowid.IWidget),
+		loading: make(map[int]bool),
+		opt:     opt,
+	}
+}
+
+// Err returns the last error encountered fetching rows, or nil.
+func (w *SQLWalker) Err() error {
+	return w.err
+}
+
+// Columns returns the names of the result set's columns, once the first page has
+// been fetched.
+func (w *SQLWalker) Columns() []string {
+	return w.columns
+}
+
+// Loading returns true if a page is being fetched.
+func (w *SQLWalker) Loading() bool {
+	return len(w.loading) > 0
+}
+
+// Reset discards every fetched page and sets the number of rows, so that a query
+// walker's rows are fetched again - e.g. after the underlying data has changed.
+func (w *SQLWalker) Reset(count int, app gowid.IApp) {
+	if w.query == nil {
+		return
+	}
+	w.pages = make(map[int][]gowid.IWidget)
+	w.used = nil
+	w.loading = make(map[int]bool)
+	w.length = count
+	w.err = nil
+	if int(w.focus) >= count {
+		w.focus = ListPos(count - 1)
+	}
+}
+
+func (w *SQLWalker) Length() int {
+	if w.done {
+		return w.length
+	}
+	return w.length + 1
+}
+
+func (w *SQLWalker) First() IWalkerPosition {
+	if w.Length() == 0 {
+		return nil
+	}
+	return ListPos(0)
+}
+
+func (w *SQLWalker) Last() IWalkerPosition {
+	if w.Length() == 0 {
+		return nil
+	}
+	return ListPos(w.Length() - 1)
+}
+
+func (w *SQLWalker) At(pos IWalkerPosition) gowid.IWidget {
+	ipos := int(pos.(ListPos))
+	if ipos < 0 || ipos >= w.Length() {
+		return nil
+	}
+	page := ipos / w.opt.PageSize
+	if ws, ok := w.pages[page]; ok && ipos%w.opt.PageSize < len(ws) {
+		w.touch(page)
+		return ws[ipos%w.opt.PageSize]
+	}
+	w.fetch(page)
+	if ws, ok := w.pages[page]; ok && ipos%w.opt.PageSize < len(ws) {
+		return ws[ipos%w.opt.PageSize]
+	}
+	if ipos >= w.Length() {
+		return nil
+	}
+	return w.opt.Placeholder(ipos)
+}
+
+func (w *SQLWalker) Focus() IWalkerPosition {
+	if int(w.focus) < 0 && w.Length() > 0 {
+		return ListPos(0)
+	}
+	return w.focus
+}
+
+func (w *SQLWalker) SetFocus(focus IWalkerPosition, app gowid.IApp) {
+	w.focus = focus.(ListPos)
+}
+
+func (w *SQLWalker) Next(ipos IWalkerPosition) IWalkerPosition {
+	pos := ipos.(ListPos)
+	if int(pos) >= w.Length()-1 {
+		return ListPos(-1)
+	}
+	return pos + 1
+}
+
+func (w *SQLWalker) Previous(ipos IWalkerPosition) IWalkerPosition {
+	pos := ipos.(ListPos)
+	if pos <= 0 {
+		return ListPos(-1)
+	}
+	return pos - 1
+}
+
+// touch marks page as the most recently used.
+func (w *SQLWalker) touch(page int) {
+	for i, p := range w.used {
+		if p == page {
+			w.used = append(w.used[:i], w.used[i+1:]...)
+			break
+		}
+	}
+	w.used = append(w.used, page)
+}
+
+// fetch starts loading page, unless it's already underway. A rows walker can only
+// load the page following those it already has.
+func (w *SQLWalker) fetch(page int) {
+	if w.loading[page] || w.err != nil {
+		return
+	}
+	if w.query == nil && (w.done || page != w.length/w.opt.PageSize) {
+		return
+	}
+	w.loading[page] = true
+
+	query, rows, size := w.query, w.rows, w.opt.PageSize
+	n := size
+	if query == nil {
+		// Complete the partial page last read, if there is one
+		n = size - w.length%size
+	}
+	load := func() ([]string, [][]interface{}, bool, error) {
+		if query != nil {
+			var err error
+			rows, err = query(page*size, size)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			defer rows.Close()
+		}
+		return scanSQLRows(rows, n)
+	}
+
+	if w.app == nil {
+		cols, vals, exhausted, err := load()
+		w.loaded(page, cols, vals, exhausted, err)
+		return
+	}
+
+	go func() {
+		cols, vals, exhausted, err := load()
+		w.app.Run(gowid.RunFunction(func(app gowid.IApp) {
+			w.loaded(page, cols, vals, exhausted, err)
+		}))
+	}()
+}
+
+// loaded stores a fetched page. It runs on the widget goroutine.
+func (w *SQLWalker) loaded(page int, cols []string, vals [][]interface{}, exhausted bool, err error) {
+	if !w.loading[page] {
+		// Discarded by Reset
+		return
+	}
+	delete(w.loading, page)
+	if err != nil {
+		w.err = err
+	}
+	if cols != nil {
+		w.columns = cols
+	}
+
+	start := page * w.opt.PageSize
+	ws := w.pages[page]
+	if w.query == nil {
+		start = w.length
+	}
+	for i, v := range vals {
+		ws = append(ws, w.row(start+i, w.columns, v))
+	}
+	w.pages[page] = ws
+	w.touch(page)
+
+	if w.query == nil {
+		w.length += len(vals)
+		if exhausted || err != nil {
+			w.done = true
+			w.rows.Close()
+		}
+		return
+	}
+
+	for len(w.used) > w.opt.CachedPages {
+		delete(w.pages, w.used[0])
+		w.used = w.used[1:]
+	}
+}
+
+// scanSQLRows reads up to n rows, returning true if rows has no more.
+func scanSQLRows(rows *sql.Rows, n int) ([]string, [][]interface{}, bool, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, true, err
+	}
+	res := make([][]interface{}, 0, n)
+	for len(res) < n {
+		if !rows.Next() {
+			return cols, res, true, rows.Err()
+		}
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return cols, res, true, err
+		}
+		for i, v := range vals {
+			if b, ok := v.([]byte); ok {
+				vals[i] = string(b)
+			}
+		}
+		res = append(res, vals)
+	}
+	return cols, res, false, nil
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
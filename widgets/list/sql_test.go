@@ -0,0 +1,158 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package list
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+// A driver serving the rows 0..n-1 of a single "n" column. A query with two
+// arguments is treated as (offset, limit).
+
+var fakeQueries int32
+
+type fakeDriver struct{}
+type fakeConn struct{ n int }
+type fakeStmt struct{ n int }
+type fakeRows struct{ cur, end int }
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+	var n int
+	fmt.Sscanf(name, "%d", &n)
+	return fakeConn{n: n}, nil
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{n: c.n}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	atomic.AddInt32(&fakeQueries, 1)
+	res := &fakeRows{end: s.n}
+	if len(args) == 2 {
+		res.cur = int(args[0].(int64))
+		if res.cur+int(args[1].(int64)) < res.end {
+			res.end = res.cur + int(args[1].(int64))
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.cur >= r.end {
+		return io.EOF
+	}
+	dest[0] = []byte(fmt.Sprintf("row%d", r.cur))
+	r.cur++
+	return nil
+}
+
+func init() {
+	sql.Register("gowidfake", fakeDriver{})
+}
+
+type queueApp struct {
+	gowid.IApp
+	events chan gowid.IAfterRenderEvent
+}
+
+func (a queueApp) Run(f gowid.IAfterRenderEvent) error {
+	a.events <- f
+	return nil
+}
+
+func sqlRow(pos int, cols []string, vals []interface{}) gowid.IWidget {
+	return text.New(fmt.Sprintf("%s=%v", cols[0], vals[0]))
+}
+
+func TestSQLQueryWalker1(t *testing.T) {
+	db, err := sql.Open("gowidfake", "25")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	atomic.StoreInt32(&fakeQueries, 0)
+	app := queueApp{IApp: gwtest.D, events: make(chan gowid.IAfterRenderEvent, 1)}
+	walker := NewSQLQueryWalker(app, 25, func(offset, limit int) (*sql.Rows, error) {
+		return db.Query("q", offset, limit)
+	}, sqlRow, SQLWalkerOptions{PageSize: 10, CachedPages: 2})
+
+	w := New(walker)
+	c := w.Render(gowid.RenderBox{C: 10, R: 2}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "…         \n…         ", c.String())
+	assert.True(t, walker.Loading())
+
+	(<-app.events).RunThenRenderEvent(app)
+	assert.False(t, walker.Loading())
+	c = w.Render(gowid.RenderBox{C: 10, R: 2}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "n=row0    \nn=row1    ", c.String())
+	assert.Equal(t, []string{"n"}, walker.Columns())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fakeQueries))
+
+	for _, pos := range []int{24, 12} {
+		walker.At(ListPos(pos))
+		(<-app.events).RunThenRenderEvent(app)
+		assert.Equal(t, fmt.Sprintf("n=row%d", pos), walker.At(ListPos(pos)).(*text.Widget).Content().String())
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&fakeQueries))
+
+	// Page 0 was evicted, so it's fetched again
+	assert.Equal(t, "…", walker.At(ListPos(0)).(*text.Widget).Content().String())
+	(<-app.events).RunThenRenderEvent(app)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&fakeQueries))
+	assert.Nil(t, walker.At(ListPos(25)))
+	assert.NoError(t, walker.Err())
+}
+
+func TestSQLRowsWalker1(t *testing.T) {
+	db, err := sql.Open("gowidfake", "15")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query("q")
+	assert.NoError(t, err)
+
+	walker := NewSQLRowsWalker(nil, rows, sqlRow, SQLWalkerOptions{PageSize: 10})
+	assert.Equal(t, 1, walker.Length())
+
+	w := New(walker)
+	c := w.Render(gowid.RenderBox{C: 10, R: 3}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "n=row0    \nn=row1    \nn=row2    ", c.String())
+	assert.Equal(t, 11, walker.Length())
+
+	lines := make([]string, 0)
+	for pos := walker.First(); !pos.Equal(ListPos(-1)); pos = walker.Next(pos) {
+		lines = append(lines, walker.At(pos).(*text.Widget).Content().String())
+	}
+	assert.Equal(t, 15, walker.Length())
+	assert.Equal(t, 15, len(lines))
+	assert.Equal(t, "n=row14", lines[14])
+	assert.Equal(t, "n=row10", lines[10])
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,404 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package logview provides a widget that displays a stream of log lines. It keeps a
+// bounded number of lines, follows new output unless the user has scrolled back, and
+// styles each line according to its log level.
+package logview
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+type Level int
+
+const (
+	LevelNone Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = map[Level]string{
+	LevelTrace: "TRACE",
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+	LevelFatal: "FATAL",
+}
+
+// levelWords maps the spellings of levels used by common loggers to levels.
+var levelWords = map[string]Level{
+	"TRACE": LevelTrace, "TRAC": LevelTrace,
+	"DEBUG": LevelDebug, "DEBU": LevelDebug, "DBG": LevelDebug,
+	"INFO": LevelInfo, "INF": LevelInfo,
+	"WARN": LevelWarn, "WARNING": LevelWarn, "WRN": LevelWarn,
+	"ERROR": LevelError, "ERRO": LevelError, "ERR": LevelError,
+	"FATAL": LevelFatal, "FATA": LevelFatal, "PANIC": LevelFatal, "PANI": LevelFatal,
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel guesses the level of a log line by looking for a level name, such as
+// "[WARN]" or "level=error", among its first few words. It returns LevelNone if there
+// isn't one.
+func ParseLevel(line string) Level {
+	words := strings.Fields(line)
+	for i := 0; i < len(words) && i < 4; i++ {
+		word := strings.ToUpper(words[i])
+		word = strings.TrimPrefix(word, "LEVEL=")
+		word = strings.Trim(word, "[]():\"")
+		if l, ok := levelWords[word]; ok {
+			return l
+		}
+	}
+	return LevelNone
+}
+
+// Entry is one line in the log.
+type Entry struct {
+	Time  time.Time
+	Level Level
+	Text  string
+}
+
+//======================================================================
+
+// DefaultStyles styles lines with palette entries named "logview-" followed by the
+// lower-case level, e.g. "logview-error".
+var DefaultStyles = map[Level]gowid.ICellStyler{
+	LevelTrace: gowid.MakePaletteRef("logview-trace"),
+	LevelDebug: gowid.MakePaletteRef("logview-debug"),
+	LevelInfo:  gowid.MakePaletteRef("logview-info"),
+	LevelWarn:  gowid.MakePaletteRef("logview-warn"),
+	LevelError: gowid.MakePaletteRef("logview-error"),
+	LevelFatal: gowid.MakePaletteRef("logview-fatal"),
+}
+
+// Options can be supplied to New. MaxLines bounds the lines kept - the oldest are
+// dropped first; if zero, 1000 is used. If Styles is nil, DefaultStyles is used. If
+// Timestamps is true, each line is prefixed with the time it was added, formatted with
+// TimeFormat, or "15:04:05" if that's empty.
+type Options struct {
+	MaxLines   int
+	Styles     map[Level]gowid.ICellStyler
+	Timestamps bool
+	TimeFormat string
+	TimeStyle  gowid.ICellStyler
+}
+
+type ILogView interface {
+	Len() int
+	At(i int) Entry
+	// Following returns true if the view sticks to the newest line.
+	Following() bool
+}
+
+type IWidget interface {
+	gowid.IWidget
+	ILogView
+}
+
+type FollowCB struct{}
+
+// Widget displays log lines, newest at the bottom. It is selectable - the cursor keys
+// and mouse wheel scroll back through the log, which stops the view following new
+// lines; scrolling back to the bottom, or pressing end, follows again.
+type Widget struct {
+	opts    Options
+	entries []Entry // ring buffer
+	head    int     // index of the oldest entry
+	count   int
+	back    int // lines scrolled back from the newest; 0 means following
+	*gowid.Callbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.MaxLines <= 0 {
+		opt.MaxLines = 1000
+	}
+	res := &Widget{
+		opts:      opt,
+		entries:   make([]Entry, opt.MaxLines),
+		Callbacks: gowid.NewCallbacks(),
+	}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("logview[%d]", w.count)
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Len() int {
+	return w.count
+}
+
+// At returns the i'th entry, oldest first.
+func (w *Widget) At(i int) Entry {
+	return w.entries[(w.head+i)%len(w.entries)]
+}
+
+// Append adds an entry to the log, dropping the oldest if the log is full.
+func (w *Widget) Append(e Entry, app gowid.IApp) {
+	if w.count < len(w.entries) {
+		w.entries[(w.head+w.count)%len(w.entries)] = e
+		w.count++
+	} else {
+		w.entries[w.head] = e
+		w.head = (w.head + 1) % len(w.entries)
+	}
+	// Keep the same lines in view if the user has scrolled back
+	if w.back > 0 {
+		w.back = gwutil.Min(w.back+1, w.count-1)
+	}
+}
+
+// AppendLine adds a line of text to the log, stamped with the current time, and with
+// its level determined by ParseLevel.
+func (w *Widget) AppendLine(line string, app gowid.IApp) {
+	w.Append(Entry{
+		Time:  time.Now(),
+		Level: ParseLevel(line),
+		Text:  line,
+	}, app)
+}
+
+// Clear removes all entries and follows new lines.
+func (w *Widget) Clear(app gowid.IApp) {
+	w.head = 0
+	w.count = 0
+	w.SetFollowing(true, app)
+}
+
+func (w *Widget) Following() bool {
+	return w.back == 0
+}
+
+// SetFollowing scrolls to the newest line and follows new lines if follow is true.
+// Otherwise the view scrolls back one line, after which new lines don't move it.
+func (w *Widget) SetFollowing(follow bool, app gowid.IApp) {
+	if follow == w.Following() {
+		return
+	}
+	if follow {
+		w.back = 0
+	} else {
+		w.back = gwutil.Min(1, w.count-1)
+	}
+	gowid.RunWidgetCallbacks(w.Callbacks, FollowCB{}, app, w)
+}
+
+func (w *Widget) OnFollowChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, FollowCB{}, f)
+}
+
+func (w *Widget) RemoveOnFollowChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, FollowCB{}, f)
+}
+
+func (w *Widget) styles() map[Level]gowid.ICellStyler {
+	if w.opts.Styles == nil {
+		return DefaultStyles
+	}
+	return w.opts.Styles
+}
+
+func (w *Widget) timeFormat() string {
+	if w.opts.TimeFormat == "" {
+		return "15:04:05"
+	}
+	return w.opts.TimeFormat
+}
+
+// scrollBack sets the number of lines scrolled back from the newest, running callbacks
+// if the view starts or stops following.
+func (w *Widget) scrollBack(back int, app gowid.IApp) {
+	back = gwutil.Max(0, gwutil.Min(back, w.count-1))
+	following := w.Following()
+	w.back = back
+	if following != w.Following() {
+		gowid.RunWidgetCallbacks(w.Callbacks, FollowCB{}, app, w)
+	}
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+// Writer adapts a log view widget to io.Writer, so that e.g. a standard library
+// log.Logger can write to it. Each complete line written is appended to the log. It is
+// safe to use from any goroutine - lines are added on the widget rendering goroutine.
+type Writer struct {
+	*Widget
+	gowid.IApp
+	mu      sync.Mutex
+	partial []byte
+}
+
+// NewWriter returns a Writer that appends to the widget.
+func NewWriter(w *Widget, app gowid.IApp) *Writer {
+	return &Writer{Widget: w, IApp: app}
+}
+
+// Write implements io.Writer. Text after the last newline is held until the line is
+// completed by a later write.
+func (w *Writer) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	w.partial = append(w.partial, p...)
+	var lines []string
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i == -1 {
+			break
+		}
+		lines = append(lines, strings.TrimSuffix(string(w.partial[:i]), "\r"))
+		w.partial = w.partial[i+1:]
+	}
+	w.mu.Unlock()
+
+	if len(lines) > 0 {
+		now := time.Now()
+		w.IApp.Run(gowid.RunFunction(func(app gowid.IApp) {
+			for _, line := range lines {
+				w.Widget.Append(Entry{Time: now, Level: ParseLevel(line), Text: line}, app)
+			}
+		}))
+	}
+	return len(p), nil
+}
+
+//======================================================================
+
+func RenderSize(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	switch sz := size.(type) {
+	case gowid.IRenderBox:
+		return gowid.RenderBox{C: sz.BoxColumns(), R: sz.BoxRows()}
+	case gowid.IRenderFlowWith:
+		return gowid.RenderBox{C: sz.FlowColumns(), R: w.count}
+	default:
+		panic(gowid.WidgetSizeError{Widget: w, Size: size, Required: "gowid.IRenderBox or gowid.IRenderFlowWith"})
+	}
+}
+
+// lineContent returns the styled content for an entry, including its timestamp if enabled.
+func lineContent(w *Widget, e Entry) *text.Content {
+	segs := make([]text.ContentSegment, 0, 2)
+	if w.opts.Timestamps {
+		segs = append(segs, text.StyledContent(e.Time.Format(w.timeFormat())+" ", w.opts.TimeStyle))
+	}
+	segs = append(segs, text.StyledContent(e.Text, w.styles()[e.Level]))
+	return text.NewContent(segs)
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	box := RenderSize(w, size, focus, app)
+	cols, rows := box.BoxColumns(), box.BoxRows()
+
+	// The newest line shown is w.back lines above the newest entry
+	last := w.count - 1 - w.back
+	first := gwutil.Max(0, last-rows+1)
+
+	res := gowid.NewCanvas()
+	for i := first; i < first+rows; i++ {
+		line := make([]gowid.Cell, cols)
+		if i <= last && i < w.count {
+			content := lineContent(w, w.At(i))
+			cells := make([]gowid.Cell, content.Width())
+			content.RangeOver(0, content.Length(), app, &text.ContentToCellArray{Cells: cells})
+			copy(line, cells)
+		}
+		res.AppendLine(line, false)
+	}
+	return res
+}
+
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	rows := RenderSize(w, size, focus, app).BoxRows()
+	// Can't scroll back further than a screenful from the oldest line
+	maxBack := gwutil.Max(0, w.count-rows)
+	back := w.back
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyUp:
+			back++
+		case tcell.KeyDown:
+			back--
+		case tcell.KeyPgUp:
+			back += gwutil.Max(1, rows-1)
+		case tcell.KeyPgDn:
+			back -= gwutil.Max(1, rows-1)
+		case tcell.KeyHome:
+			back = maxBack
+		case tcell.KeyEnd:
+			back = 0
+		default:
+			return false
+		}
+	case *tcell.EventMouse:
+		switch ev.Buttons() {
+		case tcell.WheelUp:
+			back++
+		case tcell.WheelDown:
+			back--
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	back = gwutil.Max(0, gwutil.Min(back, maxBack))
+	if back == w.back {
+		return false
+	}
+	w.scrollBack(back, app)
+	return true
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,81 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package logview
+
+import (
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestParseLevel1(t *testing.T) {
+	assert.Equal(t, LevelWarn, ParseLevel("2022/01/02 [WARN] disk low"))
+	assert.Equal(t, LevelError, ParseLevel(`time="x" level=error msg="oops"`))
+	assert.Equal(t, LevelInfo, ParseLevel("INFO: started"))
+	assert.Equal(t, LevelNone, ParseLevel("just some text about an error"))
+}
+
+func TestLogView1(t *testing.T) {
+	w := New(Options{MaxLines: 5})
+	for i := 0; i < 7; i++ {
+		w.AppendLine(fmt.Sprintf("line %d", i), gwtest.D)
+	}
+	assert.Equal(t, 5, w.Len())
+	assert.Equal(t, "line 2", w.At(0).Text)
+
+	sz := gowid.RenderBox{C: 6, R: 3}
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "line 4\nline 5\nline 6", c.String())
+
+	up := tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone)
+	assert.True(t, w.UserInput(up, sz, gowid.Focused, gwtest.D))
+	assert.False(t, w.Following())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "line 3\nline 4\nline 5", c.String())
+
+	// New lines don't move the view while scrolled back
+	w.AppendLine("line 7", gwtest.D)
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "line 3\nline 4\nline 5", c.String())
+
+	end := tcell.NewEventKey(tcell.KeyEnd, ' ', tcell.ModNone)
+	assert.True(t, w.UserInput(end, sz, gowid.Focused, gwtest.D))
+	assert.True(t, w.Following())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "line 5\nline 6\nline 7", c.String())
+
+	home := tcell.NewEventKey(tcell.KeyHome, ' ', tcell.ModNone)
+	assert.True(t, w.UserInput(home, sz, gowid.Focused, gwtest.D))
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "line 3\nline 4\nline 5", c.String())
+}
+
+func TestWriter1(t *testing.T) {
+	w := New(Options{Timestamps: true, TimeFormat: "15:04"})
+	logger := log.New(NewWriter(w, gwtest.D), "", 0)
+	logger.Print("ERROR first")
+	_, err := NewWriter(w, gwtest.D).Write([]byte("par"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, w.Len())
+	assert.Equal(t, LevelError, w.At(0).Level)
+
+	w.Append(Entry{Time: time.Date(2022, 1, 1, 10, 30, 0, 0, time.UTC), Text: "x"}, gwtest.D)
+	c := w.Render(gowid.RenderFlowWith{C: 8}, gowid.Focused, gwtest.D)
+	assert.Equal(t, 2, c.BoxRows())
+	assert.Equal(t, "10:30 x ", c.String()[9:])
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
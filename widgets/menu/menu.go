@@ -12,6 +12,7 @@ import (
 	"github.com/gcla/gowid/widgets/holder"
 	"github.com/gcla/gowid/widgets/null"
 	"github.com/gcla/gowid/widgets/overlay"
+	"github.com/gcla/gowid/widgets/padding"
 	tcell "github.com/gdamore/tcell/v2"
 )
 
@@ -32,6 +33,12 @@ type IWidget interface {
 	Name() string
 }
 
+// IKeepOnScreen is implemented by menus that can be moved so they aren't cut off by the
+// edge of the screen.
+type IKeepOnScreen interface {
+	KeepOnScreen() bool
+}
+
 type IOpener interface {
 	OpenMenu(*Widget, ISite, gowid.IApp) bool
 	CloseMenu(*Widget, gowid.IApp)
@@ -45,6 +52,7 @@ type Options struct {
 	NoAutoClose        bool
 	Modal              bool
 	OpenCloser         IOpener
+	KeepOnScreen       bool // If true, move the menu so it isn't cut off by the edge of the screen
 }
 
 var (
@@ -150,6 +158,10 @@ func (w *Widget) SetAutoClose(autoClose bool, app gowid.IApp) {
 	w.autoClose = autoClose
 }
 
+func (w *Widget) KeepOnScreen() bool {
+	return w.opts.KeepOnScreen
+}
+
 func (w *Widget) Width() gowid.IWidgetDimension {
 	return w.overlay.Width()
 }
@@ -292,7 +304,7 @@ func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Se
 func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
 	bfocus := focus.And(w.Overlay().BottomGetsFocus())
 
-	bottomC := w.Overlay().Bottom().Render(size, bfocus, app)
+	bottomC := gowid.RenderChild(w.Overlay().Bottom(), size, bfocus, app)
 
 	off, ok := bottomC.GetMark(w.Name())
 	if !ok {
@@ -300,6 +312,15 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 		return bottomC
 	}
 
+	if k, ok := w.(IKeepOnScreen); ok && k.KeepOnScreen() {
+		// Treat the site as an empty anchor, so the menu opens above it if there's no room below
+		tfocus := focus.And(w.Overlay().TopGetsFocus())
+		p := padding.New(w.Overlay().Top(), gowid.VAlignTop{}, w.Overlay().Height(), gowid.HAlignLeft{}, w.Overlay().Width())
+		ts := gowid.RenderSize(w.Overlay().Top(), p.SubWidgetSize(size, tfocus, app), tfocus, app)
+		off.X, off.Y = overlay.PlaceNextTo(gowid.Rect{X: off.X, Y: off.Y}, ts.BoxColumns(), ts.BoxRows(),
+			bottomC.BoxColumns(), bottomC.BoxRows(), overlay.AnchorBelow)
+	}
+
 	w.Overlay().SetVAlign(gowid.VAlignTop{off.Y}, app)
 	w.Overlay().SetHAlign(gowid.HAlignLeft{Margin: off.X}, app)
 
@@ -360,7 +381,7 @@ func (w *SiteWidget) SetNamer(m ISiteName, app gowid.IApp) {
 }
 
 func (w *SiteWidget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
-	res := w.IWidget.Render(size, focus, app)
+	res := gowid.RenderChild(w.IWidget, size, focus, app)
 	if w.Options.Namer != nil {
 		res.SetMark(w.Options.Namer.Name(), w.Options.XOffset, w.Options.YOffset)
 	}
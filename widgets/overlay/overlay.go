@@ -9,6 +9,8 @@ import (
 	"fmt"
 
 	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/vim"
 	"github.com/gcla/gowid/widgets/padding"
 	tcell "github.com/gdamore/tcell/v2"
 )
@@ -74,6 +76,14 @@ type IIgnoreLowerStyle interface {
 	IgnoreLowerStyle() bool
 }
 
+// IBlend is implemented by overlays whose layers' colors are blended rather than
+// replaced. The bottom blend is applied to the bottom widget's canvas before the top is
+// merged over it, with the top blend.
+type IBlend interface {
+	TopBlend() gowid.Blend
+	BottomBlend() gowid.Blend
+}
+
 type IWidget interface {
 	gowid.IWidget
 	IOverlay
@@ -102,21 +112,75 @@ type Widget struct {
 	width     gowid.IWidgetDimension
 	opts      Options
 	Callbacks *gowid.Callbacks
+	anchored  bool // True if the top widget was placed next to the anchor when last rendered
+	anchorV   gowid.IVAlignment
+	anchorH   gowid.IHAlignment
+	moving    bool      // True if in move mode, where keys move and resize the top widget
+	drag      dragState // The mouse drag of the top widget's frame in progress, if any
+	dragX     int       // Where the pointer was when the drag last moved the top widget
+	dragY     int
 }
 
+type dragState int
+
+const (
+	notDragging dragState = iota
+	draggingToMove
+	draggingToResize
+)
+
 var _ IIgnoreLowerStyle = (*Widget)(nil)
+var _ IBlend = (*Widget)(nil)
 
 // For callback registration
 type Top struct{}
 type Bottom struct{}
 
+// GeometryChangedCB is the name under which callbacks are registered that run when the
+// user moves or resizes a movable overlay's top widget. The callback's extra argument is
+// the top widget's new position and size, a gowid.Rect.
+type GeometryChangedCB struct{}
+
+// MoveModeCB is the name under which callbacks are registered that run when a movable
+// overlay enters or leaves move mode.
+type MoveModeCB struct{}
+
+// AnchorSide determines where the top widget of an anchored overlay is placed.
+type AnchorSide int
+
+const (
+	AnchorBelow AnchorSide = iota
+	AnchorAbove
+	AnchorRight
+	AnchorLeft
+)
+
+// Options can be supplied to New. If Anchor is set, and the bottom widget renders a
+// canvas with an area of that name - e.g. from an anchor.Widget - the top widget is
+// placed next to that area, on the side given by AnchorSide, instead of with the
+// overlay's alignments. If the top widget doesn't fit on that side, it goes on the
+// opposite side, and it's moved to keep it within the overlay if necessary.
+//
+// TopBlend determines how the top widget's colors combine with the bottom's - e.g.
+// BlendAlpha makes the top widget translucent. BottomBlend is applied to the bottom
+// widget on its own - e.g. BlendDarken dims the widgets behind a dialog.
 type Options struct {
 	BottomGetsFocus  bool
 	TopGetsNoFocus   bool
 	BottomGetsCursor bool
 	IgnoreLowerStyle bool
+	Anchor           string
+	AnchorSide       AnchorSide
+	TopBlend         gowid.Blend
+	BottomBlend      gowid.Blend
+	Movable          bool
+	MoveModeKeys     []vim.KeyPress
 }
 
+var (
+	DefaultMoveModeKeys = []vim.KeyPress{vim.NewKeyPress(tcell.KeyRune, 'm', tcell.ModAlt)}
+)
+
 func New(top, bottom gowid.IWidget,
 	valign gowid.IVAlignment, height gowid.IWidgetDimension,
 	halign gowid.IHAlignment, width gowid.IWidgetDimension,
@@ -125,6 +189,9 @@ func New(top, bottom gowid.IWidget,
 	if len(opts) > 0 {
 		opt = opts[0]
 	}
+	if opt.MoveModeKeys == nil {
+		opt.MoveModeKeys = DefaultMoveModeKeys
+	}
 	res := &Widget{
 		top:       top,
 		bottom:    bottom,
@@ -156,6 +223,14 @@ func (w *Widget) TopGetsFocus() bool {
 	return !w.opts.TopGetsNoFocus
 }
 
+func (w *Widget) TopBlend() gowid.Blend {
+	return w.opts.TopBlend
+}
+
+func (w *Widget) BottomBlend() gowid.Blend {
+	return w.opts.BottomBlend
+}
+
 func (w *Widget) Top() gowid.IWidget {
 	return w.top
 }
@@ -174,7 +249,12 @@ func (w *Widget) SetBottom(w2 gowid.IWidget, app gowid.IApp) {
 	gowid.RunWidgetCallbacks(w.Callbacks, Bottom{}, app, w)
 }
 
+// VAlign returns the vertical alignment of the top widget. If the top widget was
+// placed next to the anchor when last rendered, the alignment reflects that.
 func (w *Widget) VAlign() gowid.IVAlignment {
+	if w.anchored {
+		return w.anchorV
+	}
 	return w.vAlign
 }
 
@@ -192,7 +272,12 @@ func (w *Widget) SetHeight(height gowid.IWidgetDimension, app gowid.IApp) {
 	gowid.RunWidgetCallbacks(w.Callbacks, gowid.HeightCB{}, app, w)
 }
 
+// HAlign returns the horizontal alignment of the top widget. If the top widget was
+// placed next to the anchor when last rendered, the alignment reflects that.
 func (w *Widget) HAlign() gowid.IHAlignment {
+	if w.anchored {
+		return w.anchorH
+	}
 	return w.hAlign
 }
 
@@ -219,6 +304,9 @@ func (w *Widget) Selectable() bool {
 }
 
 func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if w.opts.Movable && w.top != nil && w.moveInput(ev, size, focus, app) {
+		return true
+	}
 	return UserInput(w, ev, size, focus, app)
 }
 
@@ -246,8 +334,287 @@ func (w *Widget) IgnoreLowerStyle() bool {
 	return w.opts.IgnoreLowerStyle
 }
 
+// Anchor returns the name of the area next to which the top widget is placed, or
+// the empty string.
+func (w *Widget) Anchor() string {
+	return w.opts.Anchor
+}
+
+func (w *Widget) SetAnchor(name string, side AnchorSide, app gowid.IApp) {
+	w.opts.Anchor = name
+	w.opts.AnchorSide = side
+	w.anchored = false
+}
+
+// Anchored returns true if the top widget was placed next to the anchor when last
+// rendered.
+func (w *Widget) Anchored() bool {
+	return w.anchored
+}
+
+// placeTop positions the top widget next to the anchor, if it can be found in the bottom
+// widget's canvas.
+func (w *Widget) placeTop(bottomC gowid.ICanvas, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) {
+	w.anchored = false
+	if w.opts.Anchor == "" {
+		return
+	}
+	r, ok := gowid.MarkedRect(bottomC, w.opts.Anchor)
+	if !ok {
+		return
+	}
+	p := padding.New(w.top, gowid.VAlignTop{}, w.height, gowid.HAlignLeft{}, w.width)
+	ts := gowid.RenderSize(w.top, p.SubWidgetSize(size, focus, app), focus, app)
+	x, y := PlaceNextTo(r, ts.BoxColumns(), ts.BoxRows(), bottomC.BoxColumns(), bottomC.BoxRows(), w.opts.AnchorSide)
+	w.anchorV = gowid.VAlignTop{Margin: y}
+	w.anchorH = gowid.HAlignLeft{Margin: x}
+	w.anchored = true
+}
+
+// Movable returns true if the user can move and resize the top widget.
+func (w *Widget) Movable() bool {
+	return w.opts.Movable
+}
+
+func (w *Widget) SetMovable(movable bool, app gowid.IApp) {
+	w.opts.Movable = movable
+	if !movable {
+		w.SetMoving(false, app)
+		w.drag = notDragging
+	}
+}
+
+// Moving returns true if the overlay is in move mode, where the cursor keys move and
+// resize the top widget.
+func (w *Widget) Moving() bool {
+	return w.moving
+}
+
+// SetMoving enters or leaves move mode. It has no effect unless the overlay is movable.
+func (w *Widget) SetMoving(moving bool, app gowid.IApp) {
+	if moving && !w.opts.Movable {
+		return
+	}
+	if moving != w.moving {
+		w.moving = moving
+		gowid.RunWidgetCallbacks(w.Callbacks, MoveModeCB{}, app, w, moving)
+	}
+}
+
+func (w *Widget) OnGeometryChanged(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, GeometryChangedCB{}, f)
+}
+
+func (w *Widget) RemoveOnGeometryChanged(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, GeometryChangedCB{}, f)
+}
+
+func (w *Widget) OnMoveMode(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, MoveModeCB{}, f)
+}
+
+func (w *Widget) RemoveOnMoveMode(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, MoveModeCB{}, f)
+}
+
+// TopRect returns the position and size of the top widget when the overlay is rendered
+// with size, relative to the overlay.
+func (w *Widget) TopRect(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.Rect {
+	if w.top == nil {
+		return gowid.Rect{}
+	}
+	p := padding.New(w.top, w.VAlign(), w.height, w.HAlign(), w.width)
+	ss := gowid.RenderSize(w.top, p.SubWidgetSize(size, focus, app), focus, app)
+	rs := gowid.RenderSize(p, size, focus, app)
+	cols, rows := rs.BoxColumns(), rs.BoxRows()
+	res := gowid.Rect{W: ss.BoxColumns(), H: ss.BoxRows()}
+
+	// This follows the placement in padding.UserInput
+	switch al := p.HAlign().(type) {
+	case gowid.HAlignRight:
+		res.X = cols - res.W
+	case gowid.HAlignMiddle:
+		res.X = cols - (res.W + (cols-res.W)/2)
+	case gowid.HAlignLeft:
+		if al.Margin+res.W <= cols {
+			res.X = al.Margin
+		} else {
+			res.X = gwutil.Max(0, cols-res.W)
+		}
+	}
+	switch al := p.VAlign().(type) {
+	case gowid.VAlignBottom:
+		res.Y = rows - res.H
+	case gowid.VAlignMiddle:
+		res.Y = -((res.H - rows) / 2)
+	case gowid.VAlignTop:
+		if rows > al.Margin {
+			res.Y = al.Margin
+		} else {
+			res.Y = rows - 1
+		}
+	}
+	return res
+}
+
+// SetTopRect places the top widget at the given position with the given size, relative
+// to the overlay when rendered with size. The rectangle is first adjusted to fit in the
+// overlay. The alignments become VAlignTop and HAlignLeft, the dimensions become
+// RenderWithUnits, and any anchor is removed. If the geometry changed, the geometry
+// callbacks are run.
+func (w *Widget) SetTopRect(r gowid.Rect, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) {
+	rs := gowid.RenderSize(w, size, focus, app)
+	cols, rows := rs.BoxColumns(), rs.BoxRows()
+	r.W = gwutil.Max(1, gwutil.Min(r.W, cols))
+	r.H = gwutil.Max(1, gwutil.Min(r.H, rows))
+	r.X = gwutil.Max(0, gwutil.Min(r.X, cols-r.W))
+	r.Y = gwutil.Max(0, gwutil.Min(r.Y, rows-r.H))
+	if r == w.TopRect(size, focus, app) {
+		return
+	}
+	w.opts.Anchor = ""
+	w.anchored = false
+	w.SetHAlign(gowid.HAlignLeft{Margin: r.X}, app)
+	w.SetVAlign(gowid.VAlignTop{Margin: r.Y}, app)
+	w.SetWidth(gowid.RenderWithUnits{U: r.W}, app)
+	w.SetHeight(gowid.RenderWithUnits{U: r.H}, app)
+	gowid.RunWidgetCallbacks(w.Callbacks, GeometryChangedCB{}, app, w, r)
+}
+
+// moveInput handles the keys of move mode, and mouse drags of the top widget's frame. It
+// returns true if the event was used to move or resize the top widget.
+func (w *Widget) moveInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		if !focus.Focus || w.opts.BottomGetsFocus {
+			return false
+		}
+		if vim.KeyIn(ev, w.opts.MoveModeKeys) {
+			w.SetMoving(!w.moving, app)
+			return true
+		}
+		if !w.moving {
+			return false
+		}
+		r := w.TopRect(size, focus, app)
+		dx, dy := 0, 0
+		switch ev.Key() {
+		case tcell.KeyLeft:
+			dx = -1
+		case tcell.KeyRight:
+			dx = 1
+		case tcell.KeyUp:
+			dy = -1
+		case tcell.KeyDown:
+			dy = 1
+		case tcell.KeyEnter, tcell.KeyEscape:
+			w.SetMoving(false, app)
+			return true
+		default:
+			// Keys don't reach the top widget in move mode
+			return true
+		}
+		if ev.Modifiers()&tcell.ModShift != 0 {
+			r.W += dx
+			r.H += dy
+		} else {
+			r.X += dx
+			r.Y += dy
+		}
+		w.SetTopRect(r, size, focus, app)
+		return true
+
+	case *tcell.EventMouse:
+		mx, my := ev.Position()
+		switch ev.Buttons() {
+		case tcell.Button1:
+			if !app.GetLastMouseState().LeftIsClicked() {
+				w.drag = notDragging
+				r := w.TopRect(size, focus, app)
+				if mx < r.X || mx >= r.X+r.W || my < r.Y || my >= r.Y+r.H {
+					return false
+				}
+				switch {
+				case mx == r.X+r.W-1 && my == r.Y+r.H-1:
+					w.drag = draggingToResize
+				case mx == r.X || mx == r.X+r.W-1 || my == r.Y || my == r.Y+r.H-1:
+					w.drag = draggingToMove
+				default:
+					return false
+				}
+				w.dragX, w.dragY = mx, my
+				return true
+			}
+			if w.drag == notDragging {
+				return false
+			}
+			r := w.TopRect(size, focus, app)
+			if w.drag == draggingToResize {
+				r.W += mx - w.dragX
+				r.H += my - w.dragY
+			} else {
+				r.X += mx - w.dragX
+				r.Y += my - w.dragY
+			}
+			w.SetTopRect(r, size, focus, app)
+			w.dragX, w.dragY = mx, my
+			return true
+		case tcell.ButtonNone:
+			dragging := w.drag != notDragging
+			w.drag = notDragging
+			return dragging
+		}
+	}
+	return false
+}
+
+type iPlaceTop interface {
+	placeTop(bottomC gowid.ICanvas, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp)
+}
+
 //======================================================================
 
+// PlaceNextTo returns the position for a widget of cols columns and rows rows placed on
+// the given side of the anchor rectangle, within an area of the given width and height.
+// If the widget doesn't fit on that side but would fit on the opposite side, it is
+// placed there instead. The result is adjusted to keep the widget in the area where
+// possible.
+func PlaceNextTo(anchor gowid.Rect, cols, rows int, width, height int, side AnchorSide) (x, y int) {
+	switch side {
+	case AnchorAbove, AnchorBelow:
+		x = anchor.X
+		above, below := anchor.Y-rows, anchor.Y+anchor.H
+		if side == AnchorAbove {
+			y = above
+			if above < 0 && below+rows <= height {
+				y = below
+			}
+		} else {
+			y = below
+			if below+rows > height && above >= 0 {
+				y = above
+			}
+		}
+	default:
+		y = anchor.Y
+		left, right := anchor.X-cols, anchor.X+anchor.W
+		if side == AnchorLeft {
+			x = left
+			if left < 0 && right+cols <= width {
+				x = right
+			}
+		} else {
+			x = right
+			if right+cols > width && left >= 0 {
+				x = left
+			}
+		}
+	}
+	x = gwutil.Max(0, gwutil.Min(x, width-cols))
+	y = gwutil.Max(0, gwutil.Min(y, height-rows))
+	return
+}
+
 func UserInput(w IOverlay, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
 	res := false
 	notOccluded := true
@@ -301,28 +668,41 @@ func Render(w IOverlay, size gowid.IRenderSize, focus gowid.Selector, app gowid.
 	bfocus := focus.And(w.BottomGetsFocus())
 	tfocus := focus.And(w.TopGetsFocus())
 
-	bottomC := w.Bottom().Render(size, bfocus, app)
+	bottomC := gowid.RenderChild(w.Bottom(), size, bfocus, app)
 	if w.Top() == nil {
 		return bottomC
 	} else {
+		if pt, ok := w.(iPlaceTop); ok {
+			pt.placeTop(bottomC, size, tfocus, app)
+		}
 		bottomC2 := bottomC.Duplicate()
+		gowid.ReleaseCanvas(bottomC)
 		p2 := padding.New(w.Top(), w.VAlign(), w.Height(), w.HAlign(), w.Width())
-		topC := p2.Render(size, tfocus, app)
+		topC := gowid.RenderChild(p2, size, tfocus, app)
+
+		var topBlend gowid.Blend
+		if wb, ok := w.(IBlend); ok {
+			gowid.BlendCanvas(bottomC2, wb.BottomBlend(), app.GetColorMode())
+			topBlend = wb.TopBlend()
+		}
 
 		var bottomC2mc iMergeWithFuncCanvas
 		ign := false
-		if wIgn, ok := w.(IIgnoreLowerStyle); ok {
-			if gc, ok := bottomC2.(iMergeWithFuncCanvas); ok {
-				bottomC2mc = gc
+		if gc, ok := bottomC2.(iMergeWithFuncCanvas); ok {
+			bottomC2mc = gc
+			if wIgn, ok := w.(IIgnoreLowerStyle); ok {
 				ign = wIgn.IgnoreLowerStyle()
 			}
 		}
 
 		if ign {
-			bottomC2mc.MergeWithFunc(topC, 0, 0, mergeAllExceptUpperStyle, w.BottomGetsCursor())
+			bottomC2mc.MergeWithFunc(topC, 0, 0, topBlend.MergeFunc(mergeAllExceptUpperStyle, app.GetColorMode()), w.BottomGetsCursor())
+		} else if !topBlend.IsReplace() && bottomC2mc != nil {
+			bottomC2mc.MergeWithFunc(topC, 0, 0, topBlend.MergeFunc(gowid.Cell.MergeUnder, app.GetColorMode()), w.BottomGetsCursor())
 		} else {
 			bottomC2.MergeUnder(topC, 0, 0, w.BottomGetsCursor())
 		}
+		gowid.ReleaseCanvas(topC)
 
 		return bottomC2
 	}
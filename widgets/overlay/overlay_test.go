@@ -5,13 +5,20 @@
 package overlay
 
 import (
+	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/anchor"
+	"github.com/gcla/gowid/widgets/fill"
+	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/padding"
 	"github.com/gcla/gowid/widgets/styled"
 	"github.com/gcla/gowid/widgets/text"
 	"github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -39,3 +46,187 @@ func TestOverlay1(t *testing.T) {
 	assert.Equal(t, "toptom", c.String())
 	assert.Equal(t, tcell.AttrMask(0), c.CellAt(0, 0).Style().OnOff&tcell.AttrBold)
 }
+
+func TestBlend1(t *testing.T) {
+	grey := gowid.MakeRGBColor("#888")
+	tw := text.New("top")
+	bw := styled.New(text.New("bottom"), gowid.MakePaletteEntry(grey, grey))
+	ov := New(tw, bw, gowid.VAlignTop{}, gowid.RenderFixed{}, gowid.HAlignLeft{}, gowid.RenderFixed{},
+		Options{
+			BottomBlend: gowid.Blend{Mode: gowid.BlendDarken, Alpha: 1},
+		})
+	c := ov.Render(gowid.RenderFlowWith{C: 6}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "toptom", c.String())
+	black, _ := gowid.MakeRGBColor("#000").ToTCellColor(gowid.Mode256Colors)
+	for x := 0; x < 6; x++ {
+		assert.Equal(t, black, c.CellAt(x, 0).BackgroundColor())
+	}
+
+	white := gowid.MakeRGBColor("#fff")
+	ov = New(styled.New(tw, gowid.MakePaletteEntry(white, white)), bw, gowid.VAlignTop{}, gowid.RenderFixed{}, gowid.HAlignLeft{}, gowid.RenderFixed{},
+		Options{
+			TopBlend: gowid.Blend{Mode: gowid.BlendAlpha, Alpha: 0.5},
+		})
+	c = ov.Render(gowid.RenderFlowWith{C: 6}, gowid.Focused, gwtest.D)
+	mixed := c.CellAt(0, 0).BackgroundColor()
+	greyc, _ := grey.ToTCellColor(gowid.Mode256Colors)
+	whitec, _ := white.ToTCellColor(gowid.Mode256Colors)
+	assert.NotEqual(t, greyc, mixed)
+	assert.NotEqual(t, whitec, mixed)
+	assert.Equal(t, greyc, c.CellAt(3, 0).BackgroundColor())
+}
+
+func TestAnchor1(t *testing.T) {
+	site := padding.New(anchor.New(text.New("ab"), "site"), gowid.VAlignTop{Margin: 1}, gowid.RenderFixed{}, gowid.HAlignLeft{Margin: 2}, gowid.RenderFixed{})
+	ov := New(text.New("XYZ"), site, gowid.VAlignTop{}, gowid.RenderFixed{}, gowid.HAlignLeft{}, gowid.RenderFixed{},
+		Options{Anchor: "site"})
+	sz := gowid.RenderBox{C: 6, R: 4}
+
+	c := ov.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "      \n  ab  \n  XYZ \n      ", c.String())
+	assert.True(t, ov.Anchored())
+	assert.Equal(t, gowid.VAlignTop{Margin: 2}, ov.VAlign())
+
+	ov.SetAnchor("site", AnchorAbove, gwtest.D)
+	c = ov.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "  XYZ \n  ab  \n      \n      ", c.String())
+
+	// Not enough room on either side, so the top widget is moved left to fit
+	ov.SetAnchor("site", AnchorRight, gwtest.D)
+	c = ov.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "      \n  aXYZ\n      \n      ", c.String())
+
+	// The anchor isn't rendered, so the overlay's alignment is used
+	ov.SetAnchor("nowhere", AnchorBelow, gwtest.D)
+	c = ov.Render(sz, gowid.Focused, gwtest.D)
+	assert.False(t, ov.Anchored())
+	assert.Equal(t, "XYZ   \n  ab  \n      \n      ", c.String())
+}
+
+func TestPlaceNextTo1(t *testing.T) {
+	r := gowid.Rect{X: 4, Y: 8, W: 3, H: 1}
+	x, y := PlaceNextTo(r, 5, 2, 20, 10, AnchorBelow)
+	assert.Equal(t, []int{4, 6}, []int{x, y}) // flipped above
+	x, y = PlaceNextTo(r, 5, 1, 20, 10, AnchorBelow)
+	assert.Equal(t, []int{4, 9}, []int{x, y})
+	x, y = PlaceNextTo(r, 5, 2, 20, 10, AnchorRight)
+	assert.Equal(t, []int{7, 8}, []int{x, y})
+	x, y = PlaceNextTo(r, 5, 2, 20, 10, AnchorLeft)
+	assert.Equal(t, []int{7, 8}, []int{x, y}) // no room on the left, so flipped right
+	x, y = PlaceNextTo(r, 30, 2, 20, 10, AnchorAbove)
+	assert.Equal(t, []int{0, 6}, []int{x, y})
+}
+
+func TestMovable1(t *testing.T) {
+	top := framed.New(text.New("ab"))
+	ov := New(top, fill.New('.'), gowid.VAlignMiddle{}, gowid.RenderFixed{}, gowid.HAlignMiddle{}, gowid.RenderFixed{},
+		Options{
+			Movable: true,
+		})
+	sz := gowid.RenderBox{C: 8, R: 5}
+	assert.Equal(t, gowid.Rect{X: 2, Y: 1, W: 4, H: 3}, ov.TopRect(sz, gowid.Focused, gwtest.D))
+
+	var rects []gowid.Rect
+	ov.OnGeometryChanged(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		rects = append(rects, data[0].(gowid.Rect))
+	}})
+	modes := 0
+	ov.OnMoveMode(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		modes++
+	}})
+
+	// Cursor keys reach the top widget until move mode is entered
+	assert.False(t, ov.UserInput(gwtest.CursorLeft(), sz, gowid.Focused, gwtest.D))
+	assert.True(t, ov.UserInput(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModAlt), sz, gowid.Focused, gwtest.D))
+	assert.True(t, ov.Moving())
+	assert.Equal(t, 1, modes)
+
+	ov.UserInput(gwtest.CursorLeft(), sz, gowid.Focused, gwtest.D)
+	ov.UserInput(gwtest.CursorLeft(), sz, gowid.Focused, gwtest.D)
+	ov.UserInput(gwtest.CursorLeft(), sz, gowid.Focused, gwtest.D) // already at the left
+	ov.UserInput(gwtest.CursorUp(), sz, gowid.Focused, gwtest.D)
+	c := ov.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "----....\n|ab|....\n----....\n........\n........", c.String())
+	assert.Equal(t, gowid.HAlignLeft{}, ov.HAlign())
+	assert.Equal(t, gowid.RenderWithUnits{U: 4}, ov.Width())
+	assert.Equal(t, []gowid.Rect{{X: 1, Y: 1, W: 4, H: 3}, {X: 0, Y: 1, W: 4, H: 3}, {X: 0, Y: 0, W: 4, H: 3}}, rects)
+
+	// Shift with the cursor keys resizes
+	ov.UserInput(tcell.NewEventKey(tcell.KeyRight, ' ', tcell.ModShift), sz, gowid.Focused, gwtest.D)
+	ov.UserInput(tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModShift), sz, gowid.Focused, gwtest.D)
+	c = ov.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "-----...\n-----...\n........\n........\n........", c.String())
+
+	// Other keys are swallowed; enter leaves move mode
+	assert.True(t, ov.UserInput(gwtest.KeyEvent('x'), sz, gowid.Focused, gwtest.D))
+	assert.True(t, ov.UserInput(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), sz, gowid.Focused, gwtest.D))
+	assert.False(t, ov.Moving())
+	assert.Equal(t, 2, modes)
+
+	// Drag the frame to move, and the bottom-right corner to resize
+	rects = nil
+	assert.True(t, ov.UserInput(gwtest.ClickAt(1, 0), sz, gowid.Focused, gwtest.D))
+	gwtest.D.SetLastMouseState(gowid.MouseState{true, false, false, time.Now()})
+	assert.True(t, ov.UserInput(gwtest.ClickAt(3, 2), sz, gowid.Focused, gwtest.D))
+	assert.True(t, ov.UserInput(gwtest.ClickUpAt(3, 2), sz, gowid.Focused, gwtest.D))
+	gwtest.D.SetLastMouseState(gowid.MouseState{})
+	assert.Equal(t, []gowid.Rect{{X: 2, Y: 2, W: 5, H: 2}}, rects)
+
+	assert.True(t, ov.UserInput(gwtest.ClickAt(6, 3), sz, gowid.Focused, gwtest.D))
+	gwtest.D.SetLastMouseState(gowid.MouseState{true, false, false, time.Now()})
+	ov.UserInput(gwtest.ClickAt(5, 4), sz, gowid.Focused, gwtest.D)
+	ov.UserInput(gwtest.ClickUpAt(5, 4), sz, gowid.Focused, gwtest.D)
+	gwtest.D.SetLastMouseState(gowid.MouseState{})
+	c = ov.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "........\n........\n..----..\n..|ab|..\n..----..", c.String())
+
+	// Clicks inside the frame aren't drags
+	assert.False(t, ov.UserInput(gwtest.ClickAt(3, 3), sz, gowid.Focused, gwtest.D))
+	gwtest.D.SetLastMouseState(gowid.MouseState{true, false, false, time.Now()})
+	assert.False(t, ov.UserInput(gwtest.ClickAt(1, 1), sz, gowid.Focused, gwtest.D))
+	gwtest.D.SetLastMouseState(gowid.MouseState{})
+}
+
+// boxOnlyWidget can only be rendered in a box.
+type boxOnlyWidget struct {
+	*fill.Widget
+}
+
+func (w *boxOnlyWidget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	if _, ok := size.(gowid.IRenderBox); !ok {
+		panic(gowid.WidgetSizeError{Widget: w, Size: size, Required: "IRenderBox"})
+	}
+	return w.Widget.Render(size, focus, app)
+}
+
+func TestRenderError1(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	ov := New(&boxOnlyWidget{fill.New('x')}, fill.New('.'),
+		gowid.VAlignTop{}, gowid.RenderFlow{}, gowid.HAlignLeft{}, gowid.RenderWithUnits{U: 4})
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen: screen,
+		View:   ov,
+		Log:    logger,
+	})
+	assert.NoError(t, err)
+	sz := gowid.RenderBox{C: 6, R: 2}
+
+	// The top widget is rendered as a flow widget, inside padding, which it can't handle
+	assert.Panics(t, func() {
+		ov.Render(sz, gowid.Focused, app)
+	})
+
+	var reported error
+	app.SetRenderErrorPolicy(gowid.RecoverRenderError)
+	app.OnRenderError(func(app gowid.IApp, w gowid.IWidget, size gowid.IRenderSize, err error) {
+		reported = err
+	})
+	c := ov.Render(sz, gowid.Focused, app)
+	assert.Equal(t, "!Wid..\n......", c.String())
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(0, 0).Style())
+	assert.Equal(t, gowid.StyleNone, c.CellAt(4, 0).Style())
+	assert.IsType(t, gowid.WidgetSizeError{}, reported)
+}
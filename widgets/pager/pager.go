@@ -0,0 +1,626 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package pager provides a read-only viewer for large documents, like less. Lines
+// are found and laid out only as they are needed, so multi-megabyte content can be
+// displayed without delay. The pager supports line wrapping, horizontal scrolling,
+// regular expression search with highlighting and a status line.
+package pager
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+const chunkSize = 64 * 1024
+
+// Options can be supplied to New. If TabWidth is zero, 8 is used. If CacheSize is
+// zero, up to 4096 laid-out lines are cached. If MatchStyle or StatusStyle are nil,
+// search matches and the status line are displayed in reverse video.
+type Options struct {
+	Wrap        bool
+	Status      bool // If true, the last row shows the lines displayed and the position in the document
+	TabWidth    int
+	CacheSize   int
+	MatchStyle  gowid.ICellStyler
+	StatusStyle gowid.ICellStyler
+}
+
+type IPager interface {
+	// Lines returns the number of lines found so far, and true if that is all of them.
+	Lines() (int, bool)
+	TopLine() int
+	Percent() int
+}
+
+type IWidget interface {
+	gowid.IWidget
+	IPager
+}
+
+// PositionCB is the name under which callbacks are registered that run when the
+// pager scrolls.
+type PositionCB struct{}
+
+// lineLayout is a line of the document converted to cells. Each cell records the
+// byte offset in the line of the rune it displays, so search matches can be mapped
+// onto cells. Row starts are computed for the most recent wrapping width.
+type lineLayout struct {
+	text      string
+	cells     []gowid.Cell
+	offsets   []int
+	wrapCols  int
+	rowStarts []int
+}
+
+// Widget displays a document read from an io.ReaderAt. It is selectable - the
+// keyboard and mouse wheel scroll it. Only box sizes are supported.
+type Widget struct {
+	r       io.ReaderAt
+	size    int64
+	lines   []int64 // Start offsets of the lines found so far
+	scanned int64   // How far into the document lines have been found
+	indexed bool    // True once all lines have been found
+	end     int64   // End of the last line, excluding any newline, once all lines are found
+	err     error
+	cache   *lru.Cache
+	opts    Options
+	search  *regexp.Regexp
+	top     int // First line displayed
+	topRow  int // First row displayed of the top line, if wrapping
+	xoff    int
+	bottom  int // Last line displayed in the most recent render
+	cols    int // Size of the most recent render, for scrolling outside of UserInput
+	rows    int
+	*gowid.Callbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+// New returns a pager displaying content.
+func New(content string, opts ...Options) *Widget {
+	return NewFromReaderAt(strings.NewReader(content), int64(len(content)), opts...)
+}
+
+// NewFromReaderAt returns a pager displaying size bytes read from r, e.g. an
+// *os.File. The document is read only as it is displayed or searched.
+func NewFromReaderAt(r io.ReaderAt, size int64, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.CacheSize <= 0 {
+		opt.CacheSize = 4096
+	}
+	cache, err := lru.New(opt.CacheSize)
+	if err != nil {
+		panic(err)
+	}
+	return &Widget{
+		r:         r,
+		size:      size,
+		lines:     []int64{0},
+		cache:     cache,
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("pager[line=%d]", w.top+1)
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+// SetWrap turns line wrapping on or off.
+func (w *Widget) SetWrap(wrap bool, app gowid.IApp) {
+	w.opts.Wrap = wrap
+	w.topRow = 0
+	w.xoff = 0
+}
+
+// Err returns the first error reading the document, other than io.EOF. The pager
+// displays the content read before the error.
+func (w *Widget) Err() error {
+	return w.err
+}
+
+func (w *Widget) Lines() (int, bool) {
+	return len(w.lines), w.indexed
+}
+
+// TopLine returns the zero-based index of the first line displayed.
+func (w *Widget) TopLine() int {
+	return w.top
+}
+
+// SetTopLine scrolls so that line is the first displayed.
+func (w *Widget) SetTopLine(line int, app gowid.IApp) {
+	line = gwutil.Max(0, line)
+	if !w.haveLine(line) {
+		line = len(w.lines) - 1
+	}
+	w.setTop(line, 0, app)
+	w.clampBottom(app)
+}
+
+// HorizontalOffset returns the number of columns scrolled off the left of the widget
+// when lines are not wrapped.
+func (w *Widget) HorizontalOffset() int {
+	return w.xoff
+}
+
+func (w *Widget) SetHorizontalOffset(x int, app gowid.IApp) {
+	w.xoff = gwutil.Max(0, x)
+}
+
+// Percent returns how far through the document, in bytes, the last line displayed ends.
+func (w *Widget) Percent() int {
+	if w.size == 0 {
+		return 100
+	}
+	// Include the newline
+	end := w.size
+	if w.haveLine(w.bottom + 1) {
+		end = w.lines[w.bottom+1]
+	}
+	return int(end * 100 / w.size)
+}
+
+// SetSearch highlights matches of re and scrolls to the first line, from the top
+// line on, that has a match. It returns false if there is none. If re is nil,
+// highlighting is turned off.
+func (w *Widget) SetSearch(re *regexp.Regexp, app gowid.IApp) bool {
+	w.search = re
+	if re == nil {
+		return false
+	}
+	return w.searchFrom(w.top, 1, app)
+}
+
+func (w *Widget) Search() *regexp.Regexp {
+	return w.search
+}
+
+// SearchNext scrolls to the next line after the top line that matches the search.
+func (w *Widget) SearchNext(app gowid.IApp) bool {
+	return w.searchFrom(w.top+1, 1, app)
+}
+
+// SearchPrev scrolls to the closest line before the top line that matches the search.
+func (w *Widget) SearchPrev(app gowid.IApp) bool {
+	return w.searchFrom(w.top-1, -1, app)
+}
+
+func (w *Widget) OnPositionChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, PositionCB{}, f)
+}
+
+func (w *Widget) RemoveOnPositionChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, PositionCB{}, f)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+// haveLine finds lines until line i is found or the document ends, returning false
+// if there is no line i.
+func (w *Widget) haveLine(i int) bool {
+	var buf []byte
+	// Finding the start of line i+1 tells us where line i ends
+	for !w.indexed && len(w.lines) <= i+1 {
+		if buf == nil {
+			buf = make([]byte, chunkSize)
+		}
+		n, err := w.r.ReadAt(buf, w.scanned)
+		for j := 0; j < n; {
+			k := bytes.IndexByte(buf[j:n], '\n')
+			if k == -1 {
+				break
+			}
+			j += k + 1
+			w.lines = append(w.lines, w.scanned+int64(j))
+		}
+		w.scanned += int64(n)
+		if err != nil && err != io.EOF && w.err == nil {
+			w.err = err
+		}
+		if err != nil || n == 0 || w.scanned >= w.size {
+			w.indexed = true
+			w.end = w.scanned
+			// A trailing newline doesn't start another line
+			if len(w.lines) > 1 && w.lines[len(w.lines)-1] >= w.scanned {
+				w.lines = w.lines[:len(w.lines)-1]
+				w.end--
+			}
+		}
+	}
+	return i >= 0 && i < len(w.lines)
+}
+
+// lineBounds returns the offsets of the start and end of line i, excluding the
+// newline.
+func (w *Widget) lineBounds(i int) (int64, int64) {
+	w.haveLine(i)
+	if i < 0 || i >= len(w.lines) {
+		return 0, 0
+	}
+	start := w.lines[i]
+	end := w.end
+	if i+1 < len(w.lines) {
+		end = w.lines[i+1] - 1
+	}
+	return start, end
+}
+
+func (w *Widget) readLine(i int) string {
+	start, end := w.lineBounds(i)
+	buf := make([]byte, end-start)
+	n, _ := w.r.ReadAt(buf, start)
+	return strings.TrimSuffix(string(buf[:n]), "\r")
+}
+
+func (w *Widget) tabWidth() int {
+	if w.opts.TabWidth <= 0 {
+		return 8
+	}
+	return w.opts.TabWidth
+}
+
+// layout returns line i converted to cells - tabs are expanded, and control
+// characters are shown as e.g. ^A.
+func (w *Widget) layout(i int) *lineLayout {
+	if l, ok := w.cache.Get(i); ok {
+		return l.(*lineLayout)
+	}
+	res := &lineLayout{text: w.readLine(i)}
+	add := func(c gowid.Cell, off int) {
+		res.cells = append(res.cells, c)
+		res.offsets = append(res.offsets, off)
+	}
+	for off, r := range res.text {
+		switch {
+		case r == '\t':
+			for n := w.tabWidth() - len(res.cells)%w.tabWidth(); n > 0; n-- {
+				add(gowid.CellFromRune(' '), off)
+			}
+		case r < ' ' || r == 0x7f:
+			add(gowid.CellFromRune('^'), off)
+			add(gowid.CellFromRune(r^0x40), off)
+		case r == utf8.RuneError:
+			add(gowid.CellFromRune('?'), off)
+		default:
+			switch runewidth.RuneWidth(r) {
+			case 0:
+				if len(res.cells) > 0 {
+					prev := res.cells[len(res.cells)-1]
+					if !prev.HasRune() && len(res.cells) > 1 {
+						// Attach to the wide rune, not its filler
+						k := len(res.cells) - 2
+						res.cells[k] = res.cells[k].WithCombining(append(res.cells[k].Combining(), r))
+					} else {
+						res.cells[len(res.cells)-1] = prev.WithCombining(append(prev.Combining(), r))
+					}
+				}
+			case 2:
+				add(gowid.CellFromRune(r), off)
+				add(gowid.Cell{}, off)
+			default:
+				add(gowid.CellFromRune(r), off)
+			}
+		}
+	}
+	w.cache.Add(i, res)
+	return res
+}
+
+// wrap returns the index of the first cell of each row of the line, when wrapped to
+// cols columns. A wide rune is not split across rows.
+func (l *lineLayout) wrap(cols int) []int {
+	if l.wrapCols == cols && l.rowStarts != nil {
+		return l.rowStarts
+	}
+	res := []int{0}
+	for start := 0; start+cols < len(l.cells); {
+		end := start + cols
+		if end > start+1 && l.cells[end-1].HasRune() && !l.cells[end].HasRune() && l.cells[end-1].Width() == 2 {
+			end--
+		}
+		res = append(res, end)
+		start = end
+	}
+	l.wrapCols, l.rowStarts = cols, res
+	return res
+}
+
+// rowsOf returns the number of rows line i occupies.
+func (w *Widget) rowsOf(i, cols int) int {
+	if !w.opts.Wrap || cols <= 0 {
+		return 1
+	}
+	return len(w.layout(i).wrap(cols))
+}
+
+func (w *Widget) bodyRows(rows int) int {
+	if w.opts.Status {
+		return gwutil.Max(0, rows-1)
+	}
+	return rows
+}
+
+func (w *Widget) setTop(line, row int, app gowid.IApp) {
+	if line != w.top || row != w.topRow {
+		w.top, w.topRow = line, row
+		gowid.RunWidgetCallbacks(w.Callbacks, PositionCB{}, app, w)
+	}
+}
+
+// scroll moves the display n rows forwards, or backwards if n is negative.
+func (w *Widget) scroll(n int, app gowid.IApp) {
+	line, row := w.top, w.topRow
+	for ; n > 0; n-- {
+		if row+1 < w.rowsOf(line, w.cols) {
+			row++
+		} else if w.haveLine(line + 1) {
+			line, row = line+1, 0
+		} else {
+			break
+		}
+	}
+	for ; n < 0; n++ {
+		if row > 0 {
+			row--
+		} else if line > 0 {
+			line, row = line-1, w.rowsOf(line-1, w.cols)-1
+		} else {
+			break
+		}
+	}
+	w.setTop(line, row, app)
+	w.clampBottom(app)
+}
+
+// clampBottom scrolls back if there aren't enough rows below the top of the display
+// to fill it.
+func (w *Widget) clampBottom(app gowid.IApp) {
+	rows := w.bodyRows(w.rows)
+	if rows == 0 {
+		return
+	}
+	avail := w.rowsOf(w.top, w.cols) - w.topRow
+	for i := w.top + 1; avail < rows && w.haveLine(i); i++ {
+		avail += w.rowsOf(i, w.cols)
+	}
+	if avail < rows {
+		w.goToEnd(app)
+	}
+}
+
+// goToEnd scrolls so the last row of the document is at the bottom of the display.
+func (w *Widget) goToEnd(app gowid.IApp) {
+	for !w.indexed {
+		w.haveLine(len(w.lines))
+	}
+	line := len(w.lines) - 1
+	row := w.rowsOf(line, w.cols) - 1
+	for n := w.bodyRows(w.rows) - 1; n > 0; n-- {
+		if row > 0 {
+			row--
+		} else if line > 0 {
+			line, row = line-1, w.rowsOf(line-1, w.cols)-1
+		} else {
+			break
+		}
+	}
+	w.setTop(line, row, app)
+}
+
+// searchFrom scrolls to the first line from line start, moving in direction dir,
+// that matches the search.
+func (w *Widget) searchFrom(start, dir int, app gowid.IApp) bool {
+	if w.search == nil {
+		return false
+	}
+	for i := start; i >= 0 && w.haveLine(i); i += dir {
+		if w.search.MatchString(w.readLine(i)) {
+			w.setTop(i, 0, app)
+			w.clampBottom(app)
+			return true
+		}
+	}
+	return false
+}
+
+//======================================================================
+
+func RenderSize(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	if box, ok := size.(gowid.IRenderBox); ok {
+		return gowid.RenderBox{C: box.BoxColumns(), R: box.BoxRows()}
+	}
+	panic(gowid.WidgetSizeError{Widget: w, Size: size, Required: "gowid.IRenderBox"})
+}
+
+func styleCell(styler gowid.ICellStyler, def gowid.ICellStyler, app gowid.IApp) gowid.Cell {
+	if styler == nil {
+		styler = def
+	}
+	f, b, s := styler.GetStyle(app)
+	return gowid.MakeCell(0,
+		gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+		gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+		s)
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	box := RenderSize(w, size, focus, app)
+	cols, rows := box.BoxColumns(), box.BoxRows()
+	if cols != w.cols && w.opts.Wrap {
+		w.topRow = 0
+	}
+	w.cols, w.rows = cols, rows
+
+	match := styleCell(w.opts.MatchStyle, gowid.MakeStyledAs(gowid.StyleReverse), app)
+
+	res := gowid.NewCanvas()
+	line, row := w.top, w.topRow
+	w.bottom = line
+	for y := 0; y < w.bodyRows(rows); y++ {
+		cells := make([]gowid.Cell, cols)
+		if w.haveLine(line) {
+			w.bottom = line
+			l := w.layout(line)
+			start, end := w.xoff, w.xoff+cols
+			if w.opts.Wrap {
+				starts := l.wrap(cols)
+				start, end = starts[row], len(l.cells)
+				if row+1 < len(starts) {
+					end = starts[row+1]
+				}
+			}
+			start, end = gwutil.Min(start, len(l.cells)), gwutil.Min(end, len(l.cells))
+			copy(cells, l.cells[start:end])
+			if w.search != nil {
+				for _, m := range w.search.FindAllStringIndex(l.text, -1) {
+					for x := start; x < end; x++ {
+						if l.offsets[x] >= m[0] && l.offsets[x] < m[1] {
+							cells[x-start] = cells[x-start].MergeDisplayAttrsUnder(match)
+						}
+					}
+				}
+			}
+			if row+1 < w.rowsOf(line, cols) {
+				row++
+			} else {
+				line, row = line+1, 0
+			}
+		}
+		res.AppendLine(cells, false)
+	}
+
+	if w.opts.Status && rows > 0 {
+		total := ""
+		if w.indexed {
+			total = fmt.Sprintf("/%d", len(w.lines))
+		}
+		status := gowid.Translatef("lines %d-%d%s %d%%", w.top+1, w.bottom+1, total, w.Percent())
+		status += strings.Repeat(" ", gwutil.Max(0, cols-utf8.RuneCountInString(status)))
+		cells := make([]gowid.Cell, cols)
+		content := text.NewContent([]text.ContentSegment{text.StyledContent(status, nil)})
+		content.RangeOver(0, gwutil.Min(cols, content.Length()), app, &text.ContentToCellArray{Cells: cells})
+		st := styleCell(w.opts.StatusStyle, gowid.MakeStyledAs(gowid.StyleReverse), app)
+		for x := range cells {
+			cells[x] = cells[x].MergeDisplayAttrsUnder(st)
+		}
+		res.AppendLine(cells, false)
+	}
+	return res
+}
+
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	box := RenderSize(w, size, focus, app)
+	w.cols, w.rows = box.BoxColumns(), box.BoxRows()
+	page := gwutil.Max(1, w.bodyRows(w.rows)-1)
+	top, topRow, xoff := w.top, w.topRow, w.xoff
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyUp:
+			w.scroll(-1, app)
+		case tcell.KeyDown, tcell.KeyEnter:
+			w.scroll(1, app)
+		case tcell.KeyPgUp:
+			w.scroll(-page, app)
+		case tcell.KeyPgDn:
+			w.scroll(page, app)
+		case tcell.KeyHome:
+			w.setTop(0, 0, app)
+		case tcell.KeyEnd:
+			w.goToEnd(app)
+		case tcell.KeyLeft:
+			if !w.opts.Wrap {
+				w.xoff = gwutil.Max(0, w.xoff-w.cols/2)
+			}
+		case tcell.KeyRight:
+			if !w.opts.Wrap {
+				w.xoff += gwutil.Max(1, w.cols/2)
+			}
+		case tcell.KeyRune:
+			switch ev.Rune() {
+			case 'k', 'y':
+				w.scroll(-1, app)
+			case 'j', 'e':
+				w.scroll(1, app)
+			case 'b':
+				w.scroll(-page, app)
+			case ' ', 'f':
+				w.scroll(page, app)
+			case 'u':
+				w.scroll(-page/2, app)
+			case 'd':
+				w.scroll(page/2, app)
+			case 'g', '<':
+				w.setTop(0, 0, app)
+			case 'G', '>':
+				w.goToEnd(app)
+			case 'n':
+				w.SearchNext(app)
+			case 'N':
+				w.SearchPrev(app)
+			default:
+				return false
+			}
+		default:
+			return false
+		}
+	case *tcell.EventMouse:
+		switch ev.Buttons() {
+		case tcell.WheelUp:
+			w.scroll(-3, app)
+		case tcell.WheelDown:
+			w.scroll(3, app)
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	// Let an enclosing widget use the input if the pager couldn't move
+	return top != w.top || topRow != w.topRow || xoff != w.xoff
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
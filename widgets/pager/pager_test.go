@@ -0,0 +1,101 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package pager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestPager1(t *testing.T) {
+	w := New("one\ntwo\tx\nthree\nfour\nfive\n")
+	sz := gowid.RenderBox{C: 8, R: 3}
+
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "one     \ntwo     \nthree   ", c.String())
+	n, all := w.Lines()
+	assert.True(t, all)
+	assert.Equal(t, 5, n)
+
+	evdown := tcell.NewEventKey(tcell.KeyDown, ' ', tcell.ModNone)
+	assert.True(t, w.UserInput(evdown, sz, gowid.Focused, gwtest.D))
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "two     \nthree   \nfour    ", c.String())
+
+	// Can't scroll past the end
+	evend := tcell.NewEventKey(tcell.KeyRune, 'G', tcell.ModNone)
+	assert.True(t, w.UserInput(evend, sz, gowid.Focused, gwtest.D))
+	assert.False(t, w.UserInput(evdown, sz, gowid.Focused, gwtest.D))
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "three   \nfour    \nfive    ", c.String())
+	n, all = w.Lines()
+	assert.True(t, all)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, 100, w.Percent())
+
+	evright := tcell.NewEventKey(tcell.KeyRight, ' ', tcell.ModNone)
+	assert.True(t, w.UserInput(evright, sz, gowid.Focused, gwtest.D))
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "e       \n        \n        ", c.String())
+}
+
+func TestPagerWrap1(t *testing.T) {
+	w := New("abcdefgh\nij\n", Options{Wrap: true, Status: true})
+	sz := gowid.RenderBox{C: 3, R: 3}
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "abc\ndef\nlin", c.String())
+
+	evdown := tcell.NewEventKey(tcell.KeyDown, ' ', tcell.ModNone)
+	assert.True(t, w.UserInput(evdown, sz, gowid.Focused, gwtest.D))
+	assert.True(t, w.UserInput(evdown, sz, gowid.Focused, gwtest.D))
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "gh \nij \nlin", c.String())
+	assert.False(t, w.UserInput(evdown, sz, gowid.Focused, gwtest.D))
+
+	w2 := New("ab\ncd\n", Options{Status: true})
+	c = w2.Render(gowid.RenderBox{C: 20, R: 4}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "lines 1-2/2 100%    ", strings.Split(c.String(), "\n")[3])
+}
+
+func TestPagerSearch1(t *testing.T) {
+	lines := make([]string, 0, 20000)
+	for i := 0; i < 20000; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	w := New(strings.Join(lines, "\n"))
+	sz := gowid.RenderBox{C: 10, R: 2}
+	w.Render(sz, gowid.Focused, gwtest.D)
+
+	assert.True(t, w.SetSearch(regexp.MustCompile(`e 5\d\d$`), gwtest.D))
+	assert.Equal(t, 500, w.TopLine())
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "line 500  \nline 501  ", c.String())
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(3, 0).Style())
+	assert.Equal(t, gowid.StyleNone, c.CellAt(2, 0).Style())
+
+	evn := tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone)
+	assert.True(t, w.UserInput(evn, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, 501, w.TopLine())
+	evN := tcell.NewEventKey(tcell.KeyRune, 'N', tcell.ModNone)
+	assert.True(t, w.UserInput(evN, sz, gowid.Focused, gwtest.D))
+	assert.False(t, w.UserInput(evN, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, 500, w.TopLine())
+	_, all := w.Lines()
+	assert.False(t, all)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -122,7 +122,7 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 	})
 	override := NewOverride(app, &newAttrs)
 
-	res := w.SubWidget().Render(size, focus, override)
+	res := gowid.RenderChild(w.SubWidget(), size, focus, override)
 	return res
 }
 
@@ -0,0 +1,149 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package palettescope provides a widget that adds palette entries for its subtree,
+// so a component can ship its own named styles.
+package palettescope
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+)
+
+//======================================================================
+
+type IScope interface {
+	Palette() gowid.IPalette
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IScope
+}
+
+// Widget renders its subtree with a palette scope - palette entries that are looked up
+// before the app's, so PaletteRefs in the subtree resolve to them first. Names the scope
+// doesn't define fall through to the enclosing scope, and then to the app's palette, so
+// a component can define, say, "mycomponent.header", or override "button" for itself
+// alone, without touching the app's palette. Scopes can be nested.
+type Widget struct {
+	gowid.IWidget
+	palette gowid.IPalette
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+}
+
+func New(inner gowid.IWidget, palette gowid.IPalette) *Widget {
+	res := &Widget{
+		IWidget: inner,
+		palette: palette,
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	var _ gowid.IWidget = res
+	var _ gowid.ICompositeWidget = res
+	var _ IWidget = res
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("palettescope[%v]", w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.IWidget
+}
+
+func (w *Widget) SetSubWidget(inner gowid.IWidget, app gowid.IApp) {
+	w.IWidget = inner
+	gowid.RunWidgetCallbacks(w, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) Palette() gowid.IPalette {
+	return w.palette
+}
+
+// SetPalette replaces the scope's palette entries. The subtree uses them from the next
+// render.
+func (w *Widget) SetPalette(palette gowid.IPalette, app gowid.IApp) {
+	w.palette = palette
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return w.SubWidget().RenderSize(size, focus, NewScopedApp(app, w.Palette()))
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return gowid.UserInputIfSelectable(w.SubWidget(), ev, size, focus, NewScopedApp(app, w.Palette()))
+}
+
+func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return gowid.RenderChild(w.SubWidget(), size, focus, NewScopedApp(app, w.Palette()))
+}
+
+//======================================================================
+
+// ScopedApp is the app seen by a scope's subtree. Palette entries are looked up in the
+// scope first, then in the app it wraps - which may itself be a ScopedApp.
+type ScopedApp struct {
+	gowid.IApp
+	Scope gowid.IPalette
+}
+
+var _ gowid.IApp = (*ScopedApp)(nil)
+
+func NewScopedApp(app gowid.IApp, scope gowid.IPalette) *ScopedApp {
+	return &ScopedApp{
+		IApp:  app,
+		Scope: scope,
+	}
+}
+
+func (a *ScopedApp) CellStyler(name string) (gowid.ICellStyler, bool) {
+	if a.Scope != nil {
+		if s, ok := a.Scope.CellStyler(name); ok {
+			return s, ok
+		}
+	}
+	return a.IApp.CellStyler(name)
+}
+
+// RangeOverPalette visits the scope's entries, then those of the wrapped app that the
+// scope doesn't override.
+func (a *ScopedApp) RangeOverPalette(f func(k string, v gowid.ICellStyler) bool) {
+	done := false
+	if a.Scope != nil {
+		a.Scope.RangeOverPalette(func(k string, v gowid.ICellStyler) bool {
+			done = !f(k, v)
+			return !done
+		})
+	}
+	if done {
+		return
+	}
+	a.IApp.RangeOverPalette(func(k string, v gowid.ICellStyler) bool {
+		if a.Scope != nil {
+			if _, ok := a.Scope.CellStyler(k); ok {
+				return true
+			}
+		}
+		return f(k, v)
+	})
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
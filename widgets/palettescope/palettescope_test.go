@@ -0,0 +1,57 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package palettescope
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope1(t *testing.T) {
+	fg := func(w gowid.IWidget) gowid.TCellColor {
+		c := w.Render(gowid.RenderFlowWith{C: 2}, gowid.NotSelected, gwtest.D)
+		return c.CellAt(0, 0).ForegroundColor()
+	}
+	ref := func(name string) gowid.IWidget {
+		return styled.New(text.New("ab"), gowid.MakePaletteRef(name))
+	}
+
+	assert.Equal(t, gowid.ColorGreen, fg(ref("test1notfocus")))
+	assert.Equal(t, gowid.ColorNone, fg(ref("title")))
+
+	scope := gowid.Palette{
+		"title":         gowid.MakePaletteEntry(gowid.ColorBlue, gowid.ColorBlack),
+		"test1notfocus": gowid.MakePaletteEntry(gowid.ColorYellow, gowid.ColorBlack),
+	}
+	assert.Equal(t, gowid.ColorBlue, fg(New(ref("title"), scope)))
+	assert.Equal(t, gowid.ColorYellow, fg(New(ref("test1notfocus"), scope)))
+	// Names the scope doesn't define fall through to the app's palette
+	assert.Equal(t, gowid.ColorRed, fg(New(styled.New(text.New("ab"), gowid.MakePaletteRef("test1focus")), scope)))
+
+	// The innermost scope wins
+	inner := gowid.Palette{"title": gowid.MakePaletteEntry(gowid.ColorCyan, gowid.ColorBlack)}
+	assert.Equal(t, gowid.ColorCyan, fg(New(New(ref("title"), inner), scope)))
+	assert.Equal(t, gowid.ColorYellow, fg(New(New(ref("test1notfocus"), inner), scope)))
+
+	names := make([]string, 0)
+	NewScopedApp(NewScopedApp(gwtest.D, scope), inner).RangeOverPalette(func(k string, v gowid.ICellStyler) bool {
+		names = append(names, k)
+		return true
+	})
+	sort.Strings(names)
+	assert.Equal(t, []string{"test1focus", "test1notfocus", "title"}, names)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,204 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package pile
+
+import (
+	"time"
+
+	"github.com/gcla/gowid"
+)
+
+//======================================================================
+
+// CollapseCB is the name under which callbacks are registered that run when a child
+// starts to collapse or expand. They are passed the child's index.
+type CollapseCB struct{}
+
+// CollapseDoneCB is the name under which callbacks are registered that run when a child
+// has finished collapsing or expanding - straight after CollapseCB if the change isn't
+// animated. They are passed the child's index.
+type CollapseDoneCB struct{}
+
+// ICollapsible is implemented by widgets whose children can be part way through
+// collapsing or expanding. The layout functions in this package show only the top
+// Revealed(i) fraction of the i'th child's rows.
+type ICollapsible interface {
+	Revealed(i int) float64
+}
+
+var _ ICollapsible = (*Widget)(nil)
+
+// collapseState tracks a child collapsed, or being collapsed or expanded.
+type collapseState struct {
+	collapsed bool
+	frame     int // the animation frame reached, counting up to frames
+	frames    int // zero if the child isn't animating
+}
+
+func revealed(w interface{}, i int) float64 {
+	if c, ok := w.(ICollapsible); ok {
+		return c.Revealed(i)
+	}
+	return 1
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// Collapsed returns true if the i'th child has been collapsed with SetCollapsed - even if
+// it is still shrinking.
+func (w *Widget) Collapsed(i int) bool {
+	return i >= 0 && i < len(w.collapse) && w.collapse[i].collapsed
+}
+
+// Animating returns true if the i'th child is collapsing or expanding.
+func (w *Widget) Animating(i int) bool {
+	return i >= 0 && i < len(w.collapse) && w.collapse[i].frames > 0
+}
+
+// Revealed returns the fraction of the i'th child's rows shown - 1 unless the child is
+// collapsing or expanding.
+func (w *Widget) Revealed(i int) float64 {
+	if !w.Animating(i) {
+		return 1
+	}
+	st := w.collapse[i]
+	res := float64(st.frame) / float64(st.frames)
+	if st.collapsed {
+		res = 1 - res
+	}
+	return res
+}
+
+// SetCollapsed collapses or expands the i'th child. A collapsed child is hidden, as with
+// SetHidden, so it keeps its widget, dimension and state, and focus moves away from it.
+// If Options.CollapseDuration is set, the child shrinks to nothing, or grows back,
+// over that time; it is hidden once it has shrunk, and shown as it starts to grow.
+// Collapsing or expanding a child part way through reverses from where it had got to.
+func (w *Widget) SetCollapsed(i int, collapsed bool, app gowid.IApp) {
+	if i < 0 || i >= len(w.widgets) || w.Collapsed(i) == collapsed {
+		return
+	}
+	if w.collapse == nil {
+		w.collapse = make([]collapseState, len(w.widgets))
+	}
+	st := &w.collapse[i]
+	frames := int(w.opt.CollapseDuration / w.opt.CollapseFrameInterval)
+	switch {
+	case frames < 1:
+		st.frames = 0
+	case st.frames > 0:
+		// Reverse the animation in progress
+		st.frame = st.frames - st.frame
+	default:
+		st.frame, st.frames = 0, frames
+	}
+	st.collapsed = collapsed
+	if !collapsed {
+		w.SetHidden(i, false, app)
+	}
+	gowid.RunWidgetCallbacks(w.Callbacks, CollapseCB{}, app, w, i)
+	if st.frames == 0 {
+		w.finishCollapse(i, app)
+	} else if w.collapseTimer == nil {
+		w.scheduleCollapse(app)
+	}
+}
+
+// ToggleCollapsed collapses the i'th child if it is expanded, and expands it otherwise.
+func (w *Widget) ToggleCollapsed(i int, app gowid.IApp) {
+	w.SetCollapsed(i, !w.Collapsed(i), app)
+}
+
+func (w *Widget) OnCollapse(f gowid.IWidgetChangedCallback) {
+	if w.Callbacks == nil {
+		w.Callbacks = gowid.NewCallbacks()
+	}
+	gowid.AddWidgetCallback(w.Callbacks, CollapseCB{}, f)
+}
+
+func (w *Widget) RemoveOnCollapse(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, CollapseCB{}, f)
+}
+
+func (w *Widget) OnCollapseDone(f gowid.IWidgetChangedCallback) {
+	if w.Callbacks == nil {
+		w.Callbacks = gowid.NewCallbacks()
+	}
+	gowid.AddWidgetCallback(w.Callbacks, CollapseDoneCB{}, f)
+}
+
+func (w *Widget) RemoveOnCollapseDone(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, CollapseDoneCB{}, f)
+}
+
+// finishCollapse ends the i'th child's animation, hiding it if it has collapsed.
+func (w *Widget) finishCollapse(i int, app gowid.IApp) {
+	st := &w.collapse[i]
+	st.frame, st.frames = 0, 0
+	if st.collapsed {
+		w.SetHidden(i, true, app)
+	}
+	gowid.RunWidgetCallbacks(w.Callbacks, CollapseDoneCB{}, app, w, i)
+}
+
+func (w *Widget) scheduleCollapse(app gowid.IApp) {
+	gen := w.collapseGen
+	w.collapseTimer = time.AfterFunc(w.opt.CollapseFrameInterval, func() {
+		app.Run(gowid.RunFunction(func(app gowid.IApp) {
+			if gen == w.collapseGen {
+				w.collapseTick(app)
+			}
+		}))
+	})
+}
+
+// collapseTick advances each child collapsing or expanding by one frame.
+func (w *Widget) collapseTick(app gowid.IApp) {
+	w.collapseTimer = nil
+	animating := false
+	for i := range w.collapse {
+		st := &w.collapse[i]
+		if st.frames == 0 {
+			continue
+		}
+		st.frame++
+		if st.frame >= st.frames {
+			w.finishCollapse(i, app)
+		} else {
+			animating = true
+		}
+	}
+	if animating {
+		w.scheduleCollapse(app)
+	}
+}
+
+// resetCollapse forgets which children are collapsed, stopping any animation.
+func (w *Widget) resetCollapse() {
+	if w.collapseTimer != nil {
+		w.collapseTimer.Stop()
+		w.collapseTimer = nil
+	}
+	w.collapse = nil
+	w.collapseGen++
+}
+
+//======================================================================
+
+// revealBox returns the top fraction frac of the rows of b, a canvas or a size.
+func revealBox(b gowid.IRenderBox, frac float64) gowid.IRenderBox {
+	rows := int(float64(b.BoxRows())*frac + 0.5)
+	if c, ok := b.(gowid.ICanvas); ok {
+		c.Truncate(0, c.BoxRows()-rows)
+		return c
+	}
+	return gowid.RenderBox{C: b.BoxColumns(), R: rows}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -6,8 +6,10 @@
 package pile
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwutil"
@@ -32,10 +34,18 @@ type IWidget interface {
 }
 
 type Widget struct {
-	widgets []gowid.IContainerWidget
-	focus   int // -1 means nothing selectable
-	prefRow int // caches the last set prefered row. Passes it on if widget hasn't changed focus
-	opt     Options
+	widgets     []gowid.IContainerWidget
+	focus       int    // -1 means nothing selectable
+	prefRow     int    // caches the last set prefered row. Passes it on if widget hasn't changed focus
+	hidden      []bool // children hidden with SetHidden; nil if none have been
+	unhideFocus int    // the hidden child that had focus, restored when it is shown
+	hideFocus   int    // where focus moved when that child was hidden
+	opt         Options
+
+	collapse      []collapseState // children collapsed with SetCollapsed; nil if none have been
+	collapseGen   int             // incremented when collapse is reset, so stale timers are ignored
+	collapseTimer *time.Timer     // runs the next frame while children collapse or expand
+
 	*gowid.Callbacks
 	gowid.AddressProvidesID
 	gowid.FocusCallbacks
@@ -48,12 +58,27 @@ type Options struct {
 	DoNotSetSelected bool // Whether or not to set the focus.Selected field for the selected child
 	DownKeys         []vim.KeyPress
 	UpKeys           []vim.KeyPress
+	// CollapseDuration is the time SetCollapsed takes to shrink or grow a child; if zero,
+	// the change is immediate. The widget is redrawn every CollapseFrameInterval while a
+	// child is shrinking or growing - by default, 25ms.
+	CollapseDuration      time.Duration
+	CollapseFrameInterval time.Duration
+	// ConcurrentRender renders the children in parallel - only set it if they are safe to
+	// render that way, see gowid.RenderConcurrently. Their sizes are worked out first, with
+	// RenderSize, so this pays off when children are slow to render but quick to size.
+	ConcurrentRender bool
+	// SelectedStyle is applied to the selected child when the pile isn't in focus, and
+	// FocusedStyle when it is - if FocusedStyle is nil, SelectedStyle is used for both.
+	// Neither is applied if DoNotSetSelected is set and the pile isn't in focus.
+	SelectedStyle gowid.ICellStyler
+	FocusedStyle  gowid.ICellStyler
 }
 
 var _ gowid.IWidget = (*Widget)(nil)
 var _ IWidget = (*Widget)(nil)
 var _ gowid.ICompositeMultipleDimensions = (*Widget)(nil)
 var _ gowid.ICompositeMultipleWidget = (*Widget)(nil)
+var _ gowid.IStateful = (*Widget)(nil)
 
 func New(widgets []gowid.IContainerWidget, opts ...Options) *Widget {
 	var opt Options
@@ -70,12 +95,17 @@ func New(widgets []gowid.IContainerWidget, opts ...Options) *Widget {
 	if opt.UpKeys == nil {
 		opt.UpKeys = vim.AllUpKeys
 	}
+	if opt.CollapseFrameInterval <= 0 {
+		opt.CollapseFrameInterval = 25 * time.Millisecond
+	}
 
 	res := &Widget{
-		widgets: widgets,
-		focus:   -1,
-		prefRow: -1,
-		opt:     opt,
+		widgets:     widgets,
+		focus:       -1,
+		prefRow:     -1,
+		unhideFocus: -1,
+		hideFocus:   -1,
+		opt:         opt,
 	}
 	res.FocusCallbacks = gowid.FocusCallbacks{CB: &res.Callbacks}
 	res.SubWidgetsCallbacks = gowid.SubWidgetsCallbacks{CB: &res.Callbacks}
@@ -160,6 +190,9 @@ func (w *Widget) SetSubWidgets(widgets []gowid.IWidget, app gowid.IApp) {
 			ws[i] = &gowid.ContainerWidget{IWidget: iw, D: gowid.RenderFlow{}}
 		}
 	}
+	w.hidden = nil
+	w.unhideFocus = -1
+	w.resetCollapse()
 	oldFocus := w.Focus()
 	w.widgets = ws
 	w.SetFocus(app, oldFocus)
@@ -181,12 +214,78 @@ func (w *Widget) SetDimensions(dimensions []gowid.IWidgetDimension, app gowid.IA
 	gowid.RunWidgetCallbacks(w.Callbacks, gowid.DimensionsCB{}, app, w)
 }
 
+// SaveState implements gowid.IStateful, saving the children's dimensions.
+func (w *Widget) SaveState() (interface{}, error) {
+	return gowid.SaveDimensions(w.Dimensions()), nil
+}
+
+// RestoreState implements gowid.IStateful, restoring the children's dimensions - unless
+// the number of children has changed.
+func (w *Widget) RestoreState(data json.RawMessage, ctx *gowid.StateContext, app gowid.IApp) error {
+	dims, err := gowid.RestoreDimensions(w.Dimensions(), data)
+	if err != nil {
+		return err
+	}
+	w.SetDimensions(dims, app)
+	return nil
+}
+
+// Hidden returns true if the i'th child has been hidden with SetHidden.
+func (w *Widget) Hidden(i int) bool {
+	return i >= 0 && i < len(w.hidden) && w.hidden[i]
+}
+
+// SetHidden hides or shows the i'th child. A hidden child stays in the widget's
+// list of children and keeps its dimension and state, but it is given no rows
+// and can't take focus. If the focus child is hidden, focus moves to the next
+// selectable child, and returns when the child is shown again - unless focus has
+// been moved elsewhere in the meantime. The hidden state is reset by SetSubWidgets,
+// as is the collapsed state set with SetCollapsed.
+func (w *Widget) SetHidden(i int, hidden bool, app gowid.IApp) {
+	if i < 0 || i >= len(w.widgets) || w.Hidden(i) == hidden {
+		return
+	}
+	if w.hidden == nil {
+		w.hidden = make([]bool, len(w.widgets))
+	}
+	w.hidden[i] = hidden
+	if hidden {
+		if i == w.focus {
+			next, ok := w.FindNextSelectable(1, true)
+			if !ok {
+				next = -1
+			}
+			w.focus = next
+			w.prefRow = -1
+			w.unhideFocus, w.hideFocus = i, next
+			gowid.RunWidgetCallbacks(w.Callbacks, gowid.FocusCB{}, app, w)
+		}
+	} else if i == w.unhideFocus {
+		w.unhideFocus = -1
+		if w.focus == w.hideFocus {
+			w.SetFocus(app, i)
+		}
+	}
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.DimensionsCB{}, app, w)
+}
+
 func (w *Widget) Selectable() bool {
-	return gowid.SelectableIfAnySubWidgetsAre(w)
+	for i, widget := range w.widgets {
+		if !w.Hidden(i) && widget.Selectable() {
+			return true
+		}
+	}
+	return false
 }
 
 func (w *Widget) FindNextSelectable(dir gowid.Direction, wrap bool) (int, bool) {
-	return gowid.FindNextSelectableFrom(w, w.Focus(), dir, wrap)
+	dup := gowid.CopyWidgets(w.SubWidgets())
+	for i := range dup {
+		if w.Hidden(i) {
+			dup[i] = nil
+		}
+	}
+	return gowid.FindNextSelectableWidget(dup, w.Focus(), dir, wrap)
 }
 
 func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
@@ -242,13 +341,13 @@ func (w *Widget) SetPreferedPosition(rows int, app gowid.IApp) {
 	rowLeft := row - 1
 	rowRight := row
 	for rowLeft >= 0 || rowRight < len(w.widgets) {
-		if rowRight < len(w.widgets) && w.widgets[rowRight].Selectable() {
+		if rowRight < len(w.widgets) && !w.Hidden(rowRight) && w.widgets[rowRight].Selectable() {
 			w.SetFocus(app, rowRight)
 			break
 		} else {
 			rowRight++
 		}
-		if rowLeft >= 0 && w.widgets[rowLeft].Selectable() {
+		if rowLeft >= 0 && !w.Hidden(rowLeft) && w.widgets[rowLeft].Selectable() {
 			w.SetFocus(app, rowLeft)
 			break
 		} else {
@@ -266,11 +365,56 @@ func (w *Widget) KeyIsDown(evk *tcell.EventKey) bool {
 	return vim.KeyIn(evk, w.opt.DownKeys)
 }
 
+// IHidden is implemented by widgets that can hide some of their children. The
+// layout functions in this package give a hidden child no rows.
+type IHidden interface {
+	Hidden(i int) bool
+}
+
+var _ IHidden = (*Widget)(nil)
+
+// IConcurrentRender is implemented by widgets that can render their children in
+// parallel - see gowid.RenderConcurrently.
+type IConcurrentRender interface {
+	ConcurrentRender() bool
+}
+
+var _ IConcurrentRender = (*Widget)(nil)
+
+func (w *Widget) ConcurrentRender() bool {
+	return w.opt.ConcurrentRender
+}
+
+var _ gowid.ISelectionStyler = (*Widget)(nil)
+
+// SelectionStyle returns the style for a child rendered with sel - see Options.SelectedStyle
+// and Options.FocusedStyle.
+func (w *Widget) SelectionStyle(sel gowid.Selector) gowid.ICellStyler {
+	return gowid.SelectionStyle(sel, w.opt.SelectedStyle, w.opt.FocusedStyle)
+}
+
+func isConcurrent(w interface{}) bool {
+	if c, ok := w.(IConcurrentRender); ok {
+		return c.ConcurrentRender()
+	}
+	return false
+}
+
+func isHidden(w interface{}, i int) bool {
+	if h, ok := w.(IHidden); ok {
+		return h.Hidden(i)
+	}
+	return false
+}
+
 //''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
 
 func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
 
 	subfocus := w.Focus()
+	if isHidden(w, subfocus) {
+		subfocus = -1
+	}
 	// An array of IRenderBoxes
 	ss, ss2 := RenderedChildrenSizes(w, size, focus, subfocus, app)
 	forChild := false
@@ -354,7 +498,7 @@ func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Se
 
 	res := forChild
 
-	if !forChild && w.Focus() != -1 { // e.g. if none of the subwidgets are selectable
+	if !forChild && subfocus != -1 { // e.g. if none of the subwidgets are selectable
 		res = true
 		scrollDown := false
 		scrollUp := false
@@ -409,6 +553,11 @@ type IFocusSelectable interface {
 }
 
 func RenderSize(w gowid.ICompositeMultipleWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	// Render fills the box, so there's no need to size the children
+	if box, ok := size.(gowid.IRenderBox); ok {
+		return gowid.RenderBox{C: box.BoxColumns(), R: box.BoxRows()}
+	}
+
 	subfocus := w.Focus()
 	sizes := w.RenderedSubWidgetsSizes(size, focus, subfocus, app)
 
@@ -420,10 +569,6 @@ func RenderSize(w gowid.ICompositeMultipleWidget, size gowid.IRenderSize, focus
 		maxrow += sz.BoxRows()
 	}
 
-	if sz, ok := size.(gowid.IRenderBox); ok {
-		maxrow = gwutil.Min(maxrow, sz.BoxRows())
-	}
-
 	return gowid.RenderBox{maxcol, maxrow}
 }
 
@@ -491,8 +636,14 @@ func RenderedChildrenSizes(w IWidget, size gowid.IRenderSize, focus gowid.Select
 }
 
 func RenderSubwidgets(w IWidget, size gowid.IRenderSize, focus gowid.Selector, focusIdx int, app gowid.IApp) []gowid.ICanvas {
-	fn1 := BoxMakerFunc(func(w gowid.IWidget, subSize gowid.IRenderSize, focus gowid.Selector, subApp gowid.IApp) gowid.IRenderBox {
-		return w.Render(subSize, focus, subApp)
+	if isConcurrent(w) {
+		return renderSubwidgetsConcurrently(w, size, focus, focusIdx, app)
+	}
+
+	fn1 := BoxMakerFunc(func(sub gowid.IWidget, subSize gowid.IRenderSize, focus gowid.Selector, subApp gowid.IApp) gowid.IRenderBox {
+		c := gowid.RenderChild(sub, subSize, focus, subApp)
+		gowid.ApplySelectionStyle(w, c, focus, subApp)
+		return c
 	})
 
 	canvases, _ := w.RenderBoxMaker(size, focus, focusIdx, app, fn1)
@@ -505,6 +656,26 @@ func RenderSubwidgets(w IWidget, size gowid.IRenderSize, focus gowid.Selector, f
 	return res
 }
 
+// renderSubwidgetsConcurrently works out the size of each child first, then renders
+// the children in parallel.
+func renderSubwidgetsConcurrently(w IWidget, size gowid.IRenderSize, focus gowid.Selector, focusIdx int, app gowid.IApp) []gowid.ICanvas {
+	_, ssizes := RenderedChildrenSizes(w, size, focus, focusIdx, app)
+	subs := w.SubWidgets()
+	focuses := make([]gowid.Selector, len(subs))
+	for i := range subs {
+		focuses[i] = focus.SelectIf(w.SelectChild(focus) && i == focusIdx)
+	}
+	res := gowid.RenderConcurrently(subs, ssizes, focuses, app)
+	for i := range res {
+		gowid.ApplySelectionStyle(w, res[i], focuses[i], app)
+		// Children collapsing or expanding show only some of their rows
+		if frac := revealed(w, i); frac < 1 {
+			res[i] = revealBox(res[i], frac).(gowid.ICanvas)
+		}
+	}
+	return res
+}
+
 // TODO - make this an interface
 type IPileBoxMaker interface {
 	MakeBox(gowid.IWidget, gowid.IRenderSize, gowid.Selector, gowid.IApp) gowid.IRenderBox
@@ -518,13 +689,23 @@ func (f BoxMakerFunc) MakeBox(w gowid.IWidget, s gowid.IRenderSize, b gowid.Sele
 
 func RenderBoxMaker(w IWidget, size gowid.IRenderSize, focus gowid.Selector, focusIdx int, app gowid.IApp, fn IPileBoxMaker) ([]gowid.IRenderBox, []gowid.IRenderSize) {
 	dims := w.Dimensions()
+	subs := w.SubWidgets()
+
+	// Children collapsing or expanding show only some of their rows
+	makeBox := func(i int, subSize gowid.IRenderSize) gowid.IRenderBox {
+		res := fn.MakeBox(subs[i], subSize, focus.SelectIf(w.SelectChild(focus) && i == focusIdx), app)
+		if frac := revealed(w, i); frac < 1 {
+			res = revealBox(res, frac)
+		}
+		return res
+	}
 
 	_, ok1 := size.(gowid.IRenderFlowWith)
 	_, ok2 := size.(gowid.IRenderFixed)
 	weightWidgets := 0
 	if ok1 || ok2 {
-		for _, ww := range dims {
-			if _, ok := ww.(gowid.IRenderWithWeight); ok {
+		for i, ww := range dims {
+			if _, ok := ww.(gowid.IRenderWithWeight); ok && !isHidden(w, i) {
 				weightWidgets++
 				if weightWidgets > 1 {
 					panic(fmt.Errorf("Pile is rendered as Flow/Fixed %v of type %T so cannot contain more than one Weight widget",
@@ -534,17 +715,21 @@ func RenderBoxMaker(w IWidget, size gowid.IRenderSize, focus gowid.Selector, foc
 		}
 	}
 
-	subs := w.SubWidgets()
 	wlen := len(subs)
 	res := make([]gowid.IRenderBox, wlen)
 	resSS := make([]gowid.IRenderSize, wlen)
 
 	heights := make([]int, wlen)
 	ineligible := make([]bool, wlen)
+	hidden := make([]bool, wlen)
 
 	// So I know what is initialized later and what isn't (since 0 can be a legit row height)
 	for i := 0; i < wlen; i++ {
 		heights[i] = -1
+		// Hidden children are rendered with no rows below, like weighted children
+		// that don't get any space
+		hidden[i] = isHidden(w, i)
+		ineligible[i] = hidden[i]
 	}
 
 	rowsUsed := 0
@@ -555,12 +740,15 @@ func RenderBoxMaker(w IWidget, size gowid.IRenderSize, focus gowid.Selector, foc
 	// as RenderFlow{} can then be rendered to the max width
 	maxcol := -1
 	for i := 0; i < wlen; i++ {
+		if hidden[i] {
+			continue
+		}
 		subSize, err := gowid.ComputeVerticalSubSize(size, dims[i], -1, -1)
 		if err == nil {
 			if _, ok := subSize.(gowid.IRenderFixed); ok {
 				// only do if subsize is fixed
 				resSS[i] = subSize
-				res[i] = fn.MakeBox(subs[i], subSize, focus.SelectIf(w.SelectChild(focus) && i == focusIdx), app)
+				res[i] = makeBox(i, subSize)
 				heights[i] = res[i].BoxRows()
 				rowsUsed += heights[i]
 				if res[i].BoxColumns() > maxcol {
@@ -576,11 +764,11 @@ func RenderBoxMaker(w IWidget, size gowid.IRenderSize, focus gowid.Selector, foc
 	//
 	for i := 0; i < wlen; i++ {
 		// TODO - remember which one has focus
-		if res[i] == nil {
+		if res[i] == nil && !hidden[i] {
 			subSize, err := gowid.ComputeVerticalSubSize(size, dims[i], maxcol, -1)
 			if err == nil {
 				resSS[i] = subSize
-				res[i] = fn.MakeBox(subs[i], subSize, focus.SelectIf(w.SelectChild(focus) && i == focusIdx), app)
+				res[i] = makeBox(i, subSize)
 				heights[i] = res[i].BoxRows()
 				rowsUsed += heights[i]
 			} else {
@@ -652,19 +840,19 @@ func RenderBoxMaker(w IWidget, size gowid.IRenderSize, focus gowid.Selector, foc
 		}
 		// Now actually render
 		for i := 0; i < wlen; i++ {
-			if _, ok := dims[i].(gowid.IRenderWithWeight); ok {
+			if _, ok := dims[i].(gowid.IRenderWithWeight); ok && !hidden[i] {
 				ss := gowid.RenderBox{box.BoxColumns(), heights[i]}
 				resSS[i] = ss
-				res[i] = fn.MakeBox(subs[i], ss, focus.SelectIf(w.SelectChild(focus) && i == focusIdx), app)
+				res[i] = makeBox(i, ss)
 			}
 		}
 	} else {
 		// FlowWith and Fixed
 		for i := 0; i < wlen; i++ {
 			// Should only be one!
-			if _, ok := dims[i].(gowid.IRenderWithWeight); ok {
+			if _, ok := dims[i].(gowid.IRenderWithWeight); ok && !hidden[i] {
 				resSS[i] = size
-				res[i] = fn.MakeBox(subs[i], size, focus.SelectIf(w.SelectChild(focus) && i == focusIdx), app)
+				res[i] = makeBox(i, size)
 			}
 		}
 	}
@@ -5,18 +5,23 @@ package pile
 
 import (
 	"fmt"
+	"io/ioutil"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwtest"
 	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/columns"
 	"github.com/gcla/gowid/widgets/fill"
 	"github.com/gcla/gowid/widgets/framed"
 	"github.com/gcla/gowid/widgets/list"
 	"github.com/gcla/gowid/widgets/selectable"
+	"github.com/gcla/gowid/widgets/styled"
 	"github.com/gcla/gowid/widgets/text"
 	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -289,6 +294,318 @@ bar
 baz`[1:], c.String())
 }
 
+func TestPileHidden1(t *testing.T) {
+	subs := []gowid.IContainerWidget{
+		&gowid.ContainerWidget{selectable.New(text.New("foo")), gowid.RenderFlow{}},
+		&gowid.ContainerWidget{selectable.New(fill.New('x')), gowid.RenderWithWeight{W: 1}},
+		&gowid.ContainerWidget{selectable.New(text.New("baz")), gowid.RenderFlow{}},
+	}
+	w := New(subs)
+	sz := gowid.RenderBox{C: 3, R: 4}
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "foo\nxxx\nxxx\nbaz", c.String())
+
+	w.SetHidden(0, true, gwtest.D)
+	assert.Equal(t, 1, w.Focus())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "xxx\nxxx\nxxx\nbaz", c.String())
+
+	w.SetHidden(1, true, gwtest.D)
+	assert.Equal(t, 2, w.Focus())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "baz\n   \n   \n   ", c.String())
+	assert.Equal(t, gowid.RenderBox{C: 3, R: 1}, w.RenderSize(gowid.RenderFlowWith{C: 3}, gowid.Focused, gwtest.D))
+
+	evup := tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	assert.False(t, w.UserInput(evup, sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, 2, w.Focus())
+
+	// A click on the row where the hidden widget was goes to the widget now there
+	gwtest.D.SetLastMouseState(gowid.MouseState{})
+	evclick := tcell.NewEventMouse(0, 0, tcell.Button1, 0)
+	w.UserInput(evclick, sz, gowid.Focused, gwtest.D)
+	gwtest.D.SetLastMouseState(gowid.MouseState{true, false, false, time.Now()})
+	evlmx := tcell.NewEventMouse(0, 0, tcell.ButtonNone, 0)
+	w.UserInput(evlmx, sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, 2, w.Focus())
+	gwtest.D.SetLastMouseState(gowid.MouseState{})
+
+	w.SetHidden(1, false, gwtest.D)
+	assert.Equal(t, 1, w.Focus())
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "xxx\nxxx\nxxx\nbaz", c.String())
+}
+
+func TestPileCollapse1(t *testing.T) {
+	subs := []gowid.IContainerWidget{
+		&gowid.ContainerWidget{selectable.New(text.New("head")), gowid.RenderFlow{}},
+		&gowid.ContainerWidget{selectable.New(text.New("a\nb\nc\nd")), gowid.RenderFlow{}},
+		&gowid.ContainerWidget{selectable.New(text.New("foot")), gowid.RenderFlow{}},
+	}
+	// Use a long frame interval so the timer doesn't fire - the test steps through the
+	// frames instead.
+	w := New(subs, Options{
+		StartRow:              1,
+		CollapseDuration:      2 * time.Hour,
+		CollapseFrameInterval: time.Hour,
+	})
+	started, done := []int{}, []int{}
+	w.OnCollapse(gowid.MakeWidgetCallbackExt("cb", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		started = append(started, data[0].(int))
+	}))
+	w.OnCollapseDone(gowid.MakeWidgetCallbackExt("cb", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		done = append(done, data[0].(int))
+	}))
+	sz := gowid.RenderFlowWith{C: 4}
+	render := func() string {
+		return w.Render(sz, gowid.Focused, gwtest.D).String()
+	}
+
+	w.SetCollapsed(1, true, gwtest.D)
+	assert.True(t, w.Collapsed(1))
+	assert.True(t, w.Animating(1))
+	assert.Equal(t, []int{1}, started)
+	assert.Equal(t, "head\na   \nb   \nc   \nd   \nfoot", render())
+
+	w.collapseTick(gwtest.D)
+	assert.Equal(t, 0.5, w.Revealed(1))
+	assert.Equal(t, "head\na   \nb   \nfoot", render())
+	assert.Equal(t, 1, w.Focus())
+	assert.Equal(t, 0, len(done))
+
+	w.collapseTick(gwtest.D)
+	assert.False(t, w.Animating(1))
+	assert.True(t, w.Hidden(1))
+	assert.Equal(t, []int{1}, done)
+	assert.Equal(t, 2, w.Focus())
+	assert.Equal(t, "head\nfoot", render())
+
+	// Expanding shows the child at once, and grows it
+	w.SetCollapsed(1, false, gwtest.D)
+	assert.False(t, w.Hidden(1))
+	assert.Equal(t, 1, w.Focus())
+	assert.Equal(t, "head\nfoot", render())
+	w.collapseTick(gwtest.D)
+	assert.Equal(t, "head\na   \nb   \nfoot", render())
+
+	// ...and reverses from part way through
+	w.ToggleCollapsed(1, gwtest.D)
+	assert.Equal(t, 0.5, w.Revealed(1))
+	w.collapseTick(gwtest.D)
+	assert.Equal(t, "head\nfoot", render())
+	assert.Equal(t, []int{1, 1, 1}, started)
+	assert.Equal(t, []int{1, 1}, done)
+
+	// Without a duration, the change is immediate
+	w2 := New(subs)
+	w2.SetCollapsed(0, true, gwtest.D)
+	assert.True(t, w2.Hidden(0))
+	assert.False(t, w2.Animating(0))
+	w2.SetSubWidgets(w2.SubWidgets(), gwtest.D)
+	assert.False(t, w2.Collapsed(0))
+}
+
+// deepWidget returns a tree of piles and columns of the given depth, with text at the
+// leaves, as might be found in a form or settings screen.
+func deepWidget(depth int) gowid.IWidget {
+	if depth == 0 {
+		return text.New("the quick brown fox jumps over the lazy dog")
+	}
+	subs := make([]gowid.IContainerWidget, 3)
+	for i := range subs {
+		subs[i] = &gowid.ContainerWidget{IWidget: deepWidget(depth - 1), D: gowid.RenderFlow{}}
+	}
+	if depth%2 == 0 {
+		for i := range subs {
+			subs[i].SetDimension(gowid.RenderWithWeight{W: 1})
+		}
+		return columns.New(subs)
+	}
+	return New(subs)
+}
+
+func BenchmarkRenderSizeDeep(b *testing.B) {
+	w := deepWidget(6)
+	sz := gowid.RenderFlowWith{C: 120}
+	for i := 0; i < b.N; i++ {
+		w.RenderSize(sz, gowid.Focused, gwtest.D)
+	}
+}
+
+func BenchmarkRenderSizeFallbackDeep(b *testing.B) {
+	w := deepWidget(6)
+	sz := gowid.RenderFlowWith{C: 120}
+	for i := 0; i < b.N; i++ {
+		gowid.CalculateRenderSizeFallback(w, sz, gowid.Focused, gwtest.D)
+	}
+}
+
+func TestPileConcurrent1(t *testing.T) {
+	mk := func(concurrent bool) *Widget {
+		return New([]gowid.IContainerWidget{
+			&gowid.ContainerWidget{text.New("abc"), gowid.RenderFixed{}},
+			&gowid.ContainerWidget{text.New("de fg"), gowid.RenderFlow{}},
+			&gowid.ContainerWidget{fill.New('x'), gowid.RenderWithWeight{W: 1}},
+			&gowid.ContainerWidget{fill.New('y'), gowid.RenderWithUnits{U: 2}},
+		}, Options{ConcurrentRender: concurrent})
+	}
+	sz := gowid.RenderBox{C: 3, R: 7}
+
+	w := mk(true)
+	assert.True(t, w.ConcurrentRender())
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "abc\nde \nfg \nxxx\nxxx\nyyy\nyyy", c.String())
+	assert.Equal(t, c.String(), mk(false).Render(sz, gowid.Focused, gwtest.D).String())
+
+	// Children part way through collapsing are cut short, as without concurrency
+	w.opt.CollapseDuration = time.Hour
+	w.opt.CollapseFrameInterval = 15 * time.Minute
+	w.SetCollapsed(1, true, gwtest.D)
+	w.collapseTick(gwtest.D)
+	w.collapseTick(gwtest.D)
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "abc\nde \nxxx\nxxx\nxxx\nyyy\nyyy", c.String())
+	w.resetCollapse()
+}
+
+func benchmarkRenderDeep(b *testing.B, concurrent bool) {
+	subs := make([]gowid.IContainerWidget, 4)
+	for i := range subs {
+		subs[i] = &gowid.ContainerWidget{IWidget: deepWidget(5), D: gowid.RenderFlow{}}
+	}
+	w := New(subs, Options{ConcurrentRender: concurrent})
+	sz := gowid.RenderFlowWith{C: 120}
+	for i := 0; i < b.N; i++ {
+		w.Render(sz, gowid.Focused, gwtest.D)
+	}
+}
+
+func BenchmarkRenderDeep(b *testing.B) {
+	benchmarkRenderDeep(b, false)
+}
+
+func BenchmarkRenderDeepConcurrent(b *testing.B) {
+	benchmarkRenderDeep(b, true)
+}
+
+func TestPileSelectionStyle1(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		w := New([]gowid.IContainerWidget{
+			&gowid.ContainerWidget{selectable.New(text.New("a")), gowid.RenderFlow{}},
+			&gowid.ContainerWidget{selectable.New(text.New("b")), gowid.RenderFlow{}},
+		}, Options{
+			SelectedStyle:    gowid.MakeStyledAs(gowid.StyleUnderline),
+			ConcurrentRender: concurrent,
+		})
+		sz := gowid.RenderFlowWith{C: 1}
+
+		// FocusedStyle defaults to SelectedStyle
+		c := w.Render(sz, gowid.Focused, gwtest.D)
+		assert.Equal(t, gowid.StyleUnderline, c.CellAt(0, 0).Style())
+		assert.Equal(t, gowid.StyleNone, c.CellAt(0, 1).Style())
+
+		w.SetFocus(gwtest.D, 1)
+		c = w.Render(sz, gowid.Selected, gwtest.D)
+		assert.Equal(t, gowid.StyleNone, c.CellAt(0, 0).Style())
+		assert.Equal(t, gowid.StyleUnderline, c.CellAt(0, 1).Style())
+	}
+}
+
+// trackedWidget tracks its geometry, and counts its renders without a lock - so go test
+// -race reports it if it's rendered concurrently while unsafe is set.
+type trackedWidget struct {
+	*text.Widget
+	id      string
+	unsafe  bool
+	renders *int
+}
+
+func (w *trackedWidget) ID() interface{} {
+	return w.id
+}
+
+func (w *trackedWidget) ConcurrentSafe() bool {
+	return !w.unsafe
+}
+
+func (w *trackedWidget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	if w.unsafe {
+		*w.renders++
+	}
+	res := w.Widget.Render(size, focus, app)
+	gowid.TrackGeometry(w, res, app)
+	return res
+}
+
+// Run with go test -race - the app's trackers are called from children rendered on
+// several goroutines.
+func TestPileConcurrentTrackers1(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(10, 11)
+
+	renders := 0
+	subs := make([]gowid.IContainerWidget, 0)
+	for i := 0; i < 4; i++ {
+		subs = append(subs, &gowid.ContainerWidget{button.NewWithAccelerator(fmt.Sprintf("&%c", 'a'+i)), gowid.RenderFixed{}})
+		subs = append(subs, &gowid.ContainerWidget{&trackedWidget{text.New("x"), fmt.Sprintf("t%d", i), false, nil}, gowid.RenderFixed{}})
+	}
+	// Not safe, even inside a safe widget
+	unsafe := &trackedWidget{text.New("u"), "u", true, &renders}
+	subs = append(subs, &gowid.ContainerWidget{framed.New(unsafe), gowid.RenderFixed{}}) // 3 rows
+	w := New(subs, Options{ConcurrentRender: true})
+
+	assert.False(t, gowid.ConcurrentSafe(framed.New(unsafe)))
+	assert.True(t, gowid.ConcurrentSafe(framed.New(text.New("x"))))
+
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen: screen,
+		View:   w,
+		Log:    logger,
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		gowid.RenderRoot(w, app)
+	}
+	assert.Equal(t, 3, renders)
+
+	clicked := ""
+	for i := 0; i < 4; i++ {
+		b := subs[i*2].(*gowid.ContainerWidget).IWidget.(*button.Widget)
+		b.OnClick(gowid.WidgetCallback{"cb", func(app gowid.IApp, w gowid.IWidget) {
+			clicked += "*"
+		}})
+		assert.True(t, app.ActivateAccelerator(rune('a'+i), app))
+		r, ok := app.GeometryOf(fmt.Sprintf("t%d", i))
+		assert.True(t, ok)
+		assert.Equal(t, gowid.Rect{X: 0, Y: i*2 + 1, W: 1, H: 1}, r)
+	}
+	assert.Equal(t, "****", clicked)
+	r, ok := app.GeometryOf("u")
+	assert.True(t, ok)
+	assert.Equal(t, gowid.Rect{X: 1, Y: 9, W: 1, H: 1}, r)
+}
+
+// Run with go test -race - the children share a color, converted on several goroutines.
+func TestPileConcurrentStyled1(t *testing.T) {
+	red := gowid.NewUrwidColor("dark red")
+	subs := make([]gowid.IContainerWidget, 0)
+	for i := 0; i < 8; i++ {
+		subs = append(subs, &gowid.ContainerWidget{styled.New(text.New("abc"), gowid.MakeForeground(red)), gowid.RenderFlow{}})
+	}
+	w := New(subs, Options{ConcurrentRender: true})
+	sz := gowid.RenderFlowWith{C: 3}
+
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, strings.Repeat("abc\n", 7)+"abc", c.String())
+	for y := 0; y < 8; y++ {
+		assert.Equal(t, gowid.MakeTCellColorExt(tcell.ColorMaroon), c.CellAt(0, y).ForegroundColor())
+	}
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
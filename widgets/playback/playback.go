@@ -0,0 +1,424 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package playback provides a widget that replays a recording of a terminal
+// session, such as one made by gowid's CastRecorder, inside a gowid canvas.
+// Recordings are in asciinema's cast v2 format.
+package playback
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/terminal"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/terminfo"
+	"github.com/pkg/errors"
+)
+
+//======================================================================
+
+// Event is one line of a cast after the header. Code is "o" for output and "r" for
+// a resize, in which case Data holds "COLSxROWS". Other codes are ignored on playback.
+type Event struct {
+	Time time.Duration
+	Code string
+	Data string
+}
+
+// Cast is a parsed asciinema cast v2 recording.
+type Cast struct {
+	Header gowid.CastHeader
+	Events []Event
+}
+
+type InvalidCastError struct {
+	Line int
+	Err  error
+}
+
+var _ error = InvalidCastError{}
+
+func (e InvalidCastError) Error() string {
+	return fmt.Sprintf("Invalid cast at line %d: %v", e.Line, e.Err)
+}
+
+func (e InvalidCastError) Cause() error {
+	return e.Err
+}
+
+// ReadCast parses a cast v2 recording.
+func ReadCast(r io.Reader) (*Cast, error) {
+	res := &Cast{}
+	rd := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := rd.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(strings.TrimSpace(string(b))) > 0 {
+			if line == 1 {
+				if jerr := json.Unmarshal(b, &res.Header); jerr != nil {
+					return nil, InvalidCastError{Line: line, Err: jerr}
+				}
+				if res.Header.Version != 2 {
+					return nil, InvalidCastError{Line: line, Err: errors.Errorf("unsupported version %d", res.Header.Version)}
+				}
+			} else {
+				ev, perr := parseEvent(b)
+				if perr != nil {
+					return nil, InvalidCastError{Line: line, Err: perr}
+				}
+				res.Events = append(res.Events, ev)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if res.Header.Version == 0 {
+		return nil, InvalidCastError{Line: 1, Err: errors.New("missing header")}
+	}
+	return res, nil
+}
+
+func parseEvent(b []byte) (Event, error) {
+	var raw []interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return Event{}, err
+	}
+	if len(raw) != 3 {
+		return Event{}, errors.Errorf("expected 3 fields, found %d", len(raw))
+	}
+	t, ok1 := raw[0].(float64)
+	code, ok2 := raw[1].(string)
+	data, ok3 := raw[2].(string)
+	if !ok1 || !ok2 || !ok3 {
+		return Event{}, errors.New("expected [time, code, data]")
+	}
+	return Event{
+		Time: time.Duration(t * float64(time.Second)),
+		Code: code,
+		Data: data,
+	}, nil
+}
+
+// Duration is the time of the last event in the cast.
+func (c *Cast) Duration() time.Duration {
+	if len(c.Events) == 0 {
+		return 0
+	}
+	return c.Events[len(c.Events)-1].Time
+}
+
+//======================================================================
+
+// Options can be supplied to New. Speed scales playback - 2 is twice as fast. If
+// zero, 1 is used. If Loop is true, playback restarts from the beginning when the
+// end of the recording is reached.
+type Options struct {
+	Speed float64
+	Loop  bool
+}
+
+type IPlayback interface {
+	Cast() *Cast
+	Position() time.Duration
+	IsPlaying() bool
+}
+
+type IWidget interface {
+	gowid.IWidget
+	IPlayback
+}
+
+type Finished struct{}
+
+// Widget replays a cast. It renders the state of a virtual terminal, sized by the
+// recording, at the current playback position. The widget is selectable - space
+// toggles playback, and home restarts from the beginning.
+type Widget struct {
+	cast    *Cast
+	opts    Options
+	canvas  *terminal.Canvas
+	modes   terminal.Modes
+	cols    int
+	rows    int
+	next    int           // index of the next event to apply
+	pos     time.Duration // playback position
+	playing bool
+	gen     int // incremented to invalidate pending timers
+	timer   *time.Timer
+	*gowid.Callbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+var _ terminal.ITerminal = (*player)(nil)
+
+func New(cast *Cast, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	res := &Widget{
+		cast:      cast,
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.reset()
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("playback[%v/%v]", w.pos, w.cast.Duration())
+}
+
+func (w *Widget) Cast() *Cast {
+	return w.cast
+}
+
+// Position is the time into the recording reached by playback.
+func (w *Widget) Position() time.Duration {
+	return w.pos
+}
+
+func (w *Widget) IsPlaying() bool {
+	return w.playing
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+// SetOpts changes the playback options. A change of speed takes effect from the
+// next event.
+func (w *Widget) SetOpts(opts Options, app gowid.IApp) {
+	w.opts = opts
+}
+
+func (w *Widget) OnFinished(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, Finished{}, f)
+}
+
+func (w *Widget) RemoveOnFinished(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, Finished{}, f)
+}
+
+// Play starts or resumes playback. Events are applied on the widget rendering goroutine,
+// and the app is redrawn after each one.
+func (w *Widget) Play(app gowid.IApp) {
+	if w.playing {
+		return
+	}
+	if w.next >= len(w.cast.Events) {
+		w.Seek(0, app)
+	}
+	w.playing = true
+	w.schedule(app)
+}
+
+func (w *Widget) Pause(app gowid.IApp) {
+	w.playing = false
+	w.cancel()
+}
+
+// Seek moves playback to the given time, replaying every event up to that point. If
+// the widget is playing, it continues from the new position.
+func (w *Widget) Seek(pos time.Duration, app gowid.IApp) {
+	w.cancel()
+	w.reset()
+	w.advanceTo(pos)
+	w.pos = pos
+	if w.playing {
+		w.schedule(app)
+	}
+}
+
+func (w *Widget) reset() {
+	w.cols, w.rows = w.cast.Header.Width, w.cast.Header.Height
+	w.modes = terminal.Modes{}
+	w.canvas = terminal.NewCanvasOfSize(w.cols, w.rows, 0, &player{w})
+	w.next = 0
+	w.pos = 0
+}
+
+func (w *Widget) cancel() {
+	w.gen++
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+func (w *Widget) speed() float64 {
+	if w.opts.Speed <= 0 {
+		return 1
+	}
+	return w.opts.Speed
+}
+
+// advanceTo applies all events with a time no later than pos.
+func (w *Widget) advanceTo(pos time.Duration) {
+	for ; w.next < len(w.cast.Events) && w.cast.Events[w.next].Time <= pos; w.next++ {
+		w.apply(w.cast.Events[w.next])
+	}
+}
+
+func (w *Widget) apply(ev Event) {
+	switch ev.Code {
+	case "o":
+		w.canvas.Write([]byte(ev.Data))
+	case "r":
+		var cols, rows int
+		if _, err := fmt.Sscanf(ev.Data, "%dx%d", &cols, &rows); err == nil && cols > 0 && rows > 0 {
+			w.cols, w.rows = cols, rows
+			w.canvas = terminal.NewCanvasOfSize(cols, rows, 0, &player{w})
+		}
+	}
+}
+
+func (w *Widget) schedule(app gowid.IApp) {
+	if w.next >= len(w.cast.Events) {
+		w.playing = false
+		gowid.RunWidgetCallbacks(w.Callbacks, Finished{}, app, w)
+		if w.opts.Loop && w.cast.Duration() > 0 {
+			w.Play(app)
+		}
+		return
+	}
+	gen := w.gen
+	at := w.cast.Events[w.next].Time
+	delay := time.Duration(float64(at-w.pos) / w.speed())
+	w.timer = time.AfterFunc(delay, func() {
+		app.Run(gowid.RunFunction(func(app gowid.IApp) {
+			if gen != w.gen || !w.playing {
+				return
+			}
+			w.pos = at
+			w.advanceTo(at)
+			w.schedule(app)
+		}))
+	})
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return RenderSize(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+// Frame returns a copy of the virtual terminal's contents at the current position.
+// The cursor is enabled if the recording left it visible.
+func (w *Widget) Frame() *gowid.Canvas {
+	lines := make([][]gowid.Cell, w.canvas.BoxRows())
+	for y := 0; y < len(lines); y++ {
+		line := w.canvas.Line(y, gowid.LineCopy{}).Line
+		lines[y] = append(make([]gowid.Cell, 0, len(line)), line...)
+	}
+	res := gowid.NewCanvasWithLines(lines)
+	if w.canvas.CursorEnabled() {
+		pos := w.canvas.CursorCoords()
+		res.SetCursorCoords(pos.X, pos.Y)
+	}
+	return res
+}
+
+//======================================================================
+
+// player satisfies the terminal canvas's requirements. Replies the canvas would send
+// to a process - e.g. status reports - are discarded.
+type player struct {
+	w *Widget
+}
+
+func (p *player) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (p *player) Width() int {
+	return p.w.cols
+}
+
+func (p *player) Height() int {
+	return p.w.rows
+}
+
+func (p *player) Modes() *terminal.Modes {
+	return &p.w.modes
+}
+
+func (p *player) Terminfo() *terminfo.Terminfo {
+	return nil
+}
+
+//======================================================================
+
+func RenderSize(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	switch sz := size.(type) {
+	case gowid.IRenderBox:
+		return gowid.RenderBox{C: sz.BoxColumns(), R: sz.BoxRows()}
+	case gowid.IRenderFlowWith:
+		return gowid.RenderBox{C: sz.FlowColumns(), R: w.rows}
+	case gowid.IRenderFixed:
+		return gowid.RenderBox{C: w.cols, R: w.rows}
+	default:
+		panic(gowid.WidgetSizeError{Widget: w, Size: size})
+	}
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	res := w.Frame()
+	if !focus.Focus {
+		res.SetCursorCoords(-1, -1)
+	}
+	switch sz := size.(type) {
+	case gowid.IRenderBox, gowid.IRenderFlowWith:
+		gowid.MakeCanvasRightSize(res, size)
+	case gowid.IRenderFixed:
+	default:
+		panic(gowid.WidgetSizeError{Widget: w, Size: sz})
+	}
+	return res
+}
+
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	evk, ok := ev.(*tcell.EventKey)
+	if !ok {
+		return false
+	}
+	switch {
+	case evk.Key() == tcell.KeyRune && evk.Rune() == ' ':
+		if w.IsPlaying() {
+			w.Pause(app)
+		} else {
+			w.Play(app)
+		}
+		return true
+	case evk.Key() == tcell.KeyHome:
+		w.Seek(0, app)
+		return true
+	}
+	return false
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,93 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package playback
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+var cast1 = `{"version": 2, "width": 5, "height": 2}
+[0.5, "o", "ab"]
+[1.0, "o", "\r\ncd"]
+[1.5, "i", "ignored"]
+[2.0, "o", "\u001b[H\u001b[2JX"]
+`
+
+func TestReadCast1(t *testing.T) {
+	c, err := ReadCast(strings.NewReader(cast1))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, c.Header.Width)
+	assert.Equal(t, 4, len(c.Events))
+	assert.Equal(t, Event{Time: time.Second, Code: "o", Data: "\r\ncd"}, c.Events[1])
+	assert.Equal(t, 2*time.Second, c.Duration())
+
+	_, err = ReadCast(strings.NewReader(`{"version": 1}`))
+	assert.Error(t, err)
+	_, err = ReadCast(strings.NewReader("{\"version\": 2}\n[1, \"o\"]\n"))
+	assert.Equal(t, 2, err.(InvalidCastError).Line)
+}
+
+func TestSeek1(t *testing.T) {
+	c, err := ReadCast(strings.NewReader(cast1))
+	assert.NoError(t, err)
+	w := New(c)
+
+	cv := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "     \n     ", cv.String())
+
+	w.Seek(time.Second, gwtest.D)
+	cv = w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "ab   \ncd   ", cv.String())
+
+	w.Seek(500*time.Millisecond, gwtest.D)
+	cv = w.Render(gowid.RenderBox{C: 3, R: 1}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "ab ", cv.String())
+
+	w.Seek(3*time.Second, gwtest.D)
+	cv = w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "X    \n     ", cv.String())
+
+	gwtest.RenderBoxManyTimes(t, w, 0, 10, 0, 10)
+	gwtest.RenderFlowManyTimes(t, w, 0, 10)
+}
+
+func TestRecordAndPlay1(t *testing.T) {
+	var buf bytes.Buffer
+	rec := gowid.NewCastRecorder(&buf)
+
+	cv := gowid.NewCanvasWithLines([][]gowid.Cell{gowid.CellsFromString("hi"), gowid.CellsFromString("yo")})
+	assert.NoError(t, rec.RecordFrame(cv, gwtest.D))
+	// An identical frame isn't recorded
+	assert.NoError(t, rec.RecordFrame(cv, gwtest.D))
+	cv2 := gowid.NewCanvasWithLines([][]gowid.Cell{gowid.CellsFromString("ok")})
+	assert.NoError(t, rec.RecordFrame(cv2, gwtest.D))
+
+	c, err := ReadCast(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, c.Header.Width)
+	assert.Equal(t, 2, c.Header.Height)
+	// o, r, o
+	assert.Equal(t, 3, len(c.Events))
+	assert.Equal(t, "r", c.Events[1].Code)
+	assert.Equal(t, "2x1", c.Events[1].Data)
+
+	w := New(c)
+	w.Seek(c.Duration(), gwtest.D)
+	assert.Equal(t, "ok", w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D).String())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
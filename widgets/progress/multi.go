@@ -0,0 +1,127 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package progress
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+// Multi stacks progress bars, one per row, each with a label to its left - e.g. for
+// several downloads running at once. The labels are lined up in a column as wide as
+// the longest. Multi must be rendered with a flow size, like the bars it holds.
+type Multi struct {
+	labels []string
+	bars   []*Widget
+	view   *pile.Widget
+	gowid.RejectUserInput
+	gowid.NotSelectable
+}
+
+var _ gowid.IWidget = (*Multi)(nil)
+
+func NewMulti() *Multi {
+	res := &Multi{}
+	res.build()
+	return res
+}
+
+func (m *Multi) String() string {
+	return fmt.Sprintf("multiprogress[%d]", len(m.bars))
+}
+
+// Bars returns the progress bars, from the top down.
+func (m *Multi) Bars() []*Widget {
+	return append([]*Widget(nil), m.bars...)
+}
+
+// Add adds a bar below the others, with the given label.
+func (m *Multi) Add(label string, bar *Widget, app gowid.IApp) {
+	m.labels = append(m.labels, label)
+	m.bars = append(m.bars, bar)
+	m.build()
+}
+
+// Remove removes the bar, and returns false if it isn't in the stack.
+func (m *Multi) Remove(bar *Widget, app gowid.IApp) bool {
+	i := m.find(bar)
+	if i == -1 {
+		return false
+	}
+	m.labels = append(m.labels[:i], m.labels[i+1:]...)
+	m.bars = append(m.bars[:i], m.bars[i+1:]...)
+	m.build()
+	return true
+}
+
+// Label returns the label of the bar, or "" if it isn't in the stack.
+func (m *Multi) Label(bar *Widget) string {
+	if i := m.find(bar); i != -1 {
+		return m.labels[i]
+	}
+	return ""
+}
+
+// SetLabel changes the label of the bar, and returns false if it isn't in the stack.
+func (m *Multi) SetLabel(bar *Widget, label string, app gowid.IApp) bool {
+	i := m.find(bar)
+	if i == -1 {
+		return false
+	}
+	m.labels[i] = label
+	m.build()
+	return true
+}
+
+func (m *Multi) find(bar *Widget) int {
+	for i, b := range m.bars {
+		if b == bar {
+			return i
+		}
+	}
+	return -1
+}
+
+// build makes a row for each bar, with the label padded to the width of the longest.
+func (m *Multi) build() {
+	width := 0
+	for _, l := range m.labels {
+		width = gwutil.Max(width, runewidth.StringWidth(l))
+	}
+	rows := make([]interface{}, len(m.bars))
+	for i, bar := range m.bars {
+		cws := []gowid.IContainerWidget{}
+		if width > 0 {
+			cws = append(cws, &gowid.ContainerWidget{IWidget: text.New(m.labels[i]), D: gowid.RenderWithUnits{U: width + 1}})
+		}
+		cws = append(cws, &gowid.ContainerWidget{IWidget: bar, D: gowid.RenderWithWeight{W: 1}})
+		rows[i] = columns.New(cws)
+	}
+	m.view = pile.NewFlow(rows...)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (m *Multi) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(m.view, size, focus, app)
+}
+
+func (m *Multi) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return m.view.Render(size, focus, app)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
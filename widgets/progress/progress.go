@@ -2,11 +2,16 @@
 // code is governed by the MIT license that can be found in the LICENSE
 // file.
 
-// Package progress provides a simple progress bar.
+// Package progress provides a simple progress bar, which can show how long the task has
+// taken and how long it has left, and a widget that stacks several labeled bars.
 package progress
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwutil"
@@ -42,6 +47,10 @@ type TargetCB struct{}
 type Widget struct {
 	Current, Done    int
 	normal, complete gowid.ICellStyler
+	format           string
+	start            time.Time
+	samples          []sample // Recent progress updates, to work out the rate
+	now              func() time.Time
 	Callbacks        *gowid.Callbacks
 	gowid.RejectUserInput
 	gowid.NotSelectable
@@ -63,6 +72,7 @@ func New(args Options) *Widget {
 		Done:      args.Target,
 		normal:    args.Normal,
 		complete:  args.Complete,
+		now:       time.Now,
 		Callbacks: gowid.NewCallbacks(),
 	}
 	var _ IWidget = res
@@ -73,14 +83,151 @@ func (w *Widget) String() string {
 	return fmt.Sprintf("progress")
 }
 
-func (w *Widget) Text() string {
-	var percent int
+type sample struct {
+	at      time.Time
+	current int
+}
+
+// The rate is worked out from the progress updates made in this period
+const rateWindow = 10 * time.Second
+
+func (w *Widget) percent() int {
 	if w.Done == 0 {
-		percent = 100
-	} else {
-		percent = gwutil.Min(100, gwutil.Max(0, w.Current*100/w.Done))
+		return 100
+	}
+	return gwutil.Min(100, gwutil.Max(0, w.Current*100/w.Done))
+}
+
+// Text returns the string displayed in the bar - the percentage complete, or the
+// format set with SetFormat, expanded.
+func (w *Widget) Text() string {
+	if w.format == "" {
+		return fmt.Sprintf("%d %%", w.percent())
+	}
+	return w.expand(w.format)
+}
+
+// Format returns the format set with SetFormat.
+func (w *Widget) Format() string {
+	return w.format
+}
+
+// SetFormat sets the text displayed in the bar. These verbs are replaced:
+//
+//	%p  percentage complete, e.g. 42%
+//	%c  units completed
+//	%t  units required overall
+//	%e  time elapsed since the first update, e.g. 1:05
+//	%E  estimated time remaining, or --:-- if it isn't known yet
+//	%r  units completed per second, e.g. 12.5
+//	%%  a literal %
+//
+// The times are worked out from when SetProgress and SetTarget are called. If format
+// is "", the bar shows the percentage complete.
+func (w *Widget) SetFormat(format string, app gowid.IApp) {
+	w.format = format
+}
+
+func (w *Widget) expand(format string) string {
+	var sb strings.Builder
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' || i+1 == len(runes) {
+			sb.WriteRune(runes[i])
+			continue
+		}
+		i++
+		switch runes[i] {
+		case 'p':
+			sb.WriteString(fmt.Sprintf("%d%%", w.percent()))
+		case 'c':
+			sb.WriteString(strconv.Itoa(w.Current))
+		case 't':
+			sb.WriteString(strconv.Itoa(w.Done))
+		case 'e':
+			sb.WriteString(formatDuration(w.Elapsed()))
+		case 'E':
+			if eta, ok := w.ETA(); ok {
+				sb.WriteString(formatDuration(eta))
+			} else {
+				sb.WriteString("--:--")
+			}
+		case 'r':
+			rate, _ := w.Rate()
+			sb.WriteString(strconv.FormatFloat(rate, 'f', 1, 64))
+		case '%':
+			sb.WriteRune('%')
+		default:
+			sb.WriteRune('%')
+			sb.WriteRune(runes[i])
+		}
 	}
-	return fmt.Sprintf("%d %%", percent)
+	return sb.String()
+}
+
+// formatDuration shows d as minutes and seconds, with hours if needed, e.g. 1:05 or
+// 2:01:05.
+func formatDuration(d time.Duration) string {
+	secs := int(math.Round(d.Seconds()))
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, (secs/60)%60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+// record notes the time of a progress update.
+func (w *Widget) record() {
+	now := w.now()
+	if w.start.IsZero() {
+		w.start = now
+	}
+	w.samples = append(w.samples, sample{at: now, current: w.Current})
+	for len(w.samples) > 2 && now.Sub(w.samples[1].at) >= rateWindow {
+		w.samples = w.samples[1:]
+	}
+}
+
+func (w *Widget) finished() bool {
+	return w.Done > 0 && w.Current >= w.Done
+}
+
+// Elapsed returns the time since the first call to SetProgress or SetTarget. Once the
+// target is reached, it returns the time taken.
+func (w *Widget) Elapsed() time.Duration {
+	if w.start.IsZero() {
+		return 0
+	}
+	if w.finished() {
+		return w.samples[len(w.samples)-1].at.Sub(w.start)
+	}
+	return w.now().Sub(w.start)
+}
+
+// Rate returns the number of units completed per second, measured over the last few
+// seconds, and false if there haven't been enough updates to tell.
+func (w *Widget) Rate() (float64, bool) {
+	if len(w.samples) < 2 {
+		return 0, false
+	}
+	first, last := w.samples[0], w.samples[len(w.samples)-1]
+	dt := last.at.Sub(first.at).Seconds()
+	if dt <= 0 {
+		return 0, false
+	}
+	return float64(last.current-first.current) / dt, true
+}
+
+// ETA returns the estimated time until the target is reached, and false if it can't be
+// estimated yet.
+func (w *Widget) ETA() (time.Duration, bool) {
+	if w.finished() {
+		return 0, true
+	}
+	rate, ok := w.Rate()
+	if !ok || rate <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(w.Done-w.Current) / rate * float64(time.Second)), true
 }
 
 func (w *Widget) OnSetProgress(f gowid.IWidgetChangedCallback) {
@@ -106,6 +253,7 @@ func (w *Widget) SetProgress(app gowid.IApp, current int) {
 	} else if w.Current < 0 {
 		w.Current = 0
 	}
+	w.record()
 	gowid.RunWidgetCallbacks(w.Callbacks, ProgressCB{}, app, w)
 }
 
@@ -117,9 +265,26 @@ func (w *Widget) SetTarget(app gowid.IApp, target int) {
 	if w.Current > w.Done {
 		w.Current = w.Done
 	}
+	w.record()
 	gowid.RunWidgetCallbacks(w.Callbacks, TargetCB{}, app, w)
 }
 
+// BindProgress keeps the widget's progress in step with the property. Changes made from
+// other goroutines - e.g. a worker reporting how far it has got - are applied on the
+// widget rendering goroutine.
+func (w *Widget) BindProgress(app gowid.IApp, p *gowid.Property[int]) *gowid.Binding {
+	return gowid.Bind(app, p, func(app gowid.IApp, current int) {
+		w.SetProgress(app, current)
+	})
+}
+
+// BindTarget keeps the widget's target in step with the property.
+func (w *Widget) BindTarget(app gowid.IApp, p *gowid.Property[int]) *gowid.Binding {
+	return gowid.Bind(app, p, func(app gowid.IApp, target int) {
+		w.SetTarget(app, target)
+	})
+}
+
 func (w *Widget) Progress() int {
 	return w.Current
 }
@@ -166,8 +331,8 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 	percentStyle := gowid.MakePaletteEntry(fnorm, gowid.NoColor{})
 
 	fcomp, bcomp, scomp := w.Complete().GetStyle(app)
-	fcompCol := gowid.IColorToTCell(fcomp, gowid.ColorNone, app.GetColorMode())
-	bcompCol := gowid.IColorToTCell(bcomp, gowid.ColorNone, app.GetColorMode())
+	fcompCol := gowid.ColorToTCellIn(fcomp, gowid.ColorNone, app)
+	bcompCol := gowid.ColorToTCellIn(bcomp, gowid.ColorNone, app)
 
 	cur, done := w.Progress(), w.Target()
 	var cutoff int
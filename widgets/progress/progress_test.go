@@ -6,6 +6,7 @@ package progress
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwtest"
@@ -70,6 +71,54 @@ func TestCanvas23(t *testing.T) {
 	}
 }
 
+func TestFormat1(t *testing.T) {
+	w := New(Options{Normal: gowid.EmptyPalette{}, Complete: gowid.EmptyPalette{}, Target: 100})
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+	w.SetFormat("%p %c/%t %e eta %E %r/s 100%%", gwtest.D)
+
+	assert.Equal(t, "0% 0/100 0:00 eta --:-- 0.0/s 100%", w.Text())
+	w.SetProgress(gwtest.D, 0)
+	now = now.Add(5 * time.Second)
+	w.SetProgress(gwtest.D, 10)
+	assert.Equal(t, "10% 10/100 0:05 eta 0:45 2.0/s 100%", w.Text())
+
+	now = now.Add(65 * time.Second)
+	assert.Equal(t, 70*time.Second, w.Elapsed())
+	w.SetProgress(gwtest.D, 50)
+	// Only recent updates count towards the rate
+	rate, ok := w.Rate()
+	assert.True(t, ok)
+	assert.InDelta(t, 40.0/65.0, rate, 0.001)
+
+	now = now.Add(time.Hour)
+	w.SetProgress(gwtest.D, 100)
+	now = now.Add(time.Hour)
+	assert.Equal(t, "100% 100/100 1:01:10 eta 0:00 0.0/s 100%", w.Text())
+
+	w.SetFormat("", gwtest.D)
+	assert.Equal(t, "100 %", w.Text())
+}
+
+func TestMulti1(t *testing.T) {
+	m := NewMulti()
+	a := New(Options{Normal: gowid.EmptyPalette{}, Complete: gowid.EmptyPalette{}})
+	b := New(Options{Normal: gowid.EmptyPalette{}, Complete: gowid.EmptyPalette{}})
+	m.Add("a.txt", a, gwtest.D)
+	m.Add("big.iso", b, gwtest.D)
+	b.SetProgress(gwtest.D, 50)
+
+	c := m.Render(gowid.RenderFlowWith{C: 18}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "a.txt       0 %   \nbig.iso    50 %   ", c.String())
+
+	assert.True(t, m.Remove(a, gwtest.D))
+	assert.False(t, m.Remove(a, gwtest.D))
+	assert.True(t, m.SetLabel(b, "x", gwtest.D))
+	c = m.Render(gowid.RenderFlowWith{C: 12}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "x    50 %   ", c.String())
+	assert.Equal(t, []*Widget{b}, m.Bars())
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
@@ -135,6 +135,7 @@ func Select(w IWidget, app gowid.IApp) {
 		}
 		w.SetStateInternal(true)
 		gowid.RunWidgetCallbacks(w, gowid.ClickCB{}, app, w)
+		gowid.Announce(app, w, gowid.Translate("selected"))
 	}
 }
 
@@ -0,0 +1,222 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package search
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/highlight"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/table"
+	"github.com/gcla/gowid/widgets/text"
+)
+
+//======================================================================
+
+// WidgetText returns the text displayed by w, found by looking for a Text() or
+// Content() method on w or, failing that, on its descendants, whose texts are joined
+// with spaces. It is used by the adapters to search widgets without rendering them.
+func WidgetText(w gowid.IWidget) string {
+	switch w := w.(type) {
+	case interface{ Text() string }:
+		return w.Text()
+	case interface{ Content() text.IContent }:
+		return w.Content().String()
+	case gowid.IComposite:
+		return WidgetText(w.SubWidget())
+	case gowid.ICompositeMultiple:
+		texts := make([]string, 0)
+		for _, sub := range w.SubWidgets() {
+			if t := WidgetText(sub); t != "" {
+				texts = append(texts, t)
+			}
+		}
+		return strings.Join(texts, " ")
+	default:
+		return ""
+	}
+}
+
+// AdapterOptions can be supplied to the adapters' constructors. If MatchStyle is nil,
+// DefaultMatchStyle is used.
+type AdapterOptions struct {
+	MatchStyle gowid.ICellStyler
+}
+
+func adapterOpts(opts []AdapterOptions) AdapterOptions {
+	var opt AdapterOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.MatchStyle == nil {
+		opt.MatchStyle = DefaultMatchStyle
+	}
+	return opt
+}
+
+func setPattern(h *highlight.Widget, re *regexp.Regexp, style gowid.ICellStyler, app gowid.IApp) {
+	if re == nil {
+		h.Clear(app)
+	} else {
+		h.SetPatterns([]highlight.Pattern{{Regexp: re, Style: style}}, app)
+	}
+}
+
+//======================================================================
+
+// Text makes a widget displaying text searchable - e.g. a text or edit widget. The
+// matches are highlighted; ShowMatch does nothing, since the widget doesn't scroll.
+type Text struct {
+	*highlight.Widget
+	opts AdapterOptions
+}
+
+var _ ISearchable = (*Text)(nil)
+
+func NewText(inner gowid.IWidget, opts ...AdapterOptions) *Text {
+	return &Text{
+		Widget: highlight.New(inner),
+		opts:   adapterOpts(opts),
+	}
+}
+
+func (w *Text) SetSearch(re *regexp.Regexp, app gowid.IApp) int {
+	setPattern(w.Widget, re, w.opts.MatchStyle, app)
+	if re == nil {
+		return 0
+	}
+	return len(re.FindAllStringIndex(WidgetText(w.SubWidget()), -1))
+}
+
+func (w *Text) ShowMatch(i int, app gowid.IApp) {}
+
+//======================================================================
+
+// List makes a list widget searchable. Each item's text is found with WidgetText, and
+// ShowMatch moves the list's focus to the item holding the match. The matches visible
+// on screen are highlighted.
+type List struct {
+	*highlight.Widget
+	list    *list.Widget
+	matches []list.IWalkerPosition
+	opts    AdapterOptions
+}
+
+var _ ISearchable = (*List)(nil)
+
+func NewList(l *list.Widget, opts ...AdapterOptions) *List {
+	return &List{
+		Widget: highlight.New(l),
+		list:   l,
+		opts:   adapterOpts(opts),
+	}
+}
+
+func (w *List) SetSearch(re *regexp.Regexp, app gowid.IApp) int {
+	setPattern(w.Widget, re, w.opts.MatchStyle, app)
+	w.matches = nil
+	if re == nil {
+		return 0
+	}
+	walker := w.list.Walker()
+	var pos list.IWalkerPosition
+	if home, ok := walker.(list.IWalkerHome); ok {
+		pos = home.First()
+	} else {
+		pos = walker.Focus()
+		for pos != nil && walker.At(pos) != nil {
+			prev := walker.Previous(pos)
+			if prev == nil || walker.At(prev) == nil {
+				break
+			}
+			pos = prev
+		}
+	}
+	for pos != nil {
+		item := walker.At(pos)
+		if item == nil {
+			break
+		}
+		for range re.FindAllStringIndex(WidgetText(item), -1) {
+			w.matches = append(w.matches, pos)
+		}
+		pos = walker.Next(pos)
+	}
+	return len(w.matches)
+}
+
+func (w *List) ShowMatch(i int, app gowid.IApp) {
+	if i >= 0 && i < len(w.matches) {
+		w.list.Walker().SetFocus(w.matches[i], app)
+	}
+}
+
+//======================================================================
+
+// Table makes a table widget searchable. Each cell's text is found with WidgetText,
+// and ShowMatch moves the table's focus to the cell holding the match, scrolling
+// horizontally if need be. The matches visible on screen are highlighted.
+type Table struct {
+	*highlight.Widget
+	table   *table.Widget
+	matches []table.Coords
+	opts    AdapterOptions
+}
+
+var _ ISearchable = (*Table)(nil)
+
+func NewTable(t *table.Widget, opts ...AdapterOptions) *Table {
+	return &Table{
+		Widget: highlight.New(t),
+		table:  t,
+		opts:   adapterOpts(opts),
+	}
+}
+
+func (w *Table) SetSearch(re *regexp.Regexp, app gowid.IApp) int {
+	setPattern(w.Widget, re, w.opts.MatchStyle, app)
+	w.matches = nil
+	if re == nil {
+		return 0
+	}
+	model := w.table.Model()
+	header := 0
+	if w.table.HeaderInCoords() {
+		header = 1
+	}
+	for row := 0; ; row++ {
+		if bm, ok := model.(table.IBoundedModel); ok && row >= bm.Rows() {
+			break
+		}
+		rid, ok := model.RowIdentifier(row)
+		if !ok {
+			break
+		}
+		cells := model.CellWidgets(rid)
+		if cells == nil {
+			break
+		}
+		for col, cell := range cells {
+			for range re.FindAllStringIndex(WidgetText(cell), -1) {
+				w.matches = append(w.matches, table.Coords{Column: col, Row: row + header})
+			}
+		}
+	}
+	return len(w.matches)
+}
+
+func (w *Table) ShowMatch(i int, app gowid.IApp) {
+	if i >= 0 && i < len(w.matches) {
+		w.table.SetFocusXY(app, w.matches[i])
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
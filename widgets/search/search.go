@@ -0,0 +1,264 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package search provides a search bar widget that finds text in another widget, and
+// moves between the matches. Any widget implementing ISearchable can be searched;
+// adapters are provided for text, list and table widgets, and the terminal widget
+// implements it directly to search its scrollback.
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/edit"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// ISearchable is implemented by widgets whose content can be searched. SetSearch
+// finds the matches of re - or clears the search if re is nil - highlights them, and
+// returns how many there are. ShowMatch makes match i, counting from 0 in the order
+// the matches appear, visible - e.g. by scrolling to it or moving the focus to it.
+type ISearchable interface {
+	SetSearch(re *regexp.Regexp, app gowid.IApp) int
+	ShowMatch(i int, app gowid.IApp)
+}
+
+// DefaultMatchStyle is used by the adapters in this package to highlight matches.
+var DefaultMatchStyle gowid.ICellStyler = gowid.MakePaletteEntry(gowid.ColorBlack, gowid.ColorYellow)
+
+//======================================================================
+
+// Options can be supplied to New. The caption defaults to "/". Unless Regexp is true,
+// the query is searched for literally. Unless CaseSensitive is true, the search
+// ignores case if the query is all lower-case.
+type Options struct {
+	Caption       string
+	Regexp        bool
+	CaseSensitive bool
+}
+
+// CloseCB is the name under which callbacks are registered that run when the user
+// presses escape in the bar.
+type CloseCB struct{}
+
+// MatchCB is the name under which callbacks are registered that run when the current
+// match changes. The callback's extra argument is the index of the match, or -1.
+type MatchCB struct{}
+
+type IWidget interface {
+	gowid.IWidget
+	Query() string
+	Matches() int
+	Current() int
+}
+
+// Bar is a one-line widget for typing a query. The target is searched as the query is
+// typed, and the first match shown. Enter, down and ctrl-n move to the next match, and
+// up and ctrl-p to the previous one, wrapping around; the bar shows the position of
+// the current match and the number found.
+type Bar struct {
+	gowid.IWidget
+	edit    *edit.Widget
+	count   *text.Widget
+	target  ISearchable
+	matches int
+	current int
+	err     error
+	opts    Options
+	*gowid.Callbacks
+}
+
+var _ gowid.IWidget = (*Bar)(nil)
+var _ IWidget = (*Bar)(nil)
+
+func New(target ISearchable, opts ...Options) *Bar {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Caption == "" {
+		opt.Caption = "/"
+	}
+	res := &Bar{
+		edit:      edit.New(edit.Options{Caption: opt.Caption}),
+		count:     text.New(""),
+		target:    target,
+		current:   -1,
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.IWidget = columns.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{IWidget: res.edit, D: gowid.RenderWithWeight{W: 1}},
+		&gowid.ContainerWidget{IWidget: res.count, D: gowid.RenderFixed{}},
+	})
+	res.edit.OnTextSet(gowid.WidgetCallback{Name: "search", WidgetChangedFunction: func(app gowid.IApp, w gowid.IWidget) {
+		res.update(app)
+	}})
+	return res
+}
+
+func (w *Bar) String() string {
+	return fmt.Sprintf("search[%q,%d/%d]", w.Query(), w.current+1, w.matches)
+}
+
+func (w *Bar) Opts() Options {
+	return w.opts
+}
+
+func (w *Bar) Target() ISearchable {
+	return w.target
+}
+
+// SetTarget changes the widget searched, and searches it for the current query.
+func (w *Bar) SetTarget(target ISearchable, app gowid.IApp) {
+	if w.target != nil {
+		w.target.SetSearch(nil, app)
+	}
+	w.target = target
+	w.update(app)
+}
+
+func (w *Bar) Query() string {
+	return w.edit.Text()
+}
+
+func (w *Bar) SetQuery(query string, app gowid.IApp) {
+	w.edit.SetText(query, app)
+	w.edit.SetCursorPos(len(query), app)
+}
+
+// Matches returns the number of matches of the query.
+func (w *Bar) Matches() int {
+	return w.matches
+}
+
+// Current returns the index of the current match, or -1 if there is none.
+func (w *Bar) Current() int {
+	return w.current
+}
+
+// Err returns the error from compiling the query as a regular expression, if any.
+func (w *Bar) Err() error {
+	return w.err
+}
+
+// Expression returns the regular expression searched for, or nil if the query is
+// empty.
+func (w *Bar) Expression() (*regexp.Regexp, error) {
+	query := w.Query()
+	if query == "" {
+		return nil, nil
+	}
+	if !w.opts.Regexp {
+		query = regexp.QuoteMeta(query)
+	}
+	if !w.opts.CaseSensitive && strings.IndexFunc(w.Query(), unicode.IsUpper) == -1 {
+		query = "(?i)" + query
+	}
+	return regexp.Compile(query)
+}
+
+// Next moves to the next match, returning false if there are none.
+func (w *Bar) Next(app gowid.IApp) bool {
+	return w.move(1, app)
+}
+
+// Previous moves to the previous match, returning false if there are none.
+func (w *Bar) Previous(app gowid.IApp) bool {
+	return w.move(-1, app)
+}
+
+func (w *Bar) OnClose(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, CloseCB{}, f)
+}
+
+func (w *Bar) RemoveOnClose(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, CloseCB{}, f)
+}
+
+func (w *Bar) OnMatch(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, MatchCB{}, f)
+}
+
+func (w *Bar) RemoveOnMatch(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, MatchCB{}, f)
+}
+
+// update searches the target for the query, and shows the first match.
+func (w *Bar) update(app gowid.IApp) {
+	var re *regexp.Regexp
+	re, w.err = w.Expression()
+	w.matches = 0
+	if w.target != nil {
+		w.matches = w.target.SetSearch(re, app)
+	}
+	w.current = -1
+	if w.matches > 0 {
+		w.show(0, app)
+	} else {
+		w.updateCount(app)
+		gowid.RunWidgetCallbacks(w.Callbacks, MatchCB{}, app, w, -1)
+	}
+}
+
+func (w *Bar) move(dir int, app gowid.IApp) bool {
+	if w.matches == 0 {
+		return false
+	}
+	w.show((w.current+dir+w.matches)%w.matches, app)
+	return true
+}
+
+func (w *Bar) show(i int, app gowid.IApp) {
+	w.current = i
+	w.target.ShowMatch(i, app)
+	w.updateCount(app)
+	gowid.RunWidgetCallbacks(w.Callbacks, MatchCB{}, app, w, i)
+}
+
+func (w *Bar) updateCount(app gowid.IApp) {
+	switch {
+	case w.err != nil:
+		w.count.SetText(" "+gowid.Translate("invalid"), app)
+	case w.Query() == "":
+		w.count.SetText("", app)
+	case w.matches == 0:
+		w.count.SetText(" "+gowid.Translate("no matches"), app)
+	default:
+		w.count.SetText(fmt.Sprintf(" %d/%d", w.current+1, w.matches), app)
+	}
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Bar) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if evk, ok := ev.(*tcell.EventKey); ok {
+		switch evk.Key() {
+		case tcell.KeyEnter, tcell.KeyDown, tcell.KeyCtrlN:
+			w.Next(app)
+			return true
+		case tcell.KeyUp, tcell.KeyCtrlP:
+			w.Previous(app)
+			return true
+		case tcell.KeyEscape:
+			gowid.RunWidgetCallbacks(w.Callbacks, CloseCB{}, app, w)
+			return true
+		}
+	}
+	return w.IWidget.UserInput(ev, size, focus, app)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
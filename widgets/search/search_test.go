@@ -0,0 +1,121 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/table"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func typeQuery(bar *Bar, s string) {
+	for _, r := range s {
+		bar.UserInput(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), gowid.RenderFlowWith{C: 30}, gowid.Focused, gwtest.D)
+	}
+}
+
+func key(bar *Bar, k tcell.Key) {
+	bar.UserInput(tcell.NewEventKey(k, 0, tcell.ModNone), gowid.RenderFlowWith{C: 30}, gowid.Focused, gwtest.D)
+}
+
+func TestListSearch1(t *testing.T) {
+	items := []gowid.IWidget{}
+	for _, s := range []string{"apple", "banana", "cherry", "Apple pie", "grape"} {
+		items = append(items, button.New(text.New(s)))
+	}
+	walker := list.NewSimpleListWalker(items)
+	target := NewList(list.New(walker))
+	bar := New(target)
+
+	typeQuery(bar, "app")
+	assert.Equal(t, 2, bar.Matches())
+	assert.Equal(t, 0, bar.Current())
+	assert.Equal(t, list.ListPos(0), walker.Focus())
+	assert.Equal(t, "/app      1/2", bar.Render(gowid.RenderFlowWith{C: 13}, gowid.NotSelected, gwtest.D).String())
+
+	key(bar, tcell.KeyEnter)
+	assert.Equal(t, list.ListPos(3), walker.Focus())
+	key(bar, tcell.KeyEnter)
+	assert.Equal(t, list.ListPos(0), walker.Focus())
+	key(bar, tcell.KeyUp)
+	assert.Equal(t, list.ListPos(3), walker.Focus())
+
+	// Smart case - an upper-case letter makes the search case-sensitive
+	bar.SetQuery("Apple", gwtest.D)
+	assert.Equal(t, 1, bar.Matches())
+	assert.Equal(t, list.ListPos(3), walker.Focus())
+
+	bar.SetQuery("zzz", gwtest.D)
+	assert.Equal(t, 0, bar.Matches())
+	assert.Equal(t, -1, bar.Current())
+	assert.False(t, bar.Next(gwtest.D))
+	assert.Equal(t, "/zzz  no matches", bar.Render(gowid.RenderFlowWith{C: 16}, gowid.NotSelected, gwtest.D).String())
+
+	closed := false
+	bar.OnClose(gowid.WidgetCallback{Name: "cb", WidgetChangedFunction: func(app gowid.IApp, w gowid.IWidget) {
+		closed = true
+	}})
+	key(bar, tcell.KeyEscape)
+	assert.True(t, closed)
+}
+
+func TestTableSearch1(t *testing.T) {
+	model := table.NewSimpleModel([]string{"name", "tag"}, [][]string{
+		{"one", "x"},
+		{"two", "needle"},
+		{"needle", "y"},
+	})
+	tbl := table.New(model)
+	target := NewTable(tbl)
+	bar := New(target, Options{Regexp: true})
+
+	bar.SetQuery("need.e", gwtest.D)
+	assert.Equal(t, 2, bar.Matches())
+	xy, err := tbl.FocusXY()
+	assert.NoError(t, err)
+	assert.Equal(t, table.Coords{Column: 1, Row: 1}, xy)
+
+	bar.Next(gwtest.D)
+	xy, err = tbl.FocusXY()
+	assert.NoError(t, err)
+	assert.Equal(t, table.Coords{Column: 0, Row: 2}, xy)
+
+	bar.SetQuery("(", gwtest.D)
+	assert.Error(t, bar.Err())
+	assert.Equal(t, 0, bar.Matches())
+}
+
+func TestTextSearch1(t *testing.T) {
+	target := NewText(text.New("one two one"))
+	bar := New(target)
+	bar.SetQuery("one", gwtest.D)
+	assert.Equal(t, 2, bar.Matches())
+
+	c := target.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	hits := make([]string, 0)
+	for x := 0; x < c.BoxColumns(); x++ {
+		if c.CellAt(x, 0).BackgroundColor() != gowid.ColorNone {
+			hits = append(hits, string(c.CellAt(x, 0).Rune()))
+		}
+	}
+	assert.Equal(t, "oneone", strings.Join(hits, ""))
+
+	bar.SetQuery("", gwtest.D)
+	assert.Equal(t, 0, len(target.Patterns()))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -23,6 +23,29 @@ type IWidget interface {
 	IOffset
 }
 
+// IShadowOptions is optionally implemented by a shadow widget to further customize
+// how the shadow is drawn.
+type IShadowOptions interface {
+	ShadowOptions() Options
+}
+
+// Options can be supplied to NewExt. XOffset is the number of columns to extend
+// the shadow right - if zero, twice the offset is used, so that the shadow looks as
+// wide as it is tall in a terminal whose cells are about twice as tall as they are
+// wide. Set XOffset to the offset for a shadow offset by the same number of cells in
+// each direction, e.g. 1,1. If Dim is true, the shadow
+// is drawn with empty, dimmed cells rather than black cells, so that when the
+// widget is composed with an overlay, the widget underneath shows through, dimmed.
+// If Rounded is true, box-drawing corners in the top-left, top-right, bottom-left
+// and bottom-right of the inner widget's canvas are replaced with rounded versions
+// - for example, a dialog drawn with a light unicode frame will have its corners
+// rounded.
+type Options struct {
+	XOffset int
+	Dim     bool
+	Rounded bool
+}
+
 // Widget will render a drop shadow underneath and to the right of the inner widget,
 // providing a simple 3D effect.
 //
@@ -34,18 +57,25 @@ type Widget struct {
 	offset int // Means y offset, x is 2*y because cells are not squares -
 	// we just guess at a reasonable look for a reasonable
 	// aspect ratio
+	opts Options
 	*gowid.Callbacks
 	gowid.SubWidgetCallbacks
 }
 
 func New(inner gowid.IWidget, offset int) *Widget {
+	return NewExt(inner, offset, Options{})
+}
+
+func NewExt(inner gowid.IWidget, offset int, opts Options) *Widget {
 	res := &Widget{
 		IWidget: inner,
 		offset:  offset,
+		opts:    opts,
 	}
 	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
 	var _ gowid.ICompositeWidget = res
 	var _ IWidget = res
+	var _ IShadowOptions = res
 	return res
 }
 
@@ -70,6 +100,14 @@ func (w *Widget) SetOffset(x int, app gowid.IApp) {
 	w.offset = x
 }
 
+func (w *Widget) ShadowOptions() Options {
+	return w.opts
+}
+
+func (w *Widget) SetShadowOptions(opts Options, app gowid.IApp) {
+	w.opts = opts
+}
+
 func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
 	return UserInput(w, ev, size, focus, app)
 }
@@ -103,16 +141,61 @@ func UserInput(w gowid.ICompositeWidget, ev interface{}, size gowid.IRenderSize,
 	return false
 }
 
+// Rounded corner replacements for the box-drawing corners commonly used by frames.
+var roundedCorners = map[rune]rune{
+	'┌': '╭', '┏': '╭', '╔': '╭',
+	'┐': '╮', '┓': '╮', '╗': '╮',
+	'└': '╰', '┗': '╰', '╚': '╰',
+	'┘': '╯', '┛': '╯', '╝': '╯',
+}
+
+func options(w interface{}) Options {
+	if sw, ok := w.(IShadowOptions); ok {
+		return sw.ShadowOptions()
+	}
+	return Options{}
+}
+
+func xOffset(w IOffset) int {
+	if x := options(w).XOffset; x != 0 {
+		return x
+	}
+	return w.Offset() * 2
+}
+
+func roundCorners(c gowid.ICanvas) {
+	cols, rows := c.BoxColumns(), c.BoxRows()
+	if cols == 0 || rows == 0 {
+		return
+	}
+	for _, pos := range []gowid.CanvasPos{{X: 0, Y: 0}, {X: cols - 1, Y: 0}, {X: 0, Y: rows - 1}, {X: cols - 1, Y: rows - 1}} {
+		cell := c.CellAt(pos.X, pos.Y)
+		if r, ok := roundedCorners[cell.Rune()]; ok {
+			c.SetCellAt(pos.X, pos.Y, cell.WithRune(r))
+		}
+	}
+}
+
 func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	opts := options(w)
 	newSize := w.SubWidgetSize(size, focus, app)
-	innerCanvas := w.SubWidget().Render(newSize, focus, app)
+	innerCanvas := gowid.RenderChild(w.SubWidget(), newSize, focus, app)
+
+	if opts.Rounded {
+		roundCorners(innerCanvas)
+	}
+
+	shadowCell := gowid.MakeCell(' ', gowid.MakeTCellColorExt(tcell.ColorDefault), gowid.MakeTCellColorExt(tcell.ColorBlack), gowid.StyleNone)
+	if opts.Dim {
+		// No rune and no colors - merged onto what is underneath, only dimming it
+		shadowCell = gowid.Cell{}.WithStyle(gowid.StyleDim)
+	}
 
-	shadowCanvas := gowid.NewCanvasOfSizeExt(innerCanvas.BoxColumns(), innerCanvas.BoxRows(),
-		gowid.MakeCell(' ', gowid.MakeTCellColorExt(tcell.ColorDefault), gowid.MakeTCellColorExt(tcell.ColorBlack), gowid.StyleNone))
+	shadowCanvas := gowid.CanvasPoolOf(app).NewCanvasOfSizeExt(innerCanvas.BoxColumns(), innerCanvas.BoxRows(), shadowCell)
 
-	shadowCanvas.ExtendLeft(gowid.EmptyLine(w.Offset() * 2))
+	shadowCanvas.ExtendLeft(gowid.EmptyLine(xOffset(w)))
 
-	res := gowid.NewCanvasOfSize(shadowCanvas.BoxColumns(), w.Offset())
+	res := gowid.CanvasPoolOf(app).NewCanvasOfSize(shadowCanvas.BoxColumns(), w.Offset())
 	res.AppendBelow(shadowCanvas, false, false)
 	res.MergeUnder(innerCanvas, 0, 0, false)
 
@@ -125,9 +208,9 @@ func SubWidgetSize(w IOffset, size gowid.IRenderSize, focus gowid.Selector, app
 	case gowid.IRenderFixed:
 		newSize = gowid.RenderFixed{}
 	case gowid.IRenderBox:
-		newSize = gowid.RenderBox{C: sz.BoxColumns() - (w.Offset() * 2), R: sz.BoxRows() - w.Offset()}
+		newSize = gowid.RenderBox{C: sz.BoxColumns() - xOffset(w), R: sz.BoxRows() - w.Offset()}
 	case gowid.IRenderFlowWith:
-		newSize = gowid.RenderFlowWith{C: sz.FlowColumns() - 2}
+		newSize = gowid.RenderFlowWith{C: sz.FlowColumns() - xOffset(w)}
 	default:
 		panic(gowid.WidgetSizeError{Widget: w, Size: size})
 	}
@@ -137,7 +220,7 @@ func SubWidgetSize(w IOffset, size gowid.IRenderSize, focus gowid.Selector, app
 func RenderSize(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
 	ss := w.SubWidgetSize(size, focus, app)
 	sdim := w.SubWidget().RenderSize(ss, focus, app)
-	return gowid.RenderBox{C: sdim.BoxColumns() + (w.Offset() * 2), R: sdim.BoxRows() + w.Offset()}
+	return gowid.RenderBox{C: sdim.BoxColumns() + xOffset(w), R: sdim.BoxRows() + w.Offset()}
 }
 
 //======================================================================
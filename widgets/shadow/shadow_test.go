@@ -0,0 +1,56 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package shadow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestShadow1(t *testing.T) {
+	// By default, the shadow is twice as wide as it is tall, to allow for the aspect
+	// ratio of terminal cells
+	w := New(text.New("ab"), 1)
+	c := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, 4, c.BoxColumns())
+	assert.Equal(t, 2, c.BoxRows())
+	assert.Equal(t, gowid.ColorBlack, c.CellAt(3, 1).BackgroundColor())
+	assert.False(t, c.CellAt(3, 0).HasRune())
+}
+
+func TestShadowDim1(t *testing.T) {
+	// Offset by 1,1
+	w := NewExt(text.New("ab"), 1, Options{XOffset: 1, Dim: true})
+	c := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, 3, c.BoxColumns())
+	assert.Equal(t, 2, c.BoxRows())
+	sc := c.CellAt(2, 1)
+	assert.False(t, sc.HasRune())
+	assert.Equal(t, gowid.ColorNone, sc.BackgroundColor())
+	assert.Equal(t, gowid.StyleDim, sc.Style())
+
+	gwtest.RenderBoxManyTimes(t, w, 1, 10, 2, 10)
+}
+
+func TestShadowRounded1(t *testing.T) {
+	w := NewExt(framed.NewUnicode(text.New("ab")), 1, Options{Rounded: true})
+	c := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	lines := strings.Split(c.String(), "\n")
+	assert.Equal(t, "╭━━╮  ", lines[0])
+	assert.Equal(t, "╰━━╯  ", lines[2])
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,380 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package slider provides a widget for choosing a number from a range by moving a bar
+// of block characters with the mouse or the cursor keys.
+package slider
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+type Orientation int
+
+const (
+	Horizontal Orientation = iota
+	Vertical
+)
+
+func (o Orientation) String() string {
+	switch o {
+	case Horizontal:
+		return "horizontal"
+	case Vertical:
+		return "vertical"
+	default:
+		return fmt.Sprintf("orientation(%d)", int(o))
+	}
+}
+
+// Tick is a mark drawn beside the slider at Value, with an optional label.
+type Tick struct {
+	Value float64
+	Label string
+}
+
+// Options can be supplied to New. The slider chooses values from Min to Max - if Max
+// isn't greater than Min, from 0 to 100 - in multiples of Step from Min, where Step
+// defaults to 1. A vertical slider with a flow size is Length rows high - by default,
+// 10. Ticks are drawn beside the bar, below it for a horizontal slider, and to the
+// right for a vertical one. FillStyle is used for the part of the bar up to the value,
+// and TrackStyle for the rest; FocusStyle is applied to the whole bar when the slider
+// has the focus, and defaults to bold.
+type Options struct {
+	Value       float64
+	Min         float64
+	Max         float64
+	Step        float64
+	Orientation Orientation
+	Length      int
+	Ticks       []Tick
+	FillStyle   gowid.ICellStyler
+	TrackStyle  gowid.ICellStyler
+	FocusStyle  gowid.ICellStyler
+}
+
+type IWidget interface {
+	gowid.IWidget
+	Value() float64
+	SetValue(v float64, app gowid.IApp)
+}
+
+// ChangeCB is the name under which callbacks are registered that run when the value
+// changes. The callback's extra argument is the new value, a float64.
+type ChangeCB struct{}
+
+// Widget is a slider. The cursor keys move it by a step - right and up increase the
+// value - page up and page down by a tenth of the range, and home and end to either
+// end. Pressing the left mouse button on the bar, or dragging along it, sets the value
+// under the mouse.
+type Widget struct {
+	value float64
+	opts  Options
+	*gowid.Callbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+const (
+	fullBlock  = '█'
+	emptyBlock = '░'
+	tickMark   = '┬'
+	tickMarkV  = '├'
+)
+
+// Partial blocks, indexed by eighths filled
+var (
+	hblocks = []rune(" ▏▎▍▌▋▊▉")
+	vblocks = []rune(" ▁▂▃▄▅▆▇")
+)
+
+func New(opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Max <= opt.Min {
+		opt.Min, opt.Max = 0, 100
+	}
+	if opt.Step <= 0 {
+		opt.Step = 1
+	}
+	if opt.Length <= 0 {
+		opt.Length = 10
+	}
+	if opt.FocusStyle == nil {
+		opt.FocusStyle = gowid.MakeStyledAs(gowid.StyleBold)
+	}
+	res := &Widget{
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.value = res.clamp(opt.Value)
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("slider[%v]", w.value)
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Value() float64 {
+	return w.value
+}
+
+// SetValue sets the value, rounded to a step and kept within the range, and runs the
+// change callbacks if it changed.
+func (w *Widget) SetValue(v float64, app gowid.IApp) {
+	v = w.clamp(v)
+	if v != w.value {
+		w.value = v
+		gowid.RunWidgetCallbacks(w.Callbacks, ChangeCB{}, app, w, v)
+	}
+}
+
+func (w *Widget) OnChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, ChangeCB{}, f)
+}
+
+func (w *Widget) RemoveOnChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, ChangeCB{}, f)
+}
+
+func (w *Widget) clamp(v float64) float64 {
+	o := w.opts
+	v = o.Min + math.Round((v-o.Min)/o.Step)*o.Step
+	return math.Max(o.Min, math.Min(o.Max, v))
+}
+
+// fraction returns how far v is along the range, from 0 to 1.
+func (w *Widget) fraction(v float64) float64 {
+	return (v - w.opts.Min) / (w.opts.Max - w.opts.Min)
+}
+
+func (w *Widget) hasLabels() bool {
+	for _, t := range w.opts.Ticks {
+		if t.Label != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// length returns the number of cells along the bar for the size supplied.
+func (w *Widget) length(size gowid.IRenderSize) int {
+	if w.opts.Orientation == Vertical {
+		if box, ok := size.(gowid.IRenderBox); ok {
+			return box.BoxRows()
+		}
+		return w.opts.Length
+	}
+	if cols, ok := size.(gowid.IColumns); ok {
+		return cols.Columns()
+	}
+	return w.opts.Length
+}
+
+// position returns the cell along the bar of length n at which v lies.
+func (w *Widget) position(v float64, n int) int {
+	return int(math.Round(w.fraction(v) * float64(n-1)))
+}
+
+// valueAt returns the value for the cell along the bar of length n at position i.
+func (w *Widget) valueAt(i, n int) float64 {
+	if n <= 1 {
+		return w.opts.Min
+	}
+	return w.opts.Min + float64(i)/float64(n-1)*(w.opts.Max-w.opts.Min)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.CalculateRenderSizeFallback(w, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+func styledCell(r rune, styler gowid.ICellStyler, app gowid.IApp) gowid.Cell {
+	res := gowid.MakeCell(r, gowid.ColorNone, gowid.ColorNone, gowid.StyleNone)
+	if styler != nil {
+		f, b, s := styler.GetStyle(app)
+		res = res.MergeDisplayAttrsUnder(gowid.MakeCell(0,
+			gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+			gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+			s))
+	}
+	return res
+}
+
+// barCells returns the n cells of the bar, from the Min end to the Max end.
+func (w *Widget) barCells(n int, blocks []rune, focus bool, app gowid.IApp) []gowid.Cell {
+	eighths := int(math.Round(w.fraction(w.value) * float64(n*8)))
+	res := make([]gowid.Cell, n)
+	for i := 0; i < n; i++ {
+		var c gowid.Cell
+		switch {
+		case i < eighths/8:
+			c = styledCell(fullBlock, w.opts.FillStyle, app)
+		case i == eighths/8 && eighths%8 != 0:
+			c = styledCell(blocks[eighths%8], w.opts.FillStyle, app)
+		default:
+			c = styledCell(emptyBlock, w.opts.TrackStyle, app)
+		}
+		if focus {
+			c = c.MergeDisplayAttrsUnder(styledCell(0, w.opts.FocusStyle, app))
+		}
+		res[i] = c
+	}
+	return res
+}
+
+func setString(c gowid.ICanvas, x, y int, s string) {
+	for _, r := range s {
+		if x >= c.BoxColumns() {
+			break
+		}
+		c.SetCellAt(x, y, gowid.MakeCell(r, gowid.ColorNone, gowid.ColorNone, gowid.StyleNone))
+		x += runewidth.RuneWidth(r)
+	}
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	n := w.length(size)
+	var res gowid.ICanvas
+	if w.opts.Orientation == Vertical {
+		cols := 1
+		if len(w.opts.Ticks) > 0 {
+			cols = 2
+			for _, t := range w.opts.Ticks {
+				cols = gwutil.Max(cols, 3+runewidth.StringWidth(t.Label))
+			}
+		}
+		res = gowid.NewCanvasOfSize(cols, n)
+		// The Max end is at the top
+		for i, c := range w.barCells(n, vblocks, focus.Focus, app) {
+			res.SetCellAt(0, n-1-i, c)
+		}
+		for _, t := range w.opts.Ticks {
+			if t.Value < w.opts.Min || t.Value > w.opts.Max {
+				continue
+			}
+			y := n - 1 - w.position(t.Value, n)
+			res.SetCellAt(1, y, gowid.MakeCell(tickMarkV, gowid.ColorNone, gowid.ColorNone, gowid.StyleNone))
+			if t.Label != "" {
+				setString(res, 3, y, t.Label)
+			}
+		}
+	} else {
+		rows := 1
+		if len(w.opts.Ticks) > 0 {
+			rows++
+			if w.hasLabels() {
+				rows++
+			}
+		}
+		res = gowid.NewCanvasOfSize(n, rows)
+		for i, c := range w.barCells(n, hblocks, focus.Focus, app) {
+			res.SetCellAt(i, 0, c)
+		}
+		end := 0 // Labels mustn't overlap
+		for _, t := range w.opts.Ticks {
+			if t.Value < w.opts.Min || t.Value > w.opts.Max {
+				continue
+			}
+			x := w.position(t.Value, n)
+			res.SetCellAt(x, 1, gowid.MakeCell(tickMark, gowid.ColorNone, gowid.ColorNone, gowid.StyleNone))
+			if t.Label != "" {
+				lw := runewidth.StringWidth(t.Label)
+				lx := gwutil.Max(0, gwutil.Min(x-lw/2, n-lw))
+				if lx >= end {
+					setString(res, lx, 2, t.Label)
+					end = lx + lw + 1
+				}
+			}
+		}
+	}
+	if box, ok := size.(gowid.IRenderBox); ok {
+		gowid.MakeCanvasRightSize(res, box)
+	}
+	return res
+}
+
+// UserInput moves the slider with the cursor keys, page up and page down, home and
+// end, and sets the value under the mouse when the left button is pressed on the bar.
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	o := w.opts
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		v := w.value
+		page := math.Max(o.Step, (o.Max-o.Min)/10)
+		switch ev.Key() {
+		case tcell.KeyRight, tcell.KeyUp:
+			v += o.Step
+		case tcell.KeyLeft, tcell.KeyDown:
+			v -= o.Step
+		case tcell.KeyPgUp:
+			v += page
+		case tcell.KeyPgDn:
+			v -= page
+		case tcell.KeyHome:
+			v = o.Min
+		case tcell.KeyEnd:
+			v = o.Max
+		default:
+			return false
+		}
+		// Consume the key at either end, so focus doesn't move unexpectedly
+		w.SetValue(v, app)
+		return true
+	case *tcell.EventMouse:
+		if ev.Buttons() != tcell.Button1 {
+			return false
+		}
+		x, y := ev.Position()
+		n := w.length(size)
+		i := x
+		if o.Orientation == Vertical {
+			if x != 0 {
+				return false
+			}
+			i = n - 1 - y
+		} else if y != 0 {
+			return false
+		}
+		if i < 0 || i >= n {
+			return false
+		}
+		w.SetValue(w.valueAt(i, n), app)
+		return true
+	}
+	return false
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
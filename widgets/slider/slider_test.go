@@ -0,0 +1,78 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package slider
+
+import (
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func key(k tcell.Key) *tcell.EventKey {
+	return tcell.NewEventKey(k, 0, tcell.ModNone)
+}
+
+func TestSlider1(t *testing.T) {
+	w := New(Options{
+		Value: 55,
+		Ticks: []Tick{{0, "0"}, {50, "50"}, {100, "100"}},
+	})
+	sz := gowid.RenderFlowWith{C: 10}
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "█████▌░░░░\n┬    ┬   ┬\n0   50 100", c.String())
+	assert.Equal(t, tcell.AttrBold, c.CellAt(0, 0).Style().OnOff&tcell.AttrBold)
+	c = w.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, tcell.AttrMask(0), c.CellAt(0, 0).Style().OnOff&tcell.AttrBold)
+
+	var values []float64
+	w.OnChange(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, _ gowid.IWidget, data ...interface{}) {
+		values = append(values, data[0].(float64))
+	}})
+	for _, k := range []tcell.Key{tcell.KeyRight, tcell.KeyEnd, tcell.KeyUp, tcell.KeyPgDn, tcell.KeyHome} {
+		assert.True(t, w.UserInput(key(k), sz, gowid.Focused, gwtest.D))
+	}
+	// Up at the maximum doesn't change the value
+	assert.Equal(t, []float64{56, 100, 90, 0}, values)
+	assert.False(t, w.UserInput(key(tcell.KeyEnter), sz, gowid.Focused, gwtest.D))
+
+	assert.True(t, w.UserInput(tcell.NewEventMouse(9, 0, tcell.Button1, 0), sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, float64(100), w.Value())
+	// Dragging sends more button events
+	assert.True(t, w.UserInput(tcell.NewEventMouse(3, 0, tcell.Button1, 0), sz, gowid.Focused, gwtest.D))
+	assert.Equal(t, float64(33), w.Value())
+	assert.False(t, w.UserInput(tcell.NewEventMouse(3, 1, tcell.Button1, 0), sz, gowid.Focused, gwtest.D))
+}
+
+func TestSlider2(t *testing.T) {
+	w := New(Options{
+		Value:       5,
+		Min:         0,
+		Max:         10,
+		Step:        5,
+		Orientation: Vertical,
+		Length:      4,
+		Ticks:       []Tick{{0, "lo"}, {10, "hi"}},
+	})
+	sz := gowid.RenderFixed{}
+	assert.Equal(t, "░├ hi\n░    \n█    \n█├ lo", w.Render(sz, gowid.NotSelected, gwtest.D).String())
+	assert.Equal(t, gowid.RenderBox{C: 5, R: 4}, w.RenderSize(sz, gowid.NotSelected, gwtest.D))
+
+	w.SetValue(7, gwtest.D)
+	assert.Equal(t, float64(5), w.Value())
+	w.UserInput(key(tcell.KeyUp), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, float64(10), w.Value())
+	w.UserInput(tcell.NewEventMouse(0, 3, tcell.Button1, 0), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, float64(0), w.Value())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,282 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package spinbox provides an editable numeric field with buttons and keys to step
+// the value up and down.
+package spinbox
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/vim"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/edit"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// Options can be supplied to New. Value is the initial value. If Max is greater than
+// Min, the value is kept between them; otherwise it is unbounded. Step is the amount
+// the value changes by for each increment - by default, 1 - and page up and page down
+// change it by ten steps. Values are rounded to, and shown with, Precision digits
+// after the decimal point. If Wrap is true, stepping up from Max goes to Min, and
+// stepping down from Min goes to Max. IncKeys and DecKeys default to the up and down
+// cursor keys. If NoButtons is true, the - and + buttons aren't shown.
+type Options struct {
+	Value     float64
+	Min       float64
+	Max       float64
+	Step      float64
+	Precision int
+	Wrap      bool
+	IncKeys   []vim.KeyPress
+	DecKeys   []vim.KeyPress
+	NoButtons bool
+}
+
+var (
+	DefaultIncKeys = []vim.KeyPress{
+		vim.NewKeyPress(tcell.KeyUp, 0, tcell.ModNone),
+	}
+	DefaultDecKeys = []vim.KeyPress{
+		vim.NewKeyPress(tcell.KeyDown, 0, tcell.ModNone),
+	}
+)
+
+type IWidget interface {
+	gowid.IWidget
+	Value() float64
+	SetValue(v float64, app gowid.IApp)
+	Step(n int, app gowid.IApp)
+}
+
+// ChangeCB is the name under which callbacks are registered that run when the value
+// changes. The callback's extra argument is the new value, a float64.
+type ChangeCB struct{}
+
+// Widget is a numeric field. Typed text becomes the value when enter is pressed or
+// the widget loses the focus; if it isn't a number, the field goes back to showing
+// the current value. Only characters that can appear in a number are accepted.
+type Widget struct {
+	value   float64
+	opts    Options
+	edit    *edit.Widget
+	view    *columns.Widget // The edit field, and the buttons if shown
+	focused bool
+	*gowid.Callbacks
+	gowid.IsSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Step <= 0 {
+		opt.Step = 1
+	}
+	if opt.IncKeys == nil {
+		opt.IncKeys = DefaultIncKeys
+	}
+	if opt.DecKeys == nil {
+		opt.DecKeys = DefaultDecKeys
+	}
+	res := &Widget{
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.value = res.clamp(opt.Value)
+	res.edit = edit.New(edit.Options{Text: res.format(res.value)})
+
+	cws := []gowid.IContainerWidget{
+		&gowid.ContainerWidget{IWidget: res.edit, D: gowid.RenderWithWeight{W: 1}},
+	}
+	if !opt.NoButtons {
+		for _, b := range []struct {
+			label string
+			n     int
+		}{{"-", -1}, {"+", 1}} {
+			b := b
+			btn := button.New(text.New(b.label))
+			btn.OnClick(gowid.WidgetCallback{"cb", func(app gowid.IApp, _ gowid.IWidget) {
+				res.Step(b.n, app)
+			}})
+			cws = append(cws, &gowid.ContainerWidget{IWidget: btn, D: gowid.RenderFixed{}})
+		}
+	}
+	res.view = columns.New(cws)
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("spinbox[%s]", w.format(w.value))
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Value() float64 {
+	return w.value
+}
+
+// SetValue sets the value, keeping it within the bounds, and runs the change
+// callbacks if it changed. Any text typed but not yet entered is replaced.
+func (w *Widget) SetValue(v float64, app gowid.IApp) {
+	v = w.clamp(v)
+	w.edit.SetText(w.format(v), app)
+	w.edit.SetCursorPos(len(w.edit.Text()), app)
+	if v != w.value {
+		w.value = v
+		gowid.RunWidgetCallbacks(w.Callbacks, ChangeCB{}, app, w, v)
+	}
+}
+
+// Step changes the value by n steps, after first entering any typed text.
+func (w *Widget) Step(n int, app gowid.IApp) {
+	w.commit(app)
+	v := w.value + float64(n)*w.opts.Step
+	if w.bounded() && w.opts.Wrap {
+		// Stop at the bound first, then wrap on the next step
+		if v > w.opts.Max {
+			if w.value >= w.opts.Max {
+				v = w.opts.Min
+			} else {
+				v = w.opts.Max
+			}
+		} else if v < w.opts.Min {
+			if w.value <= w.opts.Min {
+				v = w.opts.Max
+			} else {
+				v = w.opts.Min
+			}
+		}
+	}
+	w.SetValue(v, app)
+}
+
+func (w *Widget) OnChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, ChangeCB{}, f)
+}
+
+func (w *Widget) RemoveOnChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, ChangeCB{}, f)
+}
+
+func (w *Widget) bounded() bool {
+	return w.opts.Max > w.opts.Min
+}
+
+func (w *Widget) clamp(v float64) float64 {
+	p := math.Pow(10, float64(w.opts.Precision))
+	v = math.Round(v*p) / p
+	if w.bounded() {
+		v = math.Max(w.opts.Min, math.Min(w.opts.Max, v))
+	}
+	return v
+}
+
+func (w *Widget) format(v float64) string {
+	return strconv.FormatFloat(v, 'f', w.opts.Precision, 64)
+}
+
+// commit makes the typed text the value, or restores the text if it isn't a number.
+func (w *Widget) commit(app gowid.IApp) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(w.edit.Text()), 64)
+	if err != nil {
+		v = w.value
+	}
+	w.SetValue(v, app)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(w.view, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	if w.focused && !focus.Focus {
+		w.commit(app)
+	}
+	w.focused = focus.Focus
+	return w.view.Render(size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+func isNumberRune(r rune) bool {
+	return (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '+'
+}
+
+// UserInput steps the value with the configured keys, page up and page down, and the
+// mouse wheel. Enter makes the typed text the value. Clicks go to the edit field and
+// buttons, but the edit field keeps the focus.
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch {
+		case vim.KeyIn(ev, w.opts.IncKeys):
+			w.Step(1, app)
+			return true
+		case vim.KeyIn(ev, w.opts.DecKeys):
+			w.Step(-1, app)
+			return true
+		}
+		switch ev.Key() {
+		case tcell.KeyPgUp:
+			w.Step(10, app)
+			return true
+		case tcell.KeyPgDn:
+			w.Step(-10, app)
+			return true
+		case tcell.KeyEnter:
+			w.commit(app)
+			return true
+		case tcell.KeyRune:
+			if !isNumberRune(ev.Rune()) {
+				return false
+			}
+		}
+		return w.edit.UserInput(ev, gowid.RenderFixed{}, focus, app)
+	case *tcell.EventMouse:
+		x, y := ev.Position()
+		box := gowid.RenderSize(w, size, focus, app)
+		if x < 0 || y < 0 || x >= box.BoxColumns() || y >= box.BoxRows() {
+			return false
+		}
+		switch ev.Buttons() {
+		case tcell.WheelUp:
+			w.Step(1, app)
+			return true
+		case tcell.WheelDown:
+			w.Step(-1, app)
+			return true
+		}
+		res := w.view.UserInput(ev, size, focus, app)
+		w.view.SetFocus(app, 0)
+		return res
+	}
+	return false
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,101 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package spinbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func key(k tcell.Key) *tcell.EventKey {
+	return tcell.NewEventKey(k, 0, tcell.ModNone)
+}
+
+func TestSpinbox1(t *testing.T) {
+	w := New(Options{Value: 5, Min: 0, Max: 10, Step: 2})
+	sz := gowid.RenderFlowWith{C: 10}
+	assert.Equal(t, "5   <-><+>", w.Render(sz, gowid.NotSelected, gwtest.D).String())
+
+	var values []float64
+	w.OnChange(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, _ gowid.IWidget, data ...interface{}) {
+		values = append(values, data[0].(float64))
+	}})
+
+	for i := 0; i < 4; i++ {
+		assert.True(t, w.UserInput(key(tcell.KeyUp), sz, gowid.Focused, gwtest.D))
+	}
+	// Stops at the maximum
+	assert.Equal(t, []float64{7, 9, 10}, values)
+	assert.Equal(t, "10  <-><+>", w.Render(sz, gowid.Focused, gwtest.D).String())
+
+	w.UserInput(key(tcell.KeyPgDn), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, float64(0), w.Value())
+
+	// Click the + button
+	w.UserInput(tcell.NewEventMouse(8, 0, tcell.Button1, 0), sz, gowid.Focused, gwtest.D)
+	gwtest.D.SetLastMouseState(gowid.MouseState{MouseLeftClicked: true, MouseLastClickedTime: time.Now().Add(-2 * time.Second)})
+	w.UserInput(tcell.NewEventMouse(8, 0, tcell.ButtonNone, 0), sz, gowid.Focused, gwtest.D)
+	gwtest.D.SetLastMouseState(gowid.MouseState{})
+	assert.Equal(t, float64(2), w.Value())
+	// The edit field keeps the focus
+	assert.Equal(t, 0, w.view.Focus())
+
+	w.UserInput(tcell.NewEventMouse(0, 0, tcell.WheelDown, 0), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, float64(0), w.Value())
+}
+
+func TestSpinbox2(t *testing.T) {
+	w := New(Options{Value: 1, Min: 0, Max: 2, Step: 0.5, Precision: 1, Wrap: true, NoButtons: true})
+	sz := gowid.RenderFlowWith{C: 5}
+	assert.Equal(t, "1.0  ", w.Render(sz, gowid.Focused, gwtest.D).String())
+
+	var values []float64
+	w.OnChange(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, _ gowid.IWidget, data ...interface{}) {
+		values = append(values, data[0].(float64))
+	}})
+	for i := 0; i < 3; i++ {
+		w.UserInput(key(tcell.KeyUp), sz, gowid.Focused, gwtest.D)
+	}
+	w.UserInput(key(tcell.KeyDown), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, []float64{1.5, 2, 0, 2}, values)
+
+	// Type a value - letters are rejected
+	for i := 0; i < 3; i++ {
+		w.UserInput(key(tcell.KeyBackspace2), sz, gowid.Focused, gwtest.D)
+	}
+	assert.False(t, w.UserInput(tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), sz, gowid.Focused, gwtest.D))
+	for _, r := range "1.26" {
+		assert.True(t, w.UserInput(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), sz, gowid.Focused, gwtest.D))
+	}
+	assert.Equal(t, float64(2), w.Value())
+	w.UserInput(key(tcell.KeyEnter), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, 1.3, w.Value())
+	assert.Equal(t, "1.3  ", w.Render(sz, gowid.Focused, gwtest.D).String())
+
+	// Out of range text is clamped when the widget loses the focus; junk is discarded
+	for i := 0; i < 3; i++ {
+		w.UserInput(key(tcell.KeyBackspace2), sz, gowid.Focused, gwtest.D)
+	}
+	w.UserInput(tcell.NewEventKey(tcell.KeyRune, '9', tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	w.Render(sz, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, float64(2), w.Value())
+	w.SetValue(1, gwtest.D)
+	w.UserInput(tcell.NewEventKey(tcell.KeyRune, '-', tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	w.UserInput(key(tcell.KeyEnter), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, float64(1), w.Value())
+	assert.Equal(t, "1.0  ", w.Render(sz, gowid.Focused, gwtest.D).String())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,319 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package statusbar provides a one-line widget made of text segments on the left,
+// center and right, which truncates or drops its least important segments when there
+// isn't room for them all.
+package statusbar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+type Side int
+
+const (
+	Left Side = iota
+	Center
+	Right
+)
+
+func (s Side) String() string {
+	switch s {
+	case Left:
+		return "left"
+	case Center:
+		return "center"
+	case Right:
+		return "right"
+	default:
+		return fmt.Sprintf("side(%d)", int(s))
+	}
+}
+
+// Segment is a piece of the status bar. Segments on the same side are shown in the
+// order supplied. When the bar is too narrow, the segment with the lowest Priority is
+// shortened first - to no fewer than MinWidth columns, with an ellipsis - and then
+// dropped; if MinWidth is 0, the segment is dropped without being shortened. Of
+// segments with the same priority, the last is shortened first. Segments with no
+// text aren't shown.
+type Segment struct {
+	Name     string
+	Text     string
+	Side     Side
+	Priority int
+	MinWidth int
+	Style    gowid.ICellStyler
+}
+
+// Options can be supplied to New. Separator is drawn between neighbouring segments on
+// the same side - by default, " │ ". Style is applied to the whole bar, and defaults
+// to reverse video.
+type Options struct {
+	Separator string
+	Style     gowid.ICellStyler
+}
+
+type IWidget interface {
+	gowid.IWidget
+	Text(name string) string
+	SetText(name string, text string, app gowid.IApp) bool
+}
+
+// Widget is a status bar. It is always one row high.
+type Widget struct {
+	segments []Segment
+	opts     Options
+	gowid.RejectUserInput
+	gowid.NotSelectable
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(segments []Segment, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Separator == "" {
+		opt.Separator = " │ "
+	}
+	if opt.Style == nil {
+		opt.Style = gowid.MakeStyledAs(gowid.StyleReverse)
+	}
+	res := &Widget{
+		segments: append([]Segment(nil), segments...),
+		opts:     opt,
+	}
+	return res
+}
+
+func (w *Widget) String() string {
+	texts := make([]string, 0, len(w.segments))
+	for _, s := range w.segments {
+		texts = append(texts, s.Text)
+	}
+	return fmt.Sprintf("statusbar[%s]", strings.Join(texts, ","))
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+// Segments returns a copy of the bar's segments.
+func (w *Widget) Segments() []Segment {
+	return append([]Segment(nil), w.segments...)
+}
+
+func (w *Widget) SetSegments(segments []Segment, app gowid.IApp) {
+	w.segments = append([]Segment(nil), segments...)
+}
+
+func (w *Widget) find(name string) int {
+	for i, s := range w.segments {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Text returns the text of the named segment, or "" if there is no such segment.
+func (w *Widget) Text(name string) string {
+	if i := w.find(name); i != -1 {
+		return w.segments[i].Text
+	}
+	return ""
+}
+
+// SetText sets the text of the named segment, and returns false if there is no such
+// segment. Like other widget methods, it must be called on the widget rendering
+// goroutine - e.g. from a widget callback. Use PostText from other goroutines.
+func (w *Widget) SetText(name string, text string, app gowid.IApp) bool {
+	i := w.find(name)
+	if i == -1 {
+		return false
+	}
+	w.segments[i].Text = text
+	return true
+}
+
+// PostText sets the text of the named segment on the widget rendering goroutine, and
+// can be called from any goroutine.
+func (w *Widget) PostText(name string, text string, app gowid.IApp) {
+	app.Run(gowid.RunFunction(func(app gowid.IApp) {
+		w.SetText(name, text, app)
+	}))
+}
+
+// BindText keeps the text of the named segment in step with the property. Changes made
+// from other goroutines are applied on the widget rendering goroutine.
+func (w *Widget) BindText(name string, app gowid.IApp, p *gowid.Property[string]) *gowid.Binding {
+	return gowid.Bind(app, p, func(app gowid.IApp, text string) {
+		w.SetText(name, text, app)
+	})
+}
+
+// layout returns the width each segment is shown with when the bar is cols wide, or
+// -1 for segments that are dropped.
+func (w *Widget) layout(cols int) []int {
+	res := make([]int, len(w.segments))
+	for i, s := range w.segments {
+		res[i] = -1
+		if s.Text != "" {
+			res[i] = runewidth.StringWidth(s.Text)
+		}
+	}
+	for {
+		excess := w.width(res) - cols
+		if excess <= 0 {
+			break
+		}
+		j := -1
+		for i, s := range w.segments {
+			if res[i] != -1 && (j == -1 || s.Priority <= w.segments[j].Priority) {
+				j = i
+			}
+		}
+		if j == -1 {
+			break
+		}
+		if m := w.segments[j].MinWidth; m > 0 && res[j] > m {
+			res[j] = gwutil.Max(m, res[j]-excess)
+		} else {
+			res[j] = -1
+		}
+	}
+	return res
+}
+
+// sideWidth returns the number of columns taken by the segments shown on one side.
+func (w *Widget) sideWidth(side Side, widths []int) int {
+	res, n := 0, 0
+	for i, s := range w.segments {
+		if s.Side == side && widths[i] != -1 {
+			res += widths[i]
+			n++
+		}
+	}
+	if n > 1 {
+		res += (n - 1) * runewidth.StringWidth(w.opts.Separator)
+	}
+	return res
+}
+
+// width returns the number of columns needed for the segments shown, including a
+// column between the sides.
+func (w *Widget) width(widths []int) int {
+	res, n := 0, 0
+	for _, side := range []Side{Left, Center, Right} {
+		if sw := w.sideWidth(side, widths); sw > 0 {
+			res += sw
+			n++
+		}
+	}
+	if n > 1 {
+		res += n - 1
+	}
+	return res
+}
+
+// content returns the text of the bar when it is cols wide.
+func (w *Widget) content(cols int) *text.Content {
+	widths := w.layout(cols)
+	lw := w.sideWidth(Left, widths)
+	cw := w.sideWidth(Center, widths)
+	rw := w.sideWidth(Right, widths)
+
+	// Keep the center segments centered unless they would touch the other sides
+	cx := (cols - cw) / 2
+	cx = gwutil.Min(cx, cols-rw-gwutil.If(rw > 0, 1, 0).(int)-cw)
+	cx = gwutil.Max(cx, lw+gwutil.If(lw > 0, 1, 0).(int))
+
+	segs := make([]text.ContentSegment, 0, len(w.segments)*2+3)
+	pos := 0
+	for _, side := range []struct {
+		side Side
+		x    int
+	}{{Left, 0}, {Center, cx}, {Right, cols - rw}} {
+		if w.sideWidth(side.side, widths) == 0 {
+			continue
+		}
+		segs = append(segs, text.StringContent(strings.Repeat(" ", gwutil.Max(0, side.x-pos))))
+		pos = gwutil.Max(pos, side.x)
+		first := true
+		for i, s := range w.segments {
+			if s.Side != side.side || widths[i] == -1 {
+				continue
+			}
+			if !first {
+				segs = append(segs, text.StringContent(w.opts.Separator))
+				pos += runewidth.StringWidth(w.opts.Separator)
+			}
+			first = false
+			t := s.Text
+			if widths[i] < runewidth.StringWidth(t) {
+				t = runewidth.Truncate(t, widths[i], "…")
+			}
+			t += strings.Repeat(" ", gwutil.Max(0, widths[i]-runewidth.StringWidth(t)))
+			if s.Style != nil {
+				segs = append(segs, text.StyledContent(t, s.Style))
+			} else {
+				segs = append(segs, text.StringContent(t))
+			}
+			pos += widths[i]
+		}
+	}
+	segs = append(segs, text.StringContent(strings.Repeat(" ", gwutil.Max(0, cols-pos))))
+	return text.NewContent(segs)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	if cols, ok := size.(gowid.IColumns); ok {
+		return gowid.RenderBox{C: cols.Columns(), R: 1}
+	}
+	return gowid.RenderBox{C: w.naturalWidth(), R: 1}
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+// naturalWidth returns the width needed to show every segment in full.
+func (w *Widget) naturalWidth() int {
+	return w.width(w.layout(1 << 30))
+}
+
+//======================================================================
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	cols := w.naturalWidth()
+	if c, ok := size.(gowid.IColumns); ok {
+		cols = c.Columns()
+	}
+	txt := text.NewFromContentExt(w.content(cols), text.Options{Wrap: text.WrapClip})
+	res := styled.New(txt, w.opts.Style).Render(gowid.RenderFlowWith{C: cols}, gowid.NotSelected, app)
+	if box, ok := size.(gowid.IRenderBox); ok {
+		gowid.MakeCanvasRightSize(res, box)
+	}
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,60 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package statusbar
+
+import (
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func TestStatusbar1(t *testing.T) {
+	w := New([]Segment{
+		{Name: "mode", Text: "mode", Priority: 10},
+		{Name: "file", Text: "file.go", Priority: 5, MinWidth: 4},
+		{Name: "clock", Text: "12:00", Side: Center, Priority: 1},
+		{Name: "pos", Text: "Ln 3, Col 4", Side: Right, Priority: 8},
+	})
+
+	render := func(cols int) string {
+		c := w.Render(gowid.RenderFlowWith{C: cols}, gowid.NotSelected, gwtest.D)
+		assert.Equal(t, 1, c.BoxRows())
+		return c.String()
+	}
+
+	assert.Equal(t, "mode │ file.go   12:00       Ln 3, Col 4", render(40))
+	// The center segment has the lowest priority, and no minimum width
+	assert.Equal(t, "mode │ file.go     Ln 3, Col 4", render(30))
+	assert.Equal(t, "mode │ file… Ln 3, Col 4", render(24))
+	assert.Equal(t, "mode     Ln 3, Col 4", render(20))
+	assert.Equal(t, "mode      ", render(10))
+
+	c := w.Render(gowid.RenderFixed{}, gowid.NotSelected, gwtest.D)
+	assert.Equal(t, "mode │ file.go 12:00 Ln 3, Col 4", c.String())
+
+	assert.True(t, w.SetText("file", "", gwtest.D))
+	assert.False(t, w.SetText("missing", "x", gwtest.D))
+	assert.Equal(t, "", w.Text("missing"))
+	assert.Equal(t, "mode    12:00 Ln 3, Col 4", render(25))
+}
+
+func TestBindText1(t *testing.T) {
+	w := New([]Segment{{Name: "msg", Text: "ready"}})
+	p := gowid.NewProperty("busy")
+	b := w.BindText("msg", gwtest.D, p)
+	assert.Equal(t, "busy", w.Text("msg"))
+	b.Unbind()
+	p.Set("done")
+	assert.Equal(t, "busy", w.Text("msg"))
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -110,7 +110,7 @@ func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.S
 }
 
 func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
-	canvas := w.SubWidget().Render(size, focus, app)
+	canvas := gowid.RenderChild(w.SubWidget(), size, focus, app)
 
 	cols := canvas.BoxColumns()
 
@@ -146,11 +146,11 @@ func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.
 					c2 := c
 
 					if f != nil {
-						f1 = gowid.IColorToTCell(f, gowid.ColorNone, app.GetColorMode())
+						f1 = gowid.ColorToTCellIn(f, gowid.ColorNone, app)
 						c = c.WithForegroundColor(f1)
 					}
 					if b != nil {
-						b1 = gowid.IColorToTCell(b, gowid.ColorNone, app.GetColorMode())
+						b1 = gowid.ColorToTCellIn(b, gowid.ColorNone, app)
 						c = c.WithBackgroundColor(b1)
 					}
 
@@ -0,0 +1,395 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package table
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/araddon/dateparse"
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/isselected"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+// ColumnType is the type of data inferred for a column of a CsvDataModel.
+type ColumnType int
+
+const (
+	StringColumn ColumnType = iota
+	IntColumn
+	FloatColumn
+	DateTimeColumn
+)
+
+func (t ColumnType) String() string {
+	switch t {
+	case IntColumn:
+		return "int"
+	case FloatColumn:
+		return "float"
+	case DateTimeColumn:
+		return "datetime"
+	default:
+		return "string"
+	}
+}
+
+// Comparator returns an ICompare suitable for sorting a column of this type.
+func (t ColumnType) Comparator() ICompare {
+	switch t {
+	case IntColumn:
+		return IntCompare{}
+	case FloatColumn:
+		return FloatCompare{}
+	case DateTimeColumn:
+		return DateTimeCompare{}
+	default:
+		return StringCompare{}
+	}
+}
+
+// typeOf returns the narrowest type that can hold s. The empty string fits any type.
+func typeOf(s string) ColumnType {
+	s = strings.TrimSpace(s)
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return IntColumn
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return FloatColumn
+	}
+	if _, err := dateparse.ParseAny(s); err == nil {
+		return DateTimeColumn
+	}
+	return StringColumn
+}
+
+// widen returns the type able to hold values of both a and b.
+func widen(a, b ColumnType) ColumnType {
+	switch {
+	case a == b:
+		return a
+	case (a == IntColumn && b == FloatColumn) || (a == FloatColumn && b == IntColumn):
+		return FloatColumn
+	default:
+		return StringColumn
+	}
+}
+
+//======================================================================
+
+// CsvOptions configures a CsvDataModel. The zero value reads comma-separated data
+// with no header line, samples 100 rows and caps column widths at 30.
+type CsvOptions struct {
+	Comma      rune          // field separator; ',' if zero
+	Headers    bool          // the first line holds the column headers
+	SampleRows int           // rows read up front to infer types and widths; 100 if zero
+	MaxWidth   int           // cap on an auto-sized column's width; 30 if zero
+	Style      *StyleOptions // separators and styles; the SimpleModel defaults if nil
+}
+
+// CsvDataModel is a table model backed by CSV or TSV data read from an io.Reader. Only
+// enough rows to infer each column's type and width are read up front; the rest are
+// read as the table asks for them, so large files can be displayed immediately. The
+// model does not know its row count until the input is exhausted, so it implements
+// IModel but not IBoundedModel.
+type CsvDataModel struct {
+	reader  *csv.Reader
+	headers []string
+	data    [][]string
+	types   []ColumnType
+	widths  []gowid.IWidgetDimension
+	style   StyleOptions
+	eof     bool
+	err     error
+}
+
+var _ IModel = (*CsvDataModel)(nil)
+var _ IInvertible = (*CsvDataModel)(nil)
+var _ ISimpleRowProvider = (*CsvDataModel)(nil)
+
+// NewCsvDataModel reads the header line, if requested, and a sample of rows from r,
+// then infers the type of each column and sizes it to fit the sampled data, up to
+// the configured maximum. An error is returned only if the sample can't be read;
+// errors encountered later stop loading and are available from Err().
+func NewCsvDataModel(r io.Reader, opts ...CsvOptions) (*CsvDataModel, error) {
+	var opt CsvOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Comma == 0 {
+		opt.Comma = ','
+	}
+	if opt.SampleRows <= 0 {
+		opt.SampleRows = 100
+	}
+	if opt.MaxWidth <= 0 {
+		opt.MaxWidth = 30
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = opt.Comma
+	reader.FieldsPerRecord = -1
+	if opt.Comma == '\t' {
+		reader.LazyQuotes = true
+	}
+
+	res := &CsvDataModel{
+		reader: reader,
+	}
+	if opt.Style != nil {
+		res.style = *opt.Style
+	} else {
+		res.style = defaultOptions().Style
+	}
+
+	if opt.Headers {
+		line, err := reader.Read()
+		if err == io.EOF {
+			res.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+		res.headers = line
+	}
+
+	res.load(opt.SampleRows)
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	cols := len(res.headers)
+	for _, row := range res.data {
+		cols = gwutil.Max(cols, len(row))
+	}
+
+	res.types = make([]ColumnType, cols)
+	widths := make([]int, cols)
+	seen := make([]bool, cols)
+	for i, h := range res.headers {
+		widths[i] = runewidth.StringWidth(h)
+	}
+	for _, row := range res.data {
+		for i, s := range row {
+			widths[i] = gwutil.Max(widths[i], runewidth.StringWidth(s))
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			if !seen[i] {
+				res.types[i] = typeOf(s)
+				seen[i] = true
+			} else if res.types[i] != StringColumn {
+				res.types[i] = widen(res.types[i], typeOf(s))
+			}
+		}
+	}
+
+	res.widths = make([]gowid.IWidgetDimension, cols)
+	for i, w := range widths {
+		res.widths[i] = gowid.RenderWithUnits{U: gwutil.Min(gwutil.Max(w, 1), opt.MaxWidth)}
+	}
+
+	return res, nil
+}
+
+// NewTsvDataModel is like NewCsvDataModel, but reads tab-separated data.
+func NewTsvDataModel(r io.Reader, opts ...CsvOptions) (*CsvDataModel, error) {
+	var opt CsvOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt.Comma = '\t'
+	return NewCsvDataModel(r, opt)
+}
+
+// load reads from the input until at least n rows are held, or the input is exhausted.
+func (c *CsvDataModel) load(n int) {
+	for !c.eof && len(c.data) < n {
+		line, err := c.reader.Read()
+		if err == io.EOF {
+			c.eof = true
+		} else if err != nil {
+			c.err = err
+			c.eof = true
+		} else {
+			c.data = append(c.data, line)
+		}
+	}
+}
+
+// LoadAll reads the rest of the input.
+func (c *CsvDataModel) LoadAll() error {
+	for !c.eof {
+		c.load(len(c.data) + 1)
+	}
+	return c.err
+}
+
+// Loaded returns the number of rows read so far.
+func (c *CsvDataModel) Loaded() int {
+	return len(c.data)
+}
+
+// Complete returns true once the whole input has been read.
+func (c *CsvDataModel) Complete() bool {
+	return c.eof
+}
+
+// Err returns the error, if any, that stopped rows from being loaded.
+func (c *CsvDataModel) Err() error {
+	return c.err
+}
+
+// Headers returns the header line, or nil if the data has none.
+func (c *CsvDataModel) Headers() []string {
+	return c.headers
+}
+
+// Row returns the fields of the row at index i, loading it if necessary.
+func (c *CsvDataModel) Row(i int) ([]string, bool) {
+	if i < 0 {
+		return nil, false
+	}
+	c.load(i + 1)
+	if i >= len(c.data) {
+		return nil, false
+	}
+	return c.data[i], true
+}
+
+// ColumnTypes returns the type inferred for each column from the sampled rows.
+func (c *CsvDataModel) ColumnTypes() []ColumnType {
+	return c.types
+}
+
+// Comparators returns a comparator for each column, suited to its inferred type.
+func (c *CsvDataModel) Comparators() []ICompare {
+	res := make([]ICompare, len(c.types))
+	for i, t := range c.types {
+		res[i] = t.Comparator()
+	}
+	return res
+}
+
+func (c *CsvDataModel) GetStyle() StyleOptions {
+	return c.style
+}
+
+func (c *CsvDataModel) Columns() int {
+	return len(c.types)
+}
+
+func (c *CsvDataModel) RowIdentifier(row int) (RowId, bool) {
+	if _, ok := c.Row(row); !ok {
+		return RowId(-1), false
+	}
+	return RowId(row), true
+}
+
+func (c *CsvDataModel) IdentifierToRow(rowid RowId) (int, bool) {
+	if rowid < 0 || int(rowid) >= len(c.data) {
+		return -1, false
+	}
+	return int(rowid), true
+}
+
+// clip shortens s to fit column i, marking it with an ellipsis if it doesn't.
+func (c *CsvDataModel) clip(i int, s string) string {
+	if i >= len(c.widths) {
+		return s
+	}
+	return runewidth.Truncate(s, c.widths[i].(gowid.RenderWithUnits).U, "…")
+}
+
+// CellWidget returns the widget for field s of column i. Numeric columns are
+// right-aligned, and data too wide for its column is clipped.
+func (c *CsvDataModel) CellWidget(i int, s string) gowid.IWidget {
+	opt := text.Options{
+		Wrap: text.WrapClip,
+	}
+	if i < len(c.types) && (c.types[i] == IntColumn || c.types[i] == FloatColumn) {
+		opt.Align = gowid.HAlignRight{}
+	}
+	b := button.NewBare(text.New(c.clip(i, s), opt))
+	if c.style.CellStyleProvided {
+		return isselected.New(b, styled.New(b, c.style.CellStyleSelected), styled.New(b, c.style.CellStyleFocus))
+	}
+	return styled.NewExt(b, nil, gowid.MakeStyledAs(gowid.StyleReverse))
+}
+
+func (c *CsvDataModel) CellWidgets(rowid RowId) []gowid.IWidget {
+	row, ok := c.Row(int(rowid))
+	if !ok {
+		return nil
+	}
+	res := make([]gowid.IWidget, len(c.types))
+	for i := range res {
+		s := ""
+		if i < len(row) {
+			s = row[i]
+		}
+		res[i] = c.CellWidget(i, s)
+	}
+	return res
+}
+
+func (c *CsvDataModel) HeaderWidgets() []gowid.IWidget {
+	if len(c.headers) == 0 {
+		return nil
+	}
+	res := make([]gowid.IWidget, len(c.types))
+	for i := range res {
+		s := ""
+		if i < len(c.headers) {
+			s = c.headers[i]
+		}
+		var w gowid.IWidget = text.New(c.clip(i, s), text.Options{
+			Wrap: text.WrapClip,
+		})
+		if c.style.HeaderStyleProvided {
+			w = isselected.New(
+				styled.New(w, c.style.HeaderStyleNoFocus),
+				styled.New(w, c.style.HeaderStyleSelected),
+				styled.New(w, c.style.HeaderStyleFocus),
+			)
+		} else {
+			w = styled.NewExt(w, nil, gowid.MakeStyledAs(gowid.StyleReverse))
+		}
+		res[i] = w
+	}
+	return res
+}
+
+func (c *CsvDataModel) VerticalSeparator() gowid.IWidget {
+	return c.style.VerticalSeparator
+}
+
+func (c *CsvDataModel) HorizontalSeparator() gowid.IWidget {
+	return c.style.HorizontalSeparator
+}
+
+func (c *CsvDataModel) HeaderSeparator() gowid.IWidget {
+	return c.style.TableSeparator
+}
+
+// Widths returns each column's width, sized to fit the header and sampled rows, capped
+// at the configured maximum.
+func (c *CsvDataModel) Widths() []gowid.IWidgetDimension {
+	return c.widths
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -21,7 +21,6 @@ import (
 	"github.com/gcla/gowid/widgets/radio"
 	"github.com/gcla/gowid/widgets/styled"
 	"github.com/gcla/gowid/widgets/text"
-	log "github.com/sirupsen/logrus"
 )
 
 //======================================================================
@@ -78,7 +77,9 @@ func defaultOptions() SimpleOptions {
 }
 
 // NewCsvModel returns a SimpleTable built from CSV data in the supplied reader. SimpleTable
-// implements IModel, and so can be used as a source for table.IWidget.
+// implements IModel, and so can be used as a source for table.IWidget. If the data can't
+// be read, the error is logged with gowid.DefaultLogger and the table holds the rows read
+// before it.
 func NewCsvModel(csvFile io.Reader, firstLineIsHeaders bool, opts ...SimpleOptions) *SimpleModel {
 	haveHeaders := false
 	reader := csv.NewReader(csvFile)
@@ -90,7 +91,8 @@ func NewCsvModel(csvFile io.Reader, firstLineIsHeaders bool, opts ...SimpleOptio
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			log.Fatal(err)
+			gowid.DefaultLogger.Log(gowid.LogError, "Could not read CSV data", gowid.LogField{"error", err})
+			break
 		}
 		if firstLineIsHeaders && !haveHeaders {
 			headers = line
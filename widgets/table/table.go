@@ -54,6 +54,18 @@ type IMakeHeader interface {
 	HeaderWidget([]gowid.IWidget, int) gowid.IWidget
 }
 
+// IShownColumns is optionally implemented by an IRowToWidget that displays only some
+// of the table's columns - e.g. because the table has scrolled horizontally. It returns
+// the indices of the columns to display, in order, for a row of n cells.
+type IShownColumns interface {
+	ShownColumns(n int) []int
+}
+
+// CellActivatedCB is the name under which callbacks are registered that run when
+// enter is pressed on a cell. The callback's extra argument is the Coords of the cell,
+// with Row counting data rows only - the header isn't included.
+type CellActivatedCB struct{}
+
 // IBoundedTable implements ITable and can also provide the total number of
 // rows in the table.
 type IBoundedModel interface {
@@ -217,6 +229,8 @@ type Widget struct {
 	flowVertDivider  *gowid.ContainerWidget
 	flowTableDivider *gowid.ContainerWidget
 	opt              Options
+	offset           int // How many columns after the frozen ones have scrolled off to the left
+	width            int // Columns the table was last rendered with, or 0 if not known
 	*gowid.Callbacks
 	gowid.FocusCallbacks
 	gowid.IsSelectable
@@ -232,8 +246,14 @@ var _ list.IBoundedWalker = (*BoundedWidget)(nil)
 var _ list.IWalkerHome = (*BoundedWidget)(nil)
 var _ list.IWalkerEnd = (*BoundedWidget)(nil)
 
+// Options can be supplied to New. The first FrozenColumns columns stay in place when
+// the table is too narrow to show every column and the others scroll horizontally,
+// one column at a time, as the focus moves left and right. Horizontal scrolling needs
+// the width of each column to be given in units - see IModel.Widths; otherwise all the
+// columns are displayed, sharing the space available.
 type Options struct {
-	CacheSize int
+	CacheSize     int
+	FrozenColumns int
 }
 
 func New(model IModel, opts ...Options) *Widget {
@@ -332,11 +352,16 @@ func (w *Widget) update(listw *ListWithPreferedColumn, row int, model IModel, op
 	if flowTableDivider != nil {
 		pileWidgets = append(pileWidgets, flowTableDivider)
 	}
+	w.model = model
+	w.opt = opt
+	w.flowVertDivider = flowVertDivider
+
 	hws := model.HeaderWidgets() // widgets
 	var hw *columns.Widget
 	if hws != nil && len(hws) > 0 {
 		var hw2 gowid.IWidget
-		if nm, ok := model.(IMakeHeader); ok {
+		shown := w.ShownColumns(len(hws))
+		if nm, ok := model.(IMakeHeader); ok && len(shown) == len(hws) {
 			hw2 = nm.HeaderWidget(hws, hf)
 		} else {
 			cws := make([]gowid.IContainerWidget, 0)
@@ -344,7 +369,8 @@ func (w *Widget) update(listw *ListWithPreferedColumn, row int, model IModel, op
 				cws = append(cws, flowVertDivider)
 			}
 
-			for i, w := range hws {
+			for _, i := range shown {
+				w := hws[i]
 				var dim gowid.IWidgetDimension = gowid.RenderWithWeight{1}
 				if model.Widths() != nil && i < len(model.Widths()) {
 					dim = model.Widths()[i]
@@ -516,6 +542,159 @@ func (w *Widget) String() string {
 	return fmt.Sprintf("table")
 }
 
+// OnCellActivated registers a callback to run when enter is pressed on a data cell.
+// While any are registered, the table handles enter itself, so the cell widgets don't
+// see it.
+func (w *Widget) OnCellActivated(f gowid.IWidgetChangedCallback) {
+	if w.Callbacks == nil {
+		w.Callbacks = gowid.NewCallbacks()
+	}
+	gowid.AddWidgetCallback(w.Callbacks, CellActivatedCB{}, f)
+}
+
+func (w *Widget) RemoveOnCellActivated(f gowid.IIdentity) {
+	if w.Callbacks != nil {
+		gowid.RemoveWidgetCallback(w.Callbacks, CellActivatedCB{}, f)
+	}
+}
+
+func allColumns(n int) []int {
+	res := make([]int, n)
+	for i := 0; i < n; i++ {
+		res[i] = i
+	}
+	return res
+}
+
+// columnCount returns the number of columns in the model, taking care not to ask a
+// model with no rows.
+func (w *Widget) columnCount() int {
+	if bm, ok := w.model.(IBoundedModel); ok && bm.Rows() == 0 {
+		return len(w.model.HeaderWidgets())
+	}
+	return w.model.Columns()
+}
+
+// columnWidth returns the width of column i, and false if the model doesn't give it in
+// units.
+func (w *Widget) columnWidth(i int) (int, bool) {
+	widths := w.model.Widths()
+	if i >= len(widths) {
+		return 0, false
+	}
+	if u, ok := widths[i].(gowid.IRenderWithUnits); ok {
+		return u.Units(), true
+	}
+	return 0, false
+}
+
+// ShownColumns returns the indices of the columns displayed, out of n - the frozen
+// columns, then as many of the rest, from the scroll offset, as fit in the width the
+// table was last rendered with.
+func (w *Widget) ShownColumns(n int) []int {
+	frozen := gwutil.Min(gwutil.Max(w.opt.FrozenColumns, 0), n)
+	start := gwutil.Min(frozen+w.offset, n)
+	res := allColumns(frozen)
+	div := 0
+	if w.flowVertDivider != nil {
+		div = 1
+	}
+	used := div
+	for i := 0; i < frozen; i++ {
+		cw, _ := w.columnWidth(i)
+		used += cw + div
+	}
+	for i := start; i < n; i++ {
+		cw, ok := w.columnWidth(i)
+		if ok && w.width > 0 && i > start && used+cw+div > w.width {
+			break
+		}
+		used += cw + div
+		res = append(res, i)
+	}
+	return res
+}
+
+// scrolls returns true if the columns after the frozen ones can scroll horizontally.
+func (w *Widget) scrolls() bool {
+	for i := 0; i < w.columnCount(); i++ {
+		if _, ok := w.columnWidth(i); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// relayout rebuilds the table's rows and header after the columns shown have changed.
+func (w *Widget) relayout() {
+	w.cache.Purge()
+	w.update(w.listw, w.cur, w.model, w.opt)
+}
+
+// setWidth records the width the table is rendered with, and if that changes the
+// columns shown, rebuilds the table - keeping the focus cell on the screen.
+func (w *Widget) setWidth(size gowid.IRenderSize, app gowid.IApp) {
+	cols, ok := size.(gowid.IColumns)
+	if !ok || cols.Columns() == w.width || !w.scrolls() {
+		return
+	}
+	n := w.columnCount()
+	before := w.ShownColumns(n)
+	w.width = cols.Columns()
+	if after := w.ShownColumns(n); !intsEqual(before, after) {
+		xy, err := w.FocusXY()
+		w.relayout()
+		if err == nil {
+			w.SetFocusXY(app, xy)
+		}
+	}
+}
+
+func intsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// scrollTo changes the scroll offset, if needed, so that column col is shown, and
+// returns the index at which it is shown.
+func (w *Widget) scrollTo(col int) int {
+	n := w.columnCount()
+	shown := w.ShownColumns(n)
+	for i, c := range shown {
+		if c == col {
+			return i
+		}
+	}
+	frozen := gwutil.Min(gwutil.Max(w.opt.FrozenColumns, 0), n)
+	if col < frozen || col >= n {
+		return -1
+	}
+	if len(shown) == frozen || col < shown[frozen] {
+		w.offset = col - frozen
+	} else {
+		for w.offset < col-frozen {
+			w.offset++
+			if shown = w.ShownColumns(n); shown[len(shown)-1] >= col {
+				break
+			}
+		}
+	}
+	w.relayout()
+	for i, c := range w.ShownColumns(n) {
+		if c == col {
+			return i
+		}
+	}
+	return -1
+}
+
 func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
 	return gowid.CalculateRenderSizeFallback(w, size, focus, app)
 }
@@ -532,7 +711,35 @@ func (w *Widget) SetFocusOnData(app gowid.IApp) bool {
 }
 
 func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	w.setWidth(size, app)
 	oldpos, olderr := w.FocusXY()
+	if evk, ok := ev.(*tcell.EventKey); ok && olderr == nil {
+		switch evk.Key() {
+		case tcell.KeyEnter:
+			if w.Callbacks != nil && w.HaveCallbacks(CellActivatedCB{}) && (w.header == nil || oldpos.Row > 0) {
+				cell := oldpos
+				if w.header != nil {
+					cell.Row--
+				}
+				gowid.RunWidgetCallbacks(w.Callbacks, CellActivatedCB{}, app, w, cell)
+				return true
+			}
+		case tcell.KeyLeft, tcell.KeyRight:
+			// Scroll one column at a time when the focus is at either edge
+			if w.scrolls() {
+				shown := w.ShownColumns(w.columnCount())
+				frozen := gwutil.Min(gwutil.Max(w.opt.FrozenColumns, 0), len(shown))
+				if evk.Key() == tcell.KeyRight && oldpos.Column == shown[len(shown)-1] && oldpos.Column < w.columnCount()-1 {
+					w.SetFocusXY(app, Coords{Column: oldpos.Column + 1, Row: oldpos.Row})
+					return true
+				}
+				if evk.Key() == tcell.KeyLeft && w.offset > 0 && frozen < len(shown) && oldpos.Column == shown[frozen] {
+					w.SetFocusXY(app, Coords{Column: oldpos.Column - 1, Row: oldpos.Row})
+					return true
+				}
+			}
+		}
+	}
 	res := w.wrapper.UserInput(ev, size, focus, app)
 	newpos, newerr := w.FocusXY()
 	if olderr != newerr || oldpos != newpos {
@@ -542,6 +749,7 @@ func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.S
 }
 
 func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	w.setWidth(size, app)
 	return w.wrapper.Render(size, focus, app)
 }
 
@@ -619,7 +827,12 @@ func RowToWidget(t IRowToWidget, ws []gowid.IWidget) gowid.IWidget {
 		if t.VertDivider() != nil {
 			cws = append(cws, t.VertDivider())
 		}
-		for i, w := range ws {
+		shown := allColumns(len(ws))
+		if sc, ok := t.(IShownColumns); ok {
+			shown = sc.ShownColumns(len(ws))
+		}
+		for _, i := range shown {
+			w := ws[i]
 			var dim gowid.IWidgetDimension = gowid.RenderWithWeight{1}
 			if t.Model().Widths() != nil && i < len(t.Model().Widths()) {
 				dim = t.Model().Widths()[i]
@@ -914,7 +1127,7 @@ func (t *Widget) FocusXY() (Coords, error) {
 			if t.VertDivider() != nil {
 				col = (col - 1) / 2
 			}
-			return Coords{Column: col, Row: row}, nil
+			return Coords{Column: t.logicalColumn(col), Row: row}, nil
 		} else {
 			addOne = true
 		}
@@ -941,7 +1154,26 @@ func (t *Widget) FocusXY() (Coords, error) {
 	if addOne {
 		row++
 	}
-	return Coords{Column: col, Row: row}, nil
+	return Coords{Column: t.logicalColumn(col), Row: row}, nil
+}
+
+// HeaderInCoords returns true if row 0 of the coordinates used by FocusXY and SetFocusXY
+// is the table's header. That's the case unless the model has no header, or builds
+// its header widget itself via IMakeHeader.
+func (t *Widget) HeaderInCoords() bool {
+	return t.header != nil
+}
+
+// logicalColumn returns the table column displayed at index i in a row.
+func (t *Widget) logicalColumn(i int) int {
+	if t.offset == 0 {
+		return i // The columns shown are a prefix of the table's columns
+	}
+	shown := t.ShownColumns(t.columnCount())
+	if i >= 0 && i < len(shown) {
+		return shown[i]
+	}
+	return i
 }
 
 func (t *Widget) SetFocusXY(app gowid.IApp, xy Coords) {
@@ -953,6 +1185,13 @@ func (t *Widget) SetFocusXY(app gowid.IApp, xy Coords) {
 		}
 	}()
 
+	// Scroll horizontally if the column isn't shown
+	if t.width != 0 || t.offset != 0 {
+		if i := t.scrollTo(xy.Column); i != -1 {
+			xy.Column = i
+		}
+	}
+
 	if t.header != nil {
 		if xy.Row == 0 {
 			if t.TableDivider() != nil {
@@ -5,6 +5,8 @@
 package table
 
 import (
+	"fmt"
+	"io"
 	"sort"
 	"strings"
 	"testing"
@@ -442,6 +444,180 @@ bbbbbbbbbbb,2
 
 }
 
+type wideTable struct {
+	MyTable
+}
+
+func (t wideTable) Columns() int {
+	return 5
+}
+
+func TestFrozenColumns1(t *testing.T) {
+	rows := make([][]gowid.IWidget, 2)
+	for r := range rows {
+		for c := 0; c < 5; c++ {
+			rows[r] = append(rows[r], makew(fmt.Sprintf("%c%d", 'a'+c, r)))
+		}
+	}
+	wid := make([]gowid.IWidgetDimension, 5)
+	for i := range wid {
+		wid[i] = gowid.RenderWithUnits{U: 4}
+	}
+	w := New(wideTable{MyTable{rows: rows, wid: wid}}, Options{FrozenColumns: 1})
+	sz := gowid.RenderFlowWith{C: 10}
+	render := func() string {
+		return w.Render(sz, gowid.Focused, gwtest.D).String()
+	}
+	key := func(k tcell.Key) bool {
+		return w.UserInput(tcell.NewEventKey(k, 0, tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	}
+	focus := func() Coords {
+		xy, err := w.FocusXY()
+		assert.NoError(t, err)
+		return xy
+	}
+
+	assert.Equal(t, "a0  b0    \na1  b1    ", render())
+
+	assert.True(t, key(tcell.KeyRight))
+	assert.Equal(t, Coords{1, 0}, focus())
+	assert.True(t, key(tcell.KeyRight))
+	assert.Equal(t, Coords{2, 0}, focus())
+	assert.Equal(t, "a0  c0    \na1  c1    ", render())
+	assert.True(t, key(tcell.KeyRight))
+	assert.True(t, key(tcell.KeyRight))
+	assert.Equal(t, Coords{4, 0}, focus())
+	assert.False(t, key(tcell.KeyRight))
+	assert.True(t, key(tcell.KeyDown))
+	assert.Equal(t, Coords{4, 1}, focus())
+	assert.Equal(t, "a0  e0    \na1  e1    ", render())
+
+	assert.True(t, key(tcell.KeyLeft))
+	assert.Equal(t, Coords{3, 1}, focus())
+	assert.Equal(t, "a0  d0    \na1  d1    ", render())
+
+	w.SetFocusXY(gwtest.D, Coords{1, 0})
+	assert.Equal(t, Coords{1, 0}, focus())
+	assert.Equal(t, "a0  b0    \na1  b1    ", render())
+	assert.True(t, key(tcell.KeyLeft))
+	assert.Equal(t, Coords{0, 0}, focus())
+
+	// A wider table shows more columns
+	sz = gowid.RenderFlowWith{C: 14}
+	assert.Equal(t, "a0  b0  c0    \na1  b1  c1    ", render())
+
+	var activated []Coords
+	w.OnCellActivated(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		activated = append(activated, data[0].(Coords))
+	}})
+	w.SetFocusXY(gwtest.D, Coords{4, 1})
+	assert.Equal(t, "a0  d0  e0    \na1  d1  e1    ", render())
+	assert.True(t, key(tcell.KeyEnter))
+	assert.Equal(t, []Coords{{4, 1}}, activated)
+}
+
+type countingReader struct {
+	r     io.Reader
+	reads int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.reads++
+	if len(p) > 8 {
+		p = p[:8]
+	}
+	return c.r.Read(p)
+}
+
+func TestCsvDataModel1(t *testing.T) {
+	data := strings.TrimSuffix(`
+name,count,price,when
+apple,3,1.5,2020-01-02
+pear,12,2,2021-03-04
+a very long fruit name,7,0.25,2022-05-06
+`[1:], "\n")
+
+	m, err := NewCsvDataModel(strings.NewReader(data), CsvOptions{
+		Headers:  true,
+		MaxWidth: 8,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []ColumnType{StringColumn, IntColumn, FloatColumn, DateTimeColumn}, m.ColumnTypes())
+	assert.Equal(t, []gowid.IWidgetDimension{
+		gowid.RenderWithUnits{U: 8},
+		gowid.RenderWithUnits{U: 5},
+		gowid.RenderWithUnits{U: 5},
+		gowid.RenderWithUnits{U: 8},
+	}, m.Widths())
+	assert.Equal(t, IntCompare{}, m.Comparators()[1])
+
+	w := New(m)
+	c := w.Render(gowid.RenderFlowWith{C: 31}, gowid.Focused, gwtest.D)
+	assert.Equal(t, strings.TrimSuffix(`
+-------------------------------
+|name    |count|price|when    |
+-------------------------------
+|apple   |    3|  1.5|2020-01…|
+-------------------------------
+|pear    |   12|    2|2021-03…|
+-------------------------------
+|a very …|    7| 0.25|2022-05…|
+-------------------------------
+`[1:], "\n"), c.String())
+
+	tsv := "a\t1\nb\t2\nc\tx\n"
+	m, err = NewTsvDataModel(strings.NewReader(tsv))
+	assert.NoError(t, err)
+	assert.Nil(t, m.HeaderWidgets())
+	assert.Equal(t, []ColumnType{StringColumn, StringColumn}, m.ColumnTypes())
+
+	var sb strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, "%d,row%d\n", i, i)
+	}
+	cr := &countingReader{r: strings.NewReader(sb.String())}
+	m, err = NewCsvDataModel(cr, CsvOptions{SampleRows: 10})
+	assert.NoError(t, err)
+	assert.False(t, m.Complete())
+	assert.Less(t, m.Loaded(), 1000)
+
+	w = New(m)
+	w.Render(gowid.RenderBox{C: 20, R: 5}, gowid.Focused, gwtest.D)
+	assert.False(t, m.Complete())
+
+	row, ok := m.Row(500)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"500", "row500"}, row)
+	_, ok = m.RowIdentifier(1000)
+	assert.False(t, ok)
+	assert.True(t, m.Complete())
+	assert.NoError(t, m.LoadAll())
+	assert.Equal(t, 1000, m.Loaded())
+}
+
+// recordingLogger keeps the fields of each message logged.
+type recordingLogger struct {
+	fields *[]gowid.LogField
+}
+
+func (l *recordingLogger) Log(level gowid.LogLevel, msg string, fields ...gowid.LogField) {
+	*l.fields = append(*l.fields, fields...)
+}
+
+func TestCsvError1(t *testing.T) {
+	var logged []gowid.LogField
+	defer func(l gowid.ILogger) {
+		gowid.DefaultLogger = l
+	}(gowid.DefaultLogger)
+	gowid.DefaultLogger = &recordingLogger{&logged}
+
+	// The third line has too few fields - the rows before it are kept
+	t1 := NewCsvModel(strings.NewReader("a,b\nc,d\ne\nf,g\n"), false)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}}, t1.Data)
+	assert.Equal(t, 1, len(logged))
+	assert.Equal(t, "error", logged[0].Name)
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
@@ -0,0 +1,215 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package terminal
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// DefaultURLPattern finds URLs in the terminal's text if Options.URLPattern is nil.
+var DefaultURLPattern = regexp.MustCompile(`\b(?:https?|ftp|file)://[^\s<>"'` + "`" + `]+`)
+
+// LinkClicked is the callback type for OnLinkClicked. The link's URL is passed to the
+// callback as its data.
+type LinkClicked struct{}
+
+// Link is a hyperlink on the terminal's screen - one set by the program with OSC 8, or a
+// URL found in the text. Row is a row of the terminal's view; the link covers columns
+// Start up to, but not including, End.
+type Link struct {
+	URL   string
+	Row   int
+	Start int
+	End   int
+}
+
+// Contains returns true if the link covers the given column and row.
+func (l Link) Contains(col, row int) bool {
+	return row == l.Row && col >= l.Start && col < l.End
+}
+
+// LinkAt returns the link at the given column and row of the terminal's view, if there
+// is one. A link set with OSC 8 is preferred to a URL found in the text.
+func (w *Widget) LinkAt(col, row int) (Link, bool) {
+	if w.canvas == nil || row < 0 || row >= w.canvas.BoxRows() || col < 0 || col >= w.canvas.BoxColumns() {
+		return Link{}, false
+	}
+	if hl, ok := w.canvas.CellAt(col, row).Hyperlink(); ok {
+		res := Link{URL: hl.URL, Row: row, Start: col, End: col + 1}
+		for res.Start > 0 {
+			if hl2, ok := w.canvas.CellAt(res.Start-1, row).Hyperlink(); !ok || hl2 != hl {
+				break
+			}
+			res.Start--
+		}
+		for res.End < w.canvas.BoxColumns() {
+			if hl2, ok := w.canvas.CellAt(res.End, row).Hyperlink(); !ok || hl2 != hl {
+				break
+			}
+			res.End++
+		}
+		return res, true
+	}
+	for _, l := range w.urlsInRow(row) {
+		if l.Contains(col, row) {
+			return l, true
+		}
+	}
+	return Link{}, false
+}
+
+// urlsInRow returns the URLs found in the text of the given row of the view. URLs don't
+// span lines.
+func (w *Widget) urlsInRow(row int) []Link {
+	pattern := w.params.URLPattern
+	if pattern == nil {
+		pattern = DefaultURLPattern
+	}
+	// Build the line's text, remembering the column of each byte
+	var b strings.Builder
+	cols := make([]int, 0, w.canvas.BoxColumns())
+	for x := 0; x < w.canvas.BoxColumns(); x++ {
+		cell := w.canvas.CellAt(x, row)
+		s := " "
+		if cell.HasRune() {
+			s = cell.Grapheme()
+		}
+		b.WriteString(s)
+		for i := 0; i < len(s); i++ {
+			cols = append(cols, x)
+		}
+		// Skip the cells covered by a wide rune
+		x += gwutil.Max(cell.Width(), 1) - 1
+	}
+	text := b.String()
+	var res []Link
+	for _, m := range pattern.FindAllStringIndex(text, -1) {
+		url := trimURL(text[m[0]:m[1]])
+		end := m[0] + len(url)
+		res = append(res, Link{
+			URL:   url,
+			Row:   row,
+			Start: cols[m[0]],
+			End:   cols[end-1] + 1,
+		})
+	}
+	return res
+}
+
+// trimURL removes punctuation that ends a sentence around a URL rather than belonging
+// to it - e.g. a full stop, or a closing parenthesis that has no opening one in the URL.
+func trimURL(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'\"", last) != -1:
+		case last == ')' && strings.Count(url, "(") < strings.Count(url, ")"):
+		case last == ']' && strings.Count(url, "[") < strings.Count(url, "]"):
+		default:
+			return url
+		}
+		url = url[:len(url)-1]
+	}
+	return url
+}
+
+// OpenLink runs the OnLinkClicked callbacks with the URL, then Options.OpenLinkCommand,
+// if set, with the URL as its last argument. The command isn't waited for.
+func (w *Widget) OpenLink(url string, app gowid.IApp) {
+	gowid.RunWidgetCallbacks(w.Callbacks, LinkClicked{}, app, w, url)
+	if len(w.params.OpenLinkCommand) == 0 {
+		return
+	}
+	cmd := exec.Command(w.params.OpenLinkCommand[0], append(w.params.OpenLinkCommand[1:], url)...)
+	if err := cmd.Start(); err != nil {
+		w.Logger().Log(gowid.LogWarn, "Could not open link",
+			gowid.LogField{Name: "url", Val: url},
+			gowid.LogField{Name: "error", Val: err})
+		return
+	}
+	go cmd.Wait()
+}
+
+func (w *Widget) OnLinkClicked(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, LinkClicked{}, f)
+}
+
+func (w *Widget) RemoveOnLinkClicked(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, LinkClicked{}, f)
+}
+
+// HoveredLink returns the link under the mouse, if there is one.
+func (w *Widget) HoveredLink() (Link, bool) {
+	if !w.hovering {
+		return Link{}, false
+	}
+	return w.LinkAt(w.hoverPos.X, w.hoverPos.Y)
+}
+
+// ID lets the terminal be tracked, so it knows when the mouse leaves it.
+func (w *Widget) ID() interface{} {
+	return w
+}
+
+func (w *Widget) MouseEnter(app gowid.IApp) {}
+
+func (w *Widget) MouseLeave(app gowid.IApp) {
+	w.hovering = false
+}
+
+// linkInput tracks the link under the mouse, and opens a link that is clicked. If the
+// program is using the mouse, clicks go to the program unless ctrl is held. It returns
+// true if the event is consumed.
+func (w *Widget) linkInput(ev *tcell.EventMouse, app gowid.IApp) bool {
+	mx, my := ev.Position()
+	w.hoverPos = gowid.CanvasPos{X: mx, Y: my}
+	w.hovering = true
+	if w.Modes().MouseEnabled() && ev.Modifiers()&tcell.ModCtrl == 0 {
+		return false
+	}
+	l, ok := w.LinkAt(mx, my)
+	switch {
+	case ev.Buttons()&tcell.Button1 != 0 && !app.GetLastMouseState().LeftIsClicked():
+		w.pressedLink = nil
+		if ok {
+			w.pressedLink = &l
+		}
+		return ok
+	case ev.Buttons() == tcell.ButtonNone && app.GetLastMouseState().LeftIsClicked():
+		pressed := w.pressedLink
+		w.pressedLink = nil
+		if pressed != nil && ok && *pressed == l {
+			w.OpenLink(l.URL, app)
+		}
+		return pressed != nil
+	}
+	return w.pressedLink != nil
+}
+
+// underlineLink underlines the link under the mouse in the canvas to be rendered.
+func (w *Widget) underlineLink(c gowid.ICanvas) {
+	l, ok := w.HoveredLink()
+	if !ok || l.Row >= c.BoxRows() {
+		return
+	}
+	for x := l.Start; x < l.End && x < c.BoxColumns(); x++ {
+		cell := c.CellAt(x, l.Row)
+		c.SetCellAt(x, l.Row, cell.WithStyle(cell.Style().MergeUnder(gowid.StyleUnderline)))
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
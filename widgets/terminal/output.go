@@ -0,0 +1,112 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package terminal
+
+import (
+	"sync"
+	"time"
+)
+
+//======================================================================
+
+// DefaultMaxFrameRate is the number of times per second the terminal is updated with
+// the program's output if Options.MaxFrameRate is zero.
+const DefaultMaxFrameRate = 60
+
+// DefaultMaxPendingOutput is the number of bytes of the program's output held before
+// the terminal stops reading, if Options.MaxPendingOutput is zero.
+const DefaultMaxPendingOutput = 256 * 1024
+
+// outputBuffer collects the program's output as it is read from the pty, so that the
+// canvas is updated, and the app redrawn, at most once per frame rather than once per
+// read. When the app can't keep up and limit bytes are pending, Write blocks; the pty's
+// buffer then fills and the program is made to wait, rather than the terminal's memory
+// growing without bound.
+type outputBuffer struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	data      []byte
+	limit     int
+	interval  time.Duration
+	last      time.Time // when the output was last taken
+	scheduled bool      // a flush is due, and will take the output
+	closed    bool
+	flush     func() // arranges for the output to be taken and processed
+}
+
+func newOutputBuffer(frameRate int, limit int, flush func()) *outputBuffer {
+	if frameRate <= 0 {
+		frameRate = DefaultMaxFrameRate
+	}
+	if limit <= 0 {
+		limit = DefaultMaxPendingOutput
+	}
+	res := &outputBuffer{
+		limit:    limit,
+		interval: time.Second / time.Duration(frameRate),
+		flush:    flush,
+	}
+	res.cond = sync.NewCond(&res.mu)
+	return res
+}
+
+// Write adds output read from the pty, blocking while the buffer is full. The first
+// write after output is taken schedules a flush, as soon as a frame's interval has
+// passed since the last one.
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	for len(b.data) >= b.limit && !b.closed {
+		b.cond.Wait()
+	}
+	if b.closed {
+		b.mu.Unlock()
+		return len(p), nil
+	}
+	b.data = append(b.data, p...)
+	var delay time.Duration
+	schedule := !b.scheduled
+	if schedule {
+		b.scheduled = true
+		delay = b.interval - time.Since(b.last)
+	}
+	b.mu.Unlock()
+
+	switch {
+	case !schedule:
+	case delay <= 0:
+		b.flush()
+	default:
+		time.AfterFunc(delay, b.flush)
+	}
+	return len(p), nil
+}
+
+// Take returns the output pending, and unblocks Write if the buffer was full.
+func (b *outputBuffer) Take() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	res := b.data
+	b.data = nil
+	b.scheduled = false
+	b.last = time.Now()
+	b.cond.Broadcast()
+	return res
+}
+
+// Close discards the pending output, and any written later - e.g. when the app has
+// closed, so nothing will take it.
+func (b *outputBuffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = nil
+	b.closed = true
+	b.cond.Broadcast()
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,216 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gcla/gowid"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// Palette themes the terminal independently of the app's palette. Colors maps the 16
+// base ANSI colors a program uses to the colors shown, and Foreground and Background
+// replace the terminal's default colors. Nil entries leave the color as it is - e.g. a
+// nil Background shows the default background of the real terminal.
+type Palette struct {
+	Colors     [16]gowid.IColor
+	Foreground gowid.IColor
+	Background gowid.IColor
+}
+
+// IsEmpty returns true if the palette changes no colors.
+func (p *Palette) IsEmpty() bool {
+	if p.Foreground != nil || p.Background != nil {
+		return false
+	}
+	for _, col := range p.Colors {
+		if col != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply maps the colors of each cell of the canvas through the palette. Cells with the
+// default foreground or background - including empty cells - take Foreground and
+// Background.
+func (p *Palette) Apply(c gowid.ICanvas, mode gowid.ColorMode) {
+	for y := 0; y < c.BoxRows(); y++ {
+		for x := 0; x < c.BoxColumns(); x++ {
+			cell := c.CellAt(x, y)
+			if fg, ok := p.mapColor(cell.ForegroundColor(), p.Foreground, mode); ok {
+				cell = cell.WithForegroundColor(fg)
+			}
+			if bg, ok := p.mapColor(cell.BackgroundColor(), p.Background, mode); ok {
+				cell = cell.WithBackgroundColor(bg)
+			}
+			c.SetCellAt(x, y, cell)
+		}
+	}
+}
+
+// mapColor returns the color to show for a cell's color col, and true, if the palette
+// changes it. def replaces the default color.
+func (p *Palette) mapColor(col gowid.TCellColor, def gowid.IColor, mode gowid.ColorMode) (gowid.TCellColor, bool) {
+	var res gowid.IColor
+	tc := col.ToTCell()
+	switch {
+	case tc == tcell.ColorDefault:
+		res = def
+	case tc >= tcell.ColorValid && tc < tcell.ColorValid+16:
+		res = p.Colors[tc-tcell.ColorValid]
+	}
+	if res == nil {
+		return gowid.TCellColor{}, false
+	}
+	return res.ToTCellColor(mode)
+}
+
+// colorRGB returns the components of the color that would be shown for the given palette
+// entry, or those of the standard color def if the entry is nil.
+func colorRGB(col gowid.IColor, def tcell.Color) (int32, int32, int32) {
+	if col != nil {
+		if tc, ok := col.ToTCellColor(gowid.Mode24BitColors); ok {
+			return tc.ToTCell().RGB()
+		}
+	}
+	return def.RGB()
+}
+
+// ColorSpec formats a color as an X11 color spec, "rgb:rrrr/gggg/bbbb", as used in the
+// replies to OSC 4, 10 and 11 queries.
+func ColorSpec(r, g, b int32) string {
+	return fmt.Sprintf("rgb:%04x/%04x/%04x", r*0x101, g*0x101, b*0x101)
+}
+
+// ParseColorSpec parses an X11 color spec as used by OSC 4, 10 and 11 - "rgb:r/g/b",
+// with 1 to 4 hex digits per component, or "#rgb" with 1 to 4 digits per component.
+// Color names aren't supported.
+func ParseColorSpec(spec string) (gowid.IColor, bool) {
+	var comps []string
+	scale := false
+	switch {
+	case strings.HasPrefix(spec, "rgb:"):
+		comps = strings.Split(spec[4:], "/")
+		scale = true
+	case strings.HasPrefix(spec, "#") && len(spec) > 1 && (len(spec)-1)%3 == 0 && len(spec) <= 13:
+		n := (len(spec) - 1) / 3
+		comps = []string{spec[1 : 1+n], spec[1+n : 1+2*n], spec[1+2*n:]}
+	}
+	if len(comps) != 3 {
+		return nil, false
+	}
+	var rgb [3]int
+	for i, s := range comps {
+		if len(s) < 1 || len(s) > 4 {
+			return nil, false
+		}
+		v, err := strconv.ParseUint(s, 16, 16)
+		if err != nil {
+			return nil, false
+		}
+		max := uint64(1)<<(4*uint(len(s))) - 1
+		switch {
+		case scale:
+			// rgb:h/h/h gives the fraction of full intensity
+			rgb[i] = int((v*0xff + max/2) / max)
+		case len(s) == 1:
+			// #rgb gives the most significant bits
+			rgb[i] = int(v << 4)
+		default:
+			rgb[i] = int(v >> (4 * uint(len(s)-2)))
+		}
+	}
+	return gowid.MakeRGBColorExt(rgb[0], rgb[1], rgb[2]), true
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+// Palette returns the terminal's current palette - the one configured, as changed by the
+// program with OSC 4, 10 and 11.
+func (c *Canvas) Palette() Palette {
+	return c.palette
+}
+
+// SetPalette sets the terminal's palette. A terminal reset restores it.
+func (c *Canvas) SetPalette(p Palette) {
+	c.basePalette = p
+	c.palette = p
+}
+
+// parseColorOSC handles OSC 4, 10, 11, 104, 110 and 111, which set, query and reset the
+// terminal's palette. It returns false if osc is none of these.
+func (c *Canvas) parseColorOSC(osc []byte) bool {
+	args := strings.Split(string(osc), ";")
+	cmd, err := strconv.Atoi(args[0])
+	if err != nil {
+		return false
+	}
+	args = args[1:]
+	switch cmd {
+	case 4:
+		// Pairs of index and spec
+		for i := 0; i+1 < len(args); i += 2 {
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 0 || n >= 16 {
+				continue
+			}
+			c.setOrQueryColor(fmt.Sprintf("4;%d", n), args[i+1], &c.palette.Colors[n], tcell.PaletteColor(n))
+		}
+	case 10, 11:
+		// Further specs apply to the next dynamic colors in turn
+		for i, spec := range args {
+			switch cmd + i {
+			case 10:
+				c.setOrQueryColor("10", spec, &c.palette.Foreground, tcell.ColorSilver)
+			case 11:
+				c.setOrQueryColor("11", spec, &c.palette.Background, tcell.ColorBlack)
+			}
+		}
+	case 104:
+		if len(args) == 0 || (len(args) == 1 && args[0] == "") {
+			c.palette.Colors = c.basePalette.Colors
+		}
+		for _, arg := range args {
+			if n, err := strconv.Atoi(arg); err == nil && n >= 0 && n < 16 {
+				c.palette.Colors[n] = c.basePalette.Colors[n]
+			}
+		}
+	case 110:
+		c.palette.Foreground = c.basePalette.Foreground
+	case 111:
+		c.palette.Background = c.basePalette.Background
+	default:
+		return false
+	}
+	return true
+}
+
+// setOrQueryColor sets the palette entry col from spec, or, if spec is "?", replies with
+// its value. The reply starts with prefix; def is reported if the entry is nil.
+func (c *Canvas) setOrQueryColor(prefix string, spec string, col *gowid.IColor, def tcell.Color) {
+	if spec == "?" {
+		d2 := fmt.Sprintf("\033]%s;%s\033\\", prefix, ColorSpec(colorRGB(*col, def)))
+		_, err := c.terminal.Write([]byte(d2))
+		if err != nil {
+			c.logWriteError(d2, err)
+		}
+		return
+	}
+	if res, ok := ParseColorSpec(spec); ok {
+		*col = res
+	}
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
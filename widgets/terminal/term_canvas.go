@@ -19,7 +19,6 @@ import (
 	"github.com/gcla/gowid/gwutil"
 	tcell "github.com/gdamore/tcell/v2"
 	"github.com/mattn/go-runewidth"
-	log "github.com/sirupsen/logrus"
 	"golang.org/x/text/encoding/charmap"
 )
 
@@ -76,6 +75,7 @@ type Modes struct {
 	ConstrainScrolling bool
 	DontAutoWrap       bool
 	InvisibleCursor    bool
+	CursorStyle        tcell.CursorStyle // set by DECSCUSR - CSI Ps SP q
 	Charset            int
 	VT200Mouse         bool // #define SET_VT200_MOUSE             1000
 	ReportButton       bool // #define SET_BTN_EVENT_MOUSE         1002
@@ -185,6 +185,12 @@ var csiMap = CSIMap{
 		canvas.CSIEraseLine(args[0])
 		return true
 	}},
+	'I': RegularCSICommand{1, 1, func(canvas *Canvas, args []int, qmark bool) bool {
+		for i := 0; i < gwutil.Min(args[0], canvas.BoxColumns()); i++ {
+			canvas.Tab(8)
+		}
+		return true
+	}},
 	'L': RegularCSICommand{1, 1, func(canvas *Canvas, args []int, qmark bool) bool {
 		canvas.InsertLines(true, args[0])
 		return true
@@ -197,12 +203,33 @@ var csiMap = CSIMap{
 		canvas.RemoveChars(gwutil.NoneInt(), gwutil.NoneInt(), args[0])
 		return true
 	}},
+	'S': RegularCSICommand{1, 1, func(canvas *Canvas, args []int, qmark bool) bool {
+		canvas.ScrollLines(ScrollDown, args[0])
+		return true
+	}},
+	'T': RegularCSICommand{1, 1, func(canvas *Canvas, args []int, qmark bool) bool {
+		// With more arguments, this is xterm's mouse highlight tracking, which isn't supported
+		if len(args) == 1 {
+			canvas.ScrollLines(ScrollUp, args[0])
+		}
+		return true
+	}},
 	'X': RegularCSICommand{1, 1, func(canvas *Canvas, args []int, qmark bool) bool {
 		myx, myy := canvas.TermCursor()
 		canvas.Erase(myx, myy, myx+args[0]-1, myy)
 		return true
 	}},
+	'Z': RegularCSICommand{1, 1, func(canvas *Canvas, args []int, qmark bool) bool {
+		for i := 0; i < gwutil.Min(args[0], canvas.BoxColumns()); i++ {
+			canvas.BackTab()
+		}
+		return true
+	}},
 	'a': AliasCSICommand{alias: 'C'},
+	'b': RegularCSICommand{1, 1, func(canvas *Canvas, args []int, qmark bool) bool {
+		canvas.RepeatChar(args[0])
+		return true
+	}},
 	'c': RegularCSICommand{0, 0, func(canvas *Canvas, args []int, qmark bool) bool {
 		canvas.CSIGetDeviceAttributes(qmark)
 		return false
@@ -238,8 +265,11 @@ var csiMap = CSIMap{
 		return false
 	}},
 	'r': RegularCSICommand{2, 0, func(canvas *Canvas, args []int, qmark bool) bool {
-		canvas.CSISetScroll(args[0], args[1])
-		return false
+		// CSI ? r restores private modes, which isn't supported
+		if !qmark {
+			canvas.CSISetScroll(args[0], args[1])
+		}
+		return true
 	}},
 	's': RegularCSICommand{0, 0, func(canvas *Canvas, args []int, qmark bool) bool {
 		canvas.SaveCursor(false)
@@ -362,6 +392,33 @@ func (v *ViewPortCanvas) SetLineAt(row int, line []gowid.Cell) {
 	v.Canvas.SetLineAt(row+v.Offset, line)
 }
 
+func (v *ViewPortCanvas) SetLineRange(col, row int, cells []gowid.Cell) {
+	if row >= 0 && row < v.Height {
+		v.Canvas.SetLineRange(col, row+v.Offset, cells)
+	}
+}
+
+func (v *ViewPortCanvas) FillRect(r gowid.Rect, cell gowid.Cell) {
+	r = r.Intersect(gowid.Rect{W: v.BoxColumns(), H: v.Height})
+	r.Y += v.Offset
+	v.Canvas.FillRect(r, cell)
+}
+
+func (v *ViewPortCanvas) CopyRows(src gowid.IAppendCanvas, srcRow, col, row, rows int) {
+	// Keep to the visible rows
+	if row < 0 {
+		srcRow, rows, row = srcRow-row, rows+row, 0
+	}
+	rows = gwutil.Min(rows, v.Height-row)
+	if tc, ok := src.(*Canvas); ok && tc.ViewPortCanvas == v {
+		src = v
+	}
+	if src == gowid.IAppendCanvas(v) {
+		src, srcRow = v.Canvas, srcRow+v.Offset
+	}
+	v.Canvas.CopyRows(src, srcRow, col, row+v.Offset, rows)
+}
+
 func (v *ViewPortCanvas) CellAt(col, row int) gowid.Cell {
 	return v.Canvas.CellAt(col, row+v.Offset)
 }
@@ -416,7 +473,7 @@ type Canvas struct {
 	withinEscape                       bool
 	savedx, savedy                     gwutil.IntOption
 	savedstyles                        map[string]bool
-	savedfg, savedbg                   gwutil.IntOption
+	savedfg, savedbg, savedul          gwutil.IntOption
 	scrollRegionStart, scrollRegionEnd int
 	terminal                           ITerminal
 	charset                            *Charset
@@ -425,7 +482,14 @@ type Canvas struct {
 	tabstops                           []int
 	isRottenCursor                     bool
 	escbuf                             []byte
-	fg, bg                             gwutil.IntOption
+	fg, bg, ul                         gwutil.IntOption
+	subparams                          map[int][]int            // colon-separated sub-parameters of the current CSI, by argument index
+	link                               gowid.Hyperlink          // set by OSC 8, applies to cells written until cleared
+	lineAttrs                          map[*gowid.Cell]LineAttr // set by ESC # 3-6, keyed by each line's first cell so they move with the line
+	wrapped                            map[*gowid.Cell]bool     // lines that continue on the next line because of auto-wrap, keyed the same way
+	lastChar                           rune                     // the last character written, for REP
+	reflow                             bool                     // rewrap lines when the width changes, rather than truncate them
+	palette, basePalette               Palette                  // the colors shown, as changed by OSC 4, 10 and 11, and as configured
 	utf8Buffer                         []byte
 	gowid.ICallbacks
 }
@@ -456,6 +520,21 @@ func (c *Canvas) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+// View returns a copy of the rows of the canvas that are on the screen, with the cursor.
+// It is cheaper than Duplicate, which copies the scrollback too.
+func (c *Canvas) View() gowid.ICanvas {
+	lines := make([][]gowid.Cell, c.BoxRows())
+	for i := range lines {
+		lines[i] = append([]gowid.Cell(nil), c.Line(i, gowid.LineCopy{}).Line...)
+	}
+	res := gowid.NewCanvasWithLines(lines)
+	if c.CursorEnabled() {
+		pos := c.CursorCoords()
+		res.SetCursorCoords(pos.X, pos.Y)
+	}
+	return res
+}
+
 func (c *Canvas) Duplicate() gowid.ICanvas {
 	res := &Canvas{}
 	*res = *c
@@ -493,10 +572,17 @@ func (c *Canvas) Reset() {
 	c.savedy = gwutil.NoneInt()
 	c.savedfg = gwutil.NoneInt()
 	c.savedbg = gwutil.NoneInt()
+	c.savedul = gwutil.NoneInt()
 	c.savedstyles = make(map[string]bool)
 	c.fg = gwutil.NoneInt()
 	c.bg = gwutil.NoneInt()
+	c.ul = gwutil.NoneInt()
+	c.link = gowid.Hyperlink{}
+	c.lastChar = 0
+	c.lineAttrs = make(map[*gowid.Cell]LineAttr)
+	c.wrapped = make(map[*gowid.Cell]bool)
 	c.styles = make(map[string]bool)
+	c.palette = c.basePalette
 	*c.terminal.Modes() = Modes{}
 	c.ResetScroll()
 	c.InitTabstops(false)
@@ -530,6 +616,45 @@ func (c *Canvas) Tab(tabstop int) {
 	c.SetTermCursor(gwutil.SomeInt(x), gwutil.SomeInt(y))
 }
 
+// BackTab moves the cursor back to the previous tab stop, or the start of the line.
+func (c *Canvas) BackTab() {
+	x, y := c.TermCursor()
+
+	for x > 0 {
+		x -= 1
+		if c.IsTabstop(x) {
+			break
+		}
+	}
+
+	c.isRottenCursor = false
+	c.SetTermCursor(gwutil.SomeInt(x), gwutil.SomeInt(y))
+}
+
+// RepeatChar writes the last character written n more times (REP).
+func (c *Canvas) RepeatChar(n int) {
+	if c.lastChar == 0 {
+		return
+	}
+	r := c.lastChar
+	// More would only overwrite the screen again
+	n = gwutil.Min(n, c.BoxColumns()*c.BoxRows())
+	for i := 0; i < n; i++ {
+		c.PushCursor(r)
+	}
+}
+
+// ScrollLines scrolls the scrolling region n lines, as with SU and SD. The cursor
+// doesn't move.
+func (c *Canvas) ScrollLines(dir ScrollDir, n int) {
+	x, y := c.TermCursor()
+	n = gwutil.Min(n, c.BoxRows())
+	for i := 0; i < n; i++ {
+		c.Scroll(dir)
+	}
+	c.SetTermCursor(gwutil.SomeInt(x), gwutil.SomeInt(y))
+}
+
 func (c *Canvas) InitTabstops(extend bool) {
 	tablen, mod := c.BoxColumns()/8, c.BoxColumns()
 
@@ -757,12 +882,14 @@ func (c *Canvas) SaveCursor(withAttrs bool) {
 	if withAttrs {
 		c.savedfg = c.fg
 		c.savedbg = c.bg
+		c.savedul = c.ul
 		for k, v := range c.styles {
 			c.savedstyles[k] = v
 		}
 	} else {
 		c.savedfg = gwutil.NoneInt()
 		c.savedbg = gwutil.NoneInt()
+		c.savedul = gwutil.NoneInt()
 	}
 }
 
@@ -772,6 +899,7 @@ func (c *Canvas) RestoreCursor(withAttrs bool) {
 		if withAttrs {
 			c.fg = c.savedfg
 			c.bg = c.savedbg
+			c.ul = c.savedul
 			c.styles = make(map[string]bool)
 			for k, v := range c.savedstyles {
 				c.styles[k] = v
@@ -820,8 +948,181 @@ func (c *Canvas) Clear(newcx, newcy gwutil.IntOption) {
 	}
 }
 
+// LineAttr is a line's size, as set by the DEC escape sequences ESC # 3 to ESC # 6. A
+// double-width line shows only the first half of its cells, each twice as wide; a
+// double-height line is drawn as two lines, each showing one half of the enlarged
+// characters. A terminal can't show these in another terminal, so the terminal widget
+// approximates them - see ApproximateLineAttrs.
+type LineAttr int
+
+const (
+	LineSingle LineAttr = iota
+	LineDoubleWidth
+	LineDoubleHeightTop
+	LineDoubleHeightBottom
+)
+
+func (a LineAttr) String() string {
+	switch a {
+	case LineSingle:
+		return "single"
+	case LineDoubleWidth:
+		return "double-width"
+	case LineDoubleHeightTop:
+		return "double-height-top"
+	case LineDoubleHeightBottom:
+		return "double-height-bottom"
+	default:
+		return fmt.Sprintf("lineattr(%d)", int(a))
+	}
+}
+
+// lineKey returns the key for the attributes of the row of the viewport, or nil if the
+// row is empty.
+func (c *Canvas) lineKey(row int) *gowid.Cell {
+	row += c.Offset
+	if row < 0 || row >= len(c.ViewPortCanvas.Canvas.Lines) || len(c.ViewPortCanvas.Canvas.Lines[row]) == 0 {
+		return nil
+	}
+	return &c.ViewPortCanvas.Canvas.Lines[row][0]
+}
+
+// LineAttr returns the size attribute of the row of the viewport.
+func (c *Canvas) LineAttr(row int) LineAttr {
+	if key := c.lineKey(row); key != nil {
+		return c.lineAttrs[key]
+	}
+	return LineSingle
+}
+
+// SetLineAttr sets the size attribute of the row of the viewport. The attribute moves
+// with the line as the terminal scrolls, and is lost when the line is cleared.
+func (c *Canvas) SetLineAttr(row int, attr LineAttr) {
+	key := c.lineKey(row)
+	if key == nil {
+		return
+	}
+	if attr == LineSingle {
+		delete(c.lineAttrs, key)
+		return
+	}
+	c.lineAttrs[key] = attr
+	if len(c.lineAttrs) > c.BoxRows() {
+		c.pruneLineState()
+	}
+}
+
+// HasLineAttrs returns true if any row of the viewport isn't single-size.
+func (c *Canvas) HasLineAttrs() bool {
+	if len(c.lineAttrs) == 0 {
+		return false
+	}
+	for row := 0; row < c.BoxRows(); row++ {
+		if c.LineAttr(row) != LineSingle {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneLineState forgets the attributes and wrapping of lines that are no longer in
+// either screen buffer, so they can be garbage collected.
+func (c *Canvas) pruneLineState() {
+	live := make(map[*gowid.Cell]LineAttr, len(c.lineAttrs))
+	wrapped := make(map[*gowid.Cell]bool, len(c.wrapped))
+	for _, vp := range []*ViewPortCanvas{c.ViewPortCanvas, c.alternate} {
+		for _, line := range vp.Canvas.Lines {
+			if len(line) == 0 {
+				continue
+			}
+			if attr, ok := c.lineAttrs[&line[0]]; ok {
+				live[&line[0]] = attr
+			}
+			if c.wrapped[&line[0]] {
+				wrapped[&line[0]] = true
+			}
+		}
+	}
+	c.lineAttrs = live
+	c.wrapped = wrapped
+}
+
+// Wrapped returns true if the row of the viewport continues on the next row because
+// the program's output was wrapped automatically.
+func (c *Canvas) Wrapped(row int) bool {
+	if key := c.lineKey(row); key != nil {
+		return c.wrapped[key]
+	}
+	return false
+}
+
+// SetWrapped records whether the row of the viewport continues on the next row. Like a
+// line's size, this moves with the line as the terminal scrolls.
+func (c *Canvas) SetWrapped(row int, wrapped bool) {
+	key := c.lineKey(row)
+	if key == nil {
+		return
+	}
+	if !wrapped {
+		delete(c.wrapped, key)
+		return
+	}
+	c.wrapped[key] = true
+	// Wrapped lines can fill the scrollback, so prune only once half the entries may be stale
+	if len(c.wrapped) > 2*(len(c.ViewPortCanvas.Canvas.Lines)+len(c.alternate.Canvas.Lines)) {
+		c.pruneLineState()
+	}
+}
+
+// Reflow returns true if lines are rewrapped when the terminal's width changes.
+func (c *Canvas) Reflow() bool {
+	return c.reflow
+}
+
+// SetReflow determines whether lines are rewrapped when the terminal's width changes,
+// as by modern terminal emulators, rather than truncated or padded. Only lines the
+// program wrapped automatically are joined up again.
+func (c *Canvas) SetReflow(reflow bool) {
+	c.reflow = reflow
+}
+
+// ApproximateLineAttrs returns a copy of the viewport with double-size lines
+// approximated using ordinary characters: each of the first half of a double-width
+// line's cells is followed by a space, and the top half of a double-height line is
+// drawn the same way, but bold, with its bottom half left blank. If no line is
+// double-size, the canvas itself is returned.
+func ApproximateLineAttrs(c *Canvas) gowid.ICanvas {
+	if !c.HasLineAttrs() {
+		return c
+	}
+	res := c.ViewPortCanvas.Duplicate()
+	cols := c.BoxColumns()
+	for row := 0; row < c.BoxRows(); row++ {
+		attr := c.LineAttr(row)
+		if attr == LineSingle {
+			continue
+		}
+		line := emptyLine(cols)
+		if attr != LineDoubleHeightBottom {
+			for x := 0; x*2 < cols; x++ {
+				cell := c.CellAt(x, row)
+				if attr == LineDoubleHeightTop {
+					cell = cell.WithStyle(cell.Style().MergeUnder(gowid.StyleBold))
+				}
+				line[x*2] = cell
+				if x*2+1 < cols {
+					line[x*2+1] = cell.WithRune(' ')
+				}
+			}
+		}
+		res.SetLineAt(row, line)
+	}
+	return res
+}
+
 func (c *Canvas) DECAln() {
 	for i := 0; i < c.BoxRows(); i++ {
+		c.SetLineAttr(i, LineSingle)
 		for j := 0; j < c.BoxColumns(); j++ {
 			c.SetCellAt(j, i, gowid.MakeCell('E', gowid.MakeTCellColorExt(tcell.ColorDefault), gowid.MakeTCellColorExt(tcell.ColorDefault), gowid.StyleNone))
 		}
@@ -861,31 +1162,67 @@ func (c *Canvas) CSISetKeyboardLEDs(mode int) {
 	}
 }
 
+// logWriteError reports a failure to send a reply to the terminal's program, via the
+// terminal widget's logger.
+func (c *Canvas) logWriteError(data string, err error) {
+	gowid.LoggerOf(c.terminal).Log(gowid.LogWarn, "Could not write all bytes to terminal pty",
+		gowid.LogField{Name: "bytes", Val: len(data)},
+		gowid.LogField{Name: "error", Val: err})
+}
+
+// Enquiry replies to ENQ with the terminal's answerback message, if it has one - see
+// IAnswerback.
+func (c *Canvas) Enquiry() {
+	ab, ok := c.terminal.(IAnswerback)
+	if !ok || ab.Answerback() == "" {
+		return
+	}
+	d2 := ab.Answerback()
+	_, err := c.terminal.Write([]byte(d2))
+	if err != nil {
+		c.logWriteError(d2, err)
+	}
+}
+
 func (c *Canvas) CSIStatusReport(mode int) {
 	switch mode {
 	case 5:
 		d2 := "\033[0n"
 		_, err := c.terminal.Write([]byte(d2))
 		if err != nil {
-			log.Warnf("Could not write all of %d bytes to terminal pty", len(d2))
+			c.logWriteError(d2, err)
 		}
 	case 6:
 		x, y := c.TermCursor()
 		d2 := fmt.Sprintf("\033[%d;%dR", y+1, x+1)
 		_, err := c.terminal.Write([]byte(d2))
 		if err != nil {
-			log.Warnf("Could not write all of %d bytes to terminal pty", len(d2))
+			c.logWriteError(d2, err)
 		}
 	}
 }
 
-// Report as vt102, like vterm.py
+// CSISetCursorStyle records the cursor shape requested by DECSCUSR - 0 or 1 for a
+// blinking block, 2 for a steady block, 3 and 4 for an underline and 5 and 6 for a bar.
+// The widget passes it on to the real terminal when it has focus.
+func (c *Canvas) CSISetCursorStyle(ps int) {
+	if ps < 0 || ps > int(tcell.CursorStyleSteadyBar) {
+		return
+	}
+	c.terminal.Modes().CursorStyle = tcell.CursorStyle(ps)
+}
+
+// CSIGetDeviceAttributes replies to a device attributes request with the terminal's
+// configured reply - see IAnswerback - or DefaultDeviceAttributes.
 func (c *Canvas) CSIGetDeviceAttributes(qmark bool) {
 	if !qmark {
-		d2 := "\033[?6c"
+		d2 := DefaultDeviceAttributes
+		if ab, ok := c.terminal.(IAnswerback); ok && ab.DeviceAttributes() != "" {
+			d2 = ab.DeviceAttributes()
+		}
 		_, err := c.terminal.Write([]byte(d2))
 		if err != nil {
-			log.Warnf("Could not write all of %d bytes to terminal pty", len(d2))
+			c.logWriteError(d2, err)
 		}
 	}
 }
@@ -897,16 +1234,17 @@ func (c *Canvas) CSISetScroll(top, bottom int) {
 	if top == 0 {
 		top = 1
 	}
-	if bottom == 0 {
+	if bottom == 0 || bottom > c.BoxRows() {
 		bottom = c.BoxRows()
 	}
 
-	if top < bottom && bottom <= c.BoxRows() {
+	if top < bottom {
 		_, y1 := c.ConstrainCoords(0, top-1, true)
 		c.scrollRegionStart = y1
 		_, y2 := c.ConstrainCoords(0, bottom-1, true)
 		c.scrollRegionEnd = y2
-		c.SetTermCursor(gwutil.SomeInt(0), gwutil.SomeInt(0))
+		// The cursor goes home - the top of the region, in origin mode
+		c.MoveCursor(0, 0, false, false, false)
 	}
 }
 
@@ -952,12 +1290,35 @@ func (c *Canvas) SetMode(mode int, flag bool, qmark bool, reset bool) {
 			}
 		case 1006:
 			c.terminal.Modes().SgrModeMouse = flag
-		case 1049:
+		case 1047:
 			if flag {
 				c.UseAlternateScreen()
 			} else {
+				if c.alternateActive {
+					x, y := c.TermCursor()
+					c.Clear(gwutil.SomeInt(x), gwutil.SomeInt(y))
+				}
 				c.UseOriginalScreen()
 			}
+		case 1048:
+			if flag {
+				c.SaveCursor(true)
+			} else {
+				c.RestoreCursor(true)
+			}
+		case 1049:
+			// Save the cursor and switch to a cleared alternate screen; undo on reset
+			if flag {
+				if !c.alternateActive {
+					c.SaveCursor(true)
+					c.UseAlternateScreen()
+					x, y := c.TermCursor()
+					c.Clear(gwutil.SomeInt(x), gwutil.SomeInt(y))
+				}
+			} else if c.alternateActive {
+				c.UseOriginalScreen()
+				c.RestoreCursor(true)
+			}
 		}
 	} else {
 		switch mode {
@@ -1109,26 +1470,22 @@ func (c *Canvas) Erase(startx, starty, endx, endy int) {
 	ex, ey := c.ConstrainCoords(endx, endy, false)
 
 	if sy == ey {
-		for i := sx; i < ex+1; i++ {
-			c.SetCellAt(i, sy, gowid.Cell{})
+		c.FillRect(gowid.Rect{X: sx, Y: sy, W: ex + 1 - sx, H: 1}, gowid.Cell{})
+		if ex >= c.BoxColumns()-1 {
+			c.SetWrapped(sy, false)
 		}
-	} else {
-		y := sy
-		for y <= ey {
-			if y == sy {
-				for i := sx; i < c.BoxColumns(); i++ {
-					c.SetCellAt(i, y, gowid.Cell{})
-				}
-			} else if y == ey {
-				for i := 0; i < ex+1; i++ {
-					c.SetCellAt(i, y, gowid.Cell{})
-				}
-			} else {
-				for i := 0; i < c.BoxColumns(); i++ {
-					c.SetCellAt(i, y, gowid.Cell{})
+	} else if sy < ey {
+		c.FillRect(gowid.Rect{X: sx, Y: sy, W: c.BoxColumns() - sx, H: 1}, gowid.Cell{})
+		c.FillRect(gowid.Rect{Y: sy + 1, W: c.BoxColumns(), H: ey - sy - 1}, gowid.Cell{})
+		c.FillRect(gowid.Rect{Y: ey, W: ex + 1, H: 1}, gowid.Cell{})
+		// Erasing the display returns lines erased completely to single size, as xterm does
+		for y := sy; y <= ey; y++ {
+			if y < ey || ex >= c.BoxColumns()-1 {
+				c.SetWrapped(y, false)
+				if y > sy || sx == 0 {
+					c.SetLineAttr(y, LineSingle)
 				}
 			}
-			y++
 		}
 	}
 }
@@ -1142,9 +1499,8 @@ func (c *Canvas) CSIEraseLine(mode int) {
 	case 1:
 		c.Erase(0, myy, myx, myy)
 	case 2:
-		for i := 0; i < c.BoxColumns(); i++ {
-			c.SetCellAt(i, myy, gowid.Cell{})
-		}
+		c.FillRect(gowid.Rect{Y: myy, W: c.BoxColumns(), H: 1}, gowid.Cell{})
+		c.SetWrapped(myy, false)
 	}
 }
 
@@ -1164,6 +1520,7 @@ func (c *Canvas) CSISetAttr(args []int) {
 	if args[len(args)-1] == 0 {
 		c.fg = gwutil.NoneInt()
 		c.bg = gwutil.NoneInt()
+		c.ul = gwutil.NoneInt()
 		c.styles = make(map[string]bool)
 	}
 
@@ -1183,9 +1540,11 @@ func (c *Canvas) SGIToAttribs(args []int, fg, bg gwutil.IntOption, styles map[st
 		case 100 <= attr && attr <= 107:
 			bg = gwutil.SomeInt(attr - 100 + 9) // 8 basic colors -> right index into tcell array
 		case attr == 23:
-			// TODO vim sends this
+			delete(styles, "italic")
 		case attr == 38:
-			if i+2 < len(args) && args[i+1] == 5 && args[i+2] >= 0 && args[i+2] <= 255 {
+			if sub, ok := c.subparams[i]; ok {
+				fg = subparamColor(sub)
+			} else if i+2 < len(args) && args[i+1] == 5 && args[i+2] >= 0 && args[i+2] <= 255 {
 				fg = gwutil.SomeInt(args[i+2] + 1)
 				i += 2
 			} else if i+4 < len(args) && args[i+1] == 2 && args[i+2] >= 0 && args[i+2] <= 255 && args[i+3] >= 0 && args[i+3] <= 255 && args[i+4] >= 0 && args[i+4] <= 255 {
@@ -1196,7 +1555,9 @@ func (c *Canvas) SGIToAttribs(args []int, fg, bg gwutil.IntOption, styles map[st
 			delete(styles, "underline")
 			fg = gwutil.NoneInt()
 		case attr == 48:
-			if i+2 < len(args) && args[i+1] == 5 && args[i+2] >= 0 && args[i+2] <= 255 {
+			if sub, ok := c.subparams[i]; ok {
+				bg = subparamColor(sub)
+			} else if i+2 < len(args) && args[i+1] == 5 && args[i+2] >= 0 && args[i+2] <= 255 {
 				bg = gwutil.SomeInt(args[i+2] + 1)
 				i += 2
 			} else if i+4 < len(args) && args[i+1] == 2 && args[i+2] >= 0 && args[i+2] <= 255 && args[i+3] >= 0 && args[i+3] <= 255 && args[i+4] >= 0 && args[i+4] <= 255 {
@@ -1205,6 +1566,18 @@ func (c *Canvas) SGIToAttribs(args []int, fg, bg gwutil.IntOption, styles map[st
 			}
 		case attr == 49:
 			bg = gwutil.NoneInt()
+		case attr == 58:
+			if sub, ok := c.subparams[i]; ok {
+				c.ul = subparamColor(sub)
+			} else if i+2 < len(args) && args[i+1] == 5 && args[i+2] >= 0 && args[i+2] <= 255 {
+				c.ul = gwutil.SomeInt(args[i+2] + 1)
+				i += 2
+			} else if i+4 < len(args) && args[i+1] == 2 && args[i+2] >= 0 && args[i+2] <= 255 && args[i+3] >= 0 && args[i+3] <= 255 && args[i+4] >= 0 && args[i+4] <= 255 {
+				c.ul = gwutil.SomeInt(gowid.CubeStart + (((args[i+2] * gowid.CubeSize256) + args[i+3]) * gowid.CubeSize256) + args[i+4] + 1)
+				i += 4
+			}
+		case attr == 59:
+			c.ul = gwutil.NoneInt()
 		case attr == 10:
 			c.charset.ResetSgrIbmpc()
 			c.terminal.Modes().DisplayCtrl = false
@@ -1213,8 +1586,27 @@ func (c *Canvas) SGIToAttribs(args []int, fg, bg gwutil.IntOption, styles map[st
 			c.terminal.Modes().DisplayCtrl = true
 		case attr == 1:
 			styles["bold"] = true
+		case attr == 3:
+			styles["italic"] = true
 		case attr == 4:
-			styles["underline"] = true
+			delete(styles, "underline")
+			delete(styles, "doubleunderline")
+			delete(styles, "curlyunderline")
+			// 4:0 is no underline, 4:2 double, 4:3 curly; dotted and dashed are shown as plain
+			switch sub := c.subparams[i]; {
+			case len(sub) == 0 || sub[0] == 1 || sub[0] > 3:
+				styles["underline"] = true
+			case sub[0] == 2:
+				styles["doubleunderline"] = true
+			case sub[0] == 3:
+				styles["curlyunderline"] = true
+			}
+		case attr == 9:
+			styles["strikethrough"] = true
+		case attr == 21:
+			styles["doubleunderline"] = true
+			delete(styles, "underline")
+			delete(styles, "curlyunderline")
 		case attr == 7:
 			styles["reverse"] = true
 		case attr == 5:
@@ -1223,15 +1615,19 @@ func (c *Canvas) SGIToAttribs(args []int, fg, bg gwutil.IntOption, styles map[st
 			delete(styles, "bold")
 		case attr == 24:
 			delete(styles, "underline")
+			delete(styles, "doubleunderline")
+			delete(styles, "curlyunderline")
 		case attr == 25:
 			delete(styles, "blink")
 		case attr == 27:
 			delete(styles, "reverse")
+		case attr == 29:
+			delete(styles, "strikethrough")
 		case attr == 0:
 			fg = gwutil.NoneInt()
 			bg = gwutil.NoneInt()
+			c.ul = gwutil.NoneInt()
 			styles = make(map[string]bool)
-		case attr == 3:
 		case attr == 6:
 		}
 	}
@@ -1239,7 +1635,36 @@ func (c *Canvas) SGIToAttribs(args []int, fg, bg gwutil.IntOption, styles map[st
 	return fg, bg, styles
 }
 
+// subparamColor interprets the sub-parameters of e.g. 58:5:n or 58:2::r:g:b, where the
+// color space ID before r, g and b may be omitted.
+func subparamColor(sub []int) gwutil.IntOption {
+	valid := func(vals ...int) bool {
+		for _, v := range vals {
+			if v < 0 || v > 255 {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case len(sub) == 2 && sub[0] == 5 && valid(sub[1]):
+		return gwutil.SomeInt(sub[1] + 1)
+	case len(sub) >= 4 && sub[0] == 2:
+		rgb := sub[len(sub)-3:]
+		if valid(rgb...) {
+			return gwutil.SomeInt(gowid.CubeStart + (((rgb[0] * gowid.CubeSize256) + rgb[1]) * gowid.CubeSize256) + rgb[2] + 1)
+		}
+	}
+	return gwutil.NoneInt()
+}
+
 func (c *Canvas) Resize(width, height int) {
+	// The alternate screen's programs redraw for the new size themselves
+	if c.reflow && !c.alternateActive && width != c.BoxColumns() {
+		c.reflowTo(width, height)
+		return
+	}
+
 	x, y := c.TermCursor()
 
 	if width > c.BoxColumns() {
@@ -1271,8 +1696,123 @@ func (c *Canvas) Resize(width, height int) {
 	c.InitTabstops(true)
 }
 
+// reflowTo resizes the main screen by rewrapping its lines, and those of the scrollback,
+// to the new width. Lines the program wrapped automatically are joined up before being
+// wrapped again, so making the terminal narrower then wider again doesn't lose text. The
+// cursor stays with the character it was on.
+func (c *Canvas) reflowTo(width, height int) {
+	if height < 1 {
+		height = 1
+	}
+	lines := c.ViewPortCanvas.Canvas.Lines
+	cx, cy := c.TermCursor()
+	cursorRow := gwutil.Max(0, len(lines)-c.Height) + cy
+
+	var res [][]gowid.Cell
+	wrapped := make(map[*gowid.Cell]bool)
+	newX, newY := 0, 0
+	rotten := false
+	var cells []gowid.Cell
+	cursorAt := -1
+	for row, line := range lines {
+		if row == cursorRow {
+			cursorAt = len(cells) + cx
+			// A pending wrap puts the next character after the cursor's cell
+			if c.isRottenCursor {
+				cursorAt++
+			}
+		}
+		if len(line) > 0 && c.wrapped[&line[0]] && row < len(lines)-1 {
+			cells = append(cells, line...)
+			continue
+		}
+		// Drop the blank end of the logical line, but keep the cursor's cell
+		n := len(line)
+		for n > 0 && !line[n-1].HasRune() {
+			n--
+		}
+		if row == cursorRow && n <= cx {
+			n = gwutil.Min(cx+1, len(line))
+		}
+		cells = append(cells, line[:n]...)
+
+		rows, starts := rewrap(cells, width)
+		if cursorAt >= 0 {
+			i := len(starts) - 1
+			for i > 0 && starts[i] > cursorAt {
+				i--
+			}
+			col := cursorAt - starts[i]
+			rotten = col >= width
+			newX, newY = gwutil.Min(col, width-1), len(res)+i
+			cursorAt = -1
+		}
+		for i := 0; i < len(rows)-1; i++ {
+			wrapped[&rows[i][0]] = true
+		}
+		res = append(res, rows...)
+		cells = nil
+	}
+
+	// Keep the cursor on the screen, losing rows below it if necessary
+	if len(res) > newY+height {
+		res = res[:newY+height]
+	}
+	for len(res) < height {
+		res = append(res, emptyLine(width))
+	}
+	if drop := len(res) - (height + c.scrollback); drop > 0 {
+		res = res[drop:]
+		newY -= drop
+	}
+
+	c.ViewPortCanvas.Canvas = gowid.NewCanvasWithLines(res)
+	c.Offset = len(res) - height
+	c.Height = height
+	c.wrapped = wrapped
+	c.lineAttrs = make(map[*gowid.Cell]LineAttr)
+	c.isRottenCursor = rotten
+
+	c.ResetScroll()
+	c.SetTermCursor(gwutil.SomeInt(newX), gwutil.SomeInt(newY-c.Offset))
+	c.InitTabstops(true)
+}
+
+// rewrap splits a logical line into rows of the given width, without splitting wide
+// characters. It returns the rows, and the index in cells at which each row starts.
+func rewrap(cells []gowid.Cell, width int) ([][]gowid.Cell, []int) {
+	var rows [][]gowid.Cell
+	var starts []int
+	n := 0
+	for len(rows) == 0 || n < len(cells) {
+		row := emptyLine(width)
+		starts = append(starts, n)
+		col := 0
+		for n < len(cells) {
+			w := gwutil.Max(cells[n].Width(), 1)
+			if col+w > width {
+				if col > 0 {
+					break
+				}
+				// Too wide for the terminal - keep just the character
+				w = 1
+			}
+			end := gwutil.Min(n+w, len(cells))
+			copy(row[col:], cells[n:end])
+			col += w
+			n += w
+		}
+		rows = append(rows, row)
+	}
+	return rows, starts
+}
+
 func (c *Canvas) PushCursor(r rune) {
 	x, y := c.TermCursor()
+	if c.combineWithPrevious(r, x, y) {
+		return
+	}
+	c.lastChar = r
 	wid := runewidth.RuneWidth(r)
 
 	if !c.terminal.Modes().DontAutoWrap {
@@ -1282,6 +1822,7 @@ func (c *Canvas) PushCursor(r rune) {
 		} else {
 			x += wid
 			if x >= c.BoxColumns() {
+				c.SetWrapped(y, true)
 				if y >= c.scrollRegionEnd {
 					c.Scroll(false)
 				} else {
@@ -1302,6 +1843,37 @@ func (c *Canvas) PushCursor(r rune) {
 	}
 }
 
+// combineWithPrevious adds r to the grapheme cluster in the cell last written before
+// the cursor at x, y if r is a combining rune, or follows a zero-width joiner. It
+// returns false if r should occupy its own cell.
+func (c *Canvas) combineWithPrevious(r rune, x, y int) bool {
+	if r < 0x300 || c.terminal.Modes().Insert {
+		return false
+	}
+	px := x
+	if !c.isRottenCursor {
+		// The previous cell is wide if the cursor skipped over two columns to get here
+		switch {
+		case x >= 2 && c.CellAt(x-2, y).Width() == 2:
+			px = x - 2
+		case x >= 1:
+			px = x - 1
+		default:
+			return false
+		}
+	}
+	prev := c.CellAt(px, y)
+	if !prev.HasRune() {
+		return false
+	}
+	comb := prev.Combining()
+	if runewidth.RuneWidth(r) != 0 && (len(comb) == 0 || comb[len(comb)-1] != '\u200d') {
+		return false
+	}
+	c.SetCellAt(px, y, prev.WithCombining(append(comb, r)))
+	return true
+}
+
 func (c *Canvas) PushRune(r rune, x, y int) {
 	r2 := c.charset.ApplyMapping(r)
 
@@ -1328,18 +1900,34 @@ func (c *Canvas) MakeCellFrom(r rune) gowid.Cell {
 		cell = cell.WithBackgroundColor(gowid.MakeTCellColorExt(tcell.Color(c.bg.Val()-1) + tcell.ColorValid))
 	}
 	if len(c.styles) > 0 {
+		style := gowid.StyleNone
 		for k, _ := range c.styles {
 			switch k {
 			case "underline":
-				cell = cell.WithStyle(gowid.StyleUnderline)
+				style = style.MergeUnder(gowid.StyleUnderline)
 			case "bold":
-				cell = cell.WithStyle(gowid.StyleBold)
+				style = style.MergeUnder(gowid.StyleBold)
 			case "reverse":
-				cell = cell.WithStyle(gowid.StyleReverse)
+				style = style.MergeUnder(gowid.StyleReverse)
 			case "blink":
-				cell = cell.WithStyle(gowid.StyleBlink)
+				style = style.MergeUnder(gowid.StyleBlink)
+			case "italic":
+				style = style.MergeUnder(gowid.StyleItalic)
+			case "strikethrough":
+				style = style.MergeUnder(gowid.StyleStrikethrough)
+			case "doubleunderline":
+				style = style.MergeUnder(gowid.StyleDoubleUnderline)
+			case "curlyunderline":
+				style = style.MergeUnder(gowid.StyleCurlyUnderline)
 			}
 		}
+		cell = cell.WithStyle(style)
+	}
+	if !c.ul.IsNone() {
+		cell = cell.WithUnderlineColor(gowid.MakeTCellColorExt(tcell.Color(c.ul.Val()-1) + tcell.ColorValid))
+	}
+	if c.link.URL != "" {
+		cell = cell.WithHyperlink(c.link)
 	}
 	return cell
 }
@@ -1371,7 +1959,11 @@ func (c *Canvas) ParseEscapeExt(r byte) bool {
 		if _, ok := csiMap[r]; ok {
 			res = c.ParseCSIExt(r)
 			c.parsestate = defaultState
-		} else if ((r == '-') || (r == '0') || (r == '1') || (r == '2') || (r == '3') || (r == '4') || (r == '5') || (r == '6') || (r == '7') || (r == '8') || (r == '9') || (r == ';')) || (len(c.escbuf) == 0 && r == '?') {
+		} else if r == ' ' {
+			// An intermediate byte, as in DECSCUSR - CSI Ps SP q
+			c.escbuf = append(c.escbuf, r)
+			leaveEscape = false
+		} else if ((r == '-') || (r == '0') || (r == '1') || (r == '2') || (r == '3') || (r == '4') || (r == '5') || (r == '6') || (r == '7') || (r == '8') || (r == '9') || (r == ';') || (r == ':')) || (len(c.escbuf) == 0 && r == '?') {
 			c.escbuf = append(c.escbuf, r)
 			leaveEscape = false
 		}
@@ -1422,7 +2014,28 @@ func (c *Canvas) ParseOSC(osc []byte) {
 		c.RunCallbacks(Title{}, string(osc[1:]))
 	case len(osc) > 1 && osc[0] == '3' && osc[1] == ';':
 		c.RunCallbacks(Title{}, string(osc[2:]))
+	case len(osc) > 1 && osc[0] == '8' && osc[1] == ';':
+		c.link = ParseHyperlink(osc[2:])
+	default:
+		c.parseColorOSC(osc)
+	}
+}
+
+// ParseHyperlink interprets the body of an OSC 8 sequence, "params;URL", where params
+// is a colon-separated list of key=value pairs. An empty URL ends the current link.
+func ParseHyperlink(osc []byte) gowid.Hyperlink {
+	res := gowid.Hyperlink{}
+	parts := bytes.SplitN(osc, []byte{';'}, 2)
+	if len(parts) != 2 {
+		return res
+	}
+	res.URL = string(parts[1])
+	for _, kv := range bytes.Split(parts[0], []byte{':'}) {
+		if bytes.HasPrefix(kv, []byte("id=")) {
+			res.ID = string(kv[3:])
+		}
 	}
+	return res
 }
 
 func (c *Canvas) SetG01(r byte, mod byte) {
@@ -1460,6 +2073,18 @@ func (c *Canvas) ParseNonCSIExt(r byte, mod byte) bool {
 	case r == '8' && mod == '#':
 		c.DECAln()
 		res = true
+	case r == '3' && mod == '#':
+		c.SetLineAttr(c.tcy, LineDoubleHeightTop)
+		res = true
+	case r == '4' && mod == '#':
+		c.SetLineAttr(c.tcy, LineDoubleHeightBottom)
+		res = true
+	case r == '5' && mod == '#':
+		c.SetLineAttr(c.tcy, LineSingle)
+		res = true
+	case r == '6' && mod == '#':
+		c.SetLineAttr(c.tcy, LineDoubleWidth)
+		res = true
 	case mod == '%':
 		if r == '@' {
 			c.terminal.Modes().Charset = CharsetDefault
@@ -1501,6 +2126,15 @@ func (c *Canvas) ParseCSIExt(r byte) bool {
 	res := false
 	numbuf := make([]int, 0)
 	qmark := false
+	c.subparams = nil
+
+	if n := len(c.escbuf); n > 0 && c.escbuf[n-1] == ' ' {
+		if r == 'q' {
+			ps, _ := strconv.Atoi(string(c.escbuf[:n-1]))
+			c.CSISetCursorStyle(ps)
+		}
+		return true
+	}
 
 	for i, u := range bytes.Split(c.escbuf, []byte{';'}) {
 		if (i == 0) && (len(u) > 0) && (u[0] == '?') {
@@ -1508,8 +2142,25 @@ func (c *Canvas) ParseCSIExt(r byte) bool {
 			u = u[1:]
 		}
 
-		num, err := strconv.Atoi(string(u))
+		// e.g. 4:3 - the sub-parameters are recorded against the index of the 4. An
+		// empty sub-parameter is recorded as -1.
+		parts := bytes.Split(u, []byte{':'})
+		num, err := strconv.Atoi(string(parts[0]))
 		if err == nil {
+			if len(parts) > 1 {
+				if c.subparams == nil {
+					c.subparams = make(map[int][]int)
+				}
+				sub := make([]int, 0, len(parts)-1)
+				for _, p := range parts[1:] {
+					v, err := strconv.Atoi(string(p))
+					if err != nil {
+						v = -1
+					}
+					sub = append(sub, v)
+				}
+				c.subparams[len(numbuf)] = sub
+			}
 			numbuf = append(numbuf, num)
 		}
 	}
@@ -1593,6 +2244,8 @@ func (c *Canvas) ProcessByteOrCommandExt(r rune) bool {
 		}
 	case r == '\x07' && c.parsestate != oscState && !dc:
 		c.RunCallbacks(Bell{})
+	case r == '\x05' && !dc:
+		c.Enquiry()
 	case ((r == '\x18') || (r == '\x1a')) && !dc:
 		c.LeaveEscapeResetState()
 	case ((r == '\x00') || (r == '\x7f')) && !dc:
@@ -11,6 +11,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"sync"
 	"syscall"
@@ -21,13 +22,13 @@ import (
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwutil"
 	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/highlight"
 	"github.com/gcla/gowid/widgets/holder"
 	"github.com/gcla/gowid/widgets/null"
 	"github.com/gcla/gowid/widgets/vscroll"
 	tcell "github.com/gdamore/tcell/v2"
 	"github.com/gdamore/tcell/v2/terminfo"
 	"github.com/gdamore/tcell/v2/terminfo/dynamic"
-	log "github.com/sirupsen/logrus"
 )
 
 //======================================================================
@@ -139,6 +140,45 @@ type Options struct {
 	EnableBracketedPaste    bool
 	KeyPressToEndScrollMode bool // set to true to enable legacy behavior - when the user has scrolled
 	// back to the prompt, still require a keypress (q or Q) to end scroll-mode.
+	SearchStyle gowid.ICellStyler // used to highlight search matches; black on yellow if nil
+	Logger      gowid.ILogger     // receives diagnostics; if nil, the app's logger is used
+	// Term is the TERM value advertised to the command, and names the terminfo entry used
+	// to encode input for it. If empty, TERM is taken from Env, or xterm is used.
+	Term string
+	// Answerback is the reply to ENQ (^E). If empty, ENQ is ignored, as by xterm.
+	Answerback string
+	// DeviceAttributes is the reply to a primary device attributes request (CSI c). If
+	// empty, DefaultDeviceAttributes is used.
+	DeviceAttributes string
+	// Links enables hyperlinks - those set by the program with OSC 8, and URLs found in
+	// the text. The link under the mouse is underlined, and clicking it runs the
+	// OnLinkClicked callbacks. If the program is using the mouse, hold ctrl to click.
+	Links           bool
+	URLPattern      *regexp.Regexp // finds URLs in the text; DefaultURLPattern if nil
+	OpenLinkCommand []string       // run with the URL appended when a link is clicked, e.g. xdg-open
+	// Reflow rewraps the screen and scrollback when the terminal's width changes, so lines
+	// aren't cut short by making the terminal narrower - see Canvas.SetReflow.
+	Reflow bool
+	// Palette themes the terminal - the program can change it with OSC 4, 10 and 11.
+	Palette *Palette
+	// MaxFrameRate limits how many times per second the program's output is shown, so a
+	// program writing heavily doesn't starve the rest of the UI; DefaultMaxFrameRate if 0.
+	// MaxPendingOutput is the number of bytes read but not yet shown after which reading
+	// pauses, so the program waits for the terminal; DefaultMaxPendingOutput if 0.
+	MaxFrameRate     int
+	MaxPendingOutput int
+}
+
+// DefaultDeviceAttributes is the reply to a device attributes request if none is
+// configured. It identifies the terminal as a vt102, like urwid's vterm.py.
+const DefaultDeviceAttributes = "\033[?6c"
+
+// IAnswerback is implemented by terminals that choose how the canvas replies to the
+// program's identification requests. The canvas uses the defaults if its terminal
+// doesn't implement it.
+type IAnswerback interface {
+	Answerback() string
+	DeviceAttributes() string
 }
 
 // Widget is a widget that hosts a terminal-based application. The user provides the
@@ -167,6 +207,12 @@ type Widget struct {
 	cols                *columns.Widget // used if scrollbar is enabled
 	sbar                *vscroll.Widget // used if scrollbar is enabled
 	scrollbarTmpOff     bool            // a simple hack to help with UserInput and Render
+	search              *regexp.Regexp
+	matchRows           []int
+	appLogger           gowid.ILogger   // the logger of the app the terminal last rendered in
+	hoverPos            gowid.CanvasPos // the position of the mouse, if hovering, for links
+	hovering            bool
+	pressedLink         *Link // the link the left button was pressed on, if any
 	Callbacks           *gowid.Callbacks
 	gowid.IsSelectable
 }
@@ -182,11 +228,13 @@ func NewExt(opts Options) (*Widget, error) {
 	var err error
 	var ti *terminfo.Terminfo
 
-	var term string
-	for _, s := range opts.Env {
-		if strings.HasPrefix(s, "TERM=") {
-			term = s[len("TERM="):]
-			break
+	term := opts.Term
+	if term == "" {
+		for _, s := range opts.Env {
+			if strings.HasPrefix(s, "TERM=") {
+				term = s[len("TERM="):]
+				break
+			}
 		}
 	}
 
@@ -215,6 +263,14 @@ func NewExt(opts Options) (*Widget, error) {
 		opts.Scrollbar = false
 	}
 
+	if opts.SearchStyle == nil {
+		opts.SearchStyle = gowid.MakePaletteEntry(gowid.ColorBlack, gowid.ColorYellow)
+	}
+
+	if opts.DeviceAttributes == "" {
+		opts.DeviceAttributes = DefaultDeviceAttributes
+	}
+
 	var persistence IHotKeyPersistence
 	if opts.HotKeyPersistence != nil {
 		persistence = opts.HotKeyPersistence
@@ -258,6 +314,8 @@ func NewExt(opts Options) (*Widget, error) {
 	var _ IWidget = res
 	var _ IHotKeyFunctions = res
 	var _ IScrollbar = res
+	var _ IAnswerback = res
+	var _ gowid.IHoverTarget = res
 	var _ io.Writer = res
 
 	return res, nil
@@ -279,6 +337,14 @@ func (w *Widget) Terminfo() *terminfo.Terminfo {
 	return w.terminfo
 }
 
+func (w *Widget) Answerback() string {
+	return w.params.Answerback
+}
+
+func (w *Widget) DeviceAttributes() string {
+	return w.params.DeviceAttributes
+}
+
 func (w *Widget) ScrollbarEnabled() bool {
 	return w.params.Scrollbar
 }
@@ -412,6 +478,48 @@ func (w *Widget) ResetScroll() {
 	w.canvas.ScrollBuffer(false, true, gwutil.NoneInt())
 }
 
+// SetSearch highlights the matches of re in the terminal's scrollback and screen, and
+// returns how many lines contain one; a nil re clears the search. It, with ShowMatch,
+// lets the terminal be searched with the search package's bar.
+func (w *Widget) SetSearch(re *regexp.Regexp, app gowid.IApp) int {
+	w.search = re
+	w.matchRows = nil
+	if re == nil || w.canvas == nil {
+		return 0
+	}
+	buf := w.canvas.ViewPortCanvas.Canvas
+	for y := 0; y < buf.BoxRows(); y++ {
+		var line strings.Builder
+		for x := 0; x < buf.BoxColumns(); {
+			c := buf.CellAt(x, y)
+			line.WriteString(c.Grapheme())
+			x += gwutil.Max(c.Width(), 1)
+		}
+		if re.MatchString(line.String()) {
+			w.matchRows = append(w.matchRows, y)
+		}
+	}
+	return len(w.matchRows)
+}
+
+// ShowMatch scrolls the terminal, if necessary, so that the ith line holding a match is
+// on screen.
+func (w *Widget) ShowMatch(i int, app gowid.IApp) {
+	if i < 0 || i >= len(w.matchRows) || w.canvas == nil {
+		return
+	}
+	row := w.matchRows[i]
+	height := w.canvas.BoxRows()
+	if row >= w.canvas.Offset && row < w.canvas.Offset+height {
+		return
+	}
+	if lines := w.canvas.Offset - (row - height/2); lines > 0 {
+		w.Scroll(ScrollUp, false, lines)
+	} else {
+		w.Scroll(ScrollDown, false, -lines)
+	}
+}
+
 func (w *Widget) Width() int {
 	return w.curWidth
 }
@@ -448,9 +556,20 @@ func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.S
 		w.cols.SetFocus(app, 0)
 		return res
 	}
+	if ev2, ok := ev.(*tcell.EventMouse); ok && w.params.Links {
+		if w.linkInput(ev2, app) {
+			return true
+		}
+	}
 	return UserInput(w, ev, size, focus, app)
 }
 
+// ConcurrentSafe returns false, so the terminal is always rendered on the app's
+// goroutine - rendering it can start the command and resize its terminal.
+func (w *Widget) ConcurrentSafe() bool {
+	return false
+}
+
 func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
 	box, ok := size.(gowid.IRenderBox)
 	if !ok {
@@ -470,7 +589,43 @@ func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.
 	w.sbar.Middle = w.canvas.scrollRegionEnd
 	w.sbar.Bottom = gwutil.Max(0, w.canvas.ViewPortCanvas.Canvas.BoxRows()-(box.BoxRows()+w.canvas.Offset))
 
-	return w.canvas
+	res := ApproximateLineAttrs(w.canvas)
+
+	if pal := w.canvas.Palette(); !pal.IsEmpty() {
+		// Map the colors before highlighting, so the search style isn't themed
+		if res == gowid.ICanvas(w.canvas) {
+			res = w.canvas.View()
+		}
+		pal.Apply(res, app.GetColorMode())
+	}
+
+	if w.search != nil {
+		// Highlight a copy - the canvas holds the terminal's state
+		if res == gowid.ICanvas(w.canvas) {
+			res = w.canvas.Duplicate()
+		}
+		highlight.Apply(res, []highlight.Pattern{{Regexp: w.search, Style: w.params.SearchStyle}}, false, app)
+	}
+
+	if w.params.Links {
+		// Underline and track a copy, so the terminal's state and marks aren't changed
+		if res == gowid.ICanvas(w.canvas) {
+			res = w.canvas.View()
+		}
+		w.underlineLink(res)
+		gowid.TrackGeometry(w, res, app)
+	}
+
+	// Pass the cursor shape the program asked for on to the real terminal. Mark a copy, so
+	// the mark doesn't outlive this frame.
+	if focus.Focus && res.CursorEnabled() && w.modes.CursorStyle != tcell.CursorStyleDefault {
+		if res == gowid.ICanvas(w.canvas) {
+			res = w.canvas.View()
+		}
+		gowid.TrackCursorStyle(w.modes.CursorStyle, res, app)
+	}
+
+	return res
 }
 
 func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
@@ -527,11 +682,29 @@ func (e StartCommandError) Unwrap() error {
 	return e.Err
 }
 
+// Logger returns the logger that receives the terminal's diagnostics - Options.Logger
+// if set, otherwise the logger of the app the terminal was last rendered in.
+func (w *Widget) Logger() gowid.ILogger {
+	if w.params.Logger != nil {
+		return w.params.Logger
+	}
+	if w.appLogger != nil {
+		return w.appLogger
+	}
+	return gowid.DefaultLogger
+}
+
 func (w *Widget) TouchTerminal(width, height int, app gowid.IApp) {
 	setTermSize := false
+	w.appLogger = gowid.LoggerOf(app)
 
 	if w.Canvas() == nil {
-		w.SetCanvas(app, NewCanvasOfSize(width, height, w.params.Scrollback, w))
+		canvas := NewCanvasOfSize(width, height, w.params.Scrollback, w)
+		canvas.SetReflow(w.params.Reflow)
+		if w.params.Palette != nil {
+			canvas.SetPalette(*w.params.Palette)
+		}
+		w.SetCanvas(app, canvas)
 	}
 	if !w.Connected() {
 		err := w.StartCommand(app, width, height) // TODO check for errors
@@ -545,11 +718,10 @@ func (w *Widget) TouchTerminal(width, height int, app gowid.IApp) {
 		if !setTermSize {
 			err := w.SetTerminalSize(width, height)
 			if err != nil {
-				log.WithFields(log.Fields{
-					"width":  width,
-					"height": height,
-					"error":  err,
-				}).Warn("Could not set terminal size")
+				w.Logger().Log(gowid.LogWarn, "Could not set terminal size",
+					gowid.LogField{Name: "width", Val: width},
+					gowid.LogField{Name: "height", Val: height},
+					gowid.LogField{Name: "error", Val: err})
 			}
 		}
 
@@ -575,6 +747,7 @@ func (w *Widget) RequestTerminate() error {
 
 func (w *Widget) StartCommand(app gowid.IApp, width, height int) error {
 	w.Cmd = exec.Command(w.params.Command[0], w.params.Command[1:]...)
+	w.Cmd.Env = commandEnv(w.params.Env, w.params.Term)
 	var err error
 	var tty *os.File
 	w.master, tty, err = PtyStart1(w.Cmd)
@@ -585,11 +758,10 @@ func (w *Widget) StartCommand(app gowid.IApp, width, height int) error {
 
 	err = w.SetTerminalSize(width, height)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"width":  width,
-			"height": height,
-			"error":  err,
-		}).Warn("Could not set terminal size")
+		w.Logger().Log(gowid.LogWarn, "Could not set terminal size",
+			gowid.LogField{Name: "width", Val: width},
+			gowid.LogField{Name: "height", Val: height},
+			gowid.LogField{Name: "error", Val: err})
 	}
 
 	err = w.Cmd.Start()
@@ -644,42 +816,45 @@ func (w *Widget) StartCommand(app gowid.IApp, width, height int) error {
 		})
 	}
 
+	// Process the program's output on the app's goroutine, a frame's worth at a time, so
+	// heavy output doesn't redraw the app for every read
+	var output *outputBuffer
+	process := func(app gowid.IApp) bool {
+		render := false
+		for _, b := range output.Take() {
+			if canvas.ProcessByteExt(b) {
+				render = true
+			}
+		}
+		return render
+	}
+	output = newOutputBuffer(w.params.MaxFrameRate, w.params.MaxPendingOutput, func() {
+		if app.Run(&appRunExt{fn: process}) != nil {
+			output.Close()
+		}
+	})
+
 	go func() {
+		data := make([]byte, 4096)
 		for {
-			data := make([]byte, 4096)
 			n, err := master.Read(data)
-			if n == 0 && err == io.EOF {
-				w.Cmd.Wait()
-				app.Run(&appRunExt{
-					fn: func(app gowid.IApp) bool {
-						gowid.RunWidgetCallbacks(w.Callbacks, ProcessExited{}, app, w)
-						return false
-					},
-				})
-
-				break
-			} else if err != nil {
+			if n > 0 {
+				output.Write(data[0:n])
+			}
+			if err != nil {
 				w.Cmd.Wait()
-				app.Run(&appRunExt{
+				// Show what's left of the output before reporting the exit
+				if app.Run(&appRunExt{
 					fn: func(app gowid.IApp) bool {
+						render := process(app)
 						gowid.RunWidgetCallbacks(w.Callbacks, ProcessExited{}, app, w)
-						return false
+						return render
 					},
-				})
+				}) != nil {
+					output.Close()
+				}
 				break
 			}
-
-			app.Run(&appRunExt{
-				fn: func(app gowid.IApp) bool {
-					render := false
-					for _, b := range data[0:n] {
-						if canvas.ProcessByteExt(b) {
-							render = true
-						}
-					}
-					return render
-				},
-			})
 		}
 	}()
 
@@ -822,7 +997,7 @@ func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Se
 		if parsed {
 			_, err := w.Write(seq)
 			if err != nil {
-				log.WithField("error", err).Warn("Could not send all input to terminal")
+				gowid.Log(w, gowid.LogWarn, "Could not send all input to terminal", gowid.LogField{Name: "error", Val: err})
 			}
 			res = true
 		}
@@ -831,6 +1006,24 @@ func UserInput(w IWidget, ev interface{}, size gowid.IRenderSize, focus gowid.Se
 	return res
 }
 
+// commandEnv returns the environment for the terminal's command - env, or the app's own
+// environment if env is nil, with TERM set to term if it isn't empty.
+func commandEnv(env []string, term string) []string {
+	if term == "" {
+		return env
+	}
+	if env == nil {
+		env = os.Environ()
+	}
+	res := make([]string, 0, len(env)+1)
+	for _, s := range env {
+		if !strings.HasPrefix(s, "TERM=") {
+			res = append(res, s)
+		}
+	}
+	return append(res, "TERM="+term)
+}
+
 // PtyStart1 connects the supplied Cmd's stdin/stdout/stderr to a new tty
 // object. The function returns the pty and tty, and also an error which is
 // nil if the operation was successful.
@@ -9,8 +9,10 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
 	"github.com/gcla/gowid/gwutil"
 	tcell "github.com/gdamore/tcell/v2"
 	"github.com/gdamore/tcell/v2/terminfo"
@@ -541,6 +543,63 @@ func TestEncoded2(t *testing.T) {
 	AssertTermPositionIs(2, 1, c, t)
 }
 
+func TestSgrStyles1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(4, 1, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("\033[3;9ma\033[23mb\033[21mc\033[24;29md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd", c.String())
+
+	assert.Equal(t, gowid.StyleItalic.MergeUnder(gowid.StyleStrikethrough), c.CellAt(0, 0).Style())
+	assert.Equal(t, gowid.StyleStrikethrough, c.CellAt(1, 0).Style())
+	assert.Equal(t, gowid.StyleStrikethrough.MergeUnder(gowid.StyleDoubleUnderline), c.CellAt(2, 0).Style())
+	assert.Equal(t, gowid.StyleNone, c.CellAt(3, 0).Style())
+}
+
+func TestSgrUnderline1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(4, 1, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("\033[4:3;58;5;1ma\033[59mb\033[58:2::255:0:0;4:0mc\033[4md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd", c.String())
+
+	red := gowid.MakeTCellColorExt(tcell.Color(1) + tcell.ColorValid)
+	assert.Equal(t, gowid.StyleCurlyUnderline, c.CellAt(0, 0).Style())
+	assert.Equal(t, red, c.CellAt(0, 0).UnderlineColor())
+	assert.Equal(t, gowid.ColorNone, c.CellAt(1, 0).UnderlineColor())
+	assert.Equal(t, gowid.StyleNone, c.CellAt(2, 0).Style())
+	assert.Equal(t, gowid.StyleUnderline, c.CellAt(3, 0).Style())
+	assert.NotEqual(t, gowid.ColorNone, c.CellAt(3, 0).UnderlineColor())
+}
+
+func TestHyperlink1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(4, 1, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("a\033]8;id=x;http://a.b\033\\bc\033]8;;\007d"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd", c.String())
+
+	_, ok := c.CellAt(0, 0).Hyperlink()
+	assert.False(t, ok)
+	link, ok := c.CellAt(2, 0).Hyperlink()
+	assert.True(t, ok)
+	assert.Equal(t, gowid.Hyperlink{URL: "http://a.b", ID: "x"}, link)
+	_, ok = c.CellAt(3, 0).Hyperlink()
+	assert.False(t, ok)
+}
+
+func TestCombining1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(4, 1, 100, &f)
+	f.Modes().Charset = CharsetUTF8
+	_, err := io.Copy(c, strings.NewReader("e\u0301\u4e2d\u0308x"))
+	assert.NoError(t, err)
+	assert.Equal(t, []rune{'\u0301'}, c.CellAt(0, 0).Combining())
+	assert.Equal(t, []rune{'\u0308'}, c.CellAt(1, 0).Combining())
+	assert.Equal(t, 'x', c.CellAt(3, 0).Rune())
+	AssertTermPositionIs(3, 0, c, t)
+}
+
 func TestPrivacy1(t *testing.T) {
 	f := FakeTerminal{modes: &Modes{}}
 	c := NewCanvasOfSize(8, 2, 100, &f)
@@ -857,6 +916,334 @@ func TestCanvasVttest1(t *testing.T) {
 	AssertTermPositionIs(76, 3, c, t)
 }
 
+func TestLineAttrs1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(6, 4, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("\033#3BIG\r\n\033#4BIG\r\n\033#6wide\r\nplain"))
+	assert.NoError(t, err)
+	assert.Equal(t, LineDoubleHeightTop, c.LineAttr(0))
+	assert.Equal(t, LineDoubleHeightBottom, c.LineAttr(1))
+	assert.Equal(t, LineDoubleWidth, c.LineAttr(2))
+	assert.Equal(t, LineSingle, c.LineAttr(3))
+	assert.True(t, c.HasLineAttrs())
+
+	a := ApproximateLineAttrs(c)
+	assert.Equal(t, strings.Join([]string{"B I G ", "      ", "w i d ", "plain "}, "\n"), a.String())
+	assert.Equal(t, gowid.StyleBold, a.CellAt(0, 0).Style())
+	// The terminal's own state is unchanged
+	assert.Equal(t, strings.Join([]string{"BIG   ", "BIG   ", "wide  ", "plain "}, "\n"), c.String())
+
+	// Attributes move with their lines as the terminal scrolls
+	_, err = io.Copy(c, strings.NewReader("\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, LineDoubleHeightBottom, c.LineAttr(0))
+	assert.Equal(t, LineDoubleWidth, c.LineAttr(1))
+	assert.Equal(t, LineSingle, c.LineAttr(3))
+
+	_, err = io.Copy(c, strings.NewReader("\033[2;1H\033#5"))
+	assert.NoError(t, err)
+	assert.Equal(t, LineSingle, c.LineAttr(1))
+
+	// Clearing the screen returns lines to single size
+	_, err = io.Copy(c, strings.NewReader("\033[2J"))
+	assert.NoError(t, err)
+	assert.False(t, c.HasLineAttrs())
+	assert.Equal(t, gowid.ICanvas(c), ApproximateLineAttrs(c))
+}
+
+// replyTerminal records what the canvas writes back to the program.
+type replyTerminal struct {
+	FakeTerminal
+	answerback, attrs string
+	written           []byte
+}
+
+func (f *replyTerminal) Write(b []byte) (int, error) {
+	f.written = append(f.written, b...)
+	return len(b), nil
+}
+
+func (f *replyTerminal) Answerback() string {
+	return f.answerback
+}
+
+func (f *replyTerminal) DeviceAttributes() string {
+	return f.attrs
+}
+
+func TestAnswerback1(t *testing.T) {
+	f := replyTerminal{FakeTerminal: FakeTerminal{modes: &Modes{}}}
+	c := NewCanvasOfSize(4, 1, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("\005\033[c"))
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDeviceAttributes, string(f.written))
+
+	f.written = nil
+	f.answerback = "gowid"
+	f.attrs = "\033[?62;22c"
+	_, err = io.Copy(c, strings.NewReader("\005\033[0c\033[?1;2c"))
+	assert.NoError(t, err)
+	assert.Equal(t, "gowid\033[?62;22c", string(f.written))
+	assert.Equal(t, "    ", c.String())
+
+	assert.Equal(t, []string{"A=1", "TERM=vt220"}, commandEnv([]string{"TERM=xterm", "A=1"}, "vt220"))
+	assert.Equal(t, []string{"A=1"}, commandEnv([]string{"A=1"}, ""))
+}
+
+func TestCursorStyle1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(4, 1, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("\033[5 qab"))
+	assert.NoError(t, err)
+	assert.Equal(t, tcell.CursorStyleBlinkingBar, f.modes.CursorStyle)
+	assert.Equal(t, "ab  ", c.String())
+
+	_, err = io.Copy(c, strings.NewReader("\033[ q"))
+	assert.NoError(t, err)
+	assert.Equal(t, tcell.CursorStyleDefault, f.modes.CursorStyle)
+
+	// Out of range requests are ignored
+	_, err = io.Copy(c, strings.NewReader("\033[4 q\033[9 q"))
+	assert.NoError(t, err)
+	assert.Equal(t, tcell.CursorStyleSteadyUnderline, f.modes.CursorStyle)
+	assert.Equal(t, "ab  ", c.String())
+
+	v := c.View()
+	v.SetCellAt(0, 0, gowid.CellFromRune('x'))
+	assert.Equal(t, "xb  ", v.String())
+	assert.Equal(t, "ab  ", c.String())
+}
+
+func TestLinks1(t *testing.T) {
+	w, err := NewExt(Options{Links: true})
+	assert.NoError(t, err)
+	w.canvas = NewCanvasOfSize(30, 2, 100, w)
+	_, err = io.Copy(w.canvas, strings.NewReader("see (http://a.b/c). \033]8;;http://x.y\033\\link\033]8;;\033\\"))
+	assert.NoError(t, err)
+
+	_, ok := w.LinkAt(3, 0)
+	assert.False(t, ok)
+	l, ok := w.LinkAt(6, 0)
+	assert.True(t, ok)
+	assert.Equal(t, Link{URL: "http://a.b/c", Row: 0, Start: 5, End: 17}, l)
+	l, ok = w.LinkAt(21, 0)
+	assert.True(t, ok)
+	assert.Equal(t, Link{URL: "http://x.y", Row: 0, Start: 20, End: 24}, l)
+
+	var opened []string
+	w.OnLinkClicked(gowid.MakeWidgetCallbackExt("test", func(app gowid.IApp, w gowid.IWidget, data ...interface{}) {
+		opened = append(opened, data[0].(string))
+	}))
+
+	defer gwtest.D.SetLastMouseState(gowid.MouseState{})
+	click := func(x int) {
+		gwtest.D.SetLastMouseState(gowid.MouseState{})
+		assert.True(t, w.UserInput(tcell.NewEventMouse(x, 0, tcell.Button1, 0), gowid.RenderBox{C: 30, R: 2}, gowid.Focused, gwtest.D))
+		gwtest.D.SetLastMouseState(gowid.MouseState{MouseLeftClicked: true})
+		assert.True(t, w.UserInput(tcell.NewEventMouse(x, 0, tcell.ButtonNone, 0), gowid.RenderBox{C: 30, R: 2}, gowid.Focused, gwtest.D))
+	}
+	click(22)
+	click(5)
+	assert.Equal(t, []string{"http://x.y", "http://a.b/c"}, opened)
+
+	// The link under the mouse is underlined
+	c := w.canvas.View()
+	w.underlineLink(c)
+	assert.Equal(t, gowid.StyleUnderline, c.CellAt(5, 0).Style())
+	assert.NotEqual(t, gowid.StyleUnderline, c.CellAt(20, 0).Style())
+	assert.NotEqual(t, gowid.StyleUnderline, w.canvas.CellAt(5, 0).Style())
+	w.MouseLeave(gwtest.D)
+	_, ok = w.HoveredLink()
+	assert.False(t, ok)
+}
+
+func TestReflow1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(6, 3, 100, &f)
+	c.SetReflow(true)
+	_, err := io.Copy(c, strings.NewReader("abcdefghij\r\nxy"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{"abcdef", "ghij  ", "xy    "}, "\n"), c.String())
+	assert.True(t, c.Wrapped(0))
+	assert.False(t, c.Wrapped(1))
+
+	// Narrower - the first row scrolls back
+	c.Resize(4, 3)
+	assert.Equal(t, strings.Join([]string{"efgh", "ij  ", "xy  "}, "\n"), c.String())
+	assert.Equal(t, 1, c.Offset)
+	x, y := c.TermCursor()
+	assert.Equal(t, 2, x)
+	assert.Equal(t, 2, y)
+
+	// And back again, with nothing lost
+	c.Resize(6, 3)
+	assert.Equal(t, strings.Join([]string{"abcdef", "ghij  ", "xy    "}, "\n"), c.String())
+	_, err = io.Copy(c, strings.NewReader("z"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{"abcdef", "ghij  ", "xyz   "}, "\n"), c.String())
+
+	// Lines erased to their end no longer continue on the next
+	_, err = io.Copy(c, strings.NewReader("\033[1;1H\033[K"))
+	assert.NoError(t, err)
+	assert.False(t, c.Wrapped(0))
+	c.Resize(8, 3)
+	assert.Equal(t, strings.Join([]string{"        ", "ghij    ", "xyz     "}, "\n"), c.String())
+
+	// Without reflow, lines are cut
+	c.SetReflow(false)
+	c.Resize(2, 3)
+	c.Resize(8, 3)
+	assert.Equal(t, strings.Join([]string{"        ", "gh      ", "xy      "}, "\n"), c.String())
+}
+
+func TestCSIRepeat1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(6, 2, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("ab\033[3bc\033[b"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{"abbbbc", "c     "}, "\n"), c.String())
+	AssertTermPositionIs(1, 1, c, t)
+}
+
+func TestCSITabs1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(20, 1, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("\033[2Ia"))
+	assert.NoError(t, err)
+	AssertTermPositionIs(17, 0, c, t)
+	_, err = io.Copy(c, strings.NewReader("\033[2Zb\033[5Zc"))
+	assert.NoError(t, err)
+	assert.Equal(t, "c       b       a   ", c.String())
+	AssertTermPositionIs(1, 0, c, t)
+}
+
+func TestCSIScroll1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(2, 4, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("a\r\nb\r\nc\r\nd\033[2;3r"))
+	assert.NoError(t, err)
+	// Setting the region homes the cursor
+	AssertTermPositionIs(0, 0, c, t)
+	_, err = io.Copy(c, strings.NewReader("\033[4;2H\033[S"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{"a ", "c ", "  ", "d "}, "\n"), c.String())
+	AssertTermPositionIs(1, 3, c, t)
+	_, err = io.Copy(c, strings.NewReader("\033[2T"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{"a ", "  ", "  ", "d "}, "\n"), c.String())
+
+	// In origin mode, home is the top of the region
+	_, err = io.Copy(c, strings.NewReader("\033[?6h\033[2;4r"))
+	assert.NoError(t, err)
+	AssertTermPositionIs(0, 1, c, t)
+	// A private mode restore isn't a region
+	_, err = io.Copy(c, strings.NewReader("\033[?1r"))
+	assert.NoError(t, err)
+	AssertTermPositionIs(0, 1, c, t)
+}
+
+func TestAltScreen1(t *testing.T) {
+	f := FakeTerminal{modes: &Modes{}}
+	c := NewCanvasOfSize(3, 2, 100, &f)
+	_, err := io.Copy(c, strings.NewReader("ab\033[?1049hxyz\033[1;1Hq"))
+	assert.NoError(t, err)
+	// The alternate screen starts clear, with the cursor where it was
+	assert.Equal(t, strings.Join([]string{"q x", "yz "}, "\n"), c.String())
+	_, err = io.Copy(c, strings.NewReader("\033[?1049l"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{"ab ", "   "}, "\n"), c.String())
+	AssertTermPositionIs(2, 0, c, t)
+}
+
+func TestPalette1(t *testing.T) {
+	f := replyTerminal{FakeTerminal: FakeTerminal{modes: &Modes{}}}
+	c := NewCanvasOfSize(4, 1, 100, &f)
+	red := gowid.MakeRGBColorExt(0xff, 0, 0)
+	c.SetPalette(Palette{Foreground: red})
+
+	// Set color 1, query it and the default foreground and background
+	_, err := io.Copy(c, strings.NewReader("\033]4;1;rgb:00/80/ff\007\033]4;1;?\007\033]10;?;?\033\\"))
+	assert.NoError(t, err)
+	assert.Equal(t, gowid.MakeRGBColorExt(0, 0x80, 0xff), c.Palette().Colors[1])
+	assert.Equal(t, "\033]4;1;rgb:0000/8080/ffff\033\\\033]10;rgb:ffff/0000/0000\033\\\033]11;rgb:0000/0000/0000\033\\",
+		string(f.written))
+
+	// Cells using color 1 and the default colors are themed
+	_, err = io.Copy(c, strings.NewReader("\033[31ma\033[32mb\033[39;49m"))
+	assert.NoError(t, err)
+	v := c.View()
+	pal := c.Palette()
+	pal.Apply(v, gowid.Mode24BitColors)
+	assert.Equal(t, gowid.MakeTCellColorExt(tcell.NewRGBColor(0, 0x80, 0xff)), v.CellAt(0, 0).ForegroundColor())
+	assert.Equal(t, gowid.MakeTCellColorExt(tcell.ColorGreen), v.CellAt(1, 0).ForegroundColor())
+	assert.Equal(t, gowid.MakeTCellColorExt(tcell.NewRGBColor(0xff, 0, 0)), v.CellAt(2, 0).ForegroundColor())
+
+	// Reset the color, and a terminal reset restores the configured palette
+	_, err = io.Copy(c, strings.NewReader("\033]104;1\007\033]11;#00f\007"))
+	assert.NoError(t, err)
+	assert.Nil(t, c.Palette().Colors[1])
+	assert.Equal(t, gowid.MakeRGBColorExt(0, 0, 0xf0), c.Palette().Background)
+	_, err = io.Copy(c, strings.NewReader("\033c"))
+	assert.NoError(t, err)
+	assert.Equal(t, Palette{Foreground: red}, c.Palette())
+
+	col, ok := ParseColorSpec("rgb:f/8/0")
+	assert.True(t, ok)
+	assert.Equal(t, gowid.MakeRGBColorExt(0xff, 0x88, 0), col)
+	_, ok = ParseColorSpec("red")
+	assert.False(t, ok)
+}
+
+func TestOutputBuffer1(t *testing.T) {
+	flushes := make(chan struct{}, 10)
+	b := newOutputBuffer(1000, 4, func() { flushes <- struct{}{} })
+
+	// Writes before the output is taken are coalesced into one flush
+	b.Write([]byte("ab"))
+	b.Write([]byte("cd"))
+	<-flushes
+	assert.Equal(t, 0, len(flushes))
+
+	// The buffer is full, so the next write waits for the output to be taken
+	written := make(chan struct{})
+	go func() {
+		b.Write([]byte("ef"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		assert.Fail(t, "write should block while the buffer is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, "abcd", string(b.Take()))
+	<-written
+	<-flushes
+	assert.Equal(t, "ef", string(b.Take()))
+
+	b.Close()
+	b.Write([]byte("ghijkl"))
+	assert.Equal(t, "", string(b.Take()))
+}
+
+func TestViewPortBulk1(t *testing.T) {
+	lines := make([][]gowid.Cell, 4)
+	for i, s := range []string{"aaa", "bbb", "ccc", "ddd"} {
+		lines[i] = gowid.CellsFromString(s)
+	}
+	// The bottom two rows are visible
+	v := NewViewPort(gowid.NewCanvasWithLines(lines), 2, 2)
+
+	v.FillRect(gowid.Rect{X: 1, Y: -1, W: 1, H: 5}, gowid.CellFromRune('.'))
+	assert.Equal(t, "aaa\nbbb\nc.c\nd.d", v.Canvas.String())
+
+	v.SetLineRange(0, -1, gowid.CellsFromString("x"))
+	v.SetLineRange(2, 1, gowid.CellsFromString("y"))
+	assert.Equal(t, "aaa\nbbb\nc.c\nd.y", v.Canvas.String())
+
+	v.CopyRows(v, 0, 0, 1, 2)
+	assert.Equal(t, "aaa\nbbb\nc.c\nc.c", v.Canvas.String())
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
@@ -11,7 +11,6 @@ import (
 	"github.com/gcla/gowid"
 	tcell "github.com/gdamore/tcell/v2"
 	"github.com/gdamore/tcell/v2/terminfo"
-	log "github.com/sirupsen/logrus"
 )
 
 //======================================================================
@@ -332,7 +331,8 @@ func TCellEventToBytes(ev interface{}, mouse IMouseSupport, last gowid.MouseStat
 			}
 		}
 	default:
-		log.WithField("event", ev).Info("Event not implemented")
+		// The paster is the terminal widget, which provides a logger
+		gowid.Log(paster, gowid.LogInfo, "Event not implemented", gowid.LogField{Name: "event", Val: ev})
 	}
 	return res, res2
 }
@@ -0,0 +1,236 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package text
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/pkg/errors"
+)
+
+//======================================================================
+
+// MarkupError is returned when a markup string can't be parsed. Pos is the byte offset
+// of the offending tag.
+type MarkupError struct {
+	Pos int
+	Msg string
+}
+
+var _ error = MarkupError{}
+
+func (e MarkupError) Error() string {
+	return fmt.Sprintf("Invalid markup at offset %d: %s", e.Pos, e.Msg)
+}
+
+//======================================================================
+
+// NewFromMarkup initializes a text widget from a string containing inline style tags.
+// See ParseMarkup for the syntax.
+func NewFromMarkup(markup string, opts ...Options) (*Widget, error) {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	content, err := NewContentFromMarkup(markup)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromContentExt(content, opt), nil
+}
+
+// NewContentFromMarkup builds Content from a string containing inline style tags.
+// See ParseMarkup for the syntax.
+func NewContentFromMarkup(markup string) (*Content, error) {
+	segs, err := ParseMarkup(markup)
+	if err != nil {
+		return nil, err
+	}
+	return NewContent(segs), nil
+}
+
+// NewContentWithAccelerator builds Content from a label that marks a keyboard
+// accelerator with an ampersand, e.g. "&File" - see gowid.ParseAccelerator. The marked
+// character is underlined. The accelerator key is returned too, or 0 if there isn't one.
+func NewContentWithAccelerator(label string) (*Content, rune) {
+	plain, pos, key := gowid.ParseAccelerator(label)
+	if pos == -1 {
+		return NewContent([]ContentSegment{StringContent(plain)}), 0
+	}
+	runes := []rune(plain)
+	segs := make([]ContentSegment, 0, 3)
+	if pos > 0 {
+		segs = append(segs, StringContent(string(runes[:pos])))
+	}
+	segs = append(segs, StyledContent(string(runes[pos]), gowid.MakeStyledAs(gowid.StyleUnderline)))
+	if pos+1 < len(runes) {
+		segs = append(segs, StringContent(string(runes[pos+1:])))
+	}
+	return NewContent(segs), key
+}
+
+// ParseMarkup turns a string containing inline style tags into ContentSegments. A tag
+// applies to the text that follows it until it's closed with "[/]"; tags can be nested,
+// and unclosed tags apply to the end of the string. The forms of tag are:
+//
+//	[fg]            foreground color e.g. [red] or [#f00]
+//	[fg:bg]         foreground and background colors; either may be empty e.g. [:blue]
+//	[fg:bg:flags]   colors and styles - b(old), d(im), i(talic), l (blink), r(everse),
+//	                s(trikethrough), u(nderline) e.g. [::bu]
+//	[@name]         the palette entry name
+//
+// Colors are parsed with gowid.MakeColorSafe. Anything a tag doesn't specify is
+// inherited from the enclosing tag. Write "[[" for a literal "[".
+func ParseMarkup(markup string) ([]ContentSegment, error) {
+	res := make([]ContentSegment, 0, 8)
+	stack := make([]gowid.ICellStyler, 0, 8)
+	var cur gowid.ICellStyler
+	var buf strings.Builder
+
+	flush := func() {
+		if buf.Len() > 0 {
+			res = append(res, ContentSegment{cur, buf.String()})
+			buf.Reset()
+		}
+	}
+
+	for i := 0; i < len(markup); {
+		if markup[i] != '[' {
+			buf.WriteByte(markup[i])
+			i++
+			continue
+		}
+		if strings.HasPrefix(markup[i:], "[[") {
+			buf.WriteByte('[')
+			i += 2
+			continue
+		}
+		end := strings.IndexByte(markup[i:], ']')
+		if end == -1 {
+			return nil, errors.WithStack(MarkupError{Pos: i, Msg: "unterminated tag"})
+		}
+		tag := markup[i+1 : i+end]
+		flush()
+		if tag == "/" {
+			if len(stack) == 0 {
+				return nil, errors.WithStack(MarkupError{Pos: i, Msg: "no open tag to close"})
+			}
+			stack = stack[:len(stack)-1]
+			cur = nil
+			if len(stack) > 0 {
+				cur = stack[len(stack)-1]
+			}
+		} else {
+			st, err := parseMarkupTag(tag)
+			if err != nil {
+				return nil, errors.WithStack(MarkupError{Pos: i, Msg: err.Error()})
+			}
+			if cur != nil {
+				st = markupStyle{outer: cur, inner: st}
+			}
+			stack = append(stack, st)
+			cur = st
+		}
+		i += end + 1
+	}
+	flush()
+
+	return res, nil
+}
+
+// EscapeMarkup returns s with any "[" doubled, so that ParseMarkup reproduces s as
+// literal text. This is useful when building markup from untrusted strings, e.g. with
+// text/template.
+func EscapeMarkup(s string) string {
+	return strings.ReplaceAll(s, "[", "[[")
+}
+
+//======================================================================
+
+// markupFlags maps the letters allowed in the third field of a markup tag to text styles.
+var markupFlags = map[rune]gowid.StyleAttrs{
+	'b': gowid.StyleBold,
+	'd': gowid.StyleDim,
+	'i': gowid.StyleItalic,
+	'l': gowid.StyleBlink,
+	'r': gowid.StyleReverse,
+	's': gowid.StyleStrikethrough,
+	'u': gowid.StyleUnderline,
+}
+
+// parseMarkupTag returns the ICellStyler for the tag body, the text between the brackets.
+func parseMarkupTag(tag string) (gowid.ICellStyler, error) {
+	if strings.HasPrefix(tag, "@") {
+		if len(tag) == 1 {
+			return nil, errors.New("missing palette entry name")
+		}
+		return gowid.MakePaletteRef(tag[1:]), nil
+	}
+
+	fields := strings.Split(tag, ":")
+	if len(fields) > 3 {
+		return nil, errors.Errorf("too many fields in %q", tag)
+	}
+	cols := []gowid.IColor{gowid.NoColor{}, gowid.NoColor{}}
+	for i := 0; i < len(fields) && i < 2; i++ {
+		if fields[i] == "" {
+			continue
+		}
+		col, err := gowid.MakeColorSafe(fields[i])
+		if err != nil {
+			return nil, errors.Errorf("invalid color %q", fields[i])
+		}
+		cols[i] = col
+	}
+	style := gowid.StyleNone
+	if len(fields) == 3 {
+		for _, f := range fields[2] {
+			attr, ok := markupFlags[f]
+			if !ok {
+				return nil, errors.Errorf("unknown style %q", f)
+			}
+			style = style.MergeUnder(attr)
+		}
+	}
+	return gowid.MakeStyledPaletteEntry(cols[0], cols[1], style), nil
+}
+
+//======================================================================
+
+// markupStyle is the ICellStyler for a tag nested inside another. Colors and styles
+// from inner take precedence, and anything inner doesn't specify comes from outer.
+type markupStyle struct {
+	outer gowid.ICellStyler
+	inner gowid.ICellStyler
+}
+
+var _ gowid.ICellStyler = markupStyle{}
+
+func (m markupStyle) GetStyle(prov gowid.IRenderContext) (x gowid.IColor, y gowid.IColor, z gowid.StyleAttrs) {
+	x, y, z = m.outer.GetStyle(prov)
+	ifg, ibg, ist := m.inner.GetStyle(prov)
+	if hasColor(ifg, prov) {
+		x = ifg
+	}
+	if hasColor(ibg, prov) {
+		y = ibg
+	}
+	z = z.MergeUnder(ist)
+	return
+}
+
+// hasColor returns true if c expresses a preference for a color in the current color mode.
+func hasColor(c gowid.IColor, prov gowid.IRenderContext) bool {
+	tc, ok := c.ToTCellColor(prov.GetColorMode())
+	return ok && tc != gowid.ColorNone
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
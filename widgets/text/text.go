@@ -14,6 +14,7 @@ import (
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/gwutil"
 	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
 )
 
 //======================================================================
@@ -87,6 +88,80 @@ func StyledContent(text string, style gowid.ICellStyler) ContentSegment {
 	return ContentSegment{style, text}
 }
 
+// LinkContent makes a ContentSegment that renders as a terminal hyperlink to url, in
+// terminals that support them. The style may be nil.
+func LinkContent(text string, url string, style gowid.ICellStyler) ContentSegment {
+	return ContentSegment{Link{Hyperlink: gowid.Hyperlink{URL: url}, Style: style}, text}
+}
+
+// IHyperlinkStyler is implemented by an ICellStyler that also links the cells it styles
+// to a target.
+type IHyperlinkStyler interface {
+	gowid.ICellStyler
+	Link() gowid.Hyperlink
+}
+
+// Link is an ICellStyler that styles with Style, if it's not nil, and makes cells a
+// hyperlink.
+type Link struct {
+	gowid.Hyperlink
+	Style gowid.ICellStyler
+}
+
+var _ IHyperlinkStyler = Link{}
+
+func (l Link) GetStyle(prov gowid.IRenderContext) (x gowid.IColor, y gowid.IColor, z gowid.StyleAttrs) {
+	if l.Style == nil {
+		return gowid.NoColor{}, gowid.NoColor{}, gowid.StyleNone
+	}
+	return l.Style.GetStyle(prov)
+}
+
+func (l Link) Link() gowid.Hyperlink {
+	return l.Hyperlink
+}
+
+// AlignedContent makes a ContentSegment that sets the alignment of the paragraph in which
+// it appears, overriding the alignment of the text widget. The style may be nil.
+func AlignedContent(text string, align gowid.IHAlignment, style gowid.ICellStyler) ContentSegment {
+	return ContentSegment{Aligned{Align: align, Style: style}, text}
+}
+
+// IAlignedStyler is implemented by an ICellStyler that also determines the alignment of
+// the paragraph containing the cells it styles.
+type IAlignedStyler interface {
+	gowid.ICellStyler
+	Alignment() gowid.IHAlignment
+}
+
+// Aligned is an ICellStyler that styles with Style, if it's not nil, and aligns the
+// paragraph containing its text according to Align.
+type Aligned struct {
+	Align gowid.IHAlignment
+	Style gowid.ICellStyler
+}
+
+var _ IAlignedStyler = Aligned{}
+
+func (a Aligned) GetStyle(prov gowid.IRenderContext) (x gowid.IColor, y gowid.IColor, z gowid.StyleAttrs) {
+	if a.Style == nil {
+		return gowid.NoColor{}, gowid.NoColor{}, gowid.StyleNone
+	}
+	return a.Style.GetStyle(prov)
+}
+
+func (a Aligned) Alignment() gowid.IHAlignment {
+	return a.Align
+}
+
+// IAlignedContent is implemented by IContent that can specify the alignment of
+// individual paragraphs.
+type IAlignedContent interface {
+	// AlignmentAt returns the alignment requested by the content at index idx, and
+	// false if there is none.
+	AlignmentAt(idx int) (gowid.IHAlignment, bool)
+}
+
 // StyledRune is a styled rune.
 type StyledRune struct {
 	Chr  rune
@@ -98,6 +173,7 @@ type Content []StyledRune
 
 var _ IContent = (*Content)(nil)
 var _ ICloneContent = (*Content)(nil)
+var _ IAlignedContent = (*Content)(nil)
 
 // NewContent constructs Content suitable for initializing a text Widget.
 func NewContent(content []ContentSegment) *Content {
@@ -159,22 +235,48 @@ func (h Content) RangeOver(start, end int, attrs gowid.IRenderContext, proc gowi
 	var s gowid.StyleAttrs
 	var f2 gowid.TCellColor
 	var g2 gowid.TCellColor
-
-	for idx, j := start, 0; idx < end; idx, j = idx+1, j+1 {
+	var link gowid.Hyperlink
+
+	for idx, n := start, 0; idx < end; idx += n {
+		// The runes of a grapheme cluster are rendered in one cell, styled like the first
+		n, _ = clusterAt(h, idx, end)
+		var comb []rune
+		if n > 1 {
+			comb = make([]rune, 0, n-1)
+			for _, sr := range h[idx+1 : idx+n] {
+				comb = append(comb, sr.Chr)
+			}
+		}
 		if h[idx].Attr != nil {
 			if h[idx].Attr != curStyler {
 				f, g, s = h[idx].Attr.GetStyle(attrs)
-				f2 = gowid.IColorToTCell(f, gowid.ColorNone, attrs.GetColorMode())
-				g2 = gowid.IColorToTCell(g, gowid.ColorNone, attrs.GetColorMode())
+				f2 = gowid.ColorToTCellIn(f, gowid.ColorNone, attrs)
+				g2 = gowid.ColorToTCellIn(g, gowid.ColorNone, attrs)
+				link = gowid.Hyperlink{}
+				if ls, ok := h[idx].Attr.(IHyperlinkStyler); ok {
+					link = ls.Link()
+				}
 				curStyler = h[idx].Attr
 			}
-			proc.ProcessCell(gowid.MakeCell(h[idx].Chr, f2, g2, s))
+			cell := gowid.MakeCell(h[idx].Chr, f2, g2, s).WithCombining(comb)
+			if link.URL != "" {
+				cell = cell.WithHyperlink(link)
+			}
+			proc.ProcessCell(cell)
 		} else {
-			proc.ProcessCell(gowid.MakeCell(h[idx].Chr, gowid.ColorNone, gowid.ColorNone, gowid.StyleNone))
+			proc.ProcessCell(gowid.MakeCell(h[idx].Chr, gowid.ColorNone, gowid.ColorNone, gowid.StyleNone).WithCombining(comb))
 		}
 	}
 }
 
+// AlignmentAt returns the alignment of the styler at index idx, if it's an IAlignedStyler.
+func (h Content) AlignmentAt(idx int) (gowid.IHAlignment, bool) {
+	if as, ok := h[idx].Attr.(IAlignedStyler); ok && as.Alignment() != nil {
+		return as.Alignment(), true
+	}
+	return nil, false
+}
+
 // ChrAt will return the unstyled rune at index idx.
 func (h Content) ChrAt(idx int) rune {
 	return h[idx].Chr
@@ -188,8 +290,10 @@ func (h Content) Length() int {
 // Width returns the number of screen cells the content takes. Different from Length if >1-width runes are used.
 func (h Content) Width() int {
 	res := 0
-	for _, r := range h {
-		res += runewidth.RuneWidth(r.Chr)
+	for i := 0; i < len(h); {
+		n, wid := clusterAt(h, i, len(h))
+		res += wid
+		i += n
 	}
 	return res
 }
@@ -376,6 +480,14 @@ func (w *Widget) SetText(text string, app gowid.IApp) {
 	w.SetContent(app, NewContent([]ContentSegment{*content}))
 }
 
+// BindText keeps the widget's text in step with the property. Changes made from other
+// goroutines are applied on the widget rendering goroutine.
+func (w *Widget) BindText(app gowid.IApp, p *gowid.Property[string]) *gowid.Binding {
+	return gowid.Bind(app, p, func(app gowid.IApp, text string) {
+		w.SetText(text, app)
+	})
+}
+
 func (w *Widget) Wrap() WrapType {
 	return w.wrap
 }
@@ -400,8 +512,13 @@ func (w *Widget) SetLinesFromTop(l int, app gowid.IApp) {
 	w.linesFromTop = l
 }
 
+// RenderSize returns the size Render would make the widget's canvas, without making it.
+// The size isn't cached, as the content may be changed in place.
 func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
-	return gowid.CalculateRenderSizeFallback(w, size, focus, app)
+	if sz, ok := size.(gowid.IRenderBox); ok {
+		return gowid.RenderBox{C: sz.BoxColumns(), R: sz.BoxRows()}
+	}
+	return RenderSize(w, size, focus, app)
 }
 
 func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
@@ -497,10 +614,65 @@ var _ gowid.ICellProcessor = (*ContentToCellArray)(nil)
 
 func (m *ContentToCellArray) ProcessCell(cell gowid.Cell) gowid.Cell {
 	m.Cells[m.Cur] = cell
-	m.Cur += runewidth.RuneWidth(cell.Rune())
+	m.Cur += cell.Width()
 	return cell
 }
 
+// fixedSize returns the width of the widest line of the content, and the number of lines.
+func fixedSize(content IContent) (int, int) {
+	maxCol, curcol := 0, 0
+	maxRow := 1
+	for i, n := 0, 0; i < content.Length(); i += n {
+		var wid int
+		n, wid = clusterAt(content, i, content.Length())
+		if content.ChrAt(i) == '\n' {
+			maxRow++
+			if curcol > maxCol {
+				maxCol = curcol
+			}
+			curcol = 0
+		} else {
+			curcol += wid
+		}
+	}
+	if curcol > maxCol {
+		maxCol = curcol
+	}
+	return maxCol, maxRow
+}
+
+// RenderSize returns the size of the canvas Render would make for the widget. It works out
+// the layout of the text, but doesn't make the canvas's cells.
+func RenderSize(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.RenderBox {
+	var maxCol int
+	maxRow := -1
+	content := w.Content()
+	switch sz := size.(type) {
+	case gowid.IRenderBox:
+		return gowid.RenderBox{C: sz.BoxColumns(), R: sz.BoxRows()}
+	case gowid.IRenderFixed:
+		maxCol, maxRow = fixedSize(content)
+	case gowid.IRenderFlowWith:
+		maxCol = sz.FlowColumns()
+	default:
+		maxCol = content.Width()
+	}
+
+	layout := MakeTextLayout(content, maxCol, w.Wrap(), w.Align())
+
+	// Lines are padded to maxCol, or stretched to it if justified
+	res := gowid.RenderBox{C: maxCol, R: len(layout.Lines)}
+	for _, segment := range layout.Lines {
+		if segment.EndWidth-segment.StartWidth > res.C {
+			res.C = segment.EndWidth - segment.StartWidth
+		}
+	}
+	if maxRow != -1 {
+		res.R = maxRow
+	}
+	return res
+}
+
 // If rendered Fixed, then rows==1 and cols==len(text)
 func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
 	cursor := false
@@ -526,28 +698,7 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 	haveMaxRow := isBox || isFixed
 	if haveMaxRow {
 		if isFixed {
-			curcol := 0
-			maxRow = 1
-			var last rune
-			// This is lame - find a better way
-			for i := 0; i < w.Content().Length(); i++ {
-				last = w.Content().ChrAt(i)
-				if last == '\n' {
-					maxRow++
-					if curcol > maxCol {
-						maxCol = curcol
-					}
-					curcol = 0
-				} else {
-					curcol += runewidth.RuneWidth(last)
-				}
-			}
-			if curcol > maxCol {
-				maxCol = curcol
-			}
-			// if last == '\n' {
-			// 	maxRow--
-			// }
+			maxCol, maxRow = fixedSize(content)
 		} else {
 			maxRow = box.BoxRows()
 			maxCol = box.BoxColumns()
@@ -568,7 +719,18 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 	// to construct the canvas. Walk through each segment returned by
 	// the layout object
 	count := 0
+	paraEnd := -1
+	align := w.Align()
 	for x, segment := range layout.Lines {
+		// A line starting beyond the end of the current paragraph starts the next one
+		if segment.StartLength > paraEnd {
+			paraEnd = segment.StartLength
+			for paraEnd < content.Length() && content.ChrAt(paraEnd) != '\n' {
+				paraEnd++
+			}
+			align = ParagraphAlignment(content, segment.StartLength, paraEnd, w.Align())
+		}
+
 		// Make enough cells to be able to render double-width runes. The second cell will be left
 		// empty.
 		lines[x] = make([]gowid.Cell, segment.EndWidth-segment.StartWidth)
@@ -586,8 +748,13 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 			}
 		}
 
-		if len(lines[x]) < maxCol {
-			switch w.Align().(type) {
+		if _, ok := align.(gowid.HAlignJustify); ok {
+			// Don't stretch the last line of a paragraph
+			if !segment.Clipped && segment.EndLength < paraEnd {
+				lines[x] = JustifyLine(lines[x], maxCol)
+			}
+		} else if len(lines[x]) < maxCol {
+			switch align.(type) {
 			case gowid.HAlignRight:
 				length := maxCol - len(lines[x])
 				lines[x] = append(gowid.CellsFromString(gwutil.StringOfLength(' ', length)), lines[x]...)
@@ -644,6 +811,67 @@ func Render(w IWidget, size gowid.IRenderSize, focus gowid.Selector, app gowid.I
 	return res
 }
 
+// ParagraphAlignment returns the alignment of the paragraph of content between start and
+// end - the first alignment specified by the content in that range if it implements
+// IAlignedContent, else def.
+func ParagraphAlignment(content IContent, start, end int, def gowid.IHAlignment) gowid.IHAlignment {
+	if ac, ok := content.(IAlignedContent); ok {
+		for i := start; i < end; i++ {
+			if align, ok := ac.AlignmentAt(i); ok {
+				return align
+			}
+		}
+	}
+	return def
+}
+
+// JustifyLine returns the line of cells widened to width by repeating the spaces that
+// separate words. Trailing spaces are dropped and leading spaces are left alone. If the
+// line has no gaps between words, it's returned unchanged.
+func JustifyLine(line []gowid.Cell, width int) []gowid.Cell {
+	// The cell after a double-width rune is left empty, so don't mistake it for a space
+	spaces := make([]bool, len(line))
+	for i := 0; i < len(line); i++ {
+		spaces[i] = IsBreakableSpace(line[i].Rune())
+		if line[i].Width() == 2 {
+			i++
+		}
+	}
+	end := len(line)
+	for end > 0 && spaces[end-1] {
+		end--
+	}
+	// The index of the last space in each run of spaces between words
+	gaps := make([]int, 0, 16)
+	seenWord := false
+	for i := 0; i < end-1; i++ {
+		if !spaces[i] {
+			seenWord = true
+		} else if seenWord && !spaces[i+1] {
+			gaps = append(gaps, i)
+		}
+	}
+	extra := width - end
+	if len(gaps) == 0 || extra <= 0 {
+		return line
+	}
+	res := make([]gowid.Cell, 0, width)
+	for i, j := 0, 0; i < end; i++ {
+		res = append(res, line[i])
+		if j < len(gaps) && gaps[j] == i {
+			n := extra / len(gaps)
+			if j < extra%len(gaps) {
+				n++
+			}
+			for ; n > 0; n-- {
+				res = append(res, line[i])
+			}
+			j++
+		}
+	}
+	return res
+}
+
 type IChrAt interface {
 	ChrAt(i int) rune
 }
@@ -656,8 +884,10 @@ func GetCoordsFromCursorPos(cursorPos int, maxCol int, layout *TextLayout, at IC
 			crow = lineNumber
 
 			ccol = 0
-			for i := segment.StartLength; i < gwutil.Min(segment.EndLength, cursorPos); i++ {
-				ccol += runewidth.RuneWidth(at.ChrAt(i))
+			for i := segment.StartLength; i < gwutil.Min(segment.EndLength, cursorPos); {
+				n, wid := clusterAt(at, i, segment.EndLength)
+				ccol += wid
+				i += n
 			}
 		}
 	}
@@ -680,13 +910,44 @@ func GetCursorPosFromCoords(ccol int, crow int, layout *TextLayout, at IChrAt) i
 
 		col := 0
 		for i := 0; i < gwutil.Min(endw-startw, ccol); {
-			i += runewidth.RuneWidth(at.ChrAt(col + start))
-			col += 1
+			n, wid := clusterAt(at, col+start, layout.Lines[crow].EndLength)
+			i += wid
+			col += n
 		}
 		return start + col
 	}
 }
 
+// maxClusterRunes bounds the number of runes examined to find the end of a grapheme cluster.
+const maxClusterRunes = 32
+
+// clusterAt returns the number of runes in the grapheme cluster starting at index i, not
+// extending to end, and the number of screen columns the cluster occupies. Control
+// characters like newline are never combined.
+func clusterAt(at IChrAt, i, end int) (int, int) {
+	c := at.ChrAt(i)
+	// Nothing below U+0300 extends a cluster, so skip the expensive check for most text
+	if i+1 >= end || c < ' ' || (c < 0x300 && at.ChrAt(i+1) < 0x300) {
+		return 1, runewidth.RuneWidth(c)
+	}
+	runes := make([]rune, gwutil.Min(end-i, maxClusterRunes))
+	for j := range runes {
+		runes[j] = at.ChrAt(i + j)
+	}
+	cluster, _, _, _ := uniseg.FirstGraphemeClusterInString(string(runes), -1)
+	n := utf8.RuneCountInString(cluster)
+	for j := 1; j < n; j++ {
+		if runes[j] < ' ' {
+			n = j
+			break
+		}
+	}
+	if n <= 1 {
+		return 1, runewidth.RuneWidth(c)
+	}
+	return n, runewidth.StringWidth(string(runes[:n]))
+}
+
 //======================================================================
 
 type LineLayout struct {
@@ -716,7 +977,7 @@ func MakeTextLayout(content IContent, width int, wrap WrapType, align gowid.IHAl
 			startOfCurrentLineWidth := 0
 			for startOfCurrentLineLength+indexInLineLength < content.Length() {
 				c := content.ChrAt(startOfCurrentLineLength + indexInLineLength)
-				wid := runewidth.RuneWidth(c)
+				n, wid := clusterAt(content, startOfCurrentLineLength+indexInLineLength, content.Length())
 				if !skippingToEndOfLine && indexInLineWidth+wid > width { // end of space and no newline found
 					lines = append(lines, LineLayout{
 						StartLength: startOfCurrentLineLength,
@@ -727,7 +988,7 @@ func MakeTextLayout(content IContent, width int, wrap WrapType, align gowid.IHAl
 					})
 					skippingToEndOfLine = true
 					indexInLineWidth += wid
-					indexInLineLength++
+					indexInLineLength += n
 				} else if c == '\n' {
 					if !skippingToEndOfLine {
 						lines = append(lines, LineLayout{
@@ -745,7 +1006,7 @@ func MakeTextLayout(content IContent, width int, wrap WrapType, align gowid.IHAl
 					indexInLineWidth = 0
 				} else {
 					indexInLineWidth += wid
-					indexInLineLength += 1
+					indexInLineLength += n
 				}
 			}
 			if !skippingToEndOfLine {
@@ -765,7 +1026,8 @@ func MakeTextLayout(content IContent, width int, wrap WrapType, align gowid.IHAl
 			startOfCurrentSegmentWidth := 0
 			for startOfCurrentSegmentLength+indexInSegmentLength < content.Length() {
 				c := content.ChrAt(startOfCurrentSegmentLength + indexInSegmentLength)
-				if indexInSegmentWidth+runewidth.RuneWidth(c) > width { // end of space and no newline found
+				n, wid := clusterAt(content, startOfCurrentSegmentLength+indexInSegmentLength, content.Length())
+				if indexInSegmentWidth+wid > width { // end of space and no newline found
 					lines = append(lines, LineLayout{
 						StartLength: startOfCurrentSegmentLength,
 						StartWidth:  startOfCurrentSegmentWidth,
@@ -790,8 +1052,8 @@ func MakeTextLayout(content IContent, width int, wrap WrapType, align gowid.IHAl
 					indexInSegmentLength = 0
 					indexInSegmentWidth = 0
 				} else {
-					indexInSegmentWidth += runewidth.RuneWidth(c)
-					indexInSegmentLength += 1
+					indexInSegmentWidth += wid
+					indexInSegmentLength += n
 				}
 			}
 			lines = append(lines, LineLayout{
@@ -856,7 +1118,7 @@ func (w *CopyableWidget) UserInput(ev interface{}, size gowid.IRenderSize, focus
 func (w *CopyableWidget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
 	if app.InCopyMode() && app.CopyModeClaimedBy().ID() == w.ID() && focus.Focus {
 		w2 := w.AlterWidget(w.Widget, app)
-		return w2.Render(size, focus, app)
+		return gowid.RenderChild(w2, size, focus, app)
 	} else {
 		return w.Widget.Render(size, focus, app)
 	}
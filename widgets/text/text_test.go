@@ -398,6 +398,122 @@ func TestChinese1(t *testing.T) {
 	assert.Equal(t, "|你|好|，|世|界|", c1.String())
 }
 
+func TestLink1(t *testing.T) {
+	w := NewFromContent(NewContent([]ContentSegment{
+		StringContent("see "),
+		LinkContent("docs", "https://example.com", nil),
+	}))
+	c1 := w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "see docs", c1.String())
+
+	_, ok := c1.CellAt(3, 0).Hyperlink()
+	assert.False(t, ok)
+	link, ok := c1.CellAt(4, 0).Hyperlink()
+	assert.True(t, ok)
+	assert.Equal(t, "https://example.com", link.URL)
+}
+
+func TestGrapheme1(t *testing.T) {
+	w := New("e\u0301x\U0001F469\u200d\U0001F4BB")
+	assert.Equal(t, 4, w.Content().Width())
+	c1 := w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	assert.Equal(t, 4, c1.BoxColumns())
+	assert.Equal(t, "e\u0301x\U0001F469\u200d\U0001F4BB", c1.String())
+	assert.Equal(t, []rune{'\u0301'}, c1.CellAt(0, 0).Combining())
+
+	c1 = w.Render(gowid.RenderFlowWith{C: 2}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "e\u0301x\n\U0001F469\u200d\U0001F4BB", c1.String())
+}
+
+func TestMarkup1(t *testing.T) {
+	segs, err := ParseMarkup("[red:white]error[/] rest")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(segs))
+	assert.Equal(t, "error", segs[0].Text)
+	assert.Equal(t, " rest", segs[1].Text)
+	assert.Nil(t, segs[1].Style)
+
+	w, err := NewFromMarkup("[@test1focus]a[blue::b]b[/]c[/][[d")
+	assert.NoError(t, err)
+	c1 := w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "abc[d", c1.String())
+
+	red := gowid.IColorToTCell(gowid.ColorRed, gowid.ColorNone, gowid.Mode256Colors)
+	blue := gowid.IColorToTCell(gowid.ColorBlue, gowid.ColorNone, gowid.Mode256Colors)
+	black := gowid.IColorToTCell(gowid.ColorBlack, gowid.ColorNone, gowid.Mode256Colors)
+	assert.Equal(t, red, c1.CellAt(0, 0).ForegroundColor())
+	assert.Equal(t, blue, c1.CellAt(1, 0).ForegroundColor())
+	assert.Equal(t, black, c1.CellAt(1, 0).BackgroundColor())
+	assert.Equal(t, gowid.StyleBold, c1.CellAt(1, 0).Style())
+	assert.Equal(t, red, c1.CellAt(2, 0).ForegroundColor())
+	assert.Equal(t, gowid.StyleNone, c1.CellAt(2, 0).Style())
+	assert.Equal(t, gowid.ColorNone, c1.CellAt(3, 0).ForegroundColor())
+
+	assert.Equal(t, "a[[b]", EscapeMarkup("a[b]"))
+
+	for _, bad := range []string{"[/]", "[red", "[nocolor]x", "[::q]x", "[@]x", "[a:b:c:d]"} {
+		_, err = ParseMarkup(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestJustify1(t *testing.T) {
+	w := New("aa bb cc dd\nee ff", Options{Align: gowid.HAlignJustify{}})
+	c1 := w.Render(gowid.RenderFlowWith{C: 9}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "aa  bb cc\ndd       \nee ff    ", c1.String())
+
+	w = NewFromContent(NewContent([]ContentSegment{
+		StringContent("ab\n"),
+		AlignedContent("cd", gowid.HAlignRight{}, nil),
+		StringContent("\nef"),
+	}))
+	c1 = w.Render(gowid.RenderFlowWith{C: 4}, gowid.Focused, gwtest.D)
+	assert.Equal(t, "ab  \n  cd\nef  ", c1.String())
+
+	assert.Equal(t, "a   b  c", gowid.CanvasToString(gowid.NewCanvasWithLines([][]gowid.Cell{
+		JustifyLine(gowid.CellsFromString("a b c"), 8),
+	})))
+}
+
+func TestRenderSize1(t *testing.T) {
+	texts := []string{"", "hello", "hello world\nfoo", "a\n\nbc\n", "你好世界 abc", "one two three four five"}
+	sizes := []gowid.IRenderSize{
+		gowid.RenderFixed{},
+		gowid.RenderFlowWith{C: 2},
+		gowid.RenderFlowWith{C: 4},
+		gowid.RenderFlowWith{C: 11},
+		gowid.RenderFlowWith{C: 30},
+		gowid.RenderBox{C: 5, R: 2},
+	}
+	aligns := []gowid.IHAlignment{gowid.HAlignLeft{}, gowid.HAlignMiddle{}, gowid.HAlignJustify{}}
+	for _, txt := range texts {
+		for _, wrap := range []WrapType{WrapAny, WrapClip} {
+			for _, align := range aligns {
+				w := New(txt, Options{Wrap: wrap, Align: align})
+				for _, sz := range sizes {
+					c := w.Render(sz, gowid.NotSelected, gwtest.D)
+					expected := gowid.RenderBox{C: c.BoxColumns(), R: c.BoxRows()}
+					assert.Equal(t, expected, w.RenderSize(sz, gowid.NotSelected, gwtest.D), "%q %v %v %v", txt, wrap, align, sz)
+				}
+			}
+		}
+	}
+
+	// The size follows the text, however it changes
+	w := New("abc")
+	sz := gowid.RenderFlowWith{C: 3}
+	assert.Equal(t, gowid.RenderBox{C: 3, R: 1}, w.RenderSize(sz, gowid.NotSelected, gwtest.D))
+	w.SetText("abc\ndef", gwtest.D)
+	assert.Equal(t, gowid.RenderBox{C: 3, R: 2}, w.RenderSize(sz, gowid.NotSelected, gwtest.D))
+	w.Content().AddAt(0, StringContent("x"))
+	assert.Equal(t, gowid.RenderBox{C: 3, R: 3}, w.RenderSize(sz, gowid.NotSelected, gwtest.D))
+	// In place, keeping the same length
+	w.Content().DeleteAt(6, 1)
+	w.Content().AddAt(6, StringContent("\n"))
+	assert.Equal(t, "xabc\nd\nf", w.Content().String())
+	assert.Equal(t, gowid.RenderBox{C: 3, R: 4}, w.RenderSize(sz, gowid.NotSelected, gwtest.D))
+}
+
 //======================================================================
 // Local Variables:
 // mode: Go
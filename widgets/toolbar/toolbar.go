@@ -0,0 +1,343 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package toolbar provides a one-line row of buttons. Buttons that don't fit are moved
+// into a menu opened by a "more" button at the end of the row.
+package toolbar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/contextmenu"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+// Button is an entry in a toolbar. ID identifies the button to the toolbar's action
+// callbacks. An ampersand in the Label marks an accelerator, e.g. "&Save" - the letter
+// is underlined, and pressing it with alt activates the button while it is on the screen.
+// Icon, if not empty, is shown before the label - e.g. a glyph like "✚".
+type Button struct {
+	ID    string
+	Label string
+	Icon  string
+}
+
+func (b Button) String() string {
+	return fmt.Sprintf("button[%s]", b.ID)
+}
+
+// label returns the text shown for the button, with any accelerator markup.
+func (b Button) label() string {
+	if b.Icon == "" {
+		return b.Label
+	}
+	return b.Icon + " " + b.Label
+}
+
+// width returns the number of columns the button takes, including a space either side.
+func (b Button) width() int {
+	plain, _, _ := gowid.ParseAccelerator(b.label())
+	return runewidth.StringWidth(plain) + 2
+}
+
+// Options can be supplied to New. Separator is drawn between neighbouring buttons - by
+// default, "│". MoreLabel labels the button that opens the menu of buttons that don't
+// fit - by default, "»". Buttons are rendered with Style, and the focus button with
+// FocusStyle - by default, reverse video. If Wrap is true, moving right from the last
+// button goes to the first, and vice versa.
+type Options struct {
+	Separator  string
+	MoreLabel  string
+	Style      gowid.ICellStyler
+	FocusStyle gowid.ICellStyler
+	Wrap       bool
+}
+
+type IWidget interface {
+	gowid.IWidget
+	Buttons() []Button
+	SetButtons(buttons []Button, app gowid.IApp)
+}
+
+// ActionCB is the name under which callbacks are registered that run when a button is
+// activated, whether from the toolbar or from the "more" menu. The callback's extra
+// argument is the button's ID.
+type ActionCB struct{}
+
+// Widget is a toolbar. It is always one row high.
+type Widget struct {
+	buttons []Button
+	opts    Options
+	view    *columns.Widget     // The buttons shown, for the width last laid out
+	more    *contextmenu.Widget // Opens the menu of buttons that don't fit, if there are any
+	shown   int                 // The number of buttons in view
+	focus   int                 // The focus button, or len(buttons) for the "more" button
+	*gowid.Callbacks
+	gowid.AddressProvidesID
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(buttons []Button, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Separator == "" {
+		opt.Separator = "│"
+	}
+	if opt.MoreLabel == "" {
+		opt.MoreLabel = "»"
+	}
+	if opt.FocusStyle == nil {
+		opt.FocusStyle = gowid.MakeStyledAs(gowid.StyleReverse)
+	}
+	res := &Widget{
+		buttons:   append([]Button(nil), buttons...),
+		opts:      opt,
+		shown:     -1,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	return res
+}
+
+func (w *Widget) String() string {
+	ids := make([]string, 0, len(w.buttons))
+	for _, b := range w.buttons {
+		ids = append(ids, b.ID)
+	}
+	return fmt.Sprintf("toolbar[%s]", strings.Join(ids, ","))
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+// Buttons returns a copy of the toolbar's buttons.
+func (w *Widget) Buttons() []Button {
+	return append([]Button(nil), w.buttons...)
+}
+
+// SetButtons replaces the toolbar's buttons. The first button gets the focus.
+func (w *Widget) SetButtons(buttons []Button, app gowid.IApp) {
+	w.buttons = append([]Button(nil), buttons...)
+	w.focus = 0
+	w.shown = -1
+}
+
+// Focus returns the ID of the focus button, or "" if the "more" button has the focus.
+// Buttons that have moved into the "more" menu can't have the focus.
+func (w *Widget) Focus() string {
+	if w.focus < len(w.buttons) {
+		return w.buttons[w.focus].ID
+	}
+	return ""
+}
+
+// SetFocus gives the focus to the button with the given ID, and returns false if there
+// is no such button.
+func (w *Widget) SetFocus(id string, app gowid.IApp) bool {
+	for i, b := range w.buttons {
+		if b.ID == id {
+			w.focus = i
+			w.shown = -1
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Widget) OnAction(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, ActionCB{}, f)
+}
+
+func (w *Widget) RemoveOnAction(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, ActionCB{}, f)
+}
+
+// Activate runs the action callbacks for the button with the given ID, as if it had been
+// clicked.
+func (w *Widget) Activate(id string, app gowid.IApp) {
+	gowid.RunWidgetCallbacks(w.Callbacks, ActionCB{}, app, w, id)
+}
+
+// IsMoreOpen returns true if the menu of buttons that don't fit is open.
+func (w *Widget) IsMoreOpen() bool {
+	return w.more != nil && w.more.IsOpen()
+}
+
+func (w *Widget) Selectable() bool {
+	return len(w.buttons) > 0
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	if cols, ok := size.(gowid.IColumns); ok {
+		return gowid.RenderBox{C: cols.Columns(), R: 1}
+	}
+	return gowid.RenderBox{C: w.naturalWidth(), R: 1}
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+// naturalWidth returns the width needed to show every button.
+func (w *Widget) naturalWidth() int {
+	res := 0
+	for i, b := range w.buttons {
+		if i > 0 {
+			res += runewidth.StringWidth(w.opts.Separator)
+		}
+		res += b.width()
+	}
+	return res
+}
+
+// layout returns the number of buttons shown when the toolbar is cols wide. If it's
+// fewer than all of them, the "more" button is shown after them.
+func (w *Widget) layout(cols int) int {
+	if w.naturalWidth() <= cols {
+		return len(w.buttons)
+	}
+	sep := runewidth.StringWidth(w.opts.Separator)
+	used := runewidth.StringWidth(w.opts.MoreLabel) + 2
+	n := 0
+	for _, b := range w.buttons {
+		if used+sep+b.width() > cols {
+			break
+		}
+		used += sep + b.width()
+		n++
+	}
+	return n
+}
+
+// cols returns the width of the toolbar when rendered with size.
+func (w *Widget) cols(size gowid.IRenderSize) int {
+	if c, ok := size.(gowid.IColumns); ok {
+		return c.Columns()
+	}
+	return w.naturalWidth()
+}
+
+// ensureView makes sure the view shows the buttons that fit in cols, keeping the focus
+// where it was if that button is still in view.
+func (w *Widget) ensureView(cols int, app gowid.IApp) {
+	n := w.layout(cols)
+	if n == w.shown && w.view != nil {
+		return
+	}
+	w.shown = n
+
+	ws := make([]gowid.IContainerWidget, 0, 2*n+2)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			ws = append(ws, &gowid.ContainerWidget{IWidget: text.New(w.opts.Separator), D: gowid.RenderFixed{}})
+		}
+		id := w.buttons[i].ID
+		btn := button.NewWithAccelerator(" "+w.buttons[i].label()+" ", button.Options{Decoration: button.BareDecoration})
+		btn.OnClick(gowid.WidgetCallback{"cb", func(app gowid.IApp, _ gowid.IWidget) {
+			w.Activate(id, app)
+		}})
+		ws = append(ws, &gowid.ContainerWidget{IWidget: w.styled(btn), D: gowid.RenderFixed{}})
+	}
+
+	w.more = nil
+	if n < len(w.buttons) {
+		items := make([]contextmenu.Item, 0, len(w.buttons)-n)
+		for _, b := range w.buttons[n:] {
+			id := b.ID
+			items = append(items, contextmenu.Item{Label: b.label(), Action: func(app gowid.IApp) {
+				w.Activate(id, app)
+			}})
+		}
+		btn := button.New(text.New(" "+w.opts.MoreLabel+" "), button.Options{Decoration: button.BareDecoration})
+		more := contextmenu.New(w.styled(btn), items, contextmenu.Options{
+			Style:      w.opts.Style,
+			FocusStyle: w.opts.FocusStyle,
+		})
+		btn.OnClick(gowid.WidgetCallback{"cb", func(app gowid.IApp, _ gowid.IWidget) {
+			more.Open(0, 1, app)
+		}})
+		w.more = more
+		if n > 0 {
+			ws = append(ws, &gowid.ContainerWidget{IWidget: text.New(w.opts.Separator), D: gowid.RenderFixed{}})
+		}
+		ws = append(ws, &gowid.ContainerWidget{IWidget: more, D: gowid.RenderFixed{}})
+	}
+	// Fill the rest of the row
+	ws = append(ws, &gowid.ContainerWidget{IWidget: text.New(""), D: gowid.RenderWithWeight{W: 1}})
+
+	w.view = columns.New(ws, columns.Options{Wrap: w.opts.Wrap})
+	if w.focus >= n {
+		w.focus = len(w.buttons)
+		w.view.SetFocus(app, 2*n)
+	} else {
+		w.view.SetFocus(app, 2*w.focus)
+	}
+}
+
+func (w *Widget) styled(inner gowid.IWidget) gowid.IWidget {
+	if w.opts.Style == nil {
+		return styled.NewFocus(inner, w.opts.FocusStyle)
+	}
+	return styled.NewExt(inner, w.opts.Style, w.opts.FocusStyle)
+}
+
+// syncFocus records which button has the focus after the view has handled input.
+func (w *Widget) syncFocus() {
+	f := w.view.Focus()
+	if f < 0 {
+		return
+	}
+	if w.more != nil && f == 2*w.shown {
+		w.focus = len(w.buttons)
+	} else {
+		w.focus = f / 2
+	}
+}
+
+//======================================================================
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	cols := w.cols(size)
+	w.ensureView(cols, app)
+	res := w.view.Render(gowid.RenderFlowWith{C: cols}, focus, app)
+	if box, ok := size.(gowid.IRenderBox); ok {
+		gowid.MakeCanvasRightSize(res, box)
+	}
+	return res
+}
+
+// UserInput moves the focus between the buttons with the left and right cursor keys,
+// and activates the focus button with enter or space, or any button that is clicked.
+// Activating the "more" button opens a menu of the buttons that don't fit.
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	cols := w.cols(size)
+	w.ensureView(cols, app)
+	res := w.view.UserInput(ev, gowid.RenderFlowWith{C: cols}, focus, app)
+	w.syncFocus()
+	return res
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
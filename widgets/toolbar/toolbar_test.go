@@ -0,0 +1,129 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package toolbar
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func screenRows(screen tcell.SimulationScreen) []string {
+	cells, width, height := screen.GetContents()
+	res := make([]string, height)
+	for y := 0; y < height; y++ {
+		var sb strings.Builder
+		for x := 0; x < width; x++ {
+			r := cells[y*width+x].Runes
+			if len(r) == 0 {
+				sb.WriteRune(' ')
+			} else {
+				sb.WriteRune(r[0])
+			}
+		}
+		res[y] = sb.String()
+	}
+	return res
+}
+
+var testButtons = []Button{
+	{ID: "new", Label: "New", Icon: "+"},
+	{ID: "open", Label: "&Open"},
+	{ID: "save", Label: "Save"},
+}
+
+func TestToolbar1(t *testing.T) {
+	w := New(testButtons, Options{Separator: "|"})
+
+	var acted []string
+	w.OnAction(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, _ gowid.IWidget, data ...interface{}) {
+		acted = append(acted, data[0].(string))
+	}})
+
+	c := w.Render(gowid.RenderFixed{}, gowid.Focused, gwtest.D)
+	assert.Equal(t, " + New | Open | Save ", c.String())
+	assert.Equal(t, gowid.StyleReverse, c.CellAt(0, 0).Style())
+	assert.Equal(t, gowid.StyleNone, c.CellAt(8, 0).Style())
+
+	sz := gowid.RenderFlowWith{C: 24}
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, " + New | Open | Save    ", c.String())
+
+	// Keyboard navigation skips the separators
+	w.UserInput(tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "open", w.Focus())
+	w.UserInput(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, []string{"open"}, acted)
+
+	// Clicking activates a button
+	w.UserInput(tcell.NewEventMouse(17, 0, tcell.Button1, 0), sz, gowid.Focused, gwtest.D)
+	gwtest.D.SetLastMouseState(gowid.MouseState{MouseLeftClicked: true})
+	w.UserInput(tcell.NewEventMouse(17, 0, tcell.ButtonNone, 0), sz, gowid.Focused, gwtest.D)
+	gwtest.D.SetLastMouseState(gowid.MouseState{})
+	assert.Equal(t, []string{"open", "save"}, acted)
+	assert.Equal(t, "save", w.Focus())
+
+	// Too narrow - the buttons that don't fit are replaced by the "more" button, which
+	// takes the focus if the focus button is no longer in view
+	c = w.Render(gowid.RenderFlowWith{C: 18}, gowid.Focused, gwtest.D)
+	assert.Equal(t, " + New | Open | » ", c.String())
+	assert.Equal(t, "", w.Focus())
+	c = w.Render(gowid.RenderFlowWith{C: 17}, gowid.Focused, gwtest.D)
+	assert.Equal(t, " + New | »       ", c.String())
+
+	c = w.Render(gowid.RenderFlowWith{C: 3}, gowid.Focused, gwtest.D)
+	assert.Equal(t, " » ", c.String())
+}
+
+func TestToolbar2(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(20, 5)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+
+	w := New(testButtons)
+	var acted []string
+	w.OnAction(gowid.WidgetCallbackExt{"cb", func(app gowid.IApp, _ gowid.IWidget, data ...interface{}) {
+		acted = append(acted, data[0].(string))
+	}})
+
+	view := pile.NewFlow(w, text.New("body"))
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen: screen,
+		View:   view,
+		Log:    logger,
+	})
+	assert.NoError(t, err)
+	app.RedrawTerminal()
+	assert.Equal(t, " + New │ Open │ »   ", screenRows(screen)[0])
+
+	// Move to the "more" button and open its menu
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	app.RedrawTerminal()
+	assert.True(t, w.IsMoreOpen())
+	assert.Contains(t, screenRows(screen)[1], " Save ")
+
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), gowid.IgnoreUnhandledInput)
+	assert.False(t, w.IsMoreOpen())
+	assert.Equal(t, []string{"save"}, acted)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,340 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package tooltip provides a widget that shows a short help text next to its inner
+// widget when the mouse rests over it, or when it keeps the focus, for a while.
+package tooltip
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/widgets/overlay"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+//======================================================================
+
+// Options can be supplied to New. The tooltip is shown once the mouse has rested over
+// the widget, or the widget has had the focus, for Delay - by default, 750ms. Set
+// NoHover or NoFocus to turn off either trigger. The tooltip is placed on the given
+// Side of the widget if there's room, and is at most MaxWidth columns wide - by
+// default, 40. Style defaults to reverse video.
+type Options struct {
+	Delay    time.Duration
+	Side     overlay.AnchorSide
+	MaxWidth int
+	Style    gowid.ICellStyler
+	NoHover  bool
+	NoFocus  bool
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	Text() string
+	Shown() bool
+}
+
+// Widget wraps another widget, adding a tooltip. The tooltip is drawn over the whole
+// view, so it isn't clipped by the widgets containing this one. Moving the mouse off
+// the widget, clicking, or pressing a key hides it. Hovering relies on the terminal
+// reporting mouse motion - see gowid.AppArgs.EnableMouseMotion.
+type Widget struct {
+	inner   gowid.IWidget
+	text    string
+	opts    Options
+	popup   *popup // Registered with the app while the tooltip is shown or the mouse is over the widget
+	shown   bool
+	hovered bool
+	focused bool
+	gen     int // Incremented each time the delay restarts so stale timers are ignored
+	timer   *time.Timer
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+	gowid.AddressProvidesID
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, tip string, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Delay <= 0 {
+		opt.Delay = 750 * time.Millisecond
+	}
+	if opt.MaxWidth <= 0 {
+		opt.MaxWidth = 40
+	}
+	if opt.Style == nil {
+		opt.Style = gowid.MakeStyledAs(gowid.StyleReverse)
+	}
+	res := &Widget{
+		inner:     inner,
+		text:      tip,
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.popup = &popup{tip: res}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("tooltip[%q:%v]", w.text, w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.inner
+}
+
+func (w *Widget) SetSubWidget(wi gowid.IWidget, app gowid.IApp) {
+	w.inner = wi
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+// Selectable returns true unless hovering is turned off, so that the widget sees
+// the mouse move over it even if the inner widget is not selectable.
+func (w *Widget) Selectable() bool {
+	return !w.opts.NoHover || w.inner.Selectable()
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Text() string {
+	return w.text
+}
+
+func (w *Widget) SetText(tip string, app gowid.IApp) {
+	w.text = tip
+}
+
+// Shown returns true if the tooltip is showing.
+func (w *Widget) Shown() bool {
+	return w.shown
+}
+
+// Show shows the tooltip now, without waiting for the delay.
+func (w *Widget) Show(app gowid.IApp) {
+	w.stop()
+	w.shown = true
+	w.sync(app)
+}
+
+// Hide hides the tooltip, and stops it appearing if the delay is in progress.
+func (w *Widget) Hide(app gowid.IApp) {
+	w.stop()
+	w.shown = false
+	w.sync(app)
+}
+
+// restart begins the delay after which the tooltip is shown.
+func (w *Widget) restart(app gowid.IApp) {
+	w.stop()
+	gen := w.gen
+	w.timer = time.AfterFunc(w.opts.Delay, func() {
+		app.Run(gowid.RunFunction(func(app gowid.IApp) {
+			if gen == w.gen {
+				w.tick(app)
+			}
+		}))
+	})
+}
+
+func (w *Widget) stop() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.gen++
+}
+
+// tick is called when the delay is up.
+func (w *Widget) tick(app gowid.IApp) {
+	w.timer = nil
+	if w.hovered || w.focused {
+		w.Show(app)
+	}
+}
+
+// sync registers the popup with the app while it's needed - to draw the tooltip, or to
+// see the mouse leave the widget.
+func (w *Widget) sync(app gowid.IApp) {
+	want := w.shown || w.hovered
+	if want && !w.popup.registered {
+		app.RegisterMenu(w.popup)
+		w.popup.registered = true
+	} else if !want && w.popup.registered {
+		app.UnregisterMenu(w.popup)
+		w.popup.registered = false
+	}
+}
+
+// leave is called when the mouse moves off the widget.
+func (w *Widget) leave(app gowid.IApp) {
+	w.hovered = false
+	w.Hide(app)
+}
+
+func (w *Widget) markName() string {
+	return fmt.Sprintf("tooltip-%p", w)
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(w.inner, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	if !w.opts.NoFocus && focus.Focus != w.focused {
+		w.focused = focus.Focus
+		if w.focused {
+			w.restart(app)
+		} else if !w.hovered {
+			w.Hide(app)
+		}
+	}
+	res := gowid.RenderChild(w.inner, size, focus, app)
+	gowid.MarkRect(res, w.markName())
+	gowid.TrackGeometry(w, res, app)
+	return res
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+// UserInput starts the delay when the mouse moves over the widget, and hides the
+// tooltip when a mouse button is pressed. The input is then passed to the inner widget.
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if evm, ok := ev.(*tcell.EventMouse); ok && !w.opts.NoHover {
+		x, y := evm.Position()
+		box := gowid.RenderSize(w, size, focus, app)
+		if x >= 0 && y >= 0 && x < box.BoxColumns() && y < box.BoxRows() {
+			if evm.Buttons() == tcell.ButtonNone {
+				if !w.shown {
+					w.hovered = true
+					w.restart(app)
+					w.sync(app)
+				}
+			} else {
+				w.Hide(app)
+			}
+		}
+	}
+	if gowid.UserInputIfSelectable(w.inner, ev, size, focus, app) {
+		return true
+	}
+	// Don't let motion over the widget fall through to the app's unhandled input
+	if evm, ok := ev.(*tcell.EventMouse); ok && evm.Buttons() == tcell.ButtonNone && w.hovered {
+		return true
+	}
+	return false
+}
+
+//======================================================================
+
+// popup sits at the root of the widget hierarchy, like a menu, drawing the tooltip
+// over the rest of the view. Because it sees all input first, it can hide the tooltip
+// when the mouse moves off the widget or a key is pressed.
+type popup struct {
+	tip        *Widget
+	bottom     gowid.IWidget
+	registered bool
+}
+
+var _ gowid.IMenuCompatible = (*popup)(nil)
+
+func (p *popup) String() string {
+	return fmt.Sprintf("tooltip-popup[%q]", p.tip.text)
+}
+
+func (p *popup) SubWidget() gowid.IWidget {
+	return p.bottom
+}
+
+func (p *popup) SetSubWidget(w gowid.IWidget, app gowid.IApp) {
+	p.bottom = w
+}
+
+func (p *popup) Selectable() bool {
+	return true
+}
+
+func (p *popup) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(p.bottom, size, focus, app)
+}
+
+func (p *popup) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	res := gowid.RenderChild(p.bottom, size, focus, app)
+	if !p.tip.shown {
+		return res
+	}
+	r, ok := gowid.MarkedRect(res, p.tip.markName())
+	if !ok {
+		// The widget wasn't rendered
+		return res
+	}
+	tipC := p.renderTip(res.BoxColumns(), app)
+	x, y := overlay.PlaceNextTo(r, tipC.BoxColumns(), tipC.BoxRows(), res.BoxColumns(), res.BoxRows(), p.tip.opts.Side)
+	res.MergeUnder(tipC, x, y, false)
+	return res
+}
+
+// renderTip renders the text with a column of space either side, filling the
+// background so that nothing under the tooltip shows through.
+func (p *popup) renderTip(maxCols int, app gowid.IApp) gowid.ICanvas {
+	cols := gwutil.Min(runewidth.StringWidth(p.tip.text)+2, gwutil.Min(p.tip.opts.MaxWidth, maxCols))
+	cols = gwutil.Max(cols, 3)
+	txt := styled.New(text.New(p.tip.text), p.tip.opts.Style)
+	txtC := txt.Render(gowid.RenderFlowWith{C: cols - 2}, gowid.NotSelected, app)
+
+	f, b, s := p.tip.opts.Style.GetStyle(app)
+	bg := gowid.MakeCell(' ',
+		gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+		gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+		s)
+	res := gowid.NewCanvasOfSizeExt(cols, txtC.BoxRows(), bg)
+	res.MergeUnder(txtC, 1, 0, false)
+	return res
+}
+
+func (p *popup) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	w := p.tip
+	bottom := p.bottom // The popup might be unregistered below
+	switch ev := ev.(type) {
+	case *tcell.EventMouse:
+		if w.hovered {
+			x, y := ev.Position()
+			if r, ok := gowid.GeometryOf(w.ID(), app); !ok || !r.Contains(x, y) {
+				w.leave(app)
+			}
+		}
+	case *tcell.EventKey:
+		w.Hide(app)
+	}
+	return gowid.UserInputIfSelectable(bottom, ev, size, focus, app)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,110 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package tooltip
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func newTestApp(t *testing.T, view gowid.IWidget) (*gowid.App, tcell.SimulationScreen) {
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(12, 4)
+	logger := log.New()
+	logger.Out = ioutil.Discard
+
+	app, err := gowid.NewApp(gowid.AppArgs{
+		Screen:            screen,
+		View:              view,
+		Log:               logger,
+		EnableMouseMotion: true,
+	})
+	assert.NoError(t, err)
+	app.RedrawTerminal()
+	return app, screen
+}
+
+func screenRows(screen tcell.SimulationScreen) []string {
+	cells, width, height := screen.GetContents()
+	res := make([]string, height)
+	for y := 0; y < height; y++ {
+		var sb strings.Builder
+		for x := 0; x < width; x++ {
+			if r := cells[y*width+x].Runes; len(r) > 0 {
+				sb.WriteRune(r[0])
+			} else {
+				sb.WriteRune(' ')
+			}
+		}
+		res[y] = sb.String()
+	}
+	return res
+}
+
+// The delay is long enough that the timer never fires - the tests call tick instead.
+func TestHover1(t *testing.T) {
+	tip := New(text.New("hover"), "Help!", Options{Delay: time.Hour, NoFocus: true})
+	app, screen := newTestApp(t, pile.NewFlow(tip, text.New("other")))
+	assert.Equal(t, "other       ", screenRows(screen)[1])
+
+	app.HandleTCellEvent(tcell.NewEventMouse(1, 0, tcell.ButtonNone, 0), gowid.IgnoreUnhandledInput)
+	assert.False(t, tip.Shown())
+	tip.tick(app)
+	assert.True(t, tip.Shown())
+	app.RedrawTerminal()
+	assert.Equal(t, "hover       ", screenRows(screen)[0])
+	assert.Equal(t, " Help!      ", screenRows(screen)[1])
+
+	// Moving within the widget leaves the tooltip up; moving off hides it
+	app.HandleTCellEvent(tcell.NewEventMouse(3, 0, tcell.ButtonNone, 0), gowid.IgnoreUnhandledInput)
+	assert.True(t, tip.Shown())
+	app.HandleTCellEvent(tcell.NewEventMouse(3, 1, tcell.ButtonNone, 0), gowid.IgnoreUnhandledInput)
+	assert.False(t, tip.Shown())
+	assert.Equal(t, "other       ", screenRows(screen)[1])
+
+	// The delay is cancelled if the mouse leaves before it's up
+	app.HandleTCellEvent(tcell.NewEventMouse(1, 0, tcell.ButtonNone, 0), gowid.IgnoreUnhandledInput)
+	app.HandleTCellEvent(tcell.NewEventMouse(1, 2, tcell.ButtonNone, 0), gowid.IgnoreUnhandledInput)
+	tip.tick(app)
+	assert.False(t, tip.Shown())
+}
+
+func TestFocus1(t *testing.T) {
+	tip := New(button.NewBare(text.New("focus")), "A longer help text", Options{Delay: time.Hour, NoHover: true})
+	// The tooltip doesn't fit below the widget, so it goes above
+	app, screen := newTestApp(t, pile.NewFlow(text.New("a"), text.New("b"), tip))
+	assert.True(t, tip.focused)
+	tip.tick(app)
+	assert.True(t, tip.Shown())
+	app.RedrawTerminal()
+	assert.Equal(t, []string{
+		" A longer h ",
+		" elp text   ",
+		"focus       ",
+		"            ",
+	}, screenRows(screen))
+
+	app.HandleTCellEvent(tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), gowid.IgnoreUnhandledInput)
+	assert.False(t, tip.Shown())
+	assert.Equal(t, "a           ", screenRows(screen)[0])
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
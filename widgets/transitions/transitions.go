@@ -0,0 +1,326 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package transitions provides a holder-like widget that can animate the change
+// from one inner widget to another, e.g. by sliding the new widget in from the side.
+package transitions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+)
+
+//======================================================================
+
+// Effect determines how the new widget replaces the old one during a transition.
+type Effect int
+
+const (
+	// None swaps the widgets immediately.
+	None Effect = iota
+	// SlideLeft moves the new widget in from the right, covering the old one.
+	SlideLeft
+	// SlideRight moves the new widget in from the left, covering the old one.
+	SlideRight
+	// PushLeft moves the new widget in from the right, pushing the old one out to the left.
+	PushLeft
+	// PushRight moves the new widget in from the left, pushing the old one out to the right.
+	PushRight
+	// Fade dims the old widget, then shows the new widget dimmed before it appears
+	// normally.
+	Fade
+)
+
+func (e Effect) String() string {
+	switch e {
+	case None:
+		return "none"
+	case SlideLeft:
+		return "slide-left"
+	case SlideRight:
+		return "slide-right"
+	case PushLeft:
+		return "push-left"
+	case PushRight:
+		return "push-right"
+	case Fade:
+		return "fade"
+	default:
+		return fmt.Sprintf("effect(%d)", int(e))
+	}
+}
+
+// Options can be supplied to New. Effect is used by Transition, and Duration is the
+// time each transition takes - by default, 250ms. The widget is redrawn every
+// FrameInterval during a transition - by default, 25ms. FadeStyle is applied to
+// both widgets during a Fade, and defaults to dim text.
+type Options struct {
+	Effect        Effect
+	Duration      time.Duration
+	FrameInterval time.Duration
+	FadeStyle     gowid.ICellStyler
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	Transition(w gowid.IWidget, app gowid.IApp)
+	Transitioning() bool
+}
+
+// TransitionDoneCB is the name under which callbacks are registered that run when
+// a transition finishes.
+type TransitionDoneCB struct{}
+
+// Widget holds an inner widget, like holder.Widget, but can animate replacing it.
+// During a transition, user input goes to the new widget.
+type Widget struct {
+	inner  gowid.IWidget
+	old    gowid.IWidget // The widget being replaced, or nil if there's no transition
+	effect Effect
+	frame  int
+	frames int
+	gen    int // Incremented by each transition so stale timers are ignored
+	timer  *time.Timer
+	opts   Options
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+}
+
+var _ gowid.IWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Duration <= 0 {
+		opt.Duration = 250 * time.Millisecond
+	}
+	if opt.FrameInterval <= 0 {
+		opt.FrameInterval = 25 * time.Millisecond
+	}
+	if opt.FadeStyle == nil {
+		opt.FadeStyle = gowid.MakeStyledAs(gowid.StyleDim)
+	}
+	res := &Widget{
+		inner:     inner,
+		opts:      opt,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	if w.old != nil {
+		return fmt.Sprintf("transitions[%v->%v]", w.old, w.inner)
+	}
+	return fmt.Sprintf("transitions[%v]", w.inner)
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.inner
+}
+
+// SetSubWidget replaces the inner widget immediately, ending any transition in
+// progress.
+func (w *Widget) SetSubWidget(wi gowid.IWidget, app gowid.IApp) {
+	w.TransitionWith(wi, None, app)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+func (w *Widget) Opts() Options {
+	return w.opts
+}
+
+func (w *Widget) Selectable() bool {
+	return w.inner.Selectable()
+}
+
+// Transition replaces the inner widget, animating the change with the effect
+// supplied in the widget's options.
+func (w *Widget) Transition(wi gowid.IWidget, app gowid.IApp) {
+	w.TransitionWith(wi, w.opts.Effect, app)
+}
+
+// TransitionWith replaces the inner widget, animating the change with the effect
+// supplied. A transition already in progress is finished first.
+func (w *Widget) TransitionWith(wi gowid.IWidget, effect Effect, app gowid.IApp) {
+	w.Finish(app)
+	old := w.inner
+	w.inner = wi
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.SubWidgetCB{}, app, w)
+	frames := int(w.opts.Duration / w.opts.FrameInterval)
+	if effect == None || frames < 1 || old == nil {
+		gowid.RunWidgetCallbacks(w.Callbacks, TransitionDoneCB{}, app, w)
+		return
+	}
+	w.old = old
+	w.effect = effect
+	w.frame = 0
+	w.frames = frames
+	w.gen++
+	w.schedule(app)
+}
+
+// Transitioning returns true while a transition is in progress.
+func (w *Widget) Transitioning() bool {
+	return w.old != nil
+}
+
+// Progress returns how far through the current transition the widget is, from 0 to 1.
+// It returns 1 if there is no transition.
+func (w *Widget) Progress() float64 {
+	if w.old == nil {
+		return 1
+	}
+	return float64(w.frame) / float64(w.frames)
+}
+
+// Finish ends any transition in progress immediately, running the completion callbacks.
+func (w *Widget) Finish(app gowid.IApp) {
+	if w.old == nil {
+		return
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.old = nil
+	w.gen++
+	gowid.RunWidgetCallbacks(w.Callbacks, TransitionDoneCB{}, app, w)
+}
+
+func (w *Widget) OnTransitionDone(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, TransitionDoneCB{}, f)
+}
+
+func (w *Widget) RemoveOnTransitionDone(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, TransitionDoneCB{}, f)
+}
+
+func (w *Widget) schedule(app gowid.IApp) {
+	gen := w.gen
+	w.timer = time.AfterFunc(w.opts.FrameInterval, func() {
+		app.Run(gowid.RunFunction(func(app gowid.IApp) {
+			if gen == w.gen {
+				w.tick(app)
+			}
+		}))
+	})
+}
+
+// tick advances the transition by one frame.
+func (w *Widget) tick(app gowid.IApp) {
+	if w.old == nil {
+		return
+	}
+	w.frame++
+	if w.frame >= w.frames {
+		w.Finish(app)
+	} else {
+		w.schedule(app)
+	}
+}
+
+//''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''''
+
+func (w *Widget) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return gowid.RenderSize(w.inner, size, focus, app)
+}
+
+func (w *Widget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return Render(w, size, focus, app)
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return gowid.UserInputIfSelectable(w.inner, ev, size, focus, app)
+}
+
+//======================================================================
+
+// styleCanvas applies the styler's colors and style on top of each cell of the canvas.
+func styleCanvas(c gowid.ICanvas, styler gowid.ICellStyler, app gowid.IApp) {
+	f, b, s := styler.GetStyle(app)
+	mod := gowid.MakeCell(0,
+		gowid.ColorToTCellIn(f, gowid.ColorNone, app),
+		gowid.ColorToTCellIn(b, gowid.ColorNone, app),
+		s)
+	gowid.RangeOverCanvas(c, gowid.CellRangeFunc(func(cell gowid.Cell) gowid.Cell {
+		return cell.MergeDisplayAttrsUnder(mod)
+	}))
+}
+
+// blit copies n columns of src, starting at column srcX, to dst starting at column
+// dstX. Cells outside either canvas are skipped.
+func blit(dst gowid.ICanvas, src gowid.ICanvas, srcX, dstX, n int) {
+	if srcX < 0 {
+		dstX, n, srcX = dstX-srcX, n+srcX, 0
+	}
+	n = gwutil.Min(n, src.BoxColumns()-srcX)
+	if n <= 0 {
+		return
+	}
+	rows := gwutil.Min(dst.BoxRows(), src.BoxRows())
+	for y := 0; y < rows; y++ {
+		dst.SetLineRange(dstX, y, src.Line(y, gowid.LineCopy{}).Line[srcX:srcX+n])
+	}
+}
+
+func Render(w *Widget, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	if w.old == nil {
+		return gowid.RenderChild(w.inner, size, focus, app)
+	}
+
+	p := w.Progress()
+	if w.effect == Fade {
+		// Only one widget is seen at a time - the old one for the first half of the fade
+		var res gowid.ICanvas
+		if p < 0.5 {
+			res = gowid.RenderChild(w.old, size, gowid.NotSelected, app)
+		} else {
+			res = gowid.RenderChild(w.inner, size, focus, app)
+		}
+		styleCanvas(res, w.opts.FadeStyle, app)
+		return res
+	}
+
+	res := gowid.RenderChild(w.inner, size, focus, app)
+	cols, rows := res.BoxColumns(), res.BoxRows()
+	old := gowid.RenderChild(w.old, size, gowid.NotSelected, app)
+	gowid.MakeCanvasRightSize(old, gowid.RenderBox{C: cols, R: rows})
+
+	k := int(p*float64(cols) + 0.5)
+	canvas := gowid.NewCanvasOfSize(cols, rows)
+	switch w.effect {
+	case SlideLeft:
+		blit(canvas, old, 0, 0, cols-k)
+		blit(canvas, res, 0, cols-k, k)
+	case SlideRight:
+		blit(canvas, res, cols-k, 0, k)
+		blit(canvas, old, k, k, cols-k)
+	case PushLeft:
+		blit(canvas, old, k, 0, cols-k)
+		blit(canvas, res, 0, cols-k, k)
+	case PushRight:
+		blit(canvas, res, cols-k, 0, k)
+		blit(canvas, old, 0, k, cols-k)
+	default:
+		return res
+	}
+	return canvas
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,128 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package transitions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/widgets/fill"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// Use a long frame interval so the timer doesn't fire - the test steps through the
+// frames instead.
+func testOpts(e Effect) Options {
+	return Options{
+		Effect:        e,
+		Duration:      4 * time.Hour,
+		FrameInterval: time.Hour,
+	}
+}
+
+func TestTransitions1(t *testing.T) {
+	sz := gowid.RenderBox{C: 4, R: 1}
+	for _, tc := range []struct {
+		effect Effect
+		frames []string
+	}{
+		{SlideLeft, []string{"xxxx", "xxxy", "xxyy", "xyyy", "yyyy"}},
+		{SlideRight, []string{"xxxx", "yxxx", "yyxx", "yyyx", "yyyy"}},
+		{PushLeft, []string{"xxxx", "xxxy", "xxyy", "xyyy", "yyyy"}},
+		{PushRight, []string{"xxxx", "yxxx", "yyxx", "yyyx", "yyyy"}},
+	} {
+		w := New(fill.New('x'), testOpts(tc.effect))
+		done := 0
+		w.OnTransitionDone(gowid.WidgetCallback{"cb", func(app gowid.IApp, w gowid.IWidget) {
+			done++
+		}})
+		w.Transition(fill.New('y'), gwtest.D)
+		assert.True(t, w.Transitioning())
+		for i, f := range tc.frames {
+			c := w.Render(sz, gowid.Focused, gwtest.D)
+			assert.Equal(t, f, c.String(), "effect %v frame %d", tc.effect, i)
+			if i < len(tc.frames)-1 {
+				assert.Equal(t, 0, done)
+				w.tick(gwtest.D)
+			}
+		}
+		assert.False(t, w.Transitioning())
+		assert.Equal(t, 1, done)
+	}
+}
+
+func TestTransitions2(t *testing.T) {
+	sz := gowid.RenderBox{C: 3, R: 1}
+	w := New(fill.New('x'), testOpts(PushLeft))
+
+	// Make the widgets distinguishable so the push is visible
+	w.SetSubWidget(text.New("abc"), gwtest.D)
+	assert.False(t, w.Transitioning())
+	w.Transition(text.New("def"), gwtest.D)
+	w.tick(gwtest.D)
+	assert.Equal(t, "bcd", w.Render(sz, gowid.Focused, gwtest.D).String())
+	w.Finish(gwtest.D)
+	assert.Equal(t, "def", w.Render(sz, gowid.Focused, gwtest.D).String())
+
+	w = New(fill.New('x'), testOpts(Fade))
+	w.Transition(fill.New('y'), gwtest.D)
+	c := w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "xxx", c.String())
+	assert.Equal(t, tcell.AttrDim, c.CellAt(0, 0).Style().OnOff&tcell.AttrDim)
+	w.tick(gwtest.D)
+	w.tick(gwtest.D)
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, "yyy", c.String())
+	assert.Equal(t, tcell.AttrDim, c.CellAt(0, 0).Style().OnOff&tcell.AttrDim)
+	w.tick(gwtest.D)
+	w.tick(gwtest.D)
+	c = w.Render(sz, gowid.Focused, gwtest.D)
+	assert.Equal(t, tcell.AttrMask(0), c.CellAt(0, 0).Style().OnOff&tcell.AttrDim)
+}
+
+// countingWidget counts its renders.
+type countingWidget struct {
+	*fill.Widget
+	renders int
+}
+
+func (w *countingWidget) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	w.renders++
+	return w.Widget.Render(size, focus, app)
+}
+
+func TestFadeRenders1(t *testing.T) {
+	sz := gowid.RenderBox{C: 3, R: 1}
+	old, new := &countingWidget{Widget: fill.New('x')}, &countingWidget{Widget: fill.New('y')}
+	w := New(old, testOpts(Fade))
+	w.Transition(new, gwtest.D)
+
+	// Before halfway, only the old widget is rendered
+	for i := 0; i < 2; i++ {
+		assert.Less(t, w.Progress(), 0.5)
+		assert.Equal(t, "xxx", w.Render(sz, gowid.Focused, gwtest.D).String())
+		w.tick(gwtest.D)
+	}
+	assert.Equal(t, 2, old.renders)
+	assert.Equal(t, 0, new.renders)
+
+	// From halfway, only the new one
+	for i := 0; i < 2; i++ {
+		assert.GreaterOrEqual(t, w.Progress(), 0.5)
+		assert.Equal(t, "yyy", w.Render(sz, gowid.Focused, gwtest.D).String())
+		w.tick(gwtest.D)
+	}
+	assert.Equal(t, 2, old.renders)
+	assert.Equal(t, 2, new.renders)
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
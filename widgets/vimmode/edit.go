@@ -0,0 +1,303 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+package vimmode
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwutil"
+	"github.com/gcla/gowid/vim"
+	"github.com/gcla/gowid/widgets/edit"
+)
+
+//======================================================================
+
+// EditHandler executes vim commands against an edit widget. Positions are rune
+// indices into the edit's text. Text that is deleted or yanked is stored in the
+// modal widget's register; whole lines are stored with a trailing newline so that
+// p and P put them on their own line.
+type EditHandler struct {
+	*edit.Widget
+}
+
+var _ ICommandHandler = (*EditHandler)(nil)
+
+func (h *EditHandler) HandleCommand(cmd vim.Command, m IModal, app gowid.IApp) bool {
+	text := []rune(h.Text())
+	pos := 0
+	if h.CursorEnabled() {
+		pos = gwutil.Min(h.CursorPos(), len(text))
+	}
+
+	// In visual mode, an operator applies to the selection
+	if m.Mode() == vim.VisualMode && cmd.Operator != "" {
+		start, end := m.Anchor(), pos
+		if start > end {
+			start, end = end, start
+		}
+		start = gwutil.Min(start, len(text))
+		end = gwutil.Min(end+1, len(text))
+		m.SetMode(vim.NormalMode, app)
+		return h.operate(cmd.Operator, text, start, end, false, m, app)
+	}
+
+	if cmd.Operator != "" {
+		if cmd.Linewise() || cmd.Motion == "j" || cmd.Motion == "k" {
+			start, end := linesRange(text, pos, cmd)
+			return h.operate(cmd.Operator, text, start, end, true, m, app)
+		}
+		name := cmd.Motion
+		if cmd.Operator == "c" && name == "w" && pos < len(text) && class(text[pos]) != 0 {
+			// As in vim, cw changes to the end of the word and leaves the space
+			name = "e"
+		}
+		target, ok := motion(text, pos, name, cmd.Times())
+		if !ok {
+			return false
+		}
+		start, end := pos, target
+		if start > end {
+			start, end = end, start
+		}
+		if name == "e" {
+			end = gwutil.Min(end+1, len(text))
+		}
+		return h.operate(cmd.Operator, text, start, end, false, m, app)
+	}
+
+	switch cmd.Motion {
+	case "i":
+		m.SetMode(vim.InsertMode, app)
+	case "a":
+		h.SetCursorPos(gwutil.Min(pos+1, lineEnd(text, pos)), app)
+		m.SetMode(vim.InsertMode, app)
+	case "I":
+		h.SetCursorPos(lineStart(text, pos), app)
+		m.SetMode(vim.InsertMode, app)
+	case "A":
+		h.SetCursorPos(lineEnd(text, pos), app)
+		m.SetMode(vim.InsertMode, app)
+	case "x":
+		end := gwutil.Min(pos+cmd.Times(), lineEnd(text, pos))
+		return h.operate("d", text, pos, end, false, m, app)
+	case "X":
+		start := gwutil.Max(pos-cmd.Times(), lineStart(text, pos))
+		return h.operate("d", text, start, pos, false, m, app)
+	case "D":
+		return h.operate("d", text, pos, lineEnd(text, pos), false, m, app)
+	case "C":
+		return h.operate("c", text, pos, lineEnd(text, pos), false, m, app)
+	case "p", "P":
+		return h.put(text, pos, cmd.Motion == "p", cmd.Times(), m, app)
+	case "v":
+		m.SetAnchor(pos)
+		m.SetMode(vim.VisualMode, app)
+	default:
+		target, ok := motion(text, pos, cmd.Motion, cmd.Times())
+		if !ok {
+			return false
+		}
+		// Outside insert mode, the cursor rests on a character, not after the line
+		if target == lineEnd(text, target) && target > lineStart(text, target) {
+			target--
+		}
+		h.SetCursorPos(target, app)
+	}
+	return true
+}
+
+// operate applies a d, c or y operator to text[start:end].
+func (h *EditHandler) operate(op string, text []rune, start, end int, linewise bool, m IModal, app gowid.IApp) bool {
+	reg := string(text[start:end])
+	if linewise {
+		// The range may have swallowed the newline before the last line rather than the
+		// one after it
+		reg = strings.TrimPrefix(reg, "\n")
+		if !strings.HasSuffix(reg, "\n") {
+			reg += "\n"
+		}
+	}
+	m.SetRegister(reg)
+	if op == "y" || h.IsReadOnly() {
+		h.SetCursorPos(start, app)
+		return true
+	}
+	if op == "c" && linewise {
+		// Keep an empty line to type into
+		if end > start && text[end-1] == '\n' {
+			end--
+		} else if start < end && text[start] == '\n' {
+			start++
+		}
+	}
+	h.SetText(string(text[:start])+string(text[end:]), app)
+	h.SetCursorPos(start, app)
+	if op == "c" {
+		m.SetMode(vim.InsertMode, app)
+	}
+	return true
+}
+
+func (h *EditHandler) put(text []rune, pos int, after bool, times int, m IModal, app gowid.IApp) bool {
+	reg := m.Register()
+	if reg == "" || h.IsReadOnly() {
+		return true
+	}
+	ins := strings.Repeat(reg, times)
+	var at int
+	switch {
+	case strings.HasSuffix(reg, "\n") && after:
+		at = lineEnd(text, pos)
+		if at == len(text) {
+			ins = "\n" + strings.TrimSuffix(ins, "\n")
+		} else {
+			at++
+		}
+	case strings.HasSuffix(reg, "\n"):
+		at = lineStart(text, pos)
+	case after:
+		at = gwutil.Min(pos+1, len(text))
+	default:
+		at = pos
+	}
+	h.SetText(string(text[:at])+ins+string(text[at:]), app)
+	if strings.HasPrefix(ins, "\n") {
+		at++
+	}
+	h.SetCursorPos(at, app)
+	return true
+}
+
+//======================================================================
+
+func lineStart(text []rune, pos int) int {
+	for pos > 0 && text[pos-1] != '\n' {
+		pos--
+	}
+	return pos
+}
+
+func lineEnd(text []rune, pos int) int {
+	for pos < len(text) && text[pos] != '\n' {
+		pos++
+	}
+	return pos
+}
+
+// linesRange returns the range covering the lines an operator like dd or dj
+// applies to, including one newline.
+func linesRange(text []rune, pos int, cmd vim.Command) (int, int) {
+	n := cmd.Times()
+	start := lineStart(text, pos)
+	switch cmd.Motion {
+	case "j":
+		n = n + 1
+	case "k":
+		for i := 0; i < n && start > 0; i++ {
+			start = lineStart(text, start-1)
+		}
+		n = n + 1
+	}
+	end := start
+	for i := 0; i < n; i++ {
+		end = lineEnd(text, end)
+		if end == len(text) {
+			break
+		}
+		end++
+	}
+	if end == len(text) && start > 0 && (end == 0 || text[end-1] != '\n') {
+		start--
+	}
+	return start, end
+}
+
+func class(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// motion returns the position reached by moving n times from pos.
+func motion(text []rune, pos int, name string, n int) (int, bool) {
+	switch name {
+	case "0":
+		return lineStart(text, pos), true
+	case "$":
+		return lineEnd(text, pos), true
+	case "gg":
+		return 0, true
+	case "G":
+		return len(text), true
+	}
+	for i := 0; i < n; i++ {
+		switch name {
+		case "h", "<Left>":
+			pos = gwutil.Max(pos-1, lineStart(text, pos))
+		case "l", "<Right>":
+			pos = gwutil.Min(pos+1, lineEnd(text, pos))
+		case "j", "<Down>":
+			end := lineEnd(text, pos)
+			if end < len(text) {
+				col := pos - lineStart(text, pos)
+				pos = gwutil.Min(end+1+col, lineEnd(text, end+1))
+			}
+		case "k", "<Up>":
+			start := lineStart(text, pos)
+			if start > 0 {
+				prev := lineStart(text, start-1)
+				pos = gwutil.Min(prev+pos-start, start-1)
+			}
+		case "w":
+			if pos < len(text) {
+				c := class(text[pos])
+				for pos < len(text) && c != 0 && class(text[pos]) == c {
+					pos++
+				}
+				for pos < len(text) && class(text[pos]) == 0 {
+					pos++
+				}
+			}
+		case "b":
+			if pos > 0 {
+				pos--
+				for pos > 0 && class(text[pos]) == 0 {
+					pos--
+				}
+				c := class(text[pos])
+				for pos > 0 && class(text[pos-1]) == c {
+					pos--
+				}
+			}
+		case "e":
+			if pos+1 < len(text) {
+				pos++
+				for pos+1 < len(text) && class(text[pos]) == 0 {
+					pos++
+				}
+				c := class(text[pos])
+				for pos+1 < len(text) && class(text[pos+1]) == c {
+					pos++
+				}
+			}
+		default:
+			return pos, false
+		}
+	}
+	return pos, true
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,258 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source
+// code is governed by the MIT license that can be found in the LICENSE
+// file.
+
+// Package vimmode provides a widget that adds vim-style modal input to any widget
+// tree. In normal mode, keystrokes are composed into commands like "3j" or "d2w",
+// which are executed by the focused widget if it knows how - edit widgets are
+// supported - or otherwise translated into the equivalent cursor keys and sent to
+// the tree. In insert mode, keys go straight to the tree until escape is pressed.
+// Keys that don't start a command are passed through in every mode.
+package vimmode
+
+import (
+	"fmt"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/vim"
+	"github.com/gcla/gowid/widgets/edit"
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+//======================================================================
+
+// IModal is the state shared by the command handlers in a modal tree.
+type IModal interface {
+	Mode() vim.Mode
+	SetMode(mode vim.Mode, app gowid.IApp)
+	Register() string
+	SetRegister(text string)
+	Anchor() int
+	SetAnchor(pos int)
+}
+
+// ICommandHandler is implemented by widgets that can execute vim commands
+// themselves. HandleCommand should return false if the command isn't supported, in
+// which case the default translation to cursor keys is tried.
+type ICommandHandler interface {
+	HandleCommand(cmd vim.Command, m IModal, app gowid.IApp) bool
+}
+
+type IWidget interface {
+	gowid.ICompositeWidget
+	IModal
+}
+
+// Options can be supplied to New. If Parser is nil, a parser with the default
+// motions, operators and commands is used.
+type Options struct {
+	Parser    *vim.Parser
+	StartMode vim.Mode
+}
+
+type ModeCB struct{}
+
+// Widget holds the mode, the partial command typed so far, a register for text
+// that is deleted or yanked, and an anchor marking where visual mode began.
+type Widget struct {
+	gowid.IWidget
+	mode     vim.Mode
+	parser   *vim.Parser
+	register string
+	anchor   int
+	*gowid.Callbacks
+	gowid.SubWidgetCallbacks
+}
+
+var _ gowid.ICompositeWidget = (*Widget)(nil)
+var _ IWidget = (*Widget)(nil)
+
+func New(inner gowid.IWidget, opts ...Options) *Widget {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Parser == nil {
+		opt.Parser = vim.NewParser()
+	}
+	res := &Widget{
+		IWidget:   inner,
+		mode:      opt.StartMode,
+		parser:    opt.Parser,
+		Callbacks: gowid.NewCallbacks(),
+	}
+	res.SubWidgetCallbacks = gowid.SubWidgetCallbacks{CB: &res.Callbacks}
+	return res
+}
+
+func (w *Widget) String() string {
+	return fmt.Sprintf("vimmode[%v]", w.SubWidget())
+}
+
+func (w *Widget) SubWidget() gowid.IWidget {
+	return w.IWidget
+}
+
+func (w *Widget) SetSubWidget(inner gowid.IWidget, app gowid.IApp) {
+	w.IWidget = inner
+	gowid.RunWidgetCallbacks(w.Callbacks, gowid.SubWidgetCB{}, app, w)
+}
+
+func (w *Widget) SubWidgetSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderSize {
+	return size
+}
+
+func (w *Widget) Mode() vim.Mode {
+	return w.mode
+}
+
+// SetMode changes mode, discarding any partial command, and runs the ModeCB
+// callbacks - e.g. so an app can display "-- INSERT --".
+func (w *Widget) SetMode(mode vim.Mode, app gowid.IApp) {
+	w.parser.Reset()
+	if mode != w.mode {
+		w.mode = mode
+		gowid.RunWidgetCallbacks(w.Callbacks, ModeCB{}, app, w)
+	}
+}
+
+func (w *Widget) OnModeChange(f gowid.IWidgetChangedCallback) {
+	gowid.AddWidgetCallback(w.Callbacks, ModeCB{}, f)
+}
+
+func (w *Widget) RemoveOnModeChange(f gowid.IIdentity) {
+	gowid.RemoveWidgetCallback(w.Callbacks, ModeCB{}, f)
+}
+
+// Pending returns the keys typed towards the current command, e.g. "2d".
+func (w *Widget) Pending() string {
+	return w.parser.Pending()
+}
+
+func (w *Widget) Register() string {
+	return w.register
+}
+
+func (w *Widget) SetRegister(text string) {
+	w.register = text
+}
+
+func (w *Widget) Anchor() int {
+	return w.anchor
+}
+
+func (w *Widget) SetAnchor(pos int) {
+	w.anchor = pos
+}
+
+func (w *Widget) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	return UserInput(w, ev, size, focus, app)
+}
+
+//======================================================================
+
+// motionKeys are the cursor keys sent to widgets that can't handle a motion
+// themselves.
+var motionKeys = map[string]tcell.Key{
+	"h":       tcell.KeyLeft,
+	"j":       tcell.KeyDown,
+	"k":       tcell.KeyUp,
+	"l":       tcell.KeyRight,
+	"gg":      tcell.KeyHome,
+	"G":       tcell.KeyEnd,
+	"<C-f>":   tcell.KeyPgDn,
+	"<C-b>":   tcell.KeyPgUp,
+	"<Left>":  tcell.KeyLeft,
+	"<Down>":  tcell.KeyDown,
+	"<Up>":    tcell.KeyUp,
+	"<Right>": tcell.KeyRight,
+}
+
+func UserInput(w *Widget, ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	evk, ok := ev.(*tcell.EventKey)
+	if !ok {
+		return gowid.UserInputIfSelectable(w.SubWidget(), ev, size, focus, app)
+	}
+
+	if w.Mode() == vim.InsertMode {
+		if evk.Key() == tcell.KeyEscape {
+			w.SetMode(vim.NormalMode, app)
+			return true
+		}
+		return gowid.UserInputIfSelectable(w.SubWidget(), ev, size, focus, app)
+	}
+
+	w.parser.Visual = w.Mode() == vim.VisualMode
+	cmd, res := w.parser.Feed(vim.KeyPressFromTcell(evk))
+	switch res {
+	case vim.ParseNotMatched:
+		if evk.Key() == tcell.KeyEscape && w.Mode() == vim.VisualMode {
+			w.SetMode(vim.NormalMode, app)
+			return true
+		}
+		return gowid.UserInputIfSelectable(w.SubWidget(), ev, size, focus, app)
+	case vim.ParseComplete:
+		Execute(w, cmd, size, focus, app)
+	}
+	return true
+}
+
+// Execute runs the command with the deepest focused command handler, if any, or
+// otherwise translates a plain motion into cursor keys.
+func Execute(w *Widget, cmd vim.Command, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if h := FindHandler(w.SubWidget()); h != nil && h.HandleCommand(cmd, w, app) {
+		return true
+	}
+	key, ok := motionKeys[cmd.Motion]
+	if !ok || cmd.Operator != "" {
+		return false
+	}
+	res := false
+	for i := 0; i < cmd.Times(); i++ {
+		if gowid.UserInputIfSelectable(w.SubWidget(), tcell.NewEventKey(key, 0, tcell.ModNone), size, focus, app) {
+			res = true
+		}
+	}
+	return res
+}
+
+// FindHandler follows the focus down from w and returns the deepest widget that can
+// execute commands. Edit widgets are wrapped in an EditHandler.
+func FindHandler(w gowid.IWidget) ICommandHandler {
+	var res ICommandHandler
+	for w != nil {
+		if h := asHandler(w); h != nil {
+			res = h
+		}
+		switch ww := w.(type) {
+		case gowid.ICompositeMultipleFocus:
+			subs := ww.SubWidgets()
+			idx := ww.Focus()
+			if idx < 0 || idx >= len(subs) {
+				return res
+			}
+			w = subs[idx]
+		case gowid.IComposite:
+			w = ww.SubWidget()
+		default:
+			w = nil
+		}
+	}
+	return res
+}
+
+func asHandler(w gowid.IWidget) ICommandHandler {
+	switch ww := w.(type) {
+	case ICommandHandler:
+		return ww
+	case *edit.Widget:
+		return &EditHandler{Widget: ww}
+	}
+	return nil
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End:
@@ -0,0 +1,106 @@
+// Copyright 2019-2022 Graham Clark. All rights reserved.  Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package vimmode
+
+import (
+	"testing"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/gwtest"
+	"github.com/gcla/gowid/vim"
+	"github.com/gcla/gowid/widgets/button"
+	"github.com/gcla/gowid/widgets/edit"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/text"
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+//======================================================================
+
+func typeKeys(w *Widget, keys string) {
+	for _, k := range vim.VimStringToKeys(keys) {
+		kk := gowid.Key(k)
+		ev := tcell.NewEventKey(kk.Key(), kk.Rune(), kk.Modifiers())
+		w.UserInput(ev, gowid.RenderFlowWith{C: 20}, gowid.Focused, gwtest.D)
+	}
+}
+
+func TestEdit1(t *testing.T) {
+	e := edit.New(edit.Options{Text: "one two three four"})
+	e.SetCursorPos(0, gwtest.D)
+	w := New(e)
+
+	modes := 0
+	w.OnModeChange(gowid.WidgetCallback{"test", func(app gowid.IApp, w gowid.IWidget) {
+		modes++
+	}})
+
+	typeKeys(w, "w")
+	assert.Equal(t, 4, e.CursorPos())
+
+	typeKeys(w, "d2w")
+	assert.Equal(t, "one four", e.Text())
+	assert.Equal(t, "two three ", w.Register())
+
+	typeKeys(w, "$P")
+	assert.Equal(t, "one fou"+"two three "+"r", e.Text())
+
+	typeKeys(w, "0cwzero<Esc>")
+	assert.Equal(t, vim.NormalMode, w.Mode())
+	assert.Equal(t, 2, modes)
+	assert.Equal(t, "zero fout", e.Text()[0:9])
+
+	typeKeys(w, "0vey")
+	assert.Equal(t, "zero", w.Register())
+	assert.Equal(t, vim.NormalMode, w.Mode())
+
+	typeKeys(w, "2x")
+	assert.Equal(t, "ro", e.Text()[0:2])
+
+	typeKeys(w, "2d")
+	assert.Equal(t, "2d", w.Pending())
+}
+
+func TestEditLines1(t *testing.T) {
+	e := edit.New(edit.Options{Text: "aaa\nbbb\nccc"})
+	e.SetCursorPos(1, gwtest.D)
+	w := New(e)
+
+	typeKeys(w, "j")
+	assert.Equal(t, 5, e.CursorPos())
+
+	typeKeys(w, "dd")
+	assert.Equal(t, "aaa\nccc", e.Text())
+	assert.Equal(t, "bbb\n", w.Register())
+
+	typeKeys(w, "p")
+	assert.Equal(t, "aaa\nccc\nbbb", e.Text())
+
+	typeKeys(w, "gg2dd")
+	assert.Equal(t, "bbb", e.Text())
+}
+
+func TestPassThrough1(t *testing.T) {
+	p := pile.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{IWidget: button.New(text.New("1")), D: gowid.RenderFlow{}},
+		&gowid.ContainerWidget{IWidget: button.New(text.New("2")), D: gowid.RenderFlow{}},
+		&gowid.ContainerWidget{IWidget: button.New(text.New("3")), D: gowid.RenderFlow{}},
+		&gowid.ContainerWidget{IWidget: button.New(text.New("4")), D: gowid.RenderFlow{}},
+	})
+	w := New(p)
+
+	typeKeys(w, "2j")
+	assert.Equal(t, 2, p.Focus())
+	typeKeys(w, "k")
+	assert.Equal(t, 1, p.Focus())
+	typeKeys(w, "9j")
+	assert.Equal(t, 3, p.Focus())
+}
+
+//======================================================================
+// Local Variables:
+// mode: Go
+// fill-column: 110
+// End: